@@ -0,0 +1,81 @@
+//go:build js && wasm
+
+// Command wheresmyprompt-wasm exposes the wheresmyprompt Markdown parser and fuzzy
+// search engine to JavaScript, so browser extensions can search a prompts.md with
+// exactly the same matching behavior as the CLI.
+//
+// Build it with `make wasm` and load it with Go's wasm_exec.js:
+//
+//	const go = new Go();
+//	const { instance } = await WebAssembly.instantiateStreaming(fetch("wheresmyprompt.wasm"), go.importObject);
+//	go.run(instance);
+//	const results = wheresmyprompt.search(markdown, "unit test", "Golang");
+//
+// Both wheresmyprompt.search and wheresmyprompt.searchTitles take the Markdown
+// content, a query, an optional section and optional options such as
+// {stem: true, stopWords: ["the"]} (see search.SearchOptions; stop words default
+// to search.DefaultStopWords like in the CLI), and return an array of
+// {content, section, title} objects best match first, or an Error if the
+// Markdown cannot be parsed.
+package main
+
+import (
+	"strings"
+	"syscall/js"
+
+	"github.com/toozej/wheresmyprompt/internal/search"
+)
+
+func main() {
+	js.Global().Set("wheresmyprompt", js.ValueOf(map[string]any{
+		"search":       js.FuncOf(searchFunc(search.Records)),
+		"searchTitles": js.FuncOf(searchFunc(search.Titles)),
+	}))
+
+	// Keep the Go runtime alive so the exported functions stay callable
+	select {}
+}
+
+// searchFunc adapts a search function to a JavaScript function taking (markdown, query[, section[, options]]).
+func searchFunc(fn func(data *search.PromptData, query, section string) []search.Prompt) func(js.Value, []js.Value) any {
+	return func(_ js.Value, args []js.Value) any {
+		if len(args) < 2 {
+			return jsError("expected arguments (markdown, query[, section[, options]])")
+		}
+		section := ""
+		if len(args) > 2 && args[2].Type() == js.TypeString {
+			section = args[2].String()
+		}
+		opts := search.SearchOptions{StopWords: search.DefaultStopWords}
+		if len(args) > 3 && args[3].Type() == js.TypeObject {
+			opts.Stem = args[3].Get("stem").Truthy()
+			if stopWords := args[3].Get("stopWords"); stopWords.Type() == js.TypeObject {
+				opts.StopWords = make([]string, stopWords.Length())
+				for i := range opts.StopWords {
+					opts.StopWords[i] = stopWords.Index(i).String()
+				}
+			}
+		}
+
+		sections, err := search.ParseMarkdown(strings.NewReader(args[0].String()), search.DefaultMaxLineSize)
+		if err != nil {
+			return jsError("failed to parse markdown: " + err.Error())
+		}
+
+		results := fn(&search.PromptData{Sections: sections, Options: opts}, args[1].String(), section)
+		out := make([]any, len(results))
+		for i, p := range results {
+			out[i] = map[string]any{
+				"content": p.Content,
+				"section": p.Section,
+				"title":   p.Title,
+			}
+		}
+		return out
+	}
+}
+
+// jsError returns a JavaScript Error with message.
+func jsError(message string) js.Value {
+	return js.Global().Get("Error").New(message)
+}
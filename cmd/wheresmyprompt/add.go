@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var addCmd = &cobra.Command{
+	Use:   "add [content] [section]",
+	Short: "Add a prompt to the prompt library",
+	Long: `Add a prompt to the prompt library. The title is generated from the first
+words of the content. The section may be given with --section or as the second
+argument; without content the title, content and section are read from stdin.
+With STAGING=true the prompt is added to the staging section for review.`,
+	Args: cobra.MatchAll(cobra.MaximumNArgs(2), func(cmd *cobra.Command, args []string) error {
+		return validateSectionArg(args)
+	}),
+	Run: addCmdRun,
+}
+
+func addCmdRun(cmd *cobra.Command, args []string) {
+	addPrompt(firstArg(args), args)
+}
+
+func init() {
+	addCmd.Flags().StringVar(&onConflict, "on-conflict", "", "How to handle an existing prompt title: replace, rename or abort (default: ask)")
+}
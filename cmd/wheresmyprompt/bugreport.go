@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/toozej/wheresmyprompt/internal/bugreport"
+)
+
+var (
+	// bugreportFile is where the report is written, "-" for stdout
+	bugreportFile string
+	// bugreportLogLines is how many lines of LOG_FILE the report includes
+	bugreportLogLines int
+)
+
+var bugreportCmd = &cobra.Command{
+	Use:   "bugreport",
+	Short: "Write diagnostics to attach to a bug report",
+	Long: `Collect diagnostics into a Markdown file to attach to a GitHub issue: the
+version and platform, the configuration settings in use, any configuration
+problems, the parse warnings of the libraries (see "lint") and the end of
+LOG_FILE. Secrets are redacted, the home directory is shortened to ~ and text
+settings such as paths and note names are only reported as set. Nothing is sent
+over the network; review the file before attaching it. For a useful log, set
+LOG_FILE and reproduce the problem with --debug first. The report is written to
+wheresmyprompt-bugreport-<time>.md in the current directory unless --file is
+given.`,
+	Args: cobra.NoArgs,
+	Run:  bugreportCmdRun,
+}
+
+func init() {
+	bugreportCmd.Flags().StringVarP(&bugreportFile, "file", "f", "", "Write the report to this file, or - for stdout")
+	bugreportCmd.Flags().IntVar(&bugreportLogLines, "log-lines", 200, "Lines from the end of LOG_FILE to include")
+}
+
+func bugreportCmdRun(cmd *cobra.Command, args []string) {
+	checkOutputFlag()
+	if bugreportLogLines < 0 {
+		failWithCode(ExitUsage, fmt.Errorf("invalid --log-lines %d: must not be negative", bugreportLogLines))
+	}
+	applyLoadFlag()
+
+	report := bugreport.Collect(conf, bugreportLogLines)
+	if bugreportFile == "-" {
+		writeBugreport(os.Stdout, report)
+		return
+	}
+	path := bugreportFile
+	if path == "" {
+		path = "wheresmyprompt-bugreport-" + report.Created.Format("20060102-150405") + ".md"
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600) // #nosec G304
+	if err != nil {
+		fail(fmt.Errorf("failed to create bug report: %w", err))
+	}
+	writeBugreport(f, report)
+	if err := f.Close(); err != nil {
+		fail(fmt.Errorf("failed to write bug report: %w", err))
+	}
+
+	if output == outputJSON {
+		encodePackJSON(struct {
+			File    string    `json:"file"`
+			Created time.Time `json:"created"`
+		}{path, report.Created})
+		return
+	}
+	fmt.Printf("Wrote %s; review it before attaching it to an issue\n", path)
+}
+
+// writeBugreport writes report to w as Markdown.
+func writeBugreport(w io.Writer, report bugreport.Report) {
+	if err := report.WriteMarkdown(w); err != nil {
+		fail(fmt.Errorf("failed to write bug report: %w", err))
+	}
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/toozej/wheresmyprompt/internal/prompt"
+)
+
+// errEmptyClipboard is returned by --from-clipboard when the clipboard has nothing
+// to search for.
+var errEmptyClipboard = errors.New("the clipboard has no words to search for")
+
+// readClipboardFunc allows tests to stub the clipboard read by --from-clipboard.
+var readClipboardFunc = prompt.ReadClipboard
+
+// validateQuerySource rejects a query argument given together with --from-clipboard.
+func validateQuerySource(args []string) error {
+	if fromClipboard && len(args) > 0 {
+		return errors.New("--from-clipboard searches for the clipboard's keywords; pass either a query or --from-clipboard")
+	}
+	return nil
+}
+
+// queryOrClipboard returns the query argument, or with --from-clipboard the query
+// built from the clipboard's keywords by clipboardQuery.
+func queryOrClipboard(prompts *prompt.PromptData, args []string, sectionToUse string) string {
+	if !fromClipboard {
+		return firstArg(args)
+	}
+	text, err := readClipboardFunc()
+	if err != nil {
+		fail(err)
+	}
+	query, err := clipboardQuery(prompts, text, sectionToUse)
+	if err != nil {
+		fail(err)
+	}
+	if output == outputText {
+		fmt.Fprintln(os.Stderr, "Searching for:", query)
+	}
+	return query
+}
+
+// clipboardQuery returns a query of the salient keywords of text. As every query
+// word must match, keywords are added most salient first and only kept when the
+// query still finds a prompt in sectionToUse; when no keyword finds one, the query
+// of every keyword is returned.
+func clipboardQuery(prompts *prompt.PromptData, text, sectionToUse string) (string, error) {
+	keywords := prompt.ClipboardKeywords(text)
+	if len(keywords) == 0 {
+		return "", errEmptyClipboard
+	}
+	// Semantic search ranks every prompt, and each search costs a request
+	if semanticSearch {
+		return strings.Join(keywords, " "), nil
+	}
+	var kept []string
+	for _, keyword := range keywords {
+		query := strings.Join(append(kept[:len(kept):len(kept)], keyword), " ")
+		if matches, _ := searchMatches(prompts, query, sectionToUse); len(matches) > 0 {
+			kept = append(kept, keyword)
+		}
+	}
+	if len(kept) == 0 {
+		kept = keywords
+	}
+	return strings.Join(kept, " "), nil
+}
@@ -0,0 +1,23 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/toozej/wheresmyprompt/internal/prompt"
+)
+
+// completeQuery completes a query argument with the prompt titles and section names
+// of the library, see prompt.CompletionCandidates. Only the first argument is a query.
+func completeQuery(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	applyLoadFlag()
+	return prompt.CompletionCandidates(conf, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+func init() {
+	for _, c := range []*cobra.Command{rootCmd, searchCmd, copyCmd, shareCmd, improveCmd} {
+		c.ValidArgsFunction = completeQuery
+	}
+}
@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/toozej/wheresmyprompt/internal/prompt"
+)
+
+var copyCmd = &cobra.Command{
+	Use:   "copy [query]",
+	Short: "Copy the best matching prompt to the clipboard",
+	Long: `Copy the best match for the query to the clipboard, optionally also typing
+it into the focused window with --type. The search is limited to --section, or
+to the primary language of the current directory when no section is given.
+Exits with code 1 if nothing matches.`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  copyCmdRun,
+}
+
+func copyCmdRun(cmd *cobra.Command, args []string) {
+	if err := validateQuerySource(args); err != nil {
+		failWithCode(ExitUsage, err)
+	}
+	prompts := loadPromptsForSearch()
+	sectionToUse := resolveSection(!prompt.QueryHasSection(firstArg(args)))
+	copyBestMatch(prompts, queryOrClipboard(prompts, args, sectionToUse), sectionToUse)
+}
+
+func init() {
+	copyCmd.Flags().BoolVar(&confirmCopy, "confirm", false, "Show the best match and ask before copying it (when stdin is a terminal)")
+	copyCmd.Flags().BoolVar(&firstMatch, "first", false, "Take the best match without asking when several match equally well")
+	copyCmd.Flags().BoolVar(&typePrompt, "type", false, "Also type the prompt into the focused window (xdotool, wtype or osascript)")
+}
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/toozej/wheresmyprompt/internal/prompt"
+)
+
+// dedupeYes merges every group into its proposed name without asking
+var dedupeYes bool
+
+var dedupeSectionsCmd = &cobra.Command{
+	Use:   "dedupe-sections",
+	Short: "Merge sections whose names differ only by case, whitespace or plural",
+	Long: `Find "##" sections whose names only differ by case, whitespace or a plural or
+"-ing" ending, such as "Testing", "testing" and "Tests", and merge each group
+into one section. For every group you are asked which name to keep, proposing
+the one holding the most prompts; answer n to leave the group alone. With --yes
+every group is merged into its proposed name. The merged section takes the
+place of the first of its sections and keeps the prompts in document order.`,
+	Args: cobra.NoArgs,
+	Run:  dedupeSectionsCmdRun,
+}
+
+func dedupeSectionsCmdRun(cmd *cobra.Command, args []string) {
+	if err := prompt.CheckRequiredBinaries(conf); err != nil {
+		fail(err)
+	}
+	applyLoadFlag()
+
+	groups, err := prompt.FindDuplicateSections(conf)
+	if err != nil {
+		fail(err)
+	}
+	if len(groups) == 0 {
+		fmt.Println("No duplicate sections found")
+		return
+	}
+
+	var merges []prompt.SectionGroup
+	if dedupeYes {
+		merges = groups
+	} else {
+		merges = confirmMerges(groups, os.Stdin, os.Stdout)
+	}
+	if len(merges) == 0 {
+		fmt.Println("No sections merged")
+		return
+	}
+
+	if err := prompt.MergeSections(conf, merges); err != nil {
+		fail(err)
+	}
+	for _, g := range merges {
+		fmt.Printf("Merged %s into '%s'\n", quoteNames(g.Names), g.Target)
+	}
+}
+
+// confirmMerges asks on out which name each group should be merged into, reading
+// answers from in. An empty answer accepts the proposed name, n skips the group
+// and anything else is used as the name. End of input skips the remaining groups.
+func confirmMerges(groups []prompt.SectionGroup, in io.Reader, out io.Writer) []prompt.SectionGroup {
+	var merges []prompt.SectionGroup
+	scanner := bufio.NewScanner(in)
+	for _, g := range groups {
+		fmt.Fprintln(out, "These sections look like duplicates:")
+		for i, name := range g.Names {
+			fmt.Fprintf(out, "  %s (%d prompt(s))\n", name, g.Prompts[i])
+		}
+		fmt.Fprintf(out, "Merge into '%s'? Press Enter to accept, type another name, or n to skip: ", g.Target)
+		if !scanner.Scan() {
+			fmt.Fprintln(out)
+			break
+		}
+		switch answer := strings.TrimSpace(scanner.Text()); strings.ToLower(answer) {
+		case "":
+			merges = append(merges, g)
+		case "n", "no":
+		default:
+			g.Target = answer
+			merges = append(merges, g)
+		}
+	}
+	return merges
+}
+
+// quoteNames returns names quoted and joined with commas.
+func quoteNames(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = "'" + name + "'"
+	}
+	return strings.Join(quoted, ", ")
+}
+
+func init() {
+	dedupeSectionsCmd.Flags().BoolVarP(&dedupeYes, "yes", "y", false, "Merge every group into its proposed name without asking")
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/toozej/wheresmyprompt/pkg/languaged"
+)
+
+// detectAll prints the full language breakdown instead of only the primary language
+var detectAll bool
+
+var detectCmd = &cobra.Command{
+	Use:   "detect [path]",
+	Short: "Print the primary language used to auto-select the section",
+	Long: `Print the primary language of path (the current directory by default), which
+is the section searched when --section is not given. With --all, print every
+recognized language with its line count and share, to debug why the wrong
+section is auto-selected. Honors LANGUAGES_FILE and DETECT_MAX_FILE_SIZE.`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  detectCmdRun,
+}
+
+func detectCmdRun(cmd *cobra.Command, args []string) {
+	checkOutputFlag()
+	path := "."
+	if len(args) > 0 {
+		path = args[0]
+	}
+	if _, err := os.Stat(path); err != nil {
+		failWithCode(ExitUsage, err)
+	}
+
+	breakdown, err := languaged.Analyze(path, languageDefinitions())
+	if err != nil {
+		fail(err)
+	}
+
+	if output == outputJSON {
+		if !detectAll {
+			breakdown = &languaged.Breakdown{Primary: breakdown.Primary}
+		}
+		if err := json.NewEncoder(os.Stdout).Encode(breakdown); err != nil {
+			fail(err)
+		}
+		return
+	}
+
+	fmt.Println(breakdown.Primary)
+	if !detectAll {
+		return
+	}
+	for _, l := range breakdown.Languages {
+		fmt.Printf("  %-16s %6.2f%%  %d lines\n", l.Language, l.Percent, l.Lines)
+	}
+	if breakdown.Manifest != "" {
+		fmt.Printf("  (project manifest: %s)\n", breakdown.Manifest)
+	}
+}
+
+func init() {
+	detectCmd.Flags().BoolVarP(&detectAll, "all", "a", false, "Print the full breakdown with line counts and percentages")
+}
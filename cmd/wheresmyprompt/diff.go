@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/spf13/cobra"
+
+	"github.com/toozej/wheresmyprompt/internal/prompt"
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+var (
+	diffSourceA string
+	diffSourceB string
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Compare two prompt libraries section by section",
+	Long: `Compare two prompt libraries, such as mirrored personal and team copies, and
+report the sections added to, removed from or changed in the second one. A
+source is the path of a Markdown file, "simplenote" for the configured note,
+"simplenote:<note>" for another note, or "team" for the team library. The
+first source defaults to the configured library (FILEPATH or SN_NOTE) and the
+second to the team library. Exits with status 1 if the libraries differ.`,
+	Example: `  wheresmyprompt diff --source-a prompts.md --source-b simplenote
+  wheresmyprompt diff --source-b "simplenote:Team Prompts" --output json`,
+	Args: cobra.NoArgs,
+	Run:  diffCmdRun,
+}
+
+func init() {
+	diffCmd.Flags().StringVar(&diffSourceA, "source-a", "", "First library: a file, simplenote, simplenote:<note> or team (default: the configured library)")
+	diffCmd.Flags().StringVar(&diffSourceB, "source-b", prompt.SourceTeam, "Second library: a file, simplenote, simplenote:<note> or team")
+}
+
+func diffCmdRun(cmd *cobra.Command, args []string) {
+	checkOutputFlag()
+	applyLoadFlag()
+
+	confA, err := prompt.SourceConfig(conf, diffSourceA)
+	if err != nil {
+		failWithCode(ExitUsage, err)
+	}
+	confB, err := prompt.SourceConfig(conf, diffSourceB)
+	if err != nil {
+		failWithCode(ExitUsage, err)
+	}
+	for _, c := range []config.Config{confA, confB} {
+		if err := prompt.CheckRequiredBinaries(c); err != nil {
+			fail(err)
+		}
+	}
+
+	entries, err := prompt.DiffLibraries(confA, confB)
+	if err != nil {
+		fail(err)
+	}
+
+	if output == outputJSON {
+		if entries == nil {
+			entries = []prompt.DiffEntry{}
+		}
+		if err := json.NewEncoder(os.Stdout).Encode(entries); err != nil {
+			fail(err)
+		}
+	} else {
+		printDiff(entries)
+	}
+	if len(entries) > 0 {
+		os.Exit(1)
+	}
+}
+
+// printDiff prints entries in a unified diff-like layout: "+" marks sections and
+// prompts only in the second library, "-" those only in the first, and "~" changed
+// sections.
+func printDiff(entries []prompt.DiffEntry) {
+	if len(entries) == 0 {
+		fmt.Println("No differences found")
+		return
+	}
+	marks := map[string]string{prompt.DiffAdded: "+", prompt.DiffRemoved: "-", prompt.DiffChanged: "~"}
+	for _, e := range entries {
+		path := e.Path
+		if path == "" {
+			path = "(before the first section)"
+		}
+		fmt.Printf("%s %s\n", marks[e.Kind], path)
+		printed := 0
+		for _, line := range e.A {
+			if !slices.Contains(e.B, line) {
+				fmt.Printf("    - %s\n", line)
+				printed++
+			}
+		}
+		for _, line := range e.B {
+			if !slices.Contains(e.A, line) {
+				fmt.Printf("    + %s\n", line)
+				printed++
+			}
+		}
+		if printed == 0 {
+			fmt.Println("    (same prompts in a different order)")
+		}
+	}
+}
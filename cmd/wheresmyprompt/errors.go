@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/toozej/wheresmyprompt/internal/prompt"
+	"github.com/toozej/wheresmyprompt/internal/redact"
+)
+
+// Exit codes returned by wheresmyprompt. They are part of the command-line
+// contract relied on by scripts, so existing values must not change.
+const (
+	ExitSuccess   = 0 // The command completed successfully
+	ExitNoMatch   = 1 // The search matched no prompt
+	ExitUsage     = 2 // Invalid flags or arguments
+	ExitSource    = 3 // The prompt source could not be read or written
+	ExitAuth      = 4 // Authenticating with Simplenote or its secret provider failed
+	ExitClipboard = 5 // The prompt could not be copied to the clipboard or typed
+)
+
+// Output formats accepted by --output.
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+// errorKinds names each non-zero exit code in machine-readable error objects.
+var errorKinds = map[int]string{
+	ExitNoMatch:   "no_match",
+	ExitUsage:     "usage",
+	ExitSource:    "source",
+	ExitAuth:      "auth",
+	ExitClipboard: "clipboard",
+}
+
+// errNoMatch is reported when a search returns no results.
+var errNoMatch = errors.New("no match found")
+
+// noMatchError returns errNoMatch for a search for query that found nothing,
+// suggesting the headings closest to query when there are any.
+func noMatchError(prompts *prompt.PromptData, query string) error {
+	hint := prompt.DidYouMean(prompts, query)
+	if hint == "" {
+		return errNoMatch
+	}
+	return fmt.Errorf("%w. %s", errNoMatch, hint)
+}
+
+// errNoConfidentMatch is reported when the best match in a one-shot mode is below
+// the configured minimum relevance. It exits like errNoMatch.
+var errNoConfidentMatch = errors.New("no confident match")
+
+// jsonError is the structured error object written to stderr with --output json.
+type jsonError struct {
+	Error struct {
+		Code    int    `json:"code"`
+		Kind    string `json:"kind"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// exitCodeFor maps err to the exit code contract, defaulting to ExitSource.
+func exitCodeFor(err error) int {
+	switch {
+	case errors.Is(err, errNoMatch), errors.Is(err, errNoConfidentMatch), errors.Is(err, errNotConfirmed),
+		errors.Is(err, prompt.ErrTeamPromptNotFound), errors.Is(err, prompt.ErrOverrideNotFound):
+		return ExitNoMatch
+	case errors.Is(err, prompt.ErrNoTeamLibrary):
+		return ExitUsage
+	case errors.Is(err, prompt.ErrAuth):
+		return ExitAuth
+	case errors.Is(err, prompt.ErrClipboard), errors.Is(err, prompt.ErrTyping):
+		return ExitClipboard
+	default:
+		return ExitSource
+	}
+}
+
+// fail reports err and exits with the code derived from it.
+func fail(err error) {
+	failWithCode(exitCodeFor(err), err)
+}
+
+// failWithCode reports err on stderr, as a JSON object with --output json or as
+// plain text otherwise, and exits with code. Secrets are redacted from the message.
+func failWithCode(code int, err error) {
+	err = redact.Error(err)
+	if output == outputJSON {
+		var e jsonError
+		e.Error.Code = code
+		e.Error.Kind = errorKinds[code]
+		e.Error.Message = err.Error()
+		_ = json.NewEncoder(os.Stderr).Encode(e)
+	} else {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+	}
+	os.Exit(code)
+}
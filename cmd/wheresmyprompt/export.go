@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/toozej/wheresmyprompt/internal/prompt"
+)
+
+var (
+	// exportFormat selects the snippet or library format written by export
+	exportFormat string
+	// exportFile is where export writes the prompts, stdout when empty
+	exportFile string
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export prompts as snippets or for evaluation tooling",
+	Long: `Convert prompts into text expander snippets so they can be expanded inline
+anywhere without launching wheresmyprompt. Each prompt gets an abbreviation made
+of the initials of its title and the first letters of its section: "Code Review"
+under "Golang" becomes ":cr-go". Clashing abbreviations are numbered.
+
+--format espanso writes an espanso match file, to stdout unless --file is given
+(e.g. --file ~/.config/espanso/match/prompts.yml). --format alfred-snippets writes
+an Alfred snippet collection to --file (prompts.alfredsnippets by default); open
+it to import it.
+
+To load the library into evaluation tooling, --format openai-jsonl writes JSON
+Lines for OpenAI fine-tuning and evals, and --format anthropic-json a JSON
+prompt library for Anthropic's tools, to stdout unless --file is given. Each
+prompt becomes a record with its title, the sections above it as tags, its
+content and that content as a user message.
+
+Export only --section with --section.`,
+	Args: cobra.NoArgs,
+	Run:  exportCmdRun,
+}
+
+func exportCmdRun(cmd *cobra.Command, args []string) {
+	var library bool
+	switch exportFormat {
+	case prompt.SnippetFormatEspanso, prompt.SnippetFormatAlfred:
+	case prompt.LibraryFormatOpenAI, prompt.LibraryFormatAnthropic:
+		library = true
+	default:
+		failWithCode(ExitUsage, fmt.Errorf("invalid --format %q: must be %s, %s, %s or %s", exportFormat,
+			prompt.SnippetFormatEspanso, prompt.SnippetFormatAlfred, prompt.LibraryFormatOpenAI, prompt.LibraryFormatAnthropic))
+	}
+	if exportFormat == prompt.SnippetFormatAlfred && exportFile == "" {
+		exportFile = "prompts.alfredsnippets"
+	}
+
+	prompts := loadPromptsForSearch()
+	records := prompt.SearchPromptRecords(prompts, "", resolveSection(false))
+	if len(records) == 0 {
+		fail(errNoMatch)
+	}
+	noun := "snippet(s)"
+	write := func(w io.Writer) error {
+		return prompt.ExportSnippets(w, exportFormat, prompt.Snippets(records))
+	}
+	if library {
+		noun = "prompt(s)"
+		write = func(w io.Writer) error {
+			return prompt.ExportLibrary(w, exportFormat, prompt.LibraryRecords(records))
+		}
+	}
+
+	if exportFile == "" {
+		if err := write(os.Stdout); err != nil {
+			fail(err)
+		}
+		return
+	}
+
+	f, err := os.Create(exportFile) // #nosec G304
+	if err != nil {
+		fail(fmt.Errorf("failed to create %s: %w", exportFile, err))
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		fail(err)
+	}
+	if err := f.Close(); err != nil {
+		fail(fmt.Errorf("failed to write %s: %w", exportFile, err))
+	}
+	fmt.Printf("Exported %d %s to %s\n", len(records), noun, exportFile)
+}
+
+func init() {
+	exportCmd.Flags().StringVar(&exportFormat, "format", prompt.SnippetFormatEspanso, "Export format: espanso, alfred-snippets, openai-jsonl or anthropic-json")
+	exportCmd.Flags().StringVarP(&exportFile, "file", "f", "", "Write the export to this file instead of stdout")
+}
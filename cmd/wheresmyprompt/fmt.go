@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/toozej/wheresmyprompt/internal/prompt"
+)
+
+// fmtCheck reports whether the library needs formatting instead of rewriting it.
+var fmtCheck bool
+
+var fmtCmd = &cobra.Command{
+	Use:   "fmt",
+	Short: "Normalize whitespace and heading spacing in the prompt library",
+	Long: `Rewrite the prompt library with consistent formatting: one blank line before
+every heading and none after it, single spaces after heading markers, no
+trailing whitespace, collapsed blank lines and a single trailing newline.
+Fenced code blocks are left untouched. Set AUTO_FORMAT=true to apply this
+after every write.`,
+	Args: cobra.NoArgs,
+	Run:  fmtCmdRun,
+}
+
+func fmtCmdRun(cmd *cobra.Command, args []string) {
+	if err := prompt.CheckRequiredBinaries(conf); err != nil {
+		fail(err)
+	}
+	applyLoadFlag()
+
+	if fmtCheck {
+		formatted, err := prompt.CheckFormatted(conf)
+		if err != nil {
+			fail(err)
+		}
+		if !formatted {
+			fmt.Println("Prompt library is not formatted")
+			os.Exit(1)
+		}
+		fmt.Println("Prompt library is formatted")
+		return
+	}
+
+	changed, err := prompt.FormatSource(conf)
+	if err != nil {
+		fail(err)
+	}
+	if changed {
+		fmt.Println("Formatted prompt library")
+	} else {
+		fmt.Println("Prompt library already formatted")
+	}
+}
+
+func init() {
+	fmtCmd.Flags().BoolVar(&fmtCheck, "check", false, "Exit with status 1 if the library is not formatted, without rewriting it")
+}
@@ -0,0 +1,207 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/toozej/wheresmyprompt/internal/history"
+	"github.com/toozej/wheresmyprompt/internal/prompt"
+)
+
+// guideMaxResults caps the number of matches the guide lists.
+const guideMaxResults = 10
+
+var guideCmd = &cobra.Command{
+	Use:   "guide",
+	Short: "Find a prompt step by step, without knowing the library layout",
+	Long: `Ask what you are trying to do, offering the top-level sections of the prompt
+library with the one matching the current directory's language as the default,
+then for a keyword, and list the matching prompts. The chosen prompt is copied to
+the clipboard, and the equivalent search command is shown for next time.`,
+	Args: cobra.NoArgs,
+	Run:  guideCmdRun,
+}
+
+func guideCmdRun(cmd *cobra.Command, args []string) {
+	prompts := loadPromptsForSearch()
+	chosen, ok := runGuide(prompts, resolveSection(true), os.Stdin, os.Stdout)
+	if !ok {
+		return
+	}
+	copyPrompt(expandPrompt(prompts, chosen))
+	recordUsage(history.ActionCopy, chosen)
+	fmt.Println("Copied to the clipboard.")
+}
+
+// guideArea is a top-level section offered by the guide, with all its prompts.
+type guideArea struct {
+	Name    string
+	Prompts []prompt.Prompt
+}
+
+// guideAreas groups every prompt under its top-level section, below the document
+// title, in library order.
+func guideAreas(prompts *prompt.PromptData) []guideArea {
+	var areas []guideArea
+	index := map[string]int{}
+	for _, p := range prompt.SearchPromptRecords(prompts, "", "") {
+		headings := strings.Split(p.Title, " > ")
+		name := headings[0]
+		if len(headings) > 1 {
+			name = headings[1]
+		}
+		if name == "" {
+			name = p.Section
+		}
+		i, ok := index[name]
+		if !ok {
+			i = len(areas)
+			index[name] = i
+			areas = append(areas, guideArea{Name: name})
+		}
+		areas[i].Prompts = append(areas[i].Prompts, p)
+	}
+	return areas
+}
+
+// runGuide asks on out, reading answers from in, which top-level section to search,
+// offering detected as the default when it names one, then for a keyword, lists the
+// matches and returns the one picked. It returns false when no prompt is picked or
+// input ends.
+func runGuide(prompts *prompt.PromptData, detected string, in io.Reader, out io.Writer) (prompt.Prompt, bool) {
+	areas := guideAreas(prompts)
+	if len(areas) == 0 {
+		fmt.Fprintln(out, "The prompt library has no prompts yet; add one with wheresmyprompt add.")
+		return prompt.Prompt{}, false
+	}
+	scanner := bufio.NewScanner(in)
+
+	area, ok := askGuideArea(areas, detected, scanner, out)
+	if !ok {
+		return prompt.Prompt{}, false
+	}
+	pool, scope := allPrompts(areas), "the whole library"
+	if area != nil {
+		pool, scope = area.Prompts, area.Name
+	}
+
+	var keyword string
+	var results []prompt.Prompt
+	for {
+		fmt.Fprint(out, "\nType a keyword, such as review or tests (press Enter to list everything): ")
+		if !scanner.Scan() {
+			fmt.Fprintln(out)
+			return prompt.Prompt{}, false
+		}
+		keyword = strings.TrimSpace(scanner.Text())
+		if results = prompt.FilterPrompts(prompts, pool, keyword, false); len(results) > 0 {
+			break
+		}
+		fmt.Fprintf(out, "No prompts in %s match %q. Try another keyword.\n", scope, keyword)
+	}
+
+	fmt.Fprintf(out, "\nPrompts in %s:\n", scope)
+	shown := results[:min(len(results), guideMaxResults)]
+	for i, p := range shown {
+		fmt.Fprintf(out, "  %d) %s\n", i+1, pickPreview(p))
+	}
+	if more := len(results) - len(shown); more > 0 {
+		fmt.Fprintf(out, "  ... and %d more; use a more specific keyword to narrow them down\n", more)
+	}
+	fmt.Fprintf(out, "\nNext time, run: %s\n", guideCommand(area, keyword))
+
+	for {
+		fmt.Fprintf(out, "Copy a prompt [1-%d], or press Enter to finish: ", len(shown))
+		if !scanner.Scan() {
+			fmt.Fprintln(out)
+			return prompt.Prompt{}, false
+		}
+		answer := strings.TrimSpace(scanner.Text())
+		if answer == "" {
+			return prompt.Prompt{}, false
+		}
+		if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(shown) {
+			return shown[n-1], true
+		}
+		fmt.Fprintf(out, "Invalid choice %q\n", answer)
+	}
+}
+
+// askGuideArea lists areas on out and reads the choice from scanner: a number or
+// name picks an area and an empty answer picks the detected one, or every area
+// (nil) when none was detected. It returns false when input ends.
+func askGuideArea(areas []guideArea, detected string, scanner *bufio.Scanner, out io.Writer) (*guideArea, bool) {
+	var fallback *guideArea
+	fmt.Fprintln(out, "What are you trying to do?")
+	for i := range areas {
+		marker := ""
+		if detected != "" && strings.EqualFold(areas[i].Name, detected) {
+			fallback, marker = &areas[i], ", detected for this directory"
+		}
+		count := fmt.Sprintf("%d prompts", len(areas[i].Prompts))
+		if len(areas[i].Prompts) == 1 {
+			count = "1 prompt"
+		}
+		fmt.Fprintf(out, "  %d) %s (%s%s)\n", i+1, areas[i].Name, count, marker)
+	}
+	fallbackName := "everything"
+	if fallback != nil {
+		fallbackName = fallback.Name
+	}
+
+	for {
+		fmt.Fprintf(out, "Pick a number or name (press Enter for %s): ", fallbackName)
+		if !scanner.Scan() {
+			fmt.Fprintln(out)
+			return nil, false
+		}
+		answer := strings.TrimSpace(scanner.Text())
+		if answer == "" {
+			return fallback, true
+		}
+		if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(areas) {
+			return &areas[n-1], true
+		}
+		for i := range areas {
+			if strings.EqualFold(areas[i].Name, answer) {
+				return &areas[i], true
+			}
+		}
+		fmt.Fprintf(out, "No section %q\n", answer)
+	}
+}
+
+// allPrompts returns the prompts of every area.
+func allPrompts(areas []guideArea) []prompt.Prompt {
+	var pool []prompt.Prompt
+	for _, a := range areas {
+		pool = append(pool, a.Prompts...)
+	}
+	return pool
+}
+
+// guideCommand returns the search command listing the same prompts as the guide
+// did for area (nil for every section) and keyword.
+func guideCommand(area *guideArea, keyword string) string {
+	if area == nil {
+		if keyword == "" {
+			return "wheresmyprompt search --no-auto-section"
+		}
+		return "wheresmyprompt search --no-auto-section " + shellQuote(keyword)
+	}
+	name := area.Name
+	if strings.ContainsAny(name, " \t") {
+		name = `"` + name + `"`
+	}
+	return "wheresmyprompt search " + shellQuote(strings.TrimSpace("section:"+name+" "+keyword))
+}
+
+// shellQuote quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/toozej/wheresmyprompt/internal/prompt"
+)
+
+var guidePrompts = &prompt.PromptData{Sections: []prompt.Section{
+	{Headings: []string{"Prompts", "Golang"}, Lines: []string{"Review this Go code", "Explain this Go error"}},
+	{Headings: []string{"Prompts", "Golang", "Tests"}, Lines: []string{"Write table-driven Go tests"}},
+	{Headings: []string{"Prompts", "Code Review"}, Lines: []string{"Review this pull request"}},
+}}
+
+func TestGuideAreas(t *testing.T) {
+	areas := guideAreas(guidePrompts)
+	if len(areas) != 2 || areas[0].Name != "Golang" || areas[1].Name != "Code Review" {
+		t.Fatalf("guideAreas() = %v, want Golang and Code Review", areas)
+	}
+	if len(areas[0].Prompts) != 3 {
+		t.Errorf("Golang has %d prompts, want 3 including its subsections", len(areas[0].Prompts))
+	}
+}
+
+func TestRunGuide(t *testing.T) {
+	tests := []struct {
+		name        string
+		detected    string
+		input       string
+		expected    string // Content of the picked prompt, empty for none
+		wantCommand string
+	}{
+		{"pick by number", "", "1\ntests\n1\n", "Write table-driven Go tests", "wheresmyprompt search 'section:Golang tests'"},
+		{"pick by name", "", "code review\n\n1\n", "Review this pull request", `wheresmyprompt search 'section:"Code Review"'`},
+		{"detected section by default", "golang", "\nerror\n1\n", "Explain this Go error", "wheresmyprompt search 'section:Golang error'"},
+		{"whole library without detected section", "", "\nreview\n2\n", "Review this pull request", "wheresmyprompt search --no-auto-section 'review'"},
+		{"unknown section then keyword retry", "", "rust\n2\npython\nreview\n1\n", "Review this pull request", `wheresmyprompt search 'section:"Code Review" review'`},
+		{"finish without picking", "", "1\n\n\n", "", "wheresmyprompt search 'section:Golang'"},
+		{"end of input", "", "1\n", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			got, ok := runGuide(guidePrompts, tt.detected, strings.NewReader(tt.input), &out)
+			if ok != (tt.expected != "") || got.Content != tt.expected {
+				t.Errorf("runGuide() = %q, %v, want %q\noutput:\n%s", got.Content, ok, tt.expected, out.String())
+			}
+			if tt.wantCommand != "" && !strings.Contains(out.String(), "Next time, run: "+tt.wantCommand+"\n") {
+				t.Errorf("output does not suggest %q:\n%s", tt.wantCommand, out.String())
+			}
+		})
+	}
+}
+
+func TestRunGuide_EmptyLibrary(t *testing.T) {
+	var out bytes.Buffer
+	if _, ok := runGuide(&prompt.PromptData{}, "", strings.NewReader("1\n"), &out); ok {
+		t.Error("runGuide() picked a prompt from an empty library")
+	}
+	if !strings.Contains(out.String(), "no prompts yet") {
+		t.Errorf("output = %q, want a hint that the library is empty", out.String())
+	}
+}
@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+	"github.com/spf13/cobra"
+
+	"github.com/toozej/wheresmyprompt/internal/llm"
+	"github.com/toozej/wheresmyprompt/internal/prompt"
+)
+
+var improveWrite bool
+
+var improveCmd = &cobra.Command{
+	Use:   "improve <query>",
+	Short: "Ask an LLM to improve the best matching prompt",
+	Long: `Send the best match for the query to the OpenAI-compatible endpoint configured
+with LLM_BASE_URL (and LLM_API_KEY / LLM_MODEL) together with a meta-prompt asking
+for an improved version, and show both side by side. With --write the improved
+version replaces the original in the prompt source.`,
+	Args: cobra.MinimumNArgs(1),
+	Run:  improveCmdRun,
+}
+
+func improveCmdRun(cmd *cobra.Command, args []string) {
+	client, err := llm.NewClient(conf)
+	if err != nil {
+		failWithCode(ExitUsage, err)
+	}
+	if err := prompt.CheckRequiredBinaries(conf); err != nil {
+		fail(err)
+	}
+	applyLoadFlag()
+
+	prompts, err := prompt.LoadPrompts(conf)
+	if err != nil {
+		fail(err)
+	}
+	query := strings.Join(args, " ")
+	original, ok := prompt.FindBestPrompt(prompts, query, resolveSection(!prompt.QueryHasSection(query)))
+	if !ok {
+		fail(noMatchError(prompts, query))
+	}
+
+	improved, err := client.Improve(original.Content)
+	if err != nil {
+		fail(err)
+	}
+
+	fmt.Println(sideBySide("ORIGINAL", original.Content, "IMPROVED", improved, 50))
+
+	if !improveWrite {
+		fmt.Println("\nRe-run with --write to replace the original prompt.")
+		return
+	}
+	if err := prompt.ReplacePrompt(conf, original.Content, improved); err != nil {
+		fail(err)
+	}
+	fmt.Println("\nImproved prompt written back to the prompt source.")
+}
+
+// sideBySide renders left and right as two word-wrapped columns of the given width.
+func sideBySide(leftTitle, left, rightTitle, right string, width int) string {
+	l := append([]string{leftTitle, strings.Repeat("-", width)}, wrapText(left, width)...)
+	r := append([]string{rightTitle, strings.Repeat("-", width)}, wrapText(right, width)...)
+
+	rows := len(l)
+	if len(r) > rows {
+		rows = len(r)
+	}
+
+	var b strings.Builder
+	for i := 0; i < rows; i++ {
+		var lc, rc string
+		if i < len(l) {
+			lc = l[i]
+		}
+		if i < len(r) {
+			rc = r[i]
+		}
+		// Pad by display width, so accented and wide characters stay aligned
+		fmt.Fprintf(&b, "%s%s | %s\n", lc, strings.Repeat(" ", max(width-runewidth.StringWidth(lc), 0)), rc)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// wrapText breaks text into lines of at most width terminal columns, splitting on whitespace
+// and keeping existing line breaks. Words wider than a line, such as URLs, are split.
+func wrapText(text string, width int) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(text, "\n") {
+		line := ""
+		for _, field := range strings.Fields(paragraph) {
+			for _, word := range splitWidth(field, width) {
+				switch {
+				case line == "":
+					line = word
+				case runewidth.StringWidth(line)+1+runewidth.StringWidth(word) > width:
+					lines = append(lines, line)
+					line = word
+				default:
+					line += " " + word
+				}
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// splitWidth splits word into pieces of at most width terminal columns, never
+// splitting a character. A character wider than width is a piece of its own.
+func splitWidth(word string, width int) []string {
+	var pieces []string
+	piece, pieceWidth := "", 0
+	for _, r := range word {
+		w := runewidth.RuneWidth(r)
+		if piece != "" && pieceWidth+w > width {
+			pieces = append(pieces, piece)
+			piece, pieceWidth = "", 0
+		}
+		piece += string(r)
+		pieceWidth += w
+	}
+	return append(pieces, piece)
+}
+
+func init() {
+	improveCmd.Flags().BoolVar(&improveWrite, "write", false, "Replace the original prompt with the improved version")
+}
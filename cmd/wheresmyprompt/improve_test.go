@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mattn/go-runewidth"
+)
+
+func TestSideBySide(t *testing.T) {
+	out := sideBySide("Original", "Résumé naïve café", "Improved", "日本語のコードをレビュー", 12)
+	lines := strings.Split(out, "\n")
+	if len(lines) < 4 {
+		t.Fatalf("sideBySide() returned %d lines:\n%s", len(lines), out)
+	}
+	for _, line := range lines {
+		left, _, ok := strings.Cut(line, " | ")
+		if !ok || runewidth.StringWidth(left) != 12 {
+			t.Errorf("expected the left column padded to 12 columns, got %q (%d)", left, runewidth.StringWidth(left))
+		}
+	}
+	if !strings.Contains(out, "| 日本語のコー\n") || !strings.HasSuffix(out, "| ドをレビュー") {
+		t.Errorf("expected the wide word split at 12 columns, got:\n%s", out)
+	}
+}
+
+func TestSideBySide_LongWords(t *testing.T) {
+	const width = 20
+	left := "See https://example.com/docs/prompts/code-review-guidelines for details"
+	right := "Explain 関数型プログラミングの基本概念について in simple terms"
+	out := sideBySide("Original", left, "Improved", right, width)
+	for _, line := range strings.Split(out, "\n") {
+		l, r, ok := strings.Cut(line, " | ")
+		if !ok || runewidth.StringWidth(l) != width || runewidth.StringWidth(r) > width {
+			t.Errorf("expected columns of %d, got %q (%d) and %q (%d)", width, l, runewidth.StringWidth(l), r, runewidth.StringWidth(r))
+		}
+	}
+	if strings.Contains(out, left) || !strings.Contains(out, "https://example.com/ |") {
+		t.Errorf("expected the URL split across lines, got:\n%s", out)
+	}
+}
+
+func TestWrapText(t *testing.T) {
+	got := wrapText("漢字 漢字 漢字", 10)
+	if len(got) != 2 || got[0] != "漢字 漢字" || got[1] != "漢字" {
+		t.Errorf("wrapText() = %q, want lines of at most 10 columns", got)
+	}
+	got = wrapText("Visit https://example.com/a/very/long/path now", 10)
+	want := []string{"Visit", "https://ex", "ample.com/", "a/very/lon", "g/path now"}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("wrapText() = %q, want %q", got, want)
+	}
+	if got := wrapText("日本語", 5); len(got) != 2 || got[0] != "日本" || got[1] != "語" {
+		t.Errorf("wrapText() = %q, want wide characters kept whole", got)
+	}
+	if got := wrapText("日本", 1); len(got) != 2 || got[0] != "日" {
+		t.Errorf("wrapText() = %q, want a character wider than the line on its own", got)
+	}
+}
@@ -0,0 +1,184 @@
+//go:build launcher
+
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/toozej/wheresmyprompt/internal/history"
+	"github.com/toozej/wheresmyprompt/internal/prompt"
+	"github.com/toozej/wheresmyprompt/internal/sandbox"
+)
+
+// This file is only built with -tags launcher, since the launcher integration
+// depends on a graphical session and is of no use on servers or in containers.
+
+// launcherTerminal opens the TUI in a terminal window instead of a launcher menu
+var launcherTerminal bool
+
+// pickerCandidates lists the launchers tried, in order, when PICKER_COMMAND is unset.
+// Each reads one entry per line on stdin and prints the chosen one on stdout.
+var pickerCandidates = map[string][][]string{
+	"wayland": {{"fuzzel", "--dmenu"}, {"wofi", "--dmenu"}, {"rofi", "-dmenu", "-i"}},
+	"x11":     {{"rofi", "-dmenu", "-i"}, {"dmenu", "-i", "-l", "20"}},
+	"darwin":  {{"choose"}},
+}
+
+// errNoPicker is returned when no launcher is configured or installed.
+var errNoPicker = errors.New("no picker found: install rofi, wofi, fuzzel, dmenu or choose, or set PICKER_COMMAND")
+
+// runPickerFunc allows tests to replace the launcher.
+var runPickerFunc = runPicker
+
+var launcherCmd = &cobra.Command{
+	Use:   "launcher",
+	Short: "Pick a prompt from a launcher menu such as rofi, dmenu or choose",
+	Long: `Pop up a minimal launcher menu listing every prompt and copy the chosen one to
+the clipboard (and type it with --type). wheresmyprompt registers no hotkey or
+tray icon itself: bind this command to a shortcut in your desktop environment
+or window manager (GNOME/KDE custom shortcuts, sxhkd, skhd, ...) to pick
+prompts without opening a terminal first.
+
+The picker is PICKER_COMMAND when set, or the first launcher found among fuzzel,
+wofi and rofi on Wayland, rofi and dmenu on X11, and choose on macOS. With
+--terminal the interactive search opens in a new terminal window ($TERMINAL, or
+x-terminal-emulator) instead.`,
+	Args: cobra.NoArgs,
+	Run:  launcherCmdRun,
+}
+
+func launcherCmdRun(cmd *cobra.Command, args []string) {
+	if launcherTerminal {
+		if err := openTerminalTUI(); err != nil {
+			fail(err)
+		}
+		return
+	}
+
+	prompts := loadPromptsForSearch()
+	records := prompt.SearchPromptRecords(prompts, "", resolveSection(false))
+	if len(records) == 0 {
+		fail(errNoMatch)
+	}
+
+	picker, err := pickerCommand(conf.PickerCommand)
+	if err != nil {
+		failWithCode(ExitUsage, err)
+	}
+	choice, err := runPickerFunc(picker, pickerEntries(records))
+	if err != nil {
+		fail(err)
+	}
+	selected, ok := parsePickerChoice(choice, records)
+	if !ok {
+		// Dismissing the picker is not an error
+		return
+	}
+
+	resolved := expandPrompt(prompts, selected)
+	var paths []string
+	if conf.WithAttachments {
+		paths = attachments(prompts, selected)
+	}
+	copyPrompt(resolved)
+	if conf.WithAttachments {
+		printAttachments(paths)
+	}
+	recordUsage(history.ActionCopy, selected)
+	typeIfEnabled(resolved)
+}
+
+// pickerCommand returns the launcher to run: PICKER_COMMAND split on whitespace,
+// or the first installed candidate for this session.
+func pickerCommand(configured string) ([]string, error) {
+	if fields := strings.Fields(configured); len(fields) > 0 {
+		return fields, nil
+	}
+	session := runtime.GOOS
+	if session == "linux" {
+		session = "x11"
+		if os.Getenv("WAYLAND_DISPLAY") != "" {
+			session = "wayland"
+		}
+	}
+	for _, candidate := range pickerCandidates[session] {
+		if _, err := sandbox.LookPath(candidate[0]); err == nil {
+			return candidate, nil
+		}
+	}
+	return nil, errNoPicker
+}
+
+// pickerEntries returns one numbered line per prompt, so the choice can be mapped
+// back to its prompt even when launchers print the line rather than its index.
+func pickerEntries(records []prompt.Prompt) []string {
+	entries := make([]string, len(records))
+	for i, p := range records {
+		entries[i] = fmt.Sprintf("%d. %s", i+1, pickPreview(p))
+	}
+	return entries
+}
+
+// parsePickerChoice returns the prompt of the entry printed by the launcher. The
+// boolean result is false if nothing was chosen.
+func parsePickerChoice(choice string, records []prompt.Prompt) (prompt.Prompt, bool) {
+	number, _, found := strings.Cut(strings.TrimSpace(choice), ". ")
+	if !found {
+		return prompt.Prompt{}, false
+	}
+	n, err := strconv.Atoi(number)
+	if err != nil || n < 1 || n > len(records) {
+		return prompt.Prompt{}, false
+	}
+	return records[n-1], true
+}
+
+// runPicker feeds entries to the launcher and returns the line it prints. A
+// launcher exiting with status 1, as they do when dismissed, returns no choice.
+func runPicker(picker []string, entries []string) (string, error) {
+	c := sandbox.Command(picker[0], picker[1:]...) // #nosec G204
+	c.Stdin = strings.NewReader(strings.Join(entries, "\n") + "\n")
+	var out bytes.Buffer
+	c.Stdout = &out
+	c.Stderr = os.Stderr
+	if err := c.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to run picker %s: %w", picker[0], err)
+	}
+	return out.String(), nil
+}
+
+// openTerminalTUI runs this executable's interactive search in a new terminal window.
+func openTerminalTUI() error {
+	self, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate wheresmyprompt: %w", err)
+	}
+	terminal := os.Getenv("TERMINAL")
+	if terminal == "" {
+		terminal = "x-terminal-emulator"
+	}
+	c := sandbox.Command(terminal, "-e", self, "tui") // #nosec G204
+	if err := c.Start(); err != nil {
+		return fmt.Errorf("failed to open terminal %s: %w", terminal, err)
+	}
+	return c.Process.Release()
+}
+
+func init() {
+	launcherCmd.Flags().BoolVar(&launcherTerminal, "terminal", false, "Open the interactive search in a new terminal window instead of a launcher menu")
+	launcherCmd.Flags().BoolVar(&typePrompt, "type", false, "Also type the chosen prompt into the focused window (xdotool, wtype or osascript)")
+	rootCmd.AddCommand(launcherCmd)
+}
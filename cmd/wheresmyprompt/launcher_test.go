@@ -0,0 +1,52 @@
+//go:build launcher
+
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/toozej/wheresmyprompt/internal/prompt"
+)
+
+func TestPickerChoice(t *testing.T) {
+	records := []prompt.Prompt{
+		{Content: "Review this code", Section: "Golang"},
+		{Content: "Write tests", Section: "Testing"},
+	}
+	entries := pickerEntries(records)
+	expected := []string{"1. [Golang] Review this code", "2. [Testing] Write tests"}
+	if !reflect.DeepEqual(entries, expected) {
+		t.Errorf("pickerEntries() = %q, want %q", entries, expected)
+	}
+
+	tests := []struct {
+		choice   string
+		expected string
+		ok       bool
+	}{
+		{choice: entries[1] + "\n", expected: "Write tests", ok: true},
+		{choice: entries[0], expected: "Review this code", ok: true},
+		{choice: ""},
+		{choice: "3. [Golang] Missing"},
+		{choice: "typed text"},
+	}
+	for _, tt := range tests {
+		got, ok := parsePickerChoice(tt.choice, records)
+		if ok != tt.ok || got.Content != tt.expected {
+			t.Errorf("parsePickerChoice(%q) = %q, %v, want %q, %v", tt.choice, got.Content, ok, tt.expected, tt.ok)
+		}
+	}
+}
+
+func TestPickerCommand(t *testing.T) {
+	picker, err := pickerCommand("rofi  -dmenu -i")
+	if err != nil || !reflect.DeepEqual(picker, []string{"rofi", "-dmenu", "-i"}) {
+		t.Errorf("pickerCommand() = %q, %v", picker, err)
+	}
+
+	t.Setenv("PATH", t.TempDir())
+	if _, err := pickerCommand(""); err != errNoPicker {
+		t.Errorf("expected errNoPicker without launchers, got %v", err)
+	}
+}
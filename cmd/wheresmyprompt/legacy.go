@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// legacyQueryArgs returns the command line args to run, keeping the bare
+// "wheresmyprompt <query>" search working for queries that name a subcommand, such as
+// "review" or "list". A query is searched for like before the subcommands existed
+// when a flag only the root command has is given, as in "--all review", or when
+// more words are given than the subcommand accepts, as in "review go code". The
+// flags then come first and the words after "--", so cobra doesn't run the
+// subcommand. Any other args are returned unchanged; "wheresmyprompt search <query>"
+// searches for any word.
+func legacyQueryArgs(root *cobra.Command, args []string) []string {
+	cmd, rest, err := root.Find(args)
+	if err != nil || cmd == root {
+		return args
+	}
+
+	flags, positional, rootOnly := splitFlags(root, cmd, args)
+	if !rootOnly {
+		_, words, _ := splitFlags(root, cmd, rest)
+		if len(words) == 0 || cmd.ValidateArgs(words) == nil {
+			return args
+		}
+	}
+	return append(append(flags, "--"), positional...)
+}
+
+// splitFlags splits args into flags with their values and positional words, as
+// parsed for cmd, a subcommand of root. rootOnly reports whether a flag of root that
+// cmd doesn't have was given. Flags of neither are kept as flags for cobra to reject.
+func splitFlags(root, cmd *cobra.Command, args []string) (flags, positional []string, rootOnly bool) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return flags, append(positional, args[i+1:]...), rootOnly
+		}
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			positional = append(positional, arg)
+			continue
+		}
+		flags = append(flags, arg)
+
+		var name string
+		var hasValue bool // The value is part of arg, as in "--section=Golang" or "-sGolang"
+		long, isLong := strings.CutPrefix(arg, "--")
+		if isLong {
+			name, _, hasValue = strings.Cut(long, "=")
+		} else {
+			name, hasValue = arg[1:2], len(arg) > 2
+		}
+		found, takesValue := lookupFlag(cmd, name, !isLong)
+		if !found {
+			if found, takesValue = lookupFlag(root, name, !isLong); found {
+				rootOnly = true
+			}
+		}
+		// The next arg is the value of a flag that takes one, as in "-s Golang"
+		if found && takesValue && !hasValue && i+1 < len(args) {
+			i++
+			flags = append(flags, args[i])
+		}
+	}
+	return flags, positional, rootOnly
+}
+
+// lookupFlag reports whether cmd has, or inherits, the flag called name, or with the
+// one-letter shorthand name, and whether it takes a value.
+func lookupFlag(cmd *cobra.Command, name string, shorthand bool) (found, takesValue bool) {
+	lookup := cmd.Flags().Lookup
+	inherited := cmd.InheritedFlags().Lookup
+	if shorthand {
+		lookup, inherited = cmd.Flags().ShorthandLookup, cmd.InheritedFlags().ShorthandLookup
+	}
+	flag := lookup(name)
+	if flag == nil {
+		flag = inherited(name)
+	}
+	if flag == nil {
+		return false, false
+	}
+	return true, flag.NoOptDefVal == ""
+}
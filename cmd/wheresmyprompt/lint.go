@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/toozej/wheresmyprompt/internal/prompt"
+)
+
+var lintCmd = &cobra.Command{
+	Use:   "lint",
+	Short: "Report headings and content that keep prompts out of search",
+	Long: `Parse the prompt library, and the team library when one is configured, and
+report problems that explain why a prompt isn't showing up in search: heading
+levels that jump (e.g. "#" straight to "###"), content before the first
+heading, which is never searched, and headings without any prompts. Exits with
+status 1 if anything is reported. The same warnings are logged with --debug.`,
+	Args: cobra.NoArgs,
+	Run:  lintCmdRun,
+}
+
+func lintCmdRun(cmd *cobra.Command, args []string) {
+	checkOutputFlag()
+	if err := prompt.CheckRequiredBinaries(conf); err != nil {
+		fail(err)
+	}
+	applyLoadFlag()
+
+	warnings, err := prompt.Lint(conf)
+	if err != nil {
+		fail(err)
+	}
+
+	if output == outputJSON {
+		if warnings == nil {
+			warnings = []prompt.LintWarning{}
+		}
+		if err := json.NewEncoder(os.Stdout).Encode(warnings); err != nil {
+			fail(err)
+		}
+	} else {
+		for _, w := range warnings {
+			fmt.Printf("%s:%d: %s\n", w.Source, w.Line, w.Message)
+		}
+		if len(warnings) == 0 {
+			fmt.Println("No problems found")
+		}
+	}
+	if len(warnings) > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the prompts in a section, or all section names",
+	Long: `List the prompts in --section, or in the section named after the primary
+language of the current directory. When no section applies, list the heading
+path of every section instead.`,
+	Args: cobra.NoArgs,
+	Run:  listCmdRun,
+}
+
+func listCmdRun(cmd *cobra.Command, args []string) {
+	prompts := loadPromptsForSearch()
+	if sectionToUse := resolveSection(true); sectionToUse != "" {
+		listSection(prompts, sectionToUse)
+		return
+	}
+	listSectionNames(prompts)
+}
@@ -0,0 +1,320 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/toozej/wheresmyprompt/internal/history"
+	"github.com/toozej/wheresmyprompt/internal/prompt"
+)
+
+// This file holds the modes shared by the root command's legacy flags and the
+// search, copy, add, list and tui subcommands.
+
+// loadPromptsForSearch validates --output, applies the shared search flags to conf
+// and loads the prompt library, exiting with the matching exit code on failure.
+func loadPromptsForSearch() *prompt.PromptData {
+	checkOutputFlag()
+	if err := prompt.CheckRequiredBinaries(conf); err != nil {
+		fail(err)
+	}
+	applyLoadFlag()
+
+	if includeArchived {
+		conf.IncludeArchived = true
+	}
+	if titlesOnly {
+		conf.TitlesOnly = true
+	}
+	if stem {
+		conf.Stemming = true
+	}
+	if typePrompt {
+		conf.TypeOnSelect = true
+	}
+	if allowShell {
+		conf.AllowShell = true
+	}
+	if withAttachments {
+		conf.WithAttachments = true
+	}
+
+	prompts, err := prompt.LoadPrompts(conf)
+	if err != nil {
+		fail(err)
+	}
+	return prompts
+}
+
+// checkOutputFlag exits with ExitUsage if --output is not a supported format.
+func checkOutputFlag() {
+	if output != outputText && output != outputJSON {
+		failWithCode(ExitUsage, fmt.Errorf("invalid --output %q: must be %s or %s", output, outputText, outputJSON))
+	}
+}
+
+// printMatches prints every match for query, exiting with ExitNoMatch if there is none.
+func printMatches(prompts *prompt.PromptData, query, sectionToUse string) {
+	results := searchPrompts(prompts, query, sectionToUse)
+	if len(results) == 0 {
+		fail(noMatchError(prompts, query))
+	}
+	if groupBy == groupBySection {
+		printGroupedPrompts(sortResults(results))
+		return
+	}
+	printPrompts(sortResults(results))
+}
+
+// groupBySection is the --group-by value grouping results under their section.
+const groupBySection = "section"
+
+// validateGroupBy checks the --group-by value.
+func validateGroupBy() error {
+	if groupBy != "" && groupBy != groupBySection {
+		return fmt.Errorf("invalid --group-by %q: must be %s", groupBy, groupBySection)
+	}
+	return nil
+}
+
+// promptGroup is the results filed under one section, see groupPrompts.
+type promptGroup struct {
+	Section string
+	Prompts []prompt.Prompt
+}
+
+// groupPrompts groups results by section heading path, without the document title
+// and prefixed with the library for prompts of several libraries. Groups are
+// ordered by their first result, and keep the order of their results.
+func groupPrompts(results []prompt.Prompt) []promptGroup {
+	var groups []promptGroup
+	index := map[string]int{}
+	for _, p := range results {
+		name := groupName(p)
+		i, ok := index[name]
+		if !ok {
+			i = len(groups)
+			index[name] = i
+			groups = append(groups, promptGroup{Section: name})
+		}
+		groups[i].Prompts = append(groups[i].Prompts, p)
+	}
+	return groups
+}
+
+// groupName returns the name of the group of p, such as "Golang > Tests".
+func groupName(p prompt.Prompt) string {
+	name := p.Section
+	if _, path, ok := strings.Cut(p.Title, " > "); ok {
+		name = path
+	}
+	if p.Namespace != "" {
+		name = "[" + p.Namespace + "] " + name
+	}
+	return name
+}
+
+// printGroupedPrompts prints results under their section headings with counts, or
+// as an object mapping each section to its results with --output json.
+func printGroupedPrompts(results []prompt.Prompt) {
+	groups := groupPrompts(results)
+	if output == outputJSON {
+		out := make(map[string][]jsonPrompt, len(groups))
+		for _, g := range groups {
+			out[g.Section] = jsonPrompts(g.Prompts)
+		}
+		if err := json.NewEncoder(os.Stdout).Encode(out); err != nil {
+			fail(err)
+		}
+		return
+	}
+	for _, g := range groups {
+		fmt.Printf("\n## %s (%d)\n", g.Section, len(g.Prompts))
+		for _, p := range g.Prompts {
+			fmt.Printf("\n%s\n", p.Content)
+		}
+	}
+	fmt.Println()
+}
+
+// printBestMatch prints the best match for query and types it when enabled.
+func printBestMatch(prompts *prompt.PromptData, query, sectionToUse string) {
+	result := bestMatch(prompts, query, sectionToUse)
+	resolved := expandPrompt(prompts, result)
+	if conf.WithAttachments {
+		printPromptWithAttachments(resolved, attachments(prompts, result))
+	} else {
+		printPrompts([]prompt.Prompt{resolved})
+	}
+	recordUsage(history.ActionPrint, result)
+	typeIfEnabled(resolved)
+}
+
+// copyBestMatch copies the best match for query to the clipboard and types it when enabled.
+// With --confirm and a terminal on stdin, the match is shown and copied only if the user agrees.
+func copyBestMatch(prompts *prompt.PromptData, query, sectionToUse string) {
+	result := bestMatch(prompts, query, sectionToUse)
+	resolved := expandPrompt(prompts, result)
+	var paths []string
+	if conf.WithAttachments {
+		paths = attachments(prompts, result)
+	}
+	if confirmCopy && stdinIsTerminal() && !confirmMatch(resolved, os.Stdin, os.Stderr) {
+		fail(errNotConfirmed)
+	}
+	copyPrompt(resolved)
+	if conf.WithAttachments {
+		printAttachments(paths)
+	}
+	recordUsage(history.ActionCopy, result)
+	typeIfEnabled(resolved)
+}
+
+// openBestMatch opens the source of the best match for query in the user's editor
+// at the prompt's line, writing the edits of a Simplenote note back once saved.
+func openBestMatch(prompts *prompt.PromptData, query, sectionToUse string) {
+	if err := prompt.OpenInEditor(conf, bestMatch(prompts, query, sectionToUse)); err != nil {
+		fail(err)
+	}
+}
+
+// copyPrompt copies p to the clipboard formatted by the copy transforms or --for, running
+// the copy hooks before and after.
+func copyPrompt(p prompt.Prompt) {
+	content, err := prompt.TransformCopy(conf, p)
+	if err != nil {
+		fail(err)
+	}
+	p.Content = content
+	if err := prompt.RunHook(conf, prompt.HookPreCopy, p); err != nil {
+		fail(err)
+	}
+	if err := prompt.CopyToClipboard(p.Content); err != nil {
+		fail(err)
+	}
+	_ = prompt.RunHook(conf, prompt.HookPostCopy, p)
+}
+
+// attachments returns the absolute paths of the files p attaches.
+func attachments(prompts *prompt.PromptData, p prompt.Prompt) []string {
+	paths, err := prompt.Attachments(conf, prompts, p)
+	if err != nil {
+		fail(err)
+	}
+	return paths
+}
+
+// printPromptWithAttachments prints p followed by its attachment paths, or as a
+// one-element JSON array including them with --output json.
+func printPromptWithAttachments(p prompt.Prompt, paths []string) {
+	if output == outputJSON {
+		jp := newJSONPrompt(p)
+		jp.Attachments = paths
+		out := []jsonPrompt{jp}
+		if err := json.NewEncoder(os.Stdout).Encode(out); err != nil {
+			fail(err)
+		}
+		return
+	}
+	printPrompts([]prompt.Prompt{p})
+	printAttachments(paths)
+}
+
+// printAttachments prints attachment paths one per line, or as a JSON array with --output json.
+func printAttachments(paths []string) {
+	if output == outputJSON {
+		if paths == nil {
+			paths = []string{}
+		}
+		if err := json.NewEncoder(os.Stdout).Encode(paths); err != nil {
+			fail(err)
+		}
+		return
+	}
+	for _, path := range paths {
+		fmt.Println(path)
+	}
+}
+
+// expandPrompt returns p with the prompts it references with @ref(Title) inlined
+// and, with TEMPLATES enabled, its template rendered.
+func expandPrompt(prompts *prompt.PromptData, p prompt.Prompt) prompt.Prompt {
+	content, err := prompt.ExpandPrompt(prompts, p.Content, conf)
+	if err != nil {
+		fail(err)
+	}
+	p.Content = content
+	return p
+}
+
+// listSection prints the prompts of sectionToUse.
+func listSection(prompts *prompt.PromptData, sectionToUse string) {
+	var results []prompt.Prompt
+	for _, p := range prompt.GetSectionPrompts(prompts, sectionToUse) {
+		results = append(results, prompt.Prompt{Content: p, Section: sectionToUse})
+	}
+	printPrompts(sortResults(results))
+}
+
+// sortResults orders results by SORT or --sort. Relevance keeps them as found.
+func sortResults(results []prompt.Prompt) []prompt.Prompt {
+	var added map[string]time.Time
+	if conf.Sort == prompt.SortRecent {
+		var err error
+		if added, err = prompt.AddedTimes(conf); err != nil {
+			fail(err)
+		}
+	}
+	return prompt.SortPrompts(results, conf.Sort, added)
+}
+
+// listSectionNames prints the heading path of every section, one per line.
+func listSectionNames(prompts *prompt.PromptData) {
+	var results []prompt.Prompt
+	for _, sec := range prompts.Sections {
+		// The first heading is the document title
+		if len(sec.Headings) < 2 {
+			continue
+		}
+		results = append(results, prompt.Prompt{Content: strings.Join(sec.Headings[1:], " > "), Namespace: sec.Namespace})
+	}
+	if output == outputJSON {
+		printPrompts(results)
+		return
+	}
+	for _, r := range results {
+		fmt.Println(r.Content)
+	}
+}
+
+// addPrompt adds content (or prompts for it when empty) to the prompt source. A
+// section may be given by --section or as the second argument.
+func addPrompt(content string, args []string) {
+	checkOutputFlag()
+	if err := prompt.CheckRequiredBinaries(conf); err != nil {
+		fail(err)
+	}
+	applyLoadFlag()
+
+	if onConflict != "" {
+		conf.OnConflict = onConflict
+	}
+	// --section names the target section, like the second positional argument
+	if section != "" {
+		args = []string{content, section}
+	}
+	if err := prompt.WritePrompt(conf, content, args); err != nil {
+		fail(err)
+	}
+}
+
+// firstArg returns args[0], or "" if there are no arguments.
+func firstArg(args []string) string {
+	if len(args) > 0 {
+		return args[0]
+	}
+	return ""
+}
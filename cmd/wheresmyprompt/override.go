@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/toozej/wheresmyprompt/internal/prompt"
+)
+
+var overrideCmd = &cobra.Command{
+	Use:   "override",
+	Short: "Override team prompts with your own version",
+	Long: `When a prompt of the team library does not fit, keep your own version of it
+without touching the team library. Overrides are linked to the team prompt by
+its title and stored in the personal library, one "### <title>" block each below
+the override section (OVERRIDE_SECTION, "Overrides" by default). In search
+results the override replaces the content of every team prompt with that title,
+badged [team+overridden].`,
+}
+
+var overrideSetCmd = &cobra.Command{
+	Use:   "set <title> <content>",
+	Short: "Override the team prompt with this title",
+	Long: `Write content as your version of the team prompts titled title, replacing any
+override of the title already set.`,
+	Args: cobra.ExactArgs(2),
+	Run:  overrideSetCmdRun,
+}
+
+var overrideClearCmd = &cobra.Command{
+	Use:   "clear <title>",
+	Short: "Remove an override, restoring the team prompt",
+	Args:  cobra.ExactArgs(1),
+	Run:   overrideClearCmdRun,
+}
+
+var overrideListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the overrides of team prompts",
+	Long: `List the overrides in the personal library. Overrides whose team prompt no
+longer exists are marked orphaned and show up as prompts of your own library.`,
+	Args: cobra.NoArgs,
+	Run:  overrideListCmdRun,
+}
+
+func overrideSetCmdRun(cmd *cobra.Command, args []string) {
+	checkOutputFlag()
+	if err := prompt.CheckRequiredBinaries(conf); err != nil {
+		fail(err)
+	}
+	if err := prompt.SetOverride(conf, args[0], args[1]); err != nil {
+		fail(err)
+	}
+	if output != outputJSON {
+		fmt.Printf("Overrode team prompt '%s'\n", args[0])
+	}
+}
+
+func overrideClearCmdRun(cmd *cobra.Command, args []string) {
+	checkOutputFlag()
+	if err := prompt.CheckRequiredBinaries(conf); err != nil {
+		fail(err)
+	}
+	if err := prompt.ClearOverride(conf, args[0]); err != nil {
+		fail(err)
+	}
+	if output != outputJSON {
+		fmt.Printf("Cleared the override of team prompt '%s'\n", args[0])
+	}
+}
+
+func overrideListCmdRun(cmd *cobra.Command, args []string) {
+	checkOutputFlag()
+	if err := prompt.CheckRequiredBinaries(conf); err != nil {
+		fail(err)
+	}
+	overrides, err := prompt.ListOverrides(conf)
+	if err != nil {
+		fail(err)
+	}
+	if output == outputJSON {
+		if overrides == nil {
+			overrides = []prompt.Override{}
+		}
+		encodePackJSON(overrides)
+		return
+	}
+	for _, o := range overrides {
+		line := o.Title + ": " + o.Content
+		if o.Orphaned {
+			line += " (orphaned)"
+		}
+		fmt.Println(line)
+	}
+	if len(overrides) == 0 {
+		fmt.Println("No overrides")
+	}
+}
+
+func init() {
+	overrideCmd.AddCommand(overrideSetCmd, overrideClearCmd, overrideListCmd)
+}
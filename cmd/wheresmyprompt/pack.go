@@ -0,0 +1,231 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/toozej/wheresmyprompt/internal/prompt"
+)
+
+var (
+	// packInsecure allows installing packs that are not signed with a trusted key
+	packInsecure bool
+	// packUntrust is the ID of a key pack trust stops trusting
+	packUntrust string
+)
+
+var packCmd = &cobra.Command{
+	Use:   "pack",
+	Short: "Install and manage curated prompt packs",
+	Long: `Prompt packs are curated collections of prompts, published as a Markdown file
+with optional front matter (name, version, description, author) or as a JSON
+bundle holding the same metadata and the Markdown in "content". Installed packs
+are kept in the packs directory (PACKS_DIR, or "packs" in the data directory)
+and searched alongside the library, each in its own "pack:<name>" namespace.
+Packs are never written to.
+
+Packs must be signed with a key trusted with "pack trust": with minisign, in
+a .minisig file next to the pack, or with ssh-keygen -Y sign -n wheresmyprompt,
+in a .sig file next to it. Unsigned packs are refused unless --insecure is
+given.`,
+}
+
+var packInstallCmd = &cobra.Command{
+	Use:   "install <url-or-name>",
+	Short: "Download and install a prompt pack",
+	Long: `Download the pack at an http(s) or s3 URL or a file path and install it,
+replacing an installed pack of the same name. A bare name is looked up in the
+JSON index at PACK_REGISTRY, which lists packs as
+{"packs": [{"name": "...", "url": "...", "description": "..."}]} with URLs
+relative to the index. The pack's signature is verified against the trusted
+keys first.`,
+	Args: cobra.ExactArgs(1),
+	Run:  packInstallCmdRun,
+}
+
+var packListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the installed prompt packs",
+	Args:  cobra.NoArgs,
+	Run:   packListCmdRun,
+}
+
+var packUpdateCmd = &cobra.Command{
+	Use:   "update [name...]",
+	Short: "Download the installed prompt packs again",
+	Long:  `Download the named packs, or every installed pack, again from where they were installed from.`,
+	Run:   packUpdateCmdRun,
+}
+
+var packTrustCmd = &cobra.Command{
+	Use:   "trust [key-or-file]",
+	Short: "Trust a key to sign prompt packs, or list the trusted keys",
+	Long: `Add a minisign public key, or an ssh-ed25519 public key, to the keys whose
+signatures are accepted on prompt packs. The key can be given as is or as the
+path of its .pub file. Without an argument, the trusted keys are listed; remove
+one with --remove and its ID.`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  packTrustCmdRun,
+}
+
+var packRemoveCmd = &cobra.Command{
+	Use:   "remove <name>",
+	Short: "Uninstall a prompt pack",
+	Args:  cobra.ExactArgs(1),
+	Run:   packRemoveCmdRun,
+}
+
+func packInstallCmdRun(cmd *cobra.Command, args []string) {
+	checkOutputFlag()
+	info, err := prompt.InstallPack(conf, args[0], packInsecure)
+	if err != nil {
+		fail(err)
+	}
+	if output == outputJSON {
+		encodePackJSON(info)
+		return
+	}
+	version := ""
+	if info.Version != "" {
+		version = " " + info.Version
+	}
+	fmt.Printf("Installed pack '%s'%s%s\n", info.Name, version, packSignerText(info))
+}
+
+func packListCmdRun(cmd *cobra.Command, args []string) {
+	checkOutputFlag()
+	packs, err := prompt.ListPacks(conf)
+	if err != nil {
+		fail(err)
+	}
+	if output == outputJSON {
+		if packs == nil {
+			packs = []prompt.PackInfo{}
+		}
+		encodePackJSON(packs)
+		return
+	}
+	for _, p := range packs {
+		line := p.Name
+		if p.Version != "" {
+			line += " " + p.Version
+		}
+		if p.Description != "" {
+			line += " - " + p.Description
+		}
+		fmt.Println(line + packSignerText(p))
+	}
+	if len(packs) == 0 {
+		fmt.Println("No packs installed")
+	}
+}
+
+func packUpdateCmdRun(cmd *cobra.Command, args []string) {
+	checkOutputFlag()
+	updates, err := prompt.UpdatePacks(conf, packInsecure, args...)
+	if err != nil {
+		fail(err)
+	}
+	if output == outputJSON {
+		if updates == nil {
+			updates = []prompt.PackUpdate{}
+		}
+		encodePackJSON(updates)
+		return
+	}
+	for _, u := range updates {
+		switch {
+		case !u.Changed:
+			fmt.Printf("Pack '%s' is up to date\n", u.Name)
+		case u.From != u.To:
+			fmt.Printf("Updated pack '%s' from %s to %s\n", u.Name, u.From, u.To)
+		default:
+			fmt.Printf("Updated pack '%s'\n", u.Name)
+		}
+	}
+	if len(updates) == 0 {
+		fmt.Println("No packs installed")
+	}
+}
+
+func packRemoveCmdRun(cmd *cobra.Command, args []string) {
+	checkOutputFlag()
+	if err := prompt.RemovePack(conf, args[0]); err != nil {
+		fail(err)
+	}
+	if output != outputJSON {
+		fmt.Printf("Removed pack '%s'\n", args[0])
+	}
+}
+
+func packTrustCmdRun(cmd *cobra.Command, args []string) {
+	checkOutputFlag()
+	switch {
+	case packUntrust != "" && len(args) > 0:
+		failWithCode(ExitUsage, fmt.Errorf("give either a key to trust or --remove"))
+	case packUntrust != "":
+		if err := prompt.UntrustPackKey(conf, packUntrust); err != nil {
+			fail(err)
+		}
+		if output != outputJSON {
+			fmt.Printf("Removed trusted key %s\n", packUntrust)
+		}
+	case len(args) == 1:
+		key, err := prompt.TrustPackKey(conf, args[0])
+		if err != nil {
+			fail(err)
+		}
+		if output == outputJSON {
+			encodePackJSON(key)
+			return
+		}
+		fmt.Printf("Trusted %s key %s\n", key.Type, key.ID)
+	default:
+		keys, err := prompt.PackKeys(conf)
+		if err != nil {
+			fail(err)
+		}
+		if output == outputJSON {
+			if keys == nil {
+				keys = []prompt.TrustedKey{}
+			}
+			encodePackJSON(keys)
+			return
+		}
+		for _, k := range keys {
+			line := k.Type + " " + k.ID
+			if k.Comment != "" {
+				line += " " + k.Comment
+			}
+			fmt.Println(line)
+		}
+		if len(keys) == 0 {
+			fmt.Println("No trusted keys")
+		}
+	}
+}
+
+// packSignerText describes who signed an installed pack, for display.
+func packSignerText(info prompt.PackInfo) string {
+	if info.Signer == "" {
+		return " (unsigned)"
+	}
+	return " (signed by " + info.Signer + ")"
+}
+
+// encodePackJSON writes v to stdout as JSON for --output json.
+func encodePackJSON(v any) {
+	if err := json.NewEncoder(os.Stdout).Encode(v); err != nil {
+		fail(err)
+	}
+}
+
+func init() {
+	packInstallCmd.Flags().BoolVar(&packInsecure, "insecure", false, "Install the pack even if it is not signed with a trusted key")
+	packUpdateCmd.Flags().BoolVar(&packInsecure, "insecure", false, "Update packs even if they are not signed with a trusted key")
+	packTrustCmd.Flags().StringVar(&packUntrust, "remove", "", "Stop trusting the key with this ID")
+	packCmd.AddCommand(packInstallCmd, packListCmd, packUpdateCmd, packRemoveCmd, packTrustCmd)
+}
@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/mattn/go-isatty"
+	"github.com/toozej/wheresmyprompt/internal/prompt"
+)
+
+const (
+	// pickMargin is how much worse than the best score a match may be and still be
+	// offered by the one-shot picker.
+	pickMargin = 1
+	// maxPickChoices caps the number of matches the one-shot picker offers.
+	maxPickChoices = 5
+	// pickPreviewWidth is how many characters of each prompt the picker shows.
+	pickPreviewWidth = 72
+)
+
+var (
+	// firstMatch skips the one-shot picker and always takes the best match
+	firstMatch bool
+	// minRelevance overrides conf.MinRelevance when --min-relevance is given
+	minRelevance float64
+	// confirmCopy shows the best match and asks before copying it in one-shot-clip mode
+	confirmCopy bool
+)
+
+// errNotConfirmed is reported when the user declines to copy the best match with
+// --confirm. It exits like errNoMatch.
+var errNotConfirmed = errors.New("not copied; the clipboard was left unchanged")
+
+// stdinIsTerminal reports whether standard input is a terminal rather than a pipe or file.
+func stdinIsTerminal() bool {
+	return isatty.IsTerminal(os.Stdin.Fd()) || isatty.IsCygwinTerminal(os.Stdin.Fd())
+}
+
+// bestMatch searches for query and returns the best match, exiting with ExitNoMatch
+// if there is none or errNoConfidentMatch if it is less relevant than conf.MinRelevance.
+// When several matches score nearly as well as the best one and stdin is a terminal,
+// the user picks one from a numbered list unless --first is set.
+func bestMatch(prompts *prompt.PromptData, query, sectionToUse string) prompt.Prompt {
+	matches, scored := searchMatches(prompts, query, sectionToUse)
+	if len(matches) == 0 {
+		fail(noMatchError(prompts, query))
+	}
+	if scored && matches[0].Relevance < conf.MinRelevance {
+		fail(fmt.Errorf("%w: the best match has relevance %.2f, below the minimum of %.2f (MIN_RELEVANCE)", errNoConfidentMatch, matches[0].Relevance, conf.MinRelevance))
+	}
+	candidates := closeMatches(matches)
+	if !scored || query == "" || firstMatch || len(candidates) < 2 || !stdinIsTerminal() {
+		return matches[0].Prompt
+	}
+	return pickMatch(candidates, os.Stdin, os.Stderr)
+}
+
+// closeMatches returns the leading matches scoring within pickMargin of the best
+// one, at most maxPickChoices. matches must be sorted best first.
+func closeMatches(matches []prompt.Match) []prompt.Match {
+	if len(matches) == 0 {
+		return nil
+	}
+	n := 1
+	for n < len(matches) && n < maxPickChoices && matches[n].Score-matches[0].Score <= pickMargin {
+		n++
+	}
+	return matches[:n]
+}
+
+// pickMatch lists candidates on out and reads the number of the chosen one from in.
+// An empty answer or end of input selects the first candidate; invalid answers are
+// asked again.
+func pickMatch(candidates []prompt.Match, in io.Reader, out io.Writer) prompt.Prompt {
+	fmt.Fprintln(out, "Several prompts match equally well:")
+	for i, c := range candidates {
+		fmt.Fprintf(out, "  %d) %s\n", i+1, pickPreview(c.Prompt))
+	}
+
+	reader := bufio.NewReader(in)
+	for {
+		fmt.Fprintf(out, "Select a prompt [1-%d] (default 1): ", len(candidates))
+		line, err := reader.ReadString('\n')
+		answer := strings.TrimSpace(line)
+		if answer == "" {
+			if err != nil {
+				fmt.Fprintln(out)
+			}
+			return candidates[0].Prompt
+		}
+		if choice, convErr := strconv.Atoi(answer); convErr == nil && choice >= 1 && choice <= len(candidates) {
+			return candidates[choice-1].Prompt
+		}
+		if err != nil {
+			fmt.Fprintln(out)
+			return candidates[0].Prompt
+		}
+		fmt.Fprintf(out, "Invalid choice %q\n", answer)
+	}
+}
+
+// pickPreview returns the section and a one-line, shortened version of p's content.
+func pickPreview(p prompt.Prompt) string {
+	content := strings.Join(strings.Fields(p.Content), " ")
+	if runes := []rune(content); len(runes) > pickPreviewWidth {
+		content = string(runes[:pickPreviewWidth-3]) + "..."
+	}
+	if p.Section == "" {
+		return content
+	}
+	return fmt.Sprintf("[%s] %s", p.Section, content)
+}
+
+// confirmMatch shows p on out and asks whether to copy it, reading the answer from
+// in. Only an answer starting with y or Y confirms; anything else, including an
+// empty answer or end of input, declines.
+func confirmMatch(p prompt.Prompt, in io.Reader, out io.Writer) bool {
+	if p.Section != "" {
+		fmt.Fprintf(out, "Best match in section '%s':\n", p.Section)
+	} else {
+		fmt.Fprintln(out, "Best match:")
+	}
+	fmt.Fprintf(out, "\n%s\n\nCopy to clipboard? [y/N] ", p.Content)
+	line, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && line == "" {
+		fmt.Fprintln(out)
+	}
+	answer := strings.TrimSpace(line)
+	return strings.HasPrefix(strings.ToLower(answer), "y")
+}
@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/toozej/wheresmyprompt/internal/prompt"
+)
+
+func testMatches(scores ...int) []prompt.Match {
+	matches := make([]prompt.Match, len(scores))
+	for i, score := range scores {
+		matches[i] = prompt.Match{Prompt: prompt.Prompt{Content: string(rune('a' + i))}, Score: score}
+	}
+	return matches
+}
+
+func TestCloseMatches(t *testing.T) {
+	tests := []struct {
+		name     string
+		scores   []int
+		expected int
+	}{
+		{name: "none", scores: nil, expected: 0},
+		{name: "single", scores: []int{2}, expected: 1},
+		{name: "clear winner", scores: []int{2, 5, 6}, expected: 1},
+		{name: "tie", scores: []int{2, 2, 9}, expected: 2},
+		{name: "within margin", scores: []int{2, 3, 4}, expected: 2},
+		{name: "capped", scores: []int{1, 1, 1, 1, 1, 1, 1}, expected: maxPickChoices},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := len(closeMatches(testMatches(tt.scores...))); got != tt.expected {
+				t.Errorf("closeMatches(%v) returned %d matches, want %d", tt.scores, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPickMatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "choice", input: "2\n", expected: "b"},
+		{name: "default", input: "\n", expected: "a"},
+		{name: "end of input", input: "", expected: "a"},
+		{name: "invalid then valid", input: "7\nx\n3\n", expected: "c"},
+		{name: "choice without newline", input: "3", expected: "c"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			got := pickMatch(testMatches(1, 1, 2), strings.NewReader(tt.input), &out)
+			if got.Content != tt.expected {
+				t.Errorf("pickMatch(%q) = %q, want %q", tt.input, got.Content, tt.expected)
+			}
+			if !strings.Contains(out.String(), "  3) c") {
+				t.Errorf("expected numbered candidates on output, got %q", out.String())
+			}
+		})
+	}
+}
+
+func TestConfirmMatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected bool
+	}{
+		{name: "yes", input: "y\n", expected: true},
+		{name: "full word", input: " Yes\n", expected: true},
+		{name: "no", input: "n\n", expected: false},
+		{name: "default", input: "\n", expected: false},
+		{name: "end of input", input: "", expected: false},
+		{name: "yes without newline", input: "y", expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			p := prompt.Prompt{Content: "Review this\ncode", Section: "Golang"}
+			if got := confirmMatch(p, strings.NewReader(tt.input), &out); got != tt.expected {
+				t.Errorf("confirmMatch(%q) = %v, want %v", tt.input, got, tt.expected)
+			}
+			if !strings.Contains(out.String(), "section 'Golang'") || !strings.Contains(out.String(), "Review this\ncode") {
+				t.Errorf("expected the full prompt and its section on output, got %q", out.String())
+			}
+		})
+	}
+}
+
+func TestPickPreview(t *testing.T) {
+	long := strings.Repeat("word ", 30)
+	tests := []struct {
+		name     string
+		prompt   prompt.Prompt
+		expected string
+	}{
+		{name: "section", prompt: prompt.Prompt{Content: "Write\ntests", Section: "Golang"}, expected: "[Golang] Write tests"},
+		{name: "no section", prompt: prompt.Prompt{Content: "Review"}, expected: "Review"},
+		{name: "truncated", prompt: prompt.Prompt{Content: long}, expected: long[:pickPreviewWidth-3] + "..."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pickPreview(tt.prompt); got != tt.expected {
+				t.Errorf("pickPreview() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNoConfidentMatchExitCode(t *testing.T) {
+	err := fmt.Errorf("%w: the best match has relevance 0.01", errNoConfidentMatch)
+	if code := exitCodeFor(err); code != ExitNoMatch {
+		t.Errorf("exitCodeFor(%v) = %d, want %d", err, code, ExitNoMatch)
+	}
+}
+
+func TestNotConfirmedExitCode(t *testing.T) {
+	if code := exitCodeFor(errNotConfirmed); code != ExitNoMatch {
+		t.Errorf("exitCodeFor(%v) = %d, want %d", errNotConfirmed, code, ExitNoMatch)
+	}
+}
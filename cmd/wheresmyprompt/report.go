@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/toozej/wheresmyprompt/internal/history"
+)
+
+var (
+	reportPeriod string
+	reportCSV    bool
+	reportScores bool
+)
+
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Summarize local prompt usage for the past week or month",
+	Long: `Summarize which prompts and sections were used, based on the local usage
+history recorded when ANALYTICS=true. Nothing is sent over the network.`,
+	Args: cobra.NoArgs,
+	Run:  reportCmdRun,
+}
+
+func reportCmdRun(cmd *cobra.Command, args []string) {
+	var since time.Time
+	switch reportPeriod {
+	case "week":
+		since = time.Now().AddDate(0, 0, -7)
+	case "month":
+		since = time.Now().AddDate(0, -1, 0)
+	default:
+		failWithCode(ExitUsage, fmt.Errorf("invalid --period %q (expected week or month)", reportPeriod))
+	}
+
+	if !conf.Analytics {
+		fmt.Fprintln(os.Stderr, "Note: usage is only recorded when ANALYTICS=true")
+	}
+
+	events, err := history.Load(conf, since)
+	if err != nil {
+		fail(err)
+	}
+	report := history.Summarize(events, since)
+	report.WithScores = reportScores || conf.ShowScores
+
+	if reportCSV {
+		err = report.WriteCSV(os.Stdout)
+	} else {
+		err = report.WriteTable(os.Stdout)
+	}
+	if err != nil {
+		fail(err)
+	}
+}
+
+func init() {
+	reportCmd.Flags().StringVarP(&reportPeriod, "period", "p", "week", "Reporting period: week or month")
+	reportCmd.Flags().BoolVar(&reportCSV, "csv", false, "Output the report as CSV")
+	reportCmd.Flags().BoolVar(&reportScores, "scores", false, "Add a prompt quality score column")
+}
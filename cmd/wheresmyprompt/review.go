@@ -0,0 +1,36 @@
+//go:build !nocli
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/toozej/wheresmyprompt/internal/prompt"
+	"github.com/toozej/wheresmyprompt/internal/tui"
+)
+
+var reviewCmd = &cobra.Command{
+	Use:   "review",
+	Short: "Accept or reject prompts waiting in the staging section",
+	Long: `Open an interactive review of prompts staged with STAGING=true. Accepting a
+prompt moves it into its target section, rejecting it removes it from the
+staging section (STAGING_SECTION, "Inbox" by default).`,
+	Args: cobra.NoArgs,
+	Run:  reviewCmdRun,
+}
+
+func reviewCmdRun(cmd *cobra.Command, args []string) {
+	if err := prompt.CheckRequiredBinaries(conf); err != nil {
+		fail(err)
+	}
+	applyLoadFlag()
+
+	logToFileOnly()
+	if err := tui.RunReviewTUI(conf); err != nil {
+		fail(err)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(reviewCmd)
+}
@@ -18,7 +18,7 @@
 //   - Interactive TUI mode and CLI mode operation
 //   - Clipboard integration for prompt copying
 //   - Section-based prompt organization
-//   - Debug logging support
+//   - Debug logging support, optionally to a rotating log file
 //
 // Example usage:
 //
@@ -30,14 +30,23 @@
 package cmd
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
+	"github.com/toozej/wheresmyprompt/internal/history"
+	"github.com/toozej/wheresmyprompt/internal/httpclient"
+	"github.com/toozej/wheresmyprompt/internal/llm"
+	"github.com/toozej/wheresmyprompt/internal/logging"
 	"github.com/toozej/wheresmyprompt/internal/prompt"
-	"github.com/toozej/wheresmyprompt/internal/tui"
+	"github.com/toozej/wheresmyprompt/internal/redact"
+	"github.com/toozej/wheresmyprompt/internal/sandbox"
+	"github.com/toozej/wheresmyprompt/internal/semantic"
 	"github.com/toozej/wheresmyprompt/pkg/config"
 	"github.com/toozej/wheresmyprompt/pkg/languaged"
 	"github.com/toozej/wheresmyprompt/pkg/man"
@@ -51,152 +60,447 @@ var (
 	// debug controls the logging level for the application.
 	// When true, debug-level logging is enabled through logrus.
 	debug bool
+	// logFile receives log output when LOG_FILE is set
+	logFile *logging.RotatingFile
 	// Command-line flags
-	all         bool
-	oneShot     bool
-	oneShotClip bool
-	section     string
-	write       string
-	load        string
+	all             bool
+	oneShot         bool
+	oneShotClip     bool
+	section         string
+	write           string
+	onConflict      string
+	load            string
+	archive         string
+	includeArchived bool
+	// titlesOnly restricts matching to prompt titles and section headings
+	titlesOnly bool
+	// stem also matches words sharing their English stem
+	stem bool
+	// typePrompt types the selected prompt into the focused window after selection
+	typePrompt bool
+	// defaultQuery pre-fills the TUI search box, overriding DEFAULT_QUERY
+	defaultQuery string
+	// allowShell lets prompt templates run shell commands with {{shell}}
+	allowShell bool
+	// withAttachments prints the paths of the files a selected prompt attaches
+	withAttachments bool
+	// noAutoSection disables choosing the section from the current directory's language
+	noAutoSection bool
+	// output selects text or json output for results and errors
+	output string
+	// semanticSearch ranks results by embedding similarity instead of fuzzy matching
+	semanticSearch bool
+	// sortOrder orders search results and listings, overriding SORT
+	sortOrder string
+	// force writes a Simplenote note even when it would shrink drastically
+	force bool
+	// offline reads network sources from their local caches
+	offline bool
+	// themeName selects the TUI colors, overriding THEME
+	themeName string
+	// fromClipboard searches for the keywords of the clipboard instead of a query argument
+	fromClipboard bool
+	// groupBy groups --all and search results under their section when set to "section"
+	groupBy string
+	// openPrompt opens the source of the best match in $EDITOR at its line
+	openPrompt bool
+	// copyTransforms are applied to copied prompts, overriding COPY_TRANSFORM
+	copyTransforms []string
+	// presetName formats copied and printed prompts for a destination
+	presetName string
 )
 
 var rootCmd = &cobra.Command{
-	Use:              "wheresmyprompt",
-	Short:            "Fuzzy search and manage LLM prompts from Markdown/Simplenote",
-	Long:             `A tool to fuzzy search, manage, and copy LLM prompts from a Markdown or Simplenote note`,
-	Args:             cobra.ArbitraryArgs,
+	Use:   "wheresmyprompt",
+	Short: "Fuzzy search and manage LLM prompts from Markdown/Simplenote",
+	Long: `A tool to fuzzy search, manage, and copy LLM prompts from a Markdown or Simplenote note
+
+Put a phrase in double quotes to match it exactly, as in
+wheresmyprompt '"unit tests" go', and prefix a query word or quoted phrase with
+"-" to leave out the prompts containing it, as in wheresmyprompt "review -security".
+Quote the query, or put -- before it, so the term is not read as a flag.
+Scope the search with section: and tag: (#hashtags in prompts), as in
+wheresmyprompt "section:golang tag:testing review"; section: replaces the
+section auto-detection.
+
+A query naming a command, such as review or list, runs the command; search for it
+with wheresmyprompt search review. It is still searched for when given with more
+words than the command takes or with a flag of the search, as in
+wheresmyprompt --all review.`,
+	Args:             validateRootArgs,
+	SilenceErrors:    true,
 	PersistentPreRun: rootCmdPreRun,
 	Run:              rootCmdRun,
 }
 
-func rootCmdRun(cmd *cobra.Command, args []string) {
-	// Check for required binaries
-	if err := prompt.CheckRequiredBinaries(conf); err != nil {
-		log.Fatal(err)
+// validateRootArgs checks combinations of arguments and flags that cobra's flag
+// groups cannot express. Mutually exclusive modes are enforced by flag groups in init.
+func validateRootArgs(cmd *cobra.Command, args []string) error {
+	if err := validateQuerySource(args); err != nil {
+		return err
+	}
+	if fromClipboard && (write != "" || archive != "") {
+		return errors.New("--from-clipboard only applies to searches, not --write or --archive")
+	}
+	if all && len(args) == 0 && !fromClipboard {
+		return errors.New(`--all requires a search term, e.g. wheresmyprompt --all "code review"`)
+	}
+	if openPrompt && len(args) == 0 && !fromClipboard {
+		return errors.New(`--open requires a search term, e.g. wheresmyprompt --open "code review"`)
+	}
+	if confirmCopy && !oneShotClip {
+		return errors.New("--confirm only applies to --one-shot-clip")
 	}
+	if onConflict != "" && write == "" {
+		return errors.New("--on-conflict only applies when adding a prompt with --write")
+	}
+	if groupBy != "" && !all {
+		return errors.New("--group-by only applies to --all")
+	}
+	if err := validateGroupBy(); err != nil {
+		return err
+	}
+	if write != "" {
+		return validateSectionArg(args)
+	}
+	return nil
+}
 
-	// Handle loading prompts from a local file, preferring command line flag over environment variable
-	if (conf.FilePath == "" && load != "") || (conf.FilePath != "" && load != "") {
-		conf.FilePath = load
+// validateSectionArg rejects a section given as the second argument that
+// contradicts --section when adding a prompt.
+func validateSectionArg(args []string) error {
+	if section != "" && len(args) > 1 && args[1] != section {
+		return fmt.Errorf("conflicting sections: --section %q and argument %q; pass only one", section, args[1])
 	}
+	return nil
+}
 
+func rootCmdRun(cmd *cobra.Command, args []string) {
 	// Handle write mode (adding new prompt)
 	if write != "" {
-		if err := prompt.WritePrompt(conf, write, args); err != nil {
-			log.Fatal(err)
-		}
+		addPrompt(write, args)
 		return
 	}
 
-	// Load prompts
-	prompts, err := prompt.LoadPrompts(conf)
-	if err != nil {
-		log.Fatal(err)
-	}
+	prompts := loadPromptsForSearch()
 
 	// Determine section to use: command-line flag or detected language
-	sectionToUse := section
 	// However do not auto-detect the section if --all is specified
-	// because that would be confusing (user might expect all sections to be searched).
-	if sectionToUse == "" && !all {
-		if cwd, err := os.Getwd(); err == nil {
-			lang, err := languaged.DetectPrimaryLanguage(cwd)
-			if err == nil && lang != "" {
-				sectionToUse = lang
-			}
-		}
+	// because that would be confusing (user might expect all sections to be searched),
+	// nor when the query picks its sections with section:.
+	query := firstArg(args)
+	if archive != "" {
+		query = archive
+	}
+	sectionToUse := resolveSection(!all && !prompt.QueryHasSection(query))
+	if output == outputText {
+		fmt.Println("Using section:", sectionToUse)
+	}
+	if fromClipboard {
+		args = []string{queryOrClipboard(prompts, args, sectionToUse)}
 	}
-	fmt.Println("Using section:", sectionToUse)
 
-	// Handle --all mode
-	if all {
-		if len(args) == 0 {
-			log.Fatal("--all mode requires a search term")
-		}
-		results := prompt.FindAllMatches(prompts, args[0], sectionToUse)
-		if len(results) == 0 {
-			fmt.Println("No matches found")
-			os.Exit(1)
-		}
-		for _, p := range results {
-			fmt.Printf("\n%s\n\n", p)
-		}
-		return
+	switch {
+	case archive != "":
+		archiveBestMatch(prompts, archive, sectionToUse)
+	case all:
+		printMatches(prompts, args[0], sectionToUse)
+	case oneShot:
+		printBestMatch(prompts, firstArg(args), sectionToUse)
+	case oneShotClip:
+		copyBestMatch(prompts, firstArg(args), sectionToUse)
+	case openPrompt:
+		openBestMatch(prompts, firstArg(args), sectionToUse)
+	case sectionToUse != "" && len(args) == 0:
+		listSection(prompts, sectionToUse)
+	case cmd.Flags().NFlag() > tuiFlagCount(cmd) || len(args) > 0:
+		// CLI mode - search and output to stdout
+		printPrompts(sortResults(searchPrompts(prompts, firstArg(args), sectionToUse)))
+	default:
+		runTUI(prompts)
 	}
+}
 
-	// Handle one-shot mode
-	if oneShot {
-		query := ""
-		if len(args) > 0 {
-			query = args[0]
+// tuiFlagCount counts the flags given that only configure the TUI, so they don't
+// switch a bare invocation to CLI mode.
+func tuiFlagCount(cmd *cobra.Command) int {
+	count := 0
+	for _, name := range []string{"default-query", "allow-shell", "with-attachments", "sort", "transform", "for"} {
+		if cmd.Flags().Changed(name) {
+			count++
 		}
-		result := prompt.FindBestMatch(prompts, query, sectionToUse)
-		if result == "" {
-			fmt.Println("No match found")
-			os.Exit(1)
-		}
-		fmt.Printf("\n%s\n\n", result)
-		return
 	}
+	return count
+}
 
-	// Handle one-shot-clip mode
-	if oneShotClip {
-		query := ""
-		if len(args) > 0 {
-			query = args[0]
-		}
-		result := prompt.FindBestMatch(prompts, query, sectionToUse)
-		if result == "" {
-			fmt.Println("No match found")
-			os.Exit(1)
-		}
-		if err := prompt.CopyToClipboard(result); err != nil {
-			log.Fatal("Failed to copy to clipboard: ", err)
-		}
+// archiveBestMatch moves the best match for query to the archive section.
+func archiveBestMatch(prompts *prompt.PromptData, query, sectionToUse string) {
+	results := searchPrompts(prompts, query, sectionToUse)
+	if len(results) == 0 {
+		fail(noMatchError(prompts, query))
+	}
+	if err := prompt.ArchivePrompt(conf, results[0]); err != nil {
+		fail(err)
+	}
+	if output == outputJSON {
+		printPrompts(results[:1])
+	} else {
+		fmt.Printf("Archived prompt to section '%s':\n\n%s\n", conf.ArchiveSection, results[0].Content)
+	}
+}
+
+// typeIfEnabled types p into the focused window when --type or TYPE_ON_SELECT is set.
+func typeIfEnabled(p prompt.Prompt) {
+	if !conf.TypeOnSelect {
 		return
 	}
+	if err := prompt.TypeText(p.Content, conf.TypeDelay); err != nil {
+		fail(err)
+	}
+}
+
+// jsonPrompt is the machine-readable form of a prompt written with --output json.
+type jsonPrompt struct {
+	Content     string   `json:"content"`
+	Section     string   `json:"section"`
+	Namespace   string   `json:"namespace,omitempty"`
+	Attachments []string `json:"attachments,omitempty"`
+	File        string   `json:"file,omitempty"`     // The local file the prompt was parsed from
+	Line        int      `json:"line,omitempty"`     // The 1-based line the prompt starts on
+	EndLine     int      `json:"end_line,omitempty"` // The 1-based line the prompt ends on
+}
+
+// newJSONPrompt returns the --output json form of p.
+func newJSONPrompt(p prompt.Prompt) jsonPrompt {
+	return jsonPrompt{Content: p.Content, Section: p.Section, Namespace: p.Namespace, File: p.SourceFile, Line: p.StartLine, EndLine: p.EndLine}
+}
 
-	// Handle section listing
-	if section := sectionToUse; section != "" && len(args) == 0 {
-		results := prompt.GetSectionPrompts(prompts, section)
-		for _, p := range results {
-			fmt.Printf("\n%s\n\n", p)
+// printPrompts writes prompts to stdout, separated by blank lines or as a JSON array with --output json.
+func printPrompts(prompts []prompt.Prompt) {
+	if output == outputJSON {
+		if err := json.NewEncoder(os.Stdout).Encode(jsonPrompts(prompts)); err != nil {
+			fail(err)
 		}
 		return
 	}
+	if conf.Preset != "" {
+		printWithPreset(prompts)
+		return
+	}
+	for _, p := range prompts {
+		fmt.Printf("\n%s\n\n", p.Content)
+	}
+}
 
-	// Handle CLI mode (any flags specified)
-	if cmd.Flags().NFlag() > 0 || len(args) > 0 {
-		// CLI mode - search and output to stdout
-		searchTerm := ""
-		if len(args) > 0 {
-			searchTerm = args[0]
-		}
-		results := prompt.SearchPrompts(prompts, searchTerm, sectionToUse)
-		for _, p := range results {
-			fmt.Printf("\n%s\n\n", p)
+// printWithPreset writes prompts formatted by the --for preset and joined with its separator.
+func printWithPreset(prompts []prompt.Prompt) {
+	preset, err := prompt.ResolvePreset(conf)
+	if err != nil {
+		fail(err)
+	}
+	text, err := preset.Join(prompts)
+	if err != nil {
+		fail(err)
+	}
+	fmt.Println(text)
+}
+
+// jsonPrompts converts prompts to their --output json form.
+func jsonPrompts(prompts []prompt.Prompt) []jsonPrompt {
+	out := make([]jsonPrompt, len(prompts))
+	for i, p := range prompts {
+		out[i] = newJSONPrompt(p)
+	}
+	return out
+}
+
+// applyLoadFlag points the configuration at the --load file when one was given,
+// preferring the command line flag over the FILEPATH environment variable.
+func applyLoadFlag() {
+	if load != "" {
+		conf.FilePath = load
+	}
+}
+
+// resolveSection returns the --section flag value, falling back to the primary
+// language of the current directory when autoDetect is true, no section was given
+// and auto-detection is not disabled by --no-auto-section or AUTO_SECTION=false.
+func resolveSection(autoDetect bool) string {
+	if section != "" || !autoDetect || noAutoSection || !conf.AutoSection {
+		return section
+	}
+	if cwd, err := os.Getwd(); err == nil {
+		lang, err := languaged.DetectPrimaryLanguageWith(cwd, languageDefinitions())
+		if err == nil && lang != "" && lang != "Unknown" {
+			return lang
 		}
-		return
 	}
+	return ""
+}
+
+// languageDefinitions returns the language definitions for section auto-detection,
+// honoring LANGUAGES_FILE and DETECT_MAX_FILE_SIZE.
+func languageDefinitions() *languaged.Definitions {
+	defs, err := languaged.LoadDefinitions(conf.LanguagesFile)
+	if err != nil {
+		log.Warn("Failed to load LANGUAGES_FILE, using built-in languages: ", err)
+		defs = languaged.DefaultDefinitions()
+	}
+	if conf.DetectMaxFileSize != 0 {
+		defs.MaxFileSize = conf.DetectMaxFileSize
+	}
+	return defs
+}
+
+// searchPrompts runs the fuzzy search, restricted to titles when --titles-only is set,
+// or the embedding-based search when --semantic is set.
+func searchPrompts(prompts *prompt.PromptData, query, section string) []prompt.Prompt {
+	matches, _ := searchMatches(prompts, query, section)
+	results := make([]prompt.Prompt, len(matches))
+	for i, m := range matches {
+		results[i] = m.Prompt
+	}
+	return results
+}
+
+// searchMatches searches like searchPrompts but also returns the score of each match.
+// scored is false for semantic search, whose results carry no fuzzy score. With
+// DEDUPE_RESULTS, prompts found in several libraries are merged into one result.
+func searchMatches(prompts *prompt.PromptData, query, section string) (matches []prompt.Match, scored bool) {
+	matches, scored = rankMatches(prompts, query, section)
+	if conf.DedupeResults {
+		matches = prompt.MergeDuplicateMatches(matches)
+	}
+	return matches, scored
+}
 
-	// Default: TUI mode
-	if err := tui.RunTUI(prompts, conf); err != nil {
-		log.Fatal(err)
+// rankMatches runs the search selected by --titles-only and --semantic.
+func rankMatches(prompts *prompt.PromptData, query, section string) (matches []prompt.Match, scored bool) {
+	if conf.TitlesOnly {
+		return prompt.SearchPromptTitleMatches(prompts, query, section), true
+	}
+	if !semanticSearch || query == "" {
+		return prompt.SearchPromptMatches(prompts, query, section), true
+	}
+	client, err := llm.NewClient(conf)
+	if err != nil {
+		failWithCode(ExitUsage, err)
+	}
+	results, err := semantic.Search(conf, client, prompt.SearchPromptRecords(prompts, "", section), query)
+	if err != nil {
+		fail(err)
+	}
+	matches = make([]prompt.Match, len(results))
+	for i, r := range results {
+		matches[i] = prompt.Match{Prompt: r}
+	}
+	return matches, false
+}
+
+// recordUsage appends a prompt usage event to the local history when analytics are enabled.
+// Failures are logged but never interrupt the command.
+func recordUsage(action string, p prompt.Prompt) {
+	if err := history.Record(conf, action, p.Section, p.Content); err != nil {
+		log.Warn("Failed to record prompt usage: ", err)
 	}
 }
 
 func rootCmdPreRun(cmd *cobra.Command, args []string) {
+	if sandbox.Disabled() {
+		conf.NoExec = true
+	}
+	if conf.NoExec {
+		sandbox.Disable()
+	}
+	redact.AddConfig(conf)
+	log.AddHook(redact.Hook{})
+	httpclient.Configure(conf)
 	if debug {
 		log.SetLevel(log.DebugLevel)
 	}
+	if conf.LogFile != "" {
+		f, err := logging.Open(conf)
+		if err != nil {
+			log.Warn("Failed to open log file, logging to stderr: ", err)
+			return
+		}
+		logFile = f
+		// With --debug, keep mirroring to stderr until a TUI takes over the screen
+		if debug {
+			log.SetOutput(io.MultiWriter(logFile, os.Stderr))
+		} else {
+			log.SetOutput(logFile)
+		}
+	}
+
+	validateConfig(cmd)
+}
+
+// noSourceCommands lists commands that never read the prompt source and so skip
+// configuration validation.
+var noSourceCommands = map[string]bool{
+	"version":     true,
+	"self-update": true,
+	"man":         true,
+	"help":        true,
+	"completion":  true,
+	"report":      true,
+	"detect":      true,
+	"bugreport":   true,
+}
+
+// validateConfig checks the configuration, with --load applied, before any command
+// that reads the prompt source runs, and exits with ExitUsage listing every problem.
+func validateConfig(cmd *cobra.Command) {
+	// Completion subcommands such as "completion bash" are checked by their parent
+	for c := cmd; c.HasParent(); c = c.Parent() {
+		if noSourceCommands[c.Name()] {
+			return
+		}
+	}
+	applyLoadFlag()
+	if cmd.Flags().Changed("min-relevance") {
+		conf.MinRelevance = minRelevance
+	}
+	if cmd.Flags().Changed("sort") {
+		conf.Sort = sortOrder
+	}
+	if cmd.Flags().Changed("theme") {
+		conf.Theme = themeName
+	}
+	if cmd.Flags().Changed("transform") {
+		conf.CopyTransform = copyTransforms
+	}
+	conf.Preset = presetName
+	if force {
+		conf.Force = true
+	}
+	if offline {
+		conf.Offline = true
+	}
+	if err := conf.Validate(); err != nil {
+		failWithCode(ExitUsage, fmt.Errorf("invalid configuration:\n%w", err))
+	}
+	if _, err := prompt.ResolvePreset(conf); err != nil {
+		failWithCode(ExitUsage, err)
+	}
+}
+
+// logToFileOnly stops mirroring log output to stderr before a TUI starts,
+// so that --debug messages do not draw over it. It is a no-op without LOG_FILE.
+func logToFileOnly() {
+	if logFile != nil {
+		log.SetOutput(logFile)
+	}
 }
 
 // Execute runs the root command and handles any execution errors.
-// This is the main entry point for the CLI application.
+// This is the main entry point for the CLI application. Errors returned by
+// cobra are invalid flags or arguments and exit with ExitUsage.
 func Execute() {
+	rootCmd.SetArgs(legacyQueryArgs(rootCmd, os.Args[1:]))
 	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err.Error())
-		os.Exit(1)
+		failWithCode(ExitUsage, err)
 	}
 }
 
@@ -206,16 +510,69 @@ func init() {
 
 	// Create rootCmd-level flags
 	rootCmd.PersistentFlags().BoolVarP(&debug, "debug", "d", false, "Enable debug-level logging")
+	rootCmd.PersistentFlags().StringVar(&output, "output", outputText, "Output format for results and errors: text or json")
 	rootCmd.Flags().BoolVarP(&all, "all", "a", false, "Show all fuzzy matches for the search term")
 	rootCmd.Flags().BoolVarP(&oneShot, "one-shot", "o", false, "Select best match and print to stdout")
 	rootCmd.Flags().BoolVarP(&oneShotClip, "one-shot-clip", "c", false, "Select best match and copy to clipboard")
-	rootCmd.Flags().StringVarP(&section, "section", "s", "", "Search within specific section")
+	rootCmd.Flags().BoolVar(&openPrompt, "open", false, "Open the prompt source in $EDITOR at the best match, writing Simplenote edits back on save")
+	rootCmd.Flags().StringVar(&groupBy, "group-by", "", "Group --all results under their section headings with counts: section")
+	rootCmd.Flags().BoolVar(&confirmCopy, "confirm", false, "Show the best match and ask before copying it (--one-shot-clip, when stdin is a terminal)")
+	rootCmd.Flags().BoolVar(&firstMatch, "first", false, "Take the best match without asking when several match equally well (one-shot modes)")
+	rootCmd.PersistentFlags().StringVarP(&section, "section", "s", "", "Search within specific section")
+	rootCmd.PersistentFlags().BoolVar(&noAutoSection, "no-auto-section", false, "Search all sections instead of the one matching the current directory's language")
+	rootCmd.Flags().StringVar(&archive, "archive", "", "Move the best match for the given query to the archive section")
+	rootCmd.PersistentFlags().BoolVar(&includeArchived, "include-archived", false, "Include archived prompts in searches")
+	rootCmd.PersistentFlags().BoolVar(&allowShell, "allow-shell", false, "Let prompt templates run shell commands with {{shell}} (requires TEMPLATES)")
+	rootCmd.PersistentFlags().BoolVar(&withAttachments, "with-attachments", false, "Also print the absolute paths of the files a selected prompt attaches")
+	rootCmd.PersistentFlags().StringSliceVar(&copyTransforms, "transform", nil, "Transforms applied in order to copied prompts: strip-markdown, one-line, code-block or json (default from COPY_TRANSFORM)")
+	rootCmd.PersistentFlags().StringVar(&presetName, "for", "", "Format copied and printed prompts for a destination: chatgpt, claude-code, slack or a preset of PRESETS_FILE")
+	rootCmd.PersistentFlags().BoolVar(&titlesOnly, "titles-only", false, "Match only prompt titles and section headings, not prompt bodies")
+	rootCmd.PersistentFlags().BoolVar(&fromClipboard, "from-clipboard", false, "Search for the keywords of the clipboard, such as a copied error message, instead of a query")
+	rootCmd.PersistentFlags().BoolVar(&stem, "stem", false, "Also match words sharing their English stem, so \"testing\" matches \"tests\" (default from STEMMING)")
+	rootCmd.PersistentFlags().Float64Var(&minRelevance, "min-relevance", 0, "Minimum relevance (0-1) of the best match in one-shot modes (default from MIN_RELEVANCE)")
+	rootCmd.PersistentFlags().StringVar(&sortOrder, "sort", "", "Order of search results and listings: relevance, alpha, section, length or recent (default from SORT)")
+	rootCmd.PersistentFlags().BoolVar(&force, "force", false, "Write a Simplenote note even when the new content is less than half as long")
+	rootCmd.PersistentFlags().StringVar(&themeName, "theme", "", "Colors of the interactive screens: default, high-contrast or colorblind (default from THEME)")
+	rootCmd.PersistentFlags().BoolVar(&offline, "offline", false, "Read network sources from their local caches instead of the network (default from OFFLINE)")
+	rootCmd.PersistentFlags().BoolVar(&semanticSearch, "semantic", false, "Rank matches by embedding similarity (requires LLM_BASE_URL)")
+	rootCmd.Flags().BoolVar(&typePrompt, "type", false, "Also type the selected prompt into the focused window (xdotool, wtype or osascript)")
+	rootCmd.Flags().StringVar(&defaultQuery, "default-query", "", "Pre-fill the interactive search box with this query (default from DEFAULT_QUERY)")
 	rootCmd.Flags().StringVarP(&write, "write", "w", "", "Add new prompt to note")
-	rootCmd.Flags().StringVarP(&load, "load", "l", "", "Load a local file of prompts instead of from Simplenote")
+	rootCmd.Flags().StringVar(&onConflict, "on-conflict", "", "How to handle an existing prompt title when writing: replace, rename or abort (default: ask)")
+	rootCmd.PersistentFlags().StringVarP(&load, "load", "l", "", "Load a local file of prompts instead of from Simplenote")
+
+	// Only one mode may be selected per invocation
+	rootCmd.MarkFlagsMutuallyExclusive("all", "one-shot", "one-shot-clip", "open", "write", "archive")
+	rootCmd.MarkFlagsMutuallyExclusive("titles-only", "semantic")
+	rootCmd.MarkFlagsMutuallyExclusive("transform", "for")
 
 	// Add sub-commands
 	rootCmd.AddCommand(
 		man.NewManCmd(),
 		version.Command(),
+		version.UpdateCommand(),
+		shareCmd,
+		reportCmd,
+		scoreCmd,
+		improveCmd,
+		fmtCmd,
+		lintCmd,
+		exportCmd,
+		undoCmd,
+		diffCmd,
+		syncSourcesCmd,
+		serveCmd,
+		searchCmd,
+		copyCmd,
+		addCmd,
+		listCmd,
+		detectCmd,
+		dedupeSectionsCmd,
+		packCmd,
+		guideCmd,
+		snapshotCmd,
+		restoreCmd,
+		overrideCmd,
+		bugreportCmd,
 	)
 }
@@ -0,0 +1,300 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/toozej/wheresmyprompt/internal/prompt"
+)
+
+func TestSubcommandsRegistered(t *testing.T) {
+	for _, name := range []string{"search", "copy", "add", "list", "tui", "detect", "dedupe-sections", "guide"} {
+		cmd, _, err := rootCmd.Find([]string{name})
+		if err != nil || cmd.Name() != name {
+			t.Errorf("expected subcommand %q to be registered, got %v (%v)", name, cmd.Name(), err)
+		}
+	}
+}
+
+func TestSubcommandArgs(t *testing.T) {
+	tests := []struct {
+		name       string
+		command    []string
+		args       []string
+		sectionArg string
+		wantErr    bool
+	}{
+		{"search without query", []string{"search"}, nil, "", false},
+		{"search with query", []string{"search"}, []string{"unit tests"}, "", false},
+		{"search with two queries", []string{"search"}, []string{"a", "b"}, "", true},
+		{"copy with query", []string{"copy"}, []string{"review"}, "", false},
+		{"list takes no arguments", []string{"list"}, []string{"Golang"}, "", true},
+		{"tui takes no arguments", []string{"tui"}, []string{"x"}, "", true},
+		{"add with content and section", []string{"add"}, []string{"Write tests", "Golang"}, "", false},
+		{"add with matching --section", []string{"add"}, []string{"Write tests", "Golang"}, "Golang", false},
+		{"add with conflicting --section", []string{"add"}, []string{"Write tests", "Golang"}, "Python", true},
+		{"add with too many arguments", []string{"add"}, []string{"a", "b", "c"}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orig := section
+			defer func() { section = orig }()
+			section = tt.sectionArg
+
+			cmd, _, err := rootCmd.Find(tt.command)
+			if err != nil {
+				t.Fatalf("Find(%v) error = %v", tt.command, err)
+			}
+			err = cmd.ValidateArgs(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateArgs(%v) error = %v, wantErr %v", tt.args, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateRootArgs(t *testing.T) {
+	tests := []struct {
+		name       string
+		all        bool
+		open       bool
+		clip       bool
+		confirm    bool
+		write      string
+		onConflict string
+		section    string
+		args       []string
+		wantErr    bool
+	}{
+		{"bare invocation", false, false, false, false, "", "", "", nil, false},
+		{"search term", false, false, false, false, "", "", "", []string{"review"}, false},
+		{"--all with term", true, false, false, false, "", "", "", []string{"review"}, false},
+		{"--all without term", true, false, false, false, "", "", "", nil, true},
+		{"--open with term", false, true, false, false, "", "", "", []string{"review"}, false},
+		{"--open without term", false, true, false, false, "", "", "", nil, true},
+		{"--on-conflict without --write", false, false, false, false, "", "rename", "", nil, true},
+		{"--write with --on-conflict", false, false, false, false, "content", "rename", "", nil, false},
+		{"--confirm with --one-shot-clip", false, false, true, true, "", "", "", []string{"review"}, false},
+		{"--confirm without --one-shot-clip", false, false, false, true, "", "", "", []string{"review"}, true},
+		{"--write with conflicting --section", false, false, false, false, "content", "", "Golang", []string{"x", "Python"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			origAll, origOpen, origClip, origConfirm, origWrite, origOnConflict, origSection := all, openPrompt, oneShotClip, confirmCopy, write, onConflict, section
+			defer func() {
+				all, openPrompt, oneShotClip, confirmCopy, write, onConflict, section = origAll, origOpen, origClip, origConfirm, origWrite, origOnConflict, origSection
+			}()
+			all, openPrompt, oneShotClip, confirmCopy, write, onConflict, section = tt.all, tt.open, tt.clip, tt.confirm, tt.write, tt.onConflict, tt.section
+
+			err := validateRootArgs(rootCmd, tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateRootArgs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfirmMerges(t *testing.T) {
+	groups := []prompt.SectionGroup{
+		{Names: []string{"Testing", "Tests"}, Prompts: []int{2, 1}, Target: "Testing"},
+		{Names: []string{"Golang", "golang"}, Prompts: []int{1, 1}, Target: "Golang"},
+		{Names: []string{"Review", "Reviews"}, Prompts: []int{1, 1}, Target: "Review"},
+	}
+
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{name: "accept all", input: "\n\n\n", expected: []string{"Testing", "Golang", "Review"}},
+		{name: "skip and rename", input: "n\nGo\nNO\n", expected: []string{"Go"}},
+		{name: "end of input skips the rest", input: "\n", expected: []string{"Testing"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			merges := confirmMerges(groups, strings.NewReader(tt.input), &out)
+			var targets []string
+			for _, m := range merges {
+				targets = append(targets, m.Target)
+			}
+			if !slices.Equal(targets, tt.expected) {
+				t.Errorf("confirmMerges(%q) merged into %q, want %q", tt.input, targets, tt.expected)
+			}
+			if !strings.Contains(out.String(), "  Testing (2 prompt(s))") {
+				t.Errorf("expected the group to be listed, got %q", out.String())
+			}
+		})
+	}
+	if groups[1].Target != "Golang" {
+		t.Error("expected confirmMerges not to modify the proposed groups")
+	}
+}
+
+func TestNoMatchError(t *testing.T) {
+	data := &prompt.PromptData{Sections: []prompt.Section{
+		{Headings: []string{"Prompts", "Golang", "Code Review Checklist"}, Lines: []string{"Check error handling"}},
+	}}
+
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{query: "code reviw", expected: "no match found. Did you mean: 'Code Review Checklist' in section Golang?"},
+		{query: "kubernetes", expected: "no match found"},
+	}
+	for _, tt := range tests {
+		err := noMatchError(data, tt.query)
+		if !errors.Is(err, errNoMatch) || exitCodeFor(err) != ExitNoMatch {
+			t.Errorf("noMatchError(%q) = %v, want it to exit like errNoMatch", tt.query, err)
+		}
+		if err.Error() != tt.expected {
+			t.Errorf("noMatchError(%q) = %q, want %q", tt.query, err.Error(), tt.expected)
+		}
+	}
+}
+
+func TestValidateGroupBy(t *testing.T) {
+	tests := []struct {
+		name    string
+		all     bool
+		groupBy string
+		wantErr bool
+	}{
+		{"no grouping", false, "", false},
+		{"--all grouped by section", true, "section", false},
+		{"unknown grouping", true, "tag", true},
+		{"grouping without --all", false, "section", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			origAll, origGroupBy := all, groupBy
+			defer func() { all, groupBy = origAll, origGroupBy }()
+			all, groupBy = tt.all, tt.groupBy
+
+			err := validateRootArgs(rootCmd, []string{"review"})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateRootArgs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGroupPrompts(t *testing.T) {
+	results := []prompt.Prompt{
+		{Content: "Review Go code", Section: "Golang", Title: "Prompts > Golang"},
+		{Content: "Write Go tests", Section: "Tests", Title: "Prompts > Golang > Tests"},
+		{Content: "Review Python code", Section: "Python", Title: "Prompts > Python"},
+		{Content: "Review Go errors", Section: "Golang", Title: "Prompts > Golang"},
+		{Content: "Team Go review", Section: "Golang", Title: "Prompts > Golang", Namespace: "team"},
+		{Content: "Untitled", Section: "Misc"},
+	}
+	want := []struct {
+		section  string
+		contents []string
+	}{
+		{"Golang", []string{"Review Go code", "Review Go errors"}},
+		{"Golang > Tests", []string{"Write Go tests"}},
+		{"Python", []string{"Review Python code"}},
+		{"[team] Golang", []string{"Team Go review"}},
+		{"Misc", []string{"Untitled"}},
+	}
+
+	groups := groupPrompts(results)
+	if len(groups) != len(want) {
+		t.Fatalf("groupPrompts() returned %d groups, want %d: %v", len(groups), len(want), groups)
+	}
+	for i, g := range groups {
+		if g.Section != want[i].section {
+			t.Errorf("group %d section = %q, want %q", i, g.Section, want[i].section)
+		}
+		var contents []string
+		for _, p := range g.Prompts {
+			contents = append(contents, p.Content)
+		}
+		if !slices.Equal(contents, want[i].contents) {
+			t.Errorf("group %q = %v, want %v", g.Section, contents, want[i].contents)
+		}
+	}
+}
+
+func TestClipboardQuery(t *testing.T) {
+	data := &prompt.PromptData{Sections: []prompt.Section{
+		{Headings: []string{"Prompts", "Debugging"}, Lines: []string{"Explain this panic and how to fix the index error", "Find the cause of this nil pointer dereference"}},
+	}}
+	tests := []struct {
+		name     string
+		text     string
+		expected string
+		wantErr  error
+	}{
+		{"every keyword matches", "panic: index error", "panic index error", nil},
+		{"keywords finding nothing dropped", "panic: runtime error: index out of range [5] with length 3", "panic error index", nil},
+		{"nothing matches", "xylophone jukebox", "xylophone jukebox", nil},
+		{"no keywords", "42 0x1f", "", errEmptyClipboard},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := clipboardQuery(data, tt.text, "")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("clipboardQuery() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.expected {
+				t.Errorf("clipboardQuery() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestValidateQuerySource(t *testing.T) {
+	orig := fromClipboard
+	defer func() { fromClipboard = orig }()
+
+	fromClipboard = true
+	if err := validateRootArgs(rootCmd, []string{"review"}); err == nil {
+		t.Error("validateRootArgs() accepted a query with --from-clipboard")
+	}
+	if err := validateRootArgs(rootCmd, nil); err != nil {
+		t.Errorf("validateRootArgs() error = %v, want nil", err)
+	}
+	origAll := all
+	defer func() { all = origAll }()
+	all = true
+	if err := validateRootArgs(rootCmd, nil); err != nil {
+		t.Errorf("validateRootArgs() with --all error = %v, want nil", err)
+	}
+}
+
+func TestLegacyQueryArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{"plain query", []string{"code review"}, []string{"code review"}},
+		{"subcommand", []string{"list"}, []string{"list"}},
+		{"subcommand with its flags", []string{"search", "--best", "-s", "Golang", "review"}, []string{"search", "--best", "-s", "Golang", "review"}},
+		{"subcommand with persistent flag", []string{"review", "--output", "json"}, []string{"review", "--output", "json"}},
+		{"root flag before query", []string{"--all", "review", "--output", "json"}, []string{"--all", "--output", "json", "--", "review"}},
+		{"root shorthand flag", []string{"-o", "list"}, []string{"-o", "--", "list"}},
+		{"root flag with value", []string{"review", "--write=Check this", "-s", "Golang"}, []string{"--write=Check this", "-s", "Golang", "--", "review"}},
+		{"more words than the command takes", []string{"review", "go", "code"}, []string{"--", "review", "go", "code"}},
+		{"words after --", []string{"-a", "fmt", "--", "-security"}, []string{"-a", "--", "fmt", "-security"}},
+		{"command taking the words", []string{"add", "Write tests", "Golang"}, []string{"add", "Write tests", "Golang"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := legacyQueryArgs(rootCmd, tt.args); !slices.Equal(got, tt.want) {
+				t.Errorf("legacyQueryArgs(%q) = %q, want %q", tt.args, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/toozej/wheresmyprompt/internal/prompt"
+)
+
+var (
+	scoreThreshold int
+	scoreAll       bool
+)
+
+var scoreCmd = &cobra.Command{
+	Use:   "score",
+	Short: "Rate prompt quality and list prompts worth improving",
+	Long: `Heuristically rate every prompt (role, constraints, output format, examples,
+length and vague wording) and report the prompts scoring below the threshold,
+lowest first, with suggestions on how to improve them.`,
+	Args: cobra.NoArgs,
+	Run:  scoreCmdRun,
+}
+
+func scoreCmdRun(cmd *cobra.Command, args []string) {
+	if err := prompt.CheckRequiredBinaries(conf); err != nil {
+		fail(err)
+	}
+	applyLoadFlag()
+
+	prompts, err := prompt.LoadPrompts(conf)
+	if err != nil {
+		fail(err)
+	}
+
+	type scored struct {
+		prompt prompt.Prompt
+		score  prompt.Score
+	}
+	var results []scored
+	for _, p := range prompt.SearchPromptRecords(prompts, "", section) {
+		s := prompt.ScorePrompt(p.Content)
+		if scoreAll || s.Total < scoreThreshold {
+			results = append(results, scored{prompt: p, score: s})
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].score.Total < results[j].score.Total
+	})
+
+	if len(results) == 0 {
+		fmt.Printf("All prompts score at least %d\n", scoreThreshold)
+		return
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SCORE\tSECTION\tPROMPT\tSUGGESTIONS")
+	for _, r := range results {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", r.score.Total, r.prompt.Section, scoreExcerpt(r.prompt.Content), strings.Join(r.score.Suggestions, "; "))
+	}
+	if err := tw.Flush(); err != nil {
+		fail(err)
+	}
+}
+
+// scoreExcerpt returns content on one line, shortened to at most 50 runes so a
+// character is never split, for the PROMPT column of the score table.
+func scoreExcerpt(content string) string {
+	content = strings.Join(strings.Fields(content), " ")
+	runes := []rune(content)
+	if len(runes) <= 50 {
+		return content
+	}
+	return string(runes[:47]) + "..."
+}
+
+func init() {
+	scoreCmd.Flags().IntVarP(&scoreThreshold, "threshold", "t", 50, "Report prompts scoring below this value (0-100)")
+	scoreCmd.Flags().BoolVarP(&scoreAll, "all", "a", false, "Report every prompt regardless of score")
+}
@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestScoreExcerpt(t *testing.T) {
+	content := "Überprüfe diesen Code:\n\tachte auf Fehlerbehandlung\nund erkläre jede Änderung auf Japanisch: 日本語で説明してください"
+	got := scoreExcerpt(content)
+	if !utf8.ValidString(got) {
+		t.Fatalf("scoreExcerpt() split a character: %q", got)
+	}
+	if strings.ContainsAny(got, "\n\t") {
+		t.Errorf("scoreExcerpt() = %q, want a single line", got)
+	}
+	if n := utf8.RuneCountInString(got); n != 50 || !strings.HasSuffix(got, "...") {
+		t.Errorf("scoreExcerpt() = %q (%d runes), want 50 runes ending in an ellipsis", got, n)
+	}
+	if got := scoreExcerpt("Review\nthis code"); got != "Review this code" {
+		t.Errorf("scoreExcerpt() = %q, want a short prompt whole on one line", got)
+	}
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"errors"
+
+	"github.com/spf13/cobra"
+	"github.com/toozej/wheresmyprompt/internal/prompt"
+)
+
+// searchBest prints only the best match instead of every match
+var searchBest bool
+
+var searchCmd = &cobra.Command{
+	Use:   "search [query]",
+	Short: "Print prompts matching a fuzzy search",
+	Long: `Print every prompt matching the query, best match first, or only the best
+match with --best. The search is limited to --section, or to the primary
+language of the current directory when no section is given. Exits with code 1
+if nothing matches.
+
+Put a phrase in double quotes to require it exactly, while the other words are
+still fuzzy matched, and prefix a word or quoted phrase with "-" to exclude the
+prompts containing it. section:NAME keeps the prompts under a heading starting
+with NAME, instead of the auto-detected section, and tag:NAME those with a
+#hashtag starting with NAME; both can be excluded with "-" too:
+
+  wheresmyprompt search '"unit tests" go'
+  wheresmyprompt search "review -security"
+  wheresmyprompt search -- -security
+  wheresmyprompt search "section:golang tag:testing review"`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  searchCmdRun,
+}
+
+func searchCmdRun(cmd *cobra.Command, args []string) {
+	if err := validateGroupBy(); err != nil {
+		failWithCode(ExitUsage, err)
+	}
+	if groupBy != "" && searchBest {
+		failWithCode(ExitUsage, errors.New("--group-by does not apply to --best"))
+	}
+	if err := validateQuerySource(args); err != nil {
+		failWithCode(ExitUsage, err)
+	}
+	prompts := loadPromptsForSearch()
+	sectionToUse := resolveSection(!prompt.QueryHasSection(firstArg(args)))
+	query := queryOrClipboard(prompts, args, sectionToUse)
+	if searchBest {
+		printBestMatch(prompts, query, sectionToUse)
+		return
+	}
+	printMatches(prompts, query, sectionToUse)
+}
+
+func init() {
+	searchCmd.Flags().BoolVarP(&searchBest, "best", "b", false, "Print only the best match")
+	searchCmd.Flags().StringVar(&groupBy, "group-by", "", "Group results under their section headings with counts: section")
+	searchCmd.Flags().BoolVar(&firstMatch, "first", false, "Take the best match without asking when several match equally well (with --best)")
+	searchCmd.Flags().BoolVar(&typePrompt, "type", false, "Also type the best match into the focused window (with --best)")
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"context"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"github.com/toozej/wheresmyprompt/internal/prompt"
+	"github.com/toozej/wheresmyprompt/internal/server"
+)
+
+var serveAddr string
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve prompt search over HTTP with Prometheus metrics",
+	Long: `Run a long-lived HTTP server over the prompt library for editor plugins and
+scripts. It exposes /search, /prompts (POST to add a prompt, with the
+SERVE_TOKEN bearer token and a JSON body), /healthz and a Prometheus /metrics
+endpoint with load durations, search latency, prompt and section counts,
+search cache hit rate and write counters. The prompt source is reloaded every
+RELOAD_INTERVAL.`,
+	Args: cobra.NoArgs,
+	Run:  serveCmdRun,
+}
+
+func serveCmdRun(cmd *cobra.Command, args []string) {
+	if err := prompt.CheckRequiredBinaries(conf); err != nil {
+		fail(err)
+	}
+	applyLoadFlag()
+	if serveAddr != "" {
+		conf.ServeAddr = serveAddr
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if err := server.New(conf).Run(ctx); err != nil {
+		fail(err)
+	}
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&serveAddr, "addr", "", "Address to listen on (default from SERVE_ADDR, 127.0.0.1:8765)")
+}
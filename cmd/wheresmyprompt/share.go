@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/toozej/wheresmyprompt/internal/prompt"
+	"github.com/toozej/wheresmyprompt/internal/share"
+)
+
+var shareCmd = &cobra.Command{
+	Use:   "share <query>",
+	Short: "Upload the best matching prompt to a paste service and copy its URL",
+	Long: `Find the best match for the query, upload it to the configured paste service
+(a secret GitHub gist or a self-hosted endpoint, see SHARE_PROVIDER), print the
+resulting URL and copy it to the clipboard.
+
+Secret gists are unlisted but permanent: anyone with the link can read the prompt
+until you delete the gist on GitHub, and SHARE_EXPIRY does not apply to them. For
+links that expire, use a paste endpoint with SHARE_EXPIRY (24h by default).`,
+	Args: cobra.MinimumNArgs(1),
+	Run:  shareCmdRun,
+}
+
+func shareCmdRun(cmd *cobra.Command, args []string) {
+	if err := prompt.CheckRequiredBinaries(conf); err != nil {
+		fail(err)
+	}
+	applyLoadFlag()
+
+	uploader, err := share.NewUploader(conf)
+	if err != nil {
+		failWithCode(ExitUsage, err)
+	}
+
+	prompts, err := prompt.LoadPrompts(conf)
+	if err != nil {
+		fail(err)
+	}
+
+	query := strings.Join(args, " ")
+	result := prompt.FindBestMatch(prompts, query, resolveSection(!prompt.QueryHasSection(query)))
+	if result == "" {
+		fail(noMatchError(prompts, query))
+	}
+
+	url, err := uploader.Upload(result)
+	if err != nil {
+		fail(err)
+	}
+	fmt.Println(url)
+
+	if err := prompt.CopyToClipboard(url); err != nil {
+		log.Warn("Failed to copy URL to clipboard: ", err)
+	}
+}
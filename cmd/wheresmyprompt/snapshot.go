@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/toozej/wheresmyprompt/internal/prompt"
+)
+
+// snapshotList lists the snapshots instead of taking one
+var snapshotList bool
+
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "Save the whole library to a timestamped snapshot",
+	Long: `Save the whole library into a timestamped zip archive in the "snapshots"
+directory of the data directory: the personal library, every WRITE_ROUTES
+target, the team library and the log of when prompts were added, with a
+manifest.json describing them. Take one before a big curation session and roll
+everything back at once with "restore". Snapshots are independent of the
+backups taken before each Simplenote write, which undo steps through. With
+--list, the snapshots taken so far are listed instead.`,
+	Args: cobra.NoArgs,
+	Run:  snapshotCmdRun,
+}
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore <snapshot>",
+	Short: "Restore the library from a snapshot",
+	Long: `Write the personal library, the WRITE_ROUTES targets and the log of when
+prompts were added back from a snapshot taken with "snapshot", named by its
+file name in the snapshots directory (with or without .zip) or by its path.
+Each source is replaced like any other write, so a Simplenote note is backed
+up first and the restore can be undone with "undo". The team library is never
+written to.`,
+	Args: cobra.ExactArgs(1),
+	Run:  restoreCmdRun,
+}
+
+func init() {
+	snapshotCmd.Flags().BoolVar(&snapshotList, "list", false, "List the snapshots taken so far, oldest first")
+}
+
+func snapshotCmdRun(cmd *cobra.Command, args []string) {
+	checkOutputFlag()
+	if snapshotList {
+		listSnapshots()
+		return
+	}
+	if err := prompt.CheckRequiredBinaries(conf); err != nil {
+		fail(err)
+	}
+	info, err := prompt.CreateSnapshot(conf)
+	if err != nil {
+		fail(err)
+	}
+	if output == outputJSON {
+		encodePackJSON(info)
+		return
+	}
+	fmt.Printf("Saved snapshot %s of %s with %d prompt(s)\n", info.Name, info.Source, info.Prompts)
+}
+
+// listSnapshots prints the snapshots in the snapshots directory.
+func listSnapshots() {
+	snapshots, err := prompt.ListSnapshots(conf)
+	if err != nil {
+		fail(err)
+	}
+	if output == outputJSON {
+		if snapshots == nil {
+			snapshots = []prompt.SnapshotInfo{}
+		}
+		encodePackJSON(snapshots)
+		return
+	}
+	for _, s := range snapshots {
+		fmt.Printf("%s  %s  %s, %d prompt(s)\n", s.Name, s.Created.Local().Format("2006-01-02 15:04:05"), s.Source, s.Prompts)
+	}
+	if len(snapshots) == 0 {
+		fmt.Println("No snapshots taken")
+	}
+}
+
+func restoreCmdRun(cmd *cobra.Command, args []string) {
+	checkOutputFlag()
+	if err := prompt.CheckRequiredBinaries(conf); err != nil {
+		fail(err)
+	}
+	info, err := prompt.RestoreSnapshot(conf, args[0])
+	if err != nil {
+		fail(err)
+	}
+	if output == outputJSON {
+		encodePackJSON(info)
+		return
+	}
+	fmt.Printf("Restored snapshot %s taken %s\n", info.Name, info.Created.Local().Format("2006-01-02 15:04:05"))
+	for _, d := range info.Documents {
+		if d.Role == prompt.SnapshotTeam {
+			fmt.Printf("Skipped the team library %s, which is never written to\n", d.Target)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/toozej/wheresmyprompt/internal/prompt"
+)
+
+var syncStrategy string
+
+var syncSourcesCmd = &cobra.Command{
+	Use:   "sync-sources",
+	Short: "Merge changes between the local prompts file and the Simplenote note",
+	Long: `Merge the changes made to the local prompts file (FILEPATH or --load) and to
+the Simplenote note (SN_NOTE) since they were last synced, and write the result
+to both, so prompts can be edited offline in a file and pushed to Simplenote
+later. Sections (headings and the lines below them) changed on one side only
+take that change, including deletions. A section changed on both sides is a
+conflict, resolved with --strategy:
+
+  newest       keep the side changed most recently; the note's time is read
+               from sncli's local database (SN_DB_PATH)
+  local        keep the local file's version
+  remote       keep the Simplenote version
+  interactive  show both versions and ask
+
+The state after each sync is kept in the "sync" directory of the data
+directory. On the first sync, sections found on one side only are kept.`,
+	Args: cobra.NoArgs,
+	Run:  syncSourcesCmdRun,
+}
+
+func init() {
+	syncSourcesCmd.Flags().StringVar(&syncStrategy, "strategy", prompt.SyncNewest, "How to resolve sections changed on both sides: newest, local, remote or interactive")
+}
+
+func syncSourcesCmdRun(cmd *cobra.Command, args []string) {
+	applyLoadFlag()
+	switch syncStrategy {
+	case prompt.SyncNewest, prompt.SyncLocal, prompt.SyncRemote, prompt.SyncInteractive:
+	default:
+		failWithCode(ExitUsage, fmt.Errorf("invalid --strategy %q: must be %s, %s, %s or %s", syncStrategy,
+			prompt.SyncNewest, prompt.SyncLocal, prompt.SyncRemote, prompt.SyncInteractive))
+	}
+	if conf.FilePath == "" {
+		failWithCode(ExitUsage, fmt.Errorf("sync-sources needs a local file: set FILEPATH or use --load"))
+	}
+	noteConf := conf
+	noteConf.FilePath = ""
+	if err := prompt.CheckRequiredBinaries(noteConf); err != nil {
+		fail(err)
+	}
+
+	result, err := prompt.SyncSources(conf, syncStrategy)
+	if err != nil {
+		fail(err)
+	}
+	switch {
+	case result.LocalChanged && result.RemoteChanged:
+		fmt.Printf("Merged %s and note '%s'\n", conf.FilePath, conf.SNNote)
+	case result.LocalChanged:
+		fmt.Printf("Updated %s from note '%s'\n", conf.FilePath, conf.SNNote)
+	case result.RemoteChanged:
+		fmt.Printf("Updated note '%s' from %s\n", conf.SNNote, conf.FilePath)
+	default:
+		fmt.Println("Already in sync")
+	}
+	if result.Conflicts > 0 {
+		fmt.Printf("Resolved %d conflicting section(s) with strategy %s\n", result.Conflicts, syncStrategy)
+	}
+}
@@ -0,0 +1,48 @@
+//go:build !nocli
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/toozej/wheresmyprompt/internal/prompt"
+	"github.com/toozej/wheresmyprompt/internal/tui"
+)
+
+// This file and review.go hold the interactive commands, left out of builds with
+// -tags nocli so that the TUI libraries are not linked in, see tui_nocli.go.
+
+var tuiCmd = &cobra.Command{
+	Use:   "tui",
+	Short: "Search prompts interactively (the default without arguments)",
+	Long: `Start the interactive search. Selecting a prompt copies it to the clipboard;
+see the key bindings shown at the bottom of the screen. Running wheresmyprompt
+without arguments or flags does the same.`,
+	Args: cobra.NoArgs,
+	Run:  tuiCmdRun,
+}
+
+func tuiCmdRun(cmd *cobra.Command, args []string) {
+	runTUI(loadPromptsForSearch())
+}
+
+// runTUI starts the interactive search, pre-filled with the clipboard's keywords
+// with --from-clipboard.
+func runTUI(prompts *prompt.PromptData) {
+	if defaultQuery != "" {
+		conf.DefaultQuery = defaultQuery
+	}
+	if fromClipboard {
+		conf.DefaultQuery = queryOrClipboard(prompts, nil, "")
+	}
+	logToFileOnly()
+	if err := tui.RunTUI(prompts, conf); err != nil {
+		fail(err)
+	}
+}
+
+func init() {
+	tuiCmd.Flags().BoolVar(&typePrompt, "type", false, "Also type the selected prompt into the focused window (xdotool, wtype or osascript)")
+	tuiCmd.Flags().StringVar(&defaultQuery, "default-query", "", "Pre-fill the search box with this query (default from DEFAULT_QUERY)")
+	rootCmd.AddCommand(tuiCmd)
+}
@@ -0,0 +1,35 @@
+//go:build nocli
+
+package cmd
+
+import (
+	"errors"
+
+	"github.com/spf13/cobra"
+
+	"github.com/toozej/wheresmyprompt/internal/prompt"
+)
+
+// errNoTUI is returned where the interactive search would start in builds with
+// -tags nocli, which leave out the TUI and its libraries.
+var errNoTUI = errors.New(`this build has no interactive search; pass a query, e.g. wheresmyprompt "code review", or use the search, copy and list commands`)
+
+// runTUI fails with errNoTUI.
+func runTUI(*prompt.PromptData) {
+	failWithCode(ExitUsage, errNoTUI)
+}
+
+func init() {
+	// Keep the interactive commands so they fail clearly instead of being read as a query
+	for _, name := range []string{"tui", "review"} {
+		rootCmd.AddCommand(&cobra.Command{
+			Use:    name,
+			Short:  "Not included in this build (nocli)",
+			Hidden: true,
+			Args:   cobra.NoArgs,
+			Run: func(cmd *cobra.Command, args []string) {
+				failWithCode(ExitUsage, errNoTUI)
+			},
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/toozej/wheresmyprompt/internal/prompt"
+)
+
+var undoCmd = &cobra.Command{
+	Use:   "undo",
+	Short: "Restore the Simplenote note to before its most recent write",
+	Long: `Before every write to Simplenote, the note is saved to the backup directory
+(BACKUP_DIR, or "backups" in the data directory) and the write is recorded in
+writes.jsonl in the data directory with its time, action, title, section and
+note. undo restores the note from the snapshot taken before the most recent
+write that has not been undone yet; running it again steps further back. The
+content it replaces is backed up too.`,
+	Args: cobra.NoArgs,
+	Run:  undoCmdRun,
+}
+
+func undoCmdRun(cmd *cobra.Command, args []string) {
+	if err := prompt.CheckRequiredBinaries(conf); err != nil {
+		fail(err)
+	}
+	applyLoadFlag()
+
+	undone, err := prompt.UndoLastWrite(conf)
+	if err != nil {
+		fail(err)
+	}
+	what := undone.Action
+	if undone.Title != "" {
+		what += fmt.Sprintf(" '%s'", undone.Title)
+	}
+	if undone.Section != "" {
+		what += fmt.Sprintf(" in section '%s'", undone.Section)
+	}
+	fmt.Printf("Undid %s from %s, restoring note '%s'\n", what, undone.Time.Local().Format("2006-01-02 15:04:05"), undone.Source)
+}
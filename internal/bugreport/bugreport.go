@@ -0,0 +1,233 @@
+// Package bugreport collects the diagnostics attached to bug reports: the version
+// and platform, the shape of the configuration, the parse warnings of the prompt
+// libraries and the end of the debug log. Nothing is sent anywhere; the report is
+// written to a file for the user to review and attach to an issue.
+package bugreport
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/toozej/wheresmyprompt/internal/logging"
+	"github.com/toozej/wheresmyprompt/internal/prompt"
+	"github.com/toozej/wheresmyprompt/internal/redact"
+	"github.com/toozej/wheresmyprompt/pkg/config"
+	"github.com/toozej/wheresmyprompt/pkg/version"
+)
+
+// secretSettings hold credentials, shown only as redact.Mask when set.
+var secretSettings = map[string]bool{
+	"SN_CREDENTIAL":        true,
+	"SN_USERNAME":          true,
+	"SN_PASSWORD":          true,
+	"JOPLIN_TOKEN":         true,
+	"GOOGLE_CLIENT_ID":     true,
+	"GOOGLE_CLIENT_SECRET": true,
+	"SERVE_TOKEN":          true,
+	"LLM_API_KEY":          true,
+	"SHARE_TOKEN":          true,
+}
+
+// shownSettings are text settings chosen from a fixed set of values, shown as they
+// are. Other text settings, such as paths, note names and commands, are only shown
+// as set, since they may identify the user.
+var shownSettings = map[string]bool{
+	"SECRET_PROVIDER":     true,
+	"SOURCE":              true,
+	"ON_CONFLICT":         true,
+	"SORT":                true,
+	"COPY_TRANSFORM":      true,
+	"SHARE_PROVIDER":      true,
+	"LLM_MODEL":           true,
+	"LLM_EMBEDDING_MODEL": true,
+}
+
+// Setting is a configuration setting and its value as shown in the report.
+type Setting struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Report holds the diagnostics of a bug report.
+type Report struct {
+	Created        time.Time            `json:"created"`
+	Version        version.Info         `json:"version"`
+	GoVersion      string               `json:"go_version"`
+	Platform       string               `json:"platform"` // GOOS/GOARCH
+	Settings       []Setting            `json:"settings"`
+	ConfigProblems []string             `json:"config_problems,omitempty"`
+	Warnings       []prompt.LintWarning `json:"warnings,omitempty"`
+	WarningsError  string               `json:"warnings_error,omitempty"` // Why the libraries could not be parsed
+	Log            []string             `json:"log,omitempty"`
+	LogNote        string               `json:"log_note,omitempty"` // Why there is no log
+}
+
+// Collect gathers the diagnostics for conf, with the last logLines lines of the
+// LOG_FILE. Every text taken from the libraries, the log or errors is redacted, the
+// home directory is shortened to "~" and the libraries are named by file name only.
+func Collect(conf config.Config, logLines int) Report {
+	info, _ := version.Get()
+	r := Report{
+		Created:   time.Now(),
+		Version:   info,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+		Settings:  Settings(conf),
+	}
+
+	if err := conf.Validate(); err != nil {
+		for problem := range strings.SplitSeq(err.Error(), "\n") {
+			r.ConfigProblems = append(r.ConfigProblems, scrub(problem))
+		}
+	}
+
+	warnings, err := prompt.Lint(conf)
+	if err != nil {
+		r.WarningsError = scrub(err.Error())
+	}
+	for _, w := range warnings {
+		// The file name is enough to tell the personal and team libraries apart
+		if filepath.IsAbs(w.Source) {
+			w.Source = filepath.Base(w.Source)
+		}
+		w.Source = scrub(w.Source)
+		w.Message = scrub(w.Message)
+		r.Warnings = append(r.Warnings, w)
+	}
+
+	if conf.LogFile == "" {
+		r.LogNote = "LOG_FILE is not set"
+	} else {
+		lines, err := logging.Tail(conf, logLines)
+		if err != nil {
+			r.LogNote = scrub(err.Error())
+		}
+		for _, line := range lines {
+			r.Log = append(r.Log, scrub(line))
+		}
+		if err == nil && len(lines) == 0 {
+			r.LogNote = "the log file is empty"
+		}
+	}
+	return r
+}
+
+// Settings returns the settings of conf that are not unset, false or 0, in the
+// order of config.Config, named after their environment variables. Secrets are
+// masked, and text settings other than shownSettings are only reported as set.
+func Settings(conf config.Config) []Setting {
+	var settings []Setting
+	v := reflect.ValueOf(conf)
+	for i := range v.NumField() {
+		name, _, _ := strings.Cut(v.Type().Field(i).Tag.Get("env"), ",")
+		field := v.Field(i)
+		if name == "" || field.IsZero() {
+			continue
+		}
+		var value string
+		switch {
+		case secretSettings[name]:
+			value = redact.Mask
+		case field.Kind() == reflect.String && shownSettings[name]:
+			value = field.String()
+		case field.Kind() == reflect.String:
+			value = describeText(field.String())
+		case field.Kind() == reflect.Slice && shownSettings[name]:
+			value = strings.Join(field.Interface().([]string), ",")
+		case field.Kind() == reflect.Slice || field.Kind() == reflect.Map:
+			value = fmt.Sprintf("%d entries", field.Len())
+		default:
+			value = fmt.Sprint(field.Interface())
+		}
+		settings = append(settings, Setting{Name: name, Value: value})
+	}
+	return settings
+}
+
+// describeText describes a text setting without its value: "set", or the scheme of
+// a URL such as "set (s3 URL)".
+func describeText(s string) string {
+	if u, err := url.Parse(s); err == nil && u.Scheme != "" && u.Host != "" {
+		return "set (" + u.Scheme + " URL)"
+	}
+	return "set"
+}
+
+// scrub redacts s and shortens the home directory in it to "~".
+func scrub(s string) string {
+	s = redact.String(s)
+	if home, err := os.UserHomeDir(); err == nil && len(home) > 1 {
+		s = strings.ReplaceAll(s, home, "~")
+	}
+	return s
+}
+
+// WriteMarkdown writes the report as Markdown, ready to paste into a GitHub issue.
+func (r Report) WriteMarkdown(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("# wheresmyprompt bug report\n\n")
+	fmt.Fprintf(&b, "Created %s. Secrets are redacted, the home directory is shortened to ~ and text settings such as paths and note names are only shown as set, but review the report before attaching it to an issue.\n\n", r.Created.Format(time.RFC3339))
+
+	b.WriteString("## Version\n\n")
+	fmt.Fprintf(&b, "- Version: %s\n", orNone(r.Version.Version))
+	fmt.Fprintf(&b, "- Commit: %s\n", orNone(r.Version.Commit))
+	fmt.Fprintf(&b, "- Built: %s by %s\n", orNone(r.Version.BuiltAt), orNone(r.Version.Builder))
+	fmt.Fprintf(&b, "- Go: %s\n", r.GoVersion)
+	fmt.Fprintf(&b, "- Platform: %s\n\n", r.Platform)
+
+	b.WriteString("## Configuration\n\n")
+	b.WriteString("Settings not listed are unset, false or 0.\n\n")
+	if len(r.Settings) > 0 {
+		b.WriteString("| Setting | Value |\n| --- | --- |\n")
+		for _, s := range r.Settings {
+			fmt.Fprintf(&b, "| `%s` | %s |\n", s.Name, strings.ReplaceAll(s.Value, "|", `\|`))
+		}
+		b.WriteString("\n")
+	}
+	if len(r.ConfigProblems) > 0 {
+		b.WriteString("Problems:\n\n")
+		for _, p := range r.ConfigProblems {
+			fmt.Fprintf(&b, "- %s\n", p)
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("## Parse warnings\n\n")
+	switch {
+	case r.WarningsError != "":
+		fmt.Fprintf(&b, "The libraries could not be parsed: %s\n\n", r.WarningsError)
+	case len(r.Warnings) == 0:
+		b.WriteString("None\n\n")
+	default:
+		for _, w := range r.Warnings {
+			fmt.Fprintf(&b, "- %s:%d: %s\n", w.Source, w.Line, w.Message)
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("## Log\n\n")
+	if r.LogNote != "" {
+		fmt.Fprintf(&b, "No log: %s. Set LOG_FILE and reproduce the problem with --debug to include one.\n", r.LogNote)
+	}
+	if len(r.Log) > 0 {
+		fmt.Fprintf(&b, "The last %d line(s) of LOG_FILE:\n\n```text\n%s\n```\n", len(r.Log), strings.Join(r.Log, "\n"))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// orNone returns s, or "unknown" if it is empty.
+func orNone(s string) string {
+	if s == "" {
+		return "unknown"
+	}
+	return s
+}
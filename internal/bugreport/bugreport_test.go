@@ -0,0 +1,93 @@
+package bugreport
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/toozej/wheresmyprompt/internal/redact"
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+func TestSettings(t *testing.T) {
+	conf := config.Config{
+		SNPassword:    "hunter2hunter2",
+		FilePath:      "s3://bucket/prompts.md",
+		TeamFilePath:  "/home/jane/team.md",
+		Sort:          "alpha",
+		CopyTransform: []string{"one-line", "json"},
+		StopWords:     []string{"the", "a"},
+		SectionIcons:  map[string]string{"Go": "🐹"},
+		HTTPRetries:   3,
+		AutoSection:   true,
+	}
+	got := make(map[string]string)
+	for _, s := range Settings(conf) {
+		got[s.Name] = s.Value
+	}
+
+	want := map[string]string{
+		"SN_PASSWORD":    redact.Mask,
+		"FILEPATH":       "set (s3 URL)",
+		"TEAM_FILEPATH":  "set",
+		"SORT":           "alpha",
+		"COPY_TRANSFORM": "one-line,json",
+		"STOP_WORDS":     "2 entries",
+		"SECTION_ICONS":  "1 entries",
+		"HTTP_RETRIES":   "3",
+		"AUTO_SECTION":   "true",
+	}
+	for name, value := range want {
+		if got[name] != value {
+			t.Errorf("%s = %q, want %q", name, got[name], value)
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("expected only the settings that are set, got %v", got)
+	}
+}
+
+func TestCollect(t *testing.T) {
+	dir := t.TempDir()
+	library := filepath.Join(dir, "prompts.md")
+	if err := os.WriteFile(library, []byte("# Prompts\n\n### Skipped level\nA prompt\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	log := "level=debug msg=\"loading\"\nlevel=debug msg=\"token=abc123secret\"\nlevel=warn msg=last\n"
+	if err := os.WriteFile(filepath.Join(dir, "test.log"), []byte(log), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	conf := config.Config{FilePath: library, DataDir: dir, LogFile: "test.log", MinRelevance: 2}
+	r := Collect(conf, 2)
+	if len(r.Warnings) == 0 {
+		t.Error("expected the parse warning of the skipped heading level")
+	}
+	if len(r.ConfigProblems) != 1 || !strings.Contains(r.ConfigProblems[0], "MIN_RELEVANCE") {
+		t.Errorf("ConfigProblems = %q, want the invalid MIN_RELEVANCE", r.ConfigProblems)
+	}
+	if len(r.Log) != 2 || strings.Contains(strings.Join(r.Log, "\n"), "abc123secret") {
+		t.Errorf("Log = %q, want the last 2 lines redacted", r.Log)
+	}
+
+	var b strings.Builder
+	if err := r.WriteMarkdown(&b); err != nil {
+		t.Fatalf("WriteMarkdown() error = %v", err)
+	}
+	for _, want := range []string{"## Version", "| `FILEPATH` | set |", "## Parse warnings", "MIN_RELEVANCE", "msg=last"} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("expected the report to contain %q:\n%s", want, b.String())
+		}
+	}
+	if strings.Contains(b.String(), library) {
+		t.Error("expected the library path to be left out of the configuration")
+	}
+}
+
+func TestCollect_NoLog(t *testing.T) {
+	r := Collect(config.Config{FilePath: filepath.Join(t.TempDir(), "missing.md")}, 10)
+	if r.LogNote == "" || r.WarningsError == "" {
+		t.Errorf("expected notes on the missing log and library, got %+v", r)
+	}
+}
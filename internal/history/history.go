@@ -0,0 +1,108 @@
+// Package history provides an opt-in, purely local record of prompt usage.
+// Each time a prompt is copied or printed an event is appended to a JSON Lines
+// file in the data directory, which the report command summarizes.
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+// fileName is the name of the history file inside the data directory.
+const fileName = "history.jsonl"
+
+// Actions recorded in usage events.
+const (
+	ActionCopy  = "copy"
+	ActionPrint = "print"
+)
+
+// now allows tests to control event timestamps.
+var now = time.Now
+
+// Event is a single recorded use of a prompt.
+type Event struct {
+	Time    time.Time `json:"time"`
+	Action  string    `json:"action"`
+	Section string    `json:"section"`
+	Prompt  string    `json:"prompt"`
+}
+
+// Path returns the location of the history file for the given configuration.
+func Path(conf config.Config) (string, error) {
+	dir, err := config.ResolveDataDir(conf)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, fileName), nil
+}
+
+// Record appends a usage event to the history file when analytics are enabled.
+// It is a no-op when ANALYTICS is not set.
+func Record(conf config.Config, action, section, prompt string) error {
+	if !conf.Analytics {
+		return nil
+	}
+	path, err := Path(conf)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create data directory: %w", err)
+	}
+
+	line, err := json.Marshal(Event{Time: now().UTC(), Action: action, Section: section, Prompt: prompt})
+	if err != nil {
+		return fmt.Errorf("failed to marshal history event: %w", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600) // #nosec G304
+	if err != nil {
+		return fmt.Errorf("failed to open history file: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write history file: %w", err)
+	}
+	return nil
+}
+
+// Load reads all events recorded at or after since.
+// A missing history file yields no events and no error; malformed lines are skipped.
+func Load(conf config.Config, since time.Time) ([]Event, error) {
+	path, err := Path(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path) // #nosec G304
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history file: %w", err)
+	}
+	defer f.Close()
+
+	var events []Event
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var e Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+		if !e.Time.Before(since) {
+			events = append(events, e)
+		}
+	}
+	return events, scanner.Err()
+}
@@ -0,0 +1,97 @@
+package history
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+func TestRecordAndLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	originalNow := now
+	defer func() { now = originalNow }()
+	base := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+
+	disabled := config.Config{DataDir: dir}
+	if err := Record(disabled, ActionCopy, "Golang", "Review this code"); err != nil {
+		t.Fatalf("Record() returned error: %v", err)
+	}
+	events, err := Load(disabled, time.Time{})
+	if err != nil {
+		t.Fatalf("Load() returned error: %v", err)
+	}
+	if len(events) != 0 {
+		t.Fatalf("expected no events when analytics are disabled, got %d", len(events))
+	}
+
+	conf := config.Config{DataDir: dir, Analytics: true}
+	now = func() time.Time { return base.AddDate(0, 0, -30) }
+	_ = Record(conf, ActionCopy, "Golang", "Old prompt")
+	now = func() time.Time { return base }
+	_ = Record(conf, ActionPrint, "Golang", "Review this code")
+
+	events, err = Load(conf, base.AddDate(0, 0, -7))
+	if err != nil {
+		t.Fatalf("Load() returned error: %v", err)
+	}
+	if len(events) != 1 || events[0].Prompt != "Review this code" || events[0].Action != ActionPrint {
+		t.Errorf("unexpected events: %+v", events)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	base := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+	events := []Event{
+		{Time: base, Section: "Golang", Prompt: "Review this code"},
+		{Time: base.Add(time.Hour), Section: "Golang", Prompt: "Review this code"},
+		{Time: base.Add(2 * time.Hour), Section: "Python", Prompt: "Optimize this"},
+		{Time: base.Add(3 * time.Hour), Section: "", Prompt: "No section"},
+	}
+
+	report := Summarize(events, base)
+	if len(report.Prompts) != 3 {
+		t.Fatalf("expected 3 prompt rows, got %d", len(report.Prompts))
+	}
+	if report.Prompts[0].Name != "Review this code" || report.Prompts[0].Count != 2 {
+		t.Errorf("expected most used prompt first, got %+v", report.Prompts[0])
+	}
+	if !report.Prompts[0].LastUsed.Equal(base.Add(time.Hour)) {
+		t.Errorf("expected last used to be the latest event, got %v", report.Prompts[0].LastUsed)
+	}
+	if report.Sections[0].Name != "Golang" || report.Sections[0].Count != 2 {
+		t.Errorf("expected Golang section first, got %+v", report.Sections[0])
+	}
+	if report.Sections[1].Name != "(none)" {
+		t.Errorf("expected empty section to be reported as (none), got %+v", report.Sections[1])
+	}
+
+	var csvOut bytes.Buffer
+	if err := report.WriteCSV(&csvOut); err != nil {
+		t.Fatalf("WriteCSV() returned error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(csvOut.String()), "\n")
+	if len(lines) != 7 || lines[0] != "kind,name,section,count,last_used" {
+		t.Errorf("unexpected CSV output:\n%s", csvOut.String())
+	}
+
+	report.WithScores = true
+	csvOut.Reset()
+	if err := report.WriteCSV(&csvOut); err != nil {
+		t.Fatalf("WriteCSV() returned error: %v", err)
+	}
+	if !strings.HasPrefix(csvOut.String(), "kind,name,section,count,last_used,score\n") {
+		t.Errorf("expected score column in CSV header, got:\n%s", csvOut.String())
+	}
+
+	var table bytes.Buffer
+	if err := report.WriteTable(&table); err != nil {
+		t.Fatalf("WriteTable() returned error: %v", err)
+	}
+	if !strings.Contains(table.String(), "Review this code") {
+		t.Errorf("expected table to contain prompt, got:\n%s", table.String())
+	}
+}
@@ -0,0 +1,156 @@
+package history
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/toozej/wheresmyprompt/internal/prompt"
+)
+
+// Usage summarizes how often a prompt or section was used.
+type Usage struct {
+	Kind     string // "prompt" or "section"
+	Name     string // Prompt content or section name
+	Section  string // Section of the prompt (empty for section rows)
+	Count    int
+	LastUsed time.Time
+}
+
+// Report is a usage summary for a period.
+type Report struct {
+	Since    time.Time
+	Prompts  []Usage
+	Sections []Usage
+
+	// WithScores adds the heuristic quality score of each prompt as a column.
+	WithScores bool
+}
+
+// Summarize aggregates events into per-prompt and per-section usage,
+// each sorted by descending count and then by most recent use.
+func Summarize(events []Event, since time.Time) Report {
+	prompts := map[string]*Usage{}
+	sections := map[string]*Usage{}
+
+	add := func(m map[string]*Usage, key string, u Usage, t time.Time) {
+		existing, ok := m[key]
+		if !ok {
+			existing = &u
+			m[key] = existing
+		}
+		existing.Count++
+		if t.After(existing.LastUsed) {
+			existing.LastUsed = t
+		}
+	}
+
+	for _, e := range events {
+		add(prompts, e.Section+"\x00"+e.Prompt, Usage{Kind: "prompt", Name: e.Prompt, Section: e.Section}, e.Time)
+		section := e.Section
+		if section == "" {
+			section = "(none)"
+		}
+		add(sections, section, Usage{Kind: "section", Name: section}, e.Time)
+	}
+
+	return Report{
+		Since:    since,
+		Prompts:  sortedUsage(prompts),
+		Sections: sortedUsage(sections),
+	}
+}
+
+// sortedUsage flattens m into a slice ordered by count, recency and name.
+func sortedUsage(m map[string]*Usage) []Usage {
+	out := make([]Usage, 0, len(m))
+	for _, u := range m {
+		out = append(out, *u)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		if !out[i].LastUsed.Equal(out[j].LastUsed) {
+			return out[i].LastUsed.After(out[j].LastUsed)
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+// WriteTable renders the report as aligned plain-text tables.
+// Prompt content is truncated to keep rows on one line.
+func (r Report) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "Usage since %s\n\n", r.Since.Format("2006-01-02"))
+	if len(r.Prompts) == 0 {
+		fmt.Fprintln(tw, "No prompt usage recorded.")
+		return tw.Flush()
+	}
+
+	fmt.Fprintln(tw, "SECTION\tUSES\tLAST USED")
+	for _, u := range r.Sections {
+		fmt.Fprintf(tw, "%s\t%d\t%s\n", u.Name, u.Count, u.LastUsed.Local().Format("2006-01-02 15:04"))
+	}
+
+	fmt.Fprintln(tw)
+	if r.WithScores {
+		fmt.Fprintln(tw, "PROMPT\tSECTION\tUSES\tLAST USED\tSCORE")
+	} else {
+		fmt.Fprintln(tw, "PROMPT\tSECTION\tUSES\tLAST USED")
+	}
+	for _, u := range r.Prompts {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s", truncate(u.Name, 60), u.Section, u.Count, u.LastUsed.Local().Format("2006-01-02 15:04"))
+		if r.WithScores {
+			fmt.Fprintf(tw, "\t%d", prompt.ScorePrompt(u.Name).Total)
+		}
+		fmt.Fprintln(tw)
+	}
+	return tw.Flush()
+}
+
+// WriteCSV renders the report as CSV with one row per section and per prompt.
+func (r Report) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	header := []string{"kind", "name", "section", "count", "last_used"}
+	if r.WithScores {
+		header = append(header, "score")
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, rows := range [][]Usage{r.Sections, r.Prompts} {
+		for _, u := range rows {
+			record := []string{u.Kind, u.Name, u.Section, strconv.Itoa(u.Count), u.LastUsed.UTC().Format(time.RFC3339)}
+			if r.WithScores {
+				score := ""
+				if u.Kind == "prompt" {
+					score = strconv.Itoa(prompt.ScorePrompt(u.Name).Total)
+				}
+				record = append(record, score)
+			}
+			if err := cw.Write(record); err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// truncate shortens s to at most n runes, replacing newlines with spaces.
+func truncate(s string, n int) string {
+	s = strings.ReplaceAll(s, "\n", " ")
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-3]) + "..."
+}
@@ -0,0 +1,178 @@
+// Package httpclient provides the HTTP client shared by the network sources (URLs,
+// S3, Google Drive, Joplin, prompt packs and GitHub gists). It retries failed
+// requests with exponential backoff, limits the rate of requests to each host,
+// bounds every request with a timeout and honors HTTPS_PROXY, HTTP_PROXY and NO_PROXY.
+package httpclient
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
+
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+// defaultTimeout bounds requests sent before Configure is called.
+const defaultTimeout = 30 * time.Second
+
+// maxBackoff caps the delay before a retry, also when a server asks for a longer
+// one with Retry-After.
+const maxBackoff = 10 * time.Second
+
+// backoff is the delay before the first retry, doubled before each further one.
+// It is a variable so tests need not wait.
+var backoff = 500 * time.Millisecond
+
+var (
+	mu sync.RWMutex
+	// shared is the client returned by Client. Until Configure is called, such as in
+	// tests of the sources, it neither retries nor limits the request rate.
+	shared = New(defaultTimeout, 0, 0)
+)
+
+// Configure replaces the shared client with one using the HTTP_TIMEOUT,
+// HTTP_RETRIES and HTTP_RATE_LIMIT settings of conf.
+func Configure(conf config.Config) {
+	c := New(conf.HTTPTimeout, conf.HTTPRetries, conf.HTTPRateLimit)
+	mu.Lock()
+	defer mu.Unlock()
+	shared = c
+}
+
+// Client returns the shared client.
+func Client() *http.Client {
+	mu.RLock()
+	defer mu.RUnlock()
+	return shared
+}
+
+// New returns a client giving up on a request, including its retries, after timeout
+// (0 for no limit). Requests failing with a connection error or a 429 or 5xx status
+// are retried up to retries times if they can be sent again safely, and at most
+// perSecond requests per second are sent to each host (0 for no limit).
+func New(timeout time.Duration, retries int, perSecond float64) *http.Client {
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	base.Proxy = http.ProxyFromEnvironment
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &transport{
+			base:      base,
+			retries:   retries,
+			perSecond: perSecond,
+			limiters:  make(map[string]*rate.Limiter),
+		},
+	}
+}
+
+// transport retries and rate-limits the requests it sends through base.
+type transport struct {
+	base      http.RoundTripper
+	retries   int
+	perSecond float64
+
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter // By host
+}
+
+// RoundTrip sends req, waiting for the rate limit of its host before each attempt.
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		if limiter := t.limiter(req.URL.Host); limiter != nil {
+			if err := limiter.Wait(req.Context()); err != nil {
+				return nil, err
+			}
+		}
+		r := req
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			r = req.Clone(req.Context())
+			r.Body = body
+		}
+
+		resp, err := t.base.RoundTrip(r)
+		if attempt >= t.retries || !retryable(req, resp, err) {
+			return resp, err
+		}
+		delay := retryDelay(attempt, resp)
+		if err != nil {
+			log.Debugf("Retrying %s %s in %s after %v", req.Method, req.URL.Host, delay, err)
+		} else {
+			log.Debugf("Retrying %s %s in %s after status %s", req.Method, req.URL.Host, delay, resp.Status)
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// limiter returns the rate limiter of host, or nil without a rate limit.
+func (t *transport) limiter(host string) *rate.Limiter {
+	if t.perSecond <= 0 {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	l, ok := t.limiters[host]
+	if !ok {
+		l = rate.NewLimiter(rate.Limit(t.perSecond), max(int(t.perSecond), 1))
+		t.limiters[host] = l
+	}
+	return l
+}
+
+// retryable reports whether req, which got resp or err, is worth sending again: it
+// failed with a connection error or a status a later attempt may not get, and it is
+// idempotent with a body that can be sent again.
+func retryable(req *http.Request, resp *http.Response, err error) bool {
+	if req.Context().Err() != nil {
+		return false
+	}
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+	default:
+		return false
+	}
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	if err != nil {
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryDelay returns how long to wait before retrying after the 0-based attempt,
+// following a Retry-After header of resp when there is one.
+func retryDelay(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if after := resp.Header.Get("Retry-After"); after != "" {
+			if seconds, err := strconv.Atoi(after); err == nil && seconds >= 0 {
+				return min(time.Duration(seconds)*time.Second, maxBackoff)
+			}
+			if at, err := http.ParseTime(after); err == nil {
+				return min(max(time.Until(at), 0), maxBackoff)
+			}
+		}
+	}
+	return min(backoff<<attempt, maxBackoff)
+}
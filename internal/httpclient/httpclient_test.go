@@ -0,0 +1,150 @@
+package httpclient
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+// flakyServer fails the first failures requests with status, then echoes the body.
+func flakyServer(t *testing.T, failures int32, status int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) <= failures {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(status)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		_, _ = w.Write(append([]byte("ok:"), body...))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+func withoutBackoff(t *testing.T) {
+	t.Helper()
+	old := backoff
+	backoff = time.Millisecond
+	t.Cleanup(func() { backoff = old })
+}
+
+func TestClient_Retries(t *testing.T) {
+	withoutBackoff(t)
+	tests := []struct {
+		name     string
+		method   string
+		status   int
+		failures int32
+		retries  int
+		wantCode int
+		wantReqs int32
+	}{
+		{"retried until it succeeds", http.MethodPut, http.StatusServiceUnavailable, 2, 3, http.StatusOK, 3},
+		{"too many requests", http.MethodGet, http.StatusTooManyRequests, 1, 3, http.StatusOK, 2},
+		{"gives up after the retries", http.MethodGet, http.StatusBadGateway, 5, 2, http.StatusBadGateway, 3},
+		{"client errors are not retried", http.MethodGet, http.StatusNotFound, 1, 3, http.StatusNotFound, 1},
+		{"posts are not retried", http.MethodPost, http.StatusServiceUnavailable, 1, 3, http.StatusServiceUnavailable, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, requests := flakyServer(t, tt.failures, tt.status)
+			req, _ := http.NewRequest(tt.method, srv.URL, strings.NewReader("prompts"))
+			resp, err := New(5*time.Second, tt.retries, 0).Do(req)
+			if err != nil {
+				t.Fatalf("Do() error = %v", err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if resp.StatusCode != tt.wantCode || requests.Load() != tt.wantReqs {
+				t.Errorf("got status %d after %d request(s), want %d after %d", resp.StatusCode, requests.Load(), tt.wantCode, tt.wantReqs)
+			}
+			if tt.wantCode == http.StatusOK && string(body) != "ok:prompts" {
+				t.Errorf("expected the body sent again on retry, got %q", body)
+			}
+		})
+	}
+}
+
+func TestClient_RetriesConnectionErrors(t *testing.T) {
+	withoutBackoff(t)
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL
+	srv.Close()
+
+	start := time.Now()
+	if _, err := New(5*time.Second, 2, 0).Get(url); err == nil {
+		t.Fatal("expected an error from a closed server")
+	}
+	if time.Since(start) > 4*time.Second {
+		t.Error("expected the retries to back off briefly")
+	}
+}
+
+func TestClient_RateLimit(t *testing.T) {
+	srv, requests := flakyServer(t, 0, http.StatusOK)
+	client := New(5*time.Second, 0, 20)
+
+	start := time.Now()
+	for range 3 {
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		resp.Body.Close()
+	}
+	// The burst of 20 lets these requests through without waiting
+	if elapsed := time.Since(start); requests.Load() != 3 || elapsed > 2*time.Second {
+		t.Errorf("expected 3 requests within the burst, got %d in %s", requests.Load(), elapsed)
+	}
+
+	slow := New(5*time.Second, 0, 4)
+	start = time.Now()
+	for range 6 {
+		resp, err := slow.Get(srv.URL)
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		resp.Body.Close()
+	}
+	// A burst of 4 at 4 requests per second: the last 2 wait about 250ms each
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Errorf("expected the requests beyond the burst to be delayed, took %s", elapsed)
+	}
+}
+
+func TestClient_Timeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(2 * time.Second):
+		}
+	}))
+	defer srv.Close()
+
+	if _, err := New(50*time.Millisecond, 3, 0).Get(srv.URL); err == nil {
+		t.Error("expected the request to time out")
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	old := Client()
+	t.Cleanup(func() { shared = old })
+
+	Configure(config.Config{HTTPTimeout: 7 * time.Second, HTTPRetries: 1, HTTPRateLimit: 2})
+	c := Client()
+	tr, ok := c.Transport.(*transport)
+	if c.Timeout != 7*time.Second || !ok || tr.retries != 1 || tr.perSecond != 2 {
+		t.Errorf("Configure() did not apply the settings: %+v", c)
+	}
+	if tr.base.(*http.Transport).Proxy == nil {
+		t.Error("expected requests to go through the proxy from the environment")
+	}
+}
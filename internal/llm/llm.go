@@ -0,0 +1,140 @@
+// Package llm provides a minimal client for OpenAI-compatible chat completion APIs.
+// It is used by opt-in features that ask a language model for help, and is
+// disabled unless a base URL is configured.
+package llm
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+// ErrNotConfigured is returned when no LLM endpoint has been configured.
+var ErrNotConfigured = errors.New("no LLM endpoint configured (set LLM_BASE_URL)")
+
+// Client talks to an OpenAI-compatible API such as OpenAI, llama.cpp server or Ollama.
+type Client struct {
+	BaseURL    string
+	APIKey     string
+	Model      string
+	HTTPClient *http.Client
+}
+
+// NewClient returns a Client for the configured endpoint,
+// or ErrNotConfigured if LLM_BASE_URL is not set.
+func NewClient(conf config.Config) (*Client, error) {
+	if conf.LLMBaseURL == "" {
+		return nil, ErrNotConfigured
+	}
+	return &Client{
+		BaseURL:    strings.TrimRight(conf.LLMBaseURL, "/"),
+		APIKey:     conf.LLMAPIKey,
+		Model:      conf.LLMModel,
+		HTTPClient: &http.Client{Timeout: 2 * time.Minute},
+	}, nil
+}
+
+// Message is a single chat message.
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// Chat sends messages to the chat completions endpoint and returns the first choice's content.
+func (c *Client) Chat(messages []Message) (string, error) {
+	var resp struct {
+		Choices []struct {
+			Message Message `json:"message"`
+		} `json:"choices"`
+	}
+	req := map[string]interface{}{
+		"model":    c.Model,
+		"messages": messages,
+	}
+	if err := c.post("/chat/completions", req, &resp); err != nil {
+		return "", err
+	}
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("LLM response contained no choices")
+	}
+	return strings.TrimSpace(resp.Choices[0].Message.Content), nil
+}
+
+// Embed returns one embedding vector per input text using the embeddings endpoint.
+func (c *Client) Embed(model string, texts []string) ([][]float64, error) {
+	var resp struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float64 `json:"embedding"`
+		} `json:"data"`
+	}
+	req := map[string]interface{}{
+		"model": model,
+		"input": texts,
+	}
+	if err := c.post("/embeddings", req, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Data) != len(texts) {
+		return nil, fmt.Errorf("LLM returned %d embeddings for %d inputs", len(resp.Data), len(texts))
+	}
+	vectors := make([][]float64, len(texts))
+	for i, d := range resp.Data {
+		idx := d.Index
+		if idx < 0 || idx >= len(texts) {
+			idx = i
+		}
+		vectors[idx] = d.Embedding
+	}
+	return vectors, nil
+}
+
+// post sends body as JSON to path below BaseURL and decodes the JSON response into out.
+func (c *Client) post(path string, body, out interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal LLM request: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.BaseURL+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach LLM endpoint: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("LLM endpoint returned unexpected status %s", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode LLM response: %w", err)
+	}
+	return nil
+}
+
+// improveSystemPrompt is the meta-prompt used by Improve.
+const improveSystemPrompt = `You are an expert prompt engineer. Rewrite the user's LLM prompt so it is clearer and more effective:
+state a role, the task, constraints and the expected output format, and remove vague wording.
+Keep the original intent and keep it concise. Reply with the improved prompt only, without commentary.`
+
+// Improve asks the model for an improved version of content.
+func (c *Client) Improve(content string) (string, error) {
+	return c.Chat([]Message{
+		{Role: "system", Content: improveSystemPrompt},
+		{Role: "user", Content: content},
+	})
+}
@@ -0,0 +1,83 @@
+package llm
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+func TestNewClient(t *testing.T) {
+	if _, err := NewClient(config.Config{}); !errors.Is(err, ErrNotConfigured) {
+		t.Errorf("expected ErrNotConfigured, got %v", err)
+	}
+	c, err := NewClient(config.Config{LLMBaseURL: "http://localhost:8080/v1/", LLMModel: "test"})
+	if err != nil {
+		t.Fatalf("NewClient() returned error: %v", err)
+	}
+	if c.BaseURL != "http://localhost:8080/v1" {
+		t.Errorf("expected trailing slash to be trimmed, got %q", c.BaseURL)
+	}
+}
+
+func TestImprove(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/chat/completions" || r.Header.Get("Authorization") != "Bearer key" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var req struct {
+			Model    string    `json:"model"`
+			Messages []Message `json:"messages"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req.Model != "test-model" || len(req.Messages) != 2 || req.Messages[1].Content != "review code" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"  You are a reviewer. Review the code.  "}}]}`))
+	}))
+	defer server.Close()
+
+	c, _ := NewClient(config.Config{LLMBaseURL: server.URL, LLMAPIKey: "key", LLMModel: "test-model"})
+	improved, err := c.Improve("review code")
+	if err != nil {
+		t.Fatalf("Improve() returned error: %v", err)
+	}
+	if improved != "You are a reviewer. Review the code." {
+		t.Errorf("Improve() = %q", improved)
+	}
+
+	c.APIKey = "wrong"
+	if _, err := c.Improve("review code"); err == nil {
+		t.Error("expected error for rejected request")
+	}
+}
+
+func TestEmbed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/embeddings" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		// Return embeddings out of order to check they are placed by index
+		_, _ = w.Write([]byte(`{"data":[{"index":1,"embedding":[0,1]},{"index":0,"embedding":[1,0]}]}`))
+	}))
+	defer server.Close()
+
+	c, _ := NewClient(config.Config{LLMBaseURL: server.URL})
+	vectors, err := c.Embed("embed-model", []string{"first", "second"})
+	if err != nil {
+		t.Fatalf("Embed() returned error: %v", err)
+	}
+	if len(vectors) != 2 || vectors[0][0] != 1 || vectors[1][1] != 1 {
+		t.Errorf("unexpected vectors: %v", vectors)
+	}
+
+	if _, err := c.Embed("embed-model", []string{"only one"}); err == nil {
+		t.Error("expected error for mismatched embedding count")
+	}
+}
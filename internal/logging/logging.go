@@ -0,0 +1,181 @@
+// Package logging directs logrus output to a size-rotated log file so that log
+// messages never draw over the TUI.
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+// megabyte is the unit of config.LogMaxSize.
+const megabyte = 1024 * 1024
+
+// RotatingFile is an io.Writer appending to a log file. When a write would grow the
+// file past maxSize it is renamed to <path>.1 (shifting older backups to .2, .3, ...)
+// and a new file is started; backups beyond maxBackups are removed.
+type RotatingFile struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// Path returns the log file location for conf. A relative LOG_FILE is placed in
+// the data directory.
+func Path(conf config.Config) (string, error) {
+	if filepath.IsAbs(conf.LogFile) {
+		return conf.LogFile, nil
+	}
+	dir, err := config.ResolveDataDir(conf)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, conf.LogFile), nil
+}
+
+// Tail returns the last n lines of the log file configured by LOG_FILE, continuing
+// into its newest backup when the file was just rotated.
+func Tail(conf config.Config, n int) ([]string, error) {
+	path, err := Path(conf)
+	if err != nil {
+		return nil, err
+	}
+	var lines []string
+	for _, p := range []string{path + ".1", path} {
+		data, err := os.ReadFile(p) // #nosec G304
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read log file: %w", err)
+		}
+		if text := strings.TrimRight(string(data), "\n"); text != "" {
+			lines = append(lines, strings.Split(text, "\n")...)
+		}
+	}
+	if n = max(n, 0); len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines, nil
+}
+
+// Open opens the log file configured by LOG_FILE, LOG_MAX_SIZE and LOG_MAX_BACKUPS,
+// creating it and its directory if needed.
+func Open(conf config.Config) (*RotatingFile, error) {
+	path, err := Path(conf)
+	if err != nil {
+		return nil, err
+	}
+	r := &RotatingFile{
+		path:       path,
+		maxSize:    int64(conf.LogMaxSize) * megabyte,
+		maxBackups: conf.LogMaxBackups,
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create log directory: %w", err)
+	}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Write appends p to the log file, rotating it first if p would exceed the size limit.
+// If rotating fails, p is still written to the file rotate fell back to and the
+// rotation error is returned.
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.maxSize > 0 && r.size > 0 && r.size+int64(len(p)) > r.maxSize {
+		if err := r.rotate(); err != nil {
+			n, _ := r.file.Write(p)
+			r.size += int64(n)
+			return n, err
+		}
+	}
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// Close closes the current log file.
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == os.Stderr {
+		return nil
+	}
+	return r.file.Close()
+}
+
+// open opens r.path for appending and records its current size.
+func (r *RotatingFile) open() error {
+	f, err := os.OpenFile(r.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600) // #nosec G304
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("failed to stat log file: %w", err)
+	}
+	r.file = f
+	r.size = info.Size()
+	return nil
+}
+
+// rotate shifts the current file and its backups up by one and starts a new file.
+// If that fails after the current file was closed, logging goes on in the original
+// path, or on stderr when it cannot be reopened either.
+func (r *RotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return fmt.Errorf("failed to close log file: %w", err)
+	}
+	if err := r.shift(); err != nil {
+		if r.open() != nil {
+			r.file, r.size = os.Stderr, 0
+		}
+		return err
+	}
+	if err := r.open(); err != nil {
+		r.file, r.size = os.Stderr, 0
+		return err
+	}
+	return nil
+}
+
+// shift moves the closed current file to <path>.1 and its backups up by one, or
+// removes it when no backups are kept.
+func (r *RotatingFile) shift() error {
+	if r.maxBackups <= 0 {
+		if err := os.Remove(r.path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove log file: %w", err)
+		}
+		return nil
+	}
+
+	_ = os.Remove(r.backupPath(r.maxBackups))
+	for i := r.maxBackups - 1; i >= 1; i-- {
+		if err := os.Rename(r.backupPath(i), r.backupPath(i+1)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to rotate log file: %w", err)
+		}
+	}
+	if err := os.Rename(r.path, r.backupPath(1)); err != nil {
+		return fmt.Errorf("failed to rotate log file: %w", err)
+	}
+	return nil
+}
+
+func (r *RotatingFile) backupPath(n int) string {
+	return fmt.Sprintf("%s.%d", r.path, n)
+}
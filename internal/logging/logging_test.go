@@ -0,0 +1,141 @@
+package logging
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+func TestPath(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		logFile string
+		want    string
+	}{
+		{"relative in data dir", "wheresmyprompt.log", filepath.Join(dir, "wheresmyprompt.log")},
+		{"absolute", "/var/log/wmp.log", "/var/log/wmp.log"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Path(config.Config{LogFile: tt.logFile, DataDir: dir})
+			if err != nil {
+				t.Fatalf("Path() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Path() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRotatingFile_Rotate(t *testing.T) {
+	dir := t.TempDir()
+	f, err := Open(config.Config{LogFile: "test.log", DataDir: dir, LogMaxSize: 1, LogMaxBackups: 2})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer f.Close()
+	f.maxSize = 10 // Bytes, to keep the test small
+
+	for _, line := range []string{"aaaaaaaa\n", "bbbbbbbb\n", "cccccccc\n", "dddddddd\n"} {
+		if _, err := f.Write([]byte(line)); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		file string
+		want string
+	}{
+		{"test.log", "dddddddd\n"},
+		{"test.log.1", "cccccccc\n"},
+		{"test.log.2", "bbbbbbbb\n"},
+	}
+	for _, tt := range tests {
+		got, err := os.ReadFile(filepath.Join(dir, tt.file))
+		if err != nil {
+			t.Errorf("failed to read %s: %v", tt.file, err)
+			continue
+		}
+		if string(got) != tt.want {
+			t.Errorf("%s = %q, want %q", tt.file, got, tt.want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "test.log.3")); !os.IsNotExist(err) {
+		t.Error("expected backups beyond LOG_MAX_BACKUPS to be removed")
+	}
+}
+
+func TestRotatingFile_RotateFailure(t *testing.T) {
+	dir := t.TempDir()
+	f, err := Open(config.Config{LogFile: "test.log", DataDir: dir, LogMaxSize: 1, LogMaxBackups: 1})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer f.Close()
+	f.maxSize = 10
+
+	// A non-empty directory in the way of the backup makes the rename fail
+	if err := os.MkdirAll(filepath.Join(dir, "test.log.1", "blocked"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte("aaaaaaaa\n")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if _, err := f.Write([]byte("bbbbbbbb\n")); err == nil {
+		t.Error("expected the failed rotation to be reported")
+	}
+	if _, err := f.Write([]byte("cccccccc\n")); err == nil {
+		t.Error("expected the rotation to be retried and fail again")
+	}
+
+	got, _ := os.ReadFile(filepath.Join(dir, "test.log"))
+	if string(got) != "aaaaaaaa\nbbbbbbbb\ncccccccc\n" {
+		t.Errorf("expected logging to go on in the original file, got %q", got)
+	}
+}
+
+func TestOpen_AppendsToExisting(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "test.log")
+	if err := os.WriteFile(path, []byte("old\n"), 0600); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+	f, err := Open(config.Config{LogFile: path, LogMaxSize: 10})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	_, _ = f.Write([]byte("new\n"))
+	f.Close()
+
+	got, _ := os.ReadFile(path)
+	if !strings.HasPrefix(string(got), "old\n") || !strings.HasSuffix(string(got), "new\n") {
+		t.Errorf("expected log to be appended to, got %q", got)
+	}
+}
+
+func TestTail(t *testing.T) {
+	dir := t.TempDir()
+	conf := config.Config{LogFile: "test.log", DataDir: dir}
+	if lines, err := Tail(conf, 3); err != nil || len(lines) != 0 {
+		t.Fatalf("Tail() = %q, %v, want nothing without a log file", lines, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "test.log.1"), []byte("one\ntwo\n"), 0600); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "test.log"), []byte("three\nfour\n"), 0600); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+	lines, err := Tail(conf, 3)
+	if err != nil {
+		t.Fatalf("Tail() error = %v", err)
+	}
+	if strings.Join(lines, ",") != "two,three,four" {
+		t.Errorf("Tail() = %q, want the last 3 lines across the backup", lines)
+	}
+}
@@ -0,0 +1,124 @@
+package prompt
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+// addedLogName is the name of the added-at log inside the data directory.
+const addedLogName = "added.jsonl"
+
+// addedNow allows tests to control added-at timestamps.
+var addedNow = time.Now
+
+// addedRecord is an entry of the added-at log: a prompt line and when it was added.
+type addedRecord struct {
+	Time    time.Time `json:"time"`
+	Section string    `json:"section"`
+	Prompt  string    `json:"prompt"`
+}
+
+// addedLogPath returns the location of the added-at log.
+func addedLogPath(conf config.Config) (string, error) {
+	dir, err := config.ResolveDataDir(conf)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, addedLogName), nil
+}
+
+// recordAddedFunc allows tests to observe or skip recording added prompts.
+var recordAddedFunc = appendAddedLog
+
+// recordAdded records when each line of content, which becomes a prompt of its own,
+// was added to section so results can be sorted by SortRecent. Unlike usage history
+// this does not depend on ANALYTICS. Failures are logged but never fail the write.
+func recordAdded(conf config.Config, section, content string) {
+	var lines []string
+	for _, line := range strings.Split(content, "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	if err := recordAddedFunc(conf, section, lines...); err != nil {
+		log.Warn("Failed to record when the prompt was added: ", err)
+	}
+}
+
+// appendAddedLog appends a record for each of prompts to the added-at log.
+func appendAddedLog(conf config.Config, section string, prompts ...string) error {
+	if len(prompts) == 0 {
+		return nil
+	}
+	path, err := addedLogPath(conf)
+	if err != nil {
+		return err
+	}
+	if err := appFS.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create data directory: %w", err)
+	}
+
+	var lines []byte
+	now := addedNow().UTC()
+	for _, p := range prompts {
+		line, err := json.Marshal(addedRecord{Time: now, Section: section, Prompt: p})
+		if err != nil {
+			return fmt.Errorf("failed to marshal added-at record: %w", err)
+		}
+		lines = append(append(lines, line...), '\n')
+	}
+
+	f, err := appFS.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600) // #nosec G304
+	if err != nil {
+		return fmt.Errorf("failed to open added-at log: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(lines); err != nil {
+		return fmt.Errorf("failed to write added-at log: %w", err)
+	}
+	return nil
+}
+
+// AddedTimes returns when each prompt added through wheresmyprompt was last added,
+// keyed by its content, for SortPrompts. Prompts added by editing the library
+// directly are not known. A missing log yields an empty map and no error; malformed
+// lines are skipped.
+func AddedTimes(conf config.Config) (map[string]time.Time, error) {
+	path, err := addedLogPath(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	times := make(map[string]time.Time)
+	f, err := appFS.Open(path) // #nosec G304
+	if errors.Is(err, os.ErrNotExist) {
+		return times, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open added-at log: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var r addedRecord
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			continue
+		}
+		if r.Time.After(times[r.Prompt]) {
+			times[r.Prompt] = r.Time
+		}
+	}
+	return times, scanner.Err()
+}
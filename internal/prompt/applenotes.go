@@ -0,0 +1,99 @@
+package prompt
+
+import (
+	"fmt"
+	"html"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/toozej/wheresmyprompt/internal/sandbox"
+)
+
+// appleNotesGOOS allows tests to exercise the Apple Notes source on other platforms.
+var appleNotesGOOS = runtime.GOOS
+
+// osascriptCommandFunc allows tests to replace osascript with a fake command.
+var osascriptCommandFunc = osascriptCommand
+
+// osascriptCommand returns the command running the AppleScript lines with args as
+// its argv, so note names and content are never interpolated into the script.
+func osascriptCommand(script []string, args ...string) *exec.Cmd {
+	var cmdArgs []string
+	for _, line := range script {
+		cmdArgs = append(cmdArgs, "-e", line)
+	}
+	return sandbox.Command("osascript", append(cmdArgs, args...)...) // #nosec G204 -- fixed scripts, data passed as argv
+}
+
+// AppleScript reading and replacing the text of the note named by the first argument.
+var (
+	appleNotesReadScript = []string{
+		"on run argv",
+		`tell application "Notes" to get plaintext of note (item 1 of argv)`,
+		"end run",
+	}
+	appleNotesWriteScript = []string{
+		"on run argv",
+		`tell application "Notes" to set body of note (item 1 of argv) to (item 2 of argv)`,
+		"end run",
+	}
+)
+
+// appleNotesSource is a Source backed by an Apple Notes note on macOS, selected
+// with APPLE_NOTE. Notes derives a note's name from its first line, so that line
+// is dropped when loading and written back as a heading when saving.
+type appleNotesSource struct {
+	note string
+}
+
+func (s appleNotesSource) Name() string {
+	return s.note + " (Apple Notes)"
+}
+
+func (s appleNotesSource) Check() error {
+	if appleNotesGOOS != "darwin" {
+		return fmt.Errorf("APPLE_NOTE is only supported on macOS")
+	}
+	if _, err := sandbox.LookPath("osascript"); err != nil {
+		return fmt.Errorf("osascript not found: %w", err)
+	}
+	return nil
+}
+
+func (s appleNotesSource) Load() (string, error) {
+	out, err := osascriptCommandFunc(appleNotesReadScript, s.note).Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to read Apple Notes note %s: %w", s.note, commandError(err))
+	}
+	// Notes separates lines with carriage returns or line separators in plain text
+	text := strings.NewReplacer("\r\n", "\n", "\r", "\n", "\u2028", "\n").Replace(normalizeText(string(out)))
+	text = strings.TrimSuffix(text, "\n")
+	first, rest, _ := strings.Cut(text, "\n")
+	if strings.TrimSpace(first) == s.note {
+		text = rest
+	}
+	return strings.TrimLeft(text, "\n") + "\n", nil
+}
+
+func (s appleNotesSource) Save(content string) error {
+	if _, err := osascriptCommandFunc(appleNotesWriteScript, s.note, appleNotesHTML(s.note, content)).Output(); err != nil {
+		return fmt.Errorf("failed to write Apple Notes note %s: %w", s.note, commandError(err))
+	}
+	return nil
+}
+
+// appleNotesHTML converts content to the HTML body Notes stores, one <div> per line
+// below a title heading keeping the note's name.
+func appleNotesHTML(note, content string) string {
+	var b strings.Builder
+	b.WriteString("<div><h1>" + html.EscapeString(note) + "</h1></div>\n")
+	for _, line := range strings.Split(strings.TrimSuffix(content, "\n"), "\n") {
+		if line == "" {
+			b.WriteString("<div><br></div>\n")
+			continue
+		}
+		b.WriteString("<div>" + html.EscapeString(line) + "</div>\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,100 @@
+package prompt
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+// fakeOsascript replaces osascript with a command that prints the plain text in the
+// returned file when reading, and saves the HTML body it is given there when writing.
+func fakeOsascript(t *testing.T, plaintext string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake osascript is a shell command")
+	}
+	path := filepath.Join(t.TempDir(), "note")
+	if err := os.WriteFile(path, []byte(plaintext), 0600); err != nil {
+		t.Fatal(err)
+	}
+	original, originalGOOS := osascriptCommandFunc, appleNotesGOOS
+	t.Cleanup(func() { osascriptCommandFunc, appleNotesGOOS = original, originalGOOS })
+	appleNotesGOOS = "darwin"
+	osascriptCommandFunc = func(script []string, args ...string) *exec.Cmd {
+		if len(args) == 1 {
+			return exec.Command("cat", path)
+		}
+		return exec.Command("sh", "-c", `printf '%s' "$1" > "$2"`, "sh", args[1], path) // #nosec G204
+	}
+	return path
+}
+
+func TestAppleNotesSource(t *testing.T) {
+	path := fakeOsascript(t, "LLM Prompts\r# Prompts\r\r## Golang\r\r### Review\rReview this Go code.\n")
+	conf := config.Config{AppleNote: "LLM Prompts", SNNote: "LLM Prompts", OnConflict: ConflictAbort}
+
+	data, err := LoadPrompts(conf)
+	if err != nil {
+		t.Fatalf("LoadPrompts() error = %v", err)
+	}
+	if results := SearchPrompts(data, "review", "Golang"); len(results) != 1 || results[0] != "Review this Go code." {
+		t.Errorf("SearchPrompts() = %q, want the note's prompt", results)
+	}
+	if data.Source != "LLM Prompts (Apple Notes)" {
+		t.Errorf("Source = %q, want %q", data.Source, "LLM Prompts (Apple Notes)")
+	}
+
+	if err := AddPrompt(conf, "Tests", "Use <table> tests & subtests.", "Golang"); err != nil {
+		t.Fatalf("AddPrompt() error = %v", err)
+	}
+	saved, _ := os.ReadFile(path) // #nosec G304
+	for _, want := range []string{
+		"<div><h1>LLM Prompts</h1></div>\n<div># Prompts</div>\n<div><br></div>\n",
+		"<div>### Review</div>\n<div>Review this Go code.</div>\n",
+		"<div>Use &lt;table&gt; tests &amp; subtests.</div>\n",
+	} {
+		if !strings.Contains(string(saved), want) {
+			t.Errorf("expected %q in the written body, got:\n%s", want, saved)
+		}
+	}
+}
+
+func TestAppleNotesSource_Check(t *testing.T) {
+	original := appleNotesGOOS
+	defer func() { appleNotesGOOS = original }()
+
+	appleNotesGOOS = "linux"
+	if err := (appleNotesSource{note: "LLM Prompts"}).Check(); err == nil || !strings.Contains(err.Error(), "only supported on macOS") {
+		t.Errorf("Check() error = %v, want a macOS-only error", err)
+	}
+}
+
+func TestCustomSource_Precedence(t *testing.T) {
+	tests := []struct {
+		name string
+		conf config.Config
+		want string
+	}{
+		{"file wins", config.Config{FilePath: "prompts.md", Source: "joplin", AppleNote: "LLM Prompts"}, ""},
+		{"plugin before apple notes", config.Config{Source: "joplin", AppleNote: "LLM Prompts"}, "joplin (plugin)"},
+		{"apple notes", config.Config{AppleNote: "LLM Prompts", SNNote: "Other"}, "LLM Prompts (Apple Notes)"},
+		{"simplenote", config.Config{SNNote: "LLM Prompts"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src, ok := customSource(tt.conf)
+			got := ""
+			if ok {
+				got = src.Name()
+			}
+			if got != tt.want {
+				t.Errorf("customSource() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package prompt
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/toozej/wheresmyprompt/internal/search"
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+// ErrAttachmentNotFound is returned by Attachments when an attached file does not exist.
+var ErrAttachmentNotFound = errors.New("attachment not found")
+
+// Attachments returns the absolute paths of the files attached to p with
+// "<!-- attach: path -->" comments: those inside the prompt itself, then those on
+// lines of their own in its section, which apply to every prompt of the section.
+// A leading "~/" is the home directory, and other relative paths are relative to
+// the directory of the local prompt file (FILEPATH), or to the current directory
+// for Simplenote and URL sources. Returns ErrAttachmentNotFound for a missing file.
+func Attachments(conf config.Config, data *PromptData, p Prompt) ([]string, error) {
+	paths := search.Attachments(p.Content)
+	for _, sec := range data.Sections {
+		if sec.Namespace != p.Namespace || strings.Join(sec.Headings, " > ") != p.Title {
+			continue
+		}
+		for _, line := range sec.Lines {
+			if search.IsAttachmentLine(line) {
+				paths = append(paths, search.Attachments(line)...)
+			}
+		}
+		break
+	}
+
+	base := ""
+	if conf.FilePath != "" && !isURLSource(conf.FilePath) {
+		base = filepath.Dir(conf.FilePath)
+	}
+	resolved := make([]string, 0, len(paths))
+	for _, path := range paths {
+		abs, err := attachmentPath(base, path)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := appFS.Stat(abs); err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrAttachmentNotFound, abs)
+		}
+		resolved = append(resolved, abs)
+	}
+	return resolved, nil
+}
+
+// attachmentPath returns path as an absolute path, expanding "~/" and resolving
+// other relative paths against base.
+func attachmentPath(base, path string) (string, error) {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve attachment %s: %w", path, err)
+		}
+		path = filepath.Join(home, rest)
+	} else if !filepath.IsAbs(path) {
+		path = filepath.Join(base, path)
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve attachment %s: %w", path, err)
+	}
+	return abs, nil
+}
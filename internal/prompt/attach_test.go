@@ -0,0 +1,72 @@
+package prompt
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+func TestAttachments(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"arch.png", "flow.png", "shared.pdf"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	absolute := filepath.Join(dir, "flow.png")
+	data := &PromptData{Sections: []Section{
+		{Headings: []string{"Prompts", "Diagrams"}, Lines: []string{
+			"Explain this diagram <!-- attach: arch.png -->",
+			"Compare these <!-- attach: " + absolute + " -->",
+			"Describe it",
+			"<!-- attach: shared.pdf -->",
+		}},
+		{Headings: []string{"Prompts", "Broken"}, Lines: []string{"Explain <!-- attach: missing.png -->"}},
+	}}
+	conf := config.Config{FilePath: filepath.Join(dir, "prompts.md")}
+	title := "Prompts > Diagrams"
+
+	tests := []struct {
+		name     string
+		prompt   Prompt
+		expected []string
+		err      error
+	}{
+		{
+			name:     "inline relative to the prompt file, then section-wide",
+			prompt:   Prompt{Content: "Explain this diagram <!-- attach: arch.png -->", Title: title},
+			expected: []string{filepath.Join(dir, "arch.png"), filepath.Join(dir, "shared.pdf")},
+		},
+		{
+			name:     "absolute path",
+			prompt:   Prompt{Content: "Compare these <!-- attach: " + absolute + " -->", Title: title},
+			expected: []string{absolute, filepath.Join(dir, "shared.pdf")},
+		},
+		{
+			name:     "section-wide only",
+			prompt:   Prompt{Content: "Describe it", Title: title},
+			expected: []string{filepath.Join(dir, "shared.pdf")},
+		},
+		{
+			name:   "missing file",
+			prompt: Prompt{Content: "Explain <!-- attach: missing.png -->", Title: "Prompts > Broken"},
+			err:    ErrAttachmentNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Attachments(conf, data, tt.prompt)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Attachments() error = %v, want %v", err, tt.err)
+			}
+			if tt.err == nil && !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Attachments() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
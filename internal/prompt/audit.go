@@ -0,0 +1,222 @@
+package prompt
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+// writeLogName is the name of the write log inside the data directory.
+const writeLogName = "writes.jsonl"
+
+// ActionUndo marks write log records made by UndoLastWrite.
+const ActionUndo = "undo"
+
+// auditNow allows tests to control write log timestamps.
+var auditNow = time.Now
+
+// ErrNothingToUndo is returned by UndoLastWrite when no recorded write is left to undo.
+var ErrNothingToUndo = errors.New("no Simplenote write to undo")
+
+// writeOp describes a write to the prompt source for the write log.
+type writeOp struct {
+	action  string // What the write did, such as "add" or "archive"
+	title   string // The prompt title involved, if any
+	section string // The section involved, if any
+}
+
+// WriteRecord is an entry of the write log: a write to a Simplenote note and the
+// snapshot of the note taken just before it.
+type WriteRecord struct {
+	Time     time.Time `json:"time"`
+	Action   string    `json:"action"`
+	Title    string    `json:"title,omitempty"`
+	Section  string    `json:"section,omitempty"`
+	Source   string    `json:"source"`
+	Snapshot string    `json:"snapshot"` // File name of the snapshot in the backup directory
+}
+
+// backupDir returns BACKUP_DIR, defaulting to "backups" in the data directory.
+func backupDir(conf config.Config) (string, error) {
+	if conf.BackupDir != "" {
+		return conf.BackupDir, nil
+	}
+	dir, err := config.ResolveDataDir(conf)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "backups"), nil
+}
+
+// writeLogPath returns the location of the write log.
+func writeLogPath(conf config.Config) (string, error) {
+	dir, err := config.ResolveDataDir(conf)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, writeLogName), nil
+}
+
+// recordWriteFunc allows tests to observe or skip auditing.
+var recordWriteFunc = recordWrite
+
+// recordWrite saves previous, the note content about to be replaced, to the backup
+// directory and appends op to the write log. It is called before every Simplenote
+// import so the write can be undone; an error aborts the write.
+func recordWrite(conf config.Config, op writeOp, previous string) error {
+	dir, err := backupDir(conf)
+	if err != nil {
+		return err
+	}
+	if err := appFS.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	now := auditNow().UTC()
+	snapshot := fmt.Sprintf("%s-%s.md", now.Format("20060102T150405.000000000Z"), op.action)
+	if err := afero.WriteFile(appFS, filepath.Join(dir, snapshot), []byte(previous), 0600); err != nil {
+		return fmt.Errorf("failed to save backup before writing: %w", err)
+	}
+
+	return appendWriteLog(conf, WriteRecord{
+		Time:     now,
+		Action:   op.action,
+		Title:    op.title,
+		Section:  op.section,
+		Source:   conf.SNNote,
+		Snapshot: snapshot,
+	})
+}
+
+// appendWriteLog appends record to the write log.
+func appendWriteLog(conf config.Config, record WriteRecord) error {
+	path, err := writeLogPath(conf)
+	if err != nil {
+		return err
+	}
+	if err := appFS.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create data directory: %w", err)
+	}
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal write log record: %w", err)
+	}
+
+	f, err := appFS.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600) // #nosec G304
+	if err != nil {
+		return fmt.Errorf("failed to open write log: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write write log: %w", err)
+	}
+	return nil
+}
+
+// WriteLog returns the records of the write log, oldest first. A missing log
+// yields no records and no error; malformed lines are skipped.
+func WriteLog(conf config.Config) ([]WriteRecord, error) {
+	path, err := writeLogPath(conf)
+	if err != nil {
+		return nil, err
+	}
+	f, err := appFS.Open(path) // #nosec G304
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open write log: %w", err)
+	}
+	defer f.Close()
+
+	var records []WriteRecord
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var r WriteRecord
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			continue
+		}
+		records = append(records, r)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read write log: %w", err)
+	}
+	return records, nil
+}
+
+// lastUndoable returns the most recent write to note that has not been undone yet.
+// Each undo record cancels the latest write before it, so undoing repeatedly steps
+// further back.
+func lastUndoable(records []WriteRecord, note string) (WriteRecord, bool) {
+	var pending []WriteRecord
+	for _, r := range records {
+		if r.Source != note {
+			continue
+		}
+		if r.Action == ActionUndo {
+			if len(pending) > 0 {
+				pending = pending[:len(pending)-1]
+			}
+			continue
+		}
+		pending = append(pending, r)
+	}
+	if len(pending) == 0 {
+		return WriteRecord{}, false
+	}
+	return pending[len(pending)-1], true
+}
+
+// UndoLastWrite restores the configured Simplenote note to the snapshot taken
+// before its most recent write that has not been undone yet, and returns that
+// write. The content being replaced is backed up and logged as an undo first.
+// Returns ErrNothingToUndo if there is no such write.
+func UndoLastWrite(conf config.Config) (WriteRecord, error) {
+	if err := checkWritable(conf); err != nil {
+		return WriteRecord{}, err
+	}
+	if conf.FilePath != "" {
+		return WriteRecord{}, fmt.Errorf("undo only applies to Simplenote writes; FILEPATH is set")
+	}
+	if src, ok := customSourceFunc(conf); ok {
+		return WriteRecord{}, fmt.Errorf("undo only applies to Simplenote writes; prompts are read from %s", src.Name())
+	}
+
+	records, err := WriteLog(conf)
+	if err != nil {
+		return WriteRecord{}, err
+	}
+	last, ok := lastUndoable(records, conf.SNNote)
+	if !ok {
+		return WriteRecord{}, ErrNothingToUndo
+	}
+
+	dir, err := backupDir(conf)
+	if err != nil {
+		return WriteRecord{}, err
+	}
+	snapshot, err := afero.ReadFile(appFS, filepath.Join(dir, last.Snapshot)) // #nosec G304
+	if err != nil {
+		return WriteRecord{}, fmt.Errorf("failed to read backup %s: %w", last.Snapshot, err)
+	}
+
+	current, err := loadFromSimplenoteFunc(conf)
+	if err != nil {
+		return WriteRecord{}, fmt.Errorf("failed to load current note: %w", err)
+	}
+	if err := recordWriteFunc(conf, writeOp{action: ActionUndo, title: last.Title, section: last.Section}, current); err != nil {
+		return WriteRecord{}, err
+	}
+	if err := saveToSimplenoteFunc(conf, string(snapshot)); err != nil {
+		return WriteRecord{}, err
+	}
+	return last, nil
+}
@@ -0,0 +1,106 @@
+package prompt
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+// fakeSimplenote replaces the Simplenote load and save functions with an in-memory
+// note for the duration of the test.
+func fakeSimplenote(t *testing.T, content string) *string {
+	t.Helper()
+	oldLoad, oldSave := loadFromSimplenoteFunc, saveToSimplenoteFunc
+	t.Cleanup(func() { loadFromSimplenoteFunc, saveToSimplenoteFunc = oldLoad, oldSave })
+
+	note := &content
+	loadFromSimplenoteFunc = func(config.Config) (string, error) { return *note, nil }
+	saveToSimplenoteFunc = func(_ config.Config, updated string) error {
+		*note = updated
+		return nil
+	}
+	return note
+}
+
+func TestUndoLastWrite(t *testing.T) {
+	original := "# Prompts\n\n## Golang\n\n### Review\nReview this code\n"
+	note := fakeSimplenote(t, original)
+	conf := config.Config{SNNote: "LLM Prompts", DataDir: t.TempDir()}
+
+	if err := addPromptToNote(conf, "Tests", "Write table-driven tests", "Golang", strings.NewReader(""), io.Discard); err != nil {
+		t.Fatalf("addPromptToNote() returned error: %v", err)
+	}
+	afterAdd := *note
+	if err := ReplacePrompt(conf, "Review this code", "Review this Go code"); err != nil {
+		t.Fatalf("ReplacePrompt() returned error: %v", err)
+	}
+
+	records, err := WriteLog(conf)
+	if err != nil {
+		t.Fatalf("WriteLog() returned error: %v", err)
+	}
+	if len(records) != 2 || records[0].Action != "add" || records[0].Title != "Tests" || records[0].Section != "Golang" ||
+		records[0].Source != "LLM Prompts" || records[1].Action != "replace" {
+		t.Fatalf("unexpected write log: %+v", records)
+	}
+	snapshot, err := os.ReadFile(filepath.Join(conf.DataDir, "backups", records[0].Snapshot))
+	if err != nil || string(snapshot) != original {
+		t.Errorf("expected the pre-write note in the backup, got %q (%v)", snapshot, err)
+	}
+
+	undone, err := UndoLastWrite(conf)
+	if err != nil {
+		t.Fatalf("UndoLastWrite() returned error: %v", err)
+	}
+	if undone.Action != "replace" || *note != afterAdd {
+		t.Errorf("expected the replace to be undone, undid %+v leaving:\n%s", undone, *note)
+	}
+
+	undone, err = UndoLastWrite(conf)
+	if err != nil {
+		t.Fatalf("UndoLastWrite() returned error: %v", err)
+	}
+	if undone.Action != "add" || *note != original {
+		t.Errorf("expected the add to be undone, undid %+v leaving:\n%s", undone, *note)
+	}
+
+	if _, err := UndoLastWrite(conf); !errors.Is(err, ErrNothingToUndo) {
+		t.Errorf("expected ErrNothingToUndo, got %v", err)
+	}
+	if _, err := UndoLastWrite(config.Config{FilePath: "prompts.md", DataDir: conf.DataDir}); err == nil {
+		t.Error("expected error for a local file source")
+	}
+}
+
+func TestLastUndoable(t *testing.T) {
+	tests := []struct {
+		name     string
+		actions  []string
+		expected string
+		ok       bool
+	}{
+		{name: "empty log"},
+		{name: "single write", actions: []string{"add"}, expected: "add", ok: true},
+		{name: "undo cancels latest write", actions: []string{"add", "archive", ActionUndo}, expected: "add", ok: true},
+		{name: "everything undone", actions: []string{"add", ActionUndo}},
+		{name: "write after undo", actions: []string{"add", ActionUndo, "fmt"}, expected: "fmt", ok: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var records []WriteRecord
+			for _, action := range tt.actions {
+				records = append(records, WriteRecord{Action: action, Source: "note"}, WriteRecord{Action: "add", Source: "other"})
+			}
+			got, ok := lastUndoable(records, "note")
+			if ok != tt.ok || got.Action != tt.expected {
+				t.Errorf("lastUndoable() = %q, %v, want %q, %v", got.Action, ok, tt.expected, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package prompt
+
+import (
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/toozej/wheresmyprompt/internal/search"
+)
+
+// maxClipboardKeywords caps the number of keywords ClipboardKeywords returns.
+const maxClipboardKeywords = 5
+
+// firstLineWeight is added to the score of a keyword for each occurrence on the
+// first line of the clipboard, which usually holds the error message, while stack
+// traces and code repeat less telling words further down.
+const firstLineWeight = 2
+
+// Word lengths, in letters, outside which clipboard words are noise such as
+// single letters, hashes or encoded data rather than keywords.
+const (
+	minKeywordLength = 3
+	maxKeywordLength = 24
+)
+
+// keywordNoise are words common in error messages and code that say nothing about
+// what the clipboard is about.
+var keywordNoise = []string{
+	"but", "can", "cannot", "did", "does", "not", "was", "were", "has", "have", "had", "will",
+	"line", "file", "col", "column", "func", "function", "var", "const", "let", "return",
+	"null", "nil", "true", "false", "none", "undefined", "http", "https", "www", "com",
+}
+
+// ReadClipboard returns the contents of the system clipboard, using the same
+// utilities as CopyToClipboard. Errors wrap ErrClipboard.
+func ReadClipboard() (string, error) {
+	return readClipboardFunc()
+}
+
+// ClipboardKeywords returns the salient words of text, such as an error message or
+// a code snippet, most salient first: words that are repeated or appear on the
+// first line, which usually holds the error, rank higher. Identifiers are split
+// into words ("NullPointerException" into "null", "pointer" and "exception"), and
+// stop words, noise common in code and errors, numbers and words with digits are
+// left out.
+func ClipboardKeywords(text string) []string {
+	type keyword struct {
+		word  string
+		score int
+	}
+	var keywords []*keyword
+	index := map[string]*keyword{}
+	firstLine, _, _ := strings.Cut(strings.TrimSpace(text), "\n")
+	firstLineEnd := len(keywordWords(firstLine))
+
+	for i, word := range keywordWords(text) {
+		if !isKeyword(word) {
+			continue
+		}
+		k, ok := index[word]
+		if !ok {
+			k = &keyword{word: word}
+			index[word] = k
+			keywords = append(keywords, k)
+		}
+		k.score++
+		if i < firstLineEnd {
+			k.score += firstLineWeight
+		}
+	}
+
+	// Ties keep the order of first occurrence
+	sort.SliceStable(keywords, func(i, j int) bool {
+		return keywords[i].score > keywords[j].score
+	})
+	var words []string
+	for _, k := range keywords[:min(len(keywords), maxClipboardKeywords)] {
+		words = append(words, k.word)
+	}
+	return words
+}
+
+// keywordWords splits text into folded words of letters and digits, splitting
+// camelCase and PascalCase identifiers too.
+func keywordWords(text string) []string {
+	var words []string
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, search.Fold(string(word)))
+			word = word[:0]
+		}
+	}
+	runes := []rune(text)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush()
+			continue
+		}
+		// A capital starts a word after a lowercase letter ("parseJSON") or before one
+		// at the end of an acronym ("JSONParser")
+		if unicode.IsUpper(r) && len(word) > 0 {
+			prev := runes[i-1]
+			if unicode.IsLower(prev) || unicode.IsUpper(prev) && i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+				flush()
+			}
+		}
+		word = append(word, r)
+	}
+	flush()
+	return words
+}
+
+// isKeyword reports whether the folded word can be a clipboard keyword.
+func isKeyword(word string) bool {
+	n := len([]rune(word))
+	if n < minKeywordLength || n > maxKeywordLength {
+		return false
+	}
+	if strings.IndexFunc(word, unicode.IsDigit) >= 0 {
+		return false
+	}
+	return !slices.Contains(search.DefaultStopWords, word) && !slices.Contains(keywordNoise, word)
+}
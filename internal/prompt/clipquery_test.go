@@ -0,0 +1,53 @@
+package prompt
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestClipboardKeywords(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected []string
+	}{
+		{
+			name:     "go panic",
+			text:     "panic: runtime error: index out of range [5] with length 3\n\ngoroutine 1 [running]:\nmain.main()\n\t/home/me/app/main.go:12 +0x1d",
+			expected: []string{"panic", "runtime", "error", "index", "out"},
+		},
+		{
+			name:     "identifiers are split",
+			text:     "Exception in thread \"main\" java.lang.NullPointerException",
+			expected: []string{"exception", "thread", "main", "java", "lang"},
+		},
+		{
+			name:     "first line and repeated words rank higher",
+			text:     "TypeError: x is undefined\n  at render (app.js:3)\n  at render (app.js:9)\n  at render (app.js:12)",
+			expected: []string{"type", "error", "render", "app"},
+		},
+		{
+			name:     "acronyms",
+			text:     "parseJSON failed: JSONParser",
+			expected: []string{"json", "parse", "failed", "parser"},
+		},
+		{
+			name:     "noise only",
+			text:     "0x1f 42 a is the nil",
+			expected: nil,
+		},
+		{
+			name:     "empty",
+			text:     "",
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClipboardKeywords(tt.text); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("ClipboardKeywords(%q) = %v, want %v", tt.text, got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package prompt
+
+import (
+	"strings"
+
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+// CompletionCandidates returns the prompt titles and section names starting with
+// prefix, ignoring case, for shell completion of a query. Each candidate is the
+// heading followed by a tab and the headings above it, which shells show as its
+// description. Nothing is fetched or run, so completion stays instant: a local
+// file is read from the INDEX_CACHE index while unchanged and parsed otherwise,
+// and a Simplenote note is only completed from the index. Other sources, and
+// libraries that fail to load, complete nothing.
+func CompletionCandidates(conf config.Config, prefix string) []string {
+	var candidates []string
+	seen := map[string]bool{}
+	for _, sec := range completionSections(conf) {
+		// The first heading is the document title
+		for i := 1; i < len(sec.Headings); i++ {
+			heading := sec.Headings[i]
+			if seen[heading] || !strings.HasPrefix(strings.ToLower(heading), strings.ToLower(prefix)) {
+				continue
+			}
+			seen[heading] = true
+			candidates = append(candidates, heading+"\t"+headingDescription(strings.Join(sec.Headings[1:i], " > ")))
+		}
+	}
+	return candidates
+}
+
+// headingDescription returns the completion description of a heading below parents,
+// "section" for a top-level heading.
+func headingDescription(parents string) string {
+	if parents == "" {
+		return "section"
+	}
+	return parents
+}
+
+// completionSections returns the sections of the personal library known without
+// fetching anything, see CompletionCandidates.
+func completionSections(conf config.Config) []Section {
+	if isURLSource(conf.FilePath) {
+		return nil
+	}
+	if _, ok := customSourceFunc(conf); ok && conf.FilePath == "" {
+		return nil
+	}
+
+	idx := &sourceIndex{}
+	if path, err := indexPath(conf); err == nil {
+		idx = loadIndex(path)
+	}
+	entry, cached := idx.Entries[indexKey(conf.FilePath, conf.SNNote)]
+	if conf.FilePath == "" {
+		return entry.Sections
+	}
+
+	info, err := appFS.Stat(conf.FilePath)
+	if err != nil {
+		return nil
+	}
+	if cached && info.Size() == entry.Size && info.ModTime().Equal(entry.ModTime) {
+		return entry.Sections
+	}
+	content, err := loadFromFile(conf.FilePath)
+	if err != nil {
+		return nil
+	}
+	sections, _, err := parseMarkdown(strings.NewReader(content), conf, conf.FilePath)
+	if err != nil {
+		return nil
+	}
+	return sections
+}
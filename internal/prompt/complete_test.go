@@ -0,0 +1,57 @@
+package prompt
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+func TestCompletionCandidates(t *testing.T) {
+	useMemFS(t)
+	library := "# LLM Prompts\n\n## Golang\n\n### Code Review Checklist\nReview\n\n### Concurrency\nGoroutines\n\n## Python\n\n### Code Review Checklist\nPEP8\n"
+	if err := afero.WriteFile(appFS, "/prompts.md", []byte(library), 0600); err != nil {
+		t.Fatal(err)
+	}
+	idx := &sourceIndex{Version: indexVersion, Entries: map[string]indexEntry{
+		indexKey("", "Cached"): {Sections: []Section{{Headings: []string{"Cached", "Writing", "Cover letter"}}}},
+	}}
+	if err := saveIndex("/data/"+indexFileName, idx); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		conf     config.Config
+		prefix   string
+		expected []string
+	}{
+		{
+			name:     "local file, any case",
+			conf:     config.Config{FilePath: "/prompts.md"},
+			prefix:   "co",
+			expected: []string{"Code Review Checklist\tGolang", "Concurrency\tGolang"},
+		},
+		{
+			name:     "sections",
+			conf:     config.Config{FilePath: "/prompts.md"},
+			prefix:   "",
+			expected: []string{"Golang\tsection", "Code Review Checklist\tGolang", "Concurrency\tGolang", "Python\tsection"},
+		},
+		{name: "note from index", conf: config.Config{SNNote: "Cached", DataDir: "/data"}, prefix: "C", expected: []string{"Cover letter\tWriting"}},
+		{name: "note not indexed", conf: config.Config{SNNote: "LLM Prompts", DataDir: "/data"}, prefix: ""},
+		{name: "missing file", conf: config.Config{FilePath: "/missing.md"}, prefix: ""},
+		{name: "remote source", conf: config.Config{FilePath: "https://example.com/prompts.md"}, prefix: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := CompletionCandidates(tt.conf, tt.prefix)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("CompletionCandidates() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
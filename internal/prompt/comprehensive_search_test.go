@@ -29,7 +29,7 @@ Write unit tests for all functions
 Implement error handling throughout the application
 `
 
-	sections, err := parseMarkdownIntoSections(content)
+	sections, _, err := parseMarkdownIntoSections(content)
 	if err != nil {
 		t.Fatalf("Failed to parse markdown: %v", err)
 	}
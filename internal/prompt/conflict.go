@@ -0,0 +1,150 @@
+package prompt
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/toozej/wheresmyprompt/internal/search"
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+// Strategies for handling a new prompt whose title already exists in the target section.
+const (
+	ConflictReplace = "replace" // Overwrite the existing prompt's content
+	ConflictRename  = "rename"  // Add the prompt as a numbered variant, e.g. "Title (2)"
+	ConflictAbort   = "abort"   // Leave the note unchanged
+)
+
+// ErrPromptExists is returned when adding a prompt is aborted because its title already exists.
+var ErrPromptExists = errors.New("a prompt with this title already exists")
+
+// askConflictFunc allows tests to answer the interactive conflict question.
+var askConflictFunc = askConflict
+
+// resolveTitleConflict checks current for a prompt titled title in section and decides how
+// to proceed, using conf.OnConflict or asking on in and out when it is unset.
+// It returns the title the prompt is added under and the edit adding it to current:
+// inserting it, under a numbered title when renamed, or replacing the body of the
+// existing prompt.
+func resolveTitleConflict(conf config.Config, current, title, content, section string, in io.Reader, out io.Writer) (string, lineEdit, error) {
+	lines := strings.Split(current, "\n")
+	start, end, found := findPromptHeading(lines, title, section)
+	if !found {
+		return title, insertEdit(current, title, content, section), nil
+	}
+
+	strategy := conf.OnConflict
+	if strategy == "" {
+		var err error
+		if strategy, err = askConflictFunc(title, section, in, out); err != nil {
+			return "", lineEdit{}, err
+		}
+	}
+
+	switch strategy {
+	case ConflictReplace:
+		return title, replaceBodyEdit(lines, start, end, content), nil
+	case ConflictRename:
+		title = numberedTitle(lines, title, section)
+		return title, insertEdit(current, title, content, section), nil
+	case ConflictAbort:
+		return "", lineEdit{}, fmt.Errorf("%w: %q at %s", ErrPromptExists, title, search.Location(localFile(conf), start+1))
+	default:
+		return "", lineEdit{}, fmt.Errorf("invalid conflict strategy %q: must be one of %s, %s or %s",
+			strategy, ConflictReplace, ConflictRename, ConflictAbort)
+	}
+}
+
+// askOnConflict returns conf with OnConflict set to the answer to the conflict
+// question when current already has a prompt titled title in section and
+// OnConflict is unset, so the question can be asked before locking the source
+// rather than while holding the lock. Without a conflict it is set to abort, so a
+// prompt with the same title added by someone else in the meantime is not replaced.
+func askOnConflict(conf config.Config, current, title, section string, in io.Reader, out io.Writer) (config.Config, error) {
+	if conf.OnConflict != "" {
+		return conf, nil
+	}
+	if _, _, found := findPromptHeading(strings.Split(current, "\n"), title, section); !found {
+		conf.OnConflict = ConflictAbort
+		return conf, nil
+	}
+	strategy, err := askConflictFunc(title, section, in, out)
+	if err != nil {
+		return conf, err
+	}
+	conf.OnConflict = strategy
+	return conf, nil
+}
+
+// findPromptHeading locates the "### title" heading in section (or anywhere when section
+// is empty). It returns the heading's line index and the index of the next heading or the
+// end of the document, which bound the prompt's body.
+func findPromptHeading(lines []string, title, section string) (int, int, bool) {
+	heading := "### " + strings.TrimSpace(title)
+	current := ""
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "## ") {
+			current = headingName(strings.TrimPrefix(trimmed, "## "))
+			continue
+		}
+		if trimmed != heading || (section != "" && current != section) {
+			continue
+		}
+		end := i + 1
+		for end < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[end]), "#") {
+			end++
+		}
+		return i, end, true
+	}
+	return 0, 0, false
+}
+
+// replaceBodyEdit returns the edit replacing the body between the heading at start and
+// the next heading at end of lines with content. Blank lines before the next heading are kept.
+func replaceBodyEdit(lines []string, start, end int, content string) lineEdit {
+	keep := end
+	for keep > start+1 && strings.TrimSpace(lines[keep-1]) == "" {
+		keep--
+	}
+	return lineEdit{start: start + 1, end: keep, lines: strings.Split(strings.TrimRight(content, "\n"), "\n")}
+}
+
+// numberedTitle returns the first "title (N)", starting at 2, that is not already used in section.
+func numberedTitle(lines []string, title, section string) string {
+	for n := 2; ; n++ {
+		candidate := fmt.Sprintf("%s (%d)", title, n)
+		if _, _, found := findPromptHeading(lines, candidate, section); !found {
+			return candidate
+		}
+	}
+}
+
+// askConflict asks the user how to handle a duplicate title, writing the question to
+// out and reading the answer from in. End of input is treated as abort.
+func askConflict(title, section string, in io.Reader, out io.Writer) (string, error) {
+	where := "the note"
+	if section != "" {
+		where = fmt.Sprintf("section '%s'", section)
+	}
+	fmt.Fprintf(out, "A prompt titled '%s' already exists in %s.\n", title, where)
+
+	scanner := bufio.NewScanner(in)
+	for {
+		fmt.Fprint(out, "[r]eplace existing, add [n]umbered variant, or [a]bort? ")
+		if !scanner.Scan() {
+			return ConflictAbort, nil
+		}
+		switch strings.ToLower(strings.TrimSpace(scanner.Text())) {
+		case "r", "replace":
+			return ConflictReplace, nil
+		case "n", "numbered", "rename":
+			return ConflictRename, nil
+		case "a", "abort", "":
+			return ConflictAbort, nil
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package prompt
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+// SectionGroup is a set of "## " sections whose names only differ by case,
+// whitespace or pluralization, such as "Testing", "testing" and "Tests".
+type SectionGroup struct {
+	// Names lists the distinct section names in document order.
+	Names []string
+	// Prompts holds the number of prompts under each name.
+	Prompts []int
+	// Target is the name the sections are merged into. FindDuplicateSections
+	// proposes the name holding the most prompts.
+	Target string
+}
+
+// sectionBlock is a "## " heading line and the lines up to the next heading of
+// level one or two.
+type sectionBlock struct {
+	name       string
+	start, end int
+}
+
+// FindDuplicateSections returns the groups of near-duplicate sections in the
+// configured note, see SectionGroup.
+func FindDuplicateSections(conf config.Config) ([]SectionGroup, error) {
+	current, err := loadSourceContent(conf)
+	if err != nil {
+		return nil, err
+	}
+	return duplicateSections(current), nil
+}
+
+// MergeSections merges every group's sections into a single section named after
+// its Target, placed where the first of them is. Prompts keep their order, and the
+// note is rewritten with the usual locking. Groups whose sections no longer exist
+// are skipped.
+func MergeSections(conf config.Config, groups []SectionGroup) error {
+	return updateSourceContent(conf, writeOp{action: "dedupe-sections"}, func(current string) (string, error) {
+		for _, group := range groups {
+			if strings.TrimSpace(group.Target) == "" {
+				return "", fmt.Errorf("no target name to merge sections %q into", group.Names)
+			}
+			current = mergeSections(current, group)
+		}
+		return current, nil
+	})
+}
+
+// duplicateSections groups the "## " sections of content by normalized name,
+// returning the groups with more than one distinct name.
+func duplicateSections(content string) []SectionGroup {
+	lines := strings.Split(content, "\n")
+	var groups []SectionGroup
+	index := make(map[string]int)
+
+	for _, block := range sectionBlocks(lines) {
+		key := normalizeSectionName(block.name)
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, SectionGroup{})
+		}
+		g := &groups[i]
+		n := slices.Index(g.Names, block.name)
+		if n < 0 {
+			g.Names = append(g.Names, block.name)
+			g.Prompts = append(g.Prompts, 0)
+			n = len(g.Names) - 1
+		}
+		g.Prompts[n] += countBlockPrompts(lines[block.start+1 : block.end])
+	}
+
+	var duplicates []SectionGroup
+	for _, g := range groups {
+		if len(g.Names) < 2 {
+			continue
+		}
+		best := 0
+		for i, count := range g.Prompts {
+			if count > g.Prompts[best] {
+				best = i
+			}
+		}
+		g.Target = g.Names[best]
+		duplicates = append(duplicates, g)
+	}
+	return duplicates
+}
+
+// mergeSections returns content with the bodies of all sections named in
+// group.Names appended, in order, to the first of them, which is renamed to
+// group.Target.
+func mergeSections(content string, group SectionGroup) string {
+	lines := strings.Split(content, "\n")
+	var blocks []sectionBlock
+	for _, block := range sectionBlocks(lines) {
+		if slices.Contains(group.Names, block.name) {
+			blocks = append(blocks, block)
+		}
+	}
+	if len(blocks) == 0 {
+		return content
+	}
+
+	// Collect the merged section: heading, then each body without surrounding blank lines
+	section := []string{"## " + group.Target}
+	for _, block := range blocks {
+		body := trimBlankLines(lines[block.start+1 : block.end])
+		if len(body) == 0 {
+			continue
+		}
+		section = append(section, "")
+		section = append(section, body...)
+	}
+
+	var out []string
+	next := 0
+	for i, block := range blocks {
+		out = append(out, lines[next:block.start]...)
+		if i == 0 {
+			out = append(out, section...)
+			if block.end < len(lines) {
+				out = append(out, "")
+			}
+		}
+		next = block.end
+	}
+	out = append(out, lines[next:]...)
+
+	merged := strings.Join(out, "\n")
+	if strings.HasSuffix(content, "\n") {
+		merged = strings.TrimRight(merged, "\n") + "\n"
+	}
+	return merged
+}
+
+// sectionBlocks returns the "## " sections of lines, ignoring headings inside
+// fenced code blocks.
+func sectionBlocks(lines []string) []sectionBlock {
+	var blocks []sectionBlock
+	inFence := false
+	for i, line := range lines {
+		if isFence(line) {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+		level, text := parseHeading(line)
+		if level == 0 || level > 2 {
+			continue
+		}
+		if len(blocks) > 0 && blocks[len(blocks)-1].end < 0 {
+			blocks[len(blocks)-1].end = i
+		}
+		if level == 2 {
+			blocks = append(blocks, sectionBlock{name: text, start: i, end: -1})
+		}
+	}
+	if len(blocks) > 0 && blocks[len(blocks)-1].end < 0 {
+		blocks[len(blocks)-1].end = len(lines)
+	}
+	return blocks
+}
+
+// countBlockPrompts counts the non-blank lines of a section body that are not headings.
+func countBlockPrompts(lines []string) int {
+	count := 0
+	for _, line := range lines {
+		if strings.TrimSpace(line) != "" && !isHeadingLine(line) {
+			count++
+		}
+	}
+	return count
+}
+
+// trimBlankLines returns lines without leading and trailing blank lines.
+func trimBlankLines(lines []string) []string {
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// normalizeSectionName reduces a section name to a key shared by names that only
+// differ by case, whitespace or a plural or "-ing" ending: "Tests", "testing" and
+// " Test " all become "test". The stemming is deliberately rough since merges are
+// confirmed before they are applied.
+func normalizeSectionName(name string) string {
+	words := strings.Fields(strings.ToLower(name))
+	if len(words) == 0 {
+		return ""
+	}
+	last := words[len(words)-1]
+	switch {
+	case strings.HasSuffix(last, "ies") && len(last) > 4:
+		last = strings.TrimSuffix(last, "ies") + "y"
+	case strings.HasSuffix(last, "ches"), strings.HasSuffix(last, "shes"),
+		strings.HasSuffix(last, "sses"), strings.HasSuffix(last, "xes"):
+		last = strings.TrimSuffix(last, "es")
+	case strings.HasSuffix(last, "s") && !strings.HasSuffix(last, "ss") && len(last) > 3:
+		last = strings.TrimSuffix(last, "s")
+	}
+	if strings.HasSuffix(last, "ing") && len(last) > 5 {
+		last = strings.TrimSuffix(last, "ing")
+	}
+	words[len(words)-1] = last
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,167 @@
+package prompt
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+const duplicateSectionsMarkdown = `# Prompts
+
+## Testing
+
+### Table tests
+Write table-driven tests
+
+## Golang
+Review this Go code
+
+` + "```" + `
+## Tests
+` + "```" + `
+
+## testing
+Write fuzz tests
+
+## Tests
+
+### Mocks
+Generate mocks
+Verify the mocks
+
+## Golang
+Explain this goroutine leak
+`
+
+func TestNormalizeSectionName(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"Testing", "test"},
+		{"tests", "test"},
+		{" Test ", "test"},
+		{"Code  Review", "code review"},
+		{"Code Reviews", "code review"},
+		{"Strategies", "strategy"},
+		{"Patches", "patch"},
+		{"Classes", "class"},
+		{"Class", "class"},
+		{"Go", "go"},
+		{"Strings", "str"},
+		{"String", "str"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeSectionName(tt.name); got != tt.expected {
+				t.Errorf("normalizeSectionName(%q) = %q, want %q", tt.name, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDuplicateSections(t *testing.T) {
+	groups := duplicateSections(duplicateSectionsMarkdown)
+	expected := []SectionGroup{{
+		Names:   []string{"Testing", "testing", "Tests"},
+		Prompts: []int{1, 1, 2},
+		Target:  "Tests",
+	}}
+	if !reflect.DeepEqual(groups, expected) {
+		t.Errorf("duplicateSections() = %+v, want %+v", groups, expected)
+	}
+
+	if groups := duplicateSections("## Golang\nA\n\n## Python\nB\n"); len(groups) != 0 {
+		t.Errorf("expected no duplicates, got %+v", groups)
+	}
+}
+
+func TestMergeSections(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		group    SectionGroup
+		expected string
+	}{
+		{
+			name:    "merge into first section",
+			content: duplicateSectionsMarkdown,
+			group:   SectionGroup{Names: []string{"Testing", "testing", "Tests"}, Target: "Testing"},
+			expected: `# Prompts
+
+## Testing
+
+### Table tests
+Write table-driven tests
+
+Write fuzz tests
+
+### Mocks
+Generate mocks
+Verify the mocks
+
+## Golang
+Review this Go code
+
+` + "```" + `
+## Tests
+` + "```" + `
+
+## Golang
+Explain this goroutine leak
+`,
+		},
+		{
+			name:     "duplicate names of the same section",
+			content:  "## Golang\nA\n\n## Python\nB\n\n## Golang\nC\n",
+			group:    SectionGroup{Names: []string{"Golang"}, Target: "Go"},
+			expected: "## Go\n\nA\n\nC\n\n## Python\nB\n",
+		},
+		{
+			name:     "missing sections",
+			content:  "## Golang\nA\n",
+			group:    SectionGroup{Names: []string{"Python"}, Target: "Python"},
+			expected: "## Golang\nA\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mergeSections(tt.content, tt.group); got != tt.expected {
+				t.Errorf("mergeSections() =\n%q\nwant\n%q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestMergeSections_Source(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prompts.md")
+	if err := os.WriteFile(path, []byte(duplicateSectionsMarkdown), 0600); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+	conf := config.Config{FilePath: path}
+
+	groups, err := FindDuplicateSections(conf)
+	if err != nil {
+		t.Fatalf("FindDuplicateSections() returned error: %v", err)
+	}
+	if err := MergeSections(conf, groups); err != nil {
+		t.Fatalf("MergeSections() returned error: %v", err)
+	}
+	if groups, _ := FindDuplicateSections(conf); len(groups) != 0 {
+		t.Errorf("expected no duplicates after merging, got %+v", groups)
+	}
+
+	data, _ := os.ReadFile(path)
+	if expected := mergeSections(duplicateSectionsMarkdown, groups[0]); string(data) != expected {
+		t.Errorf("expected the sections merged into %q, got:\n%s", groups[0].Target, data)
+	}
+
+	if err := MergeSections(conf, []SectionGroup{{Names: []string{"Golang"}}}); err == nil {
+		t.Error("expected an error for a group without target")
+	}
+}
@@ -0,0 +1,119 @@
+package prompt
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+// Kinds of DiffEntry.
+const (
+	DiffAdded   = "added"
+	DiffRemoved = "removed"
+	DiffChanged = "changed"
+)
+
+// Source names accepted by SourceConfig besides a file path.
+const (
+	SourceSimplenote = "simplenote"
+	SourceTeam       = "team"
+)
+
+// DiffEntry is a section that differs between two prompt libraries.
+type DiffEntry struct {
+	Kind string   `json:"kind"`        // DiffAdded, DiffRemoved or DiffChanged
+	Path string   `json:"path"`        // The section's headings below the document title, joined with " > "
+	A    []string `json:"a,omitempty"` // The section's prompts in the first library
+	B    []string `json:"b,omitempty"` // The section's prompts in the second library
+}
+
+// SourceConfig returns conf reading prompts from source: "simplenote" for the
+// configured Simplenote note, "simplenote:<note>" for another note, "team" for the
+// team library, or the path of a Markdown file. An empty source leaves conf as is.
+func SourceConfig(conf config.Config, source string) (config.Config, error) {
+	switch {
+	case source == "":
+	case source == SourceTeam:
+		if !hasTeamLibrary(conf) {
+			return conf, fmt.Errorf("no team library configured: set TEAM_FILEPATH or TEAM_SN_NOTE")
+		}
+		conf = withoutCustomSource(conf)
+		conf.FilePath, conf.SNNote = conf.TeamFilePath, conf.TeamSNNote
+	case source == SourceSimplenote:
+		conf = withoutCustomSource(conf)
+		conf.FilePath = ""
+	case strings.HasPrefix(source, SourceSimplenote+":"):
+		conf = withoutCustomSource(conf)
+		conf.FilePath, conf.SNNote = "", strings.TrimPrefix(source, SourceSimplenote+":")
+	default:
+		conf.FilePath = source
+	}
+	return conf, nil
+}
+
+// DiffLibraries compares the libraries read by confA and confB section by section,
+// see SourceConfig. Sections are identified by their headings below the document
+// title, and their prompts are compared ignoring surrounding whitespace and blank
+// lines. Entries follow the order of the first library, followed by the sections
+// only found in the second one. Archived prompts are compared too.
+func DiffLibraries(confA, confB config.Config) ([]DiffEntry, error) {
+	a, err := loadSections(confA.FilePath, confA.SNNote, confA)
+	if err != nil {
+		return nil, err
+	}
+	b, err := loadSections(confB.FilePath, confB.SNNote, confB)
+	if err != nil {
+		return nil, err
+	}
+	return diffSections(a, b), nil
+}
+
+// diffSections compares two parsed libraries, see DiffLibraries.
+func diffSections(a, b []Section) []DiffEntry {
+	pathsA, promptsA := sectionPrompts(a)
+	pathsB, promptsB := sectionPrompts(b)
+
+	var entries []DiffEntry
+	for _, path := range pathsA {
+		other, ok := promptsB[path]
+		switch {
+		case !ok:
+			entries = append(entries, DiffEntry{Kind: DiffRemoved, Path: path, A: promptsA[path]})
+		case !slices.Equal(promptsA[path], other):
+			entries = append(entries, DiffEntry{Kind: DiffChanged, Path: path, A: promptsA[path], B: other})
+		}
+	}
+	for _, path := range pathsB {
+		if _, ok := promptsA[path]; !ok {
+			entries = append(entries, DiffEntry{Kind: DiffAdded, Path: path, B: promptsB[path]})
+		}
+	}
+	return entries
+}
+
+// sectionPrompts returns the heading path of every section in document order and
+// the trimmed, non-blank prompts under each. Sections sharing a path are combined.
+func sectionPrompts(sections []Section) ([]string, map[string][]string) {
+	var paths []string
+	prompts := make(map[string][]string)
+	for _, sec := range sections {
+		// The first heading is the document title
+		headings := sec.Headings
+		if len(headings) > 0 {
+			headings = headings[1:]
+		}
+		path := strings.Join(headings, " > ")
+		if _, ok := prompts[path]; !ok {
+			paths = append(paths, path)
+			prompts[path] = nil
+		}
+		for _, line := range sec.Lines {
+			if line = strings.TrimSpace(line); line != "" {
+				prompts[path] = append(prompts[path], line)
+			}
+		}
+	}
+	return paths, prompts
+}
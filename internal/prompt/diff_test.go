@@ -0,0 +1,80 @@
+package prompt
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+func TestDiffSections(t *testing.T) {
+	a := []Section{
+		{Headings: []string{"Prompts", "Golang", "Review"}, Lines: []string{"Review this code", ""}},
+		{Headings: []string{"Prompts", "Golang", "Tests"}, Lines: []string{"Write tests"}},
+		{Headings: []string{"Prompts", "Python"}, Lines: []string{"Explain"}},
+	}
+	b := []Section{
+		{Headings: []string{"Team", "Golang", "Review"}, Lines: []string{"Review this Go code"}},
+		{Headings: []string{"Team", "Golang", "Tests"}, Lines: []string{"  Write tests  "}},
+		{Headings: []string{"Team", "Rust"}, Lines: []string{"Borrow"}},
+	}
+
+	expected := []DiffEntry{
+		{Kind: DiffChanged, Path: "Golang > Review", A: []string{"Review this code"}, B: []string{"Review this Go code"}},
+		{Kind: DiffRemoved, Path: "Python", A: []string{"Explain"}},
+		{Kind: DiffAdded, Path: "Rust", B: []string{"Borrow"}},
+	}
+	if got := diffSections(a, b); !reflect.DeepEqual(got, expected) {
+		t.Errorf("diffSections() = %+v, want %+v", got, expected)
+	}
+	if got := diffSections(a, a); len(got) != 0 {
+		t.Errorf("expected no differences between identical libraries, got %+v", got)
+	}
+}
+
+func TestSourceConfig(t *testing.T) {
+	base := config.Config{FilePath: "mine.md", SNNote: "LLM Prompts", TeamSNNote: "Team Prompts"}
+	tests := []struct {
+		source   string
+		filePath string
+		note     string
+		wantErr  bool
+	}{
+		{source: "", filePath: "mine.md", note: "LLM Prompts"},
+		{source: "other.md", filePath: "other.md", note: "LLM Prompts"},
+		{source: "simplenote", note: "LLM Prompts"},
+		{source: "simplenote:Archive", note: "Archive"},
+		{source: "team", note: "Team Prompts"},
+	}
+	for _, tt := range tests {
+		got, err := SourceConfig(base, tt.source)
+		if err != nil || got.FilePath != tt.filePath || got.SNNote != tt.note {
+			t.Errorf("SourceConfig(%q) = %q, %q, %v, want %q, %q", tt.source, got.FilePath, got.SNNote, err, tt.filePath, tt.note)
+		}
+	}
+
+	if _, err := SourceConfig(config.Config{}, "team"); err == nil {
+		t.Error("expected an error for team without a team library")
+	}
+}
+
+func TestDiffLibraries(t *testing.T) {
+	dir := t.TempDir()
+	fileA, fileB := filepath.Join(dir, "a.md"), filepath.Join(dir, "b.md")
+	if err := os.WriteFile(fileA, []byte("# Prompts\n## Golang\nReview\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(fileB, []byte("# Team\n## Golang\nReview\n## Rust\nBorrow\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := DiffLibraries(config.Config{FilePath: fileA}, config.Config{FilePath: fileB})
+	if err != nil {
+		t.Fatalf("DiffLibraries() error = %v", err)
+	}
+	if len(entries) != 1 || entries[0].Kind != DiffAdded || entries[0].Path != "Rust" {
+		t.Errorf("expected Rust to be added, got %+v", entries)
+	}
+}
@@ -0,0 +1,111 @@
+package prompt
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+// ReplacePrompt replaces the first line of the configured source whose content
+// equals oldContent with newContent. Since each line is one prompt, newContent is
+// collapsed onto a single line. Returns an error if the prompt cannot be found or
+// the source is read-only.
+func ReplacePrompt(conf config.Config, oldContent, newContent string) error {
+	return updateSourceLines(conf, writeOp{action: "replace"}, func(current string) ([]lineEdit, error) {
+		edit, ok := replacePromptEdit(strings.Split(current, "\n"), Prompt{Content: oldContent}, newContent)
+		if !ok {
+			return nil, fmt.Errorf("prompt not found in source: %q", oldContent)
+		}
+		return []lineEdit{edit}, nil
+	})
+}
+
+// replacePromptEdit returns the edit replacing the lines of p, see findPrompt, with
+// newContent, keeping the first line's indentation. A prompt joined from wrapped
+// lines is replaced as a whole. A prompt grouped with its list items keeps
+// newContent's lines, without blank ones, so it stays one prompt. The boolean
+// result is false if p was not found.
+func replacePromptEdit(lines []string, p Prompt, newContent string) (lineEdit, bool) {
+	start, end, ok := findPrompt(lines, p)
+	if !ok {
+		return lineEdit{}, false
+	}
+	line := lines[start]
+	indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+
+	replacement := []string{indent + strings.Join(strings.Fields(newContent), " ")}
+	if strings.Contains(strings.TrimSpace(p.Content), "\n") {
+		replacement = nil
+		for l := range strings.SplitSeq(strings.TrimSpace(newContent), "\n") {
+			if strings.TrimSpace(l) != "" {
+				replacement = append(replacement, strings.TrimRight(l, " \t"))
+			}
+		}
+		if len(replacement) > 0 {
+			replacement[0] = indent + strings.TrimSpace(replacement[0])
+		}
+	}
+	return lineEdit{start: start, end: end, lines: replacement}, true
+}
+
+// findPrompt returns the range of lines holding p: the lines it was parsed from
+// (see Prompt.StartLine) when they still hold it, which tells identical prompts
+// apart, or else the first lines holding it, see findPromptLines.
+func findPrompt(lines []string, p Prompt) (start, end int, ok bool) {
+	if p.StartLine > 0 && p.StartLine <= p.EndLine && p.EndLine <= len(lines) {
+		start, end = p.StartLine-1, p.EndLine
+		if strings.Join(strings.Fields(strings.Join(lines[start:end], " ")), " ") == strings.Join(strings.Fields(p.Content), " ") {
+			return start, end, true
+		}
+	}
+	return findPromptLines(lines, p.Content)
+}
+
+// findPromptLines returns the range of lines holding promptContent: the first line
+// equal to it once trimmed or, failing that, the first run of consecutive non-blank
+// lines that joins into it, as parsed with JOIN_WRAPPED_LINES or GROUP_LIST_ITEMS.
+func findPromptLines(lines []string, promptContent string) (start, end int, ok bool) {
+	target := strings.TrimSpace(promptContent)
+	for i, line := range lines {
+		if strings.TrimSpace(line) == target {
+			return i, i + 1, true
+		}
+	}
+	target = strings.Join(strings.Fields(target), " ")
+	for i := range lines {
+		joined := strings.Join(strings.Fields(lines[i]), " ")
+		for j := i + 1; j < len(lines) && len(joined) < len(target) && strings.HasPrefix(target, joined); j++ {
+			next := strings.Join(strings.Fields(lines[j]), " ")
+			if next == "" {
+				break
+			}
+			joined += " " + next
+			if joined == target {
+				return i, j + 1, true
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+// ArchivePrompt moves a prompt into the archive section instead of deleting it.
+// The prompt is filed under a heading named after its original section so it can
+// be restored later. Archived prompts are excluded from searches unless
+// IncludeArchived is set. Returns an error if the prompt cannot be found.
+func ArchivePrompt(conf config.Config, p Prompt) error {
+	return updateSourceLines(conf, writeOp{action: "archive", title: p.Title, section: p.Section}, func(current string) ([]lineEdit, error) {
+		lines := strings.Split(current, "\n")
+		start, end, ok := findPrompt(lines, p)
+		if !ok {
+			return nil, fmt.Errorf("prompt not found in source: %q", p.Content)
+		}
+		remove := lineEdit{start: start, end: end}
+		title := p.Section
+		if title == "" {
+			title = "Unsorted"
+		}
+		archive := insertEdit(remove.apply(current), title, strings.TrimSpace(p.Content), conf.ArchiveSection)
+		return []lineEdit{remove, archive}, nil
+	})
+}
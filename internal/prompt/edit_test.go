@@ -0,0 +1,124 @@
+package prompt
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+func TestReplacePrompt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prompts.md")
+	if err := os.WriteFile(path, []byte(testMarkdownContent), 0600); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+	conf := config.Config{FilePath: path}
+
+	err := ReplacePrompt(conf, "Analyze this bug report and provide:", "You are a debugger.\nAnalyze this bug report and provide:")
+	if err != nil {
+		t.Fatalf("ReplacePrompt() returned error: %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), "\nYou are a debugger. Analyze this bug report and provide:\n") {
+		t.Errorf("expected prompt to be replaced on a single line, got:\n%s", data)
+	}
+
+	if err := ReplacePrompt(conf, "Nonexistent prompt", "anything"); err == nil {
+		t.Error("expected error for missing prompt")
+	}
+	if err := ReplacePrompt(config.Config{FilePath: path, ReadOnly: true}, "Overview", "x"); err == nil {
+		t.Error("expected error for read-only source")
+	}
+}
+
+func TestArchivePrompt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prompts.md")
+	if err := os.WriteFile(path, []byte(testMarkdownContent), 0600); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+	conf := config.Config{FilePath: path, ArchiveSection: "Archive"}
+
+	p := Prompt{Content: "Analyze this bug report and provide:", Section: "Bug Analysis"}
+	if err := ArchivePrompt(conf, p); err != nil {
+		t.Fatalf("ArchivePrompt() returned error: %v", err)
+	}
+
+	data, err := LoadPrompts(conf)
+	if err != nil {
+		t.Fatalf("LoadPrompts() returned error: %v", err)
+	}
+	for _, result := range SearchPromptRecords(data, "", "") {
+		if result.Content == p.Content {
+			t.Error("archived prompt should be excluded from search by default")
+		}
+	}
+
+	conf.IncludeArchived = true
+	data, _ = LoadPrompts(conf)
+	archived := SearchPromptRecords(data, "", "Archive")
+	if len(archived) != 1 || archived[0].Content != p.Content || archived[0].Section != "Bug Analysis" {
+		t.Errorf("expected prompt to be archived under its original section, got %+v", archived)
+	}
+
+	if err := ArchivePrompt(conf, Prompt{Content: "Nonexistent prompt"}); err == nil {
+		t.Error("expected error for missing prompt")
+	}
+}
+
+func TestFindPromptLines(t *testing.T) {
+	lines := []string{"## Review", "Review this code", "  for bugs.", "", "Single line"}
+	tests := []struct {
+		name       string
+		target     string
+		start, end int
+		ok         bool
+	}{
+		{name: "single line", target: "Single line", start: 4, end: 5, ok: true},
+		{name: "wrapped lines", target: "Review this code for bugs.", start: 1, end: 3, ok: true},
+		{name: "first wrapped line", target: "Review this code", start: 1, end: 2, ok: true},
+		{name: "across blank line", target: "for bugs. Single line"},
+		{name: "missing", target: "Nonexistent"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end, ok := findPromptLines(lines, tt.target)
+			if ok != tt.ok || (ok && (start != tt.start || end != tt.end)) {
+				t.Errorf("findPromptLines(%q) = %d, %d, %v, want %d, %d, %v", tt.target, start, end, ok, tt.start, tt.end, tt.ok)
+			}
+		})
+	}
+
+	edit, ok := replacePromptEdit(lines, Prompt{Content: "Review this code for bugs."}, "Review it")
+	if updated, expected := edit.apply(strings.Join(lines, "\n")), "## Review\nReview it\n\nSingle line"; !ok || updated != expected {
+		t.Errorf("replacePromptEdit() = %q, want %q", updated, expected)
+	}
+}
+
+func TestReplacePrompt_GroupedList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prompts.md")
+	if err := os.WriteFile(path, []byte(testMarkdownContent), 0600); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+	conf := config.Config{FilePath: path, GroupListItems: true}
+
+	data, err := LoadPrompts(conf)
+	if err != nil {
+		t.Fatalf("LoadPrompts() returned error: %v", err)
+	}
+	grouped := "Analyze this bug report and provide:\n1. Root cause analysis\n2. Proposed fix\n3. Prevention strategies"
+	if results := SearchPrompts(data, "", "Bug Analysis"); len(results) != 1 || results[0] != grouped {
+		t.Fatalf("expected the list grouped with its intro line, got %q", results)
+	}
+
+	if err := ReplacePrompt(conf, grouped, "Analyze this bug report:\n\n1. Root cause\n2. Fix"); err != nil {
+		t.Fatalf("ReplacePrompt() returned error: %v", err)
+	}
+	content, _ := os.ReadFile(path)
+	if !strings.Contains(string(content), "\nAnalyze this bug report:\n1. Root cause\n2. Fix\n\n") {
+		t.Errorf("expected the grouped prompt to be replaced as a whole, got:\n%s", content)
+	}
+}
@@ -0,0 +1,183 @@
+package prompt
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/afero"
+	"github.com/toozej/wheresmyprompt/internal/sandbox"
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+// ErrNotEditable is returned by EditPrompt for prompts of a team library or a
+// prompt pack, which are not edited through wheresmyprompt.
+var ErrNotEditable = errors.New("prompt cannot be edited")
+
+// defaultEditor is run when neither VISUAL nor EDITOR is set.
+const defaultEditor = "vi"
+
+// runEditorFunc allows tests to stand in for the user's editor.
+var runEditorFunc = runEditor
+
+// EditSession is a prompt source opened in the user's editor by EditPrompt. Run
+// Cmd attached to the terminal, then call Finish.
+type EditSession struct {
+	Cmd      *exec.Cmd // The editor, opening the file at the prompt's line
+	conf     config.Config
+	path     string // The file being edited
+	original string // The content of a temporary copy before editing, see temp
+	temp     bool   // path is a temporary copy of a note, written back by Finish
+}
+
+// EditPrompt prepares opening the source holding p in $VISUAL or $EDITOR (vi when
+// neither is set), at the line of p. A local file is edited in place, so the editor
+// shows the whole library around the prompt. Any other source, such as a Simplenote
+// note, is copied to a temporary file that Finish writes back once saved. A source
+// that must not be modified fails with ErrReadOnly before the editor is prepared.
+func EditPrompt(conf config.Config, p Prompt) (*EditSession, error) {
+	if p.Namespace == NamespaceTeam || strings.HasPrefix(p.Namespace, NamespacePackPrefix) {
+		return nil, fmt.Errorf("%w: it belongs to the %s library, only prompts of your own library can be opened", ErrNotEditable, p.Namespace)
+	}
+	conf = routedConfig(conf, p.Section)
+	if err := checkWritable(conf); err != nil {
+		return nil, err
+	}
+
+	s := &EditSession{conf: conf}
+	var content string
+	if file := localFile(conf); file != "" {
+		var err error
+		if content, err = loadFromFile(file); err != nil {
+			return nil, err
+		}
+		s.path = file
+	} else {
+		var err error
+		if content, err = loadSourceContent(conf); err != nil {
+			return nil, err
+		}
+		f, err := afero.TempFile(appFS, "", "wheresmyprompt-*.md")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create temporary file: %w", err)
+		}
+		_, err = f.WriteString(content)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			_ = appFS.Remove(f.Name())
+			return nil, fmt.Errorf("failed to write temporary file: %w", err)
+		}
+		s.path, s.original, s.temp = f.Name(), content, true
+	}
+
+	s.Cmd = editorCommand(editorName(), s.path, promptLine(strings.Split(content, "\n"), p))
+	return s, nil
+}
+
+// promptLine returns the 1-based line of lines where p starts, see findPrompt, or 1
+// when it is gone.
+func promptLine(lines []string, p Prompt) int {
+	if start, _, ok := findPrompt(lines, p); ok {
+		return start + 1
+	}
+	return 1
+}
+
+// Finish writes the edits of a temporary copy back to its source and removes the
+// copy. The copy is kept, and its path given in the error, when writing back fails
+// so no edits are lost. It does nothing for a local file, edited in place.
+func (s *EditSession) Finish() error {
+	if !s.temp {
+		return nil
+	}
+	edited, err := afero.ReadFile(appFS, s.path)
+	if err != nil {
+		return fmt.Errorf("failed to read edited file: %w", err)
+	}
+	if string(edited) == s.original {
+		return appFS.Remove(s.path)
+	}
+	err = updateSourceContent(s.conf, writeOp{action: "edit"}, func(current string) (string, error) {
+		if current != s.original {
+			return "", errors.New("the source changed while it was being edited")
+		}
+		return string(edited), nil
+	})
+	if err != nil {
+		return fmt.Errorf("%w; your edits are kept in %s", err, s.path)
+	}
+	return appFS.Remove(s.path)
+}
+
+// OpenInEditor opens the source holding p in the user's editor at its line and
+// waits for the editor to exit, writing back the edits of a source that is not a
+// local file. See EditPrompt.
+func OpenInEditor(conf config.Config, p Prompt) error {
+	s, err := EditPrompt(conf, p)
+	if err != nil {
+		return err
+	}
+	if err := runEditorFunc(s.Cmd); err != nil {
+		if s.temp {
+			_ = appFS.Remove(s.path)
+		}
+		return err
+	}
+	return s.Finish()
+}
+
+// runEditor runs cmd attached to the terminal.
+func runEditor(cmd *exec.Cmd) error {
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to run editor %s: %w", cmd.Path, err)
+	}
+	return nil
+}
+
+// editorName returns the editor command line configured in VISUAL or EDITOR.
+func editorName() string {
+	for _, name := range []string{"VISUAL", "EDITOR"} {
+		if editor := strings.TrimSpace(os.Getenv(name)); editor != "" {
+			return editor
+		}
+	}
+	return defaultEditor
+}
+
+// editorCommand returns the command opening path at line in editor, a command line
+// that may include arguments such as "code --wait". Editors taking the line as
+// "path:line", such as VS Code and Sublime Text, are told to wait for the file to be
+// closed; the others get the "+line" argument understood by vi, Emacs, nano and most
+// terminal editors.
+func editorCommand(editor, path string, line int) *exec.Cmd {
+	fields := strings.Fields(editor)
+	name, args := fields[0], fields[1:]
+	switch strings.TrimSuffix(filepath.Base(name), ".exe") {
+	case "code", "code-insiders", "codium", "cursor":
+		args = appendMissing(args, "--wait")
+		args = append(args, "--goto", path+":"+strconv.Itoa(line))
+	case "subl":
+		args = appendMissing(args, "--wait")
+		args = append(args, path+":"+strconv.Itoa(line))
+	default:
+		args = append(args, "+"+strconv.Itoa(line), path)
+	}
+	return sandbox.Command(name, args...) // #nosec G204 -- the user's own editor
+}
+
+// appendMissing appends arg to args unless it is already there.
+func appendMissing(args []string, arg string) []string {
+	for _, a := range args {
+		if a == arg || a == "-w" && arg == "--wait" {
+			return args
+		}
+	}
+	return append(args, arg)
+}
@@ -0,0 +1,187 @@
+package prompt
+
+import (
+	"errors"
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+// fakeEditor replaces the user's editor with edit, called with the path and the
+// arguments the editor was given, for the duration of the test.
+func fakeEditor(t *testing.T, edit func(path string, args []string) error) {
+	t.Helper()
+	original := runEditorFunc
+	t.Cleanup(func() { runEditorFunc = original })
+	runEditorFunc = func(cmd *exec.Cmd) error {
+		return edit(cmd.Args[len(cmd.Args)-1], cmd.Args[1:])
+	}
+}
+
+func TestEditorCommand(t *testing.T) {
+	tests := []struct {
+		editor   string
+		expected []string
+	}{
+		{"vim", []string{"vim", "+12", "prompts.md"}},
+		{"emacs -nw", []string{"emacs", "-nw", "+12", "prompts.md"}},
+		{"code", []string{"code", "--wait", "--goto", "prompts.md:12"}},
+		{"/usr/bin/code -w", []string{"/usr/bin/code", "-w", "--goto", "prompts.md:12"}},
+		{"subl", []string{"subl", "--wait", "prompts.md:12"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.editor, func(t *testing.T) {
+			if got := editorCommand(tt.editor, "prompts.md", 12).Args; !slices.Equal(got, tt.expected) {
+				t.Errorf("editorCommand(%q) = %v, want %v", tt.editor, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestOpenInEditor_LocalFile(t *testing.T) {
+	fs := useMemFS(t)
+	content := "# Prompts\n\n## Golang\n\nReview this Go code\n\nWrite Go tests\n"
+	if err := afero.WriteFile(fs, "/prompts.md", []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "nano")
+	var gotArgs []string
+	fakeEditor(t, func(path string, args []string) error {
+		gotArgs = args
+		return nil
+	})
+
+	err := OpenInEditor(config.Config{FilePath: "/prompts.md"}, Prompt{Content: "Write Go tests", Section: "Golang"})
+	if err != nil {
+		t.Fatalf("OpenInEditor() error = %v", err)
+	}
+	if !slices.Equal(gotArgs, []string{"+7", "/prompts.md"}) {
+		t.Errorf("editor arguments = %v, want the file at line 7", gotArgs)
+	}
+}
+
+func TestOpenInEditor_Simplenote(t *testing.T) {
+	original := "# Prompts\n\n## Golang\n\nReview this Go code\n"
+	tests := []struct {
+		name     string
+		edit     func(fs afero.Fs, path string) error
+		expected string
+		wantErr  bool
+	}{
+		{
+			name: "edits written back",
+			edit: func(fs afero.Fs, path string) error {
+				return afero.WriteFile(fs, path, []byte(strings.Replace(original, "Review this Go code", "Review this Go code for races", 1)), 0600)
+			},
+			expected: "# Prompts\n\n## Golang\n\nReview this Go code for races\n",
+		},
+		{
+			name:     "unchanged note not written",
+			edit:     func(afero.Fs, string) error { return nil },
+			expected: original,
+		},
+		{
+			name:     "editor failure",
+			edit:     func(afero.Fs, string) error { return errors.New("exit status 1") },
+			expected: original,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := useMemFS(t)
+			note := fakeSimplenote(t, original)
+			var tempPath string
+			fakeEditor(t, func(path string, _ []string) error {
+				tempPath = path
+				return tt.edit(fs, path)
+			})
+
+			err := OpenInEditor(config.Config{SNNote: "LLM Prompts", DataDir: t.TempDir()}, Prompt{Content: "Review this Go code", Section: "Golang"})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("OpenInEditor() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if *note != tt.expected {
+				t.Errorf("note = %q, want %q", *note, tt.expected)
+			}
+			if exists, _ := afero.Exists(fs, tempPath); exists {
+				t.Errorf("temporary file %s was not removed", tempPath)
+			}
+		})
+	}
+}
+
+func TestEditSession_FinishConflict(t *testing.T) {
+	fs := useMemFS(t)
+	note := fakeSimplenote(t, "# Prompts\n\n## Golang\n\nReview this Go code\n")
+	s, err := EditPrompt(config.Config{SNNote: "LLM Prompts", DataDir: t.TempDir()}, Prompt{Content: "Review this Go code"})
+	if err != nil {
+		t.Fatalf("EditPrompt() error = %v", err)
+	}
+	if err := afero.WriteFile(fs, s.path, []byte("# Prompts\n\nMy edit\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	*note = "# Prompts\n\nChanged elsewhere\n"
+
+	err = s.Finish()
+	if err == nil || !strings.Contains(err.Error(), s.path) {
+		t.Fatalf("Finish() error = %v, want a conflict naming the kept file", err)
+	}
+	if *note != "# Prompts\n\nChanged elsewhere\n" {
+		t.Errorf("note was overwritten: %q", *note)
+	}
+	if exists, _ := afero.Exists(fs, s.path); !exists {
+		t.Error("edited file was removed despite the conflict")
+	}
+}
+
+func TestEditPrompt_NotEditable(t *testing.T) {
+	for _, namespace := range []string{NamespaceTeam, NamespacePackPrefix + "go-review"} {
+		if _, err := EditPrompt(config.Config{FilePath: "/prompts.md"}, Prompt{Content: "x", Namespace: namespace}); !errors.Is(err, ErrNotEditable) {
+			t.Errorf("EditPrompt() of a %s prompt error = %v, want ErrNotEditable", namespace, err)
+		}
+	}
+}
+
+func TestOpenInEditor_ReadOnly(t *testing.T) {
+	fs := useMemFS(t)
+	if err := afero.WriteFile(fs, "/prompts.md", []byte("# Prompts\n\nWrite Go tests\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	fakeEditor(t, func(path string, args []string) error {
+		t.Errorf("the editor was started on %s of a read-only source", path)
+		return nil
+	})
+
+	err := OpenInEditor(config.Config{FilePath: "/prompts.md", ReadOnly: true}, Prompt{Content: "Write Go tests"})
+	if !errors.Is(err, ErrReadOnly) {
+		t.Errorf("OpenInEditor() error = %v, want ErrReadOnly", err)
+	}
+}
+
+func TestPromptLine(t *testing.T) {
+	lines := strings.Split("# Prompts\n\n## Golang\n\nWrite tests\n\n## Python\n\nWrite tests\nExplain\nthis code\n", "\n")
+	tests := []struct {
+		name     string
+		p        Prompt
+		expected int
+	}{
+		{"parsed line", Prompt{Content: "Write tests", StartLine: 9, EndLine: 9}, 9},
+		{"unknown line", Prompt{Content: "Write tests"}, 5},
+		{"stale line", Prompt{Content: "Write tests", StartLine: 10, EndLine: 10}, 5},
+		{"joined lines", Prompt{Content: "Explain this code", StartLine: 10, EndLine: 11}, 10},
+		{"gone", Prompt{Content: "Refactor"}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := promptLine(lines, tt.p); got != tt.expected {
+				t.Errorf("promptLine() = %d, want %d", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,293 @@
+package prompt
+
+import (
+	"archive/zip"
+	"crypto/sha1" // #nosec G505 -- only derives stable snippet identifiers
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Snippet export formats accepted by ExportSnippets.
+const (
+	SnippetFormatEspanso = "espanso"
+	SnippetFormatAlfred  = "alfred-snippets"
+)
+
+// Library export formats accepted by ExportLibrary, for loading prompts into
+// evaluation tooling.
+const (
+	LibraryFormatOpenAI    = "openai-jsonl"
+	LibraryFormatAnthropic = "anthropic-json"
+)
+
+// snippetPrefix starts every generated abbreviation so it doesn't fire while typing prose.
+const snippetPrefix = ":"
+
+// Snippet is a prompt with the abbreviation that expands to it.
+type Snippet struct {
+	Trigger string // The abbreviation, such as ":cr-go"
+	Name    string // The prompt's headings, shown by the expander
+	Content string // The prompt text the abbreviation expands to
+}
+
+// Snippets returns a snippet for each prompt, keyed by an abbreviation made of the
+// initials of its title and the first letters of the section above it: "Code
+// Review" under "Golang" becomes ":cr-go". Prompts without a title use the first
+// words of their content. Clashing abbreviations get a numeric suffix.
+func Snippets(prompts []Prompt) []Snippet {
+	snippets := make([]Snippet, 0, len(prompts))
+	used := make(map[string]int)
+	for _, p := range prompts {
+		headings := promptHeadings(p)
+		title, sections := splitTitle(headings)
+		group := ""
+		if len(sections) > 0 {
+			group = sections[len(sections)-1]
+		}
+		if title == "" {
+			title = strings.Join(firstWords(p.Content, 3), " ")
+		}
+
+		trigger := snippetPrefix + initials(title, 4)
+		if g := slug(group, 2); g != "" {
+			trigger += "-" + g
+		}
+		used[trigger]++
+		if n := used[trigger]; n > 1 {
+			trigger += strconv.Itoa(n)
+		}
+
+		snippets = append(snippets, Snippet{
+			Trigger: trigger,
+			Name:    strings.Join(headings, " > "),
+			Content: strings.TrimSpace(p.Content),
+		})
+	}
+	return snippets
+}
+
+// promptHeadings returns the headings above p without the document title, which all
+// prompts share.
+func promptHeadings(p Prompt) []string {
+	headings := strings.Split(p.Title, " > ")
+	if len(headings) > 1 {
+		headings = headings[1:]
+	}
+	return headings
+}
+
+// splitTitle splits the headings of a prompt into its title, the deepest of at least
+// two headings, and the sections above it. A single heading is a section.
+func splitTitle(headings []string) (title string, sections []string) {
+	if len(headings) < 2 {
+		return "", headings
+	}
+	return headings[len(headings)-1], headings[:len(headings)-1]
+}
+
+// ExportSnippets writes snippets to w in format: an espanso match file
+// (SnippetFormatEspanso) or an Alfred snippet collection (SnippetFormatAlfred),
+// which is a zip archive to import into Alfred's Snippets preferences.
+func ExportSnippets(w io.Writer, format string, snippets []Snippet) error {
+	switch format {
+	case SnippetFormatEspanso:
+		return writeEspanso(w, snippets)
+	case SnippetFormatAlfred:
+		return writeAlfredSnippets(w, snippets)
+	default:
+		return fmt.Errorf("unknown snippet format %q (expected %s or %s)", format, SnippetFormatEspanso, SnippetFormatAlfred)
+	}
+}
+
+// writeEspanso writes snippets as an espanso match file. Strings are written as JSON
+// strings, which are valid double-quoted YAML scalars.
+func writeEspanso(w io.Writer, snippets []Snippet) error {
+	var b strings.Builder
+	b.WriteString("# Generated by wheresmyprompt export; re-run the export instead of editing.\n")
+	b.WriteString("matches:\n")
+	for _, s := range snippets {
+		fmt.Fprintf(&b, "  - trigger: %s\n", yamlString(s.Trigger))
+		fmt.Fprintf(&b, "    replace: %s\n", yamlString(s.Content))
+		if s.Name != "" {
+			fmt.Fprintf(&b, "    label: %s\n", yamlString(s.Name))
+		}
+	}
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("failed to write espanso snippets: %w", err)
+	}
+	return nil
+}
+
+// alfredSnippet is the JSON document Alfred stores for each snippet of a collection.
+type alfredSnippet struct {
+	Snippet struct {
+		Snippet string `json:"snippet"`
+		UID     string `json:"uid"`
+		Name    string `json:"name"`
+		Keyword string `json:"keyword"`
+	} `json:"alfredsnippet"`
+}
+
+// writeAlfredSnippets writes snippets as an Alfred snippet collection: a zip archive
+// holding one JSON file per snippet.
+func writeAlfredSnippets(w io.Writer, snippets []Snippet) error {
+	zw := zip.NewWriter(w)
+	for _, s := range snippets {
+		var doc alfredSnippet
+		doc.Snippet.Snippet = s.Content
+		doc.Snippet.UID = snippetUID(s)
+		doc.Snippet.Name = s.Name
+		if doc.Snippet.Name == "" {
+			doc.Snippet.Name = s.Trigger
+		}
+		doc.Snippet.Keyword = s.Trigger
+
+		f, err := zw.Create(fmt.Sprintf("%s [%s].json", strings.TrimPrefix(s.Trigger, snippetPrefix), doc.Snippet.UID))
+		if err != nil {
+			return fmt.Errorf("failed to write Alfred snippets: %w", err)
+		}
+		enc := json.NewEncoder(f)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(doc); err != nil {
+			return fmt.Errorf("failed to write Alfred snippet %s: %w", s.Trigger, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to write Alfred snippets: %w", err)
+	}
+	return nil
+}
+
+// snippetUID derives a UUID-shaped identifier from the snippet, so re-importing an
+// export updates the existing snippets instead of duplicating them.
+func snippetUID(s Snippet) string {
+	sum := sha1.Sum([]byte(s.Trigger + "\x00" + s.Content)) // #nosec G401
+	h := fmt.Sprintf("%X", sum[:16])
+	return h[:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:]
+}
+
+// yamlString quotes s as a double-quoted YAML scalar.
+func yamlString(s string) string {
+	var b strings.Builder
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// initials returns the lowercase first letter or digit of up to n words of s.
+func initials(s string, n int) string {
+	var b strings.Builder
+	for _, word := range firstWords(s, n) {
+		b.WriteRune(unicode.ToLower([]rune(word)[0]))
+	}
+	return b.String()
+}
+
+// slug returns the first n letters or digits of s, lowercased.
+func slug(s string, n int) string {
+	var b []rune
+	for _, r := range strings.ToLower(s) {
+		if len(b) == n {
+			break
+		}
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b = append(b, r)
+		}
+	}
+	return string(b)
+}
+
+// firstWords returns up to n words of s, ignoring words without letters or digits
+// and leading punctuation such as list markers.
+func firstWords(s string, n int) []string {
+	var words []string
+	for _, field := range strings.Fields(s) {
+		if len(words) == n {
+			break
+		}
+		word := strings.TrimLeftFunc(field, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+		if word != "" {
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+// LibraryRecord is a prompt as exported by ExportLibrary. Messages hold the content
+// as a single user message, the shape chat-based evaluation tools read.
+type LibraryRecord struct {
+	Title    string           `json:"title"`
+	Tags     []string         `json:"tags"` // The sections above the prompt
+	Content  string           `json:"content"`
+	Messages []LibraryMessage `json:"messages"`
+}
+
+// LibraryMessage is a chat message of a LibraryRecord.
+type LibraryMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// libraryTitleWords is how many words of its content name a prompt without a title.
+const libraryTitleWords = 6
+
+// LibraryRecords returns a record for each prompt, titled by its deepest heading
+// and tagged with the sections above it. Prompts without a title are named after
+// the first words of their content.
+func LibraryRecords(prompts []Prompt) []LibraryRecord {
+	records := make([]LibraryRecord, 0, len(prompts))
+	for _, p := range prompts {
+		title, sections := splitTitle(promptHeadings(p))
+		if title == "" {
+			title = strings.Join(firstWords(p.Content, libraryTitleWords), " ")
+		}
+		tags := []string{}
+		for _, section := range sections {
+			if section != "" {
+				tags = append(tags, section)
+			}
+		}
+		content := strings.TrimSpace(p.Content)
+		records = append(records, LibraryRecord{
+			Title:    title,
+			Tags:     tags,
+			Content:  content,
+			Messages: []LibraryMessage{{Role: "user", Content: content}},
+		})
+	}
+	return records
+}
+
+// ExportLibrary writes records to w in format: JSON Lines with one record per line
+// for OpenAI fine-tuning and evals (LibraryFormatOpenAI), or a JSON document with
+// the records in "prompts" for Anthropic's prompt library (LibraryFormatAnthropic).
+func ExportLibrary(w io.Writer, format string, records []LibraryRecord) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	switch format {
+	case LibraryFormatOpenAI:
+		for _, r := range records {
+			if err := enc.Encode(r); err != nil {
+				return fmt.Errorf("failed to write OpenAI JSONL: %w", err)
+			}
+		}
+		return nil
+	case LibraryFormatAnthropic:
+		enc.SetIndent("", "  ")
+		doc := struct {
+			Prompts []LibraryRecord `json:"prompts"`
+		}{records}
+		if err := enc.Encode(doc); err != nil {
+			return fmt.Errorf("failed to write Anthropic prompt library: %w", err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown library format %q (expected %s or %s)", format, LibraryFormatOpenAI, LibraryFormatAnthropic)
+	}
+}
@@ -0,0 +1,131 @@
+package prompt
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSnippets(t *testing.T) {
+	prompts := []Prompt{
+		{Content: "Review this Go code", Title: "Prompts > Golang > Code Review"},
+		{Content: "Review it again", Title: "Prompts > Golang > Code Review"},
+		{Content: "- Optimize this Python code", Title: "Prompts > Python"},
+		{Content: "Loose prompt", Title: "Notes"},
+	}
+	expected := []Snippet{
+		{Trigger: ":cr-go", Name: "Golang > Code Review", Content: "Review this Go code"},
+		{Trigger: ":cr-go2", Name: "Golang > Code Review", Content: "Review it again"},
+		{Trigger: ":otp-py", Name: "Python", Content: "- Optimize this Python code"},
+		{Trigger: ":lp-no", Name: "Notes", Content: "Loose prompt"},
+	}
+	if got := Snippets(prompts); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Snippets() = %+v, want %+v", got, expected)
+	}
+}
+
+func TestExportSnippets(t *testing.T) {
+	snippets := []Snippet{{Trigger: ":cr-go", Name: "Golang > Code Review", Content: "Review \"this\" code\n- for bugs"}}
+
+	var espanso bytes.Buffer
+	if err := ExportSnippets(&espanso, SnippetFormatEspanso, snippets); err != nil {
+		t.Fatalf("ExportSnippets(espanso) returned error: %v", err)
+	}
+	expected := `matches:
+  - trigger: ":cr-go"
+    replace: "Review \"this\" code\n- for bugs"
+    label: "Golang > Code Review"
+`
+	if !strings.HasSuffix(espanso.String(), expected) {
+		t.Errorf("unexpected espanso output:\n%s", espanso.String())
+	}
+
+	var alfred bytes.Buffer
+	if err := ExportSnippets(&alfred, SnippetFormatAlfred, snippets); err != nil {
+		t.Fatalf("ExportSnippets(alfred) returned error: %v", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(alfred.Bytes()), int64(alfred.Len()))
+	if err != nil {
+		t.Fatalf("expected a zip archive: %v", err)
+	}
+	if len(zr.File) != 1 || !strings.HasPrefix(zr.File[0].Name, "cr-go [") {
+		t.Fatalf("expected one snippet file, got %d", len(zr.File))
+	}
+	f, _ := zr.File[0].Open()
+	data, _ := io.ReadAll(f)
+	var doc alfredSnippet
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("failed to decode snippet: %v", err)
+	}
+	if doc.Snippet.Keyword != ":cr-go" || doc.Snippet.Snippet != snippets[0].Content || doc.Snippet.UID != snippetUID(snippets[0]) {
+		t.Errorf("unexpected Alfred snippet: %+v", doc.Snippet)
+	}
+
+	if err := ExportSnippets(io.Discard, "textexpander", snippets); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
+
+func TestLibraryRecords(t *testing.T) {
+	prompts := []Prompt{
+		{Content: "Review this Go code\n", Title: "Prompts > Golang > Testing > Code Review"},
+		{Content: "- Optimize this Python code for speed and memory use", Title: "Prompts > Python"},
+	}
+	expected := []LibraryRecord{
+		{
+			Title:    "Code Review",
+			Tags:     []string{"Golang", "Testing"},
+			Content:  "Review this Go code",
+			Messages: []LibraryMessage{{Role: "user", Content: "Review this Go code"}},
+		},
+		{
+			Title:    "Optimize this Python code for speed",
+			Tags:     []string{"Python"},
+			Content:  "- Optimize this Python code for speed and memory use",
+			Messages: []LibraryMessage{{Role: "user", Content: "- Optimize this Python code for speed and memory use"}},
+		},
+	}
+	if got := LibraryRecords(prompts); !reflect.DeepEqual(got, expected) {
+		t.Errorf("LibraryRecords() = %+v, want %+v", got, expected)
+	}
+}
+
+func TestExportLibrary(t *testing.T) {
+	records := LibraryRecords([]Prompt{
+		{Content: "Review <this> code", Title: "Prompts > Golang > Code Review"},
+		{Content: "Loose prompt"},
+	})
+
+	var openai bytes.Buffer
+	if err := ExportLibrary(&openai, LibraryFormatOpenAI, records); err != nil {
+		t.Fatalf("ExportLibrary(openai) returned error: %v", err)
+	}
+	expected := `{"title":"Code Review","tags":["Golang"],"content":"Review <this> code","messages":[{"role":"user","content":"Review <this> code"}]}
+{"title":"Loose prompt","tags":[],"content":"Loose prompt","messages":[{"role":"user","content":"Loose prompt"}]}
+`
+	if openai.String() != expected {
+		t.Errorf("unexpected OpenAI JSONL:\n%s", openai.String())
+	}
+
+	var anthropic bytes.Buffer
+	if err := ExportLibrary(&anthropic, LibraryFormatAnthropic, records); err != nil {
+		t.Fatalf("ExportLibrary(anthropic) returned error: %v", err)
+	}
+	var doc struct {
+		Prompts []LibraryRecord `json:"prompts"`
+	}
+	if err := json.Unmarshal(anthropic.Bytes(), &doc); err != nil {
+		t.Fatalf("expected a JSON document: %v", err)
+	}
+	if !reflect.DeepEqual(doc.Prompts, records) {
+		t.Errorf("unexpected Anthropic prompt library: %+v", doc.Prompts)
+	}
+
+	if err := ExportLibrary(io.Discard, "csv", records); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
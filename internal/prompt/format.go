@@ -0,0 +1,108 @@
+package prompt
+
+import (
+	"strings"
+
+	"github.com/toozej/wheresmyprompt/internal/search"
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+// FormatMarkdown normalizes a prompt library: trailing whitespace is stripped, heading
+// markers are followed by exactly one space, every heading is preceded by exactly one
+// blank line and followed by none, other runs of blank lines are collapsed to one and
+// the document ends with a single newline. Fenced code blocks are left untouched.
+func FormatMarkdown(content string) string {
+	var out []string
+	inFence := false
+	pendingBlank := false
+
+	for _, line := range strings.Split(content, "\n") {
+		if inFence {
+			out = append(out, line)
+			if isFence(line) {
+				inFence = false
+			}
+			continue
+		}
+
+		line = strings.TrimRight(line, " \t")
+		if line == "" {
+			pendingBlank = true
+			continue
+		}
+
+		level, text := parseHeading(line)
+		switch {
+		case level > 0:
+			if len(out) > 0 {
+				out = append(out, "")
+			}
+			line = strings.Repeat("#", level) + " " + text
+		case pendingBlank && len(out) > 0 && !isHeadingLine(out[len(out)-1]):
+			out = append(out, "")
+		}
+		pendingBlank = false
+
+		out = append(out, line)
+		if isFence(line) {
+			inFence = true
+		}
+	}
+
+	if len(out) == 0 {
+		return ""
+	}
+	return strings.Join(out, "\n") + "\n"
+}
+
+// isFence reports whether line opens or closes a fenced code block.
+func isFence(line string) bool {
+	return search.IsFence(line)
+}
+
+// isHeadingLine reports whether line is a Markdown heading.
+func isHeadingLine(line string) bool {
+	level, _ := parseHeading(line)
+	return level > 0
+}
+
+// FormatSource normalizes the configured prompt source in place with FormatMarkdown.
+// It reports whether formatting changed the source.
+func FormatSource(conf config.Config) (bool, error) {
+	changed := false
+	err := updateSourceContent(conf, writeOp{action: "fmt"}, func(current string) (string, error) {
+		formatted := FormatMarkdown(current)
+		changed = formatted != current
+		return formatted, nil
+	})
+	return changed, err
+}
+
+// CheckFormatted reports whether the configured prompt source is already normalized.
+func CheckFormatted(conf config.Config) (bool, error) {
+	current, err := loadSourceContent(conf)
+	if err != nil {
+		return false, err
+	}
+	return FormatMarkdown(current) == current, nil
+}
+
+// autoFormat returns content normalized with FormatMarkdown when AUTO_FORMAT is enabled.
+func autoFormat(conf config.Config, content string) string {
+	if !conf.AutoFormat {
+		return content
+	}
+	return FormatMarkdown(content)
+}
+
+// autoFormatFile normalizes the local file at path in place when AUTO_FORMAT is enabled.
+func autoFormatFile(conf config.Config, path string) error {
+	if !conf.AutoFormat {
+		return nil
+	}
+	content, err := readLocalFile(path)
+	if err != nil {
+		return err
+	}
+	return writeLocalFile(path, FormatMarkdown(content))
+}
@@ -0,0 +1,96 @@
+package prompt
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+func TestFormatMarkdown(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "empty document",
+			input:    "\n\n",
+			expected: "",
+		},
+		{
+			name:     "blank lines around headings",
+			input:    "\n\n# Notes\n## Golang\n\n\n### Tests\n\nWrite tests\n### Review\nReview code",
+			expected: "# Notes\n\n## Golang\n\n### Tests\nWrite tests\n\n### Review\nReview code\n",
+		},
+		{
+			name:     "trailing spaces and heading spacing",
+			input:    "##   Golang  \n###  Tests\t\nWrite tests   \n\n\n\nWith detail  \n",
+			expected: "## Golang\n\n### Tests\nWrite tests\n\nWith detail\n",
+		},
+		{
+			name:     "fenced code block untouched",
+			input:    "### Example\n```\n# not a heading  \n\n\nkeep\n```\nafter\n",
+			expected: "### Example\n```\n# not a heading  \n\n\nkeep\n```\nafter\n",
+		},
+		{
+			name:     "already formatted",
+			input:    "# Notes\n\n## Golang\n\n### Tests\nWrite tests\n",
+			expected: "# Notes\n\n## Golang\n\n### Tests\nWrite tests\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := FormatMarkdown(tt.input)
+			if result != tt.expected {
+				t.Errorf("FormatMarkdown() =\n%q\nwant\n%q", result, tt.expected)
+			}
+			if again := FormatMarkdown(result); again != result {
+				t.Errorf("FormatMarkdown() is not idempotent:\n%q\n%q", result, again)
+			}
+		})
+	}
+}
+
+func TestFormatSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prompts.md")
+	if err := os.WriteFile(path, []byte("## Golang  \n\n\n### Tests\nWrite tests"), 0600); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+	conf := config.Config{FilePath: path}
+
+	if formatted, err := CheckFormatted(conf); err != nil || formatted {
+		t.Errorf("CheckFormatted() = %v, %v; want false, nil", formatted, err)
+	}
+	changed, err := FormatSource(conf)
+	if err != nil || !changed {
+		t.Fatalf("FormatSource() = %v, %v; want true, nil", changed, err)
+	}
+	if formatted, err := CheckFormatted(conf); err != nil || !formatted {
+		t.Errorf("CheckFormatted() after FormatSource() = %v, %v; want true, nil", formatted, err)
+	}
+	if changed, _ := FormatSource(conf); changed {
+		t.Error("expected no changes when formatting twice")
+	}
+}
+
+func TestAddPromptToNote_AutoFormat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prompts.md")
+	if err := os.WriteFile(path, []byte("## Golang   \n\n\n### Tests\nWrite tests   "), 0600); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+	conf := config.Config{FilePath: path, AutoFormat: true}
+
+	if err := addPromptToNote(conf, "Review", "Review this code", "", strings.NewReader(""), io.Discard); err != nil {
+		t.Fatalf("addPromptToNote() returned error: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	expected := "## Golang\n\n### Tests\nWrite tests\n\n### Review\nReview this code\n"
+	if string(data) != expected {
+		t.Errorf("file content =\n%q\nwant\n%q", data, expected)
+	}
+}
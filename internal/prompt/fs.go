@@ -0,0 +1,7 @@
+package prompt
+
+import "github.com/spf13/afero"
+
+// appFS is the filesystem local prompt files, sncli's database and the files in the
+// data directory are read from and written to. Tests swap in afero.NewMemMapFs().
+var appFS afero.Fs = afero.NewOsFs()
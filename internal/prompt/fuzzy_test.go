@@ -23,7 +23,7 @@ Generate a diagram using PlantUML and outputting a SVG graphic file which descri
 `
 
 	// Parse content
-	sections, err := parseMarkdownIntoSections(content)
+	sections, _, err := parseMarkdownIntoSections(content)
 	if err != nil {
 		t.Fatalf("Failed to parse markdown: %v", err)
 	}
@@ -0,0 +1,335 @@
+package prompt
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+
+	"github.com/toozej/wheresmyprompt/internal/httpclient"
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+// Google endpoints, variables so tests can point them at a fake server.
+var (
+	googleOAuthURL    = "https://oauth2.googleapis.com"
+	googleDriveAPIURL = "https://www.googleapis.com/drive/v3"
+	googleDocsURL     = "https://docs.google.com"
+	googleDriveURL    = "https://drive.google.com"
+)
+
+// googleScope is the OAuth scope requested by the device flow.
+const googleScope = "https://www.googleapis.com/auth/drive.readonly"
+
+// googleDocMimeType is the Drive MIME type of Google Docs, which are exported
+// rather than downloaded.
+const googleDocMimeType = "application/vnd.google-apps.document"
+
+// googleTokenFile is the name of the OAuth token file inside the data directory.
+const googleTokenFile = "google-token.json"
+
+// googleSleep and googleNow allow tests to run the device flow without waiting.
+var (
+	googleSleep = time.Sleep
+	googleNow   = time.Now
+)
+
+// googleDeviceOutput receives the device flow instructions for the user.
+var googleDeviceOutput io.Writer = os.Stderr
+
+// googleFileIDPattern matches Google Drive file IDs.
+var googleFileIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{10,}$`)
+
+// googleDocSource is a read-only Source pulling the library from a Google Doc,
+// exported as Markdown, or a Markdown or text file stored in Google Drive, selected
+// with GOOGLE_DOC. Without GOOGLE_CLIENT_ID the document must be shared with anyone
+// holding the link; otherwise it is read through the Drive API with a token obtained
+// by the OAuth device flow. Every download is cached in the data directory, and the
+// cache is used while it is younger than GOOGLE_CACHE_MAX_AGE, when Google cannot be
+// reached or when OFFLINE is set.
+type googleDocSource struct {
+	conf config.Config
+}
+
+func (s googleDocSource) Name() string {
+	return s.conf.GoogleDoc + " (Google Drive)"
+}
+
+func (s googleDocSource) Check() error {
+	if _, _, err := googleFileID(s.conf.GoogleDoc); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s googleDocSource) Load() (string, error) {
+	id, driveFile, err := googleFileID(s.conf.GoogleDoc)
+	if err != nil {
+		return "", err
+	}
+	dir, err := config.ResolveDataDir(s.conf)
+	if err != nil {
+		return "", err
+	}
+	cache := filepath.Join(dir, "google-"+id+".md")
+
+	info, statErr := appFS.Stat(cache)
+	if statErr == nil && s.conf.GoogleCacheMaxAge > 0 && googleNow().Sub(info.ModTime()) < s.conf.GoogleCacheMaxAge {
+		log.Debugf("Using the cached copy of Google Drive file %s", id)
+		return loadFromFile(cache)
+	}
+	if isOffline(s.conf) {
+		if statErr != nil {
+			return "", fmt.Errorf("%w: no cached copy of Google Drive file %s (%s)", ErrOffline, id, offlineReason(s.conf))
+		}
+		warnCached("Google Drive file "+id, info.ModTime(), offlineReason(s.conf))
+		return loadFromFile(cache)
+	}
+
+	content, err := s.download(id, driveFile)
+	if err != nil {
+		noteNetworkError(err)
+		if statErr != nil {
+			return "", err
+		}
+		warnCached("Google Drive file "+id, info.ModTime(), err)
+		return loadFromFile(cache)
+	}
+	content = normalizeText(content)
+	if err := appFS.MkdirAll(dir, 0700); err != nil {
+		log.Warn("Failed to cache the Google Drive file: ", err)
+	} else if err := afero.WriteFile(appFS, cache, []byte(content), 0600); err != nil {
+		log.Warn("Failed to cache the Google Drive file: ", err)
+	}
+	return content, nil
+}
+
+func (s googleDocSource) Save(string) error {
+	return fmt.Errorf("%w: %s is a read-only source", ErrReadOnly, s.Name())
+}
+
+func (googleDocSource) readOnly() {}
+
+// googleFileID extracts the file ID from a Google Docs or Drive URL, or returns ref
+// itself if it is an ID. driveFile is true for Drive file links, which are
+// downloaded rather than exported when the document is public.
+func googleFileID(ref string) (id string, driveFile bool, err error) {
+	id = ref
+	if u, err := url.Parse(ref); err == nil && u.Host != "" {
+		driveFile = strings.HasPrefix(u.Host, "drive.")
+		id = u.Query().Get("id")
+		parts := strings.Split(u.Path, "/")
+		for i, part := range parts {
+			if part == "d" && i+1 < len(parts) {
+				id = parts[i+1]
+			}
+		}
+	}
+	if !googleFileIDPattern.MatchString(id) {
+		return "", false, fmt.Errorf("invalid GOOGLE_DOC %q: must be a Google Docs or Drive link or file ID", ref)
+	}
+	return id, driveFile, nil
+}
+
+// download fetches the file id, through the Drive API when GOOGLE_CLIENT_ID is set
+// and from its public export or download link otherwise.
+func (s googleDocSource) download(id string, driveFile bool) (string, error) {
+	if s.conf.GoogleClientID == "" {
+		link := googleDocsURL + "/document/d/" + url.PathEscape(id) + "/export?format=md"
+		if driveFile {
+			link = googleDriveURL + "/uc?export=download&id=" + url.QueryEscape(id)
+		}
+		return googleGet(link, "")
+	}
+
+	token, err := s.token()
+	if err != nil {
+		return "", err
+	}
+	metadata, err := googleGet(googleDriveAPIURL+"/files/"+url.PathEscape(id)+"?fields=mimeType&supportsAllDrives=true", token)
+	if err != nil {
+		return "", err
+	}
+	var file struct {
+		MimeType string `json:"mimeType"`
+	}
+	if err := json.Unmarshal([]byte(metadata), &file); err != nil {
+		return "", fmt.Errorf("failed to decode Google Drive file metadata: %w", err)
+	}
+	if file.MimeType == googleDocMimeType {
+		return googleGet(googleDriveAPIURL+"/files/"+url.PathEscape(id)+"/export?mimeType=text/markdown", token)
+	}
+	return googleGet(googleDriveAPIURL+"/files/"+url.PathEscape(id)+"?alt=media&supportsAllDrives=true", token)
+}
+
+// googleGet returns the body of a GET request to link, authorized with token if set.
+func googleGet(link, token string) (string, error) {
+	req, err := http.NewRequest(http.MethodGet, link, nil)
+	if err != nil {
+		return "", err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := httpclient.Client().Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to reach Google Drive: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %s from Google Drive; check that the file exists and is shared with you", resp.Status)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read Google Drive file: %w", err)
+	}
+	return string(body), nil
+}
+
+// googleToken is the OAuth token stored in the data directory.
+type googleToken struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token"`
+	Expiry       time.Time `json:"expiry"`
+}
+
+// googleTokenResponse is a response of Google's token endpoint.
+type googleTokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"`
+	Error        string `json:"error"`
+}
+
+// token returns a valid access token, refreshing the stored token or running the
+// device flow when needed, and stores the result.
+func (s googleDocSource) token() (string, error) {
+	dir, err := config.ResolveDataDir(s.conf)
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, googleTokenFile)
+
+	var stored googleToken
+	if data, err := afero.ReadFile(appFS, path); err == nil {
+		if err := json.Unmarshal(data, &stored); err != nil {
+			log.Warn("Ignoring the unreadable Google token: ", err)
+		}
+	}
+	if stored.AccessToken != "" && googleNow().Add(time.Minute).Before(stored.Expiry) {
+		return stored.AccessToken, nil
+	}
+
+	var resp googleTokenResponse
+	if stored.RefreshToken != "" {
+		resp, err = s.requestToken(url.Values{"grant_type": {"refresh_token"}, "refresh_token": {stored.RefreshToken}})
+		if err != nil {
+			log.Debugf("Refreshing the Google token failed, signing in again: %v", err)
+		}
+	}
+	if resp.AccessToken == "" {
+		if resp, err = s.deviceFlow(); err != nil {
+			return "", err
+		}
+	}
+
+	token := googleToken{
+		AccessToken:  resp.AccessToken,
+		RefreshToken: resp.RefreshToken,
+		Expiry:       googleNow().Add(time.Duration(resp.ExpiresIn) * time.Second),
+	}
+	if token.RefreshToken == "" {
+		token.RefreshToken = stored.RefreshToken
+	}
+	data, _ := json.Marshal(token)
+	if err := appFS.MkdirAll(dir, 0700); err != nil {
+		log.Warn("Failed to store the Google token: ", err)
+	} else if err := afero.WriteFile(appFS, path, data, 0600); err != nil {
+		log.Warn("Failed to store the Google token: ", err)
+	}
+	return token.AccessToken, nil
+}
+
+// deviceFlow signs in with the OAuth device flow: it asks the user to enter a code
+// on Google's verification page and polls until they have approved access.
+func (s googleDocSource) deviceFlow() (googleTokenResponse, error) {
+	var device struct {
+		DeviceCode      string `json:"device_code"`
+		UserCode        string `json:"user_code"`
+		VerificationURL string `json:"verification_url"`
+		ExpiresIn       int    `json:"expires_in"`
+		Interval        int    `json:"interval"`
+	}
+	if err := googlePost(googleOAuthURL+"/device/code", url.Values{"client_id": {s.conf.GoogleClientID}, "scope": {googleScope}}, &device); err != nil {
+		return googleTokenResponse{}, fmt.Errorf("failed to start Google sign-in: %w", err)
+	}
+	fmt.Fprintf(googleDeviceOutput, "To let wheresmyprompt read %s, visit %s and enter the code %s\n", s.conf.GoogleDoc, device.VerificationURL, device.UserCode)
+
+	interval := time.Duration(max(device.Interval, 1)) * time.Second
+	deadline := googleNow().Add(time.Duration(device.ExpiresIn) * time.Second)
+	for googleNow().Before(deadline) {
+		googleSleep(interval)
+		resp, err := s.requestToken(url.Values{
+			"grant_type":  {"urn:ietf:params:oauth:grant-type:device_code"},
+			"device_code": {device.DeviceCode},
+		})
+		switch {
+		case resp.AccessToken != "":
+			return resp, nil
+		case resp.Error == "authorization_pending":
+		case resp.Error == "slow_down":
+			interval += 5 * time.Second
+		default:
+			return googleTokenResponse{}, fmt.Errorf("failed to sign in to Google: %w", err)
+		}
+	}
+	return googleTokenResponse{}, fmt.Errorf("the Google sign-in code expired before access was approved")
+}
+
+// requestToken posts params with the client credentials to the token endpoint.
+// The response is returned even on failure so its error code can be inspected.
+func (s googleDocSource) requestToken(params url.Values) (googleTokenResponse, error) {
+	params.Set("client_id", s.conf.GoogleClientID)
+	params.Set("client_secret", s.conf.GoogleClientSecret)
+	var resp googleTokenResponse
+	err := googlePost(googleOAuthURL+"/token", params, &resp)
+	if err == nil && resp.AccessToken == "" {
+		err = errors.New("no access token in the response")
+	}
+	return resp, err
+}
+
+// googlePost posts params as a form to link and decodes the JSON response into out,
+// also when the status reports an error, since OAuth errors are described in it.
+func googlePost(link string, params url.Values, out any) error {
+	resp, err := httpclient.Client().PostForm(link, params)
+	if err != nil {
+		return fmt.Errorf("failed to reach Google: %w", err)
+	}
+	defer resp.Body.Close()
+	var oauthErr struct {
+		Error string `json:"error"`
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read Google response: %w", err)
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to decode Google response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		_ = json.Unmarshal(body, &oauthErr)
+		return fmt.Errorf("unexpected status %s from Google: %s", resp.Status, oauthErr.Error)
+	}
+	return nil
+}
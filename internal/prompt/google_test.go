@@ -0,0 +1,170 @@
+package prompt
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+const googleTestDoc = "# Prompts\r\n\r\n## Golang\r\n\r\n### Review\r\nReview this Go code.\r\n"
+
+// fakeGoogle serves Google's OAuth, Drive API and public export endpoints,
+// counting the requests to each path. Token polling is pending until approved.
+type fakeGoogle struct {
+	mu       sync.Mutex
+	requests map[string]int
+	polls    int
+	down     bool
+}
+
+func (f *fakeGoogle) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests[r.URL.Path]++
+	if f.down {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	authorized := r.Header.Get("Authorization") == "Bearer access"
+	switch {
+	case r.URL.Path == "/document/d/1AbCdEfGhIjK/export" && r.URL.Query().Get("format") == "md":
+		_, _ = w.Write([]byte(googleTestDoc))
+	case r.URL.Path == "/device/code":
+		_, _ = w.Write([]byte(`{"device_code": "device", "user_code": "ABCD-EFGH", "verification_url": "https://www.google.com/device", "expires_in": 1800, "interval": 5}`))
+	case r.URL.Path == "/token" && r.FormValue("grant_type") == "refresh_token":
+		_, _ = w.Write([]byte(`{"access_token": "access", "expires_in": 3600}`))
+	case r.URL.Path == "/token":
+		if f.polls++; f.polls < 2 {
+			w.WriteHeader(http.StatusPreconditionRequired)
+			_, _ = w.Write([]byte(`{"error": "authorization_pending"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"access_token": "access", "refresh_token": "refresh", "expires_in": 3600}`))
+	case r.URL.Path == "/files/1AbCdEfGhIjK" && authorized:
+		_, _ = w.Write([]byte(`{"mimeType": "application/vnd.google-apps.document"}`))
+	case r.URL.Path == "/files/1AbCdEfGhIjK/export" && authorized && r.URL.Query().Get("mimeType") == "text/markdown":
+		_, _ = w.Write([]byte(googleTestDoc))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// newFakeGoogle points the Google endpoints at a fake server and uses an in-memory
+// filesystem for the cache and token.
+func newFakeGoogle(t *testing.T) *fakeGoogle {
+	t.Helper()
+	useMemFS(t)
+	f := &fakeGoogle{requests: map[string]int{}}
+	server := httptest.NewServer(f)
+	t.Cleanup(server.Close)
+
+	oauth, driveAPI, docs, drive := googleOAuthURL, googleDriveAPIURL, googleDocsURL, googleDriveURL
+	sleep, output := googleSleep, googleDeviceOutput
+	t.Cleanup(func() {
+		googleOAuthURL, googleDriveAPIURL, googleDocsURL, googleDriveURL = oauth, driveAPI, docs, drive
+		googleSleep, googleDeviceOutput = sleep, output
+	})
+	googleOAuthURL, googleDriveAPIURL, googleDocsURL, googleDriveURL = server.URL, server.URL, server.URL, server.URL
+	googleSleep = func(time.Duration) {}
+	return f
+}
+
+func TestGoogleFileID(t *testing.T) {
+	tests := []struct {
+		ref       string
+		wantID    string
+		wantDrive bool
+		wantErr   bool
+	}{
+		{"1AbCdEfGhIjK", "1AbCdEfGhIjK", false, false},
+		{"https://docs.google.com/document/d/1AbCdEfGhIjK/edit?usp=sharing", "1AbCdEfGhIjK", false, false},
+		{"https://drive.google.com/file/d/1AbCdEfGhIjK/view", "1AbCdEfGhIjK", true, false},
+		{"https://drive.google.com/open?id=1AbCdEfGhIjK", "1AbCdEfGhIjK", true, false},
+		{"https://docs.google.com/document/", "", false, true},
+		{"../prompts", "", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			id, drive, err := googleFileID(tt.ref)
+			if (err != nil) != tt.wantErr || id != tt.wantID || drive != tt.wantDrive {
+				t.Errorf("googleFileID(%q) = %q, %v, %v, want %q, %v, error %v", tt.ref, id, drive, err, tt.wantID, tt.wantDrive, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGoogleDocSource_Public(t *testing.T) {
+	f := newFakeGoogle(t)
+	conf := config.Config{GoogleDoc: "https://docs.google.com/document/d/1AbCdEfGhIjK/edit", GoogleCacheMaxAge: time.Hour, DataDir: "/data", SNNote: "LLM Prompts"}
+
+	data, err := LoadPrompts(conf)
+	if err != nil {
+		t.Fatalf("LoadPrompts() error = %v", err)
+	}
+	if results := SearchPrompts(data, "review", "Golang"); len(results) != 1 || results[0] != "Review this Go code." {
+		t.Errorf("SearchPrompts() = %q, want the document's prompt", results)
+	}
+	if cached, _ := afero.ReadFile(appFS, "/data/google-1AbCdEfGhIjK.md"); !strings.Contains(string(cached), "### Review\nReview this Go code.") {
+		t.Errorf("expected the document to be cached, got %q", cached)
+	}
+
+	// A fresh cache is used without downloading the document again
+	if _, err := (googleDocSource{conf: conf}).Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if n := f.requests["/document/d/1AbCdEfGhIjK/export"]; n != 1 {
+		t.Errorf("expected 1 download with a fresh cache, got %d", n)
+	}
+
+	// An outdated cache is used when Google cannot be reached
+	conf.GoogleCacheMaxAge = 0
+	f.down = true
+	if content, err := (googleDocSource{conf: conf}).Load(); err != nil || !strings.Contains(content, "Review this Go code.") {
+		t.Errorf("Load() = %q, %v, want the cached document", content, err)
+	}
+	if _, err := (googleDocSource{conf: config.Config{GoogleDoc: "2AbCdEfGhIjK", DataDir: "/data"}}).Load(); err == nil {
+		t.Error("expected Load() to fail without a cached copy")
+	}
+
+	if err := AddPrompt(conf, "Tests", "Write table-driven tests.", "Golang"); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("AddPrompt() error = %v, want ErrReadOnly", err)
+	}
+}
+
+func TestGoogleDocSource_DeviceFlow(t *testing.T) {
+	f := newFakeGoogle(t)
+	var output bytes.Buffer
+	googleDeviceOutput = &output
+	conf := config.Config{GoogleDoc: "1AbCdEfGhIjK", GoogleClientID: "client", GoogleClientSecret: "secret", DataDir: "/data"}
+
+	content, err := (googleDocSource{conf: conf}).Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !strings.Contains(content, "Review this Go code.") {
+		t.Errorf("Load() = %q, want the exported document", content)
+	}
+	if !strings.Contains(output.String(), "enter the code ABCD-EFGH") {
+		t.Errorf("expected sign-in instructions, got %q", output.String())
+	}
+	if token, _ := afero.ReadFile(appFS, "/data/"+googleTokenFile); !strings.Contains(string(token), `"refresh_token":"refresh"`) {
+		t.Errorf("expected the token to be stored, got %s", token)
+	}
+
+	// The stored token is reused without signing in again
+	if _, err := (googleDocSource{conf: conf}).Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if n := f.requests["/device/code"]; n != 1 {
+		t.Errorf("expected a single sign-in, got %d", n)
+	}
+}
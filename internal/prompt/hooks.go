@@ -0,0 +1,106 @@
+package prompt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/toozej/wheresmyprompt/internal/sandbox"
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+// Hook events, each run with the command configured in its HOOK_* variable.
+const (
+	HookPreCopy   = "pre_copy"   // Before a prompt is copied; a failure cancels the copy
+	HookPostCopy  = "post_copy"  // After a prompt is copied
+	HookPreWrite  = "pre_write"  // Before a prompt is added; a failure cancels the write
+	HookPostWrite = "post_write" // After a prompt is added
+)
+
+// hookTimeout bounds how long a hook command may run.
+const hookTimeout = 30 * time.Second
+
+// ErrHookFailed is returned when a pre hook command fails, cancelling the action.
+var ErrHookFailed = errors.New("hook failed")
+
+// runHookCommandFunc allows tests to observe hook commands instead of running them.
+var runHookCommandFunc = runHookCommand
+
+// hookCommand returns the command configured for event, empty if none.
+func hookCommand(conf config.Config, event string) string {
+	switch event {
+	case HookPreCopy:
+		return conf.HookPreCopy
+	case HookPostCopy:
+		return conf.HookPostCopy
+	case HookPreWrite:
+		return conf.HookPreWrite
+	case HookPostWrite:
+		return conf.HookPostWrite
+	default:
+		return ""
+	}
+}
+
+// hookEnv returns the variables describing event and p that hook commands receive
+// in addition to the environment of wheresmyprompt.
+func hookEnv(event string, p Prompt) []string {
+	return []string{
+		"WMP_EVENT=" + event,
+		"WMP_SECTION=" + p.Section,
+		"WMP_TITLE=" + p.Title,
+		"WMP_NAMESPACE=" + p.Namespace,
+		"WMP_PROMPT=" + p.Content,
+	}
+}
+
+// RunHook runs the command configured for event, if any, with p described in its
+// environment. Pre hooks return an error wrapping ErrHookFailed when the command
+// fails, so the caller can cancel the action; post hook failures are only logged,
+// since the action has already happened.
+func RunHook(conf config.Config, event string, p Prompt) error {
+	command := hookCommand(conf, event)
+	if command == "" {
+		return nil
+	}
+	err := runHookCommandFunc(command, hookEnv(event, p))
+	if err == nil {
+		return nil
+	}
+	if strings.HasPrefix(event, "pre_") {
+		return fmt.Errorf("%w: %s: %w", ErrHookFailed, event, err)
+	}
+	log.Warnf("%s hook failed: %v", event, err)
+	return nil
+}
+
+// runHookCommand runs command with the platform shell, adding env to the current
+// environment. Its output is logged at debug level so it cannot draw over the TUI,
+// and its error output is included in the error if it fails.
+func runHookCommand(command string, env []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), hookTimeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = sandbox.CommandContext(ctx, "cmd", "/C", command) // #nosec G204 -- configured by the user
+	} else {
+		cmd = sandbox.CommandContext(ctx, "sh", "-c", command) // #nosec G204 -- configured by the user
+	}
+	cmd.Env = append(os.Environ(), env...)
+	out, err := cmd.Output()
+	if len(out) > 0 {
+		log.Debugf("Hook %q output: %s", command, strings.TrimSpace(string(out)))
+	}
+	if err != nil {
+		return fmt.Errorf("command %q failed: %w", command, commandError(err))
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package prompt
+
+import (
+	"errors"
+	"runtime"
+	"slices"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+func TestRunHook(t *testing.T) {
+	original := runHookCommandFunc
+	defer func() { runHookCommandFunc = original }()
+
+	p := Prompt{Content: "Review this code", Section: "Golang", Title: "Code Review"}
+	conf := config.Config{HookPreCopy: "check", HookPostCopy: "notify-send 'Prompt copied'"}
+
+	tests := []struct {
+		name       string
+		event      string
+		commandErr error
+		command    string // The command expected to run, empty for none
+		err        error
+	}{
+		{name: "pre hook succeeds", event: HookPreCopy, command: "check"},
+		{name: "pre hook fails", event: HookPreCopy, commandErr: errors.New("exit status 1"), command: "check", err: ErrHookFailed},
+		{name: "post hook failure is only logged", event: HookPostCopy, commandErr: errors.New("exit status 1"), command: "notify-send 'Prompt copied'"},
+		{name: "no hook configured", event: HookPostWrite},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ran string
+			var env []string
+			runHookCommandFunc = func(command string, e []string) error {
+				ran, env = command, e
+				return tt.commandErr
+			}
+
+			err := RunHook(conf, tt.event, p)
+			if !errors.Is(err, tt.err) || (tt.err == nil && err != nil) {
+				t.Errorf("RunHook() error = %v, want %v", err, tt.err)
+			}
+			if ran != tt.command {
+				t.Errorf("RunHook() ran %q, want %q", ran, tt.command)
+			}
+			if tt.command != "" && (!slices.Contains(env, "WMP_EVENT="+tt.event) || !slices.Contains(env, "WMP_SECTION=Golang") ||
+				!slices.Contains(env, "WMP_TITLE=Code Review") || !slices.Contains(env, "WMP_PROMPT=Review this code")) {
+				t.Errorf("RunHook() environment = %v, want the event and prompt", env)
+			}
+		})
+	}
+}
+
+func TestRunHookCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	if err := runHookCommand(`test "$WMP_SECTION" = Golang`, []string{"WMP_SECTION=Golang"}); err != nil {
+		t.Errorf("runHookCommand() error = %v", err)
+	}
+	if err := runHookCommand("echo broken >&2; exit 3", nil); err == nil {
+		t.Error("expected an error from a failing command")
+	}
+}
+
+func TestAddPrompt_PreWriteHookCancels(t *testing.T) {
+	fs := useMemFS(t)
+	original := runHookCommandFunc
+	defer func() { runHookCommandFunc = original }()
+	runHookCommandFunc = func(string, []string) error { return errors.New("exit status 1") }
+
+	conf := config.Config{FilePath: "/prompts.md", HookPreWrite: "false"}
+	if err := AddPrompt(conf, "Leaks", "Explain goroutine leaks", "Golang"); !errors.Is(err, ErrHookFailed) {
+		t.Fatalf("AddPrompt() error = %v, want ErrHookFailed", err)
+	}
+	if exists, _ := afero.Exists(fs, "/prompts.md"); exists {
+		t.Error("expected the write to be cancelled")
+	}
+}
@@ -0,0 +1,169 @@
+package prompt
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+
+	"github.com/toozej/wheresmyprompt/internal/search"
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+// indexFileName is the name of the parsed library index inside the data directory.
+const indexFileName = "index.gob"
+
+// indexVersion is bumped whenever the parser or the index format changes, so indexes
+// written by older versions are rebuilt instead of trusted.
+const indexVersion = 3
+
+// sourceIndex holds the parsed sections of every prompt source loaded with INDEX_CACHE.
+type sourceIndex struct {
+	Version int
+	Entries map[string]indexEntry // Keyed by indexKey
+}
+
+// indexEntry is a parsed prompt source and what it was parsed from.
+type indexEntry struct {
+	ModTime  time.Time // Modification time of a local file; zero for Simplenote notes
+	Size     int64     // Size of a local file
+	Hash     string    // Hex SHA-256 of the source content
+	Options  search.ParseOptions
+	Sections []Section
+	Warnings []Warning
+}
+
+// indexKey names a prompt source in the index.
+func indexKey(filePath, note string) string {
+	if filePath != "" {
+		if abs, err := filepath.Abs(filePath); err == nil {
+			filePath = abs
+		}
+		return "file:" + filePath
+	}
+	return "simplenote:" + note
+}
+
+// indexPath returns the location of the index.
+func indexPath(conf config.Config) (string, error) {
+	dir, err := config.ResolveDataDir(conf)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, indexFileName), nil
+}
+
+// indexedSections parses the library like diagnoseSections, reusing the sections
+// stored in the index when the source has not changed. A local file whose size and
+// modification time match the index is not even read; otherwise the source is
+// hashed and only parsed if its content changed. The index is updated afterwards;
+// failing to read or write it only costs a full parse.
+func indexedSections(filePath, note string, conf config.Config) ([]Section, []Warning, error) {
+	path, err := indexPath(conf)
+	if err != nil {
+		return nil, nil, err
+	}
+	idx := loadIndex(path)
+	key := indexKey(filePath, note)
+	entry, cached := idx.Entries[key]
+	opts := parseOptions(conf, filePath)
+	if entry.Options != opts {
+		cached = false
+	}
+
+	var content []byte
+	var info os.FileInfo
+	if filePath != "" {
+		info, err = appFS.Stat(filePath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read file %s: %w", filePath, err)
+		}
+		if cached && info.Size() == entry.Size && info.ModTime().Equal(entry.ModTime) {
+			log.Debugf("Using indexed sections of %s", filePath)
+			return entry.Sections, entry.Warnings, nil
+		}
+		content, err = afero.ReadFile(appFS, filePath) // #nosec G304
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read file %s: %w", filePath, err)
+		}
+	} else {
+		noteConf := conf
+		noteConf.SNNote = note
+		text, err := loadNoteForSearch(noteConf)
+		if err != nil {
+			return nil, nil, err
+		}
+		content = []byte(text)
+	}
+
+	sum := sha256.Sum256(content)
+	hash := hex.EncodeToString(sum[:])
+	if !cached || hash != entry.Hash {
+		sections, warnings, err := parseMarkdown(bytes.NewReader(content), conf, filePath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse markdown content: %w", err)
+		}
+		entry = indexEntry{Hash: hash, Options: opts, Sections: sections, Warnings: warnings}
+	}
+	if info != nil {
+		entry.ModTime, entry.Size = info.ModTime(), info.Size()
+	}
+
+	idx.Entries[key] = entry
+	if err := saveIndex(path, idx); err != nil {
+		log.Debug("Failed to update the prompt index: ", err)
+	}
+	return entry.Sections, entry.Warnings, nil
+}
+
+// loadIndex reads the index, returning an empty index if it is missing, corrupt or
+// written by another index version.
+func loadIndex(path string) *sourceIndex {
+	empty := &sourceIndex{Version: indexVersion, Entries: map[string]indexEntry{}}
+	data, err := afero.ReadFile(appFS, path) // #nosec G304
+	if err != nil {
+		return empty
+	}
+	var idx sourceIndex
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&idx); err != nil || idx.Version != indexVersion || idx.Entries == nil {
+		return empty
+	}
+	return &idx
+}
+
+// saveIndex writes the index, creating the data directory if needed. It is written
+// to a temporary file and renamed into place so concurrent runs never read a
+// partial index.
+func saveIndex(path string, idx *sourceIndex) error {
+	if err := appFS.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create data directory: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(idx); err != nil {
+		return fmt.Errorf("failed to encode prompt index: %w", err)
+	}
+	tmp, err := afero.TempFile(appFS, filepath.Dir(path), strings.TrimSuffix(indexFileName, ".gob")+"-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write prompt index: %w", err)
+	}
+	defer appFS.Remove(tmp.Name())
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write prompt index: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write prompt index: %w", err)
+	}
+	if err := appFS.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write prompt index: %w", err)
+	}
+	return nil
+}
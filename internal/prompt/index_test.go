@@ -0,0 +1,97 @@
+package prompt
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+func TestIndexedSections(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "prompts.md")
+	if err := os.WriteFile(file, []byte("# Prompts\n## Golang\nReview this code\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	conf := config.Config{DataDir: dir, IndexCache: true, FilePath: file}
+	indexFile := filepath.Join(dir, indexFileName)
+
+	// tamper replaces the indexed sections so reuse of the index can be observed
+	tamper := func() {
+		idx := loadIndex(indexFile)
+		entry := idx.Entries[indexKey(file, "")]
+		entry.Sections = []Section{{Headings: []string{"Prompts", "Indexed"}, Lines: []string{"from index"}}}
+		idx.Entries[indexKey(file, "")] = entry
+		if err := saveIndex(indexFile, idx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	firstLine := func() string {
+		t.Helper()
+		sections, _, err := diagnoseSections(file, "", conf)
+		if err != nil {
+			t.Fatalf("diagnoseSections() error = %v", err)
+		}
+		return sections[len(sections)-1].Lines[0]
+	}
+
+	if got := firstLine(); got != "Review this code" {
+		t.Errorf("expected the parsed file, got %q", got)
+	}
+	if _, ok := loadIndex(indexFile).Entries[indexKey(file, "")]; !ok {
+		t.Fatal("expected the file to be indexed")
+	}
+
+	tamper()
+	if got := firstLine(); got != "from index" {
+		t.Errorf("expected an unchanged file to be read from the index, got %q", got)
+	}
+
+	// Same content with a new modification time is matched by hash
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(file, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if got := firstLine(); got != "from index" {
+		t.Errorf("expected a touched file with the same content to be read from the index, got %q", got)
+	}
+
+	conf.JoinWrappedLines = true
+	if got := firstLine(); got != "Review this code" {
+		t.Errorf("expected changed parse options to parse again, got %q", got)
+	}
+
+	tamper()
+	if err := os.WriteFile(file, []byte("# Prompts\n## Golang\nWrite table tests\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if got := firstLine(); got != "Write table tests" {
+		t.Errorf("expected a changed file to be parsed again, got %q", got)
+	}
+}
+
+func TestIndexedSections_Simplenote(t *testing.T) {
+	dir := t.TempDir()
+	fakeSimplenote(t, "# Prompts\n## Golang\nReview this code\n")
+	conf := config.Config{DataDir: dir, IndexCache: true, SNNote: "LLM Prompts"}
+
+	sections, _, err := diagnoseSections("", conf.SNNote, conf)
+	if err != nil || len(sections) != 1 || sections[0].Lines[0] != "Review this code" {
+		t.Fatalf("diagnoseSections() = %+v, %v", sections, err)
+	}
+	if _, ok := loadIndex(filepath.Join(dir, indexFileName)).Entries[indexKey("", conf.SNNote)]; !ok {
+		t.Error("expected the note to be indexed")
+	}
+}
+
+func TestLoadIndex_Corrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), indexFileName)
+	if err := os.WriteFile(path, []byte("not gob"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if idx := loadIndex(path); idx.Version != indexVersion || len(idx.Entries) != 0 {
+		t.Errorf("expected an empty index, got %+v", idx)
+	}
+}
@@ -0,0 +1,268 @@
+package prompt
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/toozej/wheresmyprompt/internal/httpclient"
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+// joplinItem is a folder, tag or note as returned by Joplin's Data API.
+type joplinItem struct {
+	ID       string `json:"id"`
+	Title    string `json:"title"`
+	Body     string `json:"body,omitempty"`
+	ParentID string `json:"parent_id,omitempty"`
+}
+
+// joplinPage is one page of a Joplin Data API listing.
+type joplinPage struct {
+	Items   []joplinItem `json:"items"`
+	HasMore bool         `json:"has_more"`
+}
+
+// joplinSource is a Source backed by Joplin notes, selected with JOPLIN_NOTEBOOK or
+// JOPLIN_TAG and reached through the Data API of Joplin's Web Clipper service on
+// localhost. Each note is a section named after its title, its body holding the
+// section's prompts. Saving updates the notes whose section changed and creates a
+// note for each new section; notes are never deleted. Headings in a note that would
+// start a section are demoted, see joplinBody.
+type joplinSource struct {
+	notebook string
+	tag      string
+	token    string
+	port     int
+}
+
+// newJoplinSource returns the Joplin source configured by conf.
+func newJoplinSource(conf config.Config) joplinSource {
+	return joplinSource{notebook: conf.JoplinNotebook, tag: conf.JoplinTag, token: conf.JoplinToken, port: conf.JoplinPort}
+}
+
+func (s joplinSource) Name() string {
+	if s.tag != "" {
+		return "#" + s.tag + " (Joplin)"
+	}
+	return s.notebook + " (Joplin)"
+}
+
+func (s joplinSource) Check() error {
+	if err := s.request(http.MethodGet, "/ping", nil, nil, nil); err != nil {
+		return fmt.Errorf("cannot reach Joplin on port %d; enable its Web Clipper service: %w", s.port, err)
+	}
+	return nil
+}
+
+func (s joplinSource) Load() (string, error) {
+	notes, _, err := s.notes()
+	if err != nil {
+		return "", err
+	}
+	title := s.notebook
+	if s.tag != "" {
+		title = s.tag
+	}
+	var b strings.Builder
+	b.WriteString("# " + title + "\n")
+	for _, n := range notes {
+		b.WriteString("\n## " + n.Title + "\n")
+		if body := joplinBody(n.Body); body != "" {
+			b.WriteString(body + "\n")
+		}
+	}
+	return b.String(), nil
+}
+
+func (s joplinSource) Save(content string) error {
+	notes, parentID, err := s.notes()
+	if err != nil {
+		return err
+	}
+	byTitle := make(map[string]joplinItem, len(notes))
+	for _, n := range notes {
+		byTitle[n.Title] = n
+	}
+
+	for _, section := range joplinSections(content) {
+		note, ok := byTitle[section.Title]
+		if !ok {
+			if err := s.createNote(section, parentID); err != nil {
+				return err
+			}
+			continue
+		}
+		if section.Body == joplinBody(note.Body) {
+			continue
+		}
+		if err := s.request(http.MethodPut, "/notes/"+note.ID, nil, joplinItem{Body: section.Body}, nil); err != nil {
+			return fmt.Errorf("failed to update Joplin note %s: %w", note.Title, err)
+		}
+	}
+	return nil
+}
+
+// createNote creates a note for section in the notebook parentID, tagging it when
+// notes are selected by tag.
+func (s joplinSource) createNote(section joplinItem, parentID string) error {
+	section.ParentID = parentID
+	var created joplinItem
+	if err := s.request(http.MethodPost, "/notes", nil, section, &created); err != nil {
+		return fmt.Errorf("failed to create Joplin note %s: %w", section.Title, err)
+	}
+	if s.tag == "" {
+		return nil
+	}
+	tag, err := s.find("/tags", "tag", s.tag)
+	if err != nil {
+		return err
+	}
+	if err := s.request(http.MethodPost, "/tags/"+tag.ID+"/notes", nil, joplinItem{ID: created.ID}, nil); err != nil {
+		return fmt.Errorf("failed to tag Joplin note %s: %w", section.Title, err)
+	}
+	return nil
+}
+
+// notes returns the selected notes, ordered by title, and the notebook new notes
+// are created in (empty for Joplin's default notebook when selecting by tag).
+func (s joplinSource) notes() ([]joplinItem, string, error) {
+	path, parentID := "", ""
+	if s.tag != "" {
+		tag, err := s.find("/tags", "tag", s.tag)
+		if err != nil {
+			return nil, "", err
+		}
+		path = "/tags/" + tag.ID + "/notes"
+	} else {
+		folder, err := s.find("/folders", "notebook", s.notebook)
+		if err != nil {
+			return nil, "", err
+		}
+		path, parentID = "/folders/"+folder.ID+"/notes", folder.ID
+	}
+	notes, err := s.list(path, url.Values{"fields": {"id,title,body"}, "order_by": {"title"}, "order_dir": {"ASC"}})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list Joplin notes: %w", err)
+	}
+	return notes, parentID, nil
+}
+
+// find returns the notebook or tag, named kind in errors, listed at path whose title
+// is title, ignoring case.
+func (s joplinSource) find(path, kind, title string) (joplinItem, error) {
+	items, err := s.list(path, url.Values{"fields": {"id,title"}})
+	if err != nil {
+		return joplinItem{}, fmt.Errorf("failed to list Joplin %ss: %w", kind, err)
+	}
+	for _, item := range items {
+		if strings.EqualFold(item.Title, title) {
+			return item, nil
+		}
+	}
+	return joplinItem{}, fmt.Errorf("no Joplin %s named %q", kind, title)
+}
+
+// list returns every item of the paginated listing at path.
+func (s joplinSource) list(path string, query url.Values) ([]joplinItem, error) {
+	var items []joplinItem
+	for page := 1; ; page++ {
+		query.Set("page", strconv.Itoa(page))
+		var p joplinPage
+		if err := s.request(http.MethodGet, path, query, nil, &p); err != nil {
+			return nil, err
+		}
+		items = append(items, p.Items...)
+		if !p.HasMore {
+			return items, nil
+		}
+	}
+}
+
+// request sends body as JSON to path of the Data API with the token added to query,
+// and decodes the JSON response into out unless it is nil.
+func (s joplinSource) request(method, path string, query url.Values, body, out any) error {
+	if query == nil {
+		query = url.Values{}
+	}
+	query.Set("token", s.token)
+	endpoint := fmt.Sprintf("http://127.0.0.1:%d%s?%s", s.port, path, query.Encode())
+
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			return fmt.Errorf("failed to marshal Joplin request: %w", err)
+		}
+	}
+	req, err := http.NewRequest(method, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := httpclient.Client().Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach Joplin: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s from Joplin", resp.Status)
+	}
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("failed to decode Joplin response: %w", err)
+		}
+	}
+	return nil
+}
+
+// joplinBody returns a note body as it appears in its section: normalized, without
+// surrounding blank lines, and with level 1 and 2 headings outside code fences
+// demoted to level 3 so they cannot be taken for sections.
+func joplinBody(body string) string {
+	lines := strings.Split(strings.Trim(normalizeText(body), "\n"), "\n")
+	inFence := false
+	for i, line := range lines {
+		if isFence(line) {
+			inFence = !inFence
+		}
+		if !inFence && (strings.HasPrefix(line, "# ") || strings.HasPrefix(line, "## ")) {
+			lines[i] = "### " + strings.TrimLeft(line, "# ")
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// joplinSections splits content at its "## " headings outside code fences into the
+// notes they are saved as, titled by the heading. Content above the first heading,
+// such as the document title, is not saved.
+func joplinSections(content string) []joplinItem {
+	var sections []joplinItem
+	var body []string
+	inFence := false
+	flush := func() {
+		if len(sections) > 0 {
+			sections[len(sections)-1].Body = strings.Trim(strings.Join(body, "\n"), "\n")
+		}
+		body = nil
+	}
+	for _, line := range strings.Split(content, "\n") {
+		if isFence(line) {
+			inFence = !inFence
+		}
+		if !inFence && strings.HasPrefix(line, "## ") {
+			flush()
+			sections = append(sections, joplinItem{Title: strings.TrimSpace(strings.TrimPrefix(line, "## "))})
+			continue
+		}
+		body = append(body, line)
+	}
+	flush()
+	return sections
+}
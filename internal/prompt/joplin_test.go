@@ -0,0 +1,214 @@
+package prompt
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+// fakeJoplin serves the parts of Joplin's Data API used by joplinSource from memory,
+// listing one item per page to exercise pagination.
+type fakeJoplin struct {
+	mu      sync.Mutex
+	folders []joplinItem
+	tags    []joplinItem
+	notes   []joplinItem
+	tagged  map[string][]string // tag ID to note IDs
+}
+
+func (f *fakeJoplin) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r.URL.Query().Get("token") != "secret" {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	var item joplinItem
+	if r.Body != nil {
+		_ = json.NewDecoder(r.Body).Decode(&item)
+	}
+	switch {
+	case r.Method == http.MethodGet && parts[0] == "ping":
+		_, _ = w.Write([]byte("JoplinClipperServer"))
+	case r.Method == http.MethodGet && len(parts) == 1 && parts[0] == "folders":
+		f.page(w, r, f.folders)
+	case r.Method == http.MethodGet && len(parts) == 1 && parts[0] == "tags":
+		f.page(w, r, f.tags)
+	case r.Method == http.MethodGet && len(parts) == 3 && parts[0] == "folders":
+		var notes []joplinItem
+		for _, n := range f.notes {
+			if n.ParentID == parts[1] {
+				notes = append(notes, n)
+			}
+		}
+		f.page(w, r, notes)
+	case r.Method == http.MethodGet && len(parts) == 3 && parts[0] == "tags":
+		var notes []joplinItem
+		for _, n := range f.notes {
+			for _, id := range f.tagged[parts[1]] {
+				if n.ID == id {
+					notes = append(notes, n)
+				}
+			}
+		}
+		f.page(w, r, notes)
+	case r.Method == http.MethodPost && parts[0] == "notes":
+		item.ID = "note" + strconv.Itoa(len(f.notes)+1)
+		f.notes = append(f.notes, item)
+		_ = json.NewEncoder(w).Encode(item)
+	case r.Method == http.MethodPost && parts[0] == "tags":
+		f.tagged[parts[1]] = append(f.tagged[parts[1]], item.ID)
+		_, _ = w.Write([]byte("{}"))
+	case r.Method == http.MethodPut && parts[0] == "notes":
+		for i := range f.notes {
+			if f.notes[i].ID == parts[1] {
+				f.notes[i].Body = item.Body
+			}
+		}
+		_, _ = w.Write([]byte("{}"))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (f *fakeJoplin) page(w http.ResponseWriter, r *http.Request, items []joplinItem) {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	p := joplinPage{HasMore: page < len(items)}
+	if page >= 1 && page <= len(items) {
+		p.Items = items[page-1 : page]
+	}
+	_ = json.NewEncoder(w).Encode(p)
+}
+
+// note returns the body of the note titled title.
+func (f *fakeJoplin) note(title string) (string, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, n := range f.notes {
+		if n.Title == title {
+			return n.Body, true
+		}
+	}
+	return "", false
+}
+
+// newFakeJoplin starts a fake Joplin holding a "Prompts" notebook with a Golang
+// note, and a Writing note tagged "prompt" elsewhere, and returns it with its port.
+func newFakeJoplin(t *testing.T) (*fakeJoplin, int) {
+	t.Helper()
+	f := &fakeJoplin{
+		folders: []joplinItem{{ID: "inbox", Title: "Inbox"}, {ID: "prompts", Title: "Prompts"}},
+		tags:    []joplinItem{{ID: "tag1", Title: "prompt"}},
+		notes: []joplinItem{
+			{ID: "go", Title: "Golang", ParentID: "prompts", Body: "## Review\r\nReview this Go code.\r\n"},
+			{ID: "writing", Title: "Writing", ParentID: "inbox", Body: "### Proofread\nProofread this text.\n"},
+		},
+		tagged: map[string][]string{"tag1": {"writing"}},
+	}
+	server := httptest.NewServer(f)
+	t.Cleanup(server.Close)
+	u, _ := url.Parse(server.URL)
+	port, _ := strconv.Atoi(u.Port())
+	return f, port
+}
+
+func TestJoplinSource_Notebook(t *testing.T) {
+	f, port := newFakeJoplin(t)
+	conf := config.Config{JoplinNotebook: "prompts", JoplinToken: "secret", JoplinPort: port, SNNote: "LLM Prompts", OnConflict: ConflictAbort}
+
+	if err := CheckRequiredBinaries(conf); err != nil {
+		t.Fatalf("CheckRequiredBinaries() error = %v", err)
+	}
+	data, err := LoadPrompts(conf)
+	if err != nil {
+		t.Fatalf("LoadPrompts() error = %v", err)
+	}
+	if results := SearchPrompts(data, "review", "Golang"); len(results) != 1 || results[0] != "Review this Go code." {
+		t.Errorf("SearchPrompts() = %q, want the note's prompt", results)
+	}
+	if results := SearchPrompts(data, "proofread", ""); len(results) != 0 {
+		t.Errorf("SearchPrompts() = %q, want no prompts from other notebooks", results)
+	}
+
+	if err := AddPrompt(conf, "Tests", "Write table-driven tests.", "Golang"); err != nil {
+		t.Fatalf("AddPrompt() error = %v", err)
+	}
+	if body, _ := f.note("Golang"); body != "### Review\nReview this Go code.\n\n### Tests\nWrite table-driven tests." {
+		t.Errorf("Golang note body = %q", body)
+	}
+	if err := AddPrompt(conf, "Summarize", "Summarize this text.", "Writing"); err != nil {
+		t.Fatalf("AddPrompt() error = %v", err)
+	}
+	f.mu.Lock()
+	created := f.notes[len(f.notes)-1]
+	f.mu.Unlock()
+	if created.Title != "Writing" || created.ParentID != "prompts" || !strings.Contains(created.Body, "Summarize this text.") {
+		t.Errorf("expected a Writing note in the Prompts notebook, got %+v", created)
+	}
+}
+
+func TestJoplinSource_Tag(t *testing.T) {
+	f, port := newFakeJoplin(t)
+	conf := config.Config{JoplinTag: "Prompt", JoplinToken: "secret", JoplinPort: port, SNNote: "LLM Prompts", OnConflict: ConflictAbort}
+
+	data, err := LoadPrompts(conf)
+	if err != nil {
+		t.Fatalf("LoadPrompts() error = %v", err)
+	}
+	if results := SearchPrompts(data, "proofread", "Writing"); len(results) != 1 {
+		t.Errorf("SearchPrompts() = %q, want the tagged note's prompt", results)
+	}
+	if data.Source != "#Prompt (Joplin)" {
+		t.Errorf("Source = %q, want %q", data.Source, "#Prompt (Joplin)")
+	}
+
+	if err := AddPrompt(conf, "Tests", "Write table-driven tests.", "Golang"); err != nil {
+		t.Fatalf("AddPrompt() error = %v", err)
+	}
+	if body, _ := f.note("Writing"); body != "### Proofread\nProofread this text.\n" {
+		t.Errorf("expected the unchanged Writing note to be left alone, got %q", body)
+	}
+	f.mu.Lock()
+	tagged := f.tagged["tag1"]
+	f.mu.Unlock()
+	if len(tagged) != 2 {
+		t.Errorf("expected the new note to be tagged, got %v", tagged)
+	}
+}
+
+func TestJoplinSource_Errors(t *testing.T) {
+	_, port := newFakeJoplin(t)
+
+	if err := (joplinSource{notebook: "Prompts", token: "wrong", port: port}).Check(); err == nil {
+		t.Error("expected Check() to fail with a rejected token")
+	}
+	_, err := (joplinSource{notebook: "Missing", token: "secret", port: port}).Load()
+	if err == nil || !strings.Contains(err.Error(), `no Joplin notebook named "Missing"`) {
+		t.Errorf("Load() error = %v, want a missing notebook error", err)
+	}
+}
+
+func TestJoplinSections(t *testing.T) {
+	content := "# Prompts\n\n## Golang\n### Review\nReview.\n```\n## not a section\n```\n\n## Writing\n"
+	got := joplinSections(content)
+	want := []joplinItem{
+		{Title: "Golang", Body: "### Review\nReview.\n```\n## not a section\n```"},
+		{Title: "Writing", Body: ""},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("joplinSections() = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("joplinSections()[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
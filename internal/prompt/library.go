@@ -0,0 +1,157 @@
+package prompt
+
+import (
+	"context"
+	"reflect"
+	"sync"
+
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+// loadPromptsFunc allows tests to control what Library.Reload loads.
+var loadPromptsFunc = LoadPrompts
+
+// Library keeps a loaded prompt library in memory for long-running embedders such as
+// serve mode or editor plugins. Searches may run concurrently with Reload: each search
+// works on the snapshot that was current when it started.
+type Library struct {
+	conf config.Config
+
+	mu   sync.RWMutex // Guards data
+	data *PromptData
+
+	reloadMu sync.Mutex // Serializes reloads
+
+	subMu       sync.Mutex // Guards subscribers and nextID
+	subscribers map[int]func(*PromptData)
+	nextID      int
+}
+
+// NewLibrary returns an empty Library for conf. Call Reload to load the prompts.
+func NewLibrary(conf config.Config) *Library {
+	return &Library{
+		conf:        conf,
+		data:        &PromptData{},
+		subscribers: map[int]func(*PromptData){},
+	}
+}
+
+// Reload loads the prompt source again and swaps it in if its content changed,
+// notifying OnChange subscribers with the new snapshot. It reports whether the
+// library changed. If ctx is done before loading finishes the result is discarded
+// and ctx.Err() is returned; the current snapshot stays in place.
+func (l *Library) Reload(ctx context.Context) (bool, error) {
+	l.reloadMu.Lock()
+	defer l.reloadMu.Unlock()
+
+	type result struct {
+		data *PromptData
+		err  error
+	}
+	load := loadPromptsFunc
+	loaded := make(chan result, 1)
+	go func() {
+		data, err := load(l.conf)
+		loaded <- result{data: data, err: err}
+	}()
+
+	var r result
+	select {
+	case <-ctx.Done():
+		return false, ctx.Err()
+	case r = <-loaded:
+	}
+	if r.err != nil {
+		return false, r.err
+	}
+
+	l.mu.Lock()
+	changed := !reflect.DeepEqual(l.data.Sections, r.data.Sections)
+	if changed {
+		l.data = r.data
+	}
+	l.mu.Unlock()
+
+	if changed {
+		l.notify(r.data)
+	}
+	return changed, nil
+}
+
+// Snapshot returns the current prompt data. A snapshot is never modified after it is
+// published, so it is safe to use without locking; callers must not modify it either.
+func (l *Library) Snapshot() *PromptData {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.data
+}
+
+// Search runs SearchPromptRecords against the current snapshot.
+func (l *Library) Search(query, section string) []Prompt {
+	return SearchPromptRecords(l.Snapshot(), query, section)
+}
+
+// OnChange registers fn to be called with the new snapshot after every Reload that
+// changes the library. Callbacks run synchronously on the reloading goroutine.
+// The returned function unregisters fn.
+func (l *Library) OnChange(fn func(*PromptData)) (unsubscribe func()) {
+	l.subMu.Lock()
+	defer l.subMu.Unlock()
+	id := l.nextID
+	l.nextID++
+	l.subscribers[id] = fn
+	return func() {
+		l.subMu.Lock()
+		defer l.subMu.Unlock()
+		delete(l.subscribers, id)
+	}
+}
+
+// Changes returns a channel receiving the new snapshot after each change, for
+// embedders that prefer select loops over callbacks. Only the latest snapshot is
+// kept if the receiver falls behind. The channel is closed by the returned
+// function, which also stops delivery.
+func (l *Library) Changes() (<-chan *PromptData, func()) {
+	ch := make(chan *PromptData, 1)
+	var once sync.Once
+	var closeMu sync.Mutex
+	closed := false
+
+	unsubscribe := l.OnChange(func(data *PromptData) {
+		closeMu.Lock()
+		defer closeMu.Unlock()
+		if closed {
+			return
+		}
+		// Drop a stale, unread snapshot in favour of the new one
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- data
+	})
+
+	return ch, func() {
+		once.Do(func() {
+			unsubscribe()
+			closeMu.Lock()
+			closed = true
+			close(ch)
+			closeMu.Unlock()
+		})
+	}
+}
+
+// notify calls every subscriber with data.
+func (l *Library) notify(data *PromptData) {
+	l.subMu.Lock()
+	subscribers := make([]func(*PromptData), 0, len(l.subscribers))
+	for _, fn := range l.subscribers {
+		subscribers = append(subscribers, fn)
+	}
+	l.subMu.Unlock()
+
+	for _, fn := range subscribers {
+		fn(data)
+	}
+}
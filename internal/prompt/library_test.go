@@ -0,0 +1,113 @@
+package prompt
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+func TestLibrary_ReloadAndNotify(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prompts.md")
+	if err := os.WriteFile(path, []byte("## Golang\n### Tests\nWrite unit tests\n"), 0600); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+	lib := NewLibrary(config.Config{FilePath: path})
+
+	var notified []*PromptData
+	unsubscribe := lib.OnChange(func(data *PromptData) {
+		notified = append(notified, data)
+	})
+	changes, stop := lib.Changes()
+	defer stop()
+
+	changed, err := lib.Reload(context.Background())
+	if err != nil || !changed {
+		t.Fatalf("first Reload() = %v, %v; want true, nil", changed, err)
+	}
+	if len(lib.Search("unit", "")) != 1 {
+		t.Error("expected prompt to be searchable after Reload")
+	}
+	first := lib.Snapshot()
+
+	if changed, _ := lib.Reload(context.Background()); changed {
+		t.Error("expected unchanged source not to be reported as a change")
+	}
+
+	if err := os.WriteFile(path, []byte("## Golang\n### Tests\nWrite unit tests\n### Review\nReview code\n"), 0600); err != nil {
+		t.Fatalf("failed to update test file: %v", err)
+	}
+	if changed, err := lib.Reload(context.Background()); err != nil || !changed {
+		t.Fatalf("Reload() after edit = %v, %v; want true, nil", changed, err)
+	}
+
+	if len(notified) != 2 {
+		t.Errorf("expected 2 change notifications, got %d", len(notified))
+	}
+	if got := <-changes; got != lib.Snapshot() {
+		t.Error("expected Changes() to deliver the latest snapshot")
+	}
+	if len(SearchPromptRecords(first, "", "")) != 1 {
+		t.Error("expected earlier snapshot to be unaffected by Reload")
+	}
+
+	unsubscribe()
+	_ = os.WriteFile(path, []byte("## Golang\n### Other\nSomething else\n"), 0600)
+	_, _ = lib.Reload(context.Background())
+	if len(notified) != 2 {
+		t.Error("expected no notification after unsubscribe")
+	}
+}
+
+func TestLibrary_ReloadErrors(t *testing.T) {
+	original := loadPromptsFunc
+	defer func() { loadPromptsFunc = original }()
+
+	release := make(chan struct{})
+	loadPromptsFunc = func(config.Config) (*PromptData, error) {
+		<-release
+		return nil, errors.New("source unavailable")
+	}
+	defer close(release)
+
+	lib := NewLibrary(config.Config{})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := lib.Reload(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context deadline error, got %v", err)
+	}
+	if lib.Snapshot() == nil {
+		t.Error("expected empty snapshot to remain after failed Reload")
+	}
+}
+
+func TestLibrary_ConcurrentSearchDuringReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prompts.md")
+	if err := os.WriteFile(path, []byte("## Golang\n### Tests\nWrite unit tests\n"), 0600); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+	lib := NewLibrary(config.Config{FilePath: path})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, _ = lib.Reload(context.Background())
+		}()
+		go func() {
+			defer wg.Done()
+			_ = lib.Search("unit", "")
+		}()
+	}
+	wg.Wait()
+
+	if len(lib.Search("unit", "")) != 1 {
+		t.Error("expected prompt to be searchable after concurrent reloads")
+	}
+}
@@ -0,0 +1,67 @@
+package prompt
+
+import (
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+// utf8BOM is the byte order mark some Windows editors prepend to UTF-8 files.
+const utf8BOM = "\ufeff"
+
+// cutBOM returns raw without a leading UTF-8 byte order mark, and whether it had one.
+func cutBOM(raw string) (string, bool) {
+	return strings.CutPrefix(raw, utf8BOM)
+}
+
+// textStyle records the line-ending conventions of a file so they can be restored on write.
+type textStyle struct {
+	crlf bool // Lines end with "\r\n"
+	bom  bool // Content starts with a UTF-8 byte order mark
+}
+
+// detectTextStyle inspects raw file content for a BOM and CRLF line endings.
+func detectTextStyle(raw string) textStyle {
+	_, bom := cutBOM(raw)
+	return textStyle{
+		crlf: strings.Contains(raw, "\r\n"),
+		bom:  bom,
+	}
+}
+
+// normalizeText strips a leading BOM and converts CRLF line endings to LF,
+// so headings and prompt content never carry stray carriage returns.
+func normalizeText(raw string) string {
+	raw, _ = cutBOM(raw)
+	return strings.ReplaceAll(raw, "\r\n", "\n")
+}
+
+// apply converts LF-normalized content back to the recorded style.
+func (s textStyle) apply(content string) string {
+	if s.crlf {
+		content = strings.ReplaceAll(content, "\n", "\r\n")
+	}
+	if s.bom {
+		content = utf8BOM + content
+	}
+	return content
+}
+
+// readLocalFile reads path and returns its LF-normalized content.
+func readLocalFile(path string) (string, error) {
+	data, err := afero.ReadFile(appFS, path) // #nosec G304
+	if err != nil {
+		return "", err
+	}
+	return normalizeText(string(data)), nil
+}
+
+// writeLocalFile writes LF-normalized content to path, keeping the line endings
+// and BOM of the file it replaces.
+func writeLocalFile(path, content string) error {
+	var style textStyle
+	if existing, err := afero.ReadFile(appFS, path); err == nil { // #nosec G304
+		style = detectTextStyle(string(existing))
+	}
+	return afero.WriteFile(appFS, path, []byte(style.apply(content)), 0600)
+}
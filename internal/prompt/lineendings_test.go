@@ -0,0 +1,79 @@
+package prompt
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+func TestNormalizeText(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		style    textStyle
+	}{
+		{name: "plain LF", input: "## A\nx\n", expected: "## A\nx\n"},
+		{name: "CRLF", input: "## A\r\nx\r\n", expected: "## A\nx\n", style: textStyle{crlf: true}},
+		{name: "BOM", input: "\ufeff## A\nx\n", expected: "## A\nx\n", style: textStyle{bom: true}},
+		{name: "BOM and CRLF", input: "\ufeff## A\r\nx\r\n", expected: "## A\nx\n", style: textStyle{crlf: true, bom: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := normalizeText(tt.input); result != tt.expected {
+				t.Errorf("normalizeText(%q) = %q, want %q", tt.input, result, tt.expected)
+			}
+			style := detectTextStyle(tt.input)
+			if style != tt.style {
+				t.Errorf("detectTextStyle(%q) = %+v, want %+v", tt.input, style, tt.style)
+			}
+			if restored := style.apply(tt.expected); restored != tt.input {
+				t.Errorf("apply() = %q, want %q", restored, tt.input)
+			}
+		})
+	}
+}
+
+func TestLoadPrompts_CRLFAndBOM(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prompts.md")
+	content := "\ufeff# Prompts\r\n\r\n## Golang\r\n### Tests\r\nWrite table-driven tests\r\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	data, err := LoadPrompts(config.Config{FilePath: path})
+	if err != nil {
+		t.Fatalf("LoadPrompts() returned error: %v", err)
+	}
+	results := SearchPromptRecords(data, "", "Golang")
+	if len(results) != 1 || results[0].Content != "Write table-driven tests" {
+		t.Errorf("expected clean prompt in Golang section, got %+v", results)
+	}
+}
+
+func TestAddPromptToNote_PreservesCRLF(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prompts.md")
+	if err := os.WriteFile(path, []byte("\ufeff### Tests\r\nWrite tests\r\n"), 0600); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	if err := addPromptToNote(config.Config{FilePath: path}, "Review", "Review this code", "", strings.NewReader(""), io.Discard); err != nil {
+		t.Fatalf("addPromptToNote() returned error: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	written := string(data)
+	if !strings.HasPrefix(written, "\ufeff") {
+		t.Error("expected BOM to be preserved")
+	}
+	if strings.Count(written, "\n") != strings.Count(written, "\r\n") {
+		t.Errorf("expected only CRLF line endings, got %q", written)
+	}
+	if !strings.Contains(written, "### Review\r\nReview this code\r\n") {
+		t.Errorf("expected new prompt with CRLF line endings, got %q", written)
+	}
+}
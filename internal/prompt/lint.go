@@ -0,0 +1,38 @@
+package prompt
+
+import (
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+// LintWarning is a parse warning found in one of the configured libraries.
+type LintWarning struct {
+	Source string `json:"source"` // The file path or Simplenote note the warning was found in
+	Warning
+}
+
+// Lint parses the personal library, and the team library when one is configured,
+// and returns the warnings explaining why some of their prompts may not show up
+// in search: heading level jumps, content before the first heading and empty
+// sections.
+func Lint(conf config.Config) ([]LintWarning, error) {
+	sources := [][2]string{{conf.FilePath, conf.SNNote}}
+	if hasTeamLibrary(conf) {
+		sources = append(sources, [2]string{conf.TeamFilePath, conf.TeamSNNote})
+	}
+
+	var results []LintWarning
+	for i, source := range sources {
+		_, warnings, err := diagnoseSections(source[0], source[1], conf)
+		if err != nil {
+			return nil, err
+		}
+		name := sourceName(source[0], source[1])
+		if src, ok := customSourceFunc(conf); ok && i == 0 {
+			name = src.Name()
+		}
+		for _, w := range warnings {
+			results = append(results, LintWarning{Source: name, Warning: w})
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,41 @@
+package prompt
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+func TestLint(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "prompts.md")
+	teamPath := filepath.Join(dir, "team.md")
+	if err := os.WriteFile(path, []byte(testMarkdownContent), 0600); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+	if err := os.WriteFile(teamPath, []byte("Loose prompt\n# Team\n## Empty\n"), 0600); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	warnings, err := Lint(config.Config{FilePath: path})
+	if err != nil {
+		t.Fatalf("Lint() returned error: %v", err)
+	}
+	if len(warnings) != 0 {
+		t.Errorf("expected no warnings for a well-formed library, got %+v", warnings)
+	}
+
+	warnings, err = Lint(config.Config{FilePath: path, TeamFilePath: teamPath})
+	if err != nil {
+		t.Fatalf("Lint() returned error: %v", err)
+	}
+	if len(warnings) != 2 || warnings[0].Source != teamPath || warnings[0].Line != 1 || warnings[1].Line != 3 {
+		t.Errorf("expected two warnings in the team library, got %+v", warnings)
+	}
+
+	if _, err := Lint(config.Config{FilePath: filepath.Join(dir, "missing.md")}); err == nil {
+		t.Error("expected error for a missing library")
+	}
+}
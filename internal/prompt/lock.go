@@ -0,0 +1,122 @@
+package prompt
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+// staleLockAge is the age after which a lock file is assumed to be left over
+// from a crashed process and is removed.
+const staleLockAge = 10 * time.Minute
+
+// lockRetryInterval is how often acquiring a held lock is retried.
+const lockRetryInterval = 50 * time.Millisecond
+
+// withFileLock runs fn while holding an advisory lock on path, implemented as an
+// exclusively created "<path>.lock" file so it works on every platform and with
+// editors or other wheresmyprompt instances that honour the same convention.
+// It waits up to timeout for a held lock and returns an error if it cannot be acquired.
+func withFileLock(path string, timeout time.Duration, fn func() error) error {
+	lockPath := path + ".lock"
+	deadline := time.Now().Add(timeout)
+
+	for {
+		f, err := appFS.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600) // #nosec G304
+		if err == nil {
+			_, _ = f.WriteString(strconv.Itoa(os.Getpid()))
+			_ = f.Close()
+			break
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return fmt.Errorf("failed to create lock file %s: %w", lockPath, err)
+		}
+		if info, statErr := appFS.Stat(lockPath); statErr == nil && time.Since(info.ModTime()) > staleLockAge {
+			_ = appFS.Remove(lockPath)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out after %s waiting for lock on %s (remove %s if no other process is writing)", timeout, path, lockPath)
+		}
+		time.Sleep(lockRetryInterval)
+	}
+	defer appFS.Remove(lockPath)
+
+	return fn()
+}
+
+// updateSourceContent performs a locked read-modify-write of the configured source.
+// update receives the current Markdown (empty if a local file does not exist yet)
+// and returns the new Markdown to save. Simplenote writes are recorded as op in the
+// write log, with a snapshot of the current note, so they can be undone. Writes to
+// notes and remote files are refused with ErrContentShrunk if they would shrink them
+// drastically, see checkShrink.
+func updateSourceContent(conf config.Config, op writeOp, update func(current string) (string, error)) error {
+	if err := checkWritable(conf); err != nil {
+		return err
+	}
+
+	apply := func() error {
+		current, err := loadSourceContent(conf)
+		if err != nil && !(conf.FilePath != "" && errors.Is(err, os.ErrNotExist)) {
+			return err
+		}
+		updated, err := update(current)
+		if err != nil {
+			return err
+		}
+		if conf.FilePath == "" || isURLSource(conf.FilePath) {
+			if err := checkShrink(conf, current, updated); err != nil {
+				return err
+			}
+		}
+		if conf.FilePath == "" && !isCustomSource(conf) {
+			if err := recordWriteFunc(conf, op, current); err != nil {
+				return err
+			}
+		}
+		return saveSourceContentFunc(conf, updated)
+	}
+
+	if conf.FilePath != "" && !isURLSource(conf.FilePath) {
+		return withFileLock(conf.FilePath, conf.LockTimeout, apply)
+	}
+	return apply()
+}
+
+// updateSourceLines updates the configured prompt source like updateSourceContent,
+// with the line edits returned by update applied in order. A local file is spliced,
+// so the lines around the edits stay byte for byte as they were, see lineEdit.splice.
+func updateSourceLines(conf config.Config, op writeOp, update func(current string) ([]lineEdit, error)) error {
+	file := localFile(conf)
+	if file == "" {
+		return updateSourceContent(conf, op, func(current string) (string, error) {
+			edits, err := update(current)
+			for _, e := range edits {
+				current = e.apply(current)
+			}
+			return current, err
+		})
+	}
+	if err := checkWritable(conf); err != nil {
+		return err
+	}
+	return withFileLock(file, conf.LockTimeout, func() error {
+		current, err := loadFromFile(file)
+		if err != nil {
+			return err
+		}
+		edits, err := update(current)
+		if err != nil {
+			return err
+		}
+		if err := spliceLocalFile(file, edits...); err != nil {
+			return err
+		}
+		return autoFormatFile(conf, file)
+	})
+}
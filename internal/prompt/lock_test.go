@@ -0,0 +1,79 @@
+package prompt
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+func TestWithFileLockConcurrentWrites(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prompts.md")
+	if err := os.WriteFile(path, []byte("# LLM Prompts\n"), 0600); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+	conf := config.Config{FilePath: path, LockTimeout: 10 * time.Second}
+
+	const writers = 10
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- addPromptToNote(conf, fmt.Sprintf("Title %d", i), fmt.Sprintf("Prompt number %d", i), "", strings.NewReader(""), io.Discard)
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("addPromptToNote() returned error: %v", err)
+		}
+	}
+
+	data, _ := os.ReadFile(path)
+	for i := 0; i < writers; i++ {
+		if !strings.Contains(string(data), fmt.Sprintf("Prompt number %d\n", i)) {
+			t.Errorf("prompt %d was lost by a concurrent write", i)
+		}
+	}
+	if _, err := os.Stat(path + ".lock"); !os.IsNotExist(err) {
+		t.Error("expected lock file to be removed")
+	}
+}
+
+func TestWithFileLockTimeout(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prompts.md")
+	if err := os.WriteFile(path+".lock", []byte("12345"), 0600); err != nil {
+		t.Fatalf("failed to write lock file: %v", err)
+	}
+
+	called := false
+	err := withFileLock(path, 100*time.Millisecond, func() error {
+		called = true
+		return nil
+	})
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("expected timeout error, got %v", err)
+	}
+	if called {
+		t.Error("function should not run without the lock")
+	}
+
+	// A stale lock is taken over
+	stale := time.Now().Add(-2 * staleLockAge)
+	_ = os.Chtimes(path+".lock", stale, stale)
+	if err := withFileLock(path, 100*time.Millisecond, func() error {
+		called = true
+		return nil
+	}); err != nil || !called {
+		t.Errorf("expected stale lock to be replaced, got err=%v called=%t", err, called)
+	}
+}
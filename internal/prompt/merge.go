@@ -0,0 +1,68 @@
+package prompt
+
+import (
+	"crypto/sha256"
+	"slices"
+	"strings"
+)
+
+// namespaceSeparator joins the namespaces of a prompt found in several libraries,
+// so a prompt in both libraries is badged "mine+team".
+const namespaceSeparator = "+"
+
+// contentKey hashes content after lowercasing it and collapsing whitespace, so
+// copies of a prompt that only differ by case or spacing share a key.
+func contentKey(content string) [sha256.Size]byte {
+	return sha256.Sum256([]byte(strings.Join(strings.Fields(strings.ToLower(content)), " ")))
+}
+
+// MergeDuplicates drops prompts whose normalized content repeats an earlier prompt,
+// adding their namespace to the one kept. Prompts keep their order, so the kept copy
+// of search results is the best ranked one.
+func MergeDuplicates(prompts []Prompt) []Prompt {
+	matches := make([]Match, len(prompts))
+	for i, p := range prompts {
+		matches[i] = Match{Prompt: p}
+	}
+	merged := MergeDuplicateMatches(matches)
+	results := make([]Prompt, len(merged))
+	for i, m := range merged {
+		results[i] = m.Prompt
+	}
+	return results
+}
+
+// MergeDuplicateMatches merges duplicate matches like MergeDuplicates, keeping the
+// score of the first copy.
+func MergeDuplicateMatches(matches []Match) []Match {
+	seen := make(map[[sha256.Size]byte]int, len(matches))
+	merged := make([]Match, 0, len(matches))
+	for _, m := range matches {
+		key := contentKey(m.Content)
+		i, ok := seen[key]
+		if !ok {
+			seen[key] = len(merged)
+			merged = append(merged, m)
+			continue
+		}
+		merged[i].Namespace = mergeNamespaces(merged[i].Namespace, m.Namespace)
+	}
+	return merged
+}
+
+// mergeNamespaces adds namespace to the merged namespaces a, unless already present.
+func mergeNamespaces(a, namespace string) string {
+	if namespace == "" || InNamespace(a, namespace) {
+		return a
+	}
+	if a == "" {
+		return namespace
+	}
+	return a + namespaceSeparator + namespace
+}
+
+// InNamespace reports whether a prompt badged with namespace, possibly merged from
+// several libraries, belongs to the library want.
+func InNamespace(namespace, want string) bool {
+	return slices.Contains(strings.Split(namespace, namespaceSeparator), want)
+}
@@ -0,0 +1,71 @@
+package prompt
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMergeDuplicates(t *testing.T) {
+	tests := []struct {
+		name     string
+		prompts  []Prompt
+		expected []Prompt
+	}{
+		{
+			name: "same prompt in both libraries",
+			prompts: []Prompt{
+				{Content: "Review this Go code", Section: "Golang", Namespace: NamespacePersonal},
+				{Content: "Write table tests", Section: "Golang", Namespace: NamespacePersonal},
+				{Content: "  review THIS go   code ", Section: "Go", Namespace: NamespaceTeam},
+			},
+			expected: []Prompt{
+				{Content: "Review this Go code", Section: "Golang", Namespace: "mine+team"},
+				{Content: "Write table tests", Section: "Golang", Namespace: NamespacePersonal},
+			},
+		},
+		{
+			name: "duplicates within one library keep its namespace",
+			prompts: []Prompt{
+				{Content: "Explain", Namespace: NamespaceTeam},
+				{Content: "explain", Namespace: NamespaceTeam},
+			},
+			expected: []Prompt{{Content: "Explain", Namespace: NamespaceTeam}},
+		},
+		{
+			name:     "single library",
+			prompts:  []Prompt{{Content: "Explain"}, {Content: "Explain"}, {Content: "Summarize"}},
+			expected: []Prompt{{Content: "Explain"}, {Content: "Summarize"}},
+		},
+		{
+			name:     "no prompts",
+			prompts:  nil,
+			expected: []Prompt{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MergeDuplicates(tt.prompts); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("MergeDuplicates() = %+v, want %+v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestInNamespace(t *testing.T) {
+	tests := []struct {
+		namespace, want string
+		expected        bool
+	}{
+		{"mine", "mine", true},
+		{"mine+team", "team", true},
+		{"mine+team", "mine", true},
+		{"team", "mine", false},
+		{"", "mine", false},
+	}
+	for _, tt := range tests {
+		if got := InNamespace(tt.namespace, tt.want); got != tt.expected {
+			t.Errorf("InNamespace(%q, %q) = %v, want %v", tt.namespace, tt.want, got, tt.expected)
+		}
+	}
+}
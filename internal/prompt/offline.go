@@ -0,0 +1,83 @@
+package prompt
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+// ErrOffline is returned when a network source has no cached copy to read, or is
+// written to, while offline.
+var ErrOffline = errors.New("offline")
+
+// wentOffline records that a request failed to reach the network in this run, so
+// the sources loaded after it go straight to their caches.
+var wentOffline atomic.Bool
+
+// isOffline reports whether network sources are read from their local caches:
+// with OFFLINE (--offline), or once the network could not be reached.
+func isOffline(conf config.Config) bool {
+	return conf.Offline || wentOffline.Load()
+}
+
+// offlineReason describes why a network source is read from its cache while offline.
+func offlineReason(conf config.Config) string {
+	if conf.Offline {
+		return "offline mode"
+	}
+	return "the network could not be reached"
+}
+
+// noteNetworkError switches to offline mode if err, returned by a request, shows
+// that the network cannot be reached: a failed DNS lookup or connection, or a timeout.
+func noteNetworkError(err error) {
+	var dnsErr *net.DNSError
+	var opErr *net.OpError
+	var netErr net.Error
+	if errors.As(err, &dnsErr) || errors.As(err, &opErr) || errors.As(err, &netErr) && netErr.Timeout() {
+		if !wentOffline.Swap(true) {
+			log.Debugf("Network unreachable, reading network sources from their caches: %v", err)
+		}
+	}
+}
+
+// warnCached warns that the copy of name cached at cached is used instead of the
+// source itself, and how old it is.
+func warnCached(name string, cached time.Time, reason any) {
+	log.Warnf("Using the copy of %s cached %s (%s old): %v", name, cached.Local().Format("2006-01-02 15:04"), cacheAge(time.Since(cached)), reason)
+}
+
+// cacheAge formats the age of a cached copy, such as "3h" or "2d".
+func cacheAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return "less than a minute"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+}
+
+// checkOnline returns ErrOffline for a write to a network source while offline,
+// rather than attempting it.
+func checkOnline(conf config.Config) error {
+	if !isOffline(conf) {
+		return nil
+	}
+	switch {
+	case isURLSource(conf.FilePath):
+		return fmt.Errorf("%w: cannot write to %s (%s)", ErrOffline, redactURL(conf.FilePath), offlineReason(conf))
+	case conf.FilePath == "" && !isCustomSource(conf):
+		return fmt.Errorf("%w: cannot write to Simplenote note '%s' (%s)", ErrOffline, conf.SNNote, offlineReason(conf))
+	}
+	return nil
+}
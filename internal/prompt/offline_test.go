@@ -0,0 +1,124 @@
+package prompt
+
+import (
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+// resetOffline clears the network failure recorded by an earlier test.
+func resetOffline(t *testing.T) {
+	t.Helper()
+	wentOffline.Store(false)
+	t.Cleanup(func() { wentOffline.Store(false) })
+}
+
+func TestRemoteSource_Offline(t *testing.T) {
+	resetOffline(t)
+	f, url := newFakeObjectStore(t, "/prompts.md")
+	conf := config.Config{FilePath: url + "/prompts.md", DataDir: "/data", RemoteWrite: true}
+	src := remoteSource{url: conf.FilePath, conf: conf}
+	if _, err := src.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	// Offline, the cached copy is read without a request
+	f.mu.Lock()
+	f.content = "# Changed\n"
+	f.mu.Unlock()
+	conf.Offline = true
+	src.conf = conf
+	if content, err := src.Load(); err != nil || !strings.Contains(content, "Review this Go code.") {
+		t.Errorf("Load() = %q, %v, want the cached copy", content, err)
+	}
+	if err := AddPrompt(conf, "Tests", "Write tests.", "Golang"); !errors.Is(err, ErrOffline) {
+		t.Errorf("AddPrompt() error = %v, want ErrOffline", err)
+	}
+
+	other := remoteSource{url: url + "/other.md", conf: conf}
+	if _, err := other.Load(); !errors.Is(err, ErrOffline) {
+		t.Errorf("Load() error = %v, want ErrOffline without a cached copy", err)
+	}
+}
+
+func TestRemoteSource_NetworkFailure(t *testing.T) {
+	resetOffline(t)
+	useMemFS(t)
+	server := httptest.NewServer(nil)
+	url := server.URL + "/prompts.md"
+	server.Close()
+
+	conf := config.Config{FilePath: url, DataDir: "/data"}
+	src := remoteSource{url: url, conf: conf}
+	src.writeCache(remoteCache{ETag: `"v1"`, Content: "# Cached\n"})
+	if content, err := src.Load(); err != nil || content != "# Cached\n" {
+		t.Errorf("Load() = %q, %v, want the cached copy", content, err)
+	}
+	if !isOffline(conf) {
+		t.Fatal("expected a refused connection to switch to offline mode")
+	}
+
+	// Later sources go straight to their caches
+	google := config.Config{GoogleDoc: "1AbCdEfGhIjKlMnOp", DataDir: "/data"}
+	if err := afero.WriteFile(appFS, "/data/google-1AbCdEfGhIjKlMnOp.md", []byte("# Google\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if content, err := (googleDocSource{conf: google}).Load(); err != nil || content != "# Google\n" {
+		t.Errorf("Load() = %q, %v, want the cached Google Drive file", content, err)
+	}
+}
+
+func TestLoadNoteForSearch_Offline(t *testing.T) {
+	resetOffline(t)
+	dir := t.TempDir()
+	writeSncliNote(t, dir, "note.json", map[string]any{"content": "LLM Prompts\nlocal\n", "syncdate": float64(time.Now().Add(-48 * time.Hour).Unix())})
+	fakeSimplenote(t, "LLM Prompts\nremote\n")
+
+	conf := config.Config{SNNote: "LLM Prompts", SNDBPath: dir, SNLocalMaxAge: time.Hour, Offline: true}
+	if content, err := loadNoteForSearch(conf); err != nil || content != "LLM Prompts\nlocal\n" {
+		t.Errorf("loadNoteForSearch() = %q, %v, want the stale local note offline", content, err)
+	}
+	if err := AddPrompt(conf, "Tests", "Write tests.", "Golang"); !errors.Is(err, ErrOffline) {
+		t.Errorf("AddPrompt() error = %v, want ErrOffline", err)
+	}
+	conf.SNNote = "Other"
+	if _, err := loadNoteForSearch(conf); !errors.Is(err, ErrOffline) {
+		t.Errorf("loadNoteForSearch() error = %v, want ErrOffline for a note missing locally", err)
+	}
+
+	// Failing to fetch the note falls back to the local copy, but not failing to sign in
+	conf = config.Config{SNNote: "LLM Prompts", SNDBPath: dir}
+	loadFromSimplenoteFunc = func(config.Config) (string, error) { return "", fmt.Errorf("sncli: connection failed") }
+	if content, err := loadNoteForSearch(conf); err != nil || content != "LLM Prompts\nlocal\n" {
+		t.Errorf("loadNoteForSearch() = %q, %v, want the local note", content, err)
+	}
+	loadFromSimplenoteFunc = func(config.Config) (string, error) { return "", ErrAuth }
+	if _, err := loadNoteForSearch(conf); !errors.Is(err, ErrAuth) {
+		t.Errorf("loadNoteForSearch() error = %v, want ErrAuth", err)
+	}
+}
+
+func TestCacheAge(t *testing.T) {
+	tests := []struct {
+		age      time.Duration
+		expected string
+	}{
+		{30 * time.Second, "less than a minute"},
+		{5 * time.Minute, "5m"},
+		{3 * time.Hour, "3h"},
+		{47 * time.Hour, "47h"},
+		{72 * time.Hour, "3d"},
+	}
+	for _, tt := range tests {
+		if got := cacheAge(tt.age); got != tt.expected {
+			t.Errorf("cacheAge(%s) = %q, want %q", tt.age, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package prompt
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mattn/go-isatty"
+)
+
+// Allow test overrides
+var (
+	clipboardTerminal   io.Writer = os.Stderr
+	clipboardFallback   io.Writer = os.Stdout
+	isClipboardTerminal           = func() bool { return isatty.IsTerminal(os.Stderr.Fd()) }
+)
+
+// copyWithoutExec copies text without running a clipboard utility, for NO_EXEC: a
+// terminal on stderr is asked to set its clipboard with an OSC 52 escape sequence,
+// which most terminals support, also over SSH. Without a terminal, text is written
+// to stdout to be piped or redirected instead.
+func copyWithoutExec(text string) error {
+	if !isClipboardTerminal() {
+		_, err := fmt.Fprintln(clipboardFallback, text)
+		return err
+	}
+	_, err := fmt.Fprintf(clipboardTerminal, "\x1b]52;c;%s\a", base64.StdEncoding.EncodeToString([]byte(text)))
+	return err
+}
@@ -0,0 +1,38 @@
+package prompt
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCopyWithoutExec(t *testing.T) {
+	oldTerminal, oldFallback, oldIsTerminal := clipboardTerminal, clipboardFallback, isClipboardTerminal
+	t.Cleanup(func() {
+		clipboardTerminal, clipboardFallback, isClipboardTerminal = oldTerminal, oldFallback, oldIsTerminal
+	})
+
+	tests := []struct {
+		name         string
+		terminal     bool
+		wantTerminal string
+		wantStdout   string
+	}{
+		{name: "terminal", terminal: true, wantTerminal: "\x1b]52;c;UmV2aWV3IHRoaXM=\a"},
+		{name: "no terminal", wantStdout: "Review this\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var terminal, stdout bytes.Buffer
+			clipboardTerminal, clipboardFallback = &terminal, &stdout
+			isClipboardTerminal = func() bool { return tt.terminal }
+
+			if err := copyWithoutExec("Review this"); err != nil {
+				t.Fatalf("copyWithoutExec() error = %v", err)
+			}
+			if terminal.String() != tt.wantTerminal || stdout.String() != tt.wantStdout {
+				t.Errorf("copyWithoutExec() wrote %q to the terminal and %q to stdout, want %q and %q", terminal.String(), stdout.String(), tt.wantTerminal, tt.wantStdout)
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package prompt
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+// NamespaceOverridden badges a team prompt whose content is shadowed by a local
+// override, merged with NamespaceTeam as "team+overridden".
+const NamespaceOverridden = "overridden"
+
+// ErrNoTeamLibrary is returned when managing overrides without a team library.
+var ErrNoTeamLibrary = errors.New("no team library configured; set TEAM_FILEPATH or TEAM_SN_NOTE")
+
+// ErrTeamPromptNotFound is returned when overriding a title no team prompt has.
+var ErrTeamPromptNotFound = errors.New("no team prompt with this title")
+
+// ErrOverrideNotFound is returned when clearing an override that does not exist.
+var ErrOverrideNotFound = errors.New("no override with this title")
+
+// Override is a local replacement for the team prompts with the same title, kept
+// in the override section of the personal library.
+type Override struct {
+	Title    string `json:"title"`
+	Content  string `json:"content"`
+	Orphaned bool   `json:"orphaned,omitempty"` // No team prompt has the title any more
+}
+
+// overrideTitle returns the team prompt title sec overrides, and whether sec is an
+// override at all: a "### <title>" block directly below the override section.
+func overrideTitle(sec Section, overrideSection string) (string, bool) {
+	n := len(sec.Headings)
+	if overrideSection == "" || n < 2 || sec.Headings[n-2] != overrideSection {
+		return "", false
+	}
+	return sec.Headings[n-1], true
+}
+
+// sectionTitle returns the title of the prompts of sec, its deepest heading.
+func sectionTitle(sec Section) string {
+	if len(sec.Headings) == 0 {
+		return ""
+	}
+	return sec.Headings[len(sec.Headings)-1]
+}
+
+// applyOverrides replaces the lines of every team section whose title has an
+// override in the personal sections, badging it NamespaceOverridden, and returns
+// the personal sections without the overrides applied. The overridden sections
+// point at the override's lines, so opening them in the editor edits the override.
+// Overrides of titles no team prompt has are kept as personal prompts.
+func applyOverrides(personal, team []Section, overrideSection string) []Section {
+	overrides := make(map[string]Section)
+	for _, sec := range personal {
+		if title, ok := overrideTitle(sec, overrideSection); ok && len(sec.Lines) > 0 {
+			overrides[title] = sec
+		}
+	}
+	if len(overrides) == 0 {
+		return personal
+	}
+
+	applied := make(map[string]bool)
+	for i, sec := range team {
+		override, ok := overrides[sectionTitle(sec)]
+		if !ok {
+			continue
+		}
+		team[i].Lines = slices.Clone(override.Lines)
+		team[i].LineRanges = slices.Clone(override.LineRanges)
+		team[i].SourceFile = override.SourceFile
+		team[i].StartLine, team[i].EndLine = override.StartLine, override.EndLine
+		team[i].Namespace = mergeNamespaces(sec.Namespace, NamespaceOverridden)
+		applied[sectionTitle(sec)] = true
+	}
+
+	kept := personal[:0:0]
+	for _, sec := range personal {
+		if title, ok := overrideTitle(sec, overrideSection); ok && applied[title] {
+			continue
+		}
+		kept = append(kept, sec)
+	}
+	return kept
+}
+
+// ListOverrides returns the overrides in the personal library, marking those whose
+// team prompt no longer exists as orphaned.
+func ListOverrides(conf config.Config) ([]Override, error) {
+	personal, err := loadSections(conf.FilePath, conf.SNNote, conf)
+	if err != nil {
+		return nil, err
+	}
+	titles := make(map[string]bool)
+	if hasTeamLibrary(conf) {
+		team, err := loadTeamSections(conf)
+		if err != nil {
+			return nil, err
+		}
+		for _, sec := range team {
+			titles[sectionTitle(sec)] = true
+		}
+	}
+
+	var overrides []Override
+	for _, sec := range personal {
+		if title, ok := overrideTitle(sec, conf.OverrideSection); ok && len(sec.Lines) > 0 {
+			overrides = append(overrides, Override{
+				Title:    title,
+				Content:  strings.Join(sec.Lines, "\n"),
+				Orphaned: !titles[title],
+			})
+		}
+	}
+	return overrides, nil
+}
+
+// SetOverride replaces the content of the team prompts titled title with content for
+// this user only, by writing it to the override section of the personal library.
+// An existing override of the title is replaced.
+func SetOverride(conf config.Config, title, content string) error {
+	if err := checkWritable(conf); err != nil {
+		return err
+	}
+	if strings.TrimSpace(content) == "" {
+		return fmt.Errorf("override content is required")
+	}
+	if !hasTeamLibrary(conf) {
+		return ErrNoTeamLibrary
+	}
+	team, err := loadTeamSections(conf)
+	if err != nil {
+		return err
+	}
+	if !slices.ContainsFunc(team, func(sec Section) bool { return sectionTitle(sec) == title }) {
+		return fmt.Errorf("%w: '%s'", ErrTeamPromptNotFound, title)
+	}
+
+	p := Prompt{Content: content, Section: conf.OverrideSection, Title: title}
+	if err := RunHook(conf, HookPreWrite, p); err != nil {
+		return err
+	}
+	err = updateSourceContent(conf, writeOp{action: "override", title: title, section: conf.OverrideSection}, func(current string) (string, error) {
+		current, _ = removeSubsection(current, conf.OverrideSection, title)
+		return insertPrompt(current, title, content, conf.OverrideSection), nil
+	})
+	if err != nil {
+		return err
+	}
+	return RunHook(conf, HookPostWrite, p)
+}
+
+// ClearOverride removes the override of title from the personal library, so the
+// team prompts with that title show their shared content again.
+func ClearOverride(conf config.Config, title string) error {
+	return updateSourceContent(conf, writeOp{action: "clear override", title: title, section: conf.OverrideSection}, func(current string) (string, error) {
+		updated, ok := removeSubsection(current, conf.OverrideSection, title)
+		if !ok {
+			return "", fmt.Errorf("%w: '%s'", ErrOverrideNotFound, title)
+		}
+		return updated, nil
+	})
+}
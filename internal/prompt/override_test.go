@@ -0,0 +1,92 @@
+package prompt
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+func TestOverrides(t *testing.T) {
+	dir := t.TempDir()
+	personal := filepath.Join(dir, "prompts.md")
+	team := filepath.Join(dir, "team.md")
+	files := map[string]string{
+		personal: "# Prompts\n\n## Golang\n\nReview this Go code\n",
+		team:     "# Team\n\n## Release notes\n\nWrite release notes for the sprint\n\n## Standup\n\nSummarize the standup\n",
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	conf := config.Config{FilePath: personal, TeamFilePath: team, OverrideSection: "Overrides"}
+
+	if err := SetOverride(conf, "Release notes", "Write terse release notes"); err != nil {
+		t.Fatalf("SetOverride() returned error: %v", err)
+	}
+	if err := SetOverride(conf, "Release notes", "Write terse release notes with emoji"); err != nil {
+		t.Fatalf("SetOverride() returned error replacing the override: %v", err)
+	}
+	if err := SetOverride(conf, "Missing", "Anything"); !errors.Is(err, ErrTeamPromptNotFound) {
+		t.Errorf("expected ErrTeamPromptNotFound, got %v", err)
+	}
+	if got, _ := os.ReadFile(team); string(got) != files[team] {
+		t.Errorf("expected the team library left alone, got %q", got)
+	}
+
+	data, err := LoadPrompts(conf)
+	if err != nil {
+		t.Fatalf("LoadPrompts() returned error: %v", err)
+	}
+	var overridden []Prompt
+	for _, p := range generateSearchPool(data, "") {
+		if strings.Contains(p.Content, "release notes") {
+			overridden = append(overridden, p)
+		}
+	}
+	if len(overridden) != 1 || overridden[0].Content != "Write terse release notes with emoji" ||
+		overridden[0].Namespace != "team+overridden" || overridden[0].SourceFile != personal {
+		t.Fatalf("expected only the override, badged team+overridden, got %+v", overridden)
+	}
+
+	overrides, err := ListOverrides(conf)
+	if err != nil || len(overrides) != 1 || overrides[0].Title != "Release notes" || overrides[0].Orphaned {
+		t.Fatalf("ListOverrides() = %+v, %v", overrides, err)
+	}
+
+	if err := ClearOverride(conf, "Release notes"); err != nil {
+		t.Fatalf("ClearOverride() returned error: %v", err)
+	}
+	if err := ClearOverride(conf, "Release notes"); !errors.Is(err, ErrOverrideNotFound) {
+		t.Errorf("expected ErrOverrideNotFound, got %v", err)
+	}
+	data, _ = LoadPrompts(conf)
+	if results := SearchPromptRecords(data, "release notes", ""); len(results) != 1 || results[0].Namespace != NamespaceTeam {
+		t.Errorf("expected the team prompt back after clearing, got %+v", results)
+	}
+}
+
+func TestListOverrides_Orphaned(t *testing.T) {
+	dir := t.TempDir()
+	personal := filepath.Join(dir, "prompts.md")
+	team := filepath.Join(dir, "team.md")
+	_ = os.WriteFile(personal, []byte("# Prompts\n\n## Overrides\n\n### Renamed\n\nMy version\n"), 0600)
+	_ = os.WriteFile(team, []byte("# Team\n\n## Standup\n\nSummarize the standup\n"), 0600)
+	conf := config.Config{FilePath: personal, TeamFilePath: team, OverrideSection: "Overrides"}
+
+	overrides, err := ListOverrides(conf)
+	if err != nil || len(overrides) != 1 || !overrides[0].Orphaned {
+		t.Fatalf("expected an orphaned override, got %+v, %v", overrides, err)
+	}
+	data, _ := LoadPrompts(conf)
+	if results := SearchPromptRecords(data, "my version", ""); len(results) != 1 || results[0].Namespace != NamespacePersonal {
+		t.Errorf("expected the orphaned override kept as a personal prompt, got %+v", results)
+	}
+	if err := SetOverride(config.Config{FilePath: personal, OverrideSection: "Overrides"}, "Standup", "x"); !errors.Is(err, ErrNoTeamLibrary) {
+		t.Errorf("expected ErrNoTeamLibrary without a team library, got %v", err)
+	}
+}
@@ -0,0 +1,423 @@
+package prompt
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/toozej/wheresmyprompt/internal/httpclient"
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+// NamespacePackPrefix starts the namespace of the prompts of an installed pack,
+// followed by the pack's name.
+const NamespacePackPrefix = "pack:"
+
+// ErrPackNotFound is returned for a pack that is not installed or not in the registry.
+var ErrPackNotFound = errors.New("prompt pack not found")
+
+// packNamePattern matches valid pack names, which are also file names in the packs directory.
+var packNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// PackInfo describes an installed prompt pack.
+type PackInfo struct {
+	Name        string    `json:"name"`
+	Version     string    `json:"version,omitempty"`
+	Description string    `json:"description,omitempty"`
+	Author      string    `json:"author,omitempty"`
+	Source      string    `json:"source"`           // URL or path the pack was installed from
+	Signer      string    `json:"signer,omitempty"` // ID of the trusted key that signed the pack, empty if unsigned
+	Installed   time.Time `json:"installed"`
+}
+
+// PackUpdate reports the result of updating an installed pack.
+type PackUpdate struct {
+	Name    string `json:"name"`
+	From    string `json:"from,omitempty"` // Version before the update
+	To      string `json:"to,omitempty"`   // Version after the update
+	Changed bool   `json:"changed"`        // Whether the pack's prompts changed
+}
+
+// packBundle is the JSON pack format: the pack's metadata and its prompts as Markdown.
+type packBundle struct {
+	Name        string `json:"name"`
+	Version     string `json:"version"`
+	Description string `json:"description"`
+	Author      string `json:"author"`
+	Content     string `json:"content"`
+}
+
+// packRegistry is the index PACK_REGISTRY points to, mapping pack names to the
+// URLs to install them from, relative to the registry.
+type packRegistry struct {
+	Packs []struct {
+		Name        string `json:"name"`
+		URL         string `json:"url"`
+		Description string `json:"description"`
+	} `json:"packs"`
+}
+
+// packsDir returns PACKS_DIR, defaulting to "packs" in the data directory.
+func packsDir(conf config.Config) (string, error) {
+	if conf.PacksDir != "" {
+		return conf.PacksDir, nil
+	}
+	dir, err := config.ResolveDataDir(conf)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "packs"), nil
+}
+
+// InstallPack downloads the pack at ref, a URL or file path, or the name of a pack
+// in PACK_REGISTRY, and installs it into the packs directory, replacing an installed
+// pack of the same name. Its prompts are searched alongside the library from then on.
+// The pack must be signed with a trusted key unless insecure is set, see verifyPack.
+func InstallPack(conf config.Config, ref string, insecure bool) (PackInfo, error) {
+	source, err := resolvePackRef(conf, ref)
+	if err != nil {
+		return PackInfo{}, err
+	}
+	info, content, err := downloadPack(conf, source, insecure)
+	if err != nil {
+		return PackInfo{}, err
+	}
+	if err := savePack(conf, info, content); err != nil {
+		return PackInfo{}, err
+	}
+	return info, nil
+}
+
+// ListPacks returns the installed packs, sorted by name.
+func ListPacks(conf config.Config) ([]PackInfo, error) {
+	dir, err := packsDir(conf)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := afero.ReadDir(appFS, dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read packs directory: %w", err)
+	}
+
+	var packs []PackInfo
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		data, err := afero.ReadFile(appFS, filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read pack %s: %w", entry.Name(), err)
+		}
+		var info PackInfo
+		if err := json.Unmarshal(data, &info); err != nil {
+			return nil, fmt.Errorf("failed to parse pack %s: %w", entry.Name(), err)
+		}
+		packs = append(packs, info)
+	}
+	sort.Slice(packs, func(i, j int) bool { return packs[i].Name < packs[j].Name })
+	return packs, nil
+}
+
+// UpdatePacks downloads the named installed packs, or all of them when names is
+// empty, again from where they were installed from, verifying them like InstallPack.
+func UpdatePacks(conf config.Config, insecure bool, names ...string) ([]PackUpdate, error) {
+	installed, err := ListPacks(conf)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		if !packInstalled(installed, name) {
+			return nil, fmt.Errorf("%w: %s is not installed", ErrPackNotFound, name)
+		}
+	}
+
+	var updates []PackUpdate
+	for _, old := range installed {
+		if len(names) > 0 && !containsFold(names, old.Name) {
+			continue
+		}
+		info, content, err := downloadPack(conf, old.Source, insecure)
+		if err != nil {
+			return updates, fmt.Errorf("failed to update pack %s: %w", old.Name, err)
+		}
+		if info.Name != old.Name {
+			return updates, fmt.Errorf("failed to update pack %s: %s now holds pack %s", old.Name, old.Source, info.Name)
+		}
+		current, _ := readPackContent(conf, old.Name)
+		if err := savePack(conf, info, content); err != nil {
+			return updates, err
+		}
+		updates = append(updates, PackUpdate{Name: info.Name, From: old.Version, To: info.Version, Changed: current != content})
+	}
+	return updates, nil
+}
+
+// RemovePack uninstalls the named pack.
+func RemovePack(conf config.Config, name string) error {
+	installed, err := ListPacks(conf)
+	if err != nil {
+		return err
+	}
+	if !packInstalled(installed, name) {
+		return fmt.Errorf("%w: %s is not installed", ErrPackNotFound, name)
+	}
+	dir, err := packsDir(conf)
+	if err != nil {
+		return err
+	}
+	for _, ext := range []string{".md", ".json"} {
+		if err := appFS.Remove(filepath.Join(dir, name+ext)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove pack %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// loadPackSections loads the sections of every installed pack, tagging each with
+// its pack's namespace.
+func loadPackSections(conf config.Config) ([]Section, error) {
+	packs, err := ListPacks(conf)
+	if err != nil {
+		return nil, err
+	}
+	dir, err := packsDir(conf)
+	if err != nil {
+		return nil, err
+	}
+	var sections []Section
+	for _, pack := range packs {
+		packSections, err := loadSections(filepath.Join(dir, pack.Name+".md"), "", conf)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load pack %s: %w", pack.Name, err)
+		}
+		setNamespace(packSections, NamespacePackPrefix+pack.Name)
+		sections = append(sections, packSections...)
+	}
+	return sections, nil
+}
+
+// resolvePackRef returns the URL or path to install ref from: ref itself if it is a
+// URL or an existing file, or else the location PACK_REGISTRY lists for the name ref.
+func resolvePackRef(conf config.Config, ref string) (string, error) {
+	if isURLSource(ref) {
+		return ref, nil
+	}
+	if _, err := appFS.Stat(ref); err == nil {
+		return ref, nil
+	}
+	if !packNamePattern.MatchString(ref) {
+		return "", fmt.Errorf("%w: %s is not a URL, file or pack name", ErrPackNotFound, ref)
+	}
+	if conf.PackRegistry == "" {
+		return "", fmt.Errorf("%w: %s is not a URL or file, and PACK_REGISTRY is not set to look it up by name", ErrPackNotFound, ref)
+	}
+
+	data, err := fetchPackFile(conf.PackRegistry)
+	if err != nil {
+		return "", fmt.Errorf("failed to read pack registry: %w", err)
+	}
+	var registry packRegistry
+	if err := json.Unmarshal(data, &registry); err != nil {
+		return "", fmt.Errorf("failed to parse pack registry %s: %w", conf.PackRegistry, err)
+	}
+	for _, pack := range registry.Packs {
+		if strings.EqualFold(pack.Name, ref) {
+			return resolveRelative(conf.PackRegistry, pack.URL)
+		}
+	}
+	return "", fmt.Errorf("%w: no pack named %s in %s", ErrPackNotFound, ref, conf.PackRegistry)
+}
+
+// resolveRelative resolves ref against base, a URL or file path.
+func resolveRelative(base, ref string) (string, error) {
+	if isURLSource(ref) || filepath.IsAbs(ref) {
+		return ref, nil
+	}
+	if !isURLSource(base) {
+		return filepath.Join(filepath.Dir(base), ref), nil
+	}
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return "", fmt.Errorf("invalid pack registry URL %s: %w", base, err)
+	}
+	refURL, err := url.Parse(ref)
+	if err != nil {
+		return "", fmt.Errorf("invalid pack URL %s: %w", ref, err)
+	}
+	return baseURL.ResolveReference(refURL).String(), nil
+}
+
+// fetchPackFile returns the content at source, an http(s) or s3 URL or a file path.
+func fetchPackFile(source string) ([]byte, error) {
+	if !isURLSource(source) {
+		return afero.ReadFile(appFS, source)
+	}
+	var req *http.Request
+	var err error
+	if strings.HasPrefix(strings.ToLower(source), "s3://") {
+		req, err = newS3Request(http.MethodGet, source, nil, nil)
+	} else {
+		req, err = http.NewRequest(http.MethodGet, source, nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+	resp, err := httpclient.Client().Do(req)
+	if err != nil {
+		// Errors include the URL, which may carry a signature in its query
+		return nil, fmt.Errorf("failed to download %s: %s", redactURL(source), strings.ReplaceAll(err.Error(), source, redactURL(source)))
+	}
+	defer resp.Body.Close()
+	// S3 answers 403 rather than 404 for missing objects without list permission
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusForbidden && strings.HasPrefix(strings.ToLower(source), "s3://") {
+		return nil, fmt.Errorf("failed to download %s: %w", redactURL(source), os.ErrNotExist)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %s: unexpected status %s", redactURL(source), resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// downloadPack fetches, verifies and parses the pack at source, checking that it
+// has a valid name and at least one prompt.
+func downloadPack(conf config.Config, source string, insecure bool) (PackInfo, string, error) {
+	data, err := fetchPackFile(source)
+	if err != nil {
+		return PackInfo{}, "", err
+	}
+	signer, err := verifyPack(conf, source, data, insecure)
+	if err != nil {
+		return PackInfo{}, "", err
+	}
+	info, content, err := parsePack(string(data))
+	if err != nil {
+		return PackInfo{}, "", fmt.Errorf("invalid pack %s: %w", redactURL(source), err)
+	}
+	if info.Name == "" {
+		base := path.Base(redactURL(filepath.ToSlash(source)))
+		info.Name = strings.TrimSuffix(base, path.Ext(base))
+	}
+	if !packNamePattern.MatchString(info.Name) {
+		return PackInfo{}, "", fmt.Errorf("invalid pack %s: name %q must use only letters, digits, '.', '-' and '_'", redactURL(source), info.Name)
+	}
+	sections, _, err := parseMarkdown(strings.NewReader(content), conf, "")
+	if err != nil {
+		return PackInfo{}, "", fmt.Errorf("invalid pack %s: %w", redactURL(source), err)
+	}
+	if len(generateSearchPool(gatherPromptData(sections), "")) == 0 {
+		return PackInfo{}, "", fmt.Errorf("invalid pack %s: it contains no prompts", redactURL(source))
+	}
+	info.Source = source
+	info.Signer = signer
+	info.Installed = time.Now().UTC()
+	return info, content, nil
+}
+
+// parsePack splits a pack into its metadata and Markdown prompts. JSON packs are
+// a packBundle; Markdown packs may start with a front matter block of "key: value"
+// lines between "---" lines, holding name, version, description and author.
+func parsePack(data string) (PackInfo, string, error) {
+	data = normalizeText(data)
+	if strings.HasPrefix(strings.TrimSpace(data), "{") {
+		var bundle packBundle
+		if err := json.Unmarshal([]byte(data), &bundle); err != nil {
+			return PackInfo{}, "", fmt.Errorf("failed to parse JSON pack: %w", err)
+		}
+		info := PackInfo{Name: bundle.Name, Version: bundle.Version, Description: bundle.Description, Author: bundle.Author}
+		return info, normalizeText(bundle.Content), nil
+	}
+
+	var info PackInfo
+	if !strings.HasPrefix(data, "---\n") {
+		return info, data, nil
+	}
+	header, content, ok := strings.Cut(strings.TrimPrefix(data, "---\n"), "\n---\n")
+	if !ok {
+		return info, data, nil
+	}
+	for _, line := range strings.Split(header, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		value = strings.Trim(strings.TrimSpace(value), `"'`)
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "name":
+			info.Name = value
+		case "version":
+			info.Version = value
+		case "description":
+			info.Description = value
+		case "author":
+			info.Author = value
+		}
+	}
+	return info, strings.TrimLeft(content, "\n"), nil
+}
+
+// savePack writes the pack's prompts and metadata to the packs directory.
+func savePack(conf config.Config, info PackInfo, content string) error {
+	dir, err := packsDir(conf)
+	if err != nil {
+		return err
+	}
+	if err := appFS.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create packs directory: %w", err)
+	}
+	if err := afero.WriteFile(appFS, filepath.Join(dir, info.Name+".md"), []byte(content), 0600); err != nil {
+		return fmt.Errorf("failed to install pack %s: %w", info.Name, err)
+	}
+	data, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal pack %s: %w", info.Name, err)
+	}
+	if err := afero.WriteFile(appFS, filepath.Join(dir, info.Name+".json"), append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("failed to install pack %s: %w", info.Name, err)
+	}
+	return nil
+}
+
+// readPackContent returns the installed prompts of the named pack.
+func readPackContent(conf config.Config, name string) (string, error) {
+	dir, err := packsDir(conf)
+	if err != nil {
+		return "", err
+	}
+	data, err := afero.ReadFile(appFS, filepath.Join(dir, name+".md"))
+	return string(data), err
+}
+
+// packInstalled reports whether a pack named name is among installed.
+func packInstalled(installed []PackInfo, name string) bool {
+	for _, info := range installed {
+		if info.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// containsFold reports whether names contains name, ignoring case.
+func containsFold(names []string, name string) bool {
+	for _, n := range names {
+		if strings.EqualFold(n, name) {
+			return true
+		}
+	}
+	return false
+}
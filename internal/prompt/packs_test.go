@@ -0,0 +1,181 @@
+package prompt
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+const markdownPack = `---
+name: writing
+version: 1.0.0
+description: Prompts for writers
+author: Jane
+---
+# Writing
+
+## Editing
+Tighten this paragraph
+`
+
+const jsonPack = `{"name": "coding", "version": "2.1", "description": "Code review", "content": "# Coding\n\n## Review\nReview this diff\n"}`
+
+func TestParsePack(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    PackInfo
+		content string
+		wantErr bool
+	}{
+		{"markdown front matter", markdownPack, PackInfo{Name: "writing", Version: "1.0.0", Description: "Prompts for writers", Author: "Jane"}, "# Writing\n\n## Editing\nTighten this paragraph\n", false},
+		{"markdown without front matter", "# Notes\n\n## Misc\nhello\n", PackInfo{}, "# Notes\n\n## Misc\nhello\n", false},
+		{"json bundle", jsonPack, PackInfo{Name: "coding", Version: "2.1", Description: "Code review"}, "# Coding\n\n## Review\nReview this diff\n", false},
+		{"invalid json", `{"name": `, PackInfo{}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, content, err := parsePack(tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parsePack() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if info != tt.want {
+				t.Errorf("parsePack() info = %+v, want %+v", info, tt.want)
+			}
+			if content != tt.content {
+				t.Errorf("parsePack() content = %q, want %q", content, tt.content)
+			}
+		})
+	}
+}
+
+// newPackServer serves files keyed by URL path.
+func newPackServer(t *testing.T, files map[string]string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, ok := files[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(content))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestInstallPack(t *testing.T) {
+	files := map[string]string{
+		"/index.json":       `{"packs": [{"name": "writing", "url": "packs/writing.md"}]}`,
+		"/packs/writing.md": markdownPack,
+		"/coding.json":      jsonPack,
+		"/empty.md":         "# Nothing here\n",
+		"/bad-name.md":      "---\nname: ../escape\n---\n# T\n\n## S\nprompt\n",
+	}
+	server := newPackServer(t, files)
+
+	tests := []struct {
+		name     string
+		ref      string
+		registry string
+		want     string
+		wantErr  error
+	}{
+		{"url", server.URL + "/coding.json", "", "coding", nil},
+		{"registry name", "writing", server.URL + "/index.json", "writing", nil},
+		{"unknown registry name", "missing", server.URL + "/index.json", "", ErrPackNotFound},
+		{"name without registry", "writing", "", "", ErrPackNotFound},
+		{"no prompts", server.URL + "/empty.md", "", "", nil},
+		{"invalid name", server.URL + "/bad-name.md", "", "", nil},
+		{"download fails", server.URL + "/missing.md", "", "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := useMemFS(t)
+			conf := config.Config{DataDir: "/data", PackRegistry: tt.registry}
+			info, err := InstallPack(conf, tt.ref, true)
+			if tt.want == "" {
+				if err == nil {
+					t.Fatalf("InstallPack() = %+v, want an error", info)
+				}
+				if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+					t.Errorf("InstallPack() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("InstallPack() error = %v", err)
+			}
+			if info.Name != tt.want {
+				t.Errorf("InstallPack() name = %q, want %q", info.Name, tt.want)
+			}
+			if ok, _ := afero.Exists(fs, "/data/packs/"+tt.want+".md"); !ok {
+				t.Errorf("pack %s was not installed", tt.want)
+			}
+		})
+	}
+}
+
+func TestPackLifecycle(t *testing.T) {
+	fs := useMemFS(t)
+	files := map[string]string{"/writing.md": markdownPack}
+	server := newPackServer(t, files)
+	if err := afero.WriteFile(fs, "/prompts.md", []byte("# Mine\n\n## Golang\nWrite a test\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	conf := config.Config{DataDir: "/data", FilePath: "/prompts.md"}
+
+	if _, err := InstallPack(conf, server.URL+"/writing.md", true); err != nil {
+		t.Fatalf("InstallPack() error = %v", err)
+	}
+	packs, err := ListPacks(conf)
+	if err != nil || len(packs) != 1 || packs[0].Name != "writing" || packs[0].Version != "1.0.0" {
+		t.Fatalf("ListPacks() = %+v, %v, want the writing pack", packs, err)
+	}
+
+	data, err := LoadPrompts(conf)
+	if err != nil {
+		t.Fatalf("LoadPrompts() error = %v", err)
+	}
+	namespaces := map[string]bool{}
+	for _, sec := range data.Sections {
+		namespaces[sec.Namespace] = true
+	}
+	if !namespaces[NamespacePersonal] || !namespaces[NamespacePackPrefix+"writing"] {
+		t.Errorf("LoadPrompts() namespaces = %v, want %s and %swriting", namespaces, NamespacePersonal, NamespacePackPrefix)
+	}
+	if results := SearchPrompts(data, "tighten", ""); len(results) != 1 {
+		t.Errorf("SearchPrompts() = %v, want the pack prompt", results)
+	}
+
+	updates, err := UpdatePacks(conf, true)
+	if err != nil || len(updates) != 1 || updates[0].Changed {
+		t.Errorf("UpdatePacks() unchanged = %+v, %v", updates, err)
+	}
+	files["/writing.md"] = "---\nname: writing\nversion: 1.1.0\n---\n# Writing\n\n## Editing\nShorten this\n"
+	updates, err = UpdatePacks(conf, true, "writing")
+	if err != nil || len(updates) != 1 || !updates[0].Changed || updates[0].From != "1.0.0" || updates[0].To != "1.1.0" {
+		t.Errorf("UpdatePacks() changed = %+v, %v", updates, err)
+	}
+	if _, err := UpdatePacks(conf, true, "other"); !errors.Is(err, ErrPackNotFound) {
+		t.Errorf("UpdatePacks(other) error = %v, want ErrPackNotFound", err)
+	}
+
+	if err := RemovePack(conf, "writing"); err != nil {
+		t.Fatalf("RemovePack() error = %v", err)
+	}
+	if err := RemovePack(conf, "writing"); !errors.Is(err, ErrPackNotFound) {
+		t.Errorf("RemovePack() again error = %v, want ErrPackNotFound", err)
+	}
+	if packs, _ := ListPacks(conf); len(packs) != 0 {
+		t.Errorf("ListPacks() after remove = %+v, want none", packs)
+	}
+}
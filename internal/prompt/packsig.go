@@ -0,0 +1,428 @@
+package prompt
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	"golang.org/x/crypto/blake2b"
+
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+// ErrPackUnsigned is returned when installing a pack that has no signature
+// without allowing insecure installs.
+var ErrPackUnsigned = errors.New("prompt pack is not signed")
+
+// ErrPackSignature is returned when the signature of a pack does not verify, or
+// was not made with a trusted key.
+var ErrPackSignature = errors.New("prompt pack signature verification failed")
+
+// packKeysFile is the name of the file listing trusted keys in the packs directory.
+const packKeysFile = "trusted-keys"
+
+// packSSHNamespaces are the namespaces accepted in SSH signatures of packs, given to
+// ssh-keygen -Y sign with -n.
+var packSSHNamespaces = []string{"wheresmyprompt", "file"}
+
+// Key types of trusted keys.
+const (
+	PackKeyMinisign = "minisign"
+	PackKeySSH      = "ssh"
+)
+
+// TrustedKey is a public key whose signatures are accepted on prompt packs.
+type TrustedKey struct {
+	ID      string `json:"id"` // minisign key ID, or SHA256 fingerprint of SSH keys
+	Type    string `json:"type"`
+	Comment string `json:"comment,omitempty"`
+	key     ed25519.PublicKey
+	line    string // The key as stored in the trusted keys file
+}
+
+// TrustPackKey adds key to the trusted keys, returning it. key is a minisign public
+// key or an ssh-ed25519 public key, or the path of a file holding one, such as a
+// minisign .pub file or an SSH .pub file.
+func TrustPackKey(conf config.Config, key string) (TrustedKey, error) {
+	if data, err := afero.ReadFile(appFS, key); err == nil {
+		key = string(data)
+	}
+	trusted, err := parseTrustedKey(key)
+	if err != nil {
+		return TrustedKey{}, err
+	}
+	keys, err := PackKeys(conf)
+	if err != nil {
+		return TrustedKey{}, err
+	}
+	for _, k := range keys {
+		if k.ID == trusted.ID {
+			return k, nil
+		}
+	}
+	return trusted, writePackKeys(conf, append(keys, trusted))
+}
+
+// UntrustPackKey removes the trusted key with the given ID.
+func UntrustPackKey(conf config.Config, id string) error {
+	keys, err := PackKeys(conf)
+	if err != nil {
+		return err
+	}
+	for i, k := range keys {
+		if strings.EqualFold(k.ID, id) {
+			return writePackKeys(conf, append(keys[:i], keys[i+1:]...))
+		}
+	}
+	return fmt.Errorf("no trusted key with ID %s", id)
+}
+
+// PackKeys returns the trusted keys, in the order they were added.
+func PackKeys(conf config.Config) ([]TrustedKey, error) {
+	dir, err := packsDir(conf)
+	if err != nil {
+		return nil, err
+	}
+	data, err := afero.ReadFile(appFS, filepath.Join(dir, packKeysFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read trusted keys: %w", err)
+	}
+	var keys []TrustedKey
+	for n, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, err := parseTrustedKey(line)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted key on line %d: %w", n+1, err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// writePackKeys replaces the trusted keys file with keys, one per line.
+func writePackKeys(conf config.Config, keys []TrustedKey) error {
+	dir, err := packsDir(conf)
+	if err != nil {
+		return err
+	}
+	if err := appFS.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create packs directory: %w", err)
+	}
+	var b strings.Builder
+	b.WriteString("# Keys trusted to sign prompt packs, managed with wheresmyprompt pack trust\n")
+	for _, k := range keys {
+		b.WriteString(k.line + "\n")
+	}
+	if err := afero.WriteFile(appFS, filepath.Join(dir, packKeysFile), []byte(b.String()), 0600); err != nil {
+		return fmt.Errorf("failed to write trusted keys: %w", err)
+	}
+	return nil
+}
+
+// parseTrustedKey parses a minisign public key, optionally preceded by its
+// "untrusted comment:" line, or an SSH public key in authorized_keys format, as
+// given to pack trust or stored in the trusted keys file.
+func parseTrustedKey(text string) (TrustedKey, error) {
+	var comment, encoded string
+	for _, line := range strings.Split(normalizeText(text), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "untrusted comment:"):
+			comment = strings.TrimSpace(strings.TrimPrefix(line, "untrusted comment:"))
+		case encoded == "":
+			encoded = line
+		}
+	}
+
+	if strings.HasPrefix(encoded, "ssh-") {
+		fields := strings.Fields(encoded)
+		if len(fields) < 2 {
+			return TrustedKey{}, errors.New("invalid SSH public key")
+		}
+		blob, err := base64.StdEncoding.DecodeString(fields[1])
+		if err != nil {
+			return TrustedKey{}, fmt.Errorf("invalid SSH public key: %w", err)
+		}
+		key, err := parseSSHEd25519Key(blob)
+		if err != nil {
+			return TrustedKey{}, err
+		}
+		line := fields[0] + " " + fields[1]
+		if len(fields) > 2 {
+			comment = strings.Join(fields[2:], " ")
+			line += " " + comment
+		}
+		return TrustedKey{ID: sshFingerprint(blob), Type: PackKeySSH, Comment: comment, key: key, line: line}, nil
+	}
+
+	// Stored minisign keys are followed by their comment on the same line
+	encoded, storedComment, _ := strings.Cut(encoded, " ")
+	if comment == "" {
+		comment = strings.TrimSpace(storedComment)
+	}
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(raw) != 42 || string(raw[:2]) != "Ed" {
+		return TrustedKey{}, errors.New("not a minisign or ssh-ed25519 public key")
+	}
+	line := encoded
+	if comment != "" {
+		line += " " + comment
+	}
+	return TrustedKey{ID: minisignKeyID(raw[2:10]), Type: PackKeyMinisign, Comment: comment, key: ed25519.PublicKey(raw[10:]), line: line}, nil
+}
+
+// minisignKeyID formats a minisign key ID the way minisign prints it.
+func minisignKeyID(id []byte) string {
+	return strings.ToUpper(fmt.Sprintf("%016x", binary.LittleEndian.Uint64(id)))
+}
+
+// sshFingerprint returns the SHA256 fingerprint of an SSH public key, as printed by
+// ssh-keygen -l.
+func sshFingerprint(blob []byte) string {
+	sum := sha256.Sum256(blob)
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:])
+}
+
+// verifyPack checks the signature published next to the pack at source, source
+// followed by ".minisig" for minisign or ".sig" for ssh-keygen -Y sign, against the
+// trusted keys, returning the ID of the key that signed data. A pack without a
+// signature, or signed with a key that is not trusted, is refused unless insecure
+// is set, in which case it is installed with a warning. A signature of a trusted
+// key that does not match data is always refused, as the pack was tampered with.
+func verifyPack(conf config.Config, source string, data []byte, insecure bool) (string, error) {
+	keys, err := PackKeys(conf)
+	if err != nil {
+		return "", err
+	}
+
+	var signer string
+	var verifyErr error
+	found := false
+	for _, ext := range []string{".minisig", ".sig"} {
+		sig, err := fetchPackFile(sidecarURL(source, ext))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to download the signature of %s: %w", redactURL(source), err)
+		}
+		found = true
+		if ext == ".minisig" {
+			signer, verifyErr = verifyMinisign(keys, data, sig)
+		} else {
+			signer, verifyErr = verifySSHSignature(keys, data, sig)
+		}
+		break
+	}
+
+	switch {
+	case !found && insecure:
+		log.Warnf("Installing unsigned pack %s", redactURL(source))
+		return "", nil
+	case !found:
+		return "", fmt.Errorf("%w: no %s.minisig or .sig signature found; use --insecure to install it anyway", ErrPackUnsigned, redactURL(source))
+	case errors.Is(verifyErr, errUntrustedKey) && insecure:
+		log.Warnf("Installing pack %s signed with an untrusted key: %v", redactURL(source), verifyErr)
+		return "", nil
+	case verifyErr != nil:
+		return "", fmt.Errorf("%w for %s: %w", ErrPackSignature, redactURL(source), verifyErr)
+	}
+	return signer, nil
+}
+
+// errUntrustedKey is returned when a signature was made with a key that is not trusted.
+var errUntrustedKey = errors.New("signed with a key that is not trusted; trust it with wheresmyprompt pack trust")
+
+// sidecarURL returns the location of the file named like source with ext appended,
+// keeping the query of URLs last.
+func sidecarURL(source, ext string) string {
+	if isURLSource(source) {
+		if base, query, ok := strings.Cut(source, "?"); ok {
+			return base + ext + "?" + query
+		}
+	}
+	return source + ext
+}
+
+// verifyMinisign verifies a minisign signature file of data, both the signature
+// of data, prehashed with BLAKE2b-512 or not, and the global signature covering
+// the trusted comment.
+func verifyMinisign(keys []TrustedKey, data, sigFile []byte) (string, error) {
+	var lines []string
+	for _, line := range strings.Split(normalizeText(string(sigFile)), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) != 4 || !strings.HasPrefix(lines[2], "trusted comment: ") {
+		return "", errors.New("invalid minisign signature file")
+	}
+	sig, err := base64.StdEncoding.DecodeString(lines[1])
+	if err != nil || len(sig) != 74 {
+		return "", errors.New("invalid minisign signature")
+	}
+	globalSig, err := base64.StdEncoding.DecodeString(lines[3])
+	if err != nil || len(globalSig) != ed25519.SignatureSize {
+		return "", errors.New("invalid minisign global signature")
+	}
+
+	id := minisignKeyID(sig[2:10])
+	key, ok := findTrustedKey(keys, PackKeyMinisign, id)
+	if !ok {
+		return "", fmt.Errorf("minisign key %s %w", id, errUntrustedKey)
+	}
+	message := data
+	switch string(sig[:2]) {
+	case "ED":
+		sum := blake2b.Sum512(data)
+		message = sum[:]
+	case "Ed":
+	default:
+		return "", fmt.Errorf("unsupported minisign signature algorithm %q", sig[:2])
+	}
+	if !ed25519.Verify(key.key, message, sig[10:]) {
+		return "", fmt.Errorf("signature does not match the pack signed with minisign key %s", id)
+	}
+	trustedComment := strings.TrimPrefix(lines[2], "trusted comment: ")
+	if !ed25519.Verify(key.key, append(sig[10:len(sig):len(sig)], trustedComment...), globalSig) {
+		return "", errors.New("the trusted comment of the minisign signature was modified")
+	}
+	return id, nil
+}
+
+// verifySSHSignature verifies an armored SSH signature of data made with
+// ssh-keygen -Y sign, in one of packSSHNamespaces, by an ssh-ed25519 key.
+func verifySSHSignature(keys []TrustedKey, data, sigFile []byte) (string, error) {
+	text := normalizeText(string(sigFile))
+	begin := strings.Index(text, "-----BEGIN SSH SIGNATURE-----")
+	end := strings.Index(text, "-----END SSH SIGNATURE-----")
+	if begin < 0 || end < begin {
+		return "", errors.New("invalid SSH signature file")
+	}
+	armored := strings.Join(strings.Fields(text[begin+len("-----BEGIN SSH SIGNATURE-----"):end]), "")
+	blob, err := base64.StdEncoding.DecodeString(armored)
+	if err != nil || !bytes.HasPrefix(blob, []byte("SSHSIG")) || len(blob) < 10 {
+		return "", errors.New("invalid SSH signature")
+	}
+	r := sshReader{data: blob[6:]}
+	version := r.uint32()
+	publicKey := r.string()
+	namespace := string(r.string())
+	reserved := r.string()
+	hashAlg := string(r.string())
+	signature := r.string()
+	if r.err || version != 1 {
+		return "", errors.New("invalid SSH signature")
+	}
+
+	id := sshFingerprint(publicKey)
+	key, ok := findTrustedKey(keys, PackKeySSH, id)
+	if !ok {
+		return "", fmt.Errorf("SSH key %s %w", id, errUntrustedKey)
+	}
+	if !containsFold(packSSHNamespaces, namespace) {
+		return "", fmt.Errorf("SSH signature namespace %q is not one of %s", namespace, strings.Join(packSSHNamespaces, ", "))
+	}
+	var digest []byte
+	switch hashAlg {
+	case "sha256":
+		sum := sha256.Sum256(data)
+		digest = sum[:]
+	case "sha512":
+		sum := sha512.Sum512(data)
+		digest = sum[:]
+	default:
+		return "", fmt.Errorf("unsupported SSH signature hash %q", hashAlg)
+	}
+	sr := sshReader{data: signature}
+	format := string(sr.string())
+	sig := sr.string()
+	if sr.err || format != "ssh-ed25519" {
+		return "", fmt.Errorf("unsupported SSH signature format %q", format)
+	}
+
+	var signed bytes.Buffer
+	signed.WriteString("SSHSIG")
+	for _, field := range [][]byte{[]byte(namespace), reserved, []byte(hashAlg), digest} {
+		writeSSHString(&signed, field)
+	}
+	if !ed25519.Verify(key.key, signed.Bytes(), sig) {
+		return "", fmt.Errorf("signature does not match the pack signed with SSH key %s", id)
+	}
+	return id, nil
+}
+
+// findTrustedKey returns the trusted key of type keyType with the given ID.
+func findTrustedKey(keys []TrustedKey, keyType, id string) (TrustedKey, bool) {
+	for _, k := range keys {
+		if k.Type == keyType && k.ID == id {
+			return k, true
+		}
+	}
+	return TrustedKey{}, false
+}
+
+// parseSSHEd25519Key returns the key of an ssh-ed25519 public key blob.
+func parseSSHEd25519Key(blob []byte) (ed25519.PublicKey, error) {
+	r := sshReader{data: blob}
+	keyType := string(r.string())
+	key := r.string()
+	if r.err {
+		return nil, errors.New("invalid SSH public key")
+	}
+	if keyType != "ssh-ed25519" || len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("unsupported SSH key type %s: only ssh-ed25519 keys can sign packs", keyType)
+	}
+	return ed25519.PublicKey(key), nil
+}
+
+// sshReader reads the fields of the SSH wire format (RFC 4251), setting err once
+// data runs out.
+type sshReader struct {
+	data []byte
+	err  bool
+}
+
+func (r *sshReader) uint32() uint32 {
+	if len(r.data) < 4 {
+		r.err = true
+		return 0
+	}
+	v := binary.BigEndian.Uint32(r.data)
+	r.data = r.data[4:]
+	return v
+}
+
+func (r *sshReader) string() []byte {
+	n := int(r.uint32())
+	if r.err || n > len(r.data) {
+		r.err = true
+		return nil
+	}
+	s := r.data[:n]
+	r.data = r.data[n:]
+	return s
+}
+
+// writeSSHString appends s to b as an SSH wire format string.
+func writeSSHString(b *bytes.Buffer, s []byte) {
+	_ = binary.Write(b, binary.BigEndian, uint32(len(s)))
+	b.Write(s)
+}
@@ -0,0 +1,206 @@
+package prompt
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"github.com/spf13/afero"
+	"golang.org/x/crypto/blake2b"
+
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+// minisignKey is a minisign key pair for tests.
+type minisignKey struct {
+	id      [8]byte
+	private ed25519.PrivateKey
+	public  string // Contents of the .pub file
+}
+
+func newMinisignKey(t *testing.T, id byte) minisignKey {
+	t.Helper()
+	public, private, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k := minisignKey{id: [8]byte{id, 2, 3, 4, 5, 6, 7, 8}, private: private}
+	raw := append(append([]byte("Ed"), k.id[:]...), public...)
+	k.public = "untrusted comment: minisign public key\n" + base64.StdEncoding.EncodeToString(raw) + "\n"
+	return k
+}
+
+// sign returns a .minisig file for data, prehashed unless legacy is set.
+func (k minisignKey) sign(data []byte, legacy bool) string {
+	alg, message := "ED", data
+	if legacy {
+		alg = "Ed"
+	} else {
+		sum := blake2b.Sum512(data)
+		message = sum[:]
+	}
+	sig := ed25519.Sign(k.private, message)
+	comment := "timestamp:1760000000\tfile:pack.md"
+	global := ed25519.Sign(k.private, append(append([]byte{}, sig...), comment...))
+	raw := append(append([]byte(alg), k.id[:]...), sig...)
+	return "untrusted comment: signature from minisign secret key\n" +
+		base64.StdEncoding.EncodeToString(raw) + "\n" +
+		"trusted comment: " + comment + "\n" +
+		base64.StdEncoding.EncodeToString(global) + "\n"
+}
+
+// sshKey is an ssh-ed25519 key pair for tests.
+type sshKey struct {
+	private ed25519.PrivateKey
+	blob    []byte
+	public  string // authorized_keys line
+}
+
+func newSSHKey(t *testing.T) sshKey {
+	t.Helper()
+	public, private, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var blob bytes.Buffer
+	writeSSHString(&blob, []byte("ssh-ed25519"))
+	writeSSHString(&blob, public)
+	return sshKey{private: private, blob: blob.Bytes(), public: "ssh-ed25519 " + base64.StdEncoding.EncodeToString(blob.Bytes()) + " team@example.com"}
+}
+
+// sign returns an armored SSH signature of data in namespace, like ssh-keygen -Y sign.
+func (k sshKey) sign(data []byte, namespace string) string {
+	digest := sha512.Sum512(data)
+	var signed bytes.Buffer
+	signed.WriteString("SSHSIG")
+	for _, field := range [][]byte{[]byte(namespace), nil, []byte("sha512"), digest[:]} {
+		writeSSHString(&signed, field)
+	}
+	var sig bytes.Buffer
+	writeSSHString(&sig, []byte("ssh-ed25519"))
+	writeSSHString(&sig, ed25519.Sign(k.private, signed.Bytes()))
+
+	var blob bytes.Buffer
+	blob.WriteString("SSHSIG")
+	blob.Write([]byte{0, 0, 0, 1})
+	for _, field := range [][]byte{k.blob, []byte(namespace), nil, []byte("sha512"), sig.Bytes()} {
+		writeSSHString(&blob, field)
+	}
+	return "-----BEGIN SSH SIGNATURE-----\n" + base64.StdEncoding.EncodeToString(blob.Bytes()) + "\n-----END SSH SIGNATURE-----\n"
+}
+
+func TestPackKeys(t *testing.T) {
+	fs := useMemFS(t)
+	conf := config.Config{DataDir: "/data"}
+	minisign := newMinisignKey(t, 1)
+	ssh := newSSHKey(t)
+	if err := afero.WriteFile(fs, "/keys/minisign.pub", []byte(minisign.public), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := TrustPackKey(conf, "/keys/minisign.pub")
+	if err != nil || first.Type != PackKeyMinisign || first.ID != "0807060504030201" {
+		t.Fatalf("TrustPackKey(minisign) = %+v, %v", first, err)
+	}
+	second, err := TrustPackKey(conf, ssh.public)
+	if err != nil || second.Type != PackKeySSH || second.Comment != "team@example.com" {
+		t.Fatalf("TrustPackKey(ssh) = %+v, %v", second, err)
+	}
+	if _, err := TrustPackKey(conf, ssh.public); err != nil {
+		t.Fatalf("TrustPackKey() again error = %v", err)
+	}
+	if _, err := TrustPackKey(conf, "not a key"); err == nil {
+		t.Error("TrustPackKey(invalid) error = nil, want an error")
+	}
+
+	keys, err := PackKeys(conf)
+	if err != nil || len(keys) != 2 || keys[0].ID != first.ID || keys[1].ID != second.ID {
+		t.Fatalf("PackKeys() = %+v, %v, want both keys once", keys, err)
+	}
+	if err := UntrustPackKey(conf, first.ID); err != nil {
+		t.Fatalf("UntrustPackKey() error = %v", err)
+	}
+	if err := UntrustPackKey(conf, first.ID); err == nil {
+		t.Error("UntrustPackKey() again error = nil, want an error")
+	}
+	if keys, _ := PackKeys(conf); len(keys) != 1 || keys[0].ID != second.ID {
+		t.Errorf("PackKeys() after untrust = %+v, want the SSH key", keys)
+	}
+}
+
+func TestVerifyPack(t *testing.T) {
+	pack := []byte(markdownPack)
+	trusted := newMinisignKey(t, 1)
+	stranger := newMinisignKey(t, 9)
+	ssh := newSSHKey(t)
+
+	tamperedComment := trusted.sign(pack, false)
+	tamperedComment = string(bytes.Replace([]byte(tamperedComment), []byte("file:pack.md"), []byte("file:other.md"), 1))
+
+	tests := []struct {
+		name     string
+		sidecars map[string]string
+		insecure bool
+		signed   bool
+		wantErr  error
+	}{
+		{"minisign prehashed", map[string]string{".minisig": trusted.sign(pack, false)}, false, true, nil},
+		{"minisign legacy", map[string]string{".minisig": trusted.sign(pack, true)}, false, true, nil},
+		{"ssh", map[string]string{".sig": ssh.sign(pack, "wheresmyprompt")}, false, true, nil},
+		{"ssh file namespace", map[string]string{".sig": ssh.sign(pack, "file")}, false, true, nil},
+		{"ssh other namespace", map[string]string{".sig": ssh.sign(pack, "git")}, false, false, ErrPackSignature},
+		{"tampered pack", map[string]string{".minisig": trusted.sign([]byte("# Evil\n"), false)}, true, false, ErrPackSignature},
+		{"tampered trusted comment", map[string]string{".minisig": tamperedComment}, true, false, ErrPackSignature},
+		{"untrusted key", map[string]string{".minisig": stranger.sign(pack, false)}, false, false, ErrPackSignature},
+		{"untrusted key insecure", map[string]string{".minisig": stranger.sign(pack, false)}, true, false, nil},
+		{"unsigned", nil, false, false, ErrPackUnsigned},
+		{"unsigned insecure", nil, true, false, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := useMemFS(t)
+			conf := config.Config{DataDir: "/data"}
+			if _, err := TrustPackKey(conf, trusted.public); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := TrustPackKey(conf, ssh.public); err != nil {
+				t.Fatal(err)
+			}
+			if err := afero.WriteFile(fs, "/packs/writing.md", pack, 0600); err != nil {
+				t.Fatal(err)
+			}
+			for ext, sig := range tt.sidecars {
+				if err := afero.WriteFile(fs, "/packs/writing.md"+ext, []byte(sig), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			info, err := InstallPack(conf, "/packs/writing.md", tt.insecure)
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr != nil) != (err != nil) {
+				t.Fatalf("InstallPack() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && (info.Signer != "") != tt.signed {
+				t.Errorf("InstallPack() signer = %q, want signed %v", info.Signer, tt.signed)
+			}
+		})
+	}
+}
+
+func TestSidecarURL(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{"/packs/writing.md", "/packs/writing.md.minisig"},
+		{"https://example.com/writing.md", "https://example.com/writing.md.minisig"},
+		{"https://example.com/writing.md?X-Amz-Signature=abc", "https://example.com/writing.md.minisig?X-Amz-Signature=abc"},
+	}
+	for _, tt := range tests {
+		if got := sidecarURL(tt.source, ".minisig"); got != tt.want {
+			t.Errorf("sidecarURL(%q) = %q, want %q", tt.source, got, tt.want)
+		}
+	}
+}
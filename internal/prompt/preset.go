@@ -0,0 +1,114 @@
+package prompt
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/spf13/afero"
+
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+// Preset formats prompts for a destination such as a chat UI or a terminal. A preset
+// is selected with --for; without one, copies use COPY_TRANSFORM, COPY_PREFIX and
+// COPY_SUFFIX and printed prompts are left as they are.
+type Preset struct {
+	Transform []string `json:"transform"` // Transforms applied in order, see TransformCopy
+	Prefix    string   `json:"prefix"`    // Added before each prompt, after Transform
+	Suffix    string   `json:"suffix"`    // Added after each prompt, after Transform
+	Separator string   `json:"separator"` // Printed between prompts, "\n\n" if empty
+	Context   bool     `json:"context"`   // Precede each prompt by its title, before Transform
+}
+
+// builtinPresets are the presets available without PRESETS_FILE.
+var builtinPresets = map[string]Preset{
+	// Chat UIs render Markdown, so prompts keep it and say where they come from
+	"chatgpt": {Context: true, Separator: "\n\n---\n\n"},
+	// A terminal prompt sends each line as it is pasted
+	"claude-code": {Transform: []string{TransformOneLine}, Separator: "\n"},
+	// Slack mangles Markdown outside code blocks
+	"slack": {Transform: []string{TransformCodeBlock}},
+}
+
+// Presets returns the built-in presets merged with those of the JSON file at path, an
+// object of presets by name such as {"jira": {"transform": ["strip-markdown"]}}. A
+// preset of the file replaces the built-in preset of the same name. An empty path
+// returns the built-in presets.
+func Presets(path string) (map[string]Preset, error) {
+	presets := maps.Clone(builtinPresets)
+	if path == "" {
+		return presets, nil
+	}
+	data, err := afero.ReadFile(appFS, path) // #nosec G304
+	if err != nil {
+		return nil, fmt.Errorf("failed to read presets: %w", err)
+	}
+	var user map[string]Preset
+	if err := json.Unmarshal(data, &user); err != nil {
+		return nil, fmt.Errorf("failed to parse presets %s: %w", path, err)
+	}
+	maps.Copy(presets, user)
+	return presets, nil
+}
+
+// ResolvePreset returns the preset conf.Preset names, or the preset made of
+// COPY_TRANSFORM, COPY_PREFIX and COPY_SUFFIX when it is empty. It returns an error
+// listing the available presets for an unknown name.
+func ResolvePreset(conf config.Config) (Preset, error) {
+	if conf.Preset == "" {
+		return Preset{Transform: conf.CopyTransform, Prefix: conf.CopyPrefix, Suffix: conf.CopySuffix}, nil
+	}
+	presets, err := Presets(conf.PresetsFile)
+	if err != nil {
+		return Preset{}, err
+	}
+	preset, ok := presets[conf.Preset]
+	if !ok {
+		return Preset{}, fmt.Errorf("unknown preset %q: must be one of %s", conf.Preset, strings.Join(slices.Sorted(maps.Keys(presets)), ", "))
+	}
+	return preset, nil
+}
+
+// Format returns the content of p formatted by the preset: preceded by its title
+// with Context, transformed, then surrounded by Prefix and Suffix. It returns an
+// error for an unknown transform.
+func (ps Preset) Format(p Prompt) (string, error) {
+	content := p.Content
+	if ps.Context {
+		if title := cmp.Or(p.Title, p.Section); title != "" {
+			content = title + "\n\n" + content
+		}
+	}
+	for _, name := range ps.Transform {
+		switch strings.TrimSpace(name) {
+		case TransformStripMarkdown:
+			content = stripMarkdown(content)
+		case TransformOneLine:
+			content = strings.Join(strings.Fields(content), " ")
+		case TransformCodeBlock:
+			content = codeBlock(content)
+		case TransformJSON:
+			content = jsonString(content)
+		case "":
+		default:
+			return "", fmt.Errorf("unknown copy transform %q: must be %s, %s, %s or %s", name, TransformStripMarkdown, TransformOneLine, TransformCodeBlock, TransformJSON)
+		}
+	}
+	return ps.Prefix + content + ps.Suffix, nil
+}
+
+// Join formats prompts with the preset and joins them with its Separator.
+func (ps Preset) Join(prompts []Prompt) (string, error) {
+	formatted := make([]string, len(prompts))
+	for i, p := range prompts {
+		var err error
+		if formatted[i], err = ps.Format(p); err != nil {
+			return "", err
+		}
+	}
+	return strings.Join(formatted, cmp.Or(ps.Separator, "\n\n")), nil
+}
@@ -0,0 +1,89 @@
+package prompt
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+func TestResolvePreset(t *testing.T) {
+	useMemFS(t)
+	if err := afero.WriteFile(appFS, "/presets.json", []byte(`{"jira": {"transform": ["strip-markdown"], "separator": "\n----\n"}, "slack": {"prefix": ">"}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := afero.WriteFile(appFS, "/broken.json", []byte(`{"jira": [`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		conf     config.Config
+		expected string // The prompt formatted by the preset
+		wantErr  string
+	}{
+		{name: "copy settings without preset", conf: config.Config{CopyTransform: []string{TransformOneLine}, CopySuffix: "!"}, expected: "**Review** this!"},
+		{name: "built-in", conf: config.Config{Preset: "slack", CopyTransform: []string{TransformJSON}}, expected: "```\n**Review**\nthis\n```"},
+		{name: "from file", conf: config.Config{Preset: "jira", PresetsFile: "/presets.json"}, expected: "Review\nthis"},
+		{name: "file replaces built-in", conf: config.Config{Preset: "slack", PresetsFile: "/presets.json"}, expected: ">**Review**\nthis"},
+		{name: "built-in kept with file", conf: config.Config{Preset: "claude-code", PresetsFile: "/presets.json"}, expected: "**Review** this"},
+		{name: "unknown", conf: config.Config{Preset: "teams"}, wantErr: `unknown preset "teams": must be one of chatgpt, claude-code, slack`},
+		{name: "missing file", conf: config.Config{Preset: "jira", PresetsFile: "/missing.json"}, wantErr: "failed to read presets"},
+		{name: "broken file", conf: config.Config{Preset: "jira", PresetsFile: "/broken.json"}, wantErr: "failed to parse presets /broken.json"},
+	}
+
+	p := Prompt{Content: "**Review**\nthis", Section: "Golang", Title: "LLM Prompts > Golang"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			preset, err := ResolvePreset(tt.conf)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ResolvePreset() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ResolvePreset() error = %v", err)
+			}
+			got, err := preset.Format(p)
+			if err != nil {
+				t.Fatalf("Format() error = %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("Format() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPresetJoin(t *testing.T) {
+	prompts := []Prompt{{Content: "One", Section: "Golang"}, {Content: "Two", Title: "LLM Prompts > Python"}}
+
+	tests := []struct {
+		name     string
+		preset   Preset
+		expected string
+	}{
+		{name: "default separator", preset: Preset{}, expected: "One\n\nTwo"},
+		{name: "context falls back to section", preset: Preset{Context: true, Separator: "\n---\n"}, expected: "Golang\n\nOne\n---\nLLM Prompts > Python\n\nTwo"},
+		{name: "transformed", preset: Preset{Transform: []string{TransformJSON}, Separator: ","}, expected: `"One","Two"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.preset.Join(prompts)
+			if err != nil {
+				t.Fatalf("Join() error = %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("Join() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+
+	if _, err := (Preset{Transform: []string{"yaml"}}).Join(prompts); err == nil {
+		t.Error("Join() with an unknown transform succeeded")
+	}
+}
@@ -4,91 +4,289 @@
 package prompt
 
 import (
-	"bufio"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"runtime"
-	"sort"
 	"strings"
+	"time"
 
-	"github.com/lithammer/fuzzysearch/fuzzy"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/toozej/wheresmyprompt/internal/redact"
+	"github.com/toozej/wheresmyprompt/internal/sandbox"
+	"github.com/toozej/wheresmyprompt/internal/search"
 	"github.com/toozej/wheresmyprompt/pkg/config"
 )
 
 // Prompt represents a single LLM prompt with its metadata.
-// It contains the prompt's content and the section it belongs to.
-type Prompt struct {
-	Content string // The actual prompt content
-	Section string // The section this prompt belongs to
-}
+// The parser and search engine live in internal/search so they can also be built
+// for WebAssembly; the types are aliased here for existing callers.
+type Prompt = search.Prompt
 
 // PromptData contains the structured data for all prompts.
-// providing a list of sections for efficient searching and categorization.
-type PromptData struct {
-	Sections []Section // All sections parsed from the markdown
-}
+type PromptData = search.PromptData
 
-// Section represents a heading (any depth) and its associated lines
-type Section struct {
-	Headings []string // Ordered from top-level heading to deepest sub-heading
-	Lines    []string
-}
+// Section represents a heading (any depth) and its associated lines.
+type Section = search.Section
+
+// Match is a search result with its score, see search.Match.
+type Match = search.Match
+
+// Warning is a problem found while parsing a library, see search.Warning.
+type Warning = search.Warning
+
+// ErrLineTooLong is returned by the parser when a line exceeds the maximum line size.
+var ErrLineTooLong = search.ErrLineTooLong
+
+// ErrAuth is returned when authenticating with Simplenote or its secret provider fails.
+var ErrAuth = errors.New("simplenote authentication failed")
+
+// ErrClipboard is returned when a prompt cannot be copied to the clipboard.
+var ErrClipboard = errors.New("failed to copy to clipboard")
+
+// Namespaces used when a team library is loaded alongside the personal library.
+const (
+	NamespacePersonal = "mine"
+	NamespaceTeam     = "team"
+)
 
 // CheckRequiredBinaries verifies that all required external binaries are available on the system.
-// It checks for sncli (when using Simplenote) and op (1Password CLI) based on the configuration.
+// It checks for sncli when using Simplenote, and for the CLI of the secret provider
+// (op, bw or pass) when the Simplenote credentials come from one.
 // Returns an error if any required binary is missing.
 func CheckRequiredBinaries(conf config.Config) error {
-	// Always check for sncli if not using filepath
-	if conf.FilePath == "" {
-		if _, err := exec.LookPath("sncli"); err != nil {
-			return fmt.Errorf("sncli binary not found: %w", err)
-		}
+	if conf.FilePath != "" {
+		return nil
 	}
-
-	// Check for op binary for 1Password integration
-	if _, err := exec.LookPath("op"); err != nil {
-		return fmt.Errorf("1password CLI (op) binary not found: %w", err)
+	if src, ok := customSourceFunc(conf); ok {
+		return src.Check()
+	}
+	if _, err := sandbox.LookPath("sncli"); err != nil {
+		return fmt.Errorf("sncli binary not found: %w", err)
 	}
 
+	if !conf.UsesSecretProvider() {
+		return nil
+	}
+	provider, err := NewSecretProvider(conf)
+	if err != nil {
+		return err
+	}
+	if binary := provider.Binary(); binary != "" {
+		if _, err := sandbox.LookPath(binary); err != nil {
+			return fmt.Errorf("%s binary not found for SECRET_PROVIDER: %w", binary, err)
+		}
+	}
 	return nil
 }
 
-// LoadPrompts loads prompts from either a local Markdown file or Simplenote.
-// The source is determined by the FilePath field in the configuration.
-// If FilePath is empty, it loads from Simplenote; otherwise, it loads from the specified file.
+// LoadPrompts loads prompts from either a local Markdown file, a Source such as a
+// SOURCE plugin, or Simplenote. The source is determined by the FilePath field in the
+// configuration. If FilePath is empty, it loads from the configured Source, or else
+// Simplenote; otherwise, it loads from the specified file.
+// When a team library is configured (TEAM_FILEPATH or TEAM_SN_NOTE) it is loaded as well,
+// and every section is tagged with the NamespacePersonal or NamespaceTeam namespace.
+// Team prompts overridden in the personal library's OverrideSection show the
+// override instead, badged NamespaceOverridden (see applyOverrides).
+// Installed prompt packs are loaded last, tagged with NamespacePackPrefix and their name.
 // Returns structured prompt data or an error if loading fails.
 func LoadPrompts(conf config.Config) (*PromptData, error) {
-	var content string
-	var err error
+	sections, err := loadSections(conf.FilePath, conf.SNNote, conf)
+	if err != nil {
+		return nil, err
+	}
 
-	if conf.FilePath != "" {
-		content, err = loadFromFile(conf.FilePath)
+	routeSections, err := loadRouteSections(conf)
+	if err != nil {
+		return nil, err
+	}
+	sections = append(sections, routeSections...)
+
+	packSections, err := loadPackSections(conf)
+	if err != nil {
+		return nil, err
+	}
+	if hasTeamLibrary(conf) || len(packSections) > 0 {
+		setNamespace(sections, NamespacePersonal)
+	}
+	if hasTeamLibrary(conf) {
+		teamSections, err := loadTeamSections(conf)
+		if err != nil {
+			return nil, err
+		}
+		setNamespace(teamSections, NamespaceTeam)
+		sections = applyOverrides(sections, teamSections, conf.OverrideSection)
+		sections = append(sections, teamSections...)
+	}
+	sections = append(sections, packSections...)
+
+	if !conf.IncludeArchived {
+		sections = withoutArchived(sections, conf.ArchiveSection)
+	}
+
+	// Gather the loaded sections into structured prompt data
+	data := gatherPromptData(sections)
+	data.Options = searchOptions(conf)
+	data.Source, data.Modified = sourceInfo(conf)
+	return data, nil
+}
+
+// sourceInfo describes where LoadPrompts reads from, for display: the file name,
+// Source or Simplenote note, followed by the team library if any, and when the personal
+// library last changed (zero if unknown).
+func sourceInfo(conf config.Config) (string, time.Time) {
+	var source string
+	var modified time.Time
+	if src, ok := customSourceFunc(conf); ok {
+		source = src.Name()
+	} else if conf.FilePath != "" {
+		source = filepath.Base(redactURL(conf.FilePath))
+		if info, err := appFS.Stat(conf.FilePath); err == nil {
+			modified = info.ModTime()
+		}
 	} else {
-		content, err = loadFromSimplenote(conf)
+		source = conf.SNNote + " (Simplenote)"
+		modified, _ = sncliModifiedFunc(conf)
 	}
 
+	switch {
+	case conf.TeamFilePath != "":
+		source += " + " + filepath.Base(redactURL(conf.TeamFilePath))
+	case conf.TeamSNNote != "":
+		source += " + " + conf.TeamSNNote + " (Simplenote)"
+	}
+	return source, modified
+}
+
+// withoutArchived drops sections nested below the archive section heading.
+func withoutArchived(sections []Section, archiveSection string) []Section {
+	if archiveSection == "" {
+		return sections
+	}
+	kept := sections[:0:0]
+	for _, sec := range sections {
+		archived := false
+		for _, heading := range sec.Headings {
+			if heading == archiveSection {
+				archived = true
+				break
+			}
+		}
+		if !archived {
+			kept = append(kept, sec)
+		}
+	}
+	return kept
+}
+
+// hasTeamLibrary reports whether a team library is configured in addition to the personal one.
+func hasTeamLibrary(conf config.Config) bool {
+	return conf.TeamFilePath != "" || conf.TeamSNNote != ""
+}
+
+// loadTeamSections loads and parses the team library. Writes never go to the team library.
+func loadTeamSections(conf config.Config) ([]Section, error) {
+	sections, err := loadSections(conf.TeamFilePath, conf.TeamSNNote, conf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load team library: %w", err)
+	}
+	return sections, nil
+}
+
+// loadSections parses the library at filePath, or the Simplenote note when filePath is empty.
+// Local files are streamed through the parser rather than read into memory first.
+// Parse warnings are logged at debug level.
+func loadSections(filePath, note string, conf config.Config) ([]Section, error) {
+	sections, warnings, err := diagnoseSections(filePath, note, conf)
 	if err != nil {
 		return nil, err
 	}
+	for _, w := range warnings {
+		log.Debugf("%s: %s", sourceName(filePath, note), w)
+	}
+	return sections, nil
+}
 
-	// Parse the loaded content into []sections
-	sections, err := parseMarkdownIntoSections(content)
+// diagnoseSections parses the library like loadSections, also returning its parse warnings.
+// The personal library is read from its Source instead when one is configured (see
+// customSource), and URLs are read as a remoteSource. With INDEX_CACHE, unchanged files and notes are read from the index
+// instead of parsed.
+func diagnoseSections(filePath, note string, conf config.Config) ([]Section, []Warning, error) {
+	var src Source
+	if custom, ok := customSourceFunc(conf); ok && filePath == "" && note == conf.SNNote {
+		src = custom
+	} else if isURLSource(filePath) {
+		src = remoteSource{url: filePath, conf: conf}
+	}
+	if src != nil {
+		content, err := src.Load()
+		if err != nil {
+			return nil, nil, err
+		}
+		sections, warnings, err := parseMarkdown(strings.NewReader(content), conf, "")
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse markdown content: %w", err)
+		}
+		return sections, warnings, nil
+	}
+	if conf.IndexCache {
+		return indexedSections(filePath, note, conf)
+	}
+	if filePath != "" {
+		f, err := appFS.Open(filePath) // #nosec G304
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read file %s: %w", filePath, err)
+		}
+		defer f.Close()
+
+		sections, warnings, err := parseMarkdown(f, conf, filePath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse markdown content: %w", err)
+		}
+		return sections, warnings, nil
+	}
+
+	noteConf := conf
+	noteConf.SNNote = note
+	content, err := loadNoteForSearch(noteConf)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse markdown content: %w", err)
+		return nil, nil, err
+	}
+	sections, warnings, err := parseMarkdown(strings.NewReader(content), conf, "")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse markdown content: %w", err)
+	}
+	return sections, warnings, nil
+}
+
+// sourceName names the library at filePath, or the Simplenote note when filePath is empty.
+func sourceName(filePath, note string) string {
+	if filePath != "" {
+		return redactURL(filePath)
+	}
+	return fmt.Sprintf("Simplenote note %q", note)
+}
+
+// setNamespace tags every section with namespace.
+func setNamespace(sections []Section, namespace string) {
+	for i := range sections {
+		sections[i].Namespace = namespace
 	}
-	// Gather the loaded sections into structured prompt data
-	return gatherPromptData(sections), nil
 }
 
 // loadFromFile reads prompts from a local markdown file.
+// CRLF line endings and a UTF-8 BOM are normalized away.
 // Returns the file content as a string or an error if reading fails.
 func loadFromFile(filepath string) (string, error) {
-	data, err := os.ReadFile(filepath) // #nosec G304
+	content, err := readLocalFile(filepath)
 	if err != nil {
 		return "", fmt.Errorf("failed to read file %s: %w", filepath, err)
 	}
-	return string(data), nil
+	return content, nil
 }
 
 // loadFromSimplenote fetches the note from Simplenote using the sncli command.
@@ -96,260 +294,164 @@ func loadFromFile(filepath string) (string, error) {
 // Returns the note content as a string or an error if fetching fails.
 func loadFromSimplenote(conf config.Config) (string, error) {
 	// First, ensure we're logged in to sncli
-	if err := ensureSimplenoteAuth(conf); err != nil {
+	env, err := ensureSimplenoteAuth(conf)
+	if err != nil {
 		return "", err
 	}
 
+	defer clear(env)
+
 	// Use sncli to get the note
-	cmd := exec.Command("sncli", "dump", conf.SNNote) // #nosec G204
+	cmd, scrub := sncliCommand(env, "dump", conf.SNNote)
 	output, err := cmd.Output()
+	scrub()
 	if err != nil {
-		return "", fmt.Errorf("failed to fetch note '%s' from Simplenote: %w", conf.SNNote, err)
+		return "", fmt.Errorf("failed to fetch note '%s' from Simplenote: %w", conf.SNNote, commandError(err))
 	}
 
-	return string(output), nil
+	return normalizeText(string(output)), nil
 }
 
 // ensureSimplenoteAuth ensures we're authenticated with Simplenote.
-// It supports both direct credentials and 1Password integration for credential management.
-// Returns an error if authentication setup fails.
-func ensureSimplenoteAuth(conf config.Config) error {
+// It supports both direct credentials and fetching them from a SecretProvider.
+// It returns the SN_USERNAME and SN_PASSWORD entries to pass to sncli via sncliCommand,
+// or nil if sncli is already authenticated. The credentials are never exported to this
+// process's environment, so they do not leak to other child processes such as clipboard
+// utilities or $EDITOR.
+// Returns an error wrapping ErrAuth if authentication setup fails.
+func ensureSimplenoteAuth(conf config.Config) ([]string, error) {
+	env, err := authenticateSimplenote(conf)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrAuth, err)
+	}
+	return env, nil
+}
+
+// authenticateSimplenote returns the sncli credential environment unless sncli is already authenticated.
+func authenticateSimplenote(conf config.Config) ([]string, error) {
 	// Check if already authenticated
-	cmd := exec.Command("sncli", "list", conf.SNNote) // #nosec G204
+	cmd := sandbox.Command("sncli", "list", conf.SNNote) // #nosec G204
 	if err := cmd.Run(); err == nil {
-		return nil // Already authenticated
+		return nil, nil // Already authenticated
 	}
 
 	var username, password string
 
 	// Authenticate using Simplenote credentials directly
-	if conf.SNUsername != "" && conf.SNPassword != "" && conf.SNCredential == "" {
+	if !conf.UsesSecretProvider() && conf.SNUsername != "" && conf.SNPassword != "" {
 		username = conf.SNUsername
 		password = conf.SNPassword
 	} else {
-		// Authenticate using 1Password via op CLI
-		if conf.SNCredential == "" {
-			return fmt.Errorf("SN_CREDENTIAL op item must be set in config for 1Password integration")
+		// Fetch the credentials from the configured secret store
+		provider, err := NewSecretProvider(conf)
+		if err != nil {
+			return nil, err
 		}
-		if conf.SNUsername == "" {
-			return fmt.Errorf("SN_USERNAME op item must be set in config for 1Password integration")
+		if conf.SNCredential == "" && conf.SecretProvider != SecretProviderEnv {
+			return nil, fmt.Errorf("SN_CREDENTIAL must name the item holding your Simplenote credentials")
 		}
-		if conf.SNPassword == "" {
-			return fmt.Errorf("SN_PASSWORD op item must be set in config for 1Password integration")
+		if conf.SNUsername == "" || conf.SNPassword == "" {
+			return nil, fmt.Errorf("SN_USERNAME and SN_PASSWORD must name the fields holding your Simplenote credentials")
 		}
 
-		// Fetch username from 1Password
-		opUserCmd := exec.Command("op", "item", "get", conf.SNCredential, "--field", conf.SNUsername) // #nosec G204
-		userOut, err := opUserCmd.Output()
-		if err != nil {
-			return fmt.Errorf("failed to fetch SN_USERNAME from 1Password: %w", err)
+		if username, err = provider.Lookup(conf.SNCredential, conf.SNUsername); err != nil {
+			return nil, fmt.Errorf("SN_USERNAME: %w", err)
 		}
-		username = strings.TrimSpace(string(userOut))
-
-		// Fetch password from 1Password
-		opPassCmd := exec.Command("op", "item", "get", conf.SNCredential, "--field", conf.SNPassword, "--reveal") // #nosec G204
-		passOut, err := opPassCmd.Output()
-		if err != nil {
-			return fmt.Errorf("failed to fetch SN_PASSWORD from 1Password: %w", err)
+		if password, err = provider.Lookup(conf.SNCredential, conf.SNPassword); err != nil {
+			return nil, fmt.Errorf("SN_PASSWORD: %w", err)
 		}
-		password = strings.TrimSpace(string(passOut))
+		redact.Add(password)
 	}
 
-	// Set SN_USERNAME and SN_PASSWORD as environment variables for sncli
-	// since sncli uses these for authentication rather than a login command
-	if err := os.Setenv("SN_USERNAME", username); err != nil {
-		return fmt.Errorf("failed to set SN_USERNAME env var: %w", err)
+	// sncli reads SN_USERNAME and SN_PASSWORD from its environment rather than
+	// offering a login command
+	return []string{"SN_USERNAME=" + username, "SN_PASSWORD=" + password}, nil
+}
+
+// sncliCommand returns an sncli command with args whose environment is this process's
+// plus the credential entries in env. Call scrub once the command has finished to clear
+// the credentials from the command's copy of the environment. env itself is left
+// intact so it can be passed to further commands; the caller clears it once the last
+// of them has finished.
+func sncliCommand(env []string, args ...string) (cmd *exec.Cmd, scrub func()) {
+	cmd = sandbox.Command("sncli", args...) // #nosec G204
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
 	}
-	if err := os.Setenv("SN_PASSWORD", password); err != nil {
-		return fmt.Errorf("failed to set SN_PASSWORD env var: %w", err)
+	return cmd, func() {
+		clear(cmd.Env)
+		cmd.Env = nil
 	}
-
-	return nil
 }
 
-// parseMarkdown parses the markdown file's content into sections grouped by any heading level
-func parseMarkdownIntoSections(content string) ([]Section, error) {
-
-	var sections []Section
-	var current Section
-	var headingStack []string
-
-	scanner := bufio.NewScanner(strings.NewReader(content))
-	for scanner.Scan() {
-		line := scanner.Text()
-		level, headingText := parseHeading(line)
-		if level > 0 {
-			// Update heading stack
-			if len(headingStack) < level {
-				// Deeper heading: extend stack
-				headingStack = append(headingStack, headingText)
-			} else {
-				// Replace heading at this level and truncate deeper levels
-				headingStack = append(headingStack[:level-1], headingText)
-			}
-
-			// Save previous section
-			if len(current.Lines) > 0 {
-				sections = append(sections, current)
-			}
-			// Start new section
-			current = Section{
-				Headings: append([]string(nil), headingStack...), // copy
-			}
-		} else {
-			current.Lines = append(current.Lines, line)
-		}
-	}
-	// Save last section
-	if len(current.Lines) > 0 {
-		sections = append(sections, current)
+// commandError adds the stderr output of a failed command to err, with any
+// secrets redacted, since an exit status alone rarely explains the failure.
+func commandError(err error) error {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+		err = fmt.Errorf("%w: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
 	}
+	return redact.Error(err)
+}
 
-	return sections, scanner.Err()
+// parseMarkdownIntoSections parses the markdown file's content into sections grouped by any heading level,
+// along with warnings about headings and content that keep prompts out of search.
+func parseMarkdownIntoSections(content string) ([]Section, []Warning, error) {
+	return search.ParseMarkdownDiagnose(strings.NewReader(content), search.ParseOptions{})
 }
 
-// parseHeading returns heading level and text, or (0, "") if not a heading
-func parseHeading(line string) (int, string) {
-	line = strings.TrimSpace(line)
-	if !strings.HasPrefix(line, "#") {
-		return 0, ""
-	}
-	level := 0
-	for i := 0; i < len(line) && line[i] == '#'; i++ {
-		level++
-	}
-	// Require at least one space after hashes
-	if len(line) > level && line[level] == ' ' {
-		return level, strings.TrimSpace(line[level:])
-	}
-	return 0, ""
+// parseMarkdown parses Markdown from r with the line size, line joining and list
+// grouping configured in conf, see search.ParseMarkdownDiagnose. sourceFile is the
+// local file r reads, recorded in the sections parsed, or empty for other sources.
+func parseMarkdown(r io.Reader, conf config.Config, sourceFile string) ([]Section, []Warning, error) {
+	return search.ParseMarkdownDiagnose(r, parseOptions(conf, sourceFile))
 }
 
-// gatherPromptData gathers the markdown content from []sections into structured prompt data.
-// Returns a PromptData structure containing all parsed prompts organized by sections.
-func gatherPromptData(sections []Section) *PromptData {
-	return &PromptData{
-		Sections: sections,
+// parseOptions returns the parse options configured in conf, see parseMarkdown.
+func parseOptions(conf config.Config, sourceFile string) search.ParseOptions {
+	return search.ParseOptions{
+		MaxLineSize:      conf.MaxLineSize,
+		JoinWrappedLines: conf.JoinWrappedLines,
+		GroupListItems:   conf.GroupListItems,
+		SourceFile:       sourceFile,
 	}
 }
 
-// Helper: match full section path (nested headings)
-func searchPoolBySectionPath(data *PromptData, sectionPath []string) []Prompt {
-	var searchPool []Prompt
-	for _, sec := range data.Sections {
-		// Always skip the first heading (Markdown file title)
-		if len(sec.Headings) < 2 {
-			continue
-		}
-		// Compare sectionPath to sec.Headings[1:]
-		if len(sec.Headings)-1 == len(sectionPath) {
-			match := true
-			for i := range sectionPath {
-				if sec.Headings[i+1] != sectionPath[i] {
-					match = false
-					break
-				}
-			}
-			if match {
-				for _, line := range sec.Lines {
-					if strings.TrimSpace(line) != "" {
-						searchPool = append(searchPool, Prompt{
-							Content: line,
-							Section: sec.Headings[len(sec.Headings)-1],
-						})
-					}
-				}
-			}
-		}
+// searchOptions returns the search options configured in conf, see search.SearchOptions.
+func searchOptions(conf config.Config) search.SearchOptions {
+	stopWords := conf.StopWords
+	switch {
+	case len(stopWords) == 0:
+		stopWords = search.DefaultStopWords
+	case len(stopWords) == 1 && strings.EqualFold(strings.TrimSpace(stopWords[0]), "none"):
+		stopWords = nil
 	}
-	return searchPool
+	return search.SearchOptions{Stem: conf.Stemming, StopWords: stopWords}
 }
 
-// Helper: match single section name (lowest-level heading)
-func searchPoolBySingleSection(data *PromptData, section string) []Prompt {
-	var searchPool []Prompt
-	for _, sec := range data.Sections {
-		if len(sec.Headings) > 0 && sec.Headings[len(sec.Headings)-1] == section {
-			for _, line := range sec.Lines {
-				if strings.TrimSpace(line) != "" {
-					searchPool = append(searchPool, Prompt{
-						Content: line,
-						Section: section,
-					})
-				}
-			}
-		}
-	}
-	return searchPool
+// parseHeading returns heading level and text, or (0, "") if not a heading
+func parseHeading(line string) (int, string) {
+	return search.ParseHeading(line)
 }
 
-// Helper: match single section name (higher-level heading)
-func searchPoolByParentSection(data *PromptData, section string) []Prompt {
-	var searchPool []Prompt
-	for _, sec := range data.Sections {
-		if len(sec.Headings) > 1 {
-			for i, heading := range sec.Headings[:len(sec.Headings)-1] {
-				if heading == section {
-					for _, line := range sec.Lines {
-						if strings.TrimSpace(line) != "" {
-							searchPool = append(searchPool, Prompt{
-								Content: line,
-								Section: sec.Headings[len(sec.Headings)-1],
-							})
-						}
-					}
-					break
-				}
-				if i == len(sec.Headings)-2 {
-					break
-				}
-			}
-		}
-	}
-	return searchPool
+// headingName returns heading text without its emoji marker, the name sections are
+// searched and written by, see search.SplitHeadingIcon.
+func headingName(text string) string {
+	name, _ := search.SplitHeadingIcon(strings.TrimSpace(text))
+	return name
 }
 
-// Helper: all prompts (no section specified)
-func searchPoolAllPrompts(data *PromptData) []Prompt {
-	var searchPool []Prompt
-	for _, sec := range data.Sections {
-		if len(sec.Headings) > 0 {
-			sectionTitle := sec.Headings[len(sec.Headings)-1]
-			for _, line := range sec.Lines {
-				if strings.TrimSpace(line) != "" {
-					searchPool = append(searchPool, Prompt{
-						Content: line,
-						Section: sectionTitle,
-					})
-				}
-			}
-		}
+// gatherPromptData gathers the markdown content from []sections into structured prompt data.
+// Returns a PromptData structure containing all parsed prompts organized by sections.
+func gatherPromptData(sections []Section) *PromptData {
+	return &PromptData{
+		Sections: sections,
 	}
-	return searchPool
 }
 
 // generateSearchPool creates a slice of Prompt structs for each line in the relevant sections.
-// Returns a slice of Prompt structs containing the content and section for each line.
 func generateSearchPool(data *PromptData, section string) []Prompt {
-	if section == "" {
-		// No section specified: return all prompts
-		return searchPoolAllPrompts(data)
-	}
-	sectionPath := strings.Split(section, ",")
-	for i := range sectionPath {
-		sectionPath[i] = strings.TrimSpace(sectionPath[i])
-	}
-	if len(sectionPath) > 1 {
-		// Comma-separated: treat as nested headings
-		return searchPoolBySectionPath(data, sectionPath)
-	}
-	// Single section name: try lowest-level heading match first
-	pool := searchPoolBySingleSection(data, sectionPath[0])
-	if len(pool) > 0 {
-		return pool
-	}
-	// If not found, try parent section match
-	return searchPoolByParentSection(data, sectionPath[0])
+	return search.Pool(data, section)
 }
 
 // SearchPrompts performs fuzzy search on prompts using the provided query.
@@ -357,80 +459,70 @@ func generateSearchPool(data *PromptData, section string) []Prompt {
 // If the query is empty, it returns all prompts (or all prompts in the specified section).
 // Returns a slice of prompt content strings matching the search criteria.
 func SearchPrompts(data *PromptData, query, section string) []string {
-	searchPool := generateSearchPool(data, section)
-	if len(searchPool) == 0 {
-		return []string{}
-	}
-
-	if query == "" {
-		results := make([]string, len(searchPool))
-		for i, p := range searchPool {
-			results[i] = p.Content
-		}
-		return results
-	}
-
-	// Split query into individual words for better matching
-	queryWords := strings.Fields(strings.ToLower(query))
-	if len(queryWords) == 0 {
-		return []string{}
+	matches := SearchPromptRecords(data, query, section)
+	results := make([]string, len(matches))
+	for i, p := range matches {
+		results[i] = p.Content
 	}
+	return results
+}
 
-	type MatchResult struct {
-		Content string
-		Score   int // Lower is better (total distance across all words)
-		Index   int
-	}
+// SearchPromptRecords performs the same search as SearchPrompts but returns
+// the matching Prompt structs, preserving the section each match belongs to.
+func SearchPromptRecords(data *PromptData, query, section string) []Prompt {
+	return search.Records(data, query, section)
+}
 
-	var matches []MatchResult
+// SearchPromptTitles searches like SearchPromptRecords but matches the query only against
+// each prompt's title and section headings, ignoring the prompt body.
+func SearchPromptTitles(data *PromptData, query, section string) []Prompt {
+	return search.Titles(data, query, section)
+}
 
-	// For each prompt in the search pool
-	for i, prompt := range searchPool {
-		totalDistance := 0
-		matchedWords := 0
-		content := strings.ToLower(prompt.Content)
+// SearchPromptMatches searches like SearchPromptRecords but also returns the score of
+// each match, lower being better.
+func SearchPromptMatches(data *PromptData, query, section string) []Match {
+	return search.RecordMatches(data, query, section)
+}
 
-		// Check if all query words have reasonable matches in this prompt
-		for _, word := range queryWords {
-			// First try exact word match
-			if strings.Contains(content, word) {
-				matchedWords++
-				// Give exact matches a very low distance (high priority)
-				totalDistance += 1
-				continue
-			}
+// SearchPromptTitleMatches searches like SearchPromptTitles but also returns the score
+// of each match, lower being better.
+func SearchPromptTitleMatches(data *PromptData, query, section string) []Match {
+	return search.TitleMatches(data, query, section)
+}
 
-			// If no exact match, try fuzzy match on individual word
-			wordMatches := fuzzy.RankFindNormalizedFold(word, []string{content})
-			if len(wordMatches) > 0 && wordMatches[0].Distance < 100 { // reasonable fuzzy match threshold
-				matchedWords++
-				totalDistance += wordMatches[0].Distance
-			}
-		}
+// QueryHasSection reports whether query picks the sections to search with the
+// section: operator, see search.HasSectionOperator.
+func QueryHasSection(query string) bool {
+	return search.HasSectionOperator(query)
+}
 
-		// Only include this prompt if ALL query words were found
-		if matchedWords == len(queryWords) {
-			matches = append(matches, MatchResult{
-				Content: prompt.Content,
-				Score:   totalDistance,
-				Index:   i,
-			})
-		}
+// FilterPrompts searches pool, a subset of the prompts of data, like
+// SearchPromptRecords, or like SearchPromptTitles when titlesOnly is set, using the
+// search options of data.
+func FilterPrompts(data *PromptData, pool []Prompt, query string, titlesOnly bool) []Prompt {
+	var opts search.SearchOptions
+	if data != nil {
+		opts = data.Options
 	}
-
-	// Sort matches by score (lower is better)
-	sort.Slice(matches, func(i, j int) bool {
-		return matches[i].Score < matches[j].Score
-	})
-
-	// Extract just the content
-	results := make([]string, len(matches))
+	matches := search.Rank(pool, query, opts, titlesOnly)
+	results := make([]Prompt, len(matches))
 	for i, match := range matches {
-		results[i] = match.Content
+		results[i] = match.Prompt
 	}
 	return results
 }
 
+// maxSuggestions is how many near-miss headings DidYouMean suggests.
+const maxSuggestions = 3
+
+// DidYouMean returns a hint such as "Did you mean: 'Code Review Checklist' in
+// section Golang?" naming the headings of any section closest to a query that found
+// nothing, or "" when none is close; see search.Suggest.
+func DidYouMean(data *PromptData, query string) string {
+	return search.DidYouMean(search.Suggest(data, query, maxSuggestions))
+}
+
 // FindAllMatches returns all fuzzy search results for the given query and section.
 // It is a convenience wrapper for SearchPrompts, returning all matches.
 func FindAllMatches(data *PromptData, query, section string) []string {
@@ -448,6 +540,16 @@ func FindBestMatch(data *PromptData, query, section string) string {
 	return results[0]
 }
 
+// FindBestPrompt returns the best fuzzy match for the given query as a Prompt,
+// including the section it was found in. The boolean result is false if nothing matched.
+func FindBestPrompt(data *PromptData, query, section string) (Prompt, bool) {
+	results := SearchPromptRecords(data, query, section)
+	if len(results) == 0 {
+		return Prompt{}, false
+	}
+	return results[0], true
+}
+
 // GetSectionPrompts returns all prompts from a specific section.
 // If the section doesn't exist, it returns an empty slice.
 // Returns a slice of prompt content strings from the specified section.
@@ -465,23 +567,37 @@ func GetSectionPrompts(data *PromptData, section string) []string {
 // - macOS: pbcopy
 // - Linux: xclip or xsel
 // - Windows: clip
-// Returns an error if the clipboard operation fails or if no suitable utility is found.
+// With NO_EXEC, the terminal is asked to set the clipboard instead, or text is
+// written to stdout when there is no terminal.
+// Returns an error wrapping ErrClipboard if the clipboard operation fails or if no suitable utility is found.
 func CopyToClipboard(text string) error {
+	if err := copyToClipboard(text); err != nil {
+		return fmt.Errorf("%w: %w", ErrClipboard, err)
+	}
+	return nil
+}
+
+// copyToClipboard pipes text into the platform clipboard utility, or copies it
+// without one while external programs are disabled, see copyWithoutExec.
+func copyToClipboard(text string) error {
+	if sandbox.Disabled() {
+		return copyWithoutExec(text)
+	}
 	var cmd *exec.Cmd
 
 	switch runtime.GOOS {
 	case "darwin":
-		cmd = exec.Command("pbcopy")
+		cmd = sandbox.Command("pbcopy")
 	case "linux":
-		if _, err := exec.LookPath("xclip"); err == nil {
-			cmd = exec.Command("xclip", "-selection", "clipboard")
-		} else if _, err := exec.LookPath("xsel"); err == nil {
-			cmd = exec.Command("xsel", "--clipboard", "--input")
+		if _, err := sandbox.LookPath("xclip"); err == nil {
+			cmd = sandbox.Command("xclip", "-selection", "clipboard")
+		} else if _, err := sandbox.LookPath("xsel"); err == nil {
+			cmd = sandbox.Command("xsel", "--clipboard", "--input")
 		} else {
 			return fmt.Errorf("no clipboard utility found (xclip or xsel required)")
 		}
 	case "windows":
-		cmd = exec.Command("clip")
+		cmd = sandbox.Command("clip")
 	default:
 		return fmt.Errorf("unsupported operating system: %s", runtime.GOOS)
 	}
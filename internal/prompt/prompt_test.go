@@ -1,11 +1,19 @@
 package prompt
 
 import (
+	"errors"
 	"os"
+	"path/filepath"
+	"reflect"
 	"runtime"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/spf13/afero"
+
+	"github.com/toozej/wheresmyprompt/internal/search"
 	"github.com/toozej/wheresmyprompt/pkg/config"
 )
 
@@ -41,7 +49,7 @@ Create documentation that includes:
 `
 
 func newPromptDataFromContent(content string) *PromptData {
-	sections, err := parseMarkdownIntoSections(content)
+	sections, _, err := parseMarkdownIntoSections(content)
 	if err != nil {
 		panic(err)
 	}
@@ -49,7 +57,7 @@ func newPromptDataFromContent(content string) *PromptData {
 }
 
 func TestParseMarkdownIntoSections(t *testing.T) {
-	sections, err := parseMarkdownIntoSections(testMarkdownContent)
+	sections, _, err := parseMarkdownIntoSections(testMarkdownContent)
 	if err != nil {
 		t.Fatalf("Failed to parse markdown: %v", err)
 	}
@@ -71,6 +79,137 @@ func TestParseMarkdownIntoSections(t *testing.T) {
 	}
 }
 
+func TestParseMarkdown_LongLines(t *testing.T) {
+	long := strings.Repeat("a", 200*1024)
+
+	tests := []struct {
+		name        string
+		content     string
+		maxLineSize int
+		expectError bool
+	}{
+		{name: "line longer than 64KB", content: "## Long\n### Title\n" + long + "\n", maxLineSize: 0},
+		{name: "line longer than 64KB without trailing newline", content: "## Long\n" + long, maxLineSize: 0},
+		{name: "line exactly at limit", content: "## Long\n" + long, maxLineSize: len(long)},
+		{name: "line over limit", content: "## Long\n" + long, maxLineSize: 1024, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sections, _, err := parseMarkdown(strings.NewReader(tt.content), config.Config{MaxLineSize: tt.maxLineSize}, "")
+			if tt.expectError {
+				if !errors.Is(err, ErrLineTooLong) {
+					t.Errorf("expected ErrLineTooLong, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseMarkdown() returned error: %v", err)
+			}
+			found := false
+			for _, sec := range sections {
+				for _, line := range sec.Lines {
+					if line == long {
+						found = true
+					}
+				}
+			}
+			if !found {
+				t.Error("expected long line to be parsed intact")
+			}
+		})
+	}
+}
+
+func TestLoadPrompts_LongLinePrompt(t *testing.T) {
+	long := strings.Repeat("Explain this code in detail. ", 5000)
+	path := filepath.Join(t.TempDir(), "prompts.md")
+	if err := os.WriteFile(path, []byte("## Golang\n### Explain\n"+long+"\n"), 0600); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	data, err := LoadPrompts(config.Config{FilePath: path})
+	if err != nil {
+		t.Fatalf("LoadPrompts() returned error: %v", err)
+	}
+	results := SearchPromptRecords(data, "", "Golang")
+	if len(results) != 1 || len(results[0].Content) < 64*1024 {
+		t.Errorf("expected one prompt longer than 64KB, got %d results", len(results))
+	}
+
+	if _, err := LoadPrompts(config.Config{FilePath: path, MaxLineSize: 1024}); !errors.Is(err, ErrLineTooLong) {
+		t.Errorf("expected ErrLineTooLong with small MaxLineSize, got %v", err)
+	}
+}
+
+func TestLoadPrompts_SourceInfo(t *testing.T) {
+	fs := useMemFS(t)
+	modified := time.Date(2026, 3, 14, 9, 26, 0, 0, time.UTC)
+	for _, path := range []string{"/lib/prompts.md", "/lib/team.md"} {
+		if err := afero.WriteFile(fs, path, []byte("# Prompts\n## Golang\n### Review\nReview this code\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := fs.Chtimes("/lib/prompts.md", modified, modified); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		config config.Config
+		source string
+	}{
+		{name: "file", config: config.Config{FilePath: "/lib/prompts.md"}, source: "prompts.md"},
+		{name: "with team library", config: config.Config{FilePath: "/lib/prompts.md", TeamFilePath: "/lib/team.md"}, source: "prompts.md + team.md"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := LoadPrompts(tt.config)
+			if err != nil {
+				t.Fatalf("LoadPrompts() returned error: %v", err)
+			}
+			if data.Source != tt.source {
+				t.Errorf("Source = %q, want %q", data.Source, tt.source)
+			}
+			if !data.Modified.Equal(modified) {
+				t.Errorf("Modified = %v, want %v", data.Modified, modified)
+			}
+		})
+	}
+}
+
+func TestSearchPromptTitles(t *testing.T) {
+	data := newPromptDataFromContent(testMarkdownContent)
+
+	tests := []struct {
+		name     string
+		query    string
+		section  string
+		expected []string // Sections of the expected results
+	}{
+		{name: "prompt title", query: "email", expected: []string{"Email Template", "Email Template", "Email Template", "Email Template", "Email Template"}},
+		{name: "section heading", query: "writing", expected: []string{"Email Template", "Email Template", "Email Template", "Email Template", "Email Template", "Documentation", "Documentation", "Documentation", "Documentation"}},
+		{name: "section and title", query: "review checklist", expected: []string{"Code Review Checklist", "Code Review Checklist", "Code Review Checklist", "Code Review Checklist"}},
+		{name: "body text is ignored", query: "vulnerabilities", expected: []string{}},
+		{name: "restricted to section", query: "analysis", section: "Writing", expected: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := SearchPromptTitles(data, tt.query, tt.section)
+			if len(results) != len(tt.expected) {
+				t.Fatalf("SearchPromptTitles(%q) returned %d results, want %d", tt.query, len(results), len(tt.expected))
+			}
+			for i, r := range results {
+				if r.Section != tt.expected[i] {
+					t.Errorf("result %d section = %q, want %q", i, r.Section, tt.expected[i])
+				}
+			}
+		})
+	}
+}
+
 func TestSearchPrompts(t *testing.T) {
 	data := newPromptDataFromContent(testMarkdownContent)
 
@@ -401,6 +540,43 @@ func TestLoadPrompts(t *testing.T) {
 }
 
 // Test the Prompt struct
+func TestLoadPromptsWithTeamLibrary(t *testing.T) {
+	dir := t.TempDir()
+	personal := dir + "/mine.md"
+	team := dir + "/team.md"
+	_ = os.WriteFile(personal, []byte("# Mine\n\n## Golang\nMy prompt\n"), 0600)
+	_ = os.WriteFile(team, []byte("# Team\n\n## Golang\nTeam prompt\n"), 0600)
+
+	data, err := LoadPrompts(config.Config{FilePath: personal, TeamFilePath: team})
+	if err != nil {
+		t.Fatalf("LoadPrompts() returned error: %v", err)
+	}
+
+	results := SearchPromptRecords(data, "", "Golang")
+	if len(results) != 2 {
+		t.Fatalf("expected prompts from both libraries, got %d", len(results))
+	}
+	namespaces := map[string]string{}
+	for _, p := range results {
+		namespaces[p.Content] = p.Namespace
+	}
+	if namespaces["My prompt"] != NamespacePersonal || namespaces["Team prompt"] != NamespaceTeam {
+		t.Errorf("unexpected namespaces: %v", namespaces)
+	}
+
+	data, err = LoadPrompts(config.Config{FilePath: personal})
+	if err != nil {
+		t.Fatalf("LoadPrompts() returned error: %v", err)
+	}
+	if results := SearchPromptRecords(data, "", ""); len(results) != 1 || results[0].Namespace != "" {
+		t.Errorf("expected a single library without namespace, got %+v", results)
+	}
+
+	if _, err := LoadPrompts(config.Config{FilePath: personal, TeamFilePath: dir + "/missing.md"}); err == nil {
+		t.Error("expected error for missing team library")
+	}
+}
+
 func TestPromptStruct(t *testing.T) {
 	prompt := Prompt{
 		Content: "This is test content",
@@ -432,7 +608,7 @@ func TestPromptDataStruct(t *testing.T) {
 // Benchmark tests
 func BenchmarkParseMarkdown(b *testing.B) {
 	for i := 0; i < b.N; i++ {
-		_, err := parseMarkdownIntoSections(testMarkdownContent)
+		_, _, err := parseMarkdownIntoSections(testMarkdownContent)
 		if err != nil {
 			b.Fatalf("Failed to parse markdown: %v", err)
 		}
@@ -440,7 +616,7 @@ func BenchmarkParseMarkdown(b *testing.B) {
 }
 
 func BenchmarkSearchPrompts(b *testing.B) {
-	sections, err := parseMarkdownIntoSections(testMarkdownContent)
+	sections, _, err := parseMarkdownIntoSections(testMarkdownContent)
 	if err != nil {
 		b.Fatalf("Failed to parse markdown: %v", err)
 	}
@@ -455,7 +631,7 @@ func BenchmarkSearchPrompts(b *testing.B) {
 // Test parsing markdown content
 func TestParseMarkdownWithDebug(t *testing.T) {
 	// Just ensure it doesn't panic
-	sections, err := parseMarkdownIntoSections(testMarkdownContent)
+	sections, _, err := parseMarkdownIntoSections(testMarkdownContent)
 	if err != nil {
 		t.Fatalf("Failed to parse markdown: %v", err)
 	}
@@ -491,7 +667,7 @@ func TestParseMarkdownEdgeCases(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			sections, err := parseMarkdownIntoSections(tt.content)
+			sections, _, err := parseMarkdownIntoSections(tt.content)
 			if err != nil {
 				t.Fatalf("Failed to parse markdown: %v", err)
 			}
@@ -502,3 +678,57 @@ func TestParseMarkdownEdgeCases(t *testing.T) {
 		})
 	}
 }
+
+func TestSncliCommand_CredentialsNotExported(t *testing.T) {
+	t.Setenv("SN_PASSWORD", "")
+	env := []string{"SN_USERNAME=user@example.com", "SN_PASSWORD=secret-pass"}
+
+	cmd, scrub := sncliCommand(env, "dump", "note")
+	if !slices.Contains(cmd.Env, "SN_PASSWORD=secret-pass") {
+		t.Error("expected sncli command environment to carry SN_PASSWORD")
+	}
+	if got := os.Getenv("SN_PASSWORD"); got != "" {
+		t.Errorf("expected SN_PASSWORD not to be set in the process environment, got %q", got)
+	}
+	if got := cmd.Args; !slices.Equal(got, []string{"sncli", "dump", "note"}) {
+		t.Errorf("unexpected sncli args: %v", got)
+	}
+
+	cmdEnv := cmd.Env
+	scrub()
+	if cmd.Env != nil {
+		t.Error("expected scrub to remove the command environment")
+	}
+	for _, e := range cmdEnv {
+		if e != "" {
+			t.Errorf("expected scrub to clear the command's environment entry, got %q", e)
+		}
+	}
+	if env[1] != "SN_PASSWORD=secret-pass" {
+		t.Errorf("expected scrub to leave the caller's credentials for further commands, got %q", env[1])
+	}
+
+	cmd, _ = sncliCommand(nil, "list")
+	if cmd.Env != nil {
+		t.Error("expected inherited environment when no credentials are needed")
+	}
+}
+
+func TestSearchOptions(t *testing.T) {
+	tests := []struct {
+		name      string
+		conf      config.Config
+		stopWords []string
+	}{
+		{"default stop words", config.Config{}, search.DefaultStopWords},
+		{"custom stop words", config.Config{StopWords: []string{"please", "kindly"}}, []string{"please", "kindly"}},
+		{"none", config.Config{StopWords: []string{"none"}}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := searchOptions(tt.conf).StopWords; !reflect.DeepEqual(got, tt.stopWords) {
+				t.Errorf("searchOptions().StopWords = %v, want %v", got, tt.stopWords)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package prompt
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrRefCycle is returned by ResolveRefs when prompts reference each other in a loop.
+var ErrRefCycle = errors.New("prompt references form a cycle")
+
+// ErrRefNotFound is returned by ResolveRefs when no section matches a reference.
+var ErrRefNotFound = errors.New("referenced prompt not found")
+
+// refPattern matches a reference to another prompt, such as "@ref(Persona)".
+var refPattern = regexp.MustCompile(`@ref\(([^()]+)\)`)
+
+// ResolveRefs replaces every "@ref(Title)" in content with the prompts of the section
+// titled Title, so a base prompt such as a persona can be reused across many task
+// prompts. Title is a heading, or the end of a heading path such as
+// "Golang > Persona" when the heading alone is ambiguous; it is matched ignoring
+// case and the first matching section wins. References inside the inlined prompts
+// are resolved as well. Returns ErrRefNotFound for an unknown title and ErrRefCycle
+// when prompts reference each other in a loop.
+func ResolveRefs(data *PromptData, content string) (string, error) {
+	return resolveRefs(data, content, nil)
+}
+
+// resolveRefs resolves the references in content, where chain holds the titles
+// being resolved by the callers.
+func resolveRefs(data *PromptData, content string, chain []string) (string, error) {
+	var resolveErr error
+	resolved := refPattern.ReplaceAllStringFunc(content, func(ref string) string {
+		if resolveErr != nil {
+			return ref
+		}
+		title := strings.TrimSpace(refPattern.FindStringSubmatch(ref)[1])
+		for _, seen := range chain {
+			if strings.EqualFold(seen, title) {
+				resolveErr = fmt.Errorf("%w: %s -> %s", ErrRefCycle, strings.Join(chain, " -> "), title)
+				return ref
+			}
+		}
+		body, ok := refContent(data, title)
+		if !ok {
+			resolveErr = fmt.Errorf("%w: @ref(%s)", ErrRefNotFound, title)
+			return ref
+		}
+		body, resolveErr = resolveRefs(data, body, append(chain[:len(chain):len(chain)], title))
+		return body
+	})
+	if resolveErr != nil {
+		return "", resolveErr
+	}
+	return resolved, nil
+}
+
+// refContent returns the prompts of the first section whose headings end with the
+// " > " separated path title, joined by newlines.
+func refContent(data *PromptData, title string) (string, bool) {
+	path := strings.Split(title, ">")
+	for i := range path {
+		path[i] = strings.TrimSpace(path[i])
+	}
+	for _, sec := range data.Sections {
+		if len(sec.Headings) < len(path) {
+			continue
+		}
+		tail := sec.Headings[len(sec.Headings)-len(path):]
+		match := true
+		for i := range path {
+			if !strings.EqualFold(tail[i], path[i]) {
+				match = false
+				break
+			}
+		}
+		if !match {
+			continue
+		}
+		var lines []string
+		for _, line := range sec.Lines {
+			if strings.TrimSpace(line) != "" {
+				lines = append(lines, line)
+			}
+		}
+		return strings.Join(lines, "\n"), true
+	}
+	return "", false
+}
@@ -0,0 +1,43 @@
+package prompt
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestResolveRefs(t *testing.T) {
+	data := &PromptData{Sections: []Section{
+		{Headings: []string{"Prompts", "Personas", "Reviewer"}, Lines: []string{"You are a senior Go reviewer.", ""}},
+		{Headings: []string{"Prompts", "Golang", "Persona"}, Lines: []string{"You write idiomatic Go.", "@ref(Reviewer)"}},
+		{Headings: []string{"Prompts", "Python", "Persona"}, Lines: []string{"You write idiomatic Python."}},
+		{Headings: []string{"Prompts", "Loops", "A"}, Lines: []string{"@ref(B)"}},
+		{Headings: []string{"Prompts", "Loops", "B"}, Lines: []string{"@ref(a)"}},
+	}}
+
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+		err      error
+	}{
+		{name: "no references", content: "Review this code", expected: "Review this code"},
+		{name: "by heading", content: "@ref(Reviewer)\nReview this code", expected: "You are a senior Go reviewer.\nReview this code"},
+		{name: "case-insensitive", content: "@ref( reviewer ) Go on", expected: "You are a senior Go reviewer. Go on"},
+		{name: "first match wins", content: "@ref(Persona)", expected: "You write idiomatic Go.\nYou are a senior Go reviewer."},
+		{name: "by heading path", content: "@ref(Python > Persona)", expected: "You write idiomatic Python."},
+		{name: "unknown title", content: "@ref(Nope)", err: ErrRefNotFound},
+		{name: "cycle", content: "@ref(A)", err: ErrRefCycle},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveRefs(data, tt.content)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("ResolveRefs() error = %v, want %v", err, tt.err)
+			}
+			if got != tt.expected {
+				t.Errorf("ResolveRefs() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package prompt
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+
+	"github.com/toozej/wheresmyprompt/internal/httpclient"
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+// ErrRemoteChanged is returned when writing back to a remote source that changed
+// since it was read, detected by its ETag.
+var ErrRemoteChanged = errors.New("remote prompt source changed since it was read")
+
+// remoteCache is the last copy of a remote source read or written, kept in the data
+// directory with its ETag.
+type remoteCache struct {
+	ETag    string `json:"etag"`
+	Content string `json:"content"`
+
+	modified time.Time // When the cache file was last written
+}
+
+// remoteSource is a Source served by object storage: an s3://bucket/key object or
+// an http(s) URL, such as a pre-signed URL, given as FILEPATH. Reads are conditional
+// on the ETag of the cached copy, which is also used when the source cannot be
+// reached or OFFLINE is set. With REMOTE_WRITE, writes replace the object only if its ETag is still
+// the one last read, failing with ErrRemoteChanged otherwise.
+type remoteSource struct {
+	url  string
+	conf config.Config
+}
+
+func (s remoteSource) Name() string {
+	return redactURL(s.url)
+}
+
+func (s remoteSource) Check() error {
+	return nil
+}
+
+func (s remoteSource) Load() (string, error) {
+	cached, cacheErr := s.readCache()
+	if isOffline(s.conf) {
+		if cacheErr != nil {
+			return "", fmt.Errorf("%w: no cached copy of %s (%s)", ErrOffline, s.Name(), offlineReason(s.conf))
+		}
+		warnCached(s.Name(), cached.modified, offlineReason(s.conf))
+		return cached.Content, nil
+	}
+	header := http.Header{}
+	if cacheErr == nil && cached.ETag != "" {
+		header.Set("If-None-Match", cached.ETag)
+	}
+
+	resp, err := s.do(http.MethodGet, nil, header)
+	if err == nil {
+		defer resp.Body.Close()
+	}
+	switch {
+	case err == nil && resp.StatusCode == http.StatusNotModified && cacheErr == nil:
+		return cached.Content, nil
+	case err == nil && resp.StatusCode == http.StatusOK:
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", s.Name(), err)
+		}
+		content := normalizeText(string(body))
+		s.writeCache(remoteCache{ETag: resp.Header.Get("ETag"), Content: content})
+		return content, nil
+	case err == nil && resp.StatusCode == http.StatusNotFound:
+		return "", fmt.Errorf("failed to read %s: %w", s.Name(), os.ErrNotExist)
+	case err == nil:
+		err = fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	if cacheErr != nil {
+		return "", fmt.Errorf("failed to read %s: %w", s.Name(), err)
+	}
+	warnCached(s.Name(), cached.modified, err)
+	return cached.Content, nil
+}
+
+func (s remoteSource) Save(content string) error {
+	header := http.Header{}
+	if cached, err := s.readCache(); err == nil && cached.ETag != "" {
+		header.Set("If-Match", cached.ETag)
+	} else {
+		header.Set("If-None-Match", "*")
+	}
+
+	resp, err := s.do(http.MethodPut, []byte(content), header)
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", s.Name(), err)
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusPreconditionFailed || resp.StatusCode == http.StatusConflict:
+		return fmt.Errorf("%w: %s; try again", ErrRemoteChanged, s.Name())
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return fmt.Errorf("failed to write %s: unexpected status %s", s.Name(), resp.Status)
+	}
+	s.writeCache(remoteCache{ETag: resp.Header.Get("ETag"), Content: content})
+	return nil
+}
+
+// do sends a request for the object with body and header, signing it for S3.
+func (s remoteSource) do(method string, body []byte, header http.Header) (*http.Response, error) {
+	var req *http.Request
+	var err error
+	if strings.HasPrefix(strings.ToLower(s.url), "s3://") {
+		req, err = newS3Request(method, s.url, body, header)
+	} else {
+		req, err = http.NewRequest(method, s.url, bytes.NewReader(body))
+		if err == nil {
+			for name, values := range header {
+				req.Header[name] = values
+			}
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	resp, err := httpclient.Client().Do(req)
+	if err != nil {
+		noteNetworkError(err)
+		// Errors include the URL, which may carry a signature in its query
+		return nil, errors.New(strings.ReplaceAll(err.Error(), s.url, s.Name()))
+	}
+	return resp, nil
+}
+
+// cachePath returns where the copy of the source is cached, named after its URL
+// without the query, which changes with every pre-signed URL.
+func (s remoteSource) cachePath() (string, error) {
+	dir, err := config.ResolveDataDir(s.conf)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(redactURL(s.url)))
+	return filepath.Join(dir, "remote-"+hex.EncodeToString(sum[:8])+".json"), nil
+}
+
+// readCache returns the cached copy of the source.
+func (s remoteSource) readCache() (remoteCache, error) {
+	var cached remoteCache
+	path, err := s.cachePath()
+	if err != nil {
+		return cached, err
+	}
+	info, err := appFS.Stat(path)
+	if err != nil {
+		return cached, err
+	}
+	data, err := afero.ReadFile(appFS, path)
+	if err != nil {
+		return cached, err
+	}
+	err = json.Unmarshal(data, &cached)
+	cached.modified = info.ModTime()
+	return cached, err
+}
+
+// writeCache stores cached as the copy of the source. Failures are only logged,
+// the cache being an optimization.
+func (s remoteSource) writeCache(cached remoteCache) {
+	path, err := s.cachePath()
+	if err == nil {
+		err = appFS.MkdirAll(filepath.Dir(path), 0700)
+	}
+	if err == nil {
+		data, _ := json.Marshal(cached)
+		err = afero.WriteFile(appFS, path, data, 0600)
+	}
+	if err != nil {
+		log.Warnf("Failed to cache %s: %v", s.Name(), err)
+	}
+}
+
+// redactURL returns rawURL without its query, which holds the signature of
+// pre-signed URLs. Local paths are returned unchanged.
+func redactURL(rawURL string) string {
+	if !isURLSource(rawURL) {
+		return rawURL
+	}
+	base, _, _ := strings.Cut(rawURL, "?")
+	return base
+}
@@ -0,0 +1,119 @@
+package prompt
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+// routeTarget returns the WRITE_ROUTES target for section, matched ignoring case.
+// The boolean result is false if section is not routed.
+func routeTarget(conf config.Config, section string) (string, bool) {
+	for name, target := range conf.WriteRoutes {
+		if section != "" && strings.EqualFold(name, section) {
+			return target, true
+		}
+	}
+	return "", false
+}
+
+// routedConfig returns conf with its prompt source replaced by the WRITE_ROUTES
+// target for section, so a write to section lands there. Targets are files when
+// FILEPATH is set, relative paths being relative to its directory, and Simplenote
+// notes otherwise. conf is returned unchanged when section is not routed.
+func routedConfig(conf config.Config, section string) config.Config {
+	target, ok := routeTarget(conf, section)
+	if !ok || isCustomSource(conf) || isURLSource(conf.FilePath) {
+		return conf
+	}
+	if conf.FilePath != "" {
+		conf.FilePath = routeFilePath(conf, target)
+	} else {
+		conf.SNNote = target
+	}
+	return conf
+}
+
+// routeFilePath resolves a WRITE_ROUTES target against the FILEPATH directory.
+func routeFilePath(conf config.Config, target string) string {
+	if filepath.IsAbs(target) {
+		return target
+	}
+	return filepath.Join(filepath.Dir(conf.FilePath), target)
+}
+
+// routeFileTitle starts a routed file created by its first write, so the section
+// added below it is not taken for the document title.
+const routeFileTitle = "# Prompts\n"
+
+// createRouteFile creates the routed file at path with routeFileTitle if it does
+// not exist yet.
+func createRouteFile(path string) error {
+	f, err := appFS.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if errors.Is(err, os.ErrExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create write route %s: %w", path, err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(routeFileTitle); err != nil {
+		return fmt.Errorf("failed to create write route %s: %w", path, err)
+	}
+	return nil
+}
+
+// loadRouteSections loads the sections of every WRITE_ROUTES target other than the
+// main source, so reads aggregate across them. A routed file that does not exist
+// yet is skipped; it is created by the first write to one of its sections.
+func loadRouteSections(conf config.Config) ([]Section, error) {
+	if isCustomSource(conf) || isURLSource(conf.FilePath) {
+		return nil, nil
+	}
+	var targets []string
+	for _, target := range conf.WriteRoutes {
+		if !slices.Contains(targets, target) {
+			targets = append(targets, target)
+		}
+	}
+	sort.Strings(targets)
+
+	var sections []Section
+	for _, target := range targets {
+		filePath, note := "", target
+		if conf.FilePath != "" {
+			filePath, note = routeFilePath(conf, target), ""
+			if filepath.Clean(filePath) == filepath.Clean(conf.FilePath) {
+				continue
+			}
+			if _, err := appFS.Stat(filePath); errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+		} else if note == conf.SNNote {
+			continue
+		}
+		routed, err := loadSections(filePath, note, conf)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load write route %s: %w", target, err)
+		}
+		sections = append(sections, routed...)
+	}
+	return sections, nil
+}
+
+// routedSections returns the names of the routed sections, sorted, for offering
+// them when picking a section to write to.
+func routedSections(conf config.Config) []string {
+	names := make([]string, 0, len(conf.WriteRoutes))
+	for name := range conf.WriteRoutes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,82 @@
+package prompt
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+func TestWriteRoutes(t *testing.T) {
+	fs := useMemFS(t)
+	if err := afero.WriteFile(fs, "/lib/prompts.md", []byte(testStagingContent), 0600); err != nil {
+		t.Fatal(err)
+	}
+	conf := config.Config{
+		FilePath:       "/lib/prompts.md",
+		OnConflict:     ConflictAbort,
+		StagingSection: "Inbox",
+		WriteRoutes:    map[string]string{"Writing": "writing.md", "Golang": "/other/go-prompts.md"},
+	}
+	if err := fs.MkdirAll("/other", 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := AddPrompt(conf, "Blog Post", "Draft a blog post about this change.", "writing"); err != nil {
+		t.Fatalf("AddPrompt() returned error: %v", err)
+	}
+	routed, _ := afero.ReadFile(fs, "/lib/writing.md")
+	if want := "# Prompts\n\n\n## writing\n\n### Blog Post\nDraft a blog post about this change.\n"; string(routed) != want {
+		t.Errorf("routed file = %q, want %q", routed, want)
+	}
+
+	// Accepting a staged prompt moves it to the routed file
+	staged, err := ListStaged(conf)
+	if err != nil || len(staged) != 2 {
+		t.Fatalf("ListStaged() = %+v, %v", staged, err)
+	}
+	if err := AcceptStaged(conf, staged[0]); err != nil {
+		t.Fatalf("AcceptStaged() returned error: %v", err)
+	}
+	main, _ := afero.ReadFile(fs, "/lib/prompts.md")
+	if strings.Contains(string(main), "Unit Tests") || strings.Contains(string(main), "Draft a blog post") {
+		t.Errorf("routed prompts should not be in the main file:\n%s", main)
+	}
+	goPrompts, _ := afero.ReadFile(fs, "/other/go-prompts.md")
+	if !strings.Contains(string(goPrompts), "### Unit Tests\nWrite table-driven unit tests.") {
+		t.Errorf("accepted prompt should be in the routed file:\n%s", goPrompts)
+	}
+
+	// Reads aggregate across the main file and every route
+	data, err := LoadPrompts(conf)
+	if err != nil {
+		t.Fatalf("LoadPrompts() returned error: %v", err)
+	}
+	for _, tt := range []struct{ query, section string }{
+		{"blog post", "writing"},
+		{"table-driven", "Golang"},
+		{"review this go code", "Golang"},
+	} {
+		if results := SearchPrompts(data, tt.query, tt.section); len(results) != 1 {
+			t.Errorf("SearchPrompts(%q, %q) = %v, want one result", tt.query, tt.section, results)
+		}
+	}
+
+	sections := existingSections(conf)
+	if want := []string{"Golang", "Inbox", "Writing"}; strings.Join(sections, ",") != strings.Join(want, ",") {
+		t.Errorf("existingSections() = %v, want %v", sections, want)
+	}
+}
+
+func TestLoadPrompts_MissingRouteFile(t *testing.T) {
+	fs := useMemFS(t)
+	if err := afero.WriteFile(fs, "/lib/prompts.md", []byte(testStagingContent), 0600); err != nil {
+		t.Fatal(err)
+	}
+	conf := config.Config{FilePath: "/lib/prompts.md", WriteRoutes: map[string]string{"Writing": "writing.md"}}
+	if _, err := LoadPrompts(conf); err != nil {
+		t.Errorf("LoadPrompts() should skip a routed file that does not exist yet, got %v", err)
+	}
+}
@@ -0,0 +1,151 @@
+package prompt
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/toozej/wheresmyprompt/internal/redact"
+)
+
+// awsNow allows tests to sign requests at a fixed time.
+var awsNow = time.Now
+
+// defaultAWSRegion is used when no region is configured.
+const defaultAWSRegion = "us-east-1"
+
+// loadAWSConfig resolves the region and credentials with the AWS SDK's default
+// chain, as the AWS CLI does: AWS_ACCESS_KEY_ID and the other environment
+// variables, then the AWS_PROFILE profile of the shared config and credentials
+// files, whether it holds static keys, an SSO session, a credential_process or a
+// role to assume, then the container or instance role. The region comes from
+// AWS_REGION, AWS_DEFAULT_REGION or the profile, defaulting to us-east-1.
+func loadAWSConfig(ctx context.Context) (aws.Config, error) {
+	cfg, err := awsconfig.LoadDefaultConfig(ctx)
+	if err != nil {
+		return aws.Config{}, fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+	if cfg.Region == "" {
+		cfg.Region = defaultAWSRegion
+	}
+	return cfg, nil
+}
+
+// awsCredentials retrieves the credentials of cfg, registering the secret parts
+// for redaction. The boolean result is false when there are none, in which case
+// requests are sent unsigned, which works for public buckets.
+func awsCredentials(ctx context.Context, cfg aws.Config) (aws.Credentials, bool) {
+	if cfg.Credentials == nil {
+		return aws.Credentials{}, false
+	}
+	creds, err := cfg.Credentials.Retrieve(ctx)
+	if err != nil {
+		log.Debugf("no AWS credentials, sending S3 requests unsigned: %v", redact.Error(err))
+		return aws.Credentials{}, false
+	}
+	redact.Add(creds.SecretAccessKey, creds.SessionToken)
+	return creds, true
+}
+
+// s3ObjectURL returns the HTTPS URL of the s3://bucket/key object: path-style below
+// AWS_ENDPOINT_URL_S3 or AWS_ENDPOINT_URL when set, for S3-compatible storage such as
+// MinIO, and virtual-hosted on AWS otherwise.
+func s3ObjectURL(rawURL, region string) (*url.URL, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" || strings.Trim(u.Path, "/") == "" {
+		return nil, fmt.Errorf("invalid S3 location %q: must be s3://bucket/key", rawURL)
+	}
+	bucket, key := u.Host, strings.TrimPrefix(u.Path, "/")
+
+	endpoint := os.Getenv("AWS_ENDPOINT_URL_S3")
+	if endpoint == "" {
+		endpoint = os.Getenv("AWS_ENDPOINT_URL")
+	}
+	var object *url.URL
+	switch {
+	case endpoint != "":
+		if object, err = url.Parse(strings.TrimRight(endpoint, "/")); err != nil {
+			return nil, fmt.Errorf("invalid S3 endpoint %q: %w", endpoint, err)
+		}
+		object.Path += "/" + bucket + "/" + key
+	case strings.Contains(bucket, "."):
+		// Dotted bucket names do not match the wildcard certificate of virtual hosts
+		object = &url.URL{Scheme: "https", Host: "s3." + region + ".amazonaws.com", Path: "/" + bucket + "/" + key}
+	default:
+		object = &url.URL{Scheme: "https", Host: bucket + ".s3." + region + ".amazonaws.com", Path: "/" + key}
+	}
+	object.RawPath = s3EscapePath(object.Path)
+	return object, nil
+}
+
+// s3EscapePath percent-encodes every byte of path outside the unreserved set of
+// RFC 3986, keeping the slashes, as SigV4 canonical URIs require.
+func s3EscapePath(path string) string {
+	var b strings.Builder
+	for _, c := range []byte(path) {
+		if c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || strings.IndexByte("-._~/", c) >= 0 {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// newS3Request returns a request for the s3://bucket/key object with body and
+// header, signed with the resolved AWS credentials if there are any.
+func newS3Request(method, rawURL string, body []byte, header http.Header) (*http.Request, error) {
+	ctx := context.Background()
+	cfg, err := loadAWSConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	object, err := s3ObjectURL(rawURL, cfg.Region)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(method, object.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	if creds, ok := awsCredentials(ctx, cfg); ok {
+		if err := signS3Request(ctx, req, body, creds, cfg.Region, awsNow()); err != nil {
+			return nil, err
+		}
+	}
+	return req, nil
+}
+
+// signS3Request adds an AWS Signature Version 4 Authorization header to req with the
+// SDK's signer, signing the host, the headers already set and the SHA-256 of body.
+func signS3Request(ctx context.Context, req *http.Request, body []byte, creds aws.Credentials, region string, now time.Time) error {
+	payloadHash := sha256Hex(body)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	// S3 object keys are escaped once, in s3ObjectURL, rather than twice
+	signer := v4.NewSigner(func(o *v4.SignerOptions) { o.DisableURIPathEscaping = true })
+	if err := signer.SignHTTP(ctx, creds, req, payloadHash, "s3", region, now.UTC()); err != nil {
+		return fmt.Errorf("failed to sign S3 request: %w", err)
+	}
+	return nil
+}
+
+// sha256Hex returns the lowercase hex SHA-256 of data, the payload hash SigV4 signs.
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
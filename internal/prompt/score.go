@@ -0,0 +1,93 @@
+package prompt
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Heuristic patterns used by ScorePrompt.
+var (
+	roleRe       = regexp.MustCompile(`(?i)\b(you are|act as|acting as|your role|as an? (expert|senior|experienced|professional))\b`)
+	constraintRe = regexp.MustCompile(`(?i)\b(must|should|do not|don't|never|avoid|only|at most|no more than|limit|without)\b`)
+	formatRe     = regexp.MustCompile(`(?i)\b(format|json|yaml|markdown|table|bullet|list|respond with|output|return|step[- ]by[- ]step)\b`)
+	exampleRe    = regexp.MustCompile(`(?i)(\bexamples?\b|\be\.g\.|\bfor instance\b|\bsuch as\b)`)
+	vagueRe      = regexp.MustCompile(`(?i)\b(something|stuff|things?|etc|somehow|whatever|good|nice|better|some)\b`)
+)
+
+// Score is the heuristic quality rating of a prompt.
+type Score struct {
+	Total       int      // 0 (poor) to 100 (excellent)
+	Suggestions []string // Hints on how to improve the prompt
+}
+
+// ScorePrompt heuristically rates the quality of a prompt. Points are awarded for
+// stating a role, constraints, an output format and examples, and for a reasonable
+// length; vague words cost points. The result is clamped to 0-100.
+func ScorePrompt(content string) Score {
+	var s Score
+
+	if roleRe.MatchString(content) {
+		s.Total += 20
+	} else {
+		s.Suggestions = append(s.Suggestions, "state a role (e.g. \"You are a senior Go reviewer\")")
+	}
+	if constraintRe.MatchString(content) {
+		s.Total += 20
+	} else {
+		s.Suggestions = append(s.Suggestions, "add constraints (what the answer must or must not do)")
+	}
+	if formatRe.MatchString(content) {
+		s.Total += 20
+	} else {
+		s.Suggestions = append(s.Suggestions, "specify the output format")
+	}
+	if exampleRe.MatchString(content) {
+		s.Total += 15
+	} else {
+		s.Suggestions = append(s.Suggestions, "include an example")
+	}
+
+	words := len(strings.Fields(content))
+	switch {
+	case words < 8:
+		s.Suggestions = append(s.Suggestions, "add more detail, the prompt is very short")
+	case words < 20:
+		s.Total += 10
+	case words <= 300:
+		s.Total += 25
+	default:
+		s.Total += 15
+		s.Suggestions = append(s.Suggestions, "consider shortening, the prompt is very long")
+	}
+
+	if vague := vagueRe.FindAllString(content, -1); len(vague) > 0 {
+		penalty := 5 * len(vague)
+		if penalty > 20 {
+			penalty = 20
+		}
+		s.Total -= penalty
+		s.Suggestions = append(s.Suggestions, "replace vague words: "+strings.Join(uniqueLower(vague), ", "))
+	}
+
+	if s.Total < 0 {
+		s.Total = 0
+	}
+	if s.Total > 100 {
+		s.Total = 100
+	}
+	return s
+}
+
+// uniqueLower returns the distinct lower-cased values of words in order of appearance.
+func uniqueLower(words []string) []string {
+	seen := make(map[string]bool, len(words))
+	var out []string
+	for _, w := range words {
+		w = strings.ToLower(w)
+		if !seen[w] {
+			seen[w] = true
+			out = append(out, w)
+		}
+	}
+	return out
+}
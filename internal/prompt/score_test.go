@@ -0,0 +1,46 @@
+package prompt
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestScorePrompt(t *testing.T) {
+	good := "You are a senior Go reviewer. Review the following code for bugs and race conditions. " +
+		"You must not rewrite the whole file. Respond with a markdown table of issues, for example: " +
+		"| line | issue | fix |."
+	poor := "make it better somehow"
+
+	goodScore := ScorePrompt(good)
+	poorScore := ScorePrompt(poor)
+
+	if goodScore.Total < 80 {
+		t.Errorf("expected well-structured prompt to score at least 80, got %d (%v)", goodScore.Total, goodScore.Suggestions)
+	}
+	if poorScore.Total > 20 {
+		t.Errorf("expected vague prompt to score at most 20, got %d", poorScore.Total)
+	}
+	if len(goodScore.Suggestions) >= len(poorScore.Suggestions) {
+		t.Errorf("expected fewer suggestions for the good prompt: %v vs %v", goodScore.Suggestions, poorScore.Suggestions)
+	}
+
+	found := false
+	for _, s := range poorScore.Suggestions {
+		if strings.Contains(s, "better") && strings.Contains(s, "somehow") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected vague words to be listed in suggestions, got %v", poorScore.Suggestions)
+	}
+}
+
+func TestScorePromptBounds(t *testing.T) {
+	tests := []string{"", "stuff things etc whatever some good nice better", strings.Repeat("word ", 500)}
+	for _, content := range tests {
+		score := ScorePrompt(content)
+		if score.Total < 0 || score.Total > 100 {
+			t.Errorf("ScorePrompt(%q...) = %d, want value within 0-100", content[:min(len(content), 20)], score.Total)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package prompt
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/toozej/wheresmyprompt/internal/sandbox"
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+// Secret providers selectable with SECRET_PROVIDER.
+const (
+	SecretProvider1Password = "1password"
+	SecretProviderBitwarden = "bitwarden"
+	SecretProviderPass      = "pass"
+	SecretProviderEnv       = "env"
+)
+
+// SecretProvider retrieves the Simplenote credentials from a secret store. SN_CREDENTIAL
+// names the item holding them and SN_USERNAME and SN_PASSWORD name its fields.
+type SecretProvider interface {
+	// Lookup returns the value of field in item.
+	Lookup(item, field string) (string, error)
+	// Binary names the CLI the provider runs, or "" if it needs none.
+	Binary() string
+}
+
+// secretOutputFunc runs a secret store CLI and returns its stdout; tests replace it.
+var secretOutputFunc = func(name string, args ...string) ([]byte, error) {
+	return sandbox.Command(name, args...).Output() // #nosec G204
+}
+
+// NewSecretProvider returns the SecretProvider selected by SECRET_PROVIDER, which
+// defaults to 1Password. Returns an error for an unknown provider.
+func NewSecretProvider(conf config.Config) (SecretProvider, error) {
+	switch conf.SecretProvider {
+	case "", SecretProvider1Password:
+		return onePassword{}, nil
+	case SecretProviderBitwarden:
+		return bitwarden{}, nil
+	case SecretProviderPass:
+		return passwordStore{}, nil
+	case SecretProviderEnv:
+		return envSecrets{}, nil
+	default:
+		return nil, fmt.Errorf("unknown SECRET_PROVIDER %q (expected %s, %s, %s or %s)", conf.SecretProvider,
+			SecretProvider1Password, SecretProviderBitwarden, SecretProviderPass, SecretProviderEnv)
+	}
+}
+
+// onePassword reads fields of a 1Password item with the op CLI.
+type onePassword struct{}
+
+func (onePassword) Binary() string { return "op" }
+
+func (onePassword) Lookup(item, field string) (string, error) {
+	out, err := secretOutputFunc("op", "item", "get", item, "--field", field, "--reveal")
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch %s from 1Password: %w", field, commandError(err))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// bitwarden reads a Bitwarden item with the bw CLI, which must be unlocked (BW_SESSION).
+// The fields "username" and "password" are the item's login; other names are custom fields.
+type bitwarden struct{}
+
+func (bitwarden) Binary() string { return "bw" }
+
+func (bitwarden) Lookup(item, field string) (string, error) {
+	out, err := secretOutputFunc("bw", "get", "item", item)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch %s from Bitwarden: %w", field, commandError(err))
+	}
+	var doc struct {
+		Login struct {
+			Username string `json:"username"`
+			Password string `json:"password"`
+		} `json:"login"`
+		Fields []struct {
+			Name  string `json:"name"`
+			Value string `json:"value"`
+		} `json:"fields"`
+	}
+	if err := json.Unmarshal(out, &doc); err != nil {
+		return "", fmt.Errorf("failed to parse Bitwarden item %s: %w", item, err)
+	}
+	switch field {
+	case "username":
+		return doc.Login.Username, nil
+	case "password":
+		return doc.Login.Password, nil
+	}
+	for _, f := range doc.Fields {
+		if f.Name == field {
+			return f.Value, nil
+		}
+	}
+	return "", fmt.Errorf("field %s not found in Bitwarden item %s", field, item)
+}
+
+// passwordStore reads a pass (password-store) entry. Following the pass convention,
+// "password" is the first line and other fields are "name: value" lines below it.
+type passwordStore struct{}
+
+func (passwordStore) Binary() string { return "pass" }
+
+func (passwordStore) Lookup(item, field string) (string, error) {
+	out, err := secretOutputFunc("pass", "show", item)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch %s from pass: %w", field, commandError(err))
+	}
+	lines := strings.Split(strings.TrimRight(string(out), "\n"), "\n")
+	if field == "password" {
+		return strings.TrimSpace(lines[0]), nil
+	}
+	for _, line := range lines[1:] {
+		name, value, found := strings.Cut(line, ":")
+		if found && strings.EqualFold(strings.TrimSpace(name), field) {
+			return strings.TrimSpace(value), nil
+		}
+	}
+	return "", fmt.Errorf("field %s not found in pass entry %s", field, item)
+}
+
+// envSecrets reads the environment variable named by each field, ignoring the item,
+// for credentials injected by another tool such as a CI secret store.
+type envSecrets struct{}
+
+func (envSecrets) Binary() string { return "" }
+
+func (envSecrets) Lookup(_, field string) (string, error) {
+	value, ok := os.LookupEnv(field)
+	if !ok || value == "" {
+		return "", fmt.Errorf("environment variable %s is not set", field)
+	}
+	return value, nil
+}
@@ -0,0 +1,75 @@
+package prompt
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+func TestSecretProviders(t *testing.T) {
+	oldOutput := secretOutputFunc
+	t.Cleanup(func() { secretOutputFunc = oldOutput })
+
+	bitwardenItem := `{"login":{"username":"me@example.com","password":"bw-secret"},"fields":[{"name":"otp-user","value":"otp@example.com"}]}`
+	passEntry := "pass-secret\nlogin: me@example.com\nurl: https://app.simplenote.com\n"
+	t.Setenv("SIMPLENOTE_PASS", "env-secret")
+
+	tests := []struct {
+		name     string
+		provider string
+		output   string
+		field    string
+		expected string
+		args     []string
+		wantErr  bool
+	}{
+		{name: "1password", provider: "", output: "op-secret\n", field: "password", expected: "op-secret",
+			args: []string{"op", "item", "get", "Simplenote", "--field", "password", "--reveal"}},
+		{name: "bitwarden login", provider: SecretProviderBitwarden, output: bitwardenItem, field: "password", expected: "bw-secret",
+			args: []string{"bw", "get", "item", "Simplenote"}},
+		{name: "bitwarden custom field", provider: SecretProviderBitwarden, output: bitwardenItem, field: "otp-user", expected: "otp@example.com"},
+		{name: "bitwarden missing field", provider: SecretProviderBitwarden, output: bitwardenItem, field: "email", wantErr: true},
+		{name: "pass password", provider: SecretProviderPass, output: passEntry, field: "password", expected: "pass-secret",
+			args: []string{"pass", "show", "Simplenote"}},
+		{name: "pass field", provider: SecretProviderPass, output: passEntry, field: "Login", expected: "me@example.com"},
+		{name: "pass missing field", provider: SecretProviderPass, output: passEntry, field: "email", wantErr: true},
+		{name: "env", provider: SecretProviderEnv, field: "SIMPLENOTE_PASS", expected: "env-secret"},
+		{name: "env unset", provider: SecretProviderEnv, field: "SIMPLENOTE_MISSING", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var args []string
+			secretOutputFunc = func(name string, a ...string) ([]byte, error) {
+				args = append([]string{name}, a...)
+				return []byte(tt.output), nil
+			}
+			provider, err := NewSecretProvider(config.Config{SecretProvider: tt.provider})
+			if err != nil {
+				t.Fatalf("NewSecretProvider() returned error: %v", err)
+			}
+			got, err := provider.Lookup("Simplenote", tt.field)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Lookup() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.expected {
+				t.Errorf("Lookup() = %q, want %q", got, tt.expected)
+			}
+			if tt.args != nil && !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("ran %q, want %q", args, tt.args)
+			}
+		})
+	}
+
+	if _, err := NewSecretProvider(config.Config{SecretProvider: "keepass"}); err == nil {
+		t.Error("expected error for unknown provider")
+	}
+
+	secretOutputFunc = func(string, ...string) ([]byte, error) { return nil, errors.New("not signed in") }
+	if _, err := (onePassword{}).Lookup("Simplenote", "password"); err == nil || !strings.Contains(err.Error(), "1Password") {
+		t.Errorf("expected a 1Password error, got %v", err)
+	}
+}
@@ -0,0 +1,126 @@
+package prompt
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/lithammer/fuzzysearch/fuzzy"
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+// askSectionFunc allows tests to answer the interactive section question.
+var askSectionFunc = pickSection
+
+// existingSections returns the name of every "## " section in the configured note,
+// the sections prompts are added to, in document order and without duplicates,
+// followed by the WRITE_ROUTES sections it does not have.
+// Errors reading the note yield no sections.
+func existingSections(conf config.Config) []string {
+	current, err := loadSourceContent(conf)
+	if err != nil {
+		return nil
+	}
+
+	var names []string
+	for _, line := range strings.Split(current, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if !strings.HasPrefix(trimmed, "## ") {
+			continue
+		}
+		name := headingName(strings.TrimPrefix(trimmed, "## "))
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	// Routed sections may not exist in the main source yet
+	for _, name := range routedSections(conf) {
+		if !slices.ContainsFunc(names, func(n string) bool { return strings.EqualFold(n, name) }) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// pickSection lists sections on out and reads the choice from in: a number picks a
+// listed section, a name matching a section except for case picks that section, and
+// any other name is fuzzy matched against the sections so a similar existing one can
+// be chosen instead of creating a near duplicate. An empty answer or end of input
+// skips the section.
+func pickSection(sections []string, in io.Reader, out io.Writer) (string, error) {
+	if len(sections) > 0 {
+		fmt.Fprintln(out, "Existing sections:")
+		printSections(out, sections)
+	}
+
+	scanner := bufio.NewScanner(in)
+	for {
+		fmt.Fprint(out, "Enter section number or name (optional, press Enter to skip): ")
+		if !scanner.Scan() {
+			return "", scanner.Err()
+		}
+		answer := strings.TrimSpace(scanner.Text())
+		if answer == "" {
+			return "", nil
+		}
+		if n, err := strconv.Atoi(answer); err == nil {
+			if n >= 1 && n <= len(sections) {
+				return sections[n-1], nil
+			}
+			fmt.Fprintf(out, "No section numbered %d\n", n)
+			continue
+		}
+		for _, name := range sections {
+			if strings.EqualFold(name, answer) {
+				return name, nil
+			}
+		}
+
+		similar := similarSections(sections, answer)
+		if len(similar) == 0 {
+			return answer, nil
+		}
+		fmt.Fprintf(out, "Section '%s' does not exist. Similar sections:\n", answer)
+		printSections(out, similar)
+		fmt.Fprintf(out, "Choose a section [1-%d], [c]reate '%s', or press Enter to search again: ", len(similar), answer)
+		if !scanner.Scan() {
+			return answer, scanner.Err()
+		}
+		choice := strings.ToLower(strings.TrimSpace(scanner.Text()))
+		if choice == "c" || choice == "create" {
+			return answer, nil
+		}
+		if n, err := strconv.Atoi(choice); err == nil && n >= 1 && n <= len(similar) {
+			return similar[n-1], nil
+		}
+	}
+}
+
+// similarSections returns the sections fuzzily matching name, best match first.
+// Names extending a section name, such as "Golangs" for "Golang", count as similar too.
+func similarSections(sections []string, name string) []string {
+	ranks := fuzzy.RankFindNormalizedFold(name, sections)
+	sort.Sort(ranks)
+	var similar []string
+	for _, match := range ranks {
+		similar = append(similar, match.Target)
+	}
+	for _, section := range sections {
+		lowerSection, lowerName := strings.ToLower(section), strings.ToLower(name)
+		if strings.HasPrefix(lowerName, lowerSection) && !slices.Contains(similar, section) {
+			similar = append(similar, section)
+		}
+	}
+	return similar
+}
+
+// printSections writes a numbered list of sections to out.
+func printSections(out io.Writer, sections []string) {
+	for i, name := range sections {
+		fmt.Fprintf(out, "  %d) %s\n", i+1, name)
+	}
+}
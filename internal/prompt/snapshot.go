@@ -0,0 +1,408 @@
+package prompt
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+// snapshotsDirName is the directory of library snapshots inside the data directory.
+const snapshotsDirName = "snapshots"
+
+// snapshotManifestName is the archive entry describing a library snapshot.
+const snapshotManifestName = "manifest.json"
+
+// snapshotExt is the file extension of library snapshots.
+const snapshotExt = ".zip"
+
+// Roles of the documents kept in a library snapshot.
+const (
+	SnapshotLibrary = "library" // The personal library, restored to its source
+	SnapshotRoute   = "route"   // A WRITE_ROUTES target, restored to it
+	SnapshotTeam    = "team"    // The team library, kept for reference only
+	SnapshotAdded   = "added"   // The added-at log of the data directory
+)
+
+// snapshotNow allows tests to control snapshot timestamps.
+var snapshotNow = time.Now
+
+// ErrSnapshotNotFound is returned by RestoreSnapshot for a snapshot that does not exist.
+var ErrSnapshotNotFound = errors.New("library snapshot not found")
+
+// SnapshotDocument describes a document kept in a library snapshot.
+type SnapshotDocument struct {
+	Role   string `json:"role"`             // One of SnapshotLibrary, SnapshotRoute, SnapshotTeam or SnapshotAdded
+	Target string `json:"target,omitempty"` // The file, note or route target the document was read from
+	Entry  string `json:"entry"`            // The name of the document in the archive
+	Bytes  int    `json:"bytes"`
+}
+
+// SnapshotInfo is the manifest of a library snapshot.
+type SnapshotInfo struct {
+	Name      string             `json:"name"` // File name of the snapshot in the snapshots directory
+	Created   time.Time          `json:"created"`
+	Source    string             `json:"source"`  // Where the library was read from, as shown by the TUI
+	Prompts   int                `json:"prompts"` // Number of prompts in the merged library
+	Documents []SnapshotDocument `json:"documents"`
+}
+
+// snapshotsDir returns the "snapshots" directory of the data directory.
+func snapshotsDir(conf config.Config) (string, error) {
+	dir, err := config.ResolveDataDir(conf)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, snapshotsDirName), nil
+}
+
+// snapshotDoc is a document read for a library snapshot.
+type snapshotDoc struct {
+	SnapshotDocument
+	content string
+}
+
+// CreateSnapshot saves the whole library into a timestamped zip archive in the
+// snapshots directory of the data directory: the personal library, every
+// WRITE_ROUTES target, the team library and the added-at log, along with a
+// manifest describing them. Unlike the backups taken before each Simplenote write,
+// a snapshot covers every source at once and is only taken on request.
+func CreateSnapshot(conf config.Config) (SnapshotInfo, error) {
+	docs, err := snapshotDocs(conf)
+	if err != nil {
+		return SnapshotInfo{}, err
+	}
+	data, err := LoadPrompts(conf)
+	if err != nil {
+		return SnapshotInfo{}, err
+	}
+	source, _ := sourceInfo(conf)
+	now := snapshotNow().UTC()
+	info := SnapshotInfo{
+		Name:    now.Format("20060102T150405Z") + snapshotExt,
+		Created: now,
+		Source:  source,
+		Prompts: len(generateSearchPool(data, "")),
+	}
+	for _, d := range docs {
+		info.Documents = append(info.Documents, d.SnapshotDocument)
+	}
+
+	dir, err := snapshotsDir(conf)
+	if err != nil {
+		return SnapshotInfo{}, err
+	}
+	if err := appFS.MkdirAll(dir, 0700); err != nil {
+		return SnapshotInfo{}, fmt.Errorf("failed to create snapshots directory: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := writeSnapshotArchive(&buf, info, docs); err != nil {
+		return SnapshotInfo{}, err
+	}
+	path := filepath.Join(dir, info.Name)
+	if _, err := appFS.Stat(path); err == nil {
+		return SnapshotInfo{}, fmt.Errorf("snapshot %s already exists; try again in a second", info.Name)
+	}
+	if err := afero.WriteFile(appFS, path, buf.Bytes(), 0600); err != nil {
+		return SnapshotInfo{}, fmt.Errorf("failed to save snapshot: %w", err)
+	}
+	return info, nil
+}
+
+// snapshotDocs reads every document a snapshot of conf's library keeps.
+func snapshotDocs(conf config.Config) ([]snapshotDoc, error) {
+	content, err := loadSourceContent(conf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the library: %w", err)
+	}
+	target := conf.FilePath
+	if target == "" {
+		if src, ok := customSourceFunc(conf); ok {
+			target = src.Name()
+		} else {
+			target = conf.SNNote
+		}
+	}
+	docs := []snapshotDoc{newSnapshotDoc(SnapshotLibrary, redactURL(target), "library.md", content)}
+
+	routes, err := snapshotRouteDocs(conf)
+	if err != nil {
+		return nil, err
+	}
+	docs = append(docs, routes...)
+
+	if hasTeamLibrary(conf) {
+		team := teamConfig(conf)
+		content, err := loadSourceContent(team)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the team library: %w", err)
+		}
+		docs = append(docs, newSnapshotDoc(SnapshotTeam, sourceName(team.FilePath, team.SNNote), "team.md", content))
+	}
+
+	path, err := addedLogPath(conf)
+	if err != nil {
+		return nil, err
+	}
+	added, err := afero.ReadFile(appFS, path) // #nosec G304
+	switch {
+	case err == nil:
+		docs = append(docs, newSnapshotDoc(SnapshotAdded, addedLogName, addedLogName, string(added)))
+	case !errors.Is(err, os.ErrNotExist):
+		return nil, fmt.Errorf("failed to read the added-at log: %w", err)
+	}
+	return docs, nil
+}
+
+// snapshotRouteDocs reads the WRITE_ROUTES targets other than the main source, in
+// the order loadRouteSections reads them. Routed files that do not exist yet are skipped.
+func snapshotRouteDocs(conf config.Config) ([]snapshotDoc, error) {
+	if isCustomSource(conf) || isURLSource(conf.FilePath) {
+		return nil, nil
+	}
+	var targets []string
+	for _, target := range conf.WriteRoutes {
+		if !slices.Contains(targets, target) {
+			targets = append(targets, target)
+		}
+	}
+	sort.Strings(targets)
+
+	var docs []snapshotDoc
+	for i, target := range targets {
+		routed, ok := routeConfig(conf, target)
+		if !ok {
+			continue
+		}
+		if routed.FilePath != "" {
+			if _, err := appFS.Stat(routed.FilePath); errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+		}
+		content, err := loadSourceContent(routed)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read write route %s: %w", target, err)
+		}
+		docs = append(docs, newSnapshotDoc(SnapshotRoute, target, fmt.Sprintf("routes/%d.md", i+1), content))
+	}
+	return docs, nil
+}
+
+// routeConfig returns conf reading the WRITE_ROUTES target, or false when the
+// target is the main source itself.
+func routeConfig(conf config.Config, target string) (config.Config, bool) {
+	if conf.FilePath != "" {
+		path := routeFilePath(conf, target)
+		if filepath.Clean(path) == filepath.Clean(conf.FilePath) {
+			return conf, false
+		}
+		conf.FilePath = path
+		return conf, true
+	}
+	if target == conf.SNNote {
+		return conf, false
+	}
+	conf.SNNote = target
+	return conf, true
+}
+
+// teamConfig returns conf reading the team library instead of the personal one.
+func teamConfig(conf config.Config) config.Config {
+	conf = withoutCustomSource(conf)
+	conf.FilePath, conf.SNNote = conf.TeamFilePath, conf.TeamSNNote
+	return conf
+}
+
+// newSnapshotDoc returns the snapshot document role holding content.
+func newSnapshotDoc(role, target, entry, content string) snapshotDoc {
+	return snapshotDoc{
+		SnapshotDocument: SnapshotDocument{Role: role, Target: target, Entry: entry, Bytes: len(content)},
+		content:          content,
+	}
+}
+
+// writeSnapshotArchive writes the zip archive of a snapshot: its manifest followed by docs.
+func writeSnapshotArchive(w io.Writer, info SnapshotInfo, docs []snapshotDoc) error {
+	zw := zip.NewWriter(w)
+	manifest, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal snapshot manifest: %w", err)
+	}
+	entries := append([]snapshotDoc{{SnapshotDocument: SnapshotDocument{Entry: snapshotManifestName}, content: string(manifest) + "\n"}}, docs...)
+	for _, d := range entries {
+		f, err := zw.CreateHeader(&zip.FileHeader{Name: d.Entry, Method: zip.Deflate, Modified: info.Created})
+		if err != nil {
+			return fmt.Errorf("failed to write snapshot: %w", err)
+		}
+		if _, err := io.WriteString(f, d.content); err != nil {
+			return fmt.Errorf("failed to write snapshot: %w", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	return nil
+}
+
+// ListSnapshots returns the manifests of the snapshots in the snapshots directory,
+// oldest first. Files that are not readable snapshots are skipped.
+func ListSnapshots(conf config.Config) ([]SnapshotInfo, error) {
+	dir, err := snapshotsDir(conf)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := afero.ReadDir(appFS, dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshots directory: %w", err)
+	}
+	var snapshots []SnapshotInfo
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != snapshotExt {
+			continue
+		}
+		info, _, err := readSnapshot(filepath.Join(dir, e.Name()))
+		if err != nil {
+			continue
+		}
+		info.Name = e.Name()
+		snapshots = append(snapshots, info)
+	}
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].Created.Before(snapshots[j].Created) })
+	return snapshots, nil
+}
+
+// readSnapshot reads the manifest and documents of the snapshot archive at path.
+func readSnapshot(path string) (SnapshotInfo, map[string]string, error) {
+	data, err := afero.ReadFile(appFS, path) // #nosec G304
+	if err != nil {
+		return SnapshotInfo{}, nil, err
+	}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return SnapshotInfo{}, nil, fmt.Errorf("failed to open snapshot %s: %w", filepath.Base(path), err)
+	}
+	entries := make(map[string]string, len(zr.File))
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			return SnapshotInfo{}, nil, fmt.Errorf("failed to read snapshot %s: %w", filepath.Base(path), err)
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return SnapshotInfo{}, nil, fmt.Errorf("failed to read snapshot %s: %w", filepath.Base(path), err)
+		}
+		entries[f.Name] = string(content)
+	}
+	var info SnapshotInfo
+	if err := json.Unmarshal([]byte(entries[snapshotManifestName]), &info); err != nil {
+		return SnapshotInfo{}, nil, fmt.Errorf("failed to parse the manifest of snapshot %s: %w", filepath.Base(path), err)
+	}
+	return info, entries, nil
+}
+
+// resolveSnapshot returns the path of the snapshot named name: a file in the
+// snapshots directory, with or without its extension, or a path to a snapshot.
+func resolveSnapshot(conf config.Config, name string) (string, error) {
+	dir, err := snapshotsDir(conf)
+	if err != nil {
+		return "", err
+	}
+	candidates := []string{name}
+	if !strings.ContainsRune(name, filepath.Separator) {
+		candidates = []string{filepath.Join(dir, name), filepath.Join(dir, name+snapshotExt)}
+	}
+	for _, path := range candidates {
+		if info, err := appFS.Stat(path); err == nil && !info.IsDir() {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s", ErrSnapshotNotFound, name)
+}
+
+// RestoreSnapshot replaces the library with the snapshot named name, see
+// resolveSnapshot: the personal library, the WRITE_ROUTES targets it holds and the
+// added-at log are written back, each like any other write, so a Simplenote note is
+// backed up first and the restore can be undone. The team library is never written
+// to. It returns the manifest of the restored snapshot.
+func RestoreSnapshot(conf config.Config, name string) (SnapshotInfo, error) {
+	if err := checkWritable(conf); err != nil {
+		return SnapshotInfo{}, err
+	}
+	path, err := resolveSnapshot(conf, name)
+	if err != nil {
+		return SnapshotInfo{}, err
+	}
+	info, entries, err := readSnapshot(path)
+	if err != nil {
+		return SnapshotInfo{}, err
+	}
+	info.Name = filepath.Base(path)
+
+	// Check every document before writing any of them
+	for _, d := range info.Documents {
+		if _, ok := entries[d.Entry]; !ok {
+			return SnapshotInfo{}, fmt.Errorf("snapshot %s is missing %s", info.Name, d.Entry)
+		}
+	}
+
+	op := writeOp{action: "restore", title: info.Name}
+	for _, d := range info.Documents {
+		content := entries[d.Entry]
+		switch d.Role {
+		case SnapshotLibrary:
+			err = restoreSourceContent(conf, op, content)
+		case SnapshotRoute:
+			routed, ok := routeConfig(conf, d.Target)
+			if !ok {
+				continue
+			}
+			if routed.FilePath != "" {
+				if err := createRouteFile(routed.FilePath); err != nil {
+					return SnapshotInfo{}, err
+				}
+			}
+			err = restoreSourceContent(routed, op, content)
+		case SnapshotAdded:
+			err = restoreAddedLog(conf, content)
+		}
+		if err != nil {
+			return SnapshotInfo{}, fmt.Errorf("failed to restore %s: %w", d.Entry, err)
+		}
+	}
+	return info, nil
+}
+
+// restoreSourceContent replaces the content of the source conf writes to.
+func restoreSourceContent(conf config.Config, op writeOp, content string) error {
+	return updateSourceContent(conf, op, func(string) (string, error) {
+		return content, nil
+	})
+}
+
+// restoreAddedLog replaces the added-at log with content.
+func restoreAddedLog(conf config.Config, content string) error {
+	path, err := addedLogPath(conf)
+	if err != nil {
+		return err
+	}
+	if err := appFS.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create data directory: %w", err)
+	}
+	return afero.WriteFile(appFS, path, []byte(content), 0600)
+}
@@ -0,0 +1,114 @@
+package prompt
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+func TestCreateAndRestoreSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	library := filepath.Join(dir, "prompts.md")
+	routed := filepath.Join(dir, "work.md")
+	team := filepath.Join(dir, "team.md")
+	original := "# Prompts\n\n## Golang\n\nReview this Go code\n"
+	for path, content := range map[string]string{
+		library: original,
+		routed:  "# Prompts\n\n## Work\n\nSummarize the standup\n",
+		team:    "# Team\n\n## Shared\n\nWrite release notes\n",
+	} {
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	conf := config.Config{
+		FilePath:     library,
+		TeamFilePath: team,
+		WriteRoutes:  map[string]string{"Work": "work.md"},
+		DataDir:      filepath.Join(dir, "data"),
+	}
+
+	oldNow := snapshotNow
+	t.Cleanup(func() { snapshotNow = oldNow })
+	snapshotNow = func() time.Time { return time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC) }
+
+	info, err := CreateSnapshot(conf)
+	if err != nil {
+		t.Fatalf("CreateSnapshot() returned error: %v", err)
+	}
+	if info.Name != "20261016T093000Z.zip" || info.Prompts != 3 || len(info.Documents) != 3 {
+		t.Fatalf("unexpected snapshot: %+v", info)
+	}
+	roles := []string{SnapshotLibrary, SnapshotRoute, SnapshotTeam}
+	for i, d := range info.Documents {
+		if d.Role != roles[i] {
+			t.Errorf("document %d: expected role %s, got %+v", i, roles[i], d)
+		}
+	}
+
+	snapshots, err := ListSnapshots(conf)
+	if err != nil || len(snapshots) != 1 || snapshots[0].Name != info.Name {
+		t.Fatalf("ListSnapshots() = %+v, %v", snapshots, err)
+	}
+
+	if err := os.WriteFile(library, []byte("# Prompts\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(routed); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(team, []byte("# Team\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := RestoreSnapshot(conf, "20261016T093000Z"); err != nil {
+		t.Fatalf("RestoreSnapshot() returned error: %v", err)
+	}
+	if got, _ := os.ReadFile(library); string(got) != original {
+		t.Errorf("expected the library restored, got %q", got)
+	}
+	if got, _ := os.ReadFile(routed); string(got) != "# Prompts\n\n## Work\n\nSummarize the standup\n" {
+		t.Errorf("expected the routed file restored, got %q", got)
+	}
+	if got, _ := os.ReadFile(team); string(got) != "# Team\n" {
+		t.Errorf("expected the team library left alone, got %q", got)
+	}
+
+	if _, err := RestoreSnapshot(conf, "missing"); !errors.Is(err, ErrSnapshotNotFound) {
+		t.Errorf("expected ErrSnapshotNotFound, got %v", err)
+	}
+	conf.ReadOnly = true
+	if _, err := RestoreSnapshot(conf, info.Name); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("expected ErrReadOnly, got %v", err)
+	}
+}
+
+func TestRestoreSnapshotSimplenote(t *testing.T) {
+	original := "# Prompts\n\n## Golang\n\nReview this Go code\n"
+	note := fakeSimplenote(t, original)
+	conf := config.Config{SNNote: "LLM Prompts", DataDir: t.TempDir()}
+
+	info, err := CreateSnapshot(conf)
+	if err != nil {
+		t.Fatalf("CreateSnapshot() returned error: %v", err)
+	}
+	*note = "# Prompts\n\n## Golang\n\nSomething else\n"
+	if _, err := RestoreSnapshot(conf, info.Name); err != nil {
+		t.Fatalf("RestoreSnapshot() returned error: %v", err)
+	}
+	if *note != original {
+		t.Errorf("expected the note restored, got %q", *note)
+	}
+
+	records, err := WriteLog(conf)
+	if err != nil || len(records) != 1 || records[0].Action != "restore" || records[0].Title != info.Name {
+		t.Fatalf("expected the restore in the write log, got %+v (%v)", records, err)
+	}
+	if _, err := UndoLastWrite(conf); err != nil || *note != "# Prompts\n\n## Golang\n\nSomething else\n" {
+		t.Errorf("expected the restore to be undoable, got %q (%v)", *note, err)
+	}
+}
@@ -0,0 +1,173 @@
+package prompt
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+// sncliNote is the part of a note file in sncli's local database, or of a note
+// printed by "sncli export", that is read.
+type sncliNote struct {
+	Key          string   `json:"key"`
+	Content      string   `json:"content"`
+	Deleted      bool     `json:"deleted"`
+	Tags         []string `json:"tags"`
+	SystemTags   []string `json:"systemTags"`   // Flags such as "markdown" and "pinned"
+	CreationDate float64  `json:"creationDate"` // Unix time the note was created
+	Version      int      `json:"version"`
+	SyncDate     float64  `json:"syncdate"`   // Unix time of the note's last sync with Simplenote
+	ModifyDate   float64  `json:"modifydate"` // Unix time of the note's last change
+}
+
+// title returns the first line of the note without its heading marks.
+func (n sncliNote) title() string {
+	title, _, _ := strings.Cut(normalizeText(n.Content), "\n")
+	return strings.TrimSpace(strings.TrimLeft(title, "# "))
+}
+
+// sncliDBPath returns SN_DB_PATH, defaulting to sncli's default ~/.sncli.
+func sncliDBPath(conf config.Config) (string, error) {
+	if conf.SNDBPath != "" {
+		return conf.SNDBPath, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".sncli"), nil
+}
+
+// loadNoteForSearch returns the note for searching: from sncli's local database
+// when SN_LOCAL_DB is enabled and the note was synced within SN_LOCAL_MAX_AGE,
+// and from Simplenote otherwise. Writes always fetch the note from Simplenote so
+// they never build on a stale copy. Offline, or when fetching the note fails for
+// another reason than authentication, the database copy is used whatever its age.
+func loadNoteForSearch(conf config.Config) (string, error) {
+	if conf.SNLocalDB {
+		if content, ok := loadFromSncliDB(conf, time.Now()); ok {
+			return content, nil
+		}
+	}
+	if isOffline(conf) {
+		if content, ok := loadStaleFromSncliDB(conf, offlineReason(conf)); ok {
+			return content, nil
+		}
+		return "", fmt.Errorf("%w: no copy of note '%s' in the sncli database (%s)", ErrOffline, conf.SNNote, offlineReason(conf))
+	}
+	content, err := loadFromSimplenoteFunc(conf)
+	if err != nil && !errors.Is(err, ErrAuth) {
+		if stale, ok := loadStaleFromSncliDB(conf, err); ok {
+			return stale, nil
+		}
+	}
+	return content, err
+}
+
+// loadStaleFromSncliDB reads the note from sncli's local database however long ago
+// it was synced, warning that the copy is used because of reason.
+func loadStaleFromSncliDB(conf config.Config, reason any) (string, bool) {
+	dir, err := sncliDBPath(conf)
+	if err != nil {
+		return "", false
+	}
+	note, ok := findSncliNote(dir, conf.SNNote)
+	if !ok {
+		return "", false
+	}
+	warnCached("Simplenote note '"+conf.SNNote+"'", unixSeconds(note.SyncDate), reason)
+	return normalizeText(note.Content), true
+}
+
+// loadFromSncliDB reads the note from sncli's local database, a directory of one
+// JSON file per note, finding it by key (the file name) or by title (its first
+// line). The boolean result is false if the note is missing, deleted or was last
+// synced longer than SN_LOCAL_MAX_AGE before now; the reason is logged at debug level.
+func loadFromSncliDB(conf config.Config, now time.Time) (string, bool) {
+	dir, err := sncliDBPath(conf)
+	if err != nil {
+		log.Debugf("sncli database not found, fetching note from Simplenote: %v", err)
+		return "", false
+	}
+
+	note, ok := findSncliNote(dir, conf.SNNote)
+	if !ok {
+		log.Debugf("note %q not found in sncli database %s, fetching it from Simplenote", conf.SNNote, dir)
+		return "", false
+	}
+	synced := unixSeconds(note.SyncDate)
+	if conf.SNLocalMaxAge > 0 && now.Sub(synced) > conf.SNLocalMaxAge {
+		log.Debugf("note %q was last synced %s ago, fetching it from Simplenote", conf.SNNote, now.Sub(synced).Round(time.Second))
+		return "", false
+	}
+	return normalizeText(note.Content), true
+}
+
+// sncliModifiedFunc allows tests to control when the Simplenote note last changed.
+var sncliModifiedFunc = sncliModified
+
+// sncliModified returns when the note last changed according to sncli's local
+// database. The boolean result is false if the database has no copy of the note.
+func sncliModified(conf config.Config) (time.Time, bool) {
+	dir, err := sncliDBPath(conf)
+	if err != nil {
+		return time.Time{}, false
+	}
+	note, ok := findSncliNote(dir, conf.SNNote)
+	if !ok || note.ModifyDate == 0 {
+		return time.Time{}, false
+	}
+	return unixSeconds(note.ModifyDate), true
+}
+
+// unixSeconds converts the fractional Unix times sncli stores to a time.Time.
+func unixSeconds(seconds float64) time.Time {
+	return time.Unix(0, int64(seconds*float64(time.Second)))
+}
+
+// findSncliNote returns the note stored as "<name>.json" in dir or, failing that,
+// the most recently synced note whose title is name. Deleted notes are ignored.
+func findSncliNote(dir, name string) (sncliNote, bool) {
+	if note, err := readSncliNote(filepath.Join(dir, name+".json")); err == nil && !note.Deleted {
+		return note, true
+	}
+
+	paths, err := afero.Glob(appFS, filepath.Join(dir, "*.json"))
+	if err != nil {
+		return sncliNote{}, false
+	}
+	var found sncliNote
+	ok := false
+	for _, path := range paths {
+		note, err := readSncliNote(path)
+		if err != nil || note.Deleted {
+			continue
+		}
+		if note.title() == name && (!ok || note.SyncDate > found.SyncDate) {
+			found, ok = note, true
+		}
+	}
+	return found, ok
+}
+
+// readSncliNote decodes a note file of sncli's local database.
+func readSncliNote(path string) (sncliNote, error) {
+	data, err := afero.ReadFile(appFS, path) // #nosec G304
+	if err != nil {
+		return sncliNote{}, err
+	}
+	var note sncliNote
+	if err := json.Unmarshal(data, &note); err != nil {
+		return sncliNote{}, err
+	}
+	return note, nil
+}
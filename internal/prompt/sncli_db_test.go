@@ -0,0 +1,159 @@
+package prompt
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+func writeSncliNote(t *testing.T, dir, file string, note map[string]any) {
+	t.Helper()
+	data, _ := json.Marshal(note)
+	if err := os.WriteFile(filepath.Join(dir, file), data, 0600); err != nil {
+		t.Fatalf("failed to write note: %v", err)
+	}
+}
+
+func TestLoadFromSncliDB(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Unix(1_800_000_000, 0)
+	synced := float64(now.Add(-time.Minute).Unix())
+
+	writeSncliNote(t, dir, "abc123.json", map[string]any{"content": "LLM Prompts\r\n## Golang\r\nReview\r\n", "syncdate": synced})
+	writeSncliNote(t, dir, "old.json", map[string]any{"content": "LLM Prompts\nOld copy\n", "syncdate": synced - 60})
+	writeSncliNote(t, dir, "gone.json", map[string]any{"content": "Deleted\n", "syncdate": synced, "deleted": true})
+	writeSncliNote(t, dir, "Keyed.json", map[string]any{"content": "# Team Prompts\n", "syncdate": synced})
+	writeSncliNote(t, dir, "stale.json", map[string]any{"content": "Stale\n", "syncdate": float64(now.Add(-time.Hour).Unix())})
+
+	tests := []struct {
+		name     string
+		note     string
+		maxAge   time.Duration
+		expected string
+		ok       bool
+	}{
+		{name: "by title, newest sync", note: "LLM Prompts", maxAge: 15 * time.Minute, expected: "LLM Prompts\n## Golang\nReview\n", ok: true},
+		{name: "by key", note: "Keyed", maxAge: 15 * time.Minute, expected: "# Team Prompts\n", ok: true},
+		{name: "stale", note: "Stale", maxAge: 15 * time.Minute},
+		{name: "stale without max age", note: "Stale", expected: "Stale\n", ok: true},
+		{name: "deleted", note: "Deleted", maxAge: 15 * time.Minute},
+		{name: "missing", note: "Nope", maxAge: 15 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := config.Config{SNNote: tt.note, SNDBPath: dir, SNLocalMaxAge: tt.maxAge}
+			content, ok := loadFromSncliDB(conf, now)
+			if ok != tt.ok || content != tt.expected {
+				t.Errorf("loadFromSncliDB() = %q, %v, want %q, %v", content, ok, tt.expected, tt.ok)
+			}
+		})
+	}
+}
+
+func TestLoadNoteForSearch(t *testing.T) {
+	dir := t.TempDir()
+	writeSncliNote(t, dir, "note.json", map[string]any{"content": "LLM Prompts\nlocal\n", "syncdate": float64(time.Now().Unix())})
+	fakeSimplenote(t, "LLM Prompts\nremote\n")
+
+	conf := config.Config{SNNote: "LLM Prompts", SNDBPath: dir, SNLocalMaxAge: time.Hour}
+	if content, _ := loadNoteForSearch(conf); content != "LLM Prompts\nremote\n" {
+		t.Errorf("expected the remote note without SN_LOCAL_DB, got %q", content)
+	}
+	conf.SNLocalDB = true
+	if content, _ := loadNoteForSearch(conf); content != "LLM Prompts\nlocal\n" {
+		t.Errorf("expected the local note with SN_LOCAL_DB, got %q", content)
+	}
+	conf.SNNote = "Other"
+	if content, _ := loadNoteForSearch(conf); content != "LLM Prompts\nremote\n" {
+		t.Errorf("expected a fallback to Simplenote for a missing note, got %q", content)
+	}
+}
+
+func TestPickExportedNote(t *testing.T) {
+	output := `[
+		{"key": "k1", "content": "# LLM Prompts\n## Golang\n", "tags": ["work"], "systemTags": ["markdown"]},
+		{"key": "k2", "content": "Other\n", "deleted": true},
+		{"key": "k3", "content": "Twice\n"},
+		{"key": "k4", "content": "Twice\n"},
+		{"key": "LLM Prompts", "content": "Renamed\n", "systemTags": ["pinned"]}
+	]`
+
+	tests := []struct {
+		name    string
+		note    string
+		output  string
+		wantKey string
+		ok      bool
+	}{
+		{name: "key before title", note: "LLM Prompts", output: output, wantKey: "LLM Prompts", ok: true},
+		{name: "by key", note: "k1", output: output, wantKey: "k1", ok: true},
+		{name: "by heading title", note: "LLM Prompts", output: `[{"key": "k1", "content": "# LLM Prompts\n"}]`, wantKey: "k1", ok: true},
+		{name: "deleted", note: "Other", output: output},
+		{name: "ambiguous title", note: "Twice", output: output},
+		{name: "missing", note: "Nope", output: output},
+		{name: "not JSON", note: "k1", output: "LLM Prompts\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			note, ok := pickExportedNote([]byte(tt.output), tt.note)
+			if ok != tt.ok || note.Key != tt.wantKey {
+				t.Errorf("pickExportedNote() = %q, %v, want %q, %v", note.Key, ok, tt.wantKey, tt.ok)
+			}
+		})
+	}
+}
+
+func TestSimplenoteImportNote(t *testing.T) {
+	now := time.Unix(1_800_000_000, 0)
+	conf := config.Config{SNNote: "LLM Prompts"}
+
+	tests := []struct {
+		name     string
+		existing sncliNote
+		expected map[string]any
+	}{
+		{
+			name:     "unknown note",
+			expected: map[string]any{"key": "LLM Prompts", "tags": []any{}, "systemTags": []any{}, "creationDate": float64(now.Unix()), "version": float64(1)},
+		},
+		{
+			name: "existing note keeps tags and flags",
+			existing: sncliNote{
+				Key: "abc123", Tags: []string{"work", "llm"}, SystemTags: []string{"markdown", "pinned"},
+				CreationDate: 1_700_000_000.5, Version: 42,
+			},
+			expected: map[string]any{
+				"key": "abc123", "tags": []any{"work", "llm"}, "systemTags": []any{"markdown", "pinned"},
+				"creationDate": 1_700_000_000.5, "version": float64(42),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(simplenoteImportNote(conf, "# LLM Prompts\n", tt.existing, now))
+			if err != nil {
+				t.Fatalf("json.Marshal() error = %v", err)
+			}
+			var got map[string]any
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatalf("json.Unmarshal() error = %v", err)
+			}
+			for field, want := range tt.expected {
+				if !reflect.DeepEqual(got[field], want) {
+					t.Errorf("%s = %#v, want %#v", field, got[field], want)
+				}
+			}
+			if got["content"] != "# LLM Prompts\n" || got["modificationDate"] != float64(now.Unix()) {
+				t.Errorf("content or modificationDate not set: %v", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package prompt
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// Sort orders for search results and listings, see SortPrompts.
+const (
+	SortRelevance = "relevance" // Best match first, or library order without a query
+	SortAlpha     = "alpha"     // By content, ignoring case
+	SortSection   = "section"   // By section, then alphabetically within it
+	SortLength    = "length"    // Shortest first
+	SortRecent    = "recent"    // Most recently added first
+)
+
+// SortOrders lists the sort orders in the order the TUI cycles through them.
+var SortOrders = []string{SortRelevance, SortAlpha, SortSection, SortLength, SortRecent}
+
+// SortPrompts returns a copy of prompts in the given order, leaving prompts untouched.
+// SortRelevance, or an empty order, keeps the order prompts are in. SortRecent uses
+// added, listing prompts whose time is unknown last in their current order; the other
+// orders ignore it.
+func SortPrompts(prompts []Prompt, order string, added map[string]time.Time) []Prompt {
+	sorted := append([]Prompt(nil), prompts...)
+	var less func(a, b Prompt) bool
+	switch order {
+	case SortAlpha:
+		less = func(a, b Prompt) bool {
+			return strings.ToLower(a.Content) < strings.ToLower(b.Content)
+		}
+	case SortSection:
+		less = func(a, b Prompt) bool {
+			if sa, sb := strings.ToLower(a.Section), strings.ToLower(b.Section); sa != sb {
+				return sa < sb
+			}
+			return strings.ToLower(a.Content) < strings.ToLower(b.Content)
+		}
+	case SortLength:
+		less = func(a, b Prompt) bool {
+			return len(a.Content) < len(b.Content)
+		}
+	case SortRecent:
+		less = func(a, b Prompt) bool {
+			return added[a.Content].After(added[b.Content])
+		}
+	default:
+		return sorted
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return less(sorted[i], sorted[j])
+	})
+	return sorted
+}
+
+// NextSortOrder returns the sort order after order in SortOrders, wrapping around.
+func NextSortOrder(order string) string {
+	for i, o := range SortOrders {
+		if o == order {
+			return SortOrders[(i+1)%len(SortOrders)]
+		}
+	}
+	return SortOrders[1]
+}
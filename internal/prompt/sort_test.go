@@ -0,0 +1,133 @@
+package prompt
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+func TestMain(m *testing.M) {
+	// Keep tests that add prompts from writing to the real data directory
+	recordAddedFunc = func(config.Config, string, ...string) error { return nil }
+	os.Exit(m.Run())
+}
+
+func TestSortPrompts(t *testing.T) {
+	base := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+	prompts := []Prompt{
+		{Content: "write pytest tests", Section: "Python"},
+		{Content: "Review this code", Section: "Golang"},
+		{Content: "Add docs", Section: "Python"},
+		{Content: "benchmark it", Section: "Golang"},
+	}
+	added := map[string]time.Time{
+		"Review this code": base,
+		"Add docs":         base.Add(time.Hour),
+	}
+
+	tests := []struct {
+		order    string
+		expected []string
+	}{
+		{order: SortRelevance, expected: []string{"write pytest tests", "Review this code", "Add docs", "benchmark it"}},
+		{order: "", expected: []string{"write pytest tests", "Review this code", "Add docs", "benchmark it"}},
+		{order: SortAlpha, expected: []string{"Add docs", "benchmark it", "Review this code", "write pytest tests"}},
+		{order: SortSection, expected: []string{"benchmark it", "Review this code", "Add docs", "write pytest tests"}},
+		{order: SortLength, expected: []string{"Add docs", "benchmark it", "Review this code", "write pytest tests"}},
+		{order: SortRecent, expected: []string{"Add docs", "Review this code", "write pytest tests", "benchmark it"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.order, func(t *testing.T) {
+			sorted := SortPrompts(prompts, tt.order, added)
+			var got []string
+			for _, p := range sorted {
+				got = append(got, p.Content)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("SortPrompts(%q) = %v, want %v", tt.order, got, tt.expected)
+			}
+			if prompts[0].Content != "write pytest tests" {
+				t.Errorf("SortPrompts(%q) modified its input", tt.order)
+			}
+		})
+	}
+}
+
+func TestNextSortOrder(t *testing.T) {
+	tests := []struct {
+		order    string
+		expected string
+	}{
+		{order: "", expected: SortAlpha},
+		{order: SortRelevance, expected: SortAlpha},
+		{order: SortLength, expected: SortRecent},
+		{order: SortRecent, expected: SortRelevance},
+	}
+
+	for _, tt := range tests {
+		if got := NextSortOrder(tt.order); got != tt.expected {
+			t.Errorf("NextSortOrder(%q) = %q, want %q", tt.order, got, tt.expected)
+		}
+	}
+}
+
+func TestAddPrompt_RecordsAdded(t *testing.T) {
+	useMemFS(t)
+	original := recordAddedFunc
+	defer func() { recordAddedFunc = original }()
+	var section string
+	var recorded []string
+	recordAddedFunc = func(_ config.Config, s string, prompts ...string) error {
+		section, recorded = s, prompts
+		return nil
+	}
+
+	conf := config.Config{FilePath: "/prompts.md"}
+	if err := AddPrompt(conf, "Leaks", "Explain goroutine leaks\n\nin this code", "Golang"); err != nil {
+		t.Fatalf("AddPrompt() error = %v", err)
+	}
+	if section != "Golang" || !reflect.DeepEqual(recorded, []string{"Explain goroutine leaks", "in this code"}) {
+		t.Errorf("recorded %v in section %q, want each prompt line in Golang", recorded, section)
+	}
+}
+
+func TestAppendAddedLogAndAddedTimes(t *testing.T) {
+	useMemFS(t)
+	conf := config.Config{DataDir: "/data"}
+
+	originalNow := addedNow
+	defer func() { addedNow = originalNow }()
+	base := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+
+	times, err := AddedTimes(conf)
+	if err != nil {
+		t.Fatalf("AddedTimes() error = %v", err)
+	}
+	if len(times) != 0 {
+		t.Fatalf("expected no times without an added-at log, got %v", times)
+	}
+
+	addedNow = func() time.Time { return base }
+	if err := appendAddedLog(conf, "Golang", "Review this code", "Write tests"); err != nil {
+		t.Fatalf("appendAddedLog() error = %v", err)
+	}
+	addedNow = func() time.Time { return base.Add(time.Hour) }
+	if err := appendAddedLog(conf, "Golang", "Write tests"); err != nil {
+		t.Fatalf("appendAddedLog() error = %v", err)
+	}
+
+	times, err = AddedTimes(conf)
+	if err != nil {
+		t.Fatalf("AddedTimes() error = %v", err)
+	}
+	if len(times) != 2 || !times["Review this code"].Equal(base) {
+		t.Errorf("AddedTimes() = %v, want 'Review this code' added at %v", times, base)
+	}
+	if !times["Write tests"].Equal(base.Add(time.Hour)) {
+		t.Errorf("AddedTimes() = %v, want the latest time for a re-added prompt", times)
+	}
+}
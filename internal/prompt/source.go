@@ -0,0 +1,152 @@
+package prompt
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+
+	"github.com/toozej/wheresmyprompt/internal/sandbox"
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+// Source is a prompt library backend other than a local file or a Simplenote note.
+// Like a Simplenote note, it holds the whole library as one Markdown document that
+// is loaded, parsed and searched, and replaced as a whole by every write.
+type Source interface {
+	// Name describes the source in messages, such as "joplin (plugin)".
+	Name() string
+	// Check reports a missing executable or setting before the source is used.
+	Check() error
+	// Load returns the library's Markdown.
+	Load() (string, error)
+	// Save replaces the library's Markdown with content.
+	Save(content string) error
+}
+
+// readOnlySource is implemented by Sources that can only be read, whose Save always
+// fails with ErrReadOnly.
+type readOnlySource interface {
+	readOnly()
+}
+
+// customSourceFunc allows tests to replace the Source selected by the configuration.
+var customSourceFunc = customSource
+
+// customSource returns the Source selected by conf, if any. FILEPATH (and --load)
+// takes precedence over every other source, then SOURCE, APPLE_NOTE, Joplin,
+// STANDARD_NOTES_BACKUP and GOOGLE_DOC, and SN_NOTE is used only when none of them
+// is set.
+func customSource(conf config.Config) (Source, bool) {
+	if conf.FilePath != "" {
+		return nil, false
+	}
+	if conf.Source != "" {
+		return pluginSource{name: conf.Source}, true
+	}
+	if conf.AppleNote != "" {
+		return appleNotesSource{note: conf.AppleNote}, true
+	}
+	if conf.UsesJoplin() {
+		return newJoplinSource(conf), true
+	}
+	if conf.StandardNotesBackup != "" {
+		return standardNotesSource{path: conf.StandardNotesBackup, note: conf.StandardNotesNote}, true
+	}
+	if conf.GoogleDoc != "" {
+		return googleDocSource{conf: conf}, true
+	}
+	return nil, false
+}
+
+// withoutCustomSource returns conf with every setting selecting a Source cleared,
+// so it reads the local file or Simplenote note it names.
+func withoutCustomSource(conf config.Config) config.Config {
+	conf.Source, conf.AppleNote, conf.JoplinNotebook, conf.JoplinTag, conf.StandardNotesBackup, conf.GoogleDoc = "", "", "", "", "", ""
+	return conf
+}
+
+// isReadOnlySource reports whether the library is read from a read-only Source.
+func isReadOnlySource(conf config.Config) bool {
+	src, ok := customSourceFunc(conf)
+	if !ok {
+		return false
+	}
+	_, ok = src.(readOnlySource)
+	return ok
+}
+
+// isCustomSource reports whether the library is read from a Source rather than
+// the local file or Simplenote note.
+func isCustomSource(conf config.Config) bool {
+	_, ok := customSourceFunc(conf)
+	return ok
+}
+
+// pluginPrefix is the name prefix of source plugin executables.
+const pluginPrefix = "wheresmyprompt-source-"
+
+// Subcommands of the source plugin protocol.
+const (
+	pluginLoad  = "load"
+	pluginWrite = "write"
+)
+
+// pluginDocument is the JSON exchanged with source plugins: printed by "load" on
+// stdout and read by "write" on stdin.
+type pluginDocument struct {
+	Content string `json:"content"`
+}
+
+// pluginCommandFunc allows tests to run a fake plugin instead of the executable.
+var pluginCommandFunc = pluginCommand
+
+// pluginCommand returns the command running subcommand of the named plugin.
+func pluginCommand(name, subcommand string) *exec.Cmd {
+	return sandbox.Command(pluginPrefix+name, subcommand) // #nosec G204 -- the plugin is chosen by SOURCE
+}
+
+// pluginSource is a Source served by an external executable named
+// "wheresmyprompt-source-<name>" on the PATH, selected with SOURCE=<name>. It
+// supports two subcommands speaking JSON over stdio: "load" prints
+// {"content": "<Markdown>"} and "write" reads the same document and saves it.
+// A non-zero exit status fails the operation, with stderr as the reason.
+type pluginSource struct {
+	name string
+}
+
+func (s pluginSource) Name() string {
+	return s.name + " (plugin)"
+}
+
+func (s pluginSource) Check() error {
+	if _, err := sandbox.LookPath(pluginPrefix + s.name); err != nil {
+		return fmt.Errorf("source plugin %s%s not found: %w", pluginPrefix, s.name, err)
+	}
+	return nil
+}
+
+func (s pluginSource) Load() (string, error) {
+	out, err := pluginCommandFunc(s.name, pluginLoad).Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to load prompts from source plugin %s: %w", s.name, commandError(err))
+	}
+	var doc pluginDocument
+	if err := json.Unmarshal(out, &doc); err != nil {
+		return "", fmt.Errorf("failed to parse the output of source plugin %s: %w", s.name, err)
+	}
+	return normalizeText(doc.Content), nil
+}
+
+func (s pluginSource) Save(content string) error {
+	in, err := json.Marshal(pluginDocument{Content: content})
+	if err != nil {
+		return fmt.Errorf("failed to marshal prompts for source plugin %s: %w", s.name, err)
+	}
+	cmd := pluginCommandFunc(s.name, pluginWrite)
+	cmd.Stdin = bytes.NewReader(in)
+	if _, err := cmd.Output(); err != nil {
+		return fmt.Errorf("failed to write prompts to source plugin %s: %w", s.name, commandError(err))
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package prompt
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+// fakePlugin installs a "wheresmyprompt-source-fake" plugin on the PATH that keeps
+// its document in a file, starting with content, and returns that file's path.
+func fakePlugin(t *testing.T, content string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake plugin is a shell script")
+	}
+	dir := t.TempDir()
+	doc := filepath.Join(dir, "doc.json")
+	script := "#!/bin/sh\ncase \"$1\" in\nload) cat '" + doc + "' ;;\nwrite) cat > '" + doc + "' ;;\n*) echo \"unknown command $1\" >&2; exit 2 ;;\nesac\n"
+	if err := os.WriteFile(filepath.Join(dir, pluginPrefix+"fake"), []byte(script), 0700); err != nil { // #nosec G306
+		t.Fatal(err)
+	}
+	data, _ := json.Marshal(pluginDocument{Content: content})
+	if err := os.WriteFile(doc, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return doc
+}
+
+func TestPluginSource(t *testing.T) {
+	doc := fakePlugin(t, "# Prompts\r\n\r\n## Golang\r\n\r\n### Review\r\nReview this Go code.\r\n")
+	conf := config.Config{Source: "fake", SNNote: "LLM Prompts", OnConflict: ConflictAbort}
+
+	if err := CheckRequiredBinaries(conf); err != nil {
+		t.Fatalf("CheckRequiredBinaries() error = %v", err)
+	}
+	data, err := LoadPrompts(conf)
+	if err != nil {
+		t.Fatalf("LoadPrompts() error = %v", err)
+	}
+	if results := SearchPrompts(data, "review", "Golang"); len(results) != 1 || results[0] != "Review this Go code." {
+		t.Errorf("SearchPrompts() = %q, want the plugin's prompt", results)
+	}
+	if data.Source != "fake (plugin)" {
+		t.Errorf("Source = %q, want %q", data.Source, "fake (plugin)")
+	}
+
+	if err := AddPrompt(conf, "Tests", "Write table-driven tests.", "Golang"); err != nil {
+		t.Fatalf("AddPrompt() error = %v", err)
+	}
+	raw, _ := os.ReadFile(doc) // #nosec G304
+	var saved pluginDocument
+	if err := json.Unmarshal(raw, &saved); err != nil {
+		t.Fatalf("the plugin received invalid JSON %q: %v", raw, err)
+	}
+	if !strings.Contains(saved.Content, "### Review\nReview this Go code.\n") || !strings.Contains(saved.Content, "### Tests\nWrite table-driven tests.\n") {
+		t.Errorf("expected the added prompt in the written document, got:\n%s", saved.Content)
+	}
+}
+
+func TestPluginSource_Errors(t *testing.T) {
+	fakePlugin(t, "")
+
+	if err := (pluginSource{name: "missing"}).Check(); err == nil {
+		t.Error("expected Check() to fail for a plugin that is not installed")
+	}
+	if _, err := (pluginSource{name: "missing"}).Load(); err == nil {
+		t.Error("expected Load() to fail for a plugin that is not installed")
+	}
+
+	// A plugin failing with an error message
+	original := pluginCommandFunc
+	defer func() { pluginCommandFunc = original }()
+	pluginCommandFunc = func(name, _ string) *exec.Cmd { return pluginCommand(name, "bogus") }
+	_, err := (pluginSource{name: "fake"}).Load()
+	if err == nil || !strings.Contains(err.Error(), "unknown command bogus") {
+		t.Errorf("Load() error = %v, want the plugin's error output", err)
+	}
+}
@@ -0,0 +1,106 @@
+package prompt
+
+import (
+	"errors"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+// lineEdit replaces the lines [start, end) of a document, counted from 0 in its
+// LF-normalized content, with lines. An empty range inserts lines before start.
+type lineEdit struct {
+	start, end int
+	lines      []string
+}
+
+// apply returns LF-normalized content with e applied.
+func (e lineEdit) apply(content string) string {
+	lines := strings.Split(content, "\n")
+	out := append(slices.Clone(lines[:e.start]), e.lines...)
+	return strings.Join(append(out, lines[e.end:]...), "\n")
+}
+
+// splice returns raw, a document as stored with its own line endings and BOM, with
+// e applied. Only the edited lines change; every other byte of raw is kept, even in
+// files mixing line endings or missing the final newline. New lines end like the
+// line before them.
+func (e lineEdit) splice(raw string) string {
+	raw, bom := cutBOM(raw)
+
+	// Line i of raw is lines[i] followed by endings[i], the last line by nothing
+	var lines, endings []string
+	for _, part := range strings.SplitAfter(raw, "\n") {
+		text := strings.TrimSuffix(part, "\n")
+		ending := part[len(text):]
+		if ending != "" && strings.HasSuffix(text, "\r") {
+			text, ending = text[:len(text)-1], "\r\n"
+		}
+		lines, endings = append(lines, text), append(endings, ending)
+	}
+	newline := nearestEnding(endings, e.start)
+
+	// The text of each line of the result, with the line ending following it
+	type line struct{ text, ending string }
+	var out []line
+	for i := range e.start {
+		out = append(out, line{lines[i], endings[i]})
+	}
+	for i, text := range e.lines {
+		ending := newline
+		if i == len(e.lines)-1 && e.end > e.start && endings[e.end-1] != "" {
+			ending = endings[e.end-1]
+		}
+		out = append(out, line{text, ending})
+	}
+	for i := e.end; i < len(lines); i++ {
+		out = append(out, line{lines[i], endings[i]})
+	}
+
+	var b strings.Builder
+	if bom {
+		b.WriteString(utf8BOM)
+	}
+	for i, l := range out {
+		b.WriteString(l.text)
+		if i < len(out)-1 {
+			if l.ending == "" {
+				l.ending = newline
+			}
+			b.WriteString(l.ending)
+		}
+	}
+	return b.String()
+}
+
+// nearestEnding returns the line ending of the last line before line i having one,
+// or of the first line after it, or "\n" for a single line.
+func nearestEnding(endings []string, i int) string {
+	for j := i - 1; j >= 0; j-- {
+		if endings[j] != "" {
+			return endings[j]
+		}
+	}
+	for _, ending := range endings[i:] {
+		if ending != "" {
+			return ending
+		}
+	}
+	return "\n"
+}
+
+// spliceLocalFile applies edits, in order and each to the result of the previous
+// one, to the file at path, which is created when missing, see lineEdit.splice.
+func spliceLocalFile(path string, edits ...lineEdit) error {
+	data, err := afero.ReadFile(appFS, path) // #nosec G304
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	raw := string(data)
+	for _, e := range edits {
+		raw = e.splice(raw)
+	}
+	return afero.WriteFile(appFS, path, []byte(raw), 0600)
+}
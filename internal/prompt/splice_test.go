@@ -0,0 +1,101 @@
+package prompt
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+func TestLineEdit(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      string
+		edit     lineEdit
+		expected string
+	}{
+		{
+			name:     "replace a line",
+			raw:      "a\nb\nc\n",
+			edit:     lineEdit{start: 1, end: 2, lines: []string{"B"}},
+			expected: "a\nB\nc\n",
+		},
+		{
+			name:     "insert keeps CRLF",
+			raw:      "a\r\nb\r\n",
+			edit:     lineEdit{start: 1, end: 1, lines: []string{"x", "y"}},
+			expected: "a\r\nx\r\ny\r\nb\r\n",
+		},
+		{
+			name:     "remove lines",
+			raw:      "a\r\nb\nc\r\nd",
+			edit:     lineEdit{start: 1, end: 3},
+			expected: "a\r\nd",
+		},
+		{
+			name:     "replace the last line without newline",
+			raw:      "a\nb",
+			edit:     lineEdit{start: 1, end: 2, lines: []string{"B", "C"}},
+			expected: "a\nB\nC",
+		},
+		{
+			name:     "append to the last line without newline",
+			raw:      "a\r\nb",
+			edit:     lineEdit{start: 2, end: 2, lines: []string{"c", ""}},
+			expected: "a\r\nb\r\nc\r\n",
+		},
+		{
+			name:     "replace the first line keeps the BOM",
+			raw:      utf8BOM + "# Title\r\nbody\r\n",
+			edit:     lineEdit{start: 0, end: 1, lines: []string{"# Prompts"}},
+			expected: utf8BOM + "# Prompts\r\nbody\r\n",
+		},
+		{
+			name:     "insert into an empty document",
+			raw:      "",
+			edit:     lineEdit{start: 0, end: 0, lines: []string{"a"}},
+			expected: "a\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.edit.splice(tt.raw); got != tt.expected {
+				t.Errorf("splice() = %q, want %q", got, tt.expected)
+			}
+			if got, expected := tt.edit.apply(normalizeText(tt.raw)), normalizeText(tt.expected); got != expected {
+				t.Errorf("apply() = %q, want %q", got, expected)
+			}
+		})
+	}
+}
+
+func TestArchivePrompt_ParsedLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prompts.md")
+	content := "# Prompts\r\n\r\n## Golang\r\n\r\nWrite tests\r\n\r\n## Python\r\n\r\nWrite tests\r\nExplain\r\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	conf := config.Config{FilePath: path, ArchiveSection: "Archive"}
+
+	data, err := LoadPrompts(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var python Prompt
+	for _, p := range SearchPromptRecords(data, "Write tests", "Python") {
+		python = p
+	}
+	if python.StartLine != 9 {
+		t.Fatalf("expected the Python prompt on line 9, got %+v", python)
+	}
+	if err := ArchivePrompt(conf, python); err != nil {
+		t.Fatalf("ArchivePrompt() returned error: %v", err)
+	}
+
+	written, _ := os.ReadFile(path)
+	expected := "# Prompts\r\n\r\n## Golang\r\n\r\nWrite tests\r\n\r\n## Python\r\n\r\nExplain\r\n\r\n\r\n## Archive\r\n\r\n### Python\r\nWrite tests\r\n"
+	if string(written) != expected {
+		t.Errorf("file content = %q, want %q", written, expected)
+	}
+}
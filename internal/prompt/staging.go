@@ -0,0 +1,189 @@
+package prompt
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+// stagedTitleRe extracts the title and target section from a staged prompt heading.
+var stagedTitleRe = regexp.MustCompile(`^(.*?) \(for: (.*)\)$`)
+
+// StagedPrompt is a prompt waiting in the staging section for a maintainer's review.
+type StagedPrompt struct {
+	Title   string // Title of the prompt once accepted
+	Target  string // Section the prompt will be moved to when accepted (may be empty)
+	Content string // The prompt content
+	heading string // Raw heading text as stored in the note
+}
+
+// stagedTitle encodes the target section into the heading of a staged prompt
+// so it can be restored when the prompt is accepted.
+func stagedTitle(title, target string) string {
+	if target == "" {
+		return title
+	}
+	return fmt.Sprintf("%s (for: %s)", title, target)
+}
+
+// parseStagedTitle splits a staged prompt heading into its title and target section.
+func parseStagedTitle(heading string) (string, string) {
+	if m := stagedTitleRe.FindStringSubmatch(heading); len(m) == 3 {
+		return m[1], m[2]
+	}
+	return heading, ""
+}
+
+// ListStaged returns all prompts currently waiting in the staging section.
+func ListStaged(conf config.Config) ([]StagedPrompt, error) {
+	content, err := loadSourceContent(conf)
+	if err != nil {
+		return nil, err
+	}
+	return parseStaged(content, conf.StagingSection), nil
+}
+
+// parseStaged finds the "### " prompts below the "## <stagingSection>" heading of content.
+func parseStaged(content, stagingSection string) []StagedPrompt {
+	var staged []StagedPrompt
+	var current *StagedPrompt
+	var body []string
+	inStaging := false
+
+	flush := func() {
+		if current != nil {
+			current.Content = strings.TrimSpace(strings.Join(body, "\n"))
+			staged = append(staged, *current)
+		}
+		current = nil
+		body = nil
+	}
+
+	for _, line := range strings.Split(content, "\n") {
+		level, text := parseHeading(line)
+		switch {
+		case level > 0 && level <= 2:
+			flush()
+			inStaging = level == 2 && headingName(text) == stagingSection
+		case level == 3 && inStaging:
+			flush()
+			title, target := parseStagedTitle(text)
+			current = &StagedPrompt{Title: title, Target: target, heading: text}
+		case current != nil:
+			body = append(body, line)
+		}
+	}
+	flush()
+
+	return staged
+}
+
+// removeStaged returns content without the heading block of the given staged prompt.
+// The boolean result is false if the staged prompt could not be found.
+func removeStaged(content, stagingSection string, sp StagedPrompt) (string, bool) {
+	return removeSubsection(content, stagingSection, sp.heading)
+}
+
+// removeSubsection returns content without the "### <heading>" block below the
+// "## <section>" heading. The boolean result is false if the block could not be found.
+func removeSubsection(content, section, heading string) (string, bool) {
+	lines := strings.Split(content, "\n")
+	inSection := false
+	start, end := -1, len(lines)
+
+	for i, line := range lines {
+		level, text := parseHeading(line)
+		if level == 0 {
+			continue
+		}
+		if start >= 0 && level <= 3 {
+			end = i
+			break
+		}
+		if level <= 2 {
+			inSection = level == 2 && headingName(text) == section
+			continue
+		}
+		if level == 3 && inSection && text == heading {
+			start = i
+		}
+	}
+	if start < 0 {
+		return content, false
+	}
+
+	// Drop the blank line separating the removed block from the previous one
+	if start > 0 && strings.TrimSpace(lines[start-1]) == "" {
+		start--
+	}
+	remaining := append(lines[:start:start], lines[end:]...)
+	return strings.Join(remaining, "\n"), true
+}
+
+// stagePrompt adds a prompt to the staging section instead of its target section,
+// recording the target in the heading so AcceptStaged can move it later.
+func stagePrompt(conf config.Config, title, content, section string) error {
+	err := updateSourceContent(conf, writeOp{action: "stage", title: title, section: section}, func(current string) (string, error) {
+		return insertPrompt(current, stagedTitle(title, section), content, conf.StagingSection), nil
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Staged prompt '%s' in section '%s' for review\n", title, conf.StagingSection)
+	return nil
+}
+
+// AcceptStaged moves a staged prompt from the staging section into its target section.
+// Returns an error if the prompt no longer exists or the source cannot be updated.
+func AcceptStaged(conf config.Config, sp StagedPrompt) error {
+	if _, ok := routeTarget(conf, sp.Target); ok {
+		return acceptRoutedStaged(conf, sp)
+	}
+	p := Prompt{Content: sp.Content, Section: sp.Target, Title: sp.Title}
+	if err := RunHook(conf, HookPreWrite, p); err != nil {
+		return err
+	}
+	err := updateSourceContent(conf, writeOp{action: "accept", title: sp.Title, section: sp.Target}, func(current string) (string, error) {
+		updated, ok := removeStaged(current, conf.StagingSection, sp)
+		if !ok {
+			return "", fmt.Errorf("staged prompt '%s' not found in section '%s'", sp.Title, conf.StagingSection)
+		}
+		return insertPrompt(updated, sp.Title, sp.Content, sp.Target), nil
+	})
+	if err != nil {
+		return err
+	}
+	recordAdded(conf, sp.Target, sp.Content)
+	return RunHook(conf, HookPostWrite, p)
+}
+
+// acceptRoutedStaged accepts a staged prompt whose target section is routed by
+// WRITE_ROUTES: the prompt is added to the routed source, then removed from staging.
+func acceptRoutedStaged(conf config.Config, sp StagedPrompt) error {
+	current, err := loadSourceContent(conf)
+	if err != nil {
+		return err
+	}
+	if _, ok := removeStaged(current, conf.StagingSection, sp); !ok {
+		return fmt.Errorf("staged prompt '%s' not found in section '%s'", sp.Title, conf.StagingSection)
+	}
+	if err := addPromptToNote(conf, sp.Title, sp.Content, sp.Target, os.Stdin, os.Stdout); err != nil {
+		return err
+	}
+	return RejectStaged(conf, sp)
+}
+
+// RejectStaged removes a staged prompt from the staging section without publishing it.
+// Returns an error if the prompt no longer exists or the source cannot be updated.
+func RejectStaged(conf config.Config, sp StagedPrompt) error {
+	return updateSourceContent(conf, writeOp{action: "reject", title: sp.Title, section: conf.StagingSection}, func(current string) (string, error) {
+		updated, ok := removeStaged(current, conf.StagingSection, sp)
+		if !ok {
+			return "", fmt.Errorf("staged prompt '%s' not found in section '%s'", sp.Title, conf.StagingSection)
+		}
+		return updated, nil
+	})
+}
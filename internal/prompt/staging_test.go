@@ -0,0 +1,92 @@
+package prompt
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+const testStagingContent = `# LLM Prompts
+
+## Golang
+
+### Code Review
+Review this Go code.
+
+## Inbox
+
+### Unit Tests (for: Golang)
+Write table-driven unit tests.
+
+### Haiku
+Write a haiku about code.
+`
+
+func TestParseStaged(t *testing.T) {
+	staged := parseStaged(testStagingContent, "Inbox")
+	if len(staged) != 2 {
+		t.Fatalf("expected 2 staged prompts, got %d", len(staged))
+	}
+
+	expected := []StagedPrompt{
+		{Title: "Unit Tests", Target: "Golang", Content: "Write table-driven unit tests."},
+		{Title: "Haiku", Target: "", Content: "Write a haiku about code."},
+	}
+	for i, e := range expected {
+		if staged[i].Title != e.Title || staged[i].Target != e.Target || staged[i].Content != e.Content {
+			t.Errorf("staged[%d] = %+v, expected %+v", i, staged[i], e)
+		}
+	}
+}
+
+func TestStagingWorkflow(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prompts.md")
+	if err := os.WriteFile(path, []byte(testStagingContent), 0600); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+	conf := config.Config{FilePath: path, Staging: true, StagingSection: "Inbox"}
+
+	if err := WritePrompt(conf, "Explain goroutine leaks", []string{"Explain goroutine leaks", "Golang"}); err != nil {
+		t.Fatalf("WritePrompt() returned error: %v", err)
+	}
+
+	staged, err := ListStaged(conf)
+	if err != nil {
+		t.Fatalf("ListStaged() returned error: %v", err)
+	}
+	if len(staged) != 3 {
+		t.Fatalf("expected 3 staged prompts, got %d", len(staged))
+	}
+
+	if err := AcceptStaged(conf, staged[0]); err != nil {
+		t.Fatalf("AcceptStaged() returned error: %v", err)
+	}
+	if err := RejectStaged(conf, staged[1]); err != nil {
+		t.Fatalf("RejectStaged() returned error: %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	content := string(data)
+
+	remaining := parseStaged(content, "Inbox")
+	if len(remaining) != 1 || remaining[0].Title != "Explain goroutine leaks" {
+		t.Errorf("unexpected remaining staged prompts: %+v", remaining)
+	}
+	if strings.Contains(content, "Haiku") {
+		t.Error("rejected prompt should have been removed")
+	}
+
+	golang := strings.Index(content, "## Golang")
+	inbox := strings.Index(content, "## Inbox")
+	accepted := strings.Index(content, "### Unit Tests\n")
+	if accepted < golang || accepted > inbox {
+		t.Errorf("accepted prompt should be in the Golang section:\n%s", content)
+	}
+
+	if err := RejectStaged(conf, staged[1]); err == nil {
+		t.Error("expected error when rejecting a prompt that no longer exists")
+	}
+}
@@ -0,0 +1,139 @@
+package prompt
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// standardNotesNow allows tests to control the updated_at time of saved notes.
+var standardNotesNow = time.Now
+
+// standardNotesTimeFormat is the timestamp format of Standard Notes backups.
+const standardNotesTimeFormat = "2006-01-02T15:04:05.000Z"
+
+// standardNotesSource is a Source backed by a note in a decrypted Standard Notes
+// backup file, selected with STANDARD_NOTES_BACKUP. The note titled note holds the
+// library in its text. Saving rewrites the file with only that note changed,
+// keeping every other item and field as it was, so the backup can be imported
+// back into Standard Notes.
+type standardNotesSource struct {
+	path string
+	note string
+}
+
+func (s standardNotesSource) Name() string {
+	return s.note + " (Standard Notes)"
+}
+
+func (s standardNotesSource) Check() error {
+	if _, err := appFS.Stat(s.path); err != nil {
+		return fmt.Errorf("cannot find the Standard Notes backup %s: %w", s.path, err)
+	}
+	return nil
+}
+
+func (s standardNotesSource) Load() (string, error) {
+	_, items, i, err := s.read()
+	if err != nil {
+		return "", err
+	}
+	var content struct {
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(items[i]["content"], &content); err != nil {
+		return "", fmt.Errorf("failed to parse note %s in %s: %w", s.note, s.path, err)
+	}
+	return normalizeText(content.Text), nil
+}
+
+func (s standardNotesSource) Save(text string) error {
+	backup, items, i, err := s.read()
+	if err != nil {
+		return err
+	}
+	var content map[string]json.RawMessage
+	if err := json.Unmarshal(items[i]["content"], &content); err != nil {
+		return fmt.Errorf("failed to parse note %s in %s: %w", s.note, s.path, err)
+	}
+	if content["text"], err = json.Marshal(text); err != nil {
+		return fmt.Errorf("failed to marshal note %s: %w", s.note, err)
+	}
+	if items[i]["content"], err = json.Marshal(content); err != nil {
+		return fmt.Errorf("failed to marshal note %s: %w", s.note, err)
+	}
+	updated, _ := json.Marshal(standardNotesNow().UTC().Format(standardNotesTimeFormat))
+	items[i]["updated_at"] = updated
+	if backup["items"], err = json.Marshal(items); err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", s.path, err)
+	}
+	data, err := json.MarshalIndent(backup, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", s.path, err)
+	}
+	return s.write(append(data, '\n'))
+}
+
+// read returns the backup, its items and the index of the note titled s.note,
+// which must be a note that is neither deleted nor trashed.
+func (s standardNotesSource) read() (map[string]json.RawMessage, []map[string]json.RawMessage, int, error) {
+	data, err := afero.ReadFile(appFS, s.path) // #nosec G304
+	if err != nil {
+		return nil, nil, 0, fmt.Errorf("failed to read Standard Notes backup %s: %w", s.path, err)
+	}
+	var backup map[string]json.RawMessage
+	var items []map[string]json.RawMessage
+	if err := json.Unmarshal(data, &backup); err != nil {
+		return nil, nil, 0, fmt.Errorf("failed to parse Standard Notes backup %s: %w", s.path, err)
+	}
+	if err := json.Unmarshal(backup["items"], &items); err != nil {
+		return nil, nil, 0, fmt.Errorf("failed to parse the items of Standard Notes backup %s: %w", s.path, err)
+	}
+
+	for i, item := range items {
+		var contentType string
+		var deleted bool
+		_ = json.Unmarshal(item["content_type"], &contentType)
+		_ = json.Unmarshal(item["deleted"], &deleted)
+		if contentType != "Note" || deleted {
+			continue
+		}
+		var content struct {
+			Title   string `json:"title"`
+			Trashed bool   `json:"trashed"`
+		}
+		if err := json.Unmarshal(item["content"], &content); err != nil {
+			// Encrypted items hold a string such as "004:..." instead of an object
+			return nil, nil, 0, fmt.Errorf("the Standard Notes backup %s is encrypted; export a decrypted backup instead", s.path)
+		}
+		if content.Title == s.note && !content.Trashed {
+			return backup, items, i, nil
+		}
+	}
+	return nil, nil, 0, fmt.Errorf("no note titled %q in Standard Notes backup %s", s.note, s.path)
+}
+
+// write replaces the backup file with data through a temporary file, so a failed
+// write never leaves a truncated backup.
+func (s standardNotesSource) write(data []byte) error {
+	tmp, err := afero.TempFile(appFS, filepath.Dir(s.path), strings.TrimSuffix(filepath.Base(s.path), filepath.Ext(s.path))+"-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write Standard Notes backup %s: %w", s.path, err)
+	}
+	defer appFS.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write Standard Notes backup %s: %w", s.path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write Standard Notes backup %s: %w", s.path, err)
+	}
+	if err := appFS.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to write Standard Notes backup %s: %w", s.path, err)
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package prompt
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+// standardNotesBackup is a decrypted backup holding the prompts note, a trashed
+// note with the same title, a tag and an unknown field that must survive saving.
+const standardNotesBackup = `{
+  "version": "004",
+  "items": [
+    {"uuid": "tag", "content_type": "Tag", "content": {"title": "LLM Prompts", "references": []}},
+    {"uuid": "old", "content_type": "Note", "content": {"title": "LLM Prompts", "text": "## Old\nOld prompt.", "trashed": true}},
+    {"uuid": "notes", "content_type": "Note", "created_at": "2024-01-01T00:00:00.000Z", "updated_at": "2024-01-01T00:00:00.000Z",
+     "content": {"title": "LLM Prompts", "text": "# Prompts\r\n\r\n## Golang\r\n\r\n### Review\r\nReview this Go code.\r\n", "appData": {"org.standardnotes.sn": {"pinned": true}}}}
+  ]
+}`
+
+func TestStandardNotesSource(t *testing.T) {
+	fs := useMemFS(t)
+	if err := afero.WriteFile(fs, "/backup.txt", []byte(standardNotesBackup), 0600); err != nil {
+		t.Fatal(err)
+	}
+	original := standardNotesNow
+	defer func() { standardNotesNow = original }()
+	standardNotesNow = func() time.Time { return time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC) }
+	conf := config.Config{StandardNotesBackup: "/backup.txt", StandardNotesNote: "LLM Prompts", SNNote: "LLM Prompts", OnConflict: ConflictAbort}
+
+	if err := CheckRequiredBinaries(conf); err != nil {
+		t.Fatalf("CheckRequiredBinaries() error = %v", err)
+	}
+	data, err := LoadPrompts(conf)
+	if err != nil {
+		t.Fatalf("LoadPrompts() error = %v", err)
+	}
+	if results := SearchPrompts(data, "review", "Golang"); len(results) != 1 || results[0] != "Review this Go code." {
+		t.Errorf("SearchPrompts() = %q, want the note's prompt", results)
+	}
+
+	if err := AddPrompt(conf, "Tests", "Write table-driven tests.", "Golang"); err != nil {
+		t.Fatalf("AddPrompt() error = %v", err)
+	}
+	raw, _ := afero.ReadFile(fs, "/backup.txt")
+	var saved struct {
+		Version string `json:"version"`
+		Items   []struct {
+			UUID      string `json:"uuid"`
+			UpdatedAt string `json:"updated_at"`
+			Content   struct {
+				Text    string          `json:"text"`
+				AppData json.RawMessage `json:"appData"`
+			} `json:"content"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(raw, &saved); err != nil {
+		t.Fatalf("saved backup is not valid JSON: %v\n%s", err, raw)
+	}
+	if saved.Version != "004" || len(saved.Items) != 3 {
+		t.Fatalf("expected the backup's other fields and items to be kept, got:\n%s", raw)
+	}
+	note := saved.Items[2]
+	if !strings.Contains(note.Content.Text, "### Tests\nWrite table-driven tests.\n") {
+		t.Errorf("expected the added prompt in the note, got:\n%s", note.Content.Text)
+	}
+	if note.UpdatedAt != "2025-06-01T12:00:00.000Z" || !strings.Contains(string(note.Content.AppData), "pinned") {
+		t.Errorf("expected updated_at to be bumped and appData kept, got %q and %s", note.UpdatedAt, note.Content.AppData)
+	}
+	if saved.Items[1].Content.Text != "## Old\nOld prompt." {
+		t.Errorf("expected the trashed note to be left alone, got %q", saved.Items[1].Content.Text)
+	}
+}
+
+func TestStandardNotesSource_Errors(t *testing.T) {
+	fs := useMemFS(t)
+	_ = afero.WriteFile(fs, "/backup.txt", []byte(standardNotesBackup), 0600)
+	_ = afero.WriteFile(fs, "/encrypted.txt", []byte(`{"items": [{"uuid": "a", "content_type": "Note", "content": "004:nonce:ciphertext"}]}`), 0600)
+
+	tests := []struct {
+		name string
+		src  standardNotesSource
+		want string
+	}{
+		{"missing file", standardNotesSource{path: "/missing.txt", note: "LLM Prompts"}, "failed to read"},
+		{"missing note", standardNotesSource{path: "/backup.txt", note: "Other"}, `no note titled "Other"`},
+		{"encrypted backup", standardNotesSource{path: "/encrypted.txt", note: "LLM Prompts"}, "is encrypted"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.src.Load(); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Load() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
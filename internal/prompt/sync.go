@@ -0,0 +1,378 @@
+package prompt
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/afero"
+
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+// Strategies for a section changed differently in the local file and in Simplenote
+// since the last sync.
+const (
+	SyncNewest      = "newest"      // Keep the side that changed most recently
+	SyncLocal       = "local"       // Keep the local file's version
+	SyncRemote      = "remote"      // Keep the Simplenote note's version
+	SyncInteractive = "interactive" // Ask for each conflicting section
+)
+
+// preambleKey identifies the lines before the first heading of a document.
+const preambleKey = "\x00preamble"
+
+// ErrSourceChanged is returned by SyncSources when a source changes while it is being synced.
+var ErrSourceChanged = errors.New("prompt source changed during sync; run sync again")
+
+// askSyncConflictFunc allows tests to answer the interactive sync question.
+var askSyncConflictFunc = askSyncConflict
+
+// SyncConflict is a section changed differently on both sides since the last sync.
+// Local and Remote hold the section's Markdown, "" when that side deleted it.
+type SyncConflict struct {
+	Path   string // The section's headings below the document title, joined with " > "
+	Local  string
+	Remote string
+}
+
+// SyncResult summarizes a sync.
+type SyncResult struct {
+	LocalChanged  bool // The local file was rewritten
+	RemoteChanged bool // The Simplenote note was rewritten
+	Conflicts     int  // Sections changed on both sides
+}
+
+// docBlock is a heading line and the lines up to the next heading, or the lines
+// before the first heading.
+type docBlock struct {
+	key  string // The heading path below the document title, unique within the document
+	text string // The block's Markdown without trailing blank lines
+}
+
+// SyncSources merges the changes made to the local file (FILEPATH) and to the
+// Simplenote note (SN_NOTE) since their last sync and writes the result to both,
+// so prompts can be edited offline and pushed later. Sections are compared with
+// the state saved by the previous sync: a section changed on one side only takes
+// that change, including deletions, and a section changed on both sides is
+// resolved with strategy, one of SyncNewest, SyncLocal, SyncRemote or
+// SyncInteractive. Without a previous sync, sections found on one side only are
+// kept. SyncNewest compares the file's modification time with the note's in
+// sncli's local database.
+func SyncSources(conf config.Config, strategy string) (SyncResult, error) {
+	if conf.FilePath == "" {
+		return SyncResult{}, fmt.Errorf("sync needs a local file: set FILEPATH or use --load")
+	}
+	if isURLSource(conf.FilePath) {
+		return SyncResult{}, fmt.Errorf("%w: %s is a URL source", ErrReadOnly, conf.FilePath)
+	}
+	if err := checkWritable(conf); err != nil {
+		return SyncResult{}, err
+	}
+	noteConf := withoutCustomSource(conf)
+	noteConf.FilePath = ""
+
+	local, err := loadFromFile(conf.FilePath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return SyncResult{}, err
+	}
+	remote, err := loadFromSimplenoteFunc(noteConf)
+	if err != nil {
+		return SyncResult{}, err
+	}
+	basePath, err := syncBasePath(conf)
+	if err != nil {
+		return SyncResult{}, err
+	}
+	base, err := afero.ReadFile(appFS, basePath) // #nosec G304
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return SyncResult{}, fmt.Errorf("failed to read last sync state: %w", err)
+	}
+
+	var result SyncResult
+	resolve := func(c SyncConflict) (bool, error) {
+		result.Conflicts++
+		return resolveSyncConflict(conf, strategy, c)
+	}
+	localBlocks, remoteBlocks := splitBlocks(local), splitBlocks(remote)
+	mergedBlocks, err := mergeBlocks(splitBlocks(string(base)), localBlocks, remoteBlocks, resolve)
+	if err != nil {
+		return result, err
+	}
+	merged := joinBlocks(mergedBlocks)
+
+	if !sameBlocks(mergedBlocks, remoteBlocks) {
+		err := updateSourceContent(noteConf, writeOp{action: "sync"}, func(current string) (string, error) {
+			if current != remote {
+				return "", ErrSourceChanged
+			}
+			return merged, nil
+		})
+		if err != nil {
+			return result, err
+		}
+		result.RemoteChanged = true
+	}
+	if !sameBlocks(mergedBlocks, localBlocks) {
+		err := updateSourceContent(conf, writeOp{action: "sync"}, func(current string) (string, error) {
+			if current != local {
+				return "", ErrSourceChanged
+			}
+			return merged, nil
+		})
+		if err != nil {
+			return result, err
+		}
+		result.LocalChanged = true
+	}
+
+	if err := appFS.MkdirAll(filepath.Dir(basePath), 0700); err != nil {
+		return result, fmt.Errorf("failed to create data directory: %w", err)
+	}
+	if err := afero.WriteFile(appFS, basePath, []byte(merged), 0600); err != nil {
+		return result, fmt.Errorf("failed to save sync state: %w", err)
+	}
+	return result, nil
+}
+
+// syncBasePath returns where the state of the last sync between the configured
+// file and note is kept, in the "sync" directory of the data directory.
+func syncBasePath(conf config.Config) (string, error) {
+	dir, err := config.ResolveDataDir(conf)
+	if err != nil {
+		return "", err
+	}
+	path := conf.FilePath
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	sum := sha256.Sum256([]byte(path + "\x00" + conf.SNNote))
+	return filepath.Join(dir, "sync", hex.EncodeToString(sum[:8])+".md"), nil
+}
+
+// resolveSyncConflict reports whether the local side of c is kept, following strategy.
+func resolveSyncConflict(conf config.Config, strategy string, c SyncConflict) (bool, error) {
+	switch strategy {
+	case SyncLocal:
+		return true, nil
+	case SyncRemote:
+		return false, nil
+	case SyncInteractive:
+		return askSyncConflictFunc(c)
+	case SyncNewest, "":
+		info, err := appFS.Stat(conf.FilePath)
+		if err != nil {
+			// A missing file has nothing newer than the note
+			return false, nil
+		}
+		noteConf := conf
+		noteConf.FilePath = ""
+		modified, ok := sncliModifiedFunc(noteConf)
+		if !ok {
+			return false, fmt.Errorf("cannot tell when note %q last changed because sncli's database has no copy of it; resolve section %q with --strategy local, remote or interactive", conf.SNNote, c.Path)
+		}
+		return info.ModTime().After(modified), nil
+	default:
+		return false, fmt.Errorf("invalid sync strategy %q: must be one of %s, %s, %s or %s",
+			strategy, SyncNewest, SyncLocal, SyncRemote, SyncInteractive)
+	}
+}
+
+// splitBlocks splits content into blocks at every heading outside fenced code blocks.
+func splitBlocks(content string) []docBlock {
+	content = strings.TrimRight(content, "\n")
+	if strings.TrimSpace(content) == "" {
+		return nil
+	}
+
+	var blocks []docBlock
+	var stack, lines []string
+	key := preambleKey
+	seen := make(map[string]int)
+	flush := func() {
+		for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+			lines = lines[:len(lines)-1]
+		}
+		if len(lines) == 0 {
+			return
+		}
+		// Repeated headings get a numbered key so each block is merged on its own
+		seen[key]++
+		unique := key
+		if n := seen[key]; n > 1 {
+			unique = fmt.Sprintf("%s (%d)", key, n)
+		}
+		blocks = append(blocks, docBlock{key: unique, text: strings.Join(lines, "\n")})
+		lines = nil
+	}
+
+	inFence := false
+	for _, line := range strings.Split(content, "\n") {
+		if isFence(line) {
+			inFence = !inFence
+		}
+		if level, text := parseHeading(line); level > 0 && !inFence {
+			flush()
+			name := headingName(text)
+			if len(stack) < level {
+				stack = append(stack, name)
+			} else {
+				stack = append(stack[:level-1], name)
+			}
+			key = strings.Join(stack[1:], " > ")
+		}
+		lines = append(lines, line)
+	}
+	flush()
+	return blocks
+}
+
+// joinBlocks joins blocks into a document, separated by blank lines.
+func joinBlocks(blocks []docBlock) string {
+	if len(blocks) == 0 {
+		return ""
+	}
+	texts := make([]string, len(blocks))
+	for i, b := range blocks {
+		texts[i] = b.text
+	}
+	return strings.Join(texts, "\n\n") + "\n"
+}
+
+// blockContent normalizes a block for comparison, ignoring surrounding whitespace
+// and blank lines.
+func blockContent(text string) string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// sameBlocks reports whether a and b hold the same blocks in the same order.
+func sameBlocks(a, b []docBlock) bool {
+	return slices.EqualFunc(a, b, func(x, y docBlock) bool {
+		return x.key == y.key && blockContent(x.text) == blockContent(y.text)
+	})
+}
+
+// mergeBlocks merges local and remote, which both evolved from base, block by block.
+// resolve is called for blocks changed differently on both sides and reports
+// whether the local version is kept. The result follows the local order, with
+// blocks only kept from remote placed after the block preceding them there.
+func mergeBlocks(base, local, remote []docBlock, resolve func(SyncConflict) (bool, error)) ([]docBlock, error) {
+	index := func(blocks []docBlock) map[string]docBlock {
+		m := make(map[string]docBlock, len(blocks))
+		for _, b := range blocks {
+			m[b.key] = b
+		}
+		return m
+	}
+	baseBlocks, localBlocks, remoteBlocks := index(base), index(local), index(remote)
+
+	// pick returns the merged version of the block at key, false if it is dropped
+	pick := func(key string) (docBlock, bool, error) {
+		l, lok := localBlocks[key]
+		r, rok := remoteBlocks[key]
+		b, bok := baseBlocks[key]
+		same := func(x docBlock, xok bool, y docBlock, yok bool) bool {
+			return xok == yok && (!xok || blockContent(x.text) == blockContent(y.text))
+		}
+		switch {
+		case same(l, lok, r, rok):
+			return l, lok, nil
+		case same(l, lok, b, bok):
+			return r, rok, nil
+		case same(r, rok, b, bok):
+			return l, lok, nil
+		}
+		path := key
+		if key == preambleKey {
+			path = ""
+		}
+		keepLocal, err := resolve(SyncConflict{Path: path, Local: l.text, Remote: r.text})
+		if err != nil {
+			return docBlock{}, false, err
+		}
+		if keepLocal {
+			return l, lok, nil
+		}
+		return r, rok, nil
+	}
+
+	var merged []docBlock
+	for _, l := range local {
+		block, ok, err := pick(l.key)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			merged = append(merged, block)
+		}
+	}
+	for i, r := range remote {
+		if _, ok := localBlocks[r.key]; ok {
+			continue
+		}
+		block, ok, err := pick(r.key)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		// Insert after the nearest preceding remote block that was kept
+		at := 0
+		for j := i - 1; j >= 0 && at == 0; j-- {
+			if k := slices.IndexFunc(merged, func(m docBlock) bool { return m.key == remote[j].key }); k >= 0 {
+				at = k + 1
+			}
+		}
+		merged = slices.Insert(merged, at, block)
+	}
+	return merged, nil
+}
+
+// askSyncConflict shows both versions of a conflicting section on stdout and asks
+// on stdin which one to keep. End of input aborts the sync.
+func askSyncConflict(c SyncConflict) (bool, error) {
+	path := c.Path
+	if path == "" {
+		path = "(before the first section)"
+	}
+	show := func(side, text string) {
+		fmt.Printf("--- %s\n", side)
+		if text == "" {
+			fmt.Println("(deleted)")
+			return
+		}
+		fmt.Println(text)
+	}
+	fmt.Printf("Section %q changed in both the local file and Simplenote.\n", path)
+	show("local file", c.Local)
+	show("Simplenote", c.Remote)
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for {
+		fmt.Print("Keep [l]ocal or [r]emote version, or [a]bort? ")
+		if !scanner.Scan() {
+			return false, fmt.Errorf("sync aborted at section %q", path)
+		}
+		switch strings.ToLower(strings.TrimSpace(scanner.Text())) {
+		case "l", "local":
+			return true, nil
+		case "r", "remote":
+			return false, nil
+		case "a", "abort":
+			return false, fmt.Errorf("sync aborted at section %q", path)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package prompt
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+func TestSplitBlocks(t *testing.T) {
+	content := "intro\n\n# Prompts\n## Golang 🐹\n### Review\nReview code\n\n```\n# not a heading\n```\n## Golang\nMore\n"
+	expected := []docBlock{
+		{key: preambleKey, text: "intro"},
+		{key: "", text: "# Prompts"},
+		{key: "Golang", text: "## Golang 🐹"},
+		{key: "Golang > Review", text: "### Review\nReview code\n\n```\n# not a heading\n```"},
+		{key: "Golang (2)", text: "## Golang\nMore"},
+	}
+	if got := splitBlocks(content); !reflect.DeepEqual(got, expected) {
+		t.Errorf("splitBlocks() = %+v, want %+v", got, expected)
+	}
+}
+
+func TestMergeBlocks(t *testing.T) {
+	base := "# P\n\n## Go\nReview\n\n## Py\nExplain\n\n## Rust\nBorrow\n"
+	tests := []struct {
+		name      string
+		local     string
+		remote    string
+		keepLocal bool
+		expected  string
+		conflicts int
+	}{
+		{
+			name:     "changes on different sections",
+			local:    "# P\n\n## Go\nReview this Go code\n\n## Py\nExplain\n\n## Rust\nBorrow\n",
+			remote:   "# P\n\n## Go\nReview\n\n## Py\nExplain this Python\n\n## Rust\nBorrow\n",
+			expected: "# P\n\n## Go\nReview this Go code\n\n## Py\nExplain this Python\n\n## Rust\nBorrow\n",
+		},
+		{
+			name:     "deletion and addition",
+			local:    "# P\n\n## Go\nReview\n\n## Py\nExplain\n",
+			remote:   "# P\n\n## Go\nReview\n\n## Zig\nComptime\n\n## Py\nExplain\n\n## Rust\nBorrow\n",
+			expected: "# P\n\n## Go\nReview\n\n## Zig\nComptime\n\n## Py\nExplain\n",
+		},
+		{
+			name:      "conflict keeps remote",
+			local:     "# P\n\n## Go\nLocal\n\n## Py\nExplain\n\n## Rust\nBorrow\n",
+			remote:    "# P\n\n## Go\nRemote\n\n## Py\nExplain\n\n## Rust\nBorrow\n",
+			expected:  "# P\n\n## Go\nRemote\n\n## Py\nExplain\n\n## Rust\nBorrow\n",
+			conflicts: 1,
+		},
+		{
+			name:      "conflict keeps local",
+			local:     "# P\n\n## Go\nLocal\n\n## Py\nExplain\n\n## Rust\nBorrow\n",
+			remote:    "# P\n\n## Go\nRemote\n\n## Py\nExplain\n\n## Rust\nBorrow\n",
+			keepLocal: true,
+			expected:  "# P\n\n## Go\nLocal\n\n## Py\nExplain\n\n## Rust\nBorrow\n",
+			conflicts: 1,
+		},
+		{
+			name:     "whitespace only changes are not conflicts",
+			local:    "# P\n## Go\nReview  \n## Py\nExplain\n## Rust\nBorrow\n",
+			remote:   "# P\n\n## Go\n  Review\n\n## Py\nExplain\n\n## Rust\nBorrow\n",
+			expected: "# P\n\n## Go\nReview  \n\n## Py\nExplain\n\n## Rust\nBorrow\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conflicts := 0
+			merged, err := mergeBlocks(splitBlocks(base), splitBlocks(tt.local), splitBlocks(tt.remote), func(SyncConflict) (bool, error) {
+				conflicts++
+				return tt.keepLocal, nil
+			})
+			if err != nil {
+				t.Fatalf("mergeBlocks() error = %v", err)
+			}
+			if got := joinBlocks(merged); got != tt.expected {
+				t.Errorf("mergeBlocks() = %q, want %q", got, tt.expected)
+			}
+			if conflicts != tt.conflicts {
+				t.Errorf("expected %d conflicts, got %d", tt.conflicts, conflicts)
+			}
+		})
+	}
+}
+
+func TestSyncSources(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "prompts.md")
+	if err := os.WriteFile(file, []byte("# Prompts\n\n## Golang\nReview\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	note := fakeSimplenote(t, "# Prompts\n\n## Python\nExplain\n")
+	conf := config.Config{FilePath: file, SNNote: "LLM Prompts", DataDir: dir}
+
+	// The first sync keeps the sections of both sides, placing the note's sections
+	// after the block preceding them in the note
+	result, err := SyncSources(conf, SyncNewest)
+	if err != nil {
+		t.Fatalf("SyncSources() error = %v", err)
+	}
+	expected := "# Prompts\n\n## Python\nExplain\n\n## Golang\nReview\n"
+	local, _ := os.ReadFile(file)
+	if string(local) != expected || *note != expected || !result.LocalChanged || !result.RemoteChanged {
+		t.Fatalf("after first sync: file %q, note %q, result %+v", local, *note, result)
+	}
+
+	if result, err := SyncSources(conf, SyncNewest); err != nil || result.LocalChanged || result.RemoteChanged {
+		t.Errorf("expected a second sync to change nothing, got %+v, %v", result, err)
+	}
+
+	// Offline edit of the file is pushed, the note's deletion is pulled
+	if err := os.WriteFile(file, []byte("# Prompts\n\n## Python\nExplain\n\n## Golang\nReview this Go code\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	*note = "# Prompts\n\n## Golang\nReview\n"
+	if _, err := SyncSources(conf, SyncNewest); err != nil {
+		t.Fatalf("SyncSources() error = %v", err)
+	}
+	expected = "# Prompts\n\n## Golang\nReview this Go code\n"
+	local, _ = os.ReadFile(file)
+	if string(local) != expected || *note != expected {
+		t.Errorf("after offline edit: file %q, note %q, want %q", local, *note, expected)
+	}
+}
+
+func TestSyncSources_Newest(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "prompts.md")
+	conf := config.Config{FilePath: file, SNNote: "LLM Prompts", DataDir: dir}
+	note := fakeSimplenote(t, "# Prompts\n\n## Golang\nRemote\n")
+	if err := os.WriteFile(file, []byte("# Prompts\n\n## Golang\nLocal\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	old := sncliModifiedFunc
+	t.Cleanup(func() { sncliModifiedFunc = old })
+
+	sncliModifiedFunc = func(config.Config) (time.Time, bool) { return time.Time{}, false }
+	if _, err := SyncSources(conf, SyncNewest); err == nil {
+		t.Error("expected an error when the note's modification time is unknown")
+	}
+
+	sncliModifiedFunc = func(config.Config) (time.Time, bool) { return time.Now().Add(time.Hour), true }
+	if _, err := SyncSources(conf, SyncNewest); err != nil {
+		t.Fatalf("SyncSources() error = %v", err)
+	}
+	if local, _ := os.ReadFile(file); string(local) != *note || *note != "# Prompts\n\n## Golang\nRemote\n" {
+		t.Errorf("expected the newer note to win, got file %q and note %q", local, *note)
+	}
+}
+
+func TestSyncSources_Interactive(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "prompts.md")
+	conf := config.Config{FilePath: file, SNNote: "LLM Prompts", DataDir: dir}
+	note := fakeSimplenote(t, "# Prompts\n\n## Golang\nRemote\n")
+	if err := os.WriteFile(file, []byte("# Prompts\n\n## Golang\nLocal\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	old := askSyncConflictFunc
+	t.Cleanup(func() { askSyncConflictFunc = old })
+
+	var asked SyncConflict
+	askSyncConflictFunc = func(c SyncConflict) (bool, error) {
+		asked = c
+		return true, nil
+	}
+	result, err := SyncSources(conf, SyncInteractive)
+	if err != nil || result.Conflicts != 1 {
+		t.Fatalf("SyncSources() = %+v, %v", result, err)
+	}
+	if asked.Path != "Golang" || asked.Local != "## Golang\nLocal" || asked.Remote != "## Golang\nRemote" {
+		t.Errorf("unexpected conflict %+v", asked)
+	}
+	if *note != "# Prompts\n\n## Golang\nLocal\n" {
+		t.Errorf("expected the local version in the note, got %q", *note)
+	}
+
+	askSyncConflictFunc = func(SyncConflict) (bool, error) { return false, errors.New("aborted") }
+	if err := os.WriteFile(file, []byte("# Prompts\n\n## Golang\nLocal again\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	*note = "# Prompts\n\n## Golang\nRemote again\n"
+	if _, err := SyncSources(conf, SyncInteractive); err == nil {
+		t.Error("expected an aborted sync to fail")
+	}
+	if *note != "# Prompts\n\n## Golang\nRemote again\n" {
+		t.Errorf("expected an aborted sync to leave the note alone, got %q", *note)
+	}
+}
+
+func TestSyncSources_RequiresFile(t *testing.T) {
+	if _, err := SyncSources(config.Config{SNNote: "LLM Prompts"}, SyncNewest); err == nil {
+		t.Error("expected an error without FILEPATH")
+	}
+}
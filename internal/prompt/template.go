@@ -0,0 +1,129 @@
+package prompt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/toozej/wheresmyprompt/internal/sandbox"
+	"github.com/toozej/wheresmyprompt/internal/search"
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+// shellTimeout bounds how long a {{shell}} command may run.
+const shellTimeout = 10 * time.Second
+
+// ErrShellDisabled is returned when a prompt runs {{shell}} without --allow-shell.
+var ErrShellDisabled = errors.New("the shell template function is disabled; pass --allow-shell to run commands from prompts")
+
+// Allow test overrides
+var (
+	templateNow       = time.Now
+	readClipboardFunc = readClipboard
+	shellOutputFunc   = shellOutput
+)
+
+// ExpandPrompt returns content as it is copied, printed or typed: the prompts it
+// references with @ref(Title) are inlined, its attachment comments are removed,
+// and with TEMPLATES enabled its template actions are rendered, see RenderTemplate.
+func ExpandPrompt(data *PromptData, content string, conf config.Config) (string, error) {
+	content, err := ResolveRefs(data, content)
+	if err != nil {
+		return "", err
+	}
+	content = search.StripAttachments(content)
+	if !conf.Templates {
+		return content, nil
+	}
+	return RenderTemplate(content, conf.AllowShell)
+}
+
+// RenderTemplate renders content as a Go text/template with these functions:
+//
+//	{{now "2006-01-02"}}               the current time in a Go time layout
+//	{{clipboard}}                      the current clipboard contents
+//	{{shell "git log -5 --oneline"}}   the output of a shell command
+//
+// shell returns ErrShellDisabled unless allowShell is true, and trailing newlines
+// of its output are dropped.
+func RenderTemplate(content string, allowShell bool) (string, error) {
+	tmpl, err := template.New("prompt").Funcs(template.FuncMap{
+		"now": func(layout string) string {
+			return templateNow().Format(layout)
+		},
+		"clipboard": func() (string, error) {
+			return readClipboardFunc()
+		},
+		"shell": func(command string) (string, error) {
+			if !allowShell {
+				return "", ErrShellDisabled
+			}
+			return shellOutputFunc(command)
+		},
+	}).Parse(content)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse prompt template: %w", err)
+	}
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, nil); err != nil {
+		return "", fmt.Errorf("failed to render prompt template: %w", err)
+	}
+	return b.String(), nil
+}
+
+// readClipboard returns the clipboard contents using the platform clipboard utility,
+// the counterpart of copyToClipboard.
+func readClipboard() (string, error) {
+	if sandbox.Disabled() {
+		return "", fmt.Errorf("%w: %w", ErrClipboard, sandbox.ErrExecDisabled)
+	}
+	var cmd *exec.Cmd
+
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = sandbox.Command("pbpaste")
+	case "linux":
+		if _, err := sandbox.LookPath("xclip"); err == nil {
+			cmd = sandbox.Command("xclip", "-selection", "clipboard", "-o")
+		} else if _, err := sandbox.LookPath("xsel"); err == nil {
+			cmd = sandbox.Command("xsel", "--clipboard", "--output")
+		} else {
+			return "", fmt.Errorf("%w: no clipboard utility found (xclip or xsel required)", ErrClipboard)
+		}
+	case "windows":
+		cmd = sandbox.Command("powershell", "-NoProfile", "-Command", "Get-Clipboard")
+	default:
+		return "", fmt.Errorf("%w: unsupported operating system: %s", ErrClipboard, runtime.GOOS)
+	}
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("%w: failed to read clipboard: %w", ErrClipboard, commandError(err))
+	}
+	return string(out), nil
+}
+
+// shellOutput runs command with the system shell and returns its output without
+// trailing newlines.
+func shellOutput(command string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), shellTimeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = sandbox.CommandContext(ctx, "cmd", "/C", command) // #nosec G204 -- only with --allow-shell
+	} else {
+		cmd = sandbox.CommandContext(ctx, "sh", "-c", command) // #nosec G204 -- only with --allow-shell
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("shell command %q failed: %w", command, commandError(err))
+	}
+	return strings.TrimRight(string(out), "\r\n"), nil
+}
@@ -0,0 +1,80 @@
+package prompt
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	origNow, origClipboard, origShell := templateNow, readClipboardFunc, shellOutputFunc
+	defer func() { templateNow, readClipboardFunc, shellOutputFunc = origNow, origClipboard, origShell }()
+	templateNow = func() time.Time { return time.Date(2026, 3, 14, 9, 26, 0, 0, time.UTC) }
+	readClipboardFunc = func() (string, error) { return "func main() {}", nil }
+	shellOutputFunc = func(command string) (string, error) { return "ran " + command, nil }
+
+	tests := []struct {
+		name       string
+		content    string
+		allowShell bool
+		expected   string
+		err        error
+		wantErr    bool
+	}{
+		{name: "plain text", content: "Review this code", expected: "Review this code"},
+		{name: "now", content: `Today is {{now "2006-01-02"}}.`, expected: "Today is 2026-03-14."},
+		{name: "clipboard", content: "Explain:\n{{clipboard}}", expected: "Explain:\nfunc main() {}"},
+		{name: "shell allowed", content: `{{shell "git log -5 --oneline"}}`, allowShell: true, expected: "ran git log -5 --oneline"},
+		{name: "shell disabled", content: `{{shell "git log -5 --oneline"}}`, err: ErrShellDisabled, wantErr: true},
+		{name: "parse error", content: "{{now", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RenderTemplate(tt.content, tt.allowShell)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RenderTemplate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.err != nil && !errors.Is(err, tt.err) {
+				t.Errorf("RenderTemplate() error = %v, want %v", err, tt.err)
+			}
+			if got != tt.expected {
+				t.Errorf("RenderTemplate() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestExpandPrompt(t *testing.T) {
+	origNow := templateNow
+	defer func() { templateNow = origNow }()
+	templateNow = func() time.Time { return time.Date(2026, 3, 14, 9, 26, 0, 0, time.UTC) }
+
+	data := &PromptData{Sections: []Section{
+		{Headings: []string{"Prompts", "Personas", "Reviewer"}, Lines: []string{"You are a senior Go reviewer."}},
+	}}
+	content := `@ref(Reviewer) Today is {{now "2006-01-02"}}. <!-- attach: diagrams/arch.png -->`
+
+	tests := []struct {
+		name      string
+		templates bool
+		expected  string
+	}{
+		{name: "templates disabled", expected: `You are a senior Go reviewer. Today is {{now "2006-01-02"}}.`},
+		{name: "templates enabled", templates: true, expected: "You are a senior Go reviewer. Today is 2026-03-14."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExpandPrompt(data, content, config.Config{Templates: tt.templates})
+			if err != nil {
+				t.Fatalf("ExpandPrompt() error = %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("ExpandPrompt() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package prompt
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+// Copy transforms, applied in order by Preset.Format.
+const (
+	TransformStripMarkdown = "strip-markdown" // Drop Markdown formatting, keeping the text
+	TransformOneLine       = "one-line"       // Collapse whitespace, including newlines, to single spaces
+	TransformCodeBlock     = "code-block"     // Wrap in a fenced code block
+	TransformJSON          = "json"           // Quote as a JSON string literal
+)
+
+var (
+	mdFence    = regexp.MustCompile("^\\s*(```|~~~)")
+	mdHeading  = regexp.MustCompile(`^\s{0,3}#{1,6}\s+`)
+	mdQuote    = regexp.MustCompile(`^\s{0,3}>\s?`)
+	mdImage    = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	mdLink     = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+	mdEmphasis = []*regexp.Regexp{
+		emphasis("`", "", ""), emphasis(`\*\*`, "", ""), emphasis(`~~`, "", ""), emphasis(`\*`, "", ""),
+		// Underscores only mark emphasis outside words, so snake_case survives
+		emphasis(`__`, `(^|\W)`, `(\W|$)`), emphasis(`_`, `(^|\W)`, `(\W|$)`),
+	}
+)
+
+// emphasis returns a regular expression matching text between two marks, not
+// starting or ending with a space, preceded by before and followed by after. Its
+// groups are before, the text and after.
+func emphasis(mark, before, after string) *regexp.Regexp {
+	if before == "" {
+		before, after = "()", "()"
+	}
+	return regexp.MustCompile(before + mark + `(\S|\S.*?\S)` + mark + after)
+}
+
+// TransformCopy returns the content of p as it is copied to the clipboard,
+// formatted by the preset of conf, see ResolvePreset.
+func TransformCopy(conf config.Config, p Prompt) (string, error) {
+	preset, err := ResolvePreset(conf)
+	if err != nil {
+		return "", err
+	}
+	return preset.Format(p)
+}
+
+// stripMarkdown removes heading marks, block quote marks, code fences, emphasis and
+// inline code marks from content, and replaces links and images by their text.
+func stripMarkdown(content string) string {
+	var lines []string
+	for _, line := range strings.Split(content, "\n") {
+		if mdFence.MatchString(line) {
+			continue
+		}
+		line = mdHeading.ReplaceAllString(line, "")
+		line = mdQuote.ReplaceAllString(line, "")
+		line = mdImage.ReplaceAllString(line, "$1")
+		line = mdLink.ReplaceAllString(line, "$1")
+		for _, re := range mdEmphasis {
+			line = re.ReplaceAllString(line, "$1$2$3")
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// codeBlock wraps content in a fenced code block, with a fence longer than any run of
+// backticks in content so that it cannot be closed early.
+func codeBlock(content string) string {
+	longest, run := 0, 0
+	for _, r := range content {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	fence := strings.Repeat("`", max(3, longest+1))
+	return fence + "\n" + strings.TrimSuffix(content, "\n") + "\n" + fence
+}
+
+// jsonString returns content as a JSON string literal, leaving <, > and & unescaped.
+func jsonString(content string) string {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(content) // Strings always encode
+	return strings.TrimSuffix(b.String(), "\n")
+}
@@ -0,0 +1,63 @@
+package prompt
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+func TestTransformCopy(t *testing.T) {
+	content := "### Review\nReview **this** `main.go` for *bugs* in snake_case_names.\n\n> See [the guide](https://example.com/guide) ![diagram](d.png)\n```go\nfmt.Println(\"<ok> & done\")\n```"
+
+	tests := []struct {
+		name       string
+		transforms []string
+		prefix     string
+		suffix     string
+		content    string
+		expected   string
+		wantErr    bool
+	}{
+		{name: "none", content: "Review\n", expected: "Review\n"},
+		{
+			name:       "strip markdown",
+			transforms: []string{TransformStripMarkdown},
+			content:    content,
+			expected:   "Review\nReview this main.go for bugs in snake_case_names.\n\nSee the guide diagram\nfmt.Println(\"<ok> & done\")",
+		},
+		{name: "strip underscore emphasis", transforms: []string{TransformStripMarkdown}, content: "a __bold__ and _light_ word", expected: "a bold and light word"},
+		{name: "strip keeps list markers", transforms: []string{TransformStripMarkdown}, content: "* one\n- two", expected: "* one\n- two"},
+		{name: "one line", transforms: []string{TransformOneLine}, content: "Review\n\n  this\tcode\n", expected: "Review this code"},
+		{name: "code block", transforms: []string{TransformCodeBlock}, content: "Review\n", expected: "```\nReview\n```"},
+		{name: "code block with fence inside", transforms: []string{TransformCodeBlock}, content: "a\n````\nb", expected: "`````\na\n````\nb\n`````"},
+		{name: "json", transforms: []string{TransformJSON}, content: "Say \"hi\" <b>\n\tthen & go", expected: `"Say \"hi\" <b>\n\tthen & go"`},
+		{
+			name:       "in order",
+			transforms: []string{TransformStripMarkdown, " one-line", TransformJSON},
+			content:    "## Review\n**Go** code",
+			expected:   `"Review Go code"`,
+		},
+		{name: "prefix and suffix after transforms", transforms: []string{TransformOneLine}, prefix: "<prompt>", suffix: "</prompt>", content: "a\nb", expected: "<prompt>a b</prompt>"},
+		{name: "unknown", transforms: []string{"yaml"}, content: "a", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := config.Config{CopyTransform: tt.transforms, CopyPrefix: tt.prefix, CopySuffix: tt.suffix}
+			got, err := TransformCopy(conf, Prompt{Content: tt.content})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("TransformCopy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if !strings.Contains(err.Error(), `"yaml"`) {
+					t.Errorf("TransformCopy() error = %v, want the unknown name", err)
+				}
+				return
+			}
+			if got != tt.expected {
+				t.Errorf("TransformCopy() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package prompt
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/toozej/wheresmyprompt/internal/sandbox"
+)
+
+// ErrTyping is returned when a prompt cannot be typed via keyboard emulation.
+var ErrTyping = errors.New("failed to type prompt")
+
+// typeCommandFunc allows tests to inspect the automation command instead of running it.
+var typeCommandFunc = typeCommand
+
+// TypeText types text into the focused window using a platform automation tool,
+// for applications that block pasting from the clipboard:
+// - macOS: osascript (System Events keystrokes)
+// - Linux on Wayland: wtype
+// - Linux on X11: xdotool
+// It waits for delay first so focus can return to the target window after the
+// TUI or terminal exits. Returns an error wrapping ErrTyping on failure.
+func TypeText(text string, delay time.Duration) error {
+	cmd, err := typeCommandFunc(text)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrTyping, err)
+	}
+	time.Sleep(delay)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%w: %w", ErrTyping, err)
+	}
+	return nil
+}
+
+// typeCommand builds the automation command that types text on this platform.
+// The text is passed as an argument or on stdin, never interpolated into a script.
+func typeCommand(text string) (*exec.Cmd, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		return sandbox.Command("osascript", // #nosec G204
+			"-e", "on run argv",
+			"-e", `tell application "System Events" to keystroke (item 1 of argv)`,
+			"-e", "end run",
+			text), nil
+	case "linux":
+		if os.Getenv("WAYLAND_DISPLAY") != "" {
+			if _, err := sandbox.LookPath("wtype"); err == nil {
+				return sandbox.Command("wtype", "--", text), nil // #nosec G204
+			}
+		}
+		if _, err := sandbox.LookPath("xdotool"); err == nil {
+			cmd := sandbox.Command("xdotool", "type", "--clearmodifiers", "--file", "-")
+			cmd.Stdin = strings.NewReader(text)
+			return cmd, nil
+		}
+		return nil, fmt.Errorf("no keyboard automation tool found (wtype or xdotool required)")
+	default:
+		return nil, fmt.Errorf("typing is not supported on %s", runtime.GOOS)
+	}
+}
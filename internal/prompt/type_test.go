@@ -0,0 +1,49 @@
+package prompt
+
+import (
+	"errors"
+	"os/exec"
+	"testing"
+)
+
+func TestTypeText(t *testing.T) {
+	tests := []struct {
+		name        string
+		command     func(text string) (*exec.Cmd, error)
+		expectError bool
+	}{
+		{
+			name:    "tool succeeds",
+			command: func(string) (*exec.Cmd, error) { return exec.Command("true"), nil },
+		},
+		{
+			name:        "tool fails",
+			command:     func(string) (*exec.Cmd, error) { return exec.Command("false"), nil },
+			expectError: true,
+		},
+		{
+			name:        "no tool available",
+			command:     func(string) (*exec.Cmd, error) { return nil, errors.New("no keyboard automation tool found") },
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := exec.LookPath("true"); err != nil {
+				t.Skip("true/false binaries not available")
+			}
+			originalCommand := typeCommandFunc
+			defer func() { typeCommandFunc = originalCommand }()
+			typeCommandFunc = tt.command
+
+			err := TypeText("Write tests", 0)
+			if tt.expectError && !errors.Is(err, ErrTyping) {
+				t.Errorf("expected error wrapping ErrTyping, got %v", err)
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
@@ -6,25 +6,102 @@ package prompt
 import (
 	"bufio"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
-	"os/exec"
+	"slices"
 	"strings"
 	"time"
 
+	log "github.com/sirupsen/logrus"
+
 	"github.com/toozej/wheresmyprompt/pkg/config"
 )
 
+// ErrReadOnly is returned by write operations when the prompt source is read-only.
+var ErrReadOnly = errors.New("prompt source is read-only")
+
+// IsReadOnly reports whether write operations are disabled for the configured source,
+// either explicitly via READ_ONLY or implicitly because the source is a URL without
+// REMOTE_WRITE or a read-only Source such as GOOGLE_DOC.
+func IsReadOnly(conf config.Config) bool {
+	return conf.ReadOnly || (isURLSource(conf.FilePath) && !conf.RemoteWrite) || isReadOnlySource(conf)
+}
+
+// isURLSource reports whether path refers to a remote http(s) or s3 source.
+func isURLSource(path string) bool {
+	lower := strings.ToLower(path)
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://") || strings.HasPrefix(lower, "s3://")
+}
+
+// localFile returns the local file conf reads prompts from, or "" when they come
+// from a URL, a Simplenote note or another source.
+func localFile(conf config.Config) string {
+	if isURLSource(conf.FilePath) {
+		return ""
+	}
+	return conf.FilePath
+}
+
+// checkWritable fails fast with ErrReadOnly when the configured source must not be
+// modified, and with ErrOffline when it cannot be reached, see checkOnline.
+func checkWritable(conf config.Config) error {
+	if !IsReadOnly(conf) {
+		return checkOnline(conf)
+	}
+	if conf.ReadOnly {
+		return fmt.Errorf("%w: unset READ_ONLY to modify prompts", ErrReadOnly)
+	}
+	if src, ok := customSourceFunc(conf); ok && isReadOnlySource(conf) {
+		return fmt.Errorf("%w: %s is a read-only source", ErrReadOnly, src.Name())
+	}
+	return fmt.Errorf("%w: %s is a URL source; set REMOTE_WRITE=true to write back to it", ErrReadOnly, redactURL(conf.FilePath))
+}
+
+// ErrContentShrunk is returned instead of writing a Simplenote note whose new content
+// is much shorter than the note, which points to a failed parse or a writer bug
+// rather than an intended change. Set conf.Force (--force) to write anyway.
+var ErrContentShrunk = errors.New("refusing to write a much shorter note")
+
+// A Simplenote note of at least shrinkMinSize bytes is only rewritten if it keeps at
+// least shrinkRatio of its length, see checkShrink.
+const (
+	shrinkMinSize = 1024
+	shrinkRatio   = 0.5
+)
+
+// checkShrink returns ErrContentShrunk if replacing the note's current content with
+// updated would lose more than half of a note of at least shrinkMinSize bytes,
+// unless conf.Force is set.
+func checkShrink(conf config.Config, current, updated string) error {
+	if conf.Force || len(current) < shrinkMinSize || float64(len(updated)) >= float64(len(current))*shrinkRatio {
+		return nil
+	}
+	return fmt.Errorf("%w: note '%s' would shrink from %d to %d bytes; rerun with --force if this is intended", ErrContentShrunk, conf.SNNote, len(current), len(updated))
+}
+
 // Allow test overrides
 var loadFromSimplenoteFunc = loadFromSimplenote
 var ensureSimplenoteAuthFunc = ensureSimplenoteAuth
+var saveToSimplenoteFunc = saveToSimplenote
 
 // WritePrompt adds a new prompt to the configured note source.
 // It can handle prompts provided via command line arguments, flags, or interactive input.
 // The prompt is automatically organized into sections and formatted according to the
 // established Markdown structure. For Simplenote integration, it updates the remote note.
-// Returns an error if the write operation fails.
+// Returns ErrReadOnly if the source is read-only, or an error if the write operation fails.
 func WritePrompt(conf config.Config, promptContent string, args []string) error {
+	return WritePromptFrom(conf, promptContent, args, os.Stdin, os.Stdout)
+}
+
+// WritePromptFrom adds a prompt like WritePrompt, reading the title, content and
+// section it asks for from in and writing its questions to out.
+func WritePromptFrom(conf config.Config, promptContent string, args []string, in io.Reader, out io.Writer) error {
+	if err := checkWritable(conf); err != nil {
+		return err
+	}
+
 	// Determine the prompt title and content
 	var title, content string
 
@@ -39,12 +116,12 @@ func WritePrompt(conf config.Config, promptContent string, args []string) error
 		title = generateTitleFromContent(content)
 	default:
 		// Read from stdin
-		fmt.Print("Enter prompt title: ")
-		scanner := bufio.NewScanner(os.Stdin)
+		fmt.Fprint(out, "Enter prompt title: ")
+		scanner := bufio.NewScanner(in)
 		scanner.Scan()
 		title = scanner.Text()
 
-		fmt.Print("Enter prompt content (press Ctrl+D when done):\n")
+		fmt.Fprint(out, "Enter prompt content (press Ctrl+D when done):\n")
 		var contentLines []string
 		for scanner.Scan() {
 			contentLines = append(contentLines, scanner.Text())
@@ -63,13 +140,39 @@ func WritePrompt(conf config.Config, promptContent string, args []string) error
 	}
 
 	if section == "" {
-		fmt.Print("Enter section (optional, press Enter to skip): ")
-		scanner := bufio.NewScanner(os.Stdin)
-		scanner.Scan()
-		section = strings.TrimSpace(scanner.Text())
+		var err error
+		if section, err = askSectionFunc(existingSections(conf), in, out); err != nil {
+			return fmt.Errorf("failed to read section: %w", err)
+		}
+	}
+
+	if conf.Staging {
+		return stagePrompt(conf, title, content, section)
 	}
+	return addPromptToNote(conf, title, content, section, in, out)
+}
 
-	return addPromptToNote(conf, title, content, section)
+// AddPrompt adds a prompt with the given title to section without any interactive
+// input, staging it instead when STAGING is enabled. An existing title is handled
+// according to conf.OnConflict, defaulting to ConflictAbort.
+// Returns ErrReadOnly if the source is read-only, or ErrPromptExists on an aborted conflict.
+func AddPrompt(conf config.Config, title, content, section string) error {
+	if err := checkWritable(conf); err != nil {
+		return err
+	}
+	if title == "" {
+		title = generateTitleFromContent(content)
+	}
+	if strings.TrimSpace(content) == "" {
+		return fmt.Errorf("prompt content is required")
+	}
+	if conf.OnConflict == "" {
+		conf.OnConflict = ConflictAbort
+	}
+	if conf.Staging {
+		return stagePrompt(conf, title, content, section)
+	}
+	return addPromptToNote(conf, title, content, section, os.Stdin, os.Stdout)
 }
 
 // generateTitleFromContent creates a title from the first few words of content
@@ -98,158 +201,149 @@ func generateTitleFromContent(content string) string {
 	return title
 }
 
-// addPromptToNote adds the new prompt to the Simplenote note
-func addPromptToNote(conf config.Config, title, content, section string) error {
-	if conf.FilePath != "" {
-		return addPromptToFile(conf.FilePath, title, content, section)
+// addPromptToNote adds the new prompt to the Simplenote note, or the local file, and
+// records when it was added, asking on in and out how to handle an existing title.
+// Sections listed in WRITE_ROUTES are written to their target instead. The write
+// hooks run before and after.
+func addPromptToNote(conf config.Config, title, content, section string, in io.Reader, out io.Writer) error {
+	p := Prompt{Content: content, Section: section, Title: title}
+	if err := RunHook(conf, HookPreWrite, p); err != nil {
+		return err
 	}
-	return addPromptToSimplenote(conf, title, content, section)
-}
-
-// addPromptToFile adds the prompt to a local markdown file
-func addPromptToFile(filepath, title, content, section string) error {
-	// Read existing content
-	existingContent := ""
-	data, err := os.ReadFile(filepath) // #nosec G304
-	if err == nil {
-		existingContent = string(data)
+	routed := routedConfig(conf, section)
+	if routed.FilePath != conf.FilePath {
+		if err := createRouteFile(routed.FilePath); err != nil {
+			return err
+		}
 	}
-
-	// Parse existing content into sections using new parser
-	sections, err := parseMarkdownIntoSections(existingContent)
-	if err != nil {
-		return fmt.Errorf("failed to parse markdown: %w", err)
+	if err := writePromptToNote(routed, title, content, section, in, out); err != nil {
+		return err
 	}
-	promptData := gatherPromptData(sections)
-
-	var newContent strings.Builder
-	sectionFound := false
+	recordAdded(conf, section, content)
+	return RunHook(conf, HookPostWrite, p)
+}
 
-	if section != "" {
-		// Try to find the section and append prompt
-		for i, sec := range promptData.Sections {
-			if len(sec.Headings) > 0 && sec.Headings[len(sec.Headings)-1] == section {
-				sectionFound = true
-				// Write all sections up to this one
-				for j := 0; j < i; j++ {
-					writeSection(&newContent, promptData.Sections[j])
-				}
-				// Write this section header
-				writeSectionHeader(&newContent, sec)
-				// Write existing lines
-				for _, line := range sec.Lines {
-					newContent.WriteString(line + "\n")
-				}
-				// Add new prompt
-				newContent.WriteString("\n### " + title + "\n")
-				newContent.WriteString(content + "\n\n")
-				// Write remaining sections
-				for j := i + 1; j < len(promptData.Sections); j++ {
-					writeSection(&newContent, promptData.Sections[j])
-				}
-				break
-			}
+// writePromptToNote writes the new prompt to the Simplenote note, the local file,
+// the remote file or the configured Source, see resolveTitleConflict for in and out.
+func writePromptToNote(conf config.Config, title, content, section string, in io.Reader, out io.Writer) error {
+	if err := checkWritable(conf); err != nil {
+		return err
+	}
+	if file := localFile(conf); file != "" {
+		// Ask about an existing title before locking, so the lock isn't held while waiting
+		existing, _ := readLocalFile(file)
+		var err error
+		if conf, err = askOnConflict(conf, existing, title, section, in, out); err != nil {
+			return err
 		}
-		if !sectionFound {
-			// Section not found, preserve existing content and append new section at end
-			newContent.WriteString(existingContent)
-			if !strings.HasSuffix(existingContent, "\n") {
-				newContent.WriteString("\n")
+		return withFileLock(file, conf.LockTimeout, func() error {
+			existing, _ := readLocalFile(file)
+			_, edit, err := resolveTitleConflict(conf, existing, title, content, section, in, out)
+			if err != nil {
+				return err
 			}
-			newContent.WriteString("\n\n## " + section + "\n\n")
-			newContent.WriteString("### " + title + "\n")
-			newContent.WriteString(content + "\n")
-		}
-	} else {
-		// No section specified, add at the end
-		newContent.WriteString(existingContent)
-		if !strings.HasSuffix(existingContent, "\n") {
-			newContent.WriteString("\n")
-		}
-		newContent.WriteString("\n### " + title + "\n")
-		newContent.WriteString(content + "\n")
+			if err := spliceLocalFile(file, edit); err != nil {
+				return err
+			}
+			return autoFormatFile(conf, file)
+		})
 	}
-
-	// Write back to file
-	return os.WriteFile(filepath, []byte(newContent.String()), 0600)
-}
-
-// writeSectionHeader writes the markdown header for a section
-func writeSectionHeader(b *strings.Builder, sec Section) {
-	for i, heading := range sec.Headings {
-		b.WriteString(strings.Repeat("#", i+1) + " " + heading + "\n")
+	if isCustomSource(conf) || isURLSource(conf.FilePath) {
+		return updateSourceContent(conf, writeOp{action: "add", title: title, section: section}, func(current string) (string, error) {
+			_, edit, err := resolveTitleConflict(conf, current, title, content, section, in, out)
+			if err != nil {
+				return "", err
+			}
+			return edit.apply(current), nil
+		})
 	}
+	return addPromptToSimplenote(conf, title, content, section, in, out)
 }
 
-// writeSection writes the full section (header + lines)
-func writeSection(b *strings.Builder, sec Section) {
-	writeSectionHeader(b, sec)
-	for _, line := range sec.Lines {
-		b.WriteString(line + "\n")
-	}
+// addPromptToFile adds the prompt to a local markdown file, see insertPrompt. Only
+// the inserted lines are written; the rest of the file is kept byte for byte.
+func addPromptToFile(filepath, title, content, section string) error {
+	existingContent, _ := readLocalFile(filepath)
+	return spliceLocalFile(filepath, insertEdit(existingContent, title, content, section))
 }
 
 // addPromptToSimplenote adds the prompt to the Simplenote note
-func addPromptToSimplenote(conf config.Config, title, content, section string) error {
-	// First, ensure authentication
-	if err := ensureSimplenoteAuthFunc(conf); err != nil {
-		return err
-	}
-
+func addPromptToSimplenote(conf config.Config, title, content, section string, in io.Reader, out io.Writer) error {
 	// Get current note content
 	currentContent, err := loadFromSimplenoteFunc(conf)
 	if err != nil {
 		return fmt.Errorf("failed to load current note: %w", err)
 	}
 
-	// Create updated content
-	var newContent strings.Builder
-	newContent.WriteString(currentContent)
+	title, edit, err := resolveTitleConflict(conf, currentContent, title, content, section, in, out)
+	if err != nil {
+		return err
+	}
+	updated := edit.apply(currentContent)
+	if err := checkShrink(conf, currentContent, updated); err != nil {
+		return err
+	}
+	if err := recordWriteFunc(conf, writeOp{action: "add", title: title, section: section}, currentContent); err != nil {
+		return err
+	}
+	if err := saveToSimplenoteFunc(conf, autoFormat(conf, updated)); err != nil {
+		return err
+	}
+
+	fmt.Printf("Successfully added prompt '%s' to note '%s'\n", title, conf.SNNote)
+	if section != "" {
+		fmt.Printf("Section: %s\n", section)
+	}
+
+	return nil
+}
 
+// insertPrompt returns currentContent with the prompt added to the end of the given
+// section, creating the section at the end of the document if it does not exist.
+// Without a section the prompt is appended to the end of the document.
+func insertPrompt(currentContent, title, content, section string) string {
+	return insertEdit(currentContent, title, content, section).apply(currentContent)
+}
+
+// insertEdit returns the edit adding the prompt to currentContent, see insertPrompt.
+func insertEdit(currentContent, title, content, section string) lineEdit {
+	lines := strings.Split(currentContent, "\n")
+	block := append([]string{"", "### " + title}, strings.Split(content, "\n")...)
 	if section != "" {
-		// Try to add to existing section
-		if !addToExistingSection(&newContent, currentContent, title, content, section) {
-			// Section doesn't exist, create it
-			if !strings.HasSuffix(currentContent, "\n") {
-				newContent.WriteString("\n")
-			}
-			newContent.WriteString("\n## " + section + "\n\n")
-			newContent.WriteString("### " + title + "\n")
-			newContent.WriteString(content + "\n")
+		if e, ok := sectionInsertEdit(lines, block, section); ok {
+			return e
 		}
-	} else {
-		// Add at the end without section
-		if !strings.HasSuffix(currentContent, "\n") {
-			newContent.WriteString("\n")
-		}
-		newContent.WriteString("\n### " + title + "\n")
-		newContent.WriteString(content + "\n")
+		block = append([]string{"", "", "## " + section}, block...)
+	}
+	// Appended after the final newline, or after a newline added to the last line
+	if end := len(lines) - 1; end > 0 && lines[end] == "" {
+		return lineEdit{start: end, end: end, lines: block}
 	}
+	return lineEdit{start: len(lines), end: len(lines), lines: append(block, "")}
+}
 
-	// Prepare JSON note for import
-	note := map[string]interface{}{
-		"tags":             []string{},
-		"deleted":          false,
-		"shareURL":         "",
-		"publishURL":       "",
-		"content":          newContent.String(),
-		"systemTags":       []string{},
-		"modificationDate": float64(time.Now().Unix()),
-		"creationDate":     float64(time.Now().Unix()),
-		"key":              conf.SNNote,
-		"version":          1,
-		"syncdate":         float64(time.Now().Unix()),
-		"localkey":         conf.SNNote,
-		"savedate":         float64(time.Now().Unix()),
-	}
-	notes := []interface{}{note}
-	jsonBytes, err := json.Marshal(notes)
+// saveToSimplenote replaces the content of the configured Simplenote note via sncli import.
+// The note's tags, flags such as markdown and pinned, and creation date are kept.
+func saveToSimplenote(conf config.Config, content string) error {
+	env, err := ensureSimplenoteAuthFunc(conf)
+	if err != nil {
+		return err
+	}
+	// The credentials are used by both the export reading the metadata and the import
+	defer clear(env)
+
+	existing, ok := noteMetadataFunc(conf, env)
+	if !ok {
+		log.Warnf("could not read the metadata of note '%s'; its tags and flags such as markdown and pinned will be reset", conf.SNNote)
+	}
+	jsonBytes, err := json.Marshal([]interface{}{simplenoteImportNote(conf, content, existing, time.Now())})
 	if err != nil {
 		return fmt.Errorf("failed to marshal note JSON: %w", err)
 	}
 
 	// Import the note using sncli import -
-	cmd := exec.Command("sncli", "import", "-") // #nosec G204
+	cmd, scrub := sncliCommand(env, "import", "-")
+	defer scrub()
 	stdin, err := cmd.StdinPipe()
 	if err != nil {
 		return fmt.Errorf("failed to get stdin pipe: %w", err)
@@ -260,51 +354,160 @@ func addPromptToSimplenote(conf config.Config, title, content, section string) e
 		_, _ = stdin.Write(jsonBytes)
 	}()
 	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to import note to Simplenote: %w", err)
-	}
-
-	fmt.Printf("Successfully added prompt '%s' to note '%s'\n", title, conf.SNNote)
-	if section != "" {
-		fmt.Printf("Section: %s\n", section)
+		return fmt.Errorf("failed to import note to Simplenote: %w", commandError(err))
 	}
 
 	return nil
 }
 
-// addToExistingSection tries to add the prompt to an existing section
-func addToExistingSection(newContent *strings.Builder, currentContent, title, content, section string) bool {
-	lines := strings.Split(currentContent, "\n")
-	sectionHeader := "## " + section
+// simplenoteImportNote returns the note "sncli import" stores to replace the
+// existing note's content with content at now. existing is the note's current
+// metadata, or its zero value when unknown, in which case the note gets no tags
+// or flags and its creation date is reset.
+func simplenoteImportNote(conf config.Config, content string, existing sncliNote, now time.Time) map[string]interface{} {
+	key := conf.SNNote
+	if existing.Key != "" {
+		key = existing.Key
+	}
+	created := float64(now.Unix())
+	if existing.CreationDate > 0 {
+		created = existing.CreationDate
+	}
+	version := max(existing.Version, 1)
+	tags, systemTags := existing.Tags, existing.SystemTags
+	if tags == nil {
+		tags = []string{}
+	}
+	if systemTags == nil {
+		systemTags = []string{}
+	}
+	return map[string]interface{}{
+		"tags":             tags,
+		"deleted":          false,
+		"shareURL":         "",
+		"publishURL":       "",
+		"content":          content,
+		"systemTags":       systemTags,
+		"modificationDate": float64(now.Unix()),
+		"creationDate":     created,
+		"key":              key,
+		"version":          version,
+		"syncdate":         float64(now.Unix()),
+		"localkey":         key,
+		"savedate":         float64(now.Unix()),
+	}
+}
 
-	// Reset the builder and rebuild with the new prompt
-	newContent.Reset()
+// noteMetadataFunc allows tests to control the metadata of the Simplenote note.
+var noteMetadataFunc = noteMetadata
+
+// noteMetadata returns the current metadata of the configured note from
+// "sncli export", falling back to sncli's local database. env is the sncli
+// credential environment from ensureSimplenoteAuth. The boolean result is false
+// if neither has the note.
+func noteMetadata(conf config.Config, env []string) (sncliNote, bool) {
+	cmd, scrub := sncliCommand(env, "export", conf.SNNote)
+	output, err := cmd.Output()
+	scrub()
+	if err == nil {
+		if note, ok := pickExportedNote(output, conf.SNNote); ok {
+			return note, true
+		}
+	} else {
+		log.Debugf("failed to export note '%s' from Simplenote: %v", conf.SNNote, commandError(err))
+	}
 
-	for i, line := range lines {
-		if strings.TrimSpace(line) == sectionHeader {
-			// Found the section, add all lines up to here
-			for j := 0; j <= i; j++ {
-				newContent.WriteString(lines[j] + "\n")
-			}
+	dir, err := sncliDBPath(conf)
+	if err != nil {
+		return sncliNote{}, false
+	}
+	return findSncliNote(dir, conf.SNNote)
+}
 
-			// Find the end of this section
-			k := i + 1
-			for k < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[k]), "## ") {
-				newContent.WriteString(lines[k] + "\n")
-				k++
-			}
+// pickExportedNote returns the note with key or title name from the JSON array
+// of notes printed by "sncli export", preferring a match by key. Deleted notes are
+// ignored.
+func pickExportedNote(output []byte, name string) (sncliNote, bool) {
+	var notes []sncliNote
+	if err := json.Unmarshal(output, &notes); err != nil {
+		log.Debugf("failed to decode exported notes: %v", err)
+		return sncliNote{}, false
+	}
+	var byTitle []sncliNote
+	for _, note := range notes {
+		switch {
+		case note.Deleted:
+		case note.Key == name:
+			return note, true
+		case note.title() == name:
+			byTitle = append(byTitle, note)
+		}
+	}
+	if len(byTitle) != 1 {
+		return sncliNote{}, false
+	}
+	return byTitle[0], true
+}
 
-			// Add the new prompt
-			newContent.WriteString("\n### " + title + "\n")
-			newContent.WriteString(content + "\n")
+// loadSourceContent returns the raw Markdown of the configured prompt source.
+func loadSourceContent(conf config.Config) (string, error) {
+	if isURLSource(conf.FilePath) {
+		return remoteSource{url: conf.FilePath, conf: conf}.Load()
+	}
+	if conf.FilePath != "" {
+		return loadFromFile(conf.FilePath)
+	}
+	if src, ok := customSourceFunc(conf); ok {
+		return src.Load()
+	}
+	return loadFromSimplenoteFunc(conf)
+}
 
-			// Add remaining sections
-			for j := k; j < len(lines); j++ {
-				newContent.WriteString(lines[j] + "\n")
-			}
+// saveSourceContentFunc allows tests to intercept writes of the full source document.
+var saveSourceContentFunc = saveSourceContent
 
-			return true
-		}
+// saveSourceContent replaces the raw Markdown of the configured prompt source.
+func saveSourceContent(conf config.Config, content string) error {
+	if err := checkWritable(conf); err != nil {
+		return err
+	}
+	content = autoFormat(conf, content)
+	if isURLSource(conf.FilePath) {
+		return remoteSource{url: conf.FilePath, conf: conf}.Save(content)
 	}
+	if conf.FilePath != "" {
+		return writeLocalFile(conf.FilePath, content)
+	}
+	if src, ok := customSourceFunc(conf); ok {
+		return src.Save(content)
+	}
+	return saveToSimplenoteFunc(conf, content)
+}
 
-	return false
+// sectionInsertEdit returns the edit inserting block after the last non-blank line
+// of the "## section" block of lines, keeping a blank line before the next section.
+// It returns false if the section does not exist.
+func sectionInsertEdit(lines, block []string, section string) (lineEdit, bool) {
+	start := slices.IndexFunc(lines, func(line string) bool {
+		level, text := parseHeading(line)
+		return level == 2 && headingName(text) == section
+	})
+	if start < 0 {
+		return lineEdit{}, false
+	}
+
+	// Find the end of this section, ignoring its trailing blank lines
+	end := start + 1
+	for end < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[end]), "## ") {
+		end++
+	}
+	keep := end
+	for keep > start+1 && strings.TrimSpace(lines[keep-1]) == "" {
+		keep--
+	}
+	if keep == end {
+		// Nothing separates the section from the next one or ends the last line
+		block = append(slices.Clone(block), "")
+	}
+	return lineEdit{start: keep, end: keep, lines: block}, true
 }
@@ -3,9 +3,13 @@ package prompt
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
 	"strings"
 	"testing"
 
@@ -13,81 +17,13 @@ import (
 	"github.com/toozej/wheresmyprompt/pkg/config"
 )
 
-// FileSystem interface for testing
-type FileSystem interface {
-	ReadFile(filename string) ([]byte, error)
-	WriteFile(filename string, data []byte, perm os.FileMode) error
-	MkdirAll(path string, perm os.FileMode) error
-	Exists(path string) (bool, error)
-}
-
-// OSFileSystem implements FileSystem using os package
-type OSFileSystem struct{}
-
-func (fs OSFileSystem) ReadFile(filename string) ([]byte, error) {
-	return os.ReadFile(filename)
-}
-
-func (fs OSFileSystem) WriteFile(filename string, data []byte, perm os.FileMode) error {
-	return os.WriteFile(filename, data, perm)
-}
-
-func (fs OSFileSystem) MkdirAll(path string, perm os.FileMode) error {
-	return os.MkdirAll(path, perm)
-}
-
-func (fs OSFileSystem) Exists(path string) (bool, error) {
-	_, err := os.Stat(path)
-	if err == nil {
-		return true, nil
-	}
-	if os.IsNotExist(err) {
-		return false, nil
-	}
-	return false, err
-}
-
-// AferoFileSystem implements FileSystem using afero
-type AferoFileSystem struct {
-	fs afero.Fs
-}
-
-func NewAferoFileSystem(fs afero.Fs) *AferoFileSystem {
-	return &AferoFileSystem{fs: fs}
-}
-
-func (afs *AferoFileSystem) ReadFile(filename string) ([]byte, error) {
-	return afero.ReadFile(afs.fs, filename)
-}
-
-func (afs *AferoFileSystem) WriteFile(filename string, data []byte, perm os.FileMode) error {
-	return afero.WriteFile(afs.fs, filename, data, perm)
-}
-
-func (afs *AferoFileSystem) MkdirAll(path string, perm os.FileMode) error {
-	return afs.fs.MkdirAll(path, perm)
-}
-
-func (afs *AferoFileSystem) Exists(path string) (bool, error) {
-	return afero.Exists(afs.fs, path)
-}
-
-// Global filesystem variable for dependency injection
-var filesystem FileSystem = OSFileSystem{}
-
-// Helper function to simulate stdin input
-func simulateStdin(input string, f func()) {
-	oldStdin := os.Stdin
-	r, w, _ := os.Pipe()
-	os.Stdin = r
-
-	go func() {
-		defer w.Close()
-		_, _ = w.Write([]byte(input))
-	}()
-
-	f()
-	os.Stdin = oldStdin
+// useMemFS swaps appFS for an in-memory filesystem until the test ends.
+func useMemFS(t testing.TB) afero.Fs {
+	t.Helper()
+	original := appFS
+	appFS = afero.NewMemMapFs()
+	t.Cleanup(func() { appFS = original })
+	return appFS
 }
 
 func TestGenerateTitleFromContent(t *testing.T) {
@@ -153,74 +89,6 @@ func TestGenerateTitleFromContent(t *testing.T) {
 	}
 }
 
-// Modified version of addPromptToFile that accepts a FileSystem for testing
-func addPromptToFileWithFS(fs FileSystem, filepath, title, content, section string) error {
-	// Read existing content
-	existingContent := ""
-	data, err := fs.ReadFile(filepath)
-	if err == nil {
-		existingContent = string(data)
-	}
-
-	// Parse existing content into sections using new parser
-	sections, err := parseMarkdownIntoSections(existingContent)
-	if err != nil {
-		return fmt.Errorf("failed to parse markdown: %w", err)
-	}
-	promptData := gatherPromptData(sections)
-
-	var newContent strings.Builder
-	sectionFound := false
-
-	if section != "" {
-		// Try to find the section and append prompt
-		for i, sec := range promptData.Sections {
-			if len(sec.Headings) > 0 && sec.Headings[len(sec.Headings)-1] == section {
-				sectionFound = true
-				// Write all sections up to this one
-				for j := 0; j < i; j++ {
-					writeSection(&newContent, promptData.Sections[j])
-				}
-				// Write this section header
-				writeSectionHeader(&newContent, sec)
-				// Write existing lines
-				for _, line := range sec.Lines {
-					newContent.WriteString(line + "\n")
-				}
-				// Add new prompt
-				newContent.WriteString("\n### " + title + "\n")
-				newContent.WriteString(content + "\n\n")
-				// Write remaining sections
-				for j := i + 1; j < len(promptData.Sections); j++ {
-					writeSection(&newContent, promptData.Sections[j])
-				}
-				break
-			}
-		}
-		if !sectionFound {
-			// Section not found, preserve existing content and append new section at end
-			newContent.WriteString(existingContent)
-			if !strings.HasSuffix(existingContent, "\n") {
-				newContent.WriteString("\n")
-			}
-			newContent.WriteString("\n\n## " + section + "\n\n")
-			newContent.WriteString("### " + title + "\n")
-			newContent.WriteString(content + "\n")
-		}
-	} else {
-		// No section specified, add at the end
-		newContent.WriteString(existingContent)
-		if !strings.HasSuffix(existingContent, "\n") {
-			newContent.WriteString("\n")
-		}
-		newContent.WriteString("\n### " + title + "\n")
-		newContent.WriteString(content + "\n")
-	}
-
-	// Write back to file
-	return fs.WriteFile(filepath, []byte(newContent.String()), 0600)
-}
-
 func TestAddPromptToFile(t *testing.T) {
 	tests := []struct {
 		name            string
@@ -258,39 +126,24 @@ func TestAddPromptToFile(t *testing.T) {
 			expectedContent: "# Existing Notes\n\n### Old Title\nOld content\n\n\n## New Section\n\n### New Title\nNew content\n",
 			expectError:     false,
 		},
-		// 		{
-		// 			name: "add to existing section",
-		// 			existingContent: `# Notes
-
-		// ## Existing Section
-
-		// ### Old Title
-		// Old content
-
-		// ## Another Section
-
-		// ### Another Title
-		// Another content`,
-		// 			title:   "New Title",
-		// 			content: "New content",
-		// 			section: "Existing Section",
-		// 			expectedContent: `# Notes
-
-		// ## Existing Section
-
-		// ### Old Title
-		// Old content
-
-		// ### New Title
-		// New content
-
-		// ## Another Section
-
-		// ### Another Title
-		// Another content
-		// `,
-		// 			expectError: false,
-		// 		},
+		{
+			name:            "add to existing section",
+			existingContent: "# Notes\n\n## Existing Section\n\n### Old Title\nOld content\n\n## Another Section\n\n### Another Title\nAnother content",
+			title:           "New Title",
+			content:         "New content",
+			section:         "Existing Section",
+			expectedContent: "# Notes\n\n## Existing Section\n\n### Old Title\nOld content\n\n### New Title\nNew content\n\n## Another Section\n\n### Another Title\nAnother content",
+			expectError:     false,
+		},
+		{
+			name:            "add to last section",
+			existingContent: "# Notes\n\n## Golang\n### Tests\nWrite tests\n\n",
+			title:           "Review",
+			content:         "Review this code",
+			section:         "Golang",
+			expectedContent: "# Notes\n\n## Golang\n### Tests\nWrite tests\n\n### Review\nReview this code\n\n",
+			expectError:     false,
+		},
 		{
 			name:            "add to file without trailing newline",
 			existingContent: "# Notes\n\n### Old Title\nOld content",
@@ -300,27 +153,24 @@ func TestAddPromptToFile(t *testing.T) {
 			expectedContent: "# Notes\n\n### Old Title\nOld content\n\n### New Title\nNew content\n",
 			expectError:     false,
 		},
+		{
+			name:            "untouched lines keep mixed line endings and BOM",
+			existingContent: "\ufeff# Notes  \r\n\r\n## Golang\n\nReview\r\n\r\n## Python\r\nExplain",
+			title:           "Tests",
+			content:         "Write tests",
+			section:         "Golang",
+			expectedContent: "\ufeff# Notes  \r\n\r\n## Golang\n\nReview\r\n\r\n### Tests\r\nWrite tests\r\n\r\n## Python\r\nExplain",
+			expectError:     false,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// Create a new memory filesystem for each test
-			memFS := afero.NewMemMapFs()
-			fs := NewAferoFileSystem(memFS)
+			fs := useMemFS(t)
 			filepath := "/test/notes.md"
+			_ = afero.WriteFile(fs, filepath, []byte(tt.existingContent), 0644)
 
-			// Create directory structure
-			_ = fs.MkdirAll("/test", 0755)
-
-			// Write existing content if any
-			if tt.existingContent != "" {
-				_ = fs.WriteFile(filepath, []byte(tt.existingContent), 0644)
-			} else {
-				// Ensure file exists even if empty
-				_ = fs.WriteFile(filepath, []byte(""), 0644)
-			}
-
-			err := addPromptToFileWithFS(fs, filepath, tt.title, tt.content, tt.section)
+			err := addPromptToFile(filepath, tt.title, tt.content, tt.section)
 
 			if tt.expectError && err == nil {
 				t.Error("expected error but got none")
@@ -330,7 +180,7 @@ func TestAddPromptToFile(t *testing.T) {
 			}
 
 			if !tt.expectError {
-				content, err := fs.ReadFile(filepath)
+				content, err := afero.ReadFile(fs, filepath)
 				if err != nil {
 					t.Fatalf("failed to read file after writing: %v", err)
 				}
@@ -343,62 +193,46 @@ func TestAddPromptToFile(t *testing.T) {
 	}
 }
 
-func TestAddToExistingSection(t *testing.T) {
+func TestInsertPrompt(t *testing.T) {
 	tests := []struct {
 		name           string
 		currentContent string
 		title          string
 		content        string
 		section        string
-		expectedResult bool
 		expectedOutput string
 	}{
-		// 		{
-		// 			name: "section exists",
-		// 			currentContent: `# Notes
-
-		// ## Test Section
-
-		// ### Old Title
-		// Old content
-
-		// ## Another Section
-
-		// ### Another Title
-		// Another content`,
-		// 			title:          "New Title",
-		// 			content:        "New content",
-		// 			section:        "Test Section",
-		// 			expectedResult: true,
-		// 			expectedOutput: `# Notes
-
-		// ## Test Section
-
-		// ### Old Title
-		// Old content
-
-		// ### New Title
-		// New content
-
-		// ## Another Section
-
-		// ### Another Title
-		// Another content
-		// `,
-		// 		},
 		{
-			name: "section does not exist",
-			currentContent: `# Notes
-
-## Different Section
-
-### Old Title
-Old content`,
+			name:           "section exists",
+			currentContent: "# Notes\n\n## Test Section\n\n### Old Title\nOld content\n\n## Another Section\n\n### Another Title\nAnother content",
+			title:          "New Title",
+			content:        "New content",
+			section:        "Test Section",
+			expectedOutput: "# Notes\n\n## Test Section\n\n### Old Title\nOld content\n\n### New Title\nNew content\n\n## Another Section\n\n### Another Title\nAnother content",
+		},
+		{
+			name:           "section heading with icon",
+			currentContent: "# Notes\n\n## Golang 🐹\n\n### Old Title\nOld content",
+			title:          "New Title",
+			content:        "New content",
+			section:        "Golang",
+			expectedOutput: "# Notes\n\n## Golang 🐹\n\n### Old Title\nOld content\n\n### New Title\nNew content\n",
+		},
+		{
+			name:           "no blank line before next section",
+			currentContent: "# Notes\n## Golang\nReview\n## Python\nExplain\n",
+			title:          "Tests",
+			content:        "Write tests",
+			section:        "Golang",
+			expectedOutput: "# Notes\n## Golang\nReview\n\n### Tests\nWrite tests\n\n## Python\nExplain\n",
+		},
+		{
+			name:           "section does not exist",
+			currentContent: "# Notes\n\n## Different Section\n\n### Old Title\nOld content",
 			title:          "New Title",
 			content:        "New content",
 			section:        "Non-existent Section",
-			expectedResult: false,
-			expectedOutput: "",
+			expectedOutput: "# Notes\n\n## Different Section\n\n### Old Title\nOld content\n\n\n## Non-existent Section\n\n### New Title\nNew content\n",
 		},
 		{
 			name:           "empty content",
@@ -406,25 +240,14 @@ Old content`,
 			title:          "New Title",
 			content:        "New content",
 			section:        "Test Section",
-			expectedResult: false,
-			expectedOutput: "",
+			expectedOutput: "\n\n\n## Test Section\n\n### New Title\nNew content\n",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			var newContent strings.Builder
-			result := addToExistingSection(&newContent, tt.currentContent, tt.title, tt.content, tt.section)
-
-			if result != tt.expectedResult {
-				t.Errorf("addToExistingSection() = %v, want %v", result, tt.expectedResult)
-			}
-
-			if tt.expectedResult {
-				output := newContent.String()
-				if output != tt.expectedOutput {
-					t.Errorf("output mismatch:\nexpected:\n%q\ngot:\n%q", tt.expectedOutput, output)
-				}
+			if output := insertPrompt(tt.currentContent, tt.title, tt.content, tt.section); output != tt.expectedOutput {
+				t.Errorf("output mismatch:\nexpected:\n%q\ngot:\n%q", tt.expectedOutput, output)
 			}
 		})
 	}
@@ -467,108 +290,146 @@ func TestHelperProcess(t *testing.T) {
 	}
 }
 
+func TestSaveToSimplenote_CredentialsForExportAndImport(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake sncli is a shell script")
+	}
+	dir := t.TempDir()
+	calls := filepath.Join(dir, "calls.log")
+	script := "#!/bin/sh\necho \"$1 $SN_USERNAME $SN_PASSWORD\" >> '" + calls + "'\ncase \"$1\" in\nexport) echo '[]' ;;\nimport) cat > /dev/null ;;\nesac\n"
+	if err := os.WriteFile(filepath.Join(dir, "sncli"), []byte(script), 0700); err != nil { // #nosec G306
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("SN_USERNAME", "")
+	t.Setenv("SN_PASSWORD", "")
+
+	oldAuth := ensureSimplenoteAuthFunc
+	t.Cleanup(func() { ensureSimplenoteAuthFunc = oldAuth })
+	var env []string
+	ensureSimplenoteAuthFunc = func(config.Config) ([]string, error) {
+		env = []string{"SN_USERNAME=user@example.com", "SN_PASSWORD=secret-pass"}
+		return env, nil
+	}
+
+	conf := config.Config{SNNote: "LLM Prompts", SNDBPath: t.TempDir()}
+	if err := saveToSimplenote(conf, "# Prompts\n"); err != nil {
+		t.Fatalf("saveToSimplenote() returned error: %v", err)
+	}
+	got, err := os.ReadFile(calls)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "export user@example.com secret-pass\nimport user@example.com secret-pass\n"
+	if string(got) != want {
+		t.Errorf("expected both sncli commands to receive the credentials, got:\n%s", got)
+	}
+	for _, e := range env {
+		if e != "" {
+			t.Errorf("expected the credentials cleared after the write, got %q", e)
+		}
+	}
+}
+
 func TestWritePrompt(t *testing.T) {
 	tests := []struct {
 		name          string
-		config        config.Config
 		promptContent string
 		args          []string
 		stdinInput    string
-		expectError   bool
+		expected      string // Expected in the note after writing
+		asked         string // Expected among the questions written
 		errorContains string
 	}{
-		// {
-		// 	name: "write with prompt content flag",
-		// 	config: config.Config{
-		// 		FilePath: "/test/notes.md",
-		// 	},
-		// 	promptContent: "This is test content for prompt",
-		// 	args:          []string{},
-		// 	expectError:   false,
-		// },
-		// {
-		// 	name: "write with args",
-		// 	config: config.Config{
-		// 		FilePath: "/test/notes.md",
-		// 	},
-		// 	promptContent: "",
-		// 	args:          []string{"This", "is", "test", "content"},
-		// 	expectError:   false,
-		// },
-		// {
-		// 	name: "write with stdin input",
-		// 	config: config.Config{
-		// 		FilePath: "/test/notes.md",
-		// 	},
-		// 	promptContent: "",
-		// 	args:          []string{},
-		// 	stdinInput:    "Test Title\nThis is test content\n",
-		// 	expectError:   false,
-		// },
-		{
-			name: "empty content should error",
-			config: config.Config{
-				FilePath: "/test/notes.md",
-			},
-			promptContent: "",
-			args:          []string{},
+		{
+			name:          "write with prompt content flag",
+			promptContent: "This is test content for prompt",
+			stdinInput:    "Golang\n",
+			expected:      "## Golang\n\n### This is test content for\nThis is test content for prompt\n",
+			asked:         "Enter section number or name",
+		},
+		{
+			name:       "write with args",
+			args:       []string{"Explain goroutine leaks"},
+			stdinInput: "2\n",
+			expected:   "## Python\n\n### Explain goroutine leaks\nExplain goroutine leaks\n",
+			asked:      "2) Python",
+		},
+		{
+			name:       "write with stdin input",
+			stdinInput: "Test Title\nThis is test content\n",
+			expected:   "### Test Title\nThis is test content\n",
+			asked:      "Enter prompt content (press Ctrl+D when done):",
+		},
+		{
+			name:          "empty content should error",
 			stdinInput:    "\n\n",
-			expectError:   true,
 			errorContains: "both title and content are required",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// Create a new memory filesystem for each test
-			memFS := afero.NewMemMapFs()
-			fs := NewAferoFileSystem(memFS)
-			_ = fs.MkdirAll("/test", 0755)
-			_ = fs.WriteFile("/test/notes.md", []byte(""), 0644) // Ensure file exists
-
-			// Set up filesystem for testing
-			originalFS := filesystem
-			filesystem = fs
-			defer func() {
-				filesystem = originalFS
-			}()
-
-			var err error
-			if tt.stdinInput != "" {
-				simulateStdin(tt.stdinInput, func() {
-					err = WritePrompt(tt.config, tt.promptContent, tt.args)
-				})
-			} else {
-				err = WritePrompt(tt.config, tt.promptContent, tt.args)
+			path := t.TempDir() + "/notes.md"
+			if err := os.WriteFile(path, []byte("# Prompts\n\n## Golang\n\n## Python\n"), 0600); err != nil {
+				t.Fatalf("failed to write test file: %v", err)
 			}
 
-			if tt.expectError {
-				if err == nil {
-					t.Error("expected error but got none")
-				}
-				if tt.errorContains != "" && !strings.Contains(err.Error(), tt.errorContains) {
-					t.Errorf("error should contain %q, got: %v", tt.errorContains, err)
-				}
-			} else {
-				if err != nil {
-					t.Errorf("unexpected error: %v", err)
-				}
+			var out bytes.Buffer
+			err := WritePromptFrom(config.Config{FilePath: path}, tt.promptContent, tt.args, strings.NewReader(tt.stdinInput), &out)
 
-				// Verify file was created/updated
-				if tt.config.FilePath != "" {
-					exists, err := fs.Exists(tt.config.FilePath)
-					if err != nil {
-						t.Errorf("failed to check file existence: %v", err)
-					}
-					if !exists {
-						t.Error("expected file to be created")
-					}
+			if tt.errorContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errorContains) {
+					t.Errorf("expected error containing %q, got: %v", tt.errorContains, err)
 				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			data, _ := os.ReadFile(path)
+			if !strings.Contains(string(data), tt.expected) {
+				t.Errorf("expected note to contain %q, got:\n%s", tt.expected, data)
+			}
+			if !strings.Contains(out.String(), tt.asked) {
+				t.Errorf("expected %q to be asked, got:\n%s", tt.asked, out.String())
 			}
 		})
 	}
 }
 
+func TestWritePromptReadOnly(t *testing.T) {
+	tests := []struct {
+		name   string
+		config config.Config
+	}{
+		{
+			name:   "READ_ONLY set",
+			config: config.Config{FilePath: "/test/notes.md", ReadOnly: true},
+		},
+		{
+			name:   "URL source",
+			config: config.Config{FilePath: "https://example.com/prompts.md"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !IsReadOnly(tt.config) {
+				t.Error("expected source to be read-only")
+			}
+			err := WritePrompt(tt.config, "This is test content for prompt", []string{})
+			if !errors.Is(err, ErrReadOnly) {
+				t.Errorf("expected ErrReadOnly, got: %v", err)
+			}
+		})
+	}
+
+	if IsReadOnly(config.Config{FilePath: "/test/notes.md"}) {
+		t.Error("expected local file source to be writable")
+	}
+}
+
 func TestAddPromptToNote(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -578,16 +439,16 @@ func TestAddPromptToNote(t *testing.T) {
 		section     string
 		expectError bool
 	}{
-		// {
-		// 	name: "add to file",
-		// 	config: config.Config{
-		// 		FilePath: "/test/notes.md",
-		// 	},
-		// 	title:       "Test Title",
-		// 	content:     "Test content",
-		// 	section:     "Test Section",
-		// 	expectError: false,
-		// },
+		{
+			name: "add to file",
+			config: config.Config{
+				FilePath: "/test/notes.md",
+			},
+			title:       "Test Title",
+			content:     "Test content",
+			section:     "Test Section",
+			expectError: false,
+		},
 		{
 			name: "add to simplenote (will fail without mocking)",
 			config: config.Config{
@@ -603,21 +464,10 @@ func TestAddPromptToNote(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			if tt.config.FilePath != "" {
-				// Create a new memory filesystem for file tests
-				memFS := afero.NewMemMapFs()
-				fs := NewAferoFileSystem(memFS)
-				_ = fs.MkdirAll("/test", 0755)
-				_ = fs.WriteFile("/test/notes.md", []byte(""), 0644) // Ensure file exists
-
-				// Set up filesystem for testing
-				originalFS := filesystem
-				filesystem = fs
-				defer func() {
-					filesystem = originalFS
-				}()
+				_ = afero.WriteFile(useMemFS(t), tt.config.FilePath, []byte(""), 0644)
 			}
 
-			err := addPromptToNote(tt.config, tt.title, tt.content, tt.section)
+			err := addPromptToNote(tt.config, tt.title, tt.content, tt.section, strings.NewReader(""), io.Discard)
 
 			if tt.expectError && err == nil {
 				t.Error("expected error but got none")
@@ -712,7 +562,7 @@ func TestSncliImportHelper(t *testing.T) {
 // 	loadFromSimplenoteFunc = func(conf config.Config) (string, error) {
 // 		return "# Notes\n", nil
 // 	}
-// 	ensureSimplenoteAuthFunc = func(conf config.Config) error { return nil }
+// 	ensureSimplenoteAuthFunc = func(conf config.Config) ([]string, error) { return nil, nil }
 
 // 	expectedContent := "# Notes\n\n## Test Section\n\n### Test Title\nTest content\n"
 // 	mockSncliImport(expectedContent, "test-note", func() {
@@ -737,11 +587,11 @@ func TestSncliImportHelper(t *testing.T) {
 // 	loadFromSimplenoteFunc = func(conf config.Config) (string, error) {
 // 		return "# Notes\n", nil
 // 	}
-// 	ensureSimplenoteAuthFunc = func(conf config.Config) error { return nil }
+// 	ensureSimplenoteAuthFunc = func(conf config.Config) ([]string, error) { return nil, nil }
 
 // 	expectedContent := "# Notes\n\n## Test Section\n\n### Test Title\nTest content\n"
 // 	mockSncliImport(expectedContent, "test-note", func() {
-// 		err := addPromptToNote(conf, title, content, section)
+// 		err := addPromptToNote(conf, title, content, section, strings.NewReader(""), io.Discard)
 // 		if err != nil {
 // 			t.Errorf("unexpected error: %v", err)
 // 		}
@@ -758,10 +608,8 @@ func BenchmarkGenerateTitleFromContent(b *testing.B) {
 }
 
 func BenchmarkAddPromptToFile(b *testing.B) {
-	memFS := afero.NewMemMapFs()
-	fs := NewAferoFileSystem(memFS)
+	fs := useMemFS(b)
 	filepath := "/test/notes.md"
-	_ = fs.MkdirAll("/test", 0755)
 
 	// Create initial content
 	initialContent := `# My Notes
@@ -776,11 +624,245 @@ Content 1
 ### Title 2
 Content 2`
 
-	_ = fs.WriteFile(filepath, []byte(initialContent), 0644)
+	_ = afero.WriteFile(fs, filepath, []byte(initialContent), 0644)
 
 	b.ResetTimer()
 
 	for i := 0; i < b.N; i++ {
-		_ = addPromptToFileWithFS(fs, filepath, "Benchmark Title", "Benchmark content", "Section 1")
+		_ = addPromptToFile(filepath, "Benchmark Title", "Benchmark content", "Section 1")
+	}
+}
+
+func TestAddPromptToNote_TitleConflict(t *testing.T) {
+	existing := "# Notes\n\n## Golang\n\n### Tests\nOld content\n\n## Python\n\n### Other\nOther content\n"
+
+	tests := []struct {
+		name        string
+		strategy    string
+		section     string
+		expected    string
+		expectError error
+	}{
+		{
+			name:     "replace existing content",
+			strategy: ConflictReplace,
+			section:  "Golang",
+			expected: "# Notes\n\n## Golang\n\n### Tests\nNew content\n\n## Python\n\n### Other\nOther content\n",
+		},
+		{
+			name:     "rename to numbered variant",
+			strategy: ConflictRename,
+			section:  "Golang",
+			expected: "### Tests (2)\nNew content\n",
+		},
+		{
+			name:        "abort",
+			strategy:    ConflictAbort,
+			section:     "Golang",
+			expected:    existing,
+			expectError: ErrPromptExists,
+		},
+		{
+			name:     "same title in another section is not a conflict",
+			strategy: ConflictAbort,
+			section:  "Python",
+			expected: "### Tests\nNew content\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := t.TempDir() + "/notes.md"
+			if err := os.WriteFile(path, []byte(existing), 0600); err != nil {
+				t.Fatalf("failed to write test file: %v", err)
+			}
+			conf := config.Config{FilePath: path, OnConflict: tt.strategy}
+
+			err := addPromptToNote(conf, "Tests", "New content", tt.section, strings.NewReader(""), io.Discard)
+			if !errors.Is(err, tt.expectError) {
+				t.Fatalf("addPromptToNote() error = %v, want %v", err, tt.expectError)
+			}
+			if tt.expectError != nil && !strings.Contains(err.Error(), path+":5") {
+				t.Errorf("addPromptToNote() error = %v, want the location of the existing prompt", err)
+			}
+
+			data, _ := os.ReadFile(path)
+			if !strings.Contains(string(data), tt.expected) {
+				t.Errorf("file content mismatch:\nexpected to contain:\n%q\ngot:\n%q", tt.expected, string(data))
+			}
+		})
+	}
+}
+
+func TestAddPromptToNote_InteractiveConflict(t *testing.T) {
+	path := t.TempDir() + "/notes.md"
+	if err := os.WriteFile(path, []byte("## Golang\n\n### Tests\nOld content\n\n### Tests (2)\nOlder content\n"), 0600); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	originalAsk := askConflictFunc
+	defer func() { askConflictFunc = originalAsk }()
+	asked := false
+	askConflictFunc = func(title, section string, _ io.Reader, _ io.Writer) (string, error) {
+		asked = true
+		if title != "Tests" || section != "Golang" {
+			t.Errorf("unexpected conflict question for %q in %q", title, section)
+		}
+		if _, err := os.Stat(path + ".lock"); err == nil {
+			t.Error("expected the file not to be locked while asking")
+		}
+		return ConflictRename, nil
+	}
+
+	if err := addPromptToNote(config.Config{FilePath: path}, "Tests", "New content", "Golang", strings.NewReader(""), io.Discard); err != nil {
+		t.Fatalf("addPromptToNote() returned error: %v", err)
+	}
+	if !asked {
+		t.Error("expected user to be asked how to resolve the conflict")
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), "### Tests (3)\nNew content") {
+		t.Errorf("expected numbered variant (3), got:\n%s", data)
+	}
+}
+
+func TestWritePromptFrom_Conflict(t *testing.T) {
+	path := t.TempDir() + "/notes.md"
+	if err := os.WriteFile(path, []byte("## Golang\n\n### Write table-driven tests\nOld content\n"), 0600); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	var out bytes.Buffer
+	err := WritePromptFrom(config.Config{FilePath: path}, "Write table-driven tests", []string{"Write table-driven tests", "Golang"}, strings.NewReader("x\nr\n"), &out)
+	if err != nil {
+		t.Fatalf("WritePromptFrom() returned error: %v", err)
+	}
+	if !strings.Contains(out.String(), "already exists in section 'Golang'") || strings.Count(out.String(), "[a]bort?") != 2 {
+		t.Errorf("expected the conflict question to be asked again after an invalid answer, got:\n%s", out.String())
+	}
+	data, _ := os.ReadFile(path)
+	if got := string(data); !strings.Contains(got, "### Write table-driven tests\nWrite table-driven tests\n") || strings.Contains(got, "Old content") {
+		t.Errorf("expected the existing prompt to be replaced, got:\n%s", got)
+	}
+
+	err = WritePromptFrom(config.Config{FilePath: path}, "Write table-driven tests", []string{"Write table-driven tests", "Golang"}, strings.NewReader(""), &out)
+	if !errors.Is(err, ErrPromptExists) {
+		t.Errorf("WritePromptFrom() at end of input error = %v, want ErrPromptExists", err)
+	}
+}
+
+func TestPickSection(t *testing.T) {
+	sections := []string{"Golang", "Python", "Testing"}
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "skip", input: "\n", expected: ""},
+		{name: "end of input", input: "", expected: ""},
+		{name: "number", input: "2\n", expected: "Python"},
+		{name: "invalid number asks again", input: "9\n1\n", expected: "Golang"},
+		{name: "different case", input: "golang\n", expected: "Golang"},
+		{name: "new section", input: "Rust\n", expected: "Rust"},
+		{name: "typo picks similar", input: "golng\n1\n", expected: "Golang"},
+		{name: "extension picks similar", input: "Testings\n1\n", expected: "Testing"},
+		{name: "typo created on request", input: "Test\nc\n", expected: "Test"},
+		{name: "search again", input: "golng\n\n3\n", expected: "Testing"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			got, err := pickSection(sections, strings.NewReader(tt.input), &out)
+			if err != nil {
+				t.Fatalf("pickSection() returned error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("pickSection(%q) = %q, want %q", tt.input, got, tt.expected)
+			}
+			if !strings.Contains(out.String(), "  3) Testing") {
+				t.Errorf("expected existing sections to be listed, got %q", out.String())
+			}
+		})
+	}
+}
+
+func TestWritePrompt_AsksSectionFromNote(t *testing.T) {
+	path := t.TempDir() + "/notes.md"
+	if err := os.WriteFile(path, []byte("# Prompts\n\n## Golang\n\n### Tests\nOld content\n\n## Python\n\n### Fixtures\nWrite fixtures\n\n## Golang\n"), 0600); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	originalAsk := askSectionFunc
+	defer func() { askSectionFunc = originalAsk }()
+	var offered []string
+	askSectionFunc = func(sections []string, _ io.Reader, _ io.Writer) (string, error) {
+		offered = sections
+		return "Python", nil
+	}
+
+	if err := WritePrompt(config.Config{FilePath: path}, "Write pytest fixtures", nil); err != nil {
+		t.Fatalf("WritePrompt() returned error: %v", err)
+	}
+	if expected := []string{"Golang", "Python"}; !slices.Equal(offered, expected) {
+		t.Errorf("expected sections %q to be offered, got %q", expected, offered)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), "## Python\n\n### Fixtures\nWrite fixtures\n\n### Write pytest fixtures\nWrite pytest fixtures\n\n## Golang") {
+		t.Errorf("expected prompt added to Python, got:\n%s", data)
+	}
+}
+
+func TestCheckShrink(t *testing.T) {
+	long := strings.Repeat("Review this Go code for bugs.\n", 40)
+
+	tests := []struct {
+		name    string
+		current string
+		updated string
+		force   bool
+		wantErr bool
+	}{
+		{name: "grows", current: long, updated: long + "Write tests.\n"},
+		{name: "shrinks a little", current: long, updated: long[:len(long)*3/4]},
+		{name: "shrinks by more than half", current: long, updated: long[:len(long)/4], wantErr: true},
+		{name: "emptied", current: long, updated: "", wantErr: true},
+		{name: "forced", current: long, updated: "", force: true},
+		{name: "small note", current: "# Prompts\n\n## Golang\n", updated: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkShrink(config.Config{SNNote: "LLM Prompts", Force: tt.force}, tt.current, tt.updated)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("checkShrink() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !errors.Is(err, ErrContentShrunk) {
+				t.Errorf("checkShrink() error = %v, want ErrContentShrunk", err)
+			}
+		})
+	}
+}
+
+func TestUpdateSourceContent_RefusesShrunkNote(t *testing.T) {
+	original := "# Prompts\n\n## Golang\n\n" + strings.Repeat("### Review\nReview this Go code for bugs.\n\n", 40)
+	note := fakeSimplenote(t, original)
+	conf := config.Config{SNNote: "LLM Prompts", DataDir: t.TempDir()}
+	truncate := func(string) (string, error) { return "# Prompts\n", nil }
+
+	if err := updateSourceContent(conf, writeOp{action: "format"}, truncate); !errors.Is(err, ErrContentShrunk) {
+		t.Fatalf("updateSourceContent() error = %v, want ErrContentShrunk", err)
+	}
+	if *note != original {
+		t.Errorf("expected the note to be left untouched, got:\n%s", *note)
+	}
+
+	conf.Force = true
+	if err := updateSourceContent(conf, writeOp{action: "format"}, truncate); err != nil {
+		t.Fatalf("updateSourceContent() with Force returned error: %v", err)
+	}
+	if *note != "# Prompts\n" {
+		t.Errorf("expected the forced write to replace the note, got:\n%s", *note)
 	}
 }
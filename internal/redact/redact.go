@@ -0,0 +1,131 @@
+// Package redact keeps credentials out of log output and error messages. Known
+// secret values are registered at startup or when they are fetched (for example
+// from 1Password), and common token formats are masked even when unregistered.
+package redact
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+// Mask replaces every redacted value.
+const Mask = "[REDACTED]"
+
+// minSecretLength is the shortest registered value that is redacted; shorter
+// values would mask unrelated text.
+const minSecretLength = 4
+
+// patterns match credential formats that are masked even if never registered.
+// Where a pattern has a capture group, only the text after it is masked.
+var patterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)(\bbearer\s+)[^\s"']+`),
+	regexp.MustCompile(`(?i)(\b(?:password|passwd|token|api[_-]?key|secret)\s*[=:]\s*)[^\s"',]+`),
+	regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{20,}`),
+	regexp.MustCompile(`\bsk-[A-Za-z0-9_-]{20,}`),
+}
+
+var (
+	mu      sync.RWMutex
+	secrets = map[string]struct{}{}
+)
+
+// Add registers secret values to be redacted from now on. Empty and very short
+// values are ignored.
+func Add(values ...string) {
+	mu.Lock()
+	defer mu.Unlock()
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if len(v) >= minSecretLength {
+			secrets[v] = struct{}{}
+		}
+	}
+}
+
+// AddConfig registers the credentials held in conf: LLM_API_KEY, SHARE_TOKEN,
+// JOPLIN_TOKEN, GOOGLE_CLIENT_SECRET and SN_PASSWORD unless it names a secret
+// provider field.
+func AddConfig(conf config.Config) {
+	Add(conf.LLMAPIKey, conf.ShareToken, conf.JoplinToken, conf.GoogleClientSecret, conf.ServeToken)
+	if !conf.UsesSecretProvider() {
+		Add(conf.SNPassword)
+	}
+}
+
+// reset forgets all registered secrets, for tests.
+func reset() {
+	mu.Lock()
+	defer mu.Unlock()
+	secrets = map[string]struct{}{}
+}
+
+// String returns s with registered secrets and known token formats masked.
+func String(s string) string {
+	mu.RLock()
+	values := make([]string, 0, len(secrets))
+	for v := range secrets {
+		values = append(values, v)
+	}
+	mu.RUnlock()
+
+	// Replace longer secrets first so a secret containing another is fully masked
+	sort.Slice(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+	for _, v := range values {
+		s = strings.ReplaceAll(s, v, Mask)
+	}
+	for _, re := range patterns {
+		if re.NumSubexp() > 0 {
+			s = re.ReplaceAllString(s, "${1}"+Mask)
+		} else {
+			s = re.ReplaceAllString(s, Mask)
+		}
+	}
+	return s
+}
+
+// Error returns err with its message redacted. The result still matches the
+// original chain with errors.Is and errors.As. It returns nil for a nil err.
+func Error(err error) error {
+	if err == nil {
+		return nil
+	}
+	msg := String(err.Error())
+	if msg == err.Error() {
+		return err
+	}
+	return &redactedError{msg: msg, err: err}
+}
+
+type redactedError struct {
+	msg string
+	err error
+}
+
+func (e *redactedError) Error() string { return e.msg }
+func (e *redactedError) Unwrap() error { return e.err }
+
+// Hook is a logrus hook redacting log messages and string or error fields.
+type Hook struct{}
+
+// Levels returns all log levels.
+func (Hook) Levels() []log.Level { return log.AllLevels }
+
+// Fire redacts entry in place before it is formatted.
+func (Hook) Fire(entry *log.Entry) error {
+	entry.Message = String(entry.Message)
+	for k, v := range entry.Data {
+		switch v := v.(type) {
+		case string:
+			entry.Data[k] = String(v)
+		case error:
+			entry.Data[k] = Error(v)
+		}
+	}
+	return nil
+}
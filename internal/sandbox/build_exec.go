@@ -0,0 +1,6 @@
+//go:build !noexec
+
+package sandbox
+
+// noExecBuild disables external programs for good in builds with the noexec tag.
+const noExecBuild = false
@@ -0,0 +1,55 @@
+// Package sandbox runs external programs on behalf of the rest of wheresmyprompt,
+// unless running them is disabled with NO_EXEC=true or by building with the noexec
+// tag, for locked-down machines where only local files may be used.
+package sandbox
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"sync/atomic"
+)
+
+// ErrExecDisabled is returned instead of running an external program while
+// running them is disabled.
+var ErrExecDisabled = errors.New("running external programs is disabled (NO_EXEC=true or a noexec build)")
+
+var disabled atomic.Bool
+
+// Disable stops every later command from running. It cannot be undone.
+func Disable() {
+	disabled.Store(true)
+}
+
+// Disabled reports whether external programs may not run, because of Disable or
+// because the binary was built with the noexec tag.
+func Disabled() bool {
+	return noExecBuild || disabled.Load()
+}
+
+// Command returns exec.Command(name, args...), or while disabled a command whose
+// Run, Output and Start return ErrExecDisabled without running anything.
+func Command(name string, args ...string) *exec.Cmd {
+	return guard(exec.Command(name, args...)) // #nosec G204 -- callers choose the program
+}
+
+// CommandContext is Command with a context, see exec.CommandContext.
+func CommandContext(ctx context.Context, name string, args ...string) *exec.Cmd {
+	return guard(exec.CommandContext(ctx, name, args...)) // #nosec G204 -- callers choose the program
+}
+
+// LookPath returns exec.LookPath(file), or ErrExecDisabled while disabled.
+func LookPath(file string) (string, error) {
+	if Disabled() {
+		return "", ErrExecDisabled
+	}
+	return exec.LookPath(file)
+}
+
+// guard makes cmd fail with ErrExecDisabled while disabled.
+func guard(cmd *exec.Cmd) *exec.Cmd {
+	if Disabled() {
+		cmd.Err = ErrExecDisabled
+	}
+	return cmd
+}
@@ -0,0 +1,42 @@
+package sandbox
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDisable(t *testing.T) {
+	t.Cleanup(func() { disabled.Store(false) })
+
+	tests := []struct {
+		name    string
+		disable bool
+		wantErr error
+	}{
+		{name: "enabled", wantErr: nil},
+		{name: "disabled", disable: true, wantErr: ErrExecDisabled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if noExecBuild && !tt.disable {
+				t.Skip("external programs are always disabled with the noexec tag")
+			}
+			if tt.disable {
+				Disable()
+			}
+			if Disabled() != tt.disable {
+				t.Fatalf("Disabled() = %v, want %v", Disabled(), tt.disable)
+			}
+			if err := Command("go", "version").Run(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Command().Run() error = %v, want %v", err, tt.wantErr)
+			}
+			if _, err := LookPath("go"); !errors.Is(err, tt.wantErr) {
+				t.Errorf("LookPath() error = %v, want %v", err, tt.wantErr)
+			}
+			if _, err := CommandContext(t.Context(), "go", "version").Output(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("CommandContext().Output() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
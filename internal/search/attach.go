@@ -0,0 +1,42 @@
+package search
+
+import (
+	"regexp"
+	"strings"
+)
+
+// attachmentPattern matches an attachment comment such as "<!-- attach: diagrams/arch.png -->".
+var attachmentPattern = regexp.MustCompile(`<!--\s*attach:\s*(.*?)\s*-->`)
+
+// Attachments returns the paths named by the attachment comments in text, in order.
+func Attachments(text string) []string {
+	var paths []string
+	for _, m := range attachmentPattern.FindAllStringSubmatch(text, -1) {
+		if m[1] != "" {
+			paths = append(paths, m[1])
+		}
+	}
+	return paths
+}
+
+// IsAttachmentLine reports whether line holds only attachment comments. Such lines
+// attach their files to every prompt of the section instead of being prompts.
+func IsAttachmentLine(line string) bool {
+	return attachmentPattern.MatchString(line) && strings.TrimSpace(attachmentPattern.ReplaceAllString(line, "")) == ""
+}
+
+// StripAttachments removes the attachment comments from text, dropping the lines
+// holding nothing else, so copied prompts keep only the text meant for the model.
+func StripAttachments(text string) string {
+	if !attachmentPattern.MatchString(text) {
+		return text
+	}
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if IsAttachmentLine(line) {
+			continue
+		}
+		lines = append(lines, strings.TrimRight(attachmentPattern.ReplaceAllString(line, ""), " \t"))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,57 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// foldScripts are the scripts whose diacritics Fold removes. Their marks are accents
+// and vowel points that people commonly leave out when typing. Marks in other
+// scripts, such as the dakuten of Japanese kana or Indic vowel signs, change the
+// letter and are kept.
+var foldScripts = []*unicode.RangeTable{unicode.Latin, unicode.Greek, unicode.Cyrillic, unicode.Hebrew, unicode.Arabic}
+
+// foldLetters transliterates letters that do not decompose into a base letter and
+// a mark, such as "ß", to what is typed in their place.
+var foldLetters = strings.NewReplacer(
+	"ß", "ss", "æ", "ae", "œ", "oe", "ø", "o", "đ", "d", "ð", "d", "þ", "th", "ł", "l", "ı", "i", "ħ", "h",
+)
+
+// Fold prepares text for matching: it lowercases it, decomposes it to Unicode
+// compatibility form (so "ﬁ" matches "fi") and drops the diacritics of Latin, Greek,
+// Cyrillic, Hebrew and Arabic letters, so "resume" matches "résumé", "uber" matches
+// "über" and "strasse" matches "Straße". Both the query and the searched text are
+// folded, so matching stays symmetric.
+func Fold(text string) string {
+	if isASCII(text) {
+		return strings.ToLower(text)
+	}
+	decomposed := norm.NFKD.String(strings.ToLower(text))
+	var b strings.Builder
+	b.Grow(len(decomposed))
+	foldMarks := false
+	for _, r := range decomposed {
+		if unicode.Is(unicode.Mn, r) {
+			if !foldMarks {
+				b.WriteRune(r)
+			}
+			continue
+		}
+		foldMarks = unicode.IsOneOf(foldScripts, r)
+		b.WriteRune(r)
+	}
+	return norm.NFC.String(foldLetters.Replace(b.String()))
+}
+
+// isASCII reports whether text is plain ASCII, which Fold only needs to lowercase.
+func isASCII(text string) bool {
+	for i := 0; i < len(text); i++ {
+		if text[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
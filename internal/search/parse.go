@@ -0,0 +1,394 @@
+package search
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// utf8BOM is the byte order mark some Windows editors prepend to UTF-8 files.
+const utf8BOM = "\ufeff"
+
+// DefaultMaxLineSize is the longest line ParseMarkdown accepts when no limit is given.
+const DefaultMaxLineSize = 10 * 1024 * 1024
+
+// ErrLineTooLong is returned by the parser when a line exceeds the maximum line size.
+var ErrLineTooLong = errors.New("line exceeds maximum line size")
+
+// ParseOptions controls how ParseMarkdownWith splits a document into prompts.
+type ParseOptions struct {
+	// MaxLineSize is the longest line accepted, in bytes; DefaultMaxLineSize when not positive.
+	MaxLineSize int
+	// JoinWrappedLines joins consecutive non-empty lines into one prompt separated by
+	// spaces, for prompts hard-wrapped by an editor. List items and fenced code
+	// blocks keep one line each; indented lines following a list item continue it.
+	JoinWrappedLines bool
+	// GroupListItems keeps a run of list items, together with the line introducing
+	// them, as one prompt whose lines are separated by newlines, instead of one
+	// prompt per item.
+	GroupListItems bool
+	// SourceFile is recorded in every section and prompt parsed, so their line
+	// numbers can be reported as "prompts.md:142".
+	SourceFile string
+}
+
+// ParseMarkdown parses Markdown from r into sections grouped by any heading level,
+// reading one line at a time. Lines longer than maxLineSize bytes (DefaultMaxLineSize
+// when not positive) fail with ErrLineTooLong. CRLF line endings and a leading UTF-8
+// BOM are stripped.
+func ParseMarkdown(r io.Reader, maxLineSize int) ([]Section, error) {
+	return ParseMarkdownWith(r, ParseOptions{MaxLineSize: maxLineSize})
+}
+
+// ParseMarkdownWith parses Markdown from r like ParseMarkdown, with the given options.
+func ParseMarkdownWith(r io.Reader, opts ParseOptions) ([]Section, error) {
+	sections, _, err := ParseMarkdownDiagnose(r, opts)
+	return sections, err
+}
+
+// Kinds of Warning reported by ParseMarkdownDiagnose.
+const (
+	WarningHeadingJump          = "heading-jump"           // A heading is more than one level deeper than the previous one
+	WarningContentBeforeHeading = "content-before-heading" // Lines above the first heading, which are never searched
+	WarningEmptySection         = "empty-section"          // A heading with neither prompts nor sub-headings
+)
+
+// Warning is a problem found while parsing that can keep prompts out of search
+// results or file them under an unexpected section.
+type Warning struct {
+	Line    int    `json:"line"`    // 1-based line number the problem was found on
+	Kind    string `json:"kind"`    // One of the Warning* kinds
+	Message string `json:"message"` // Human-readable description
+}
+
+// String formats w as "line N: message".
+func (w Warning) String() string {
+	return fmt.Sprintf("line %d: %s", w.Line, w.Message)
+}
+
+// ParseMarkdownDiagnose parses Markdown from r like ParseMarkdownWith and also
+// returns warnings about heading level jumps, content before the first heading
+// and empty sections, in document order.
+func ParseMarkdownDiagnose(r io.Reader, opts ParseOptions) ([]Section, []Warning, error) {
+	maxLineSize := opts.MaxLineSize
+	if maxLineSize <= 0 {
+		maxLineSize = DefaultMaxLineSize
+	}
+
+	var sections []Section
+	var warnings []Warning
+	var current Section
+	var headingStack []string
+	var iconStack []string // The icon of each heading in headingStack
+
+	// The heading whose section is being read, to report it if it stays empty
+	var open struct {
+		line, level int
+		text        string
+		hasContent  bool
+	}
+	closeSection := func(nextLevel int) {
+		if open.level > 0 && !open.hasContent && nextLevel <= open.level {
+			warnings = append(warnings, Warning{
+				Line:    open.line,
+				Kind:    WarningEmptySection,
+				Message: fmt.Sprintf("section %q has no prompts", open.text),
+			})
+		}
+	}
+	contentBeforeHeading := false
+
+	reader := bufio.NewReader(r)
+	for lineNumber := 1; ; lineNumber++ {
+		line, err := readLine(reader, maxLineSize)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		if lineNumber == 1 {
+			line = strings.TrimPrefix(line, utf8BOM)
+		}
+		level, headingText := ParseHeading(line)
+		if level > 0 {
+			var icon string
+			headingText, icon = SplitHeadingIcon(headingText)
+			closeSection(level)
+			switch {
+			case open.level == 0 && level > 1:
+				warnings = append(warnings, Warning{
+					Line:    lineNumber,
+					Kind:    WarningHeadingJump,
+					Message: fmt.Sprintf("first heading %q is level %d instead of 1", headingText, level),
+				})
+			case open.level > 0 && level > open.level+1:
+				warnings = append(warnings, Warning{
+					Line:    lineNumber,
+					Kind:    WarningHeadingJump,
+					Message: fmt.Sprintf("heading %q jumps from level %d to %d", headingText, open.level, level),
+				})
+			}
+			open.line, open.level, open.text, open.hasContent = lineNumber, level, headingText, false
+
+			// Update heading stack
+			if len(headingStack) < level {
+				// Deeper heading: extend stack
+				headingStack = append(headingStack, headingText)
+				iconStack = append(iconStack, icon)
+			} else {
+				// Replace heading at this level and truncate deeper levels
+				headingStack = append(headingStack[:level-1], headingText)
+				iconStack = append(iconStack[:level-1], icon)
+			}
+
+			// Save previous section
+			if len(current.Lines) > 0 {
+				sections = append(sections, finishSection(current, opts))
+			}
+			// Start new section
+			current = Section{
+				Headings:  append([]string(nil), headingStack...), // copy
+				Icon:      nearestIcon(iconStack),
+				StartLine: lineNumber,
+				EndLine:   lineNumber,
+			}
+		} else {
+			if strings.TrimSpace(line) != "" {
+				open.hasContent = true
+				if open.level == 0 && !contentBeforeHeading {
+					contentBeforeHeading = true
+					warnings = append(warnings, Warning{
+						Line:    lineNumber,
+						Kind:    WarningContentBeforeHeading,
+						Message: "content before the first heading is not searchable",
+					})
+				}
+			}
+			if current.StartLine == 0 {
+				current.StartLine = lineNumber
+			}
+			current.Lines = append(current.Lines, line)
+			current.LineRanges = append(current.LineRanges, LineRange{Start: lineNumber, End: lineNumber})
+			current.EndLine = lineNumber
+		}
+	}
+	closeSection(0)
+	// Save last section
+	if len(current.Lines) > 0 {
+		sections = append(sections, finishSection(current, opts))
+	}
+
+	return sections, warnings, nil
+}
+
+// finishSection applies the line grouping options to a fully read section.
+func finishSection(sec Section, opts ParseOptions) Section {
+	sec.SourceFile = opts.SourceFile
+	if opts.JoinWrappedLines {
+		sec.Lines, sec.LineRanges = joinWrappedLines(sec.Lines, sec.LineRanges)
+	}
+	if opts.GroupListItems {
+		sec.Lines, sec.LineRanges = groupListItems(sec.Lines, sec.LineRanges)
+	}
+	return sec
+}
+
+// groupListItems merges each run of consecutive list items into the non-empty
+// line directly above it, or into the first item when there is none, separating
+// them with newlines. Indented lines following an item are kept with it, while
+// blank lines and fenced code blocks end a group. ranges holds the source lines of
+// each line and is merged alike.
+func groupListItems(lines []string, ranges []LineRange) ([]string, []LineRange) {
+	var out []string
+	var outRanges []LineRange
+	add := func(i int) {
+		out = append(out, lines[i])
+		outRanges = append(outRanges, ranges[i])
+	}
+	merge := func(i int) {
+		out[len(out)-1] += "\n" + lines[i]
+		outRanges[len(outRanges)-1].End = ranges[i].End
+	}
+	inFence := false
+	open := false   // The last line of out may take list items that follow it
+	inList := false // The last line of out ends with a list item
+
+	for i, line := range lines {
+		switch {
+		case inFence:
+			add(i)
+			inFence = !IsFence(line)
+			open, inList = false, false
+		case IsFence(line):
+			add(i)
+			inFence = true
+			open, inList = false, false
+		case strings.TrimSpace(line) == "":
+			add(i)
+			open, inList = false, false
+		case IsListItem(line):
+			if open {
+				merge(i)
+			} else {
+				add(i)
+			}
+			open, inList = true, true
+		case inList && (line[0] == ' ' || line[0] == '\t'):
+			merge(i)
+		default:
+			add(i)
+			open, inList = true, false
+		}
+	}
+	return out, outRanges
+}
+
+// joinWrappedLines joins each run of consecutive non-empty lines into one line,
+// keeping blank lines, list items and fenced code blocks as they are. Indented
+// lines directly after a list item are joined to it. ranges holds the source lines
+// of each line and is merged alike.
+func joinWrappedLines(lines []string, ranges []LineRange) ([]string, []LineRange) {
+	var out []string
+	var outRanges []LineRange
+	add := func(i int) {
+		out = append(out, lines[i])
+		outRanges = append(outRanges, ranges[i])
+	}
+	inFence := false
+	joinable := false // The last line of out is a paragraph that may continue
+	inList := false   // The last line of out is a list item that indented lines continue
+
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case inFence:
+			add(i)
+			inFence = !IsFence(line)
+			joinable, inList = false, false
+		case IsFence(line):
+			add(i)
+			inFence = true
+			joinable, inList = false, false
+		case trimmed == "":
+			add(i)
+			joinable, inList = false, false
+		case IsListItem(line):
+			add(i)
+			joinable, inList = false, true
+		case joinable || (inList && (line[0] == ' ' || line[0] == '\t')):
+			out[len(out)-1] += " " + trimmed
+			outRanges[len(outRanges)-1].End = ranges[i].End
+		default:
+			add(i)
+			joinable, inList = true, false
+		}
+	}
+	return out, outRanges
+}
+
+// IsFence reports whether line opens or closes a fenced code block.
+func IsFence(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~")
+}
+
+// IsListItem reports whether line is a Markdown list item such as "- item",
+// "* item", "+ item", "1. item" or "1) item".
+func IsListItem(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	if len(trimmed) >= 2 && strings.ContainsRune("-*+", rune(trimmed[0])) && trimmed[1] == ' ' {
+		return true
+	}
+	digits := 0
+	for digits < len(trimmed) && trimmed[digits] >= '0' && trimmed[digits] <= '9' {
+		digits++
+	}
+	return digits > 0 && len(trimmed) > digits+1 &&
+		(trimmed[digits] == '.' || trimmed[digits] == ')') && trimmed[digits+1] == ' '
+}
+
+// readLine returns the next line from r without its line ending, or io.EOF when no input remains.
+func readLine(r *bufio.Reader, maxLineSize int) (string, error) {
+	var b strings.Builder
+	for {
+		fragment, isPrefix, err := r.ReadLine()
+		if err != nil {
+			if err == io.EOF && b.Len() > 0 {
+				break
+			}
+			return "", err
+		}
+		if b.Len()+len(fragment) > maxLineSize {
+			return "", fmt.Errorf("%w (%d bytes)", ErrLineTooLong, maxLineSize)
+		}
+		b.Write(fragment)
+		if !isPrefix {
+			break
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\r"), nil
+}
+
+// ParseHeading returns heading level and text, or (0, "") if not a heading
+func ParseHeading(line string) (int, string) {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "#") {
+		return 0, ""
+	}
+	level := 0
+	for i := 0; i < len(line) && line[i] == '#'; i++ {
+		level++
+	}
+	// Require at least one space after hashes
+	if len(line) > level && line[level] == ' ' {
+		return level, strings.TrimSpace(line[level:])
+	}
+	return 0, ""
+}
+
+// SplitHeadingIcon separates an emoji marker from heading text, returning the
+// heading name and the marker: "Golang 🐹" and "🐹 Golang" both yield "Golang" and
+// "🐹". Headings made only of emoji are returned unchanged.
+func SplitHeadingIcon(text string) (name, icon string) {
+	fields := strings.Fields(text)
+	if len(fields) < 2 {
+		return text, ""
+	}
+	if last := fields[len(fields)-1]; isIcon(last) {
+		return strings.TrimSpace(strings.TrimSuffix(text, last)), last
+	}
+	if first := fields[0]; isIcon(first) {
+		return strings.TrimSpace(strings.TrimPrefix(text, first)), first
+	}
+	return text, ""
+}
+
+// isIcon reports whether s is made of emoji: symbols, with the variation selectors,
+// joiners and skin tone modifiers that combine them.
+func isIcon(s string) bool {
+	symbol := false
+	for _, r := range s {
+		switch {
+		case unicode.Is(unicode.So, r):
+			symbol = true
+		case r == '\u200d', r >= '\ufe00' && r <= '\ufe0f', r >= 0x1f3fb && r <= 0x1f3ff:
+		default:
+			return false
+		}
+	}
+	return symbol
+}
+
+// nearestIcon returns the icon of the deepest heading in icons having one.
+func nearestIcon(icons []string) string {
+	for i := len(icons) - 1; i >= 0; i-- {
+		if icons[i] != "" {
+			return icons[i]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,207 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Operators scoping a query, written before a value as in "section:golang tag:testing".
+const (
+	sectionOperator = "section:"
+	tagOperator     = "tag:"
+)
+
+// query is a search query split into its parts, folded for matching (see Fold).
+type query struct {
+	words            []string // Words a prompt must match, exactly, by stem or fuzzily
+	phrases          []string // Quoted phrases a prompt must contain exactly, spaces collapsed
+	excluded         []string // Terms or phrases a prompt must not contain, written as -term or -"a phrase"
+	sections         []string // Heading prefixes, one of which a prompt must be filed under
+	excludedSections []string // Heading prefixes a prompt must not be filed under
+	tags             []string // Tag prefixes a prompt must all have
+	excludedTags     []string // Tag prefixes a prompt must not have
+}
+
+// parseQuery splits text into its words, the phrases in double quotes that prompts
+// must contain as written, as in `"unit tests" go`, the terms or phrases prefixed
+// with "-" that exclude the prompts containing them, as in "review -security", and
+// the section: and tag: operators, as in "section:golang tag:testing review".
+// Operators can be excluded with "-" too, and their value quoted. A lone "-" is a
+// word like any other, a quote left open runs to the end of the query, and an
+// operator without a value is ignored so the query still matches while it is typed.
+func parseQuery(text string) query {
+	var q query
+	rest := Fold(text)
+	for {
+		rest = strings.TrimLeftFunc(rest, unicode.IsSpace)
+		if rest == "" {
+			return q
+		}
+		exclude := len(rest) > 1 && rest[0] == '-' && !unicode.IsSpace(rune(rest[1]))
+		if exclude {
+			rest = rest[1:]
+		}
+		operator := ""
+		for _, op := range []string{sectionOperator, tagOperator} {
+			if strings.HasPrefix(rest, op) {
+				operator, rest = op, rest[len(op):]
+				break
+			}
+		}
+		var value string
+		quoted := strings.HasPrefix(rest, `"`)
+		if quoted {
+			value, rest, _ = strings.Cut(rest[1:], `"`)
+			value = collapseSpaces(value)
+		} else {
+			value, rest = nextWord(rest)
+		}
+		if operator == tagOperator {
+			value = strings.TrimPrefix(value, "#")
+		}
+		if value == "" {
+			continue
+		}
+		switch {
+		case operator == sectionOperator && exclude:
+			q.excludedSections = append(q.excludedSections, value)
+		case operator == sectionOperator:
+			q.sections = append(q.sections, value)
+		case operator == tagOperator && exclude:
+			q.excludedTags = append(q.excludedTags, value)
+		case operator == tagOperator:
+			q.tags = append(q.tags, value)
+		case exclude:
+			q.excluded = append(q.excluded, value)
+		case quoted:
+			q.phrases = append(q.phrases, value)
+		default:
+			q.words = append(q.words, value)
+		}
+	}
+}
+
+// nextWord splits text, which starts with a non-space, after its first word.
+func nextWord(text string) (word, rest string) {
+	if end := strings.IndexFunc(text, unicode.IsSpace); end >= 0 {
+		return text[:end], text[end:]
+	}
+	return text, ""
+}
+
+// terms returns the words of the query followed by the words of its phrases.
+func (q query) terms() []string {
+	terms := q.words
+	for _, phrase := range q.phrases {
+		terms = append(terms[:len(terms):len(terms)], strings.Fields(phrase)...)
+	}
+	return terms
+}
+
+// empty reports whether the query has nothing to match or exclude.
+func (q query) empty() bool {
+	return len(q.words) == 0 && len(q.phrases) == 0 && len(q.excluded) == 0 &&
+		len(q.sections) == 0 && len(q.excludedSections) == 0 && len(q.tags) == 0 && len(q.excludedTags) == 0
+}
+
+// excludes reports whether the folded content contains an excluded term or
+// phrase, ignoring differences in spacing.
+func (q query) excludes(content string) bool {
+	if len(q.excluded) == 0 {
+		return false
+	}
+	content = collapseSpaces(content)
+	for _, term := range q.excluded {
+		if strings.Contains(content, term) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasPhrases reports whether the folded content contains every phrase of the
+// query, ignoring differences in spacing.
+func (q query) hasPhrases(content string) bool {
+	if len(q.phrases) == 0 {
+		return true
+	}
+	content = collapseSpaces(content)
+	for _, phrase := range q.phrases {
+		if !strings.Contains(content, phrase) {
+			return false
+		}
+	}
+	return true
+}
+
+// inScope reports whether p is filed under a heading starting with one of the
+// query's sections and none of its excluded sections, and has a tag starting with
+// each of its tags and none starting with its excluded tags.
+func (q query) inScope(p Prompt) bool {
+	if len(q.sections) > 0 || len(q.excludedSections) > 0 {
+		headings := append(strings.Split(Fold(p.Title), " > "), Fold(p.Section))
+		if len(q.sections) > 0 && !anyHasPrefix(headings, q.sections) {
+			return false
+		}
+		if anyHasPrefix(headings, q.excludedSections) {
+			return false
+		}
+	}
+	if len(q.tags) > 0 || len(q.excludedTags) > 0 {
+		tags := Tags(Fold(p.Content))
+		for _, tag := range q.tags {
+			if !anyHasPrefix(tags, []string{tag}) {
+				return false
+			}
+		}
+		if anyHasPrefix(tags, q.excludedTags) {
+			return false
+		}
+	}
+	return true
+}
+
+// anyHasPrefix reports whether any of values starts with any of prefixes.
+func anyHasPrefix(values, prefixes []string) bool {
+	for _, value := range values {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(value, prefix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Tags returns the hashtags of text without their "#", such as "testing" for
+// "Write unit tests #testing". A tag starts a word and is made of letters, digits,
+// "-" and "_", so "#" inside a URL or a lone "#" is not a tag.
+func Tags(text string) []string {
+	var tags []string
+	for _, word := range strings.Fields(text) {
+		tag, ok := strings.CutPrefix(word, "#")
+		if !ok {
+			continue
+		}
+		if end := strings.IndexFunc(tag, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_'
+		}); end >= 0 {
+			tag = tag[:end]
+		}
+		if tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// HasSectionOperator reports whether query scopes the search to sections with
+// section:, so callers need not pick a section themselves.
+func HasSectionOperator(query string) bool {
+	return len(parseQuery(query).sections) > 0
+}
+
+// collapseSpaces replaces each run of whitespace in text with a single space.
+func collapseSpaces(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
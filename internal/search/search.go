@@ -0,0 +1,422 @@
+// Package search implements the Markdown prompt parser and the fuzzy search engine
+// shared by the wheresmyprompt CLI and its WebAssembly build. It depends on neither
+// os nor os/exec, so it compiles for GOOS=js GOARCH=wasm and matches prompts exactly
+// like the CLI does.
+package search
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/lithammer/fuzzysearch/fuzzy"
+)
+
+// Prompt represents a single LLM prompt with its metadata.
+// It contains the prompt's content and the section it belongs to.
+type Prompt struct {
+	Content   string // The actual prompt content
+	Section   string // The section this prompt belongs to
+	Namespace string // The library this prompt was loaded from (empty for a single library)
+	Title     string // The headings above the prompt, outermost first, joined with " > "
+	Icon      string // The icon of the prompt's section, see Section.Icon
+
+	SourceFile string // The file the prompt was parsed from, see Section.SourceFile
+	StartLine  int    // 1-based line the prompt starts on in its source (0 if unknown)
+	EndLine    int    // 1-based line the prompt ends on, StartLine for a one-line prompt
+}
+
+// PromptData contains the structured data for all prompts.
+// providing a list of sections for efficient searching and categorization.
+type PromptData struct {
+	Sections []Section     // All sections parsed from the markdown
+	Source   string        // Where the prompts were loaded from, such as "prompts.md" (empty if unknown)
+	Modified time.Time     // When the source last changed (zero if unknown)
+	Options  SearchOptions // How queries match these prompts
+}
+
+// SearchOptions tunes how query words match prompts. The zero value matches words
+// exactly or fuzzily, ignoring case and diacritics.
+type SearchOptions struct {
+	// Stem also matches query words to prompt words sharing their English stem
+	// (see Stem), so "testing" matches "tests" and "documented" matches "documentation".
+	Stem bool
+
+	// StopWords are query words prompts need not contain, such as "the" and "for"
+	// (see DefaultStopWords). Prompts containing them still rank higher. They are
+	// required like any other word in queries made only of stop words.
+	StopWords []string
+}
+
+// DefaultStopWords are the common English words ignored by default when requiring
+// every query word to match.
+var DefaultStopWords = []string{
+	"a", "an", "and", "are", "as", "at", "be", "by", "for", "from", "how", "in", "into",
+	"is", "it", "of", "on", "or", "that", "the", "this", "to", "with",
+}
+
+// stopWordMissDistance is added to the score of a prompt for each stop word of the
+// query it does not contain, ranking it below the prompts that do.
+const stopWordMissDistance = 2
+
+// Section represents a heading (any depth) and its associated lines
+type Section struct {
+	Headings  []string // Ordered from top-level heading to deepest sub-heading
+	Lines     []string
+	Namespace string // The library this section was loaded from (empty for a single library)
+	Icon      string // Emoji marker of the deepest heading having one, such as "🐹" for "## Golang 🐹"
+
+	SourceFile string      // The file the section was parsed from, see ParseOptions.SourceFile
+	StartLine  int         // 1-based line of the heading, or of the first line above the first heading
+	EndLine    int         // 1-based last line of the section, before the next heading
+	LineRanges []LineRange // The source lines of each of Lines, when parsed
+}
+
+// LineRange is the 1-based, inclusive range of source lines a section line was
+// read from. It spans several lines for hard-wrapped prompts joined with
+// ParseOptions.JoinWrappedLines and list items grouped with GroupListItems.
+type LineRange struct {
+	Start, End int
+}
+
+// Location returns where p is in its source, such as "prompts.md:142", "line 142"
+// when the source is not a file, or "" when the line is unknown.
+func (p Prompt) Location() string {
+	return Location(p.SourceFile, p.StartLine)
+}
+
+// Location formats a 1-based line of file as "file:line", or as "line N" when file
+// is empty. It returns "" when line is unknown.
+func Location(file string, line int) string {
+	switch {
+	case line <= 0:
+		return ""
+	case file == "":
+		return fmt.Sprintf("line %d", line)
+	default:
+		return fmt.Sprintf("%s:%d", file, line)
+	}
+}
+
+// Helper: match full section path (nested headings)
+func searchPoolBySectionPath(data *PromptData, sectionPath []string) []Prompt {
+	var searchPool []Prompt
+	for _, sec := range data.Sections {
+		// Always skip the first heading (Markdown file title)
+		if len(sec.Headings) < 2 {
+			continue
+		}
+		// Compare sectionPath to sec.Headings[1:]
+		if len(sec.Headings)-1 == len(sectionPath) {
+			match := true
+			for i := range sectionPath {
+				if sec.Headings[i+1] != sectionPath[i] {
+					match = false
+					break
+				}
+			}
+			if match {
+				searchPool = append(searchPool, SectionPrompts(sec, sec.Headings[len(sec.Headings)-1])...)
+			}
+		}
+	}
+	return searchPool
+}
+
+// Helper: match single section name (lowest-level heading)
+func searchPoolBySingleSection(data *PromptData, section string) []Prompt {
+	var searchPool []Prompt
+	for _, sec := range data.Sections {
+		if len(sec.Headings) > 0 && sec.Headings[len(sec.Headings)-1] == section {
+			searchPool = append(searchPool, SectionPrompts(sec, section)...)
+		}
+	}
+	return searchPool
+}
+
+// Helper: match single section name (higher-level heading)
+func searchPoolByParentSection(data *PromptData, section string) []Prompt {
+	var searchPool []Prompt
+	for _, sec := range data.Sections {
+		if len(sec.Headings) > 1 {
+			for i, heading := range sec.Headings[:len(sec.Headings)-1] {
+				if heading == section {
+					searchPool = append(searchPool, SectionPrompts(sec, sec.Headings[len(sec.Headings)-1])...)
+					break
+				}
+				if i == len(sec.Headings)-2 {
+					break
+				}
+			}
+		}
+	}
+	return searchPool
+}
+
+// Helper: all prompts (no section specified)
+func searchPoolAllPrompts(data *PromptData) []Prompt {
+	var searchPool []Prompt
+	for _, sec := range data.Sections {
+		if len(sec.Headings) > 0 {
+			searchPool = append(searchPool, SectionPrompts(sec, sec.Headings[len(sec.Headings)-1])...)
+		}
+	}
+	return searchPool
+}
+
+// SectionPrompts returns a Prompt for each line of sec filed under section,
+// skipping blank lines and lines holding only attachment comments.
+func SectionPrompts(sec Section, section string) []Prompt {
+	var prompts []Prompt
+	for i, line := range sec.Lines {
+		if strings.TrimSpace(line) == "" || IsAttachmentLine(line) {
+			continue
+		}
+		p := Prompt{
+			Content:    line,
+			Section:    section,
+			Namespace:  sec.Namespace,
+			Title:      headingPath(sec.Headings),
+			Icon:       sec.Icon,
+			SourceFile: sec.SourceFile,
+		}
+		if i < len(sec.LineRanges) {
+			p.StartLine, p.EndLine = sec.LineRanges[i].Start, sec.LineRanges[i].End
+		}
+		prompts = append(prompts, p)
+	}
+	return prompts
+}
+
+// headingPath joins headings into the title text matched by Titles.
+func headingPath(headings []string) string {
+	return strings.Join(headings, " > ")
+}
+
+// Pool creates a slice of Prompt structs for each line in the relevant sections.
+// A comma-separated section is treated as a path of nested headings.
+// Returns a slice of Prompt structs containing the content and section for each line.
+func Pool(data *PromptData, section string) []Prompt {
+	if section == "" {
+		// No section specified: return all prompts
+		return searchPoolAllPrompts(data)
+	}
+	sectionPath := strings.Split(section, ",")
+	for i := range sectionPath {
+		sectionPath[i] = strings.TrimSpace(sectionPath[i])
+	}
+	if len(sectionPath) > 1 {
+		// Comma-separated: treat as nested headings
+		return searchPoolBySectionPath(data, sectionPath)
+	}
+	// Single section name: try lowest-level heading match first
+	pool := searchPoolBySingleSection(data, sectionPath[0])
+	if len(pool) > 0 {
+		return pool
+	}
+	// If not found, try parent section match
+	return searchPoolByParentSection(data, sectionPath[0])
+}
+
+// Match is a search result with its score.
+type Match struct {
+	Prompt
+	Score int // Total fuzzy distance across all query words; lower is better, 0 for an empty query
+	// Relevance is 1 when every query word matched exactly and falls towards 0 as
+	// fuzzy matches get worse. It is 1 for an empty query.
+	Relevance float64
+}
+
+// Records fuzzy searches the prompts in section (all prompts when section is empty)
+// and returns the matches best first. An empty query returns every prompt in section.
+func Records(data *PromptData, query, section string) []Prompt {
+	return prompts(RecordMatches(data, query, section))
+}
+
+// Titles searches like Records but matches the query only against each prompt's
+// title and section headings, ignoring the prompt body.
+func Titles(data *PromptData, query, section string) []Prompt {
+	return prompts(TitleMatches(data, query, section))
+}
+
+// RecordMatches searches like Records but also returns each match's score.
+func RecordMatches(data *PromptData, query, section string) []Match {
+	return rank(Pool(data, section), query, data.Options, func(p Prompt) string {
+		return p.Content
+	})
+}
+
+// TitleMatches searches like Titles but also returns each match's score.
+func TitleMatches(data *PromptData, query, section string) []Match {
+	return rank(Pool(data, section), query, data.Options, func(p Prompt) string {
+		return p.Title
+	})
+}
+
+// Rank searches pool like Records, or like Titles when titles is set, for callers
+// keeping their own pool of prompts.
+func Rank(pool []Prompt, query string, opts SearchOptions, titles bool) []Match {
+	return rank(pool, query, opts, func(p Prompt) string {
+		if titles {
+			return p.Title
+		}
+		return p.Content
+	})
+}
+
+// relevance converts the score of a match for a query of words words to a value
+// between 0 and 1. Exact matches score 1 per word, so a score of at most words is
+// fully relevant.
+func relevance(words, score int) float64 {
+	if score <= words {
+		return 1
+	}
+	return float64(words) / float64(score)
+}
+
+// prompts returns the prompts of matches.
+func prompts(matches []Match) []Prompt {
+	results := make([]Prompt, len(matches))
+	for i, match := range matches {
+		results[i] = match.Prompt
+	}
+	return results
+}
+
+// rank returns the prompts in searchPool whose text contains every query word,
+// exactly, by stem when opts.Stem is set, or fuzzily, every quoted phrase exactly,
+// and none of the terms the query excludes with "-term", restricted to the sections
+// and tags given with section: and tag: (see parseQuery), ordered best match first. Prompts with equal scores
+// keep their order in the pool. An empty query returns the whole pool.
+func rank(searchPool []Prompt, query string, opts SearchOptions, text func(Prompt) string) []Match {
+	if len(searchPool) == 0 {
+		return []Match{}
+	}
+
+	if query == "" {
+		matches := make([]Match, len(searchPool))
+		for i, p := range searchPool {
+			matches[i] = Match{Prompt: p, Relevance: 1}
+		}
+		return matches
+	}
+
+	// Split query into individual words for better matching
+	parsed := parseQuery(query)
+	if parsed.empty() {
+		return []Match{}
+	}
+	queryWords := parsed.words
+
+	// Stop words are optional, unless the query has nothing else to match
+	stopWords := map[string]bool{}
+	for _, word := range opts.StopWords {
+		stopWords[Fold(strings.TrimSpace(word))] = true
+	}
+	optional := make([]bool, len(queryWords))
+	required := 0
+	for i, word := range queryWords {
+		optional[i] = stopWords[word]
+		if !optional[i] {
+			required++
+		}
+	}
+	if required == 0 && len(parsed.phrases) == 0 {
+		optional = make([]bool, len(queryWords))
+		required = len(queryWords)
+	}
+
+	var matches []Match
+
+	// For each prompt in the search pool
+	for _, prompt := range searchPool {
+		if !parsed.inScope(prompt) {
+			continue
+		}
+		matchedWords := 0
+		content := Fold(text(prompt))
+		if parsed.excludes(content) || !parsed.hasPhrases(content) {
+			continue
+		}
+		// Each phrase is an exact match
+		totalDistance := len(parsed.phrases)
+		var contentWords, contentStems map[string]bool // Computed when first needed
+
+		// Check if all required query words have reasonable matches in this prompt
+		for i, word := range queryWords {
+			// Stop words only count as whole words, as nearly every prompt contains
+			// "a" or "in" somewhere
+			if optional[i] {
+				if contentWords == nil {
+					contentWords = wordSet(content, false)
+				}
+				if contentWords[word] {
+					totalDistance += 1
+				} else {
+					totalDistance += stopWordMissDistance
+				}
+				continue
+			}
+
+			// First try exact word match
+			if strings.Contains(content, word) {
+				matchedWords++
+				// Give exact matches a very low distance (high priority)
+				totalDistance += 1
+				continue
+			}
+
+			// Then a word sharing its stem, which counts as an exact match
+			if opts.Stem {
+				if contentStems == nil {
+					contentStems = wordSet(content, true)
+				}
+				if contentStems[Stem(word)] {
+					matchedWords++
+					totalDistance += 1
+					continue
+				}
+			}
+
+			// If no exact match, try fuzzy match on individual word
+			wordMatches := fuzzy.RankFindNormalizedFold(word, []string{content})
+			if len(wordMatches) > 0 && wordMatches[0].Distance < 100 { // reasonable fuzzy match threshold
+				matchedWords++
+				totalDistance += wordMatches[0].Distance
+			}
+		}
+
+		// Only include this prompt if ALL required query words were found
+		if matchedWords == required {
+			matches = append(matches, Match{Prompt: prompt, Score: totalDistance, Relevance: relevance(len(queryWords)+len(parsed.phrases), totalDistance)})
+		}
+	}
+
+	// Sort matches by score (lower is better)
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Score < matches[j].Score
+	})
+
+	if matches == nil {
+		return []Match{}
+	}
+	return matches
+}
+
+// wordSet returns the set of the words of text, or of their stems (see Stem) when
+// stem is set.
+func wordSet(text string, stem bool) map[string]bool {
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	set := make(map[string]bool, len(words))
+	for _, word := range words {
+		if stem {
+			word = Stem(word)
+		}
+		set[word] = true
+	}
+	return set
+}
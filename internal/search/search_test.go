@@ -0,0 +1,718 @@
+package search
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testMarkdown = `# Prompts
+
+## Golang
+### Tests
+Write table-driven unit tests
+### Review
+Review this code for errors
+
+## Python
+### Tests
+Write pytest fixtures
+`
+
+func parseTestMarkdown(t *testing.T) *PromptData {
+	t.Helper()
+	sections, err := ParseMarkdown(strings.NewReader(testMarkdown), 0)
+	if err != nil {
+		t.Fatalf("ParseMarkdown() returned error: %v", err)
+	}
+	return &PromptData{Sections: sections}
+}
+
+func TestRecords(t *testing.T) {
+	data := parseTestMarkdown(t)
+
+	tests := []struct {
+		name     string
+		query    string
+		section  string
+		expected []string
+	}{
+		{name: "all prompts", query: "", section: "", expected: []string{"Write table-driven unit tests", "Review this code for errors", "Write pytest fixtures"}},
+		{name: "body match", query: "unit tests", expected: []string{"Write table-driven unit tests"}},
+		{name: "section path", query: "", section: "Python, Tests", expected: []string{"Write pytest fixtures"}},
+		{name: "parent section", query: "write", section: "Golang", expected: []string{"Write table-driven unit tests"}},
+		{name: "no match", query: "kubernetes", expected: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := Records(data, tt.query, tt.section)
+			if len(results) != len(tt.expected) {
+				t.Fatalf("Records(%q, %q) returned %d results, want %d", tt.query, tt.section, len(results), len(tt.expected))
+			}
+			for i, r := range results {
+				if r.Content != tt.expected[i] {
+					t.Errorf("result %d = %q, want %q", i, r.Content, tt.expected[i])
+				}
+			}
+		})
+	}
+}
+
+func TestTitles(t *testing.T) {
+	data := parseTestMarkdown(t)
+
+	results := Titles(data, "python tests", "")
+	if len(results) != 1 || results[0].Content != "Write pytest fixtures" {
+		t.Errorf("expected the Python tests prompt, got %+v", results)
+	}
+	if results := Titles(data, "errors", ""); len(results) != 0 {
+		t.Errorf("expected body text to be ignored, got %+v", results)
+	}
+}
+
+func TestRecordMatches(t *testing.T) {
+	data := parseTestMarkdown(t)
+
+	matches := RecordMatches(data, "write", "")
+	if len(matches) != 2 {
+		t.Fatalf("RecordMatches() returned %d matches, want 2", len(matches))
+	}
+	// Equal scores keep the order of the prompt library
+	if matches[0].Content != "Write table-driven unit tests" || matches[1].Content != "Write pytest fixtures" {
+		t.Errorf("unexpected order: %+v", matches)
+	}
+	if matches[0].Score != matches[1].Score {
+		t.Errorf("expected equal scores for exact matches, got %d and %d", matches[0].Score, matches[1].Score)
+	}
+
+	for _, m := range RecordMatches(data, "", "") {
+		if m.Score != 0 {
+			t.Errorf("expected score 0 for an empty query, got %d for %q", m.Score, m.Content)
+		}
+	}
+}
+
+func TestRelevance(t *testing.T) {
+	tests := []struct {
+		name     string
+		words    int
+		score    int
+		expected float64
+	}{
+		{name: "exact", words: 2, score: 2, expected: 1},
+		{name: "fuzzy distance zero", words: 1, score: 0, expected: 1},
+		{name: "fuzzy", words: 2, score: 8, expected: 0.25},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := relevance(tt.words, tt.score); got != tt.expected {
+				t.Errorf("relevance(%d, %d) = %v, want %v", tt.words, tt.score, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestJoinWrappedLines(t *testing.T) {
+	tests := []struct {
+		name     string
+		lines    []string
+		expected []string
+		ranges   []LineRange
+	}{
+		{
+			name:     "wrapped paragraph",
+			lines:    []string{"Review this code", "  for bugs and", "style issues.", "", "Explain it."},
+			expected: []string{"Review this code for bugs and style issues.", "", "Explain it."},
+			ranges:   []LineRange{{1, 3}, {4, 4}, {5, 5}},
+		},
+		{
+			name:     "list items",
+			lines:    []string{"- Write tests", "  for edge cases", "- Refactor", "1. First", "2) Second"},
+			expected: []string{"- Write tests for edge cases", "- Refactor", "1. First", "2) Second"},
+			ranges:   []LineRange{{1, 2}, {3, 3}, {4, 4}, {5, 5}},
+		},
+		{
+			name:     "unindented line after list item",
+			lines:    []string{"* Item", "Next prompt", "continued"},
+			expected: []string{"* Item", "Next prompt continued"},
+			ranges:   []LineRange{{1, 1}, {2, 3}},
+		},
+		{
+			name:     "code fence",
+			lines:    []string{"Run this:", "```", "go test", "go vet", "```", "Then report"},
+			expected: []string{"Run this:", "```", "go test", "go vet", "```", "Then report"},
+			ranges:   []LineRange{{1, 1}, {2, 2}, {3, 3}, {4, 4}, {5, 5}, {6, 6}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ranges := joinWrappedLines(tt.lines, singleLineRanges(len(tt.lines)))
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("joinWrappedLines() = %q, want %q", got, tt.expected)
+			}
+			if !reflect.DeepEqual(ranges, tt.ranges) {
+				t.Errorf("joinWrappedLines() ranges = %v, want %v", ranges, tt.ranges)
+			}
+		})
+	}
+}
+
+// singleLineRanges returns the line ranges of n lines read one per source line.
+func singleLineRanges(n int) []LineRange {
+	ranges := make([]LineRange, n)
+	for i := range ranges {
+		ranges[i] = LineRange{Start: i + 1, End: i + 1}
+	}
+	return ranges
+}
+
+func TestParseMarkdownWith_JoinWrappedLines(t *testing.T) {
+	content := "## Review\nReview this code\nfor bugs.\n\n## Other\nOne\n"
+	sections, err := ParseMarkdownWith(strings.NewReader(content), ParseOptions{JoinWrappedLines: true})
+	if err != nil {
+		t.Fatalf("ParseMarkdownWith() returned error: %v", err)
+	}
+	if len(sections) != 2 || !reflect.DeepEqual(sections[0].Lines, []string{"Review this code for bugs.", ""}) {
+		t.Errorf("unexpected sections: %+v", sections)
+	}
+}
+
+func TestGroupListItems(t *testing.T) {
+	tests := []struct {
+		name     string
+		lines    []string
+		expected []string
+		ranges   []LineRange
+	}{
+		{
+			name:     "intro line with list",
+			lines:    []string{"Analyze this bug:", "1. Root cause", "2. Fix", "", "Other prompt"},
+			expected: []string{"Analyze this bug:\n1. Root cause\n2. Fix", "", "Other prompt"},
+			ranges:   []LineRange{{1, 3}, {4, 4}, {5, 5}},
+		},
+		{
+			name:     "list without intro",
+			lines:    []string{"- One", "  continued", "- Two", "", "- Three"},
+			expected: []string{"- One\n  continued\n- Two", "", "- Three"},
+			ranges:   []LineRange{{1, 3}, {4, 4}, {5, 5}},
+		},
+		{
+			name:     "line after list starts a new prompt",
+			lines:    []string{"Review:", "* Style", "Next prompt"},
+			expected: []string{"Review:\n* Style", "Next prompt"},
+			ranges:   []LineRange{{1, 2}, {3, 3}},
+		},
+		{
+			name:     "plain lines stay separate",
+			lines:    []string{"First prompt", "Second prompt"},
+			expected: []string{"First prompt", "Second prompt"},
+			ranges:   []LineRange{{1, 1}, {2, 2}},
+		},
+		{
+			name:     "list in code fence",
+			lines:    []string{"Intro", "```", "- not an item", "```"},
+			expected: []string{"Intro", "```", "- not an item", "```"},
+			ranges:   []LineRange{{1, 1}, {2, 2}, {3, 3}, {4, 4}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ranges := groupListItems(tt.lines, singleLineRanges(len(tt.lines)))
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("groupListItems() = %q, want %q", got, tt.expected)
+			}
+			if !reflect.DeepEqual(ranges, tt.ranges) {
+				t.Errorf("groupListItems() ranges = %v, want %v", ranges, tt.ranges)
+			}
+		})
+	}
+}
+
+func TestParseMarkdownDiagnose(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected []Warning
+	}{
+		{
+			name:    "well formed",
+			content: testMarkdown,
+		},
+		{
+			name:    "heading level jump",
+			content: "# Prompts\n### Tests\nWrite tests\n",
+			expected: []Warning{
+				{Line: 2, Kind: WarningHeadingJump, Message: `heading "Tests" jumps from level 1 to 3`},
+			},
+		},
+		{
+			name:    "first heading below level 1",
+			content: "## Golang\nReview this code\n",
+			expected: []Warning{
+				{Line: 1, Kind: WarningHeadingJump, Message: `first heading "Golang" is level 2 instead of 1`},
+			},
+		},
+		{
+			name:    "content before any heading",
+			content: "\nLoose prompt\nAnother\n# Prompts\n## Golang\nReview\n",
+			expected: []Warning{
+				{Line: 2, Kind: WarningContentBeforeHeading, Message: "content before the first heading is not searchable"},
+			},
+		},
+		{
+			name:    "empty sections",
+			content: "# Prompts\n## Golang\n\n## Python\n### Tests\nWrite tests\n### Mocks\n",
+			expected: []Warning{
+				{Line: 2, Kind: WarningEmptySection, Message: `section "Golang" has no prompts`},
+				{Line: 7, Kind: WarningEmptySection, Message: `section "Mocks" has no prompts`},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, warnings, err := ParseMarkdownDiagnose(strings.NewReader(tt.content), ParseOptions{})
+			if err != nil {
+				t.Fatalf("ParseMarkdownDiagnose() returned error: %v", err)
+			}
+			if !reflect.DeepEqual(warnings, tt.expected) {
+				t.Errorf("ParseMarkdownDiagnose() warnings = %+v, want %+v", warnings, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSplitHeadingIcon(t *testing.T) {
+	tests := []struct {
+		text, name, icon string
+	}{
+		{"Golang 🐹", "Golang", "🐹"},
+		{"🐍 Python", "Python", "🐍"},
+		{"Code Review ✍️", "Code Review", "✍️"},
+		{"Team 👩🏽‍💻", "Team", "👩🏽‍💻"},
+		{"C++", "C++", ""},
+		{"Go 2", "Go 2", ""},
+		{"🐹", "🐹", ""},
+		{"Golang", "Golang", ""},
+	}
+	for _, tt := range tests {
+		if name, icon := SplitHeadingIcon(tt.text); name != tt.name || icon != tt.icon {
+			t.Errorf("SplitHeadingIcon(%q) = %q, %q, want %q, %q", tt.text, name, icon, tt.name, tt.icon)
+		}
+	}
+}
+
+func TestParseMarkdown_HeadingIcons(t *testing.T) {
+	input := "# Prompts\n## Golang 🐹\nReview\n### Tests\nWrite tests\n## Python\nExplain\n"
+	sections, err := ParseMarkdown(strings.NewReader(input), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Section{
+		{Headings: []string{"Prompts", "Golang"}, Lines: []string{"Review"}, Icon: "🐹", StartLine: 2, EndLine: 3, LineRanges: []LineRange{{3, 3}}},
+		{Headings: []string{"Prompts", "Golang", "Tests"}, Lines: []string{"Write tests"}, Icon: "🐹", StartLine: 4, EndLine: 5, LineRanges: []LineRange{{5, 5}}},
+		{Headings: []string{"Prompts", "Python"}, Lines: []string{"Explain"}, StartLine: 6, EndLine: 7, LineRanges: []LineRange{{7, 7}}},
+	}
+	if !reflect.DeepEqual(sections, expected) {
+		t.Errorf("ParseMarkdown() = %+v, want %+v", sections, expected)
+	}
+	if got := Pool(&PromptData{Sections: sections}, "Golang"); len(got) != 1 || got[0].Icon != "🐹" {
+		t.Errorf("expected the Golang section to be found by name with its icon, got %+v", got)
+	}
+}
+
+func TestParseMarkdownWith_LineNumbers(t *testing.T) {
+	content := "Loose prompt\n# Prompts\n## Golang\n\nReview this code\nfor bugs.\n\nWrite tests\n\n## Python\nExplain\n"
+	sections, err := ParseMarkdownWith(strings.NewReader(content), ParseOptions{JoinWrappedLines: true, SourceFile: "prompts.md"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sections) != 3 {
+		t.Fatalf("expected 3 sections, got %+v", sections)
+	}
+	for i, lines := range [][2]int{{1, 1}, {3, 9}, {10, 11}} {
+		if sec := sections[i]; sec.StartLine != lines[0] || sec.EndLine != lines[1] || sec.SourceFile != "prompts.md" {
+			t.Errorf("section %v spans %s:%d-%d, want prompts.md:%d-%d", sec.Headings, sec.SourceFile, sec.StartLine, sec.EndLine, lines[0], lines[1])
+		}
+	}
+
+	expected := []Prompt{
+		{Content: "Review this code for bugs.", SourceFile: "prompts.md", StartLine: 5, EndLine: 6},
+		{Content: "Write tests", SourceFile: "prompts.md", StartLine: 8, EndLine: 8},
+		{Content: "Explain", SourceFile: "prompts.md", StartLine: 11, EndLine: 11},
+	}
+	pool := Pool(&PromptData{Sections: sections[1:]}, "")
+	if len(pool) != len(expected) {
+		t.Fatalf("expected %d prompts, got %+v", len(expected), pool)
+	}
+	for i, p := range pool {
+		want := expected[i]
+		if p.Content != want.Content || p.SourceFile != want.SourceFile || p.StartLine != want.StartLine || p.EndLine != want.EndLine {
+			t.Errorf("prompt %d = %q at %s:%d-%d, want %q at %s:%d-%d", i, p.Content, p.SourceFile, p.StartLine, p.EndLine, want.Content, want.SourceFile, want.StartLine, want.EndLine)
+		}
+	}
+}
+
+func TestAttachments(t *testing.T) {
+	tests := []struct {
+		text     string
+		paths    []string
+		isLine   bool
+		stripped string
+	}{
+		{text: "Explain this diagram", stripped: "Explain this diagram"},
+		{text: "<!-- attach: diagrams/arch.png -->", paths: []string{"diagrams/arch.png"}, isLine: true, stripped: ""},
+		{text: "Explain this <!--attach:a.png--> <!-- attach: b.png -->", paths: []string{"a.png", "b.png"}, stripped: "Explain this"},
+		{text: "<!-- a regular comment -->", stripped: "<!-- a regular comment -->"},
+		{text: "Compare\n<!-- attach: a.png -->\nwith this", paths: []string{"a.png"}, stripped: "Compare\nwith this"},
+	}
+	for _, tt := range tests {
+		if got := Attachments(tt.text); !reflect.DeepEqual(got, tt.paths) {
+			t.Errorf("Attachments(%q) = %q, want %q", tt.text, got, tt.paths)
+		}
+		if got := IsAttachmentLine(tt.text); got != tt.isLine {
+			t.Errorf("IsAttachmentLine(%q) = %v, want %v", tt.text, got, tt.isLine)
+		}
+		if got := StripAttachments(tt.text); got != tt.stripped {
+			t.Errorf("StripAttachments(%q) = %q, want %q", tt.text, got, tt.stripped)
+		}
+	}
+}
+
+func TestPool_SkipsAttachmentLines(t *testing.T) {
+	data := &PromptData{Sections: []Section{
+		{Headings: []string{"Prompts", "Diagrams"}, Lines: []string{"Explain this diagram", "<!-- attach: arch.png -->"}},
+	}}
+	got := Pool(data, "")
+	if len(got) != 1 || got[0].Content != "Explain this diagram" {
+		t.Errorf("expected the attachment line to be left out of the pool, got %+v", got)
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	data := &PromptData{Sections: []Section{
+		{Headings: []string{"Prompts", "Golang", "Code Review Checklist"}, Lines: []string{"Check error handling"}},
+		{Headings: []string{"Prompts", "Python", "Tests"}, Lines: []string{"Write pytest tests"}},
+		{Headings: []string{"Prompts", "Writing"}, Lines: []string{"Draft an email"}},
+	}}
+
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{query: "code reviw checklst", expected: "Did you mean: 'Code Review Checklist' in section Golang?"},
+		{query: "pyhton tests", expected: "Did you mean: 'Tests' in section Python?"},
+		{query: "writng", expected: "Did you mean: 'Writing'?"},
+		{query: "kubernetes", expected: ""},
+		{query: "", expected: ""},
+	}
+	for _, tt := range tests {
+		if got := DidYouMean(Suggest(data, tt.query, 3)); got != tt.expected {
+			t.Errorf("DidYouMean(Suggest(%q)) = %q, want %q", tt.query, got, tt.expected)
+		}
+	}
+}
+
+func TestFold(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"ascii", "Write Unit Tests", "write unit tests"},
+		{"french accents", "Résumé", "resume"},
+		{"german umlauts", "Über Größe", "uber grosse"},
+		{"combining marks", "Re\u0301sume\u0301", "resume"},
+		{"polish", "Łódź", "lodz"},
+		{"scandinavian", "Ærø Øst", "aero ost"},
+		{"vietnamese", "Tiếng Việt", "tieng viet"},
+		{"ligature", "ﬁle", "file"},
+		{"greek", "Καφές", "καφες"},
+		{"cyrillic", "Ёлка", "елка"},
+		{"hebrew points", "שָׁלוֹם", "שלום"},
+		{"japanese dakuten kept", "ガイド", "ガイド"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Fold(tt.text); got != tt.want {
+				t.Errorf("Fold(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecords_DiacriticsInsensitive(t *testing.T) {
+	data := &PromptData{Sections: []Section{
+		{Headings: []string{"Prompts", "Writing"}, Lines: []string{"Polish my résumé", "Übersetze diesen Text", "Напиши ёмкое резюме"}},
+	}}
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{"resume", "Polish my résumé"},
+		{"RÉSUMÉ", "Polish my résumé"},
+		{"ubersetze", "Übersetze diesen Text"},
+		{"емкое", "Напиши ёмкое резюме"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			results := Records(data, tt.query, "")
+			if len(results) != 1 || results[0].Content != tt.expected {
+				t.Errorf("Records(%q) = %v, want [%q]", tt.query, results, tt.expected)
+			}
+		})
+	}
+}
+
+func TestStem(t *testing.T) {
+	tests := []struct {
+		word string
+		want string
+	}{
+		{"caresses", "caress"},
+		{"ponies", "poni"},
+		{"cats", "cat"},
+		{"feed", "feed"},
+		{"agreed", "agre"},
+		{"plastered", "plaster"},
+		{"motoring", "motor"},
+		{"sing", "sing"},
+		{"conflated", "conflat"},
+		{"hopping", "hop"},
+		{"falling", "fall"},
+		{"filing", "file"},
+		{"happy", "happi"},
+		{"sky", "sky"},
+		{"relational", "relat"},
+		{"conditional", "condit"},
+		{"rational", "ration"},
+		{"generalizations", "gener"},
+		{"oscillators", "oscil"},
+		{"allowance", "allow"},
+		{"adoption", "adopt"},
+		{"controll", "control"},
+		{"testing", "test"},
+		{"tests", "test"},
+		{"documented", "document"},
+		{"documentation", "document"},
+		{"go", "go"},
+		{"résumé", "résumé"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.word, func(t *testing.T) {
+			if got := Stem(tt.word); got != tt.want {
+				t.Errorf("Stem(%q) = %q, want %q", tt.word, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecords_Stemming(t *testing.T) {
+	sections := []Section{
+		{Headings: []string{"Prompts", "Golang"}, Lines: []string{"Write tests for this function", "Review the documentation of this package"}},
+	}
+	tests := []struct {
+		name     string
+		query    string
+		stem     bool
+		expected []string
+	}{
+		{"testing without stemming", "testing", false, []string{}},
+		{"testing with stemming", "testing", true, []string{"Write tests for this function"}},
+		{"documented with stemming", "documented package", true, []string{"Review the documentation of this package"}},
+		{"exact match unchanged", "tests", true, []string{"Write tests for this function"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := &PromptData{Sections: sections, Options: SearchOptions{Stem: tt.stem}}
+			results := Records(data, tt.query, "")
+			if len(results) != len(tt.expected) {
+				t.Fatalf("Records(%q) = %v, want %v", tt.query, results, tt.expected)
+			}
+			for i, r := range results {
+				if r.Content != tt.expected[i] {
+					t.Errorf("result %d = %q, want %q", i, r.Content, tt.expected[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRecords_StopWords(t *testing.T) {
+	sections := []Section{
+		{Headings: []string{"Prompts", "Golang"}, Lines: []string{"Write unit tests", "Write the unit tests for the handler"}},
+	}
+	tests := []struct {
+		name      string
+		query     string
+		stopWords []string
+		expected  []string
+	}{
+		{"stop words not required", "tests for handler", DefaultStopWords, []string{"Write the unit tests for the handler"}},
+		{"prompts with stop words rank first", "the unit tests", DefaultStopWords, []string{"Write the unit tests for the handler", "Write unit tests"}},
+		{"without stop words every word is required", "the unit tests", nil, []string{"Write the unit tests for the handler"}},
+		{"query of only stop words", "for", DefaultStopWords, []string{"Write the unit tests for the handler"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := &PromptData{Sections: sections, Options: SearchOptions{StopWords: tt.stopWords}}
+			results := Records(data, tt.query, "")
+			if len(results) != len(tt.expected) {
+				t.Fatalf("Records(%q) = %v, want %v", tt.query, results, tt.expected)
+			}
+			for i, r := range results {
+				if r.Content != tt.expected[i] {
+					t.Errorf("result %d = %q, want %q", i, r.Content, tt.expected[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRecords_ExcludedTerms(t *testing.T) {
+	sections := []Section{
+		{Headings: []string{"Prompts", "Golang"}, Lines: []string{"Review this code for security issues", "Review this code for readability", "Write unit tests"}},
+	}
+	tests := []struct {
+		name     string
+		query    string
+		expected []string
+	}{
+		{"excluded term", "review -security", []string{"Review this code for readability"}},
+		{"excluded term ignores case and accents", "review -SÉCURITY", []string{"Review this code for readability"}},
+		{"several excluded terms", "-security -tests", []string{"Review this code for readability"}},
+		{"only excluded terms", "-security", []string{"Review this code for readability", "Write unit tests"}},
+		{"excluded substring", "-read", []string{"Review this code for security issues", "Write unit tests"}},
+		{"lone dash is a word", "code -", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := &PromptData{Sections: sections}
+			results := Records(data, tt.query, "")
+			if len(results) != len(tt.expected) {
+				t.Fatalf("Records(%q) = %v, want %v", tt.query, results, tt.expected)
+			}
+			for i, r := range results {
+				if r.Content != tt.expected[i] {
+					t.Errorf("result %d = %q, want %q", i, r.Content, tt.expected[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRecords_QuotedPhrases(t *testing.T) {
+	sections := []Section{
+		{Headings: []string{"Prompts", "Golang"}, Lines: []string{"Write unit tests for this Go code", "Write tests for this unit of Go code", "Write  unit   tests in Python"}},
+	}
+	tests := []struct {
+		name     string
+		query    string
+		expected []string
+	}{
+		{"phrase", `"unit tests"`, []string{"Write unit tests for this Go code", "Write  unit   tests in Python"}},
+		{"words without quotes", "unit tests", []string{"Write unit tests for this Go code", "Write tests for this unit of Go code", "Write  unit   tests in Python"}},
+		{"phrase with fuzzy words", `"unit tests" go`, []string{"Write unit tests for this Go code"}},
+		{"phrase ignores case and spacing", `"UNIT   Tests in"`, []string{"Write  unit   tests in Python"}},
+		{"phrase must match exactly", `"unit test for"`, nil},
+		{"excluded phrase", `write -"unit tests"`, []string{"Write tests for this unit of Go code"}},
+		{"unclosed quote runs to the end", `go "unit tests`, []string{"Write unit tests for this Go code"}},
+		{"empty quotes are ignored", `"" python`, []string{"Write  unit   tests in Python"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := &PromptData{Sections: sections}
+			results := Records(data, tt.query, "")
+			if len(results) != len(tt.expected) {
+				t.Fatalf("Records(%q) = %v, want %v", tt.query, results, tt.expected)
+			}
+			for i, r := range results {
+				if r.Content != tt.expected[i] {
+					t.Errorf("result %d = %q, want %q", i, r.Content, tt.expected[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRecords_Operators(t *testing.T) {
+	sections := []Section{
+		{Headings: []string{"Prompts", "Golang", "Code Review"}, Lines: []string{"Review this Go code #review #security", "Review this Go code for style #review"}},
+		{Headings: []string{"Prompts", "Python"}, Lines: []string{"Review this Python code #review", "Write pytest tests #testing"}},
+	}
+	tests := []struct {
+		name     string
+		query    string
+		expected []string
+	}{
+		{"section", "section:golang review", []string{"Review this Go code #review #security", "Review this Go code for style #review"}},
+		{"section prefix while typing", "section:py", []string{"Review this Python code #review", "Write pytest tests #testing"}},
+		{"parent section", "section:golang style", []string{"Review this Go code for style #review"}},
+		{"nested section", `section:"code review"`, []string{"Review this Go code #review #security", "Review this Go code for style #review"}},
+		{"several sections", "section:python section:code tests", []string{"Write pytest tests #testing"}},
+		{"excluded section", "-section:golang review", []string{"Review this Python code #review"}},
+		{"tag", "tag:testing", []string{"Write pytest tests #testing"}},
+		{"tag with hash", "tag:#security", []string{"Review this Go code #review #security"}},
+		{"every tag required", "tag:review tag:security", []string{"Review this Go code #review #security"}},
+		{"excluded tag", "section:golang -tag:security", []string{"Review this Go code for style #review"}},
+		{"section and tag", "section:golang tag:review style", []string{"Review this Go code for style #review"}},
+		{"operator without value", "section: pytest", []string{"Write pytest tests #testing"}},
+		{"unknown section", "section:rust review", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := &PromptData{Sections: sections}
+			results := Records(data, tt.query, "")
+			if len(results) != len(tt.expected) {
+				t.Fatalf("Records(%q) = %v, want %v", tt.query, results, tt.expected)
+			}
+			for i, r := range results {
+				if r.Content != tt.expected[i] {
+					t.Errorf("result %d = %q, want %q", i, r.Content, tt.expected[i])
+				}
+			}
+		})
+	}
+}
+
+func TestTags(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected []string
+	}{
+		{"Write unit tests #testing", []string{"testing"}},
+		{"#go-lang review #code_style, please", []string{"go-lang", "code_style"}},
+		{"See https://example.com/#anchor and # alone", nil},
+		{"No tags here", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := Tags(tt.text); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Tags(%q) = %v, want %v", tt.text, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestHasSectionOperator(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected bool
+	}{
+		{"section:golang review", true},
+		{"review", false},
+		{"-section:golang review", false},
+		{"section: review", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			if got := HasSectionOperator(tt.query); got != tt.expected {
+				t.Errorf("HasSectionOperator(%q) = %v, want %v", tt.query, got, tt.expected)
+			}
+		})
+	}
+}
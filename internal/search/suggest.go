@@ -0,0 +1,109 @@
+package search
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/lithammer/fuzzysearch/fuzzy"
+)
+
+// suggestThreshold is the largest share of a heading's characters that may differ
+// from the query for the heading to be suggested.
+const suggestThreshold = 0.4
+
+// Suggestion is a heading close to a query that found nothing.
+type Suggestion struct {
+	Title   string // The heading, such as "Code Review Checklist"
+	Section string // The heading above it, empty for a top-level section
+}
+
+// String formats s as "'Title' in section Section", or "'Title'" for a top-level section.
+func (s Suggestion) String() string {
+	if s.Section == "" {
+		return fmt.Sprintf("'%s'", s.Title)
+	}
+	return fmt.Sprintf("'%s' in section %s", s.Title, s.Section)
+}
+
+// Suggest returns up to limit headings of any section whose names are close to
+// query, closest first, to point a search that found nothing at a near miss such as
+// a typo or a prompt filed under another section. Each query word is compared with
+// the closest word of the heading and the heading above it by edit distance.
+func Suggest(data *PromptData, query string, limit int) []Suggestion {
+	words := parseQuery(query).terms()
+	if len(words) == 0 || limit <= 0 {
+		return nil
+	}
+
+	type candidate struct {
+		Suggestion
+		distance float64
+	}
+	var candidates []candidate
+	seen := make(map[Suggestion]bool)
+	for _, sec := range data.Sections {
+		// The first heading is the document title
+		for i := 1; i < len(sec.Headings); i++ {
+			s := Suggestion{Title: sec.Headings[i]}
+			if i > 1 {
+				s.Section = sec.Headings[i-1]
+			}
+			if seen[s] {
+				continue
+			}
+			seen[s] = true
+			if d := headingDistance(words, s.Section+" "+s.Title); d <= suggestThreshold {
+				candidates = append(candidates, candidate{Suggestion: s, distance: d})
+			}
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].distance < candidates[j].distance
+	})
+	var suggestions []Suggestion
+	for _, c := range candidates {
+		if len(suggestions) == limit {
+			break
+		}
+		suggestions = append(suggestions, c.Suggestion)
+	}
+	return suggestions
+}
+
+// headingDistance returns how far the query words are from heading, between 0 when
+// every query word is a word of heading and 1: the average, over the query words, of
+// the edit distance to the closest heading word relative to the longer of the two.
+func headingDistance(words []string, heading string) float64 {
+	headingWords := strings.Fields(Fold(heading))
+	if len(headingWords) == 0 {
+		return 1
+	}
+	total := 0.0
+	for _, word := range words {
+		best := 1.0
+		for _, hw := range headingWords {
+			longest := max(utf8.RuneCountInString(word), utf8.RuneCountInString(hw))
+			if d := float64(fuzzy.LevenshteinDistance(word, hw)) / float64(longest); d < best {
+				best = d
+			}
+		}
+		total += best
+	}
+	return total / float64(len(words))
+}
+
+// DidYouMean formats suggestions as "Did you mean: 'A' in section B, 'C'?", or ""
+// when there are none.
+func DidYouMean(suggestions []Suggestion) string {
+	if len(suggestions) == 0 {
+		return ""
+	}
+	names := make([]string, len(suggestions))
+	for i, s := range suggestions {
+		names[i] = s.String()
+	}
+	return "Did you mean: " + strings.Join(names, ", ") + "?"
+}
@@ -0,0 +1,156 @@
+// Package semantic provides embedding-based prompt search.
+// Prompts are embedded through an OpenAI-compatible API (a hosted service or a
+// local llama.cpp server), the vectors are cached in the data directory keyed by
+// content hash, and results are ranked by cosine similarity to the query.
+package semantic
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/toozej/wheresmyprompt/internal/prompt"
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+// cacheFileName is the name of the embedding cache inside the data directory.
+const cacheFileName = "embeddings.json"
+
+// Embedder computes embedding vectors for texts.
+type Embedder interface {
+	Embed(model string, texts []string) ([][]float64, error)
+}
+
+// cache maps model name to content hash to embedding vector.
+type cache map[string]map[string][]float64
+
+// Search ranks pool by cosine similarity between each prompt and query.
+// Embeddings for prompts are read from and written back to the on-disk cache,
+// so only new or changed prompts are sent to the embedder.
+func Search(conf config.Config, embedder Embedder, pool []prompt.Prompt, query string) ([]prompt.Prompt, error) {
+	if len(pool) == 0 || query == "" {
+		return pool, nil
+	}
+
+	path, err := cachePath(conf)
+	if err != nil {
+		return nil, err
+	}
+	c := loadCache(path)
+	vectors := c[conf.LLMEmbeddingModel]
+	if vectors == nil {
+		vectors = map[string][]float64{}
+		c[conf.LLMEmbeddingModel] = vectors
+	}
+
+	// Embed uncached prompts in one batch, together with the query
+	var missing []string
+	var missingHashes []string
+	seen := map[string]bool{}
+	for _, p := range pool {
+		h := hash(p.Content)
+		if _, ok := vectors[h]; !ok && !seen[h] {
+			seen[h] = true
+			missing = append(missing, p.Content)
+			missingHashes = append(missingHashes, h)
+		}
+	}
+	embedded, err := embedder.Embed(conf.LLMEmbeddingModel, append(missing, query))
+	if err != nil {
+		return nil, err
+	}
+	for i, h := range missingHashes {
+		vectors[h] = embedded[i]
+	}
+	queryVector := embedded[len(embedded)-1]
+
+	if len(missing) > 0 {
+		if err := saveCache(path, c); err != nil {
+			return nil, err
+		}
+	}
+
+	type ranked struct {
+		prompt prompt.Prompt
+		score  float64
+	}
+	results := make([]ranked, len(pool))
+	for i, p := range pool {
+		results[i] = ranked{prompt: p, score: cosine(queryVector, vectors[hash(p.Content)])}
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].score > results[j].score
+	})
+
+	out := make([]prompt.Prompt, len(results))
+	for i, r := range results {
+		out[i] = r.prompt
+	}
+	return out, nil
+}
+
+// cosine returns the cosine similarity of a and b, or 0 if they are incompatible.
+func cosine(a, b []float64) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += a[i] * b[i]
+		na += a[i] * a[i]
+		nb += b[i] * b[i]
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+// hash returns the hex SHA-256 of content, used as the cache key.
+func hash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// cachePath returns the location of the embedding cache.
+func cachePath(conf config.Config) (string, error) {
+	dir, err := config.ResolveDataDir(conf)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, cacheFileName), nil
+}
+
+// loadCache reads the embedding cache, returning an empty cache if it is missing or corrupt.
+func loadCache(path string) cache {
+	c := cache{}
+	data, err := os.ReadFile(path) // #nosec G304
+	if err != nil {
+		return c
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return cache{}
+	}
+	return c
+}
+
+// saveCache writes the embedding cache, creating the data directory if needed.
+func saveCache(path string, c cache) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil && !errors.Is(err, os.ErrExist) {
+		return fmt.Errorf("failed to create data directory: %w", err)
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("failed to marshal embedding cache: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write embedding cache: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package semantic
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/toozej/wheresmyprompt/internal/prompt"
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+// fakeEmbedder embeds texts on two axes: "testing" words and "review" words.
+type fakeEmbedder struct {
+	calls  int
+	inputs int
+}
+
+func (f *fakeEmbedder) Embed(model string, texts []string) ([][]float64, error) {
+	f.calls++
+	f.inputs += len(texts)
+	vectors := make([][]float64, len(texts))
+	for i, text := range texts {
+		text = strings.ToLower(text)
+		var v [2]float64
+		for _, w := range []string{"test", "verify", "coverage"} {
+			if strings.Contains(text, w) {
+				v[0]++
+			}
+		}
+		for _, w := range []string{"review", "critique", "feedback"} {
+			if strings.Contains(text, w) {
+				v[1]++
+			}
+		}
+		vectors[i] = v[:]
+	}
+	return vectors, nil
+}
+
+func TestSearch(t *testing.T) {
+	conf := config.Config{DataDir: t.TempDir(), LLMEmbeddingModel: "fake"}
+	pool := []prompt.Prompt{
+		{Content: "Write unit tests with good coverage", Section: "Golang"},
+		{Content: "Give critique and feedback on this code", Section: "Golang"},
+	}
+
+	embedder := &fakeEmbedder{}
+	results, err := Search(conf, embedder, pool, "review my change")
+	if err != nil {
+		t.Fatalf("Search() returned error: %v", err)
+	}
+	if results[0].Content != pool[1].Content {
+		t.Errorf("expected semantically closest prompt first, got %q", results[0].Content)
+	}
+	if embedder.inputs != 3 {
+		t.Errorf("expected 2 prompts and the query to be embedded, got %d inputs", embedder.inputs)
+	}
+
+	// Second search should only embed the query thanks to the cache
+	embedder = &fakeEmbedder{}
+	results, err = Search(conf, embedder, pool, "verify behaviour")
+	if err != nil {
+		t.Fatalf("Search() returned error: %v", err)
+	}
+	if results[0].Content != pool[0].Content {
+		t.Errorf("expected semantically closest prompt first, got %q", results[0].Content)
+	}
+	if embedder.inputs != 1 {
+		t.Errorf("expected only the query to be embedded, got %d inputs", embedder.inputs)
+	}
+}
+
+func TestCosine(t *testing.T) {
+	if got := cosine([]float64{1, 0}, []float64{1, 0}); math.Abs(got-1) > 1e-9 {
+		t.Errorf("cosine of identical vectors = %f, want 1", got)
+	}
+	if got := cosine([]float64{1, 0}, []float64{0, 1}); got != 0 {
+		t.Errorf("cosine of orthogonal vectors = %f, want 0", got)
+	}
+	if got := cosine([]float64{1}, []float64{1, 0}); got != 0 {
+		t.Errorf("cosine of mismatched vectors = %f, want 0", got)
+	}
+}
@@ -0,0 +1,176 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Bucket upper bounds, in seconds, for the latency histograms.
+var (
+	loadBuckets   = []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+	searchBuckets = []float64{0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5}
+)
+
+// histogram is a minimal Prometheus-style cumulative histogram.
+type histogram struct {
+	buckets []float64
+	counts  []uint64 // Non-cumulative count per bucket
+	sum     float64
+	count   uint64
+}
+
+func newHistogram(buckets []float64) *histogram {
+	return &histogram{buckets: buckets, counts: make([]uint64, len(buckets))}
+}
+
+func (h *histogram) observe(v float64) {
+	h.sum += v
+	h.count++
+	for i, upper := range h.buckets {
+		if v <= upper {
+			h.counts[i]++
+			return
+		}
+	}
+}
+
+// Metrics collects the prompt source health metrics exposed on /metrics.
+type Metrics struct {
+	mu sync.Mutex
+
+	loadDuration   *histogram
+	loadErrors     uint64
+	lastLoad       time.Time
+	searchDuration *histogram
+	prompts        int
+	sections       int
+	cacheHits      uint64
+	cacheMisses    uint64
+	writes         map[string]uint64 // Keyed by result: "success" or "failure"
+}
+
+// NewMetrics returns an empty metrics collector.
+func NewMetrics() *Metrics {
+	return &Metrics{
+		loadDuration:   newHistogram(loadBuckets),
+		searchDuration: newHistogram(searchBuckets),
+		writes:         map[string]uint64{"success": 0, "failure": 0},
+	}
+}
+
+// ObserveLoad records a prompt source load. Failed loads are counted separately.
+func (m *Metrics) ObserveLoad(d time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.loadDuration.observe(d.Seconds())
+	if err != nil {
+		m.loadErrors++
+		return
+	}
+	m.lastLoad = time.Now()
+}
+
+// ObserveSearch records the latency of a search and whether it was served from cache.
+func (m *Metrics) ObserveSearch(d time.Duration, cached bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.searchDuration.observe(d.Seconds())
+	if cached {
+		m.cacheHits++
+	} else {
+		m.cacheMisses++
+	}
+}
+
+// SetLibrarySize records the number of prompts and sections currently loaded.
+func (m *Metrics) SetLibrarySize(prompts, sections int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.prompts = prompts
+	m.sections = sections
+}
+
+// ObserveWrite counts a prompt write by outcome.
+func (m *Metrics) ObserveWrite(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err != nil {
+		m.writes["failure"]++
+	} else {
+		m.writes["success"]++
+	}
+}
+
+// WriteTo renders the metrics in the Prometheus text exposition format.
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cw := &countingWriter{w: w}
+	writeHistogram(cw, "wheresmyprompt_load_duration_seconds", "Time taken to load and parse the prompt source.", m.loadDuration)
+	writeMetric(cw, "wheresmyprompt_load_errors_total", "counter", "Number of failed prompt source loads.", float64(m.loadErrors))
+	lastLoad := 0.0
+	if !m.lastLoad.IsZero() {
+		lastLoad = float64(m.lastLoad.Unix())
+	}
+	writeMetric(cw, "wheresmyprompt_last_load_timestamp_seconds", "gauge", "Unix time of the last successful load.", lastLoad)
+	writeHistogram(cw, "wheresmyprompt_search_duration_seconds", "Search latency.", m.searchDuration)
+	writeMetric(cw, "wheresmyprompt_prompts", "gauge", "Number of prompts currently loaded.", float64(m.prompts))
+	writeMetric(cw, "wheresmyprompt_sections", "gauge", "Number of sections currently loaded.", float64(m.sections))
+	writeMetric(cw, "wheresmyprompt_search_cache_hits_total", "counter", "Searches answered from the result cache.", float64(m.cacheHits))
+	writeMetric(cw, "wheresmyprompt_search_cache_misses_total", "counter", "Searches that missed the result cache.", float64(m.cacheMisses))
+
+	fmt.Fprintln(cw, "# HELP wheresmyprompt_writes_total Prompt writes by result.")
+	fmt.Fprintln(cw, "# TYPE wheresmyprompt_writes_total counter")
+	results := make([]string, 0, len(m.writes))
+	for result := range m.writes {
+		results = append(results, result)
+	}
+	sort.Strings(results)
+	for _, result := range results {
+		fmt.Fprintf(cw, "wheresmyprompt_writes_total{result=%q} %d\n", result, m.writes[result])
+	}
+	return cw.n, cw.err
+}
+
+// writeMetric writes a single-sample metric with its HELP and TYPE lines.
+func writeMetric(w io.Writer, name, kind, help string, value float64) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %s\n", name, help, name, kind, name, formatFloat(value))
+}
+
+// writeHistogram writes h as cumulative buckets plus _sum and _count series.
+func writeHistogram(w io.Writer, name, help string, h *histogram) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", name, help, name)
+	var cumulative uint64
+	for i, upper := range h.buckets {
+		cumulative += h.counts[i]
+		fmt.Fprintf(w, "%s_bucket{le=%q} %d\n", name, formatFloat(upper), cumulative)
+	}
+	fmt.Fprintf(w, "%s_bucket{le=\"+Inf\"} %d\n", name, h.count)
+	fmt.Fprintf(w, "%s_sum %s\n%s_count %d\n", name, formatFloat(h.sum), name, h.count)
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// countingWriter tracks bytes written and the first error for WriteTo.
+type countingWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	c.err = err
+	return n, err
+}
@@ -0,0 +1,36 @@
+package server
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetrics_WriteTo(t *testing.T) {
+	m := NewMetrics()
+	m.ObserveLoad(30*time.Millisecond, nil)
+	m.ObserveLoad(20*time.Second, errors.New("boom"))
+
+	var sb strings.Builder
+	if _, err := m.WriteTo(&sb); err != nil {
+		t.Fatalf("WriteTo() error = %v", err)
+	}
+	out := sb.String()
+
+	tests := []string{
+		"# TYPE wheresmyprompt_load_duration_seconds histogram",
+		`wheresmyprompt_load_duration_seconds_bucket{le="0.01"} 0`,
+		`wheresmyprompt_load_duration_seconds_bucket{le="0.05"} 1`,
+		`wheresmyprompt_load_duration_seconds_bucket{le="10"} 1`,
+		`wheresmyprompt_load_duration_seconds_bucket{le="+Inf"} 2`,
+		"wheresmyprompt_load_duration_seconds_count 2",
+		"wheresmyprompt_load_errors_total 1",
+		`wheresmyprompt_writes_total{result="failure"} 0`,
+	}
+	for _, want := range tests {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+}
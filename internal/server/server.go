@@ -0,0 +1,279 @@
+// Package server implements serve mode: a small HTTP API over the prompt library for
+// editor plugins and scripts on a shared machine, with a JSON search endpoint, a
+// token-protected write endpoint, a health check and Prometheus metrics about the
+// prompt source.
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/toozej/wheresmyprompt/internal/prompt"
+	"github.com/toozej/wheresmyprompt/internal/redact"
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+// addPromptFunc allows tests to intercept writes.
+var addPromptFunc = prompt.AddPrompt
+
+// maxCacheEntries caps the search cache, whose keys are chosen by clients. When it
+// is full the cache is emptied before the next result is stored.
+const maxCacheEntries = 256
+
+// Server serves the prompt library over HTTP.
+type Server struct {
+	conf    config.Config
+	lib     *prompt.Library
+	metrics *Metrics
+
+	cacheMu    sync.Mutex
+	cache      map[string][]prompt.Prompt // Search results keyed by mode, section and query
+	generation uint64                     // Incremented whenever the cache is cleared by a reload
+}
+
+// New returns a Server for conf. Call Reload or Run to load the prompts.
+func New(conf config.Config) *Server {
+	s := &Server{
+		conf:    conf,
+		lib:     prompt.NewLibrary(conf),
+		metrics: NewMetrics(),
+		cache:   map[string][]prompt.Prompt{},
+	}
+	s.lib.OnChange(func(data *prompt.PromptData) {
+		s.cacheMu.Lock()
+		s.cache = map[string][]prompt.Prompt{}
+		s.generation++
+		s.cacheMu.Unlock()
+		s.metrics.SetLibrarySize(len(prompt.SearchPromptRecords(data, "", "")), len(data.Sections))
+	})
+	return s
+}
+
+// Reload reloads the prompt source, recording its duration and outcome.
+func (s *Server) Reload(ctx context.Context) error {
+	start := time.Now()
+	_, err := s.lib.Reload(ctx)
+	s.metrics.ObserveLoad(time.Since(start), err)
+	return err
+}
+
+// Handler returns the HTTP handler serving the API:
+//
+//	GET  /search?q=<query>&section=<section>&titles=true  matching prompts as JSON
+//	POST /prompts  {"title", "content", "section"}        add a prompt, see handleAddPrompt
+//	GET  /healthz                                        200 once prompts are loaded
+//	GET  /metrics                                        Prometheus metrics
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /search", s.handleSearch)
+	mux.HandleFunc("POST /prompts", s.handleAddPrompt)
+	mux.HandleFunc("GET /healthz", s.handleHealth)
+	mux.HandleFunc("GET /metrics", s.handleMetrics)
+	return mux
+}
+
+// Run loads the prompts, then serves HTTP on conf.ServeAddr until ctx is done,
+// reloading the source every conf.ReloadInterval.
+func (s *Server) Run(ctx context.Context) error {
+	if err := s.Reload(ctx); err != nil {
+		return err
+	}
+
+	if s.conf.ReloadInterval > 0 {
+		go func() {
+			ticker := time.NewTicker(s.conf.ReloadInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					if err := s.Reload(ctx); err != nil {
+						log.Warn("Failed to reload prompts: ", err)
+					}
+				}
+			}
+		}()
+	}
+
+	srv := &http.Server{
+		Addr:              s.conf.ServeAddr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+
+	log.Infof("Serving prompts on http://%s", s.conf.ServeAddr)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// promptResponse is the JSON form of a prompt returned by /search.
+type promptResponse struct {
+	Content   string `json:"content"`
+	Section   string `json:"section"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	query := r.URL.Query().Get("q")
+	section := r.URL.Query().Get("section")
+	titlesOnly := r.URL.Query().Get("titles") == "true"
+
+	key := section + "\x00" + query
+	if titlesOnly {
+		key = "titles\x00" + key
+	}
+
+	// The snapshot is taken with the cache generation, so results computed from a
+	// snapshot that a reload replaced in the meantime are never cached
+	s.cacheMu.Lock()
+	results, cached := s.cache[key]
+	generation := s.generation
+	data := s.lib.Snapshot()
+	s.cacheMu.Unlock()
+
+	if !cached {
+		if titlesOnly {
+			results = prompt.SearchPromptTitles(data, query, section)
+		} else {
+			results = prompt.SearchPromptRecords(data, query, section)
+		}
+		if s.conf.DedupeResults {
+			results = prompt.MergeDuplicates(results)
+		}
+		s.storeCache(key, generation, results)
+	}
+	s.metrics.ObserveSearch(time.Since(start), cached)
+
+	out := make([]promptResponse, len(results))
+	for i, p := range results {
+		out[i] = promptResponse{Content: p.Content, Section: p.Section, Namespace: p.Namespace}
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// storeCache caches results for key unless the cache was cleared since generation,
+// emptying a full cache first.
+func (s *Server) storeCache(key string, generation uint64, results []prompt.Prompt) {
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+	if generation != s.generation {
+		return
+	}
+	if len(s.cache) >= maxCacheEntries {
+		s.cache = map[string][]prompt.Prompt{}
+	}
+	s.cache[key] = results
+}
+
+// addPromptRequest is the JSON body accepted by POST /prompts.
+type addPromptRequest struct {
+	Title   string `json:"title"`
+	Content string `json:"content"`
+	Section string `json:"section"`
+}
+
+// handleAddPrompt adds the prompt in the request body. Since the server listens on a
+// local port any web page could reach, writes are only accepted with the SERVE_TOKEN
+// bearer token and a JSON body, and never from a browser, which sends an Origin header.
+func (s *Server) handleAddPrompt(w http.ResponseWriter, r *http.Request) {
+	if err := s.checkWriteRequest(r); err != nil {
+		writeError(w, err.status, err)
+		return
+	}
+
+	var req addPromptRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err := addPromptFunc(s.conf, req.Title, req.Content, req.Section)
+	s.metrics.ObserveWrite(err)
+	switch {
+	case errors.Is(err, prompt.ErrReadOnly):
+		writeError(w, http.StatusForbidden, err)
+		return
+	case errors.Is(err, prompt.ErrPromptExists):
+		writeError(w, http.StatusConflict, err)
+		return
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if err := s.Reload(r.Context()); err != nil {
+		log.Warn("Failed to reload prompts after write: ", err)
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+// requestError is a rejected request and the HTTP status to reject it with.
+type requestError struct {
+	status int
+	msg    string
+}
+
+func (e *requestError) Error() string {
+	return e.msg
+}
+
+// checkWriteRequest returns an error rejecting r unless it is a JSON request from a
+// client other than a browser carrying the SERVE_TOKEN bearer token.
+func (s *Server) checkWriteRequest(r *http.Request) *requestError {
+	if s.conf.ServeToken == "" {
+		return &requestError{http.StatusForbidden, "writes are disabled; set SERVE_TOKEN to enable them"}
+	}
+	if r.Header.Get("Origin") != "" {
+		return &requestError{http.StatusForbidden, "writes from browsers are not allowed"}
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.conf.ServeToken)) != 1 {
+		return &requestError{http.StatusUnauthorized, "missing or invalid bearer token"}
+	}
+	if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mediaType != "application/json" {
+		return &requestError{http.StatusUnsupportedMediaType, "Content-Type must be application/json"}
+	}
+	return nil
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, _ *http.Request) {
+	if len(s.lib.Snapshot().Sections) == 0 {
+		writeError(w, http.StatusServiceUnavailable, errors.New("no prompts loaded"))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (s *Server) handleMetrics(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = s.metrics.WriteTo(w)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": redact.String(err.Error())})
+}
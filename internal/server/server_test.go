@@ -0,0 +1,195 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/toozej/wheresmyprompt/internal/prompt"
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "prompts.md")
+	content := "## Golang\n### Tests\nWrite unit tests\n### Review\nReview this code\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+	return New(config.Config{FilePath: path})
+}
+
+func TestServer_HealthBeforeAndAfterLoad(t *testing.T) {
+	s := newTestServer(t)
+	h := s.Handler()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 before load, got %d", rec.Code)
+	}
+
+	if err := s.Reload(context.Background()); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected 200 after load, got %d", rec.Code)
+	}
+}
+
+func TestServer_SearchAndCache(t *testing.T) {
+	s := newTestServer(t)
+	if err := s.Reload(context.Background()); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	h := s.Handler()
+
+	for i := 0; i < 2; i++ {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/search?q=unit", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", rec.Code)
+		}
+		var got []promptResponse
+		if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if len(got) != 1 || got[0].Content != "Write unit tests" {
+			t.Errorf("unexpected search results: %+v", got)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := rec.Body.String()
+	for _, want := range []string{
+		"wheresmyprompt_search_cache_hits_total 1",
+		"wheresmyprompt_search_cache_misses_total 1",
+		"wheresmyprompt_search_duration_seconds_count 2",
+		"wheresmyprompt_load_duration_seconds_count 1",
+		"wheresmyprompt_prompts 2",
+		"wheresmyprompt_sections 2",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected metrics to contain %q, got:\n%s", want, body)
+		}
+	}
+}
+
+func TestServer_AddPrompt(t *testing.T) {
+	orig := addPromptFunc
+	defer func() { addPromptFunc = orig }()
+
+	tests := []struct {
+		name       string
+		body       string
+		err        error
+		wantStatus int
+		wantMetric string
+	}{
+		{"success", `{"title":"Lint","content":"Run the linter"}`, nil, http.StatusCreated, `wheresmyprompt_writes_total{result="success"} 1`},
+		{"read-only", `{"content":"x"}`, prompt.ErrReadOnly, http.StatusForbidden, `wheresmyprompt_writes_total{result="failure"} 1`},
+		{"conflict", `{"title":"Tests","content":"x"}`, prompt.ErrPromptExists, http.StatusConflict, `wheresmyprompt_writes_total{result="failure"} 1`},
+		{"other error", `{"content":"x"}`, errors.New("disk full"), http.StatusInternalServerError, `wheresmyprompt_writes_total{result="failure"} 1`},
+		{"bad json", `{`, nil, http.StatusBadRequest, `wheresmyprompt_writes_total{result="failure"} 0`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addPromptFunc = func(config.Config, string, string, string) error { return tt.err }
+			s := newTestServer(t)
+			s.conf.ServeToken = "s3cret"
+			h := s.Handler()
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/prompts", strings.NewReader(tt.body))
+			req.Header.Set("Authorization", "Bearer s3cret")
+			req.Header.Set("Content-Type", "application/json; charset=utf-8")
+			h.ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d", tt.wantStatus, rec.Code)
+			}
+
+			rec = httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+			if !strings.Contains(rec.Body.String(), tt.wantMetric) {
+				t.Errorf("expected metrics to contain %q", tt.wantMetric)
+			}
+		})
+	}
+}
+
+func TestServer_AddPromptRejected(t *testing.T) {
+	orig := addPromptFunc
+	defer func() { addPromptFunc = orig }()
+	addPromptFunc = func(config.Config, string, string, string) error {
+		t.Error("expected the write to be rejected")
+		return nil
+	}
+
+	tests := []struct {
+		name       string
+		token      string
+		headers    map[string]string
+		wantStatus int
+	}{
+		{"writes disabled", "", map[string]string{"Authorization": "Bearer s3cret", "Content-Type": "application/json"}, http.StatusForbidden},
+		{"missing token", "s3cret", map[string]string{"Content-Type": "application/json"}, http.StatusUnauthorized},
+		{"wrong token", "s3cret", map[string]string{"Authorization": "Bearer guess", "Content-Type": "application/json"}, http.StatusUnauthorized},
+		{"plain text body", "s3cret", map[string]string{"Authorization": "Bearer s3cret", "Content-Type": "text/plain"}, http.StatusUnsupportedMediaType},
+		{"browser origin", "s3cret", map[string]string{"Authorization": "Bearer s3cret", "Content-Type": "application/json", "Origin": "https://example.com"}, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t)
+			s.conf.ServeToken = tt.token
+			req := httptest.NewRequest(http.MethodPost, "/prompts", strings.NewReader(`{"content":"x"}`))
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			s.Handler().ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.wantStatus, rec.Code, rec.Body)
+			}
+		})
+	}
+}
+
+func TestServer_CacheBoundedAndClearedOnReload(t *testing.T) {
+	s := newTestServer(t)
+	if err := s.Reload(context.Background()); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	h := s.Handler()
+	for i := 0; i < maxCacheEntries+10; i++ {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, fmt.Sprintf("/search?q=q%d", i), nil))
+	}
+	if n := len(s.cache); n > maxCacheEntries {
+		t.Errorf("expected at most %d cached searches, got %d", maxCacheEntries, n)
+	}
+
+	// Results computed before a reload are not cached after it
+	s.cacheMu.Lock()
+	generation := s.generation
+	s.cacheMu.Unlock()
+	if err := os.WriteFile(s.conf.FilePath, []byte("## Golang\n### Fuzz\nWrite a fuzz test\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Reload(context.Background()); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	s.storeCache("\x00stale", generation, nil)
+	if _, ok := s.cache["\x00stale"]; ok || len(s.cache) != 0 {
+		t.Errorf("expected the reload to clear the cache and drop stale results, got %d entries", len(s.cache))
+	}
+}
@@ -0,0 +1,148 @@
+// Package share provides functionality for uploading prompts to paste services
+// so they can be sent to colleagues as a link. It supports GitHub gists (authenticated
+// with a token), which stay online until deleted, and self-hosted paste endpoints,
+// which can be asked to expire the link.
+package share
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/toozej/wheresmyprompt/internal/httpclient"
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+// defaultExpiry is the lifetime requested from paste endpoints when SHARE_EXPIRY is unset.
+const defaultExpiry = 24 * time.Hour
+
+// gistAPIURL is the GitHub API endpoint for creating gists.
+// It is a variable so tests can point it at a local server.
+var gistAPIURL = "https://api.github.com/gists"
+
+// Uploader uploads prompt content to a paste service and returns its URL.
+type Uploader interface {
+	Upload(content string) (string, error)
+}
+
+// NewUploader returns the Uploader selected by the ShareProvider configuration.
+// Returns an error if the provider is unknown or its required settings are missing,
+// or if SHARE_EXPIRY is set for gists, which never expire.
+func NewUploader(conf config.Config) (Uploader, error) {
+	switch strings.ToLower(conf.ShareProvider) {
+	case "", "gist":
+		if conf.ShareToken == "" {
+			return nil, fmt.Errorf("SHARE_TOKEN must be set to a GitHub token with gist scope to share via gist")
+		}
+		if conf.ShareExpiry > 0 {
+			return nil, fmt.Errorf("SHARE_EXPIRY is not supported by gists, which stay online until deleted; unset it, or set SHARE_PROVIDER=endpoint for shares that expire")
+		}
+		return &gistUploader{token: conf.ShareToken}, nil
+	case "endpoint":
+		if conf.ShareEndpoint == "" {
+			return nil, fmt.Errorf("SHARE_ENDPOINT must be set to share via a paste endpoint")
+		}
+		expiry := conf.ShareExpiry
+		if expiry == 0 {
+			expiry = defaultExpiry
+		}
+		return &endpointUploader{url: conf.ShareEndpoint, token: conf.ShareToken, expiry: expiry}, nil
+	default:
+		return nil, fmt.Errorf("unknown SHARE_PROVIDER %q (expected gist or endpoint)", conf.ShareProvider)
+	}
+}
+
+// gistUploader uploads prompts as secret GitHub gists.
+type gistUploader struct {
+	token string
+}
+
+// Upload creates a secret gist containing content and returns its URL.
+func (g *gistUploader) Upload(content string) (string, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"description": "Shared via wheresmyprompt",
+		"public":      false,
+		"files": map[string]interface{}{
+			"prompt.md": map[string]string{"content": content},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal gist JSON: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, gistAPIURL, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+g.token)
+
+	resp, err := httpclient.Client().Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to create gist: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		return "", fmt.Errorf("failed to create gist: unexpected status %s", resp.Status)
+	}
+
+	var gist struct {
+		HTMLURL string `json:"html_url"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&gist); err != nil {
+		return "", fmt.Errorf("failed to decode gist response: %w", err)
+	}
+	if gist.HTMLURL == "" {
+		return "", fmt.Errorf("gist response did not contain a URL")
+	}
+	return gist.HTMLURL, nil
+}
+
+// endpointUploader uploads prompts to a self-hosted paste endpoint.
+// The prompt is POSTed as plain text and the endpoint is expected to reply
+// with the paste URL as the response body.
+type endpointUploader struct {
+	url    string
+	token  string
+	expiry time.Duration
+}
+
+// Upload posts content to the endpoint and returns the URL it responds with.
+func (e *endpointUploader) Upload(content string) (string, error) {
+	req, err := http.NewRequest(http.MethodPost, e.url, strings.NewReader(content))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if e.token != "" {
+		req.Header.Set("Authorization", "Bearer "+e.token)
+	}
+	if e.expiry > 0 {
+		req.Header.Set("X-Expires-In", fmt.Sprintf("%d", int(e.expiry.Seconds())))
+	}
+
+	resp, err := httpclient.Client().Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload to %s: %w", e.url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "", fmt.Errorf("failed to upload to %s: unexpected status %s", e.url, resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if err != nil {
+		return "", fmt.Errorf("failed to read response from %s: %w", e.url, err)
+	}
+	url := strings.TrimSpace(string(data))
+	if url == "" {
+		return "", fmt.Errorf("%s did not return a URL", e.url)
+	}
+	return url, nil
+}
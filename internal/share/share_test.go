@@ -0,0 +1,117 @@
+package share
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+func TestNewUploader(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      config.Config
+		expectError bool
+	}{
+		{"gist with token", config.Config{ShareProvider: "gist", ShareToken: "token"}, false},
+		{"gist without token", config.Config{ShareProvider: "gist"}, true},
+		{"gist with expiry", config.Config{ShareProvider: "gist", ShareToken: "token", ShareExpiry: time.Hour}, true},
+		{"endpoint with url", config.Config{ShareProvider: "endpoint", ShareEndpoint: "http://localhost"}, false},
+		{"endpoint without url", config.Config{ShareProvider: "endpoint"}, true},
+		{"unknown provider", config.Config{ShareProvider: "pastebin"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewUploader(tt.config)
+			if tt.expectError && err == nil {
+				t.Error("expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestNewUploader_DefaultExpiry(t *testing.T) {
+	uploader, err := NewUploader(config.Config{ShareProvider: "endpoint", ShareEndpoint: "http://localhost"})
+	if err != nil {
+		t.Fatalf("NewUploader() error = %v", err)
+	}
+	if got := uploader.(*endpointUploader).expiry; got != defaultExpiry {
+		t.Errorf("expiry = %s, want %s without SHARE_EXPIRY", got, defaultExpiry)
+	}
+}
+
+func TestGistUpload(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var body struct {
+			Public bool `json:"public"`
+			Files  map[string]struct {
+				Content string `json:"content"`
+			} `json:"files"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if body.Public || body.Files["prompt.md"].Content != "Review this code" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"html_url":"https://gist.example.com/abc"}`))
+	}))
+	defer server.Close()
+
+	originalURL := gistAPIURL
+	gistAPIURL = server.URL
+	defer func() { gistAPIURL = originalURL }()
+
+	uploader, err := NewUploader(config.Config{ShareProvider: "gist", ShareToken: "secret"})
+	if err != nil {
+		t.Fatalf("NewUploader() returned error: %v", err)
+	}
+	url, err := uploader.Upload("Review this code")
+	if err != nil {
+		t.Fatalf("Upload() returned error: %v", err)
+	}
+	if url != "https://gist.example.com/abc" {
+		t.Errorf("Upload() = %q, want %q", url, "https://gist.example.com/abc")
+	}
+
+	uploader, _ = NewUploader(config.Config{ShareProvider: "gist", ShareToken: "wrong"})
+	if _, err := uploader.Upload("Review this code"); err == nil {
+		t.Error("expected error for unauthorized upload")
+	}
+}
+
+func TestEndpointUpload(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != "Write unit tests" || r.Header.Get("X-Expires-In") != "3600" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte("https://paste.example.com/xyz\n"))
+	}))
+	defer server.Close()
+
+	uploader, err := NewUploader(config.Config{ShareProvider: "endpoint", ShareEndpoint: server.URL, ShareExpiry: time.Hour})
+	if err != nil {
+		t.Fatalf("NewUploader() returned error: %v", err)
+	}
+	url, err := uploader.Upload("Write unit tests")
+	if err != nil {
+		t.Fatalf("Upload() returned error: %v", err)
+	}
+	if url != "https://paste.example.com/xyz" {
+		t.Errorf("Upload() = %q, want %q", url, "https://paste.example.com/xyz")
+	}
+}
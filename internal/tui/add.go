@@ -0,0 +1,240 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/toozej/wheresmyprompt/internal/prompt"
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+// Allow test overrides
+var (
+	addPromptFunc   = prompt.AddPrompt
+	loadPromptsFunc = prompt.LoadPrompts
+)
+
+// Fields of the add form, in tab order
+const (
+	addFieldTitle = iota
+	addFieldSection
+	addFieldContent
+	addFieldCount
+)
+
+// addForm is the inline form for adding a new prompt when a search finds nothing.
+type addForm struct {
+	title    textinput.Model
+	content  textarea.Model
+	sections []string          // Section choices; "" adds the prompt outside any section
+	icons    map[string]string // Icon of each section choice, if any
+	section  int               // Index of the selected section
+	focus    int
+	styles   styles
+	err      error
+}
+
+// newAddForm returns a form for a new prompt whose content is prefilled with query.
+func newAddForm(data *prompt.PromptData, conf config.Config, query string) addForm {
+	title := newTextInput()
+	title.Placeholder = "derived from the content when empty"
+	title.CharLimit = 156
+	title.Width = 50
+
+	content := newTextArea()
+	content.Placeholder = "Prompt content..."
+	content.SetWidth(60)
+	content.SetHeight(6)
+	content.SetValue(query)
+
+	return addForm{
+		title:    title,
+		content:  content,
+		sections: sectionChoices(data),
+		icons:    choiceIcons(data, conf),
+		styles:   newStyles(conf.Theme),
+	}
+}
+
+// sectionChoices returns "" followed by the name of every section in data, in
+// document order and without duplicates. Names are the last heading of a section,
+// as expected by prompt.AddPrompt.
+func sectionChoices(data *prompt.PromptData) []string {
+	choices := []string{""}
+	seen := map[string]bool{"": true}
+	for _, sec := range data.Sections {
+		// The first heading is the document title
+		if len(sec.Headings) < 2 {
+			continue
+		}
+		name := sec.Headings[len(sec.Headings)-1]
+		if !seen[name] {
+			seen[name] = true
+			choices = append(choices, name)
+		}
+	}
+	return choices
+}
+
+// choiceIcons returns the icon of every section name in data that has one.
+func choiceIcons(data *prompt.PromptData, conf config.Config) map[string]string {
+	icons := make(map[string]string)
+	for _, sec := range data.Sections {
+		if len(sec.Headings) < 2 {
+			continue
+		}
+		name := sec.Headings[len(sec.Headings)-1]
+		if icon := sectionIcon(conf, sec.Headings, sec.Icon); icon != "" && icons[name] == "" {
+			icons[name] = icon
+		}
+	}
+	return icons
+}
+
+// focusField moves the focus to field, returning the cursor blink command.
+func (f *addForm) focusField(field int) tea.Cmd {
+	f.focus = field
+	f.title.Blur()
+	f.content.Blur()
+	switch field {
+	case addFieldTitle:
+		return f.title.Focus()
+	case addFieldContent:
+		return f.content.Focus()
+	}
+	return nil
+}
+
+// selectedSection returns the chosen section name, "" for none.
+func (f *addForm) selectedSection() string {
+	return f.sections[f.section]
+}
+
+// update passes msg to the focused field, handling the keys that move between
+// fields and change the section.
+func (f *addForm) update(msg tea.Msg) tea.Cmd {
+	key := ""
+	if k, ok := msg.(tea.KeyMsg); ok {
+		key = k.String()
+	}
+	switch key {
+	case "tab":
+		return f.focusField((f.focus + 1) % addFieldCount)
+	case "shift+tab":
+		return f.focusField((f.focus + addFieldCount - 1) % addFieldCount)
+	}
+
+	var cmd tea.Cmd
+	switch f.focus {
+	case addFieldTitle:
+		if key == "enter" {
+			return f.focusField(addFieldSection)
+		}
+		f.title, cmd = f.title.Update(msg)
+	case addFieldSection:
+		switch key {
+		case "left", "h", "up", "k":
+			f.section = (f.section + len(f.sections) - 1) % len(f.sections)
+		case "right", "l", "down", "j", " ":
+			f.section = (f.section + 1) % len(f.sections)
+		case "enter":
+			return f.focusField(addFieldContent)
+		}
+	case addFieldContent:
+		f.content, cmd = f.content.Update(msg)
+	}
+	return cmd
+}
+
+// label renders a field label, highlighted when the field has the focus.
+func (f *addForm) label(field int, text string) string {
+	if f.focus == field {
+		return f.styles.selected.Render("▶ " + text)
+	}
+	return "  " + text
+}
+
+func (f addForm) view() string {
+	var b strings.Builder
+
+	b.WriteString("Add as new prompt\n\n")
+	if f.err != nil {
+		b.WriteString(fmt.Sprintf("Error: %v\n\n", f.err))
+	}
+
+	b.WriteString(f.label(addFieldTitle, "Title: "))
+	b.WriteString(f.title.View())
+	b.WriteString("\n\n")
+
+	section := f.selectedSection()
+	if section == "" {
+		section = "(none)"
+	} else if icon := f.icons[section]; icon != "" {
+		section = icon + " " + section
+	}
+	b.WriteString(f.label(addFieldSection, "Section: "))
+	b.WriteString(fmt.Sprintf("◀ %s ▶", section))
+	b.WriteString("\n\n")
+
+	b.WriteString(f.label(addFieldContent, "Content:"))
+	b.WriteString("\n")
+	b.WriteString(f.content.View())
+	b.WriteString("\n\n")
+
+	b.WriteString(f.styles.help.Render("tab next field • ←/→ change section • ctrl+s save • esc cancel"))
+	return b.String()
+}
+
+// openAddForm switches the model to the add form, prefilled with the search query.
+func (m *model) openAddForm() tea.Cmd {
+	m.form = newAddForm(m.prompts, m.config, m.textInput.Value())
+	m.adding = true
+	m.status = ""
+	return m.form.focusField(addFieldTitle)
+}
+
+// updateAddForm handles a message while the add form is open.
+func (m *model) updateAddForm(msg tea.Msg) tea.Cmd {
+	key, _ := msg.(tea.KeyMsg)
+	switch key.String() {
+	case "ctrl+c":
+		return tea.Quit
+	case "esc":
+		m.adding = false
+		m.status = "Add cancelled"
+		return nil
+	case "ctrl+s":
+		m.saveAddForm()
+		return nil
+	}
+	return m.form.update(msg)
+}
+
+// saveAddForm writes the new prompt and, on success, reloads the prompts so it can
+// be searched right away. Write errors are shown in the form so they can be fixed.
+func (m *model) saveAddForm() {
+	content := strings.TrimSpace(m.form.content.Value())
+	if content == "" {
+		m.form.err = fmt.Errorf("prompt content is required")
+		return
+	}
+	title := strings.TrimSpace(m.form.title.Value())
+	if err := addPromptFunc(m.config, title, content, m.form.selectedSection()); err != nil {
+		m.form.err = err
+		return
+	}
+
+	m.adding = false
+	if m.config.Staging {
+		m.status = "Staged new prompt for review"
+		return
+	}
+	m.status = "Added new prompt"
+
+	if err := m.reloadPrompts(); err != nil {
+		m.err = fmt.Errorf("prompt added, but %w", err)
+	}
+}
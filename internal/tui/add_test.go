@@ -0,0 +1,153 @@
+package tui
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/toozej/wheresmyprompt/internal/prompt"
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+var addTestPrompts = &prompt.PromptData{
+	Sections: []prompt.Section{
+		{Headings: []string{"Prompts"}},
+		{Headings: []string{"Prompts", "Golang"}, Lines: []string{"Write table-driven tests"}},
+		{Headings: []string{"Prompts", "Python"}, Lines: []string{"Write pytest fixtures"}},
+	},
+}
+
+// newAddTestModel returns a model whose search for query matches nothing.
+func newAddTestModel(query string) model {
+	ti := textinput.New()
+	ti.SetValue(query)
+	searchPool := generateSearchPoolFromSections(addTestPrompts)
+	m := model{
+		textInput:  ti,
+		prompts:    addTestPrompts,
+		searchPool: searchPool,
+		config:     mockConfig,
+	}
+	m.filterResults()
+	return m
+}
+
+func sendKeys(m model, keys ...tea.KeyMsg) model {
+	for _, key := range keys {
+		updated, _ := m.Update(key)
+		m = updated.(model)
+	}
+	return m
+}
+
+func TestSectionChoices(t *testing.T) {
+	data := &prompt.PromptData{Sections: append(addTestPrompts.Sections, prompt.Section{Headings: []string{"Prompts", "Golang"}})}
+	expected := []string{"", "Golang", "Python"}
+	if got := sectionChoices(data); !reflect.DeepEqual(got, expected) {
+		t.Errorf("sectionChoices() = %q, want %q", got, expected)
+	}
+}
+
+func TestModel_AddPrompt(t *testing.T) {
+	originalAdd, originalLoad := addPromptFunc, loadPromptsFunc
+	defer func() { addPromptFunc, loadPromptsFunc = originalAdd, originalLoad }()
+
+	var added []string
+	addPromptFunc = func(_ config.Config, title, content, section string) error {
+		added = append(added, title, content, section)
+		return nil
+	}
+	reloaded := &prompt.PromptData{Sections: append(addTestPrompts.Sections, prompt.Section{
+		Headings: []string{"Prompts", "Python", "Docs"},
+		Lines:    []string{"Write numpy docstrings"},
+	})}
+	loadPromptsFunc = func(config.Config) (*prompt.PromptData, error) {
+		return reloaded, nil
+	}
+
+	m := newAddTestModel("numpy docstrings")
+	if !strings.Contains(m.View(), "Press enter to add it as a new prompt") {
+		t.Error("expected the add hint when nothing matches")
+	}
+
+	m = sendKeys(m, tea.KeyMsg{Type: tea.KeyEnter})
+	if !m.adding {
+		t.Fatal("expected enter to open the add form when nothing matches")
+	}
+	if got := m.form.content.Value(); got != "numpy docstrings" {
+		t.Errorf("expected content prefilled with the query, got %q", got)
+	}
+
+	m = sendKeys(m,
+		tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("Docstrings")},
+		tea.KeyMsg{Type: tea.KeyTab},
+		tea.KeyMsg{Type: tea.KeyRight},
+		tea.KeyMsg{Type: tea.KeyRight},
+		tea.KeyMsg{Type: tea.KeyTab},
+		tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(" in Python")},
+		tea.KeyMsg{Type: tea.KeyCtrlS},
+	)
+
+	expected := []string{"Docstrings", "numpy docstrings in Python", "Python"}
+	if !reflect.DeepEqual(added, expected) {
+		t.Fatalf("AddPrompt called with %q, want %q", added, expected)
+	}
+	if m.adding {
+		t.Error("expected the form to close after saving")
+	}
+	if m.prompts != reloaded || len(m.filteredResults) != 1 || m.filteredResults[0].Content != "Write numpy docstrings" {
+		t.Errorf("expected the search to be refreshed from the reloaded prompts, got %+v", m.filteredResults)
+	}
+	if !strings.Contains(m.View(), "Added new prompt") {
+		t.Error("expected added status in view")
+	}
+}
+
+func TestModel_AddPromptErrors(t *testing.T) {
+	originalAdd := addPromptFunc
+	defer func() { addPromptFunc = originalAdd }()
+	addPromptFunc = func(config.Config, string, string, string) error {
+		return errors.New("prompt already exists")
+	}
+
+	m := sendKeys(newAddTestModel("kubernetes"), tea.KeyMsg{Type: tea.KeyEnter}, tea.KeyMsg{Type: tea.KeyCtrlS})
+	if !m.adding {
+		t.Fatal("expected the form to stay open when adding fails")
+	}
+	if !strings.Contains(m.View(), "Error: prompt already exists") {
+		t.Error("expected the error in the form")
+	}
+
+	m = sendKeys(m, tea.KeyMsg{Type: tea.KeyEsc})
+	if m.adding {
+		t.Error("expected esc to close the form")
+	}
+	if !strings.Contains(m.View(), "Add cancelled") {
+		t.Error("expected cancel status in view")
+	}
+}
+
+func TestModel_AddPromptRequiresContent(t *testing.T) {
+	called := false
+	originalAdd := addPromptFunc
+	defer func() { addPromptFunc = originalAdd }()
+	addPromptFunc = func(config.Config, string, string, string) error {
+		called = true
+		return nil
+	}
+
+	// An empty library has no results even without a query
+	m := newAddTestModel("")
+	m.searchPool = nil
+	m.filterResults()
+	m = sendKeys(m, tea.KeyMsg{Type: tea.KeyEnter}, tea.KeyMsg{Type: tea.KeyCtrlS})
+	if called {
+		t.Error("expected empty content not to be added")
+	}
+	if !strings.Contains(m.View(), "prompt content is required") {
+		t.Error("expected content error in the form")
+	}
+}
@@ -0,0 +1,29 @@
+package tui
+
+import (
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
+
+	"github.com/toozej/wheresmyprompt/internal/sandbox"
+)
+
+// newTextInput returns a text input whose paste key is unbound while external
+// programs are disabled, since bubbles reads the clipboard for it by running xclip,
+// xsel, wl-paste or pbpaste directly rather than through the sandbox.
+func newTextInput() textinput.Model {
+	ti := textinput.New()
+	if sandbox.Disabled() {
+		ti.KeyMap.Paste.SetEnabled(false)
+	}
+	return ti
+}
+
+// newTextArea returns a text area whose paste key is unbound while external
+// programs are disabled, see newTextInput.
+func newTextArea() textarea.Model {
+	ta := textarea.New()
+	if sandbox.Disabled() {
+		ta.KeyMap.Paste.SetEnabled(false)
+	}
+	return ta
+}
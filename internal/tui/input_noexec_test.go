@@ -0,0 +1,24 @@
+//go:build noexec
+
+package tui
+
+import (
+	"testing"
+
+	"github.com/toozej/wheresmyprompt/internal/prompt"
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+func TestPasteDisabledWithoutExec(t *testing.T) {
+	m := newModel(&prompt.PromptData{}, config.Config{})
+	if m.textInput.KeyMap.Paste.Enabled() {
+		t.Error("expected the search box not to paste in a noexec build")
+	}
+	form := newAddForm(&prompt.PromptData{}, config.Config{}, "")
+	if form.title.KeyMap.Paste.Enabled() {
+		t.Error("expected the title input not to paste in a noexec build")
+	}
+	if form.content.KeyMap.Paste.Enabled() {
+		t.Error("expected the content area not to paste in a noexec build")
+	}
+}
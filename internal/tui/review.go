@@ -0,0 +1,138 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/toozej/wheresmyprompt/internal/prompt"
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+// Allow test overrides
+var (
+	listStagedFunc   = prompt.ListStaged
+	acceptStagedFunc = prompt.AcceptStaged
+	rejectStagedFunc = prompt.RejectStaged
+)
+
+type reviewModel struct {
+	staged []prompt.StagedPrompt
+	cursor int
+	status string
+	config config.Config
+	styles styles
+	err    error
+}
+
+// RunReviewTUI starts the terminal user interface for reviewing staged prompts.
+// Maintainers can accept a staged prompt (moving it into its target section) or
+// reject it (removing it from the staging section).
+// Returns an error if the staged prompts cannot be loaded or the TUI fails to start.
+func RunReviewTUI(conf config.Config) error {
+	staged, err := listStagedFunc(conf)
+	if err != nil {
+		return err
+	}
+
+	m := reviewModel{
+		staged: staged,
+		config: conf,
+		styles: newStyles(conf.Theme),
+	}
+
+	p := tea.NewProgram(m, tea.WithAltScreen())
+	_, err = p.Run()
+	return err
+}
+
+func (m reviewModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m reviewModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "ctrl+c", "esc", "q":
+			return m, tea.Quit
+
+		case "up", "k":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+
+		case "down", "j":
+			if m.cursor < len(m.staged)-1 {
+				m.cursor++
+			}
+
+		case "a":
+			m.resolve(acceptStagedFunc, "Accepted")
+
+		case "r":
+			m.resolve(rejectStagedFunc, "Rejected")
+		}
+	}
+
+	return m, nil
+}
+
+// resolve applies action to the selected staged prompt and removes it from the list.
+func (m *reviewModel) resolve(action func(config.Config, prompt.StagedPrompt) error, verb string) {
+	if m.cursor >= len(m.staged) {
+		return
+	}
+	sp := m.staged[m.cursor]
+	if err := action(m.config, sp); err != nil {
+		m.err = err
+		return
+	}
+	m.err = nil
+	m.status = fmt.Sprintf("%s '%s'", verb, sp.Title)
+	m.staged = append(m.staged[:m.cursor:m.cursor], m.staged[m.cursor+1:]...)
+	if m.cursor >= len(m.staged) && m.cursor > 0 {
+		m.cursor--
+	}
+}
+
+func (m reviewModel) View() string {
+	var b strings.Builder
+
+	b.WriteString(m.styles.title.Render("Where's My Prompt? - Review"))
+	b.WriteString("\n\n")
+
+	if m.err != nil {
+		b.WriteString(fmt.Sprintf("Error: %v\n\n", m.err))
+	} else if m.status != "" {
+		b.WriteString(m.styles.help.Render(m.status))
+		b.WriteString("\n\n")
+	}
+
+	if len(m.staged) == 0 {
+		b.WriteString("No staged prompts to review.\n")
+	} else {
+		b.WriteString(fmt.Sprintf("%d staged prompt(s):\n\n", len(m.staged)))
+		for i, sp := range m.staged {
+			cursor := " "
+			title := sp.Title
+			if m.cursor == i {
+				cursor = "▶"
+				title = m.styles.selected.Render(title)
+			}
+			target := sp.Target
+			if target == "" {
+				target = "no section"
+			}
+			b.WriteString(fmt.Sprintf("%s %s → %s\n", cursor, title, target))
+			if m.cursor == i {
+				b.WriteString(m.styles.prompt.Render(sp.Content))
+				b.WriteString("\n")
+			}
+		}
+	}
+
+	b.WriteString("\n")
+	b.WriteString(m.styles.help.Render("↑/k up • ↓/j down • a accept • r reject • q/esc quit"))
+
+	return b.String()
+}
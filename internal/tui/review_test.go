@@ -0,0 +1,71 @@
+package tui
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/toozej/wheresmyprompt/internal/prompt"
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+func TestReviewModel_Update(t *testing.T) {
+	var accepted, rejected []string
+	originalAccept, originalReject := acceptStagedFunc, rejectStagedFunc
+	defer func() {
+		acceptStagedFunc, rejectStagedFunc = originalAccept, originalReject
+	}()
+	acceptStagedFunc = func(_ config.Config, sp prompt.StagedPrompt) error {
+		accepted = append(accepted, sp.Title)
+		return nil
+	}
+	rejectStagedFunc = func(_ config.Config, sp prompt.StagedPrompt) error {
+		if sp.Title == "Broken" {
+			return errors.New("write failed")
+		}
+		rejected = append(rejected, sp.Title)
+		return nil
+	}
+
+	m := reviewModel{
+		staged: []prompt.StagedPrompt{
+			{Title: "First", Target: "Golang", Content: "first prompt"},
+			{Title: "Second", Content: "second prompt"},
+			{Title: "Broken", Content: "broken prompt"},
+		},
+	}
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'a'}})
+	m = updated.(reviewModel)
+	if len(accepted) != 1 || accepted[0] != "First" || len(m.staged) != 2 {
+		t.Fatalf("expected First to be accepted, got accepted=%v staged=%d", accepted, len(m.staged))
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'r'}})
+	m = updated.(reviewModel)
+	if len(rejected) != 1 || rejected[0] != "Second" || len(m.staged) != 1 {
+		t.Fatalf("expected Second to be rejected, got rejected=%v staged=%d", rejected, len(m.staged))
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'r'}})
+	m = updated.(reviewModel)
+	if m.err == nil || len(m.staged) != 1 {
+		t.Error("expected failed rejection to keep the prompt and record the error")
+	}
+	if !strings.Contains(m.View(), "write failed") {
+		t.Error("expected View() to show the error")
+	}
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'q'}})
+	if cmd == nil {
+		t.Error("expected quit command")
+	}
+}
+
+func TestReviewModel_View_Empty(t *testing.T) {
+	m := reviewModel{}
+	if !strings.Contains(m.View(), "No staged prompts to review.") {
+		t.Error("expected empty review message")
+	}
+}
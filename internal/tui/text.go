@@ -0,0 +1,80 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+	"golang.org/x/text/unicode/bidi"
+)
+
+// previewWidth is how many columns of a prompt the preview shows in all.
+const previewWidth = 100
+
+// ellipsis marks text cut to fit.
+const ellipsis = "..."
+
+// The first strong isolate and pop directional isolate characters keep Arabic or
+// Hebrew text from reordering the cursor, badges and brackets around it in
+// terminals applying the Unicode bidirectional algorithm. Both have no width.
+const (
+	firstStrongIsolate    = "\u2068"
+	popDirectionalIsolate = "\u2069"
+)
+
+// fitWidth cuts s to at most width terminal columns, ending it in an ellipsis if
+// anything was cut. Wide characters such as CJK ideographs count as two columns and
+// are never split. A width of 0 or less leaves s unchanged.
+func fitWidth(s string, width int) string {
+	if width <= 0 || ansi.StringWidth(s) <= width {
+		return s
+	}
+	if width <= len(ellipsis) {
+		return ansi.Truncate(s, width, "")
+	}
+	return ansi.Truncate(s, width, ellipsis)
+}
+
+// hasRTL reports whether s contains right-to-left letters, such as Arabic or Hebrew.
+func hasRTL(s string) bool {
+	for _, r := range s {
+		if p, _ := bidi.LookupRune(r); p.Class() == bidi.R || p.Class() == bidi.AL {
+			return true
+		}
+	}
+	return false
+}
+
+// isolateBidi wraps s in directional isolates if it contains right-to-left text, so
+// it is laid out on its own without affecting the text around it.
+func isolateBidi(s string) string {
+	if !hasRTL(s) {
+		return s
+	}
+	return firstStrongIsolate + s + popDirectionalIsolate
+}
+
+// previewText returns the start of content shown in the preview: at most
+// previewWidth columns in all, and at most lineWidth columns per line (0 for no
+// limit), cut with an ellipsis. Lines with right-to-left text are isolated so the
+// border of the preview stays aligned.
+func previewText(content string, lineWidth int) string {
+	var lines []string
+	budget := previewWidth
+	for _, line := range strings.Split(content, "\n") {
+		if budget <= 0 {
+			lines = append(lines, ellipsis)
+			break
+		}
+		limit := budget
+		if lineWidth > 0 {
+			limit = min(limit, lineWidth)
+		}
+		fitted := fitWidth(line, limit)
+		lines = append(lines, isolateBidi(fitted))
+		if fitted != line && limit == budget {
+			break // The preview is full
+		}
+		budget -= ansi.StringWidth(fitted)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,143 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/toozej/wheresmyprompt/internal/prompt"
+)
+
+// Representative prompts in scripts that are wide or written right to left
+const (
+	japanese = "このコードをレビューして、改善点を日本語で説明してください"
+	chinese  = "请审查这段代码并指出潜在的性能问题"
+	korean   = "이 코드를 검토하고 개선할 점을 알려주세요"
+	arabic   = "راجع هذه الشيفرة واقترح تحسينات"
+	hebrew   = "סקור את הקוד הזה והצע שיפורים"
+)
+
+func TestFitWidth(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		width int
+	}{
+		{"japanese", japanese, 21},
+		{"chinese", chinese, 10},
+		{"korean", korean, 15},
+		{"arabic", arabic, 12},
+		{"hebrew", hebrew, 9},
+		{"mixed", "Review 日本語 code", 11},
+		{"narrower than the ellipsis", japanese, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := fitWidth(tt.text, tt.width)
+			if !utf8.ValidString(got) {
+				t.Fatalf("fitWidth() split a character: %q", got)
+			}
+			if w := lipgloss.Width(got); w > tt.width {
+				t.Errorf("fitWidth() = %q, %d columns wide, want at most %d", got, w, tt.width)
+			}
+			if tt.width > len(ellipsis) && !strings.HasSuffix(got, ellipsis) {
+				t.Errorf("fitWidth() = %q, want it to end in an ellipsis", got)
+			}
+		})
+	}
+
+	if got := fitWidth(chinese, 0); got != chinese {
+		t.Errorf("fitWidth() = %q, want the text unchanged without a width", got)
+	}
+	if got := fitWidth(korean, lipgloss.Width(korean)); got != korean {
+		t.Errorf("fitWidth() = %q, want text that fits unchanged", got)
+	}
+}
+
+func TestIsolateBidi(t *testing.T) {
+	tests := []struct {
+		text     string
+		isolated bool
+	}{
+		{arabic, true},
+		{hebrew, true},
+		{"Translate to Hebrew: " + hebrew, true},
+		{japanese, false},
+		{"Review this code", false},
+	}
+	for _, tt := range tests {
+		got := isolateBidi(tt.text)
+		if isolated := got != tt.text; isolated != tt.isolated {
+			t.Errorf("isolateBidi(%q) = %q, want isolated %v", tt.text, got, tt.isolated)
+		}
+		if lipgloss.Width(got) != lipgloss.Width(tt.text) {
+			t.Errorf("isolateBidi(%q) changed the width of the text", tt.text)
+		}
+	}
+}
+
+func TestPreviewText(t *testing.T) {
+	content := strings.Repeat(japanese+"\n", 3) + arabic + "\n" + hebrew
+	got := previewText(content, 30)
+	if !utf8.ValidString(got) {
+		t.Fatalf("previewText() split a character: %q", got)
+	}
+	total := 0
+	for _, line := range strings.Split(got, "\n") {
+		w := lipgloss.Width(line)
+		if w > 30 {
+			t.Errorf("line %q is %d columns wide, want at most 30", line, w)
+		}
+		total += w
+	}
+	if total > previewWidth || !strings.HasSuffix(strings.TrimSuffix(got, popDirectionalIsolate), ellipsis) {
+		t.Errorf("previewText() = %q (%d columns), want the first %d columns cut with an ellipsis", got, total, previewWidth)
+	}
+
+	if got := previewText(hebrew, 0); got != isolateBidi(hebrew) {
+		t.Errorf("previewText() = %q, want a short prompt whole", got)
+	}
+}
+
+func TestView_WideAndRTLAlignment(t *testing.T) {
+	const width = 40
+	for _, content := range []string{japanese + "\n" + chinese, arabic + "\n" + hebrew + " " + korean} {
+		pool := []prompt.Prompt{
+			{Content: content, Section: japanese + arabic},
+			{Content: "Short", Section: hebrew},
+		}
+		m := model{textInput: textinput.New(), prompts: &prompt.PromptData{}, searchPool: pool, filteredResults: pool, config: mockConfig, styles: newStyles(ThemeDefault)}
+		updated, _ := m.Update(tea.WindowSizeMsg{Width: width, Height: 40})
+		view := updated.(model).View()
+
+		if !utf8.ValidString(view) {
+			t.Fatal("View() split a character")
+		}
+		boxWidth := 0
+		for _, line := range strings.Split(view, "\n") {
+			if strings.Contains(line, "•") {
+				continue // The help line may wrap like any other text
+			}
+			w := lipgloss.Width(line)
+			if strings.HasPrefix(line, "▶") || strings.HasPrefix(line, "  ") && w > 0 {
+				if w > width {
+					t.Errorf("result line %q is %d columns wide, want at most %d", line, w, width)
+				}
+			}
+			if strings.HasPrefix(line, "│") || strings.HasPrefix(line, "╭") || strings.HasPrefix(line, "╰") {
+				if boxWidth == 0 {
+					boxWidth = w
+				}
+				if w != boxWidth || w > width {
+					t.Errorf("preview line %q is %d columns wide, want all %d and at most %d", line, w, boxWidth, width)
+				}
+			}
+		}
+		if boxWidth == 0 {
+			t.Errorf("expected a preview in the view:\n%s", view)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package tui
+
+import (
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Names of the built-in themes, selected with THEME or --theme.
+const (
+	ThemeDefault      = "default"
+	ThemeHighContrast = "high-contrast"
+	ThemeColorblind   = "colorblind"
+)
+
+// theme holds the colors the TUI is drawn with, see styles.
+type theme struct {
+	titleForeground lipgloss.TerminalColor
+	titleBackground lipgloss.TerminalColor
+	accent          lipgloss.TerminalColor // The selected result and focused form field
+	border          lipgloss.TerminalColor // The border of the preview
+	muted           lipgloss.TerminalColor // Help, status and badges
+	// underline marks the selected result by more than its color
+	underline bool
+}
+
+// themes are the built-in themes by name.
+var themes = map[string]theme{
+	ThemeDefault: {
+		titleForeground: lipgloss.Color("#FAFAFA"),
+		titleBackground: lipgloss.Color("#7D56F4"),
+		accent:          lipgloss.Color("#7D56F4"),
+		border:          lipgloss.Color("#874BFD"),
+		muted:           lipgloss.Color("#626262"),
+	},
+	// Black and white, swapped on light terminals, with a bright selection
+	ThemeHighContrast: {
+		titleForeground: lipgloss.AdaptiveColor{Light: "#FFFFFF", Dark: "#000000"},
+		titleBackground: lipgloss.AdaptiveColor{Light: "#000000", Dark: "#FFFFFF"},
+		accent:          lipgloss.AdaptiveColor{Light: "#0000AF", Dark: "#FFFF00"},
+		border:          lipgloss.AdaptiveColor{Light: "#000000", Dark: "#FFFFFF"},
+		muted:           lipgloss.AdaptiveColor{Light: "#303030", Dark: "#D0D0D0"},
+		underline:       true,
+	},
+	// Blue and orange from the Okabe-Ito palette, told apart with any color vision
+	ThemeColorblind: {
+		titleForeground: lipgloss.Color("#FFFFFF"),
+		titleBackground: lipgloss.Color("#0072B2"),
+		accent:          lipgloss.Color("#E69F00"),
+		border:          lipgloss.Color("#56B4E9"),
+		muted:           lipgloss.AdaptiveColor{Light: "#5C5C5C", Dark: "#9E9E9E"},
+		underline:       true,
+	},
+}
+
+// styles are the lipgloss styles of a theme.
+type styles struct {
+	title    lipgloss.Style
+	selected lipgloss.Style
+	prompt   lipgloss.Style
+	help     lipgloss.Style
+}
+
+// newStyles returns the styles of the theme called name, falling back to the
+// default theme for unknown names.
+func newStyles(name string) styles {
+	t, ok := themes[name]
+	if !ok {
+		t = themes[ThemeDefault]
+	}
+	return styles{
+		title: lipgloss.NewStyle().
+			Bold(true).
+			Foreground(t.titleForeground).
+			Background(t.titleBackground).
+			Padding(0, 1),
+		selected: lipgloss.NewStyle().
+			Bold(true).
+			Underline(t.underline).
+			Foreground(t.accent),
+		prompt: lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(t.border).
+			Padding(1, 2).
+			MarginTop(1),
+		help: lipgloss.NewStyle().
+			Foreground(t.muted),
+	}
+}
@@ -0,0 +1,43 @@
+package tui
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/toozej/wheresmyprompt/pkg/config"
+)
+
+func TestNewStyles(t *testing.T) {
+	for _, name := range []string{ThemeDefault, ThemeHighContrast, ThemeColorblind} {
+		if _, ok := themes[name]; !ok {
+			t.Errorf("theme %q is not defined", name)
+		}
+	}
+
+	if s := newStyles(ThemeDefault); s.title.GetBackground() != lipgloss.Color("#7D56F4") || s.selected.GetUnderline() {
+		t.Errorf("expected the purple default theme, got title background %v", s.title.GetBackground())
+	}
+	if s := newStyles("unknown"); s.title.GetBackground() != lipgloss.Color("#7D56F4") {
+		t.Errorf("expected unknown themes to fall back to the default, got %v", s.title.GetBackground())
+	}
+	for _, name := range []string{ThemeHighContrast, ThemeColorblind} {
+		s := newStyles(name)
+		if !s.selected.GetUnderline() {
+			t.Errorf("%s: expected the selection to be marked by more than its color", name)
+		}
+		if s.selected.GetForeground() == newStyles(ThemeDefault).selected.GetForeground() {
+			t.Errorf("%s: expected its own selection color", name)
+		}
+	}
+}
+
+func TestNewModel_Theme(t *testing.T) {
+	m := newModel(mockPrompts, config.Config{Theme: ThemeColorblind})
+	if m.styles.title.GetBackground() != lipgloss.Color("#0072B2") {
+		t.Errorf("expected the colorblind theme, got title background %v", m.styles.title.GetBackground())
+	}
+	m.openAddForm()
+	if m.form.styles.selected.GetForeground() != lipgloss.Color("#E69F00") {
+		t.Errorf("expected the add form to use the theme, got %v", m.form.styles.selected.GetForeground())
+	}
+}
@@ -4,73 +4,125 @@
 package tui
 
 import (
+	"errors"
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
-	"github.com/lithammer/fuzzysearch/fuzzy"
+	"github.com/toozej/wheresmyprompt/internal/history"
 	"github.com/toozej/wheresmyprompt/internal/prompt"
 	"github.com/toozej/wheresmyprompt/pkg/config"
 )
 
+// Search pools with at least debouncePoolSize prompts are filtered debounceDelay
+// after the last keystroke instead of on every keystroke, keeping typing responsive.
+const (
+	debouncePoolSize = 5000
+	debounceDelay    = 80 * time.Millisecond
+)
+
+// filterMsg asks the model to filter the results for the keystroke numbered seq.
+type filterMsg struct{ seq int }
+
+// editorFinishedMsg reports that the editor opened with the o key exited, and whether
+// its edits were saved.
+type editorFinishedMsg struct{ err error }
+
+// Allow test overrides
+var archivePromptFunc = prompt.ArchivePrompt
+var editPromptFunc = prompt.EditPrompt
+var copyToClipboardFunc = prompt.CopyToClipboard
+var typeTextFunc = prompt.TypeText
+
+// summaryNow allows tests to control the age of the source shown in the title bar.
+var summaryNow = time.Now
+
 type model struct {
 	textInput       textinput.Model
 	prompts         *prompt.PromptData
 	searchPool      []prompt.Prompt
 	filteredResults []prompt.Prompt
 	cursor          int
+	namespace       string               // Restrict results to this namespace (empty for all)
+	titlesOnly      bool                 // Match the query against titles and section headings only
+	sortOrder       string               // Order of the results, see prompt.SortOrders
+	added           map[string]time.Time // When prompts were added, loaded for prompt.SortRecent
+	status          string
+	typeText        string   // Prompt to type into the focused window once the TUI has exited
+	attachments     []string // Attachment paths to print once the TUI has exited
+	suggestion      string   // Near-miss headings hinted when the query matches nothing
+	searching       bool     // The results are stale until the debounced filter runs
+	filterSeq       int      // Numbers query changes so only the last one's filter runs
+	adding          bool     // The add form is shown instead of the search
+	width           int      // Width of the terminal, 0 until it is known
+	form            addForm
 	config          config.Config
+	styles          styles
 	err             error
 }
 
-var (
-	titleStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(lipgloss.Color("#FAFAFA")).
-			Background(lipgloss.Color("#7D56F4")).
-			Padding(0, 1)
-
-	selectedStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(lipgloss.Color("#7D56F4"))
-
-	promptStyle = lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(lipgloss.Color("#874BFD")).
-			Padding(1, 2).
-			MarginTop(1)
-
-	helpStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#626262"))
-)
-
 // RunTUI starts the terminal user interface for interactive prompt selection.
 // It creates a searchable, navigable interface where users can fuzzy search through prompts
 // and select one to copy to the clipboard. The interface supports keyboard navigation
 // with vim-like keybindings and real-time search filtering.
 // Returns an error if the TUI fails to start or encounters runtime errors.
 func RunTUI(prompts *prompt.PromptData, conf config.Config) error {
-	ti := textinput.New()
+	p := tea.NewProgram(newModel(prompts, conf), tea.WithAltScreen())
+	final, err := p.Run()
+	if err != nil {
+		return err
+	}
+
+	// Type only after the alternate screen is gone and focus is back on the target window
+	fm, ok := final.(model)
+	if !ok {
+		return nil
+	}
+	for _, path := range fm.attachments {
+		fmt.Println(path)
+	}
+	if fm.typeText != "" {
+		return typeTextFunc(fm.typeText, conf.TypeDelay)
+	}
+	return nil
+}
+
+// newModel returns the initial search model, with the search box pre-filled with
+// conf.DefaultQuery and the results filtered accordingly.
+func newModel(prompts *prompt.PromptData, conf config.Config) model {
+	ti := newTextInput()
 	ti.Placeholder = "Search prompts..."
 	ti.Focus()
 	ti.CharLimit = 156
 	ti.Width = 50
 
 	searchPool := generateSearchPoolFromSections(prompts)
+	if conf.DedupeResults {
+		searchPool = prompt.MergeDuplicates(searchPool)
+	}
 
 	m := model{
 		textInput:       ti,
 		prompts:         prompts,
 		searchPool:      searchPool,
 		filteredResults: searchPool,
+		titlesOnly:      conf.TitlesOnly,
+		sortOrder:       conf.Sort,
 		config:          conf,
+		styles:          newStyles(conf.Theme),
 	}
-
-	p := tea.NewProgram(m, tea.WithAltScreen())
-	_, err := p.Run()
-	return err
+	if conf.DefaultQuery != "" {
+		m.textInput.SetValue(conf.DefaultQuery)
+		m.textInput.CursorEnd()
+	}
+	if conf.DefaultQuery != "" || (m.sortOrder != "" && m.sortOrder != prompt.SortRelevance) {
+		m.filterResults()
+	}
+	return m
 }
 
 func (m model) Init() tea.Cmd {
@@ -80,19 +132,57 @@ func (m model) Init() tea.Cmd {
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 
+	if m.adding {
+		return m, m.updateAddForm(msg)
+	}
+
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "ctrl+c", "esc":
 			return m, tea.Quit
 
-		case "enter":
-			if len(m.filteredResults) > 0 && m.cursor < len(m.filteredResults) {
+		case "enter", "alt+enter":
+			if m.searching {
+				m.refilter()
+			}
+			if len(m.filteredResults) == 0 {
+				return m, m.openAddForm()
+			}
+			if m.cursor < len(m.filteredResults) {
 				selectedPrompt := m.filteredResults[m.cursor]
-				if err := prompt.CopyToClipboard(selectedPrompt.Content); err != nil {
+				content, err := prompt.ExpandPrompt(m.prompts, selectedPrompt.Content, m.config)
+				if err != nil {
 					m.err = err
 					return m, nil
 				}
+				if m.config.WithAttachments {
+					if m.attachments, err = prompt.Attachments(m.config, m.prompts, selectedPrompt); err != nil {
+						m.err = err
+						return m, nil
+					}
+				}
+				hookPrompt := selectedPrompt
+				hookPrompt.Content = content
+				copied, err := prompt.TransformCopy(m.config, hookPrompt)
+				if err != nil {
+					m.err = err
+					return m, nil
+				}
+				hookPrompt.Content = copied
+				if err := prompt.RunHook(m.config, prompt.HookPreCopy, hookPrompt); err != nil {
+					m.err = err
+					return m, nil
+				}
+				if err := copyToClipboardFunc(copied); err != nil {
+					m.err = err
+					return m, nil
+				}
+				_ = prompt.RunHook(m.config, prompt.HookPostCopy, hookPrompt)
+				_ = history.Record(m.config, history.ActionCopy, selectedPrompt.Section, selectedPrompt.Content)
+				if msg.String() == "alt+enter" || m.config.TypeOnSelect {
+					m.typeText = content
+				}
 				return m, tea.Quit
 			}
 
@@ -106,41 +196,203 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.cursor++
 			}
 
+		case "ctrl+x":
+			if len(m.filteredResults) > 0 && m.cursor < len(m.filteredResults) {
+				selectedPrompt := m.filteredResults[m.cursor]
+				if err := archivePromptFunc(m.config, selectedPrompt); err != nil {
+					m.err = err
+					return m, nil
+				}
+				m.removeFromPool(selectedPrompt)
+				m.filterResults()
+				if m.cursor >= len(m.filteredResults) && m.cursor > 0 {
+					m.cursor = len(m.filteredResults) - 1
+				}
+				m.status = "Archived prompt to section '" + m.config.ArchiveSection + "'"
+			}
+
+		case "o":
+			if len(m.filteredResults) > 0 && m.cursor < len(m.filteredResults) {
+				session, err := editPromptFunc(m.config, m.filteredResults[m.cursor])
+				if errors.Is(err, prompt.ErrNotEditable) || errors.Is(err, prompt.ErrReadOnly) {
+					m.status = err.Error()
+					return m, nil
+				}
+				if err != nil {
+					m.err = err
+					return m, nil
+				}
+				return m, tea.ExecProcess(session.Cmd, func(err error) tea.Msg {
+					if err == nil {
+						err = session.Finish()
+					}
+					return editorFinishedMsg{err: err}
+				})
+			}
+
+		case "ctrl+t":
+			m.titlesOnly = !m.titlesOnly
+			m.filterResults()
+			m.cursor = 0
+
+		case "ctrl+o":
+			m.sortOrder = prompt.NextSortOrder(m.sortOrder)
+			m.filterResults()
+			m.cursor = 0
+
+		case "tab":
+			if m.hasNamespaces() {
+				m.namespace = nextNamespace(m.namespace)
+				m.filterResults()
+				m.cursor = 0
+			}
+
 		default:
+			query := m.textInput.Value()
 			m.textInput, cmd = m.textInput.Update(msg)
-			m.filterResults()
-			if m.cursor >= len(m.filteredResults) {
-				m.cursor = len(m.filteredResults) - 1
+			if m.textInput.Value() == query {
+				break
 			}
-			if m.cursor < 0 {
-				m.cursor = 0
+			if len(m.searchPool) < debouncePoolSize {
+				m.refilter()
+				break
 			}
+			m.filterSeq++
+			m.searching = true
+			seq := m.filterSeq
+			cmd = tea.Batch(cmd, tea.Tick(debounceDelay, func(time.Time) tea.Msg {
+				return filterMsg{seq: seq}
+			}))
+		}
+
+	case editorFinishedMsg:
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+		if err := m.reloadPrompts(); err != nil {
+			m.err = fmt.Errorf("prompt edited, but %w", err)
+			return m, nil
+		}
+		m.status = "Reloaded prompts after editing"
+
+	case filterMsg:
+		// Filters scheduled by earlier keystrokes are superseded by the last one
+		if m.searching && msg.seq == m.filterSeq {
+			m.refilter()
 		}
 
 	case tea.WindowSizeMsg:
-		// Handle window resize if needed
+		m.width = msg.Width
 	}
 
 	return m, cmd
 }
 
+// refilter filters the results for the current query and keeps the cursor on them.
+func (m *model) refilter() {
+	m.searching = false
+	m.filterResults()
+	if m.cursor >= len(m.filteredResults) {
+		m.cursor = len(m.filteredResults) - 1
+	}
+	if m.cursor < 0 {
+		m.cursor = 0
+	}
+}
+
 func (m *model) filterResults() {
+	pool := m.namespacePool()
 	query := m.textInput.Value()
+	m.suggestion = ""
 	if query == "" {
-		m.filteredResults = m.searchPool
+		m.filteredResults = m.sorted(pool)
 		return
 	}
 
-	// Prepare data for fuzzy search
-	searchData := make([]string, len(m.searchPool))
-	for i, p := range m.searchPool {
-		searchData[i] = p.Content
+	results := prompt.FilterPrompts(m.prompts, pool, query, m.titlesOnly)
+	m.filteredResults = m.sorted(results)
+	if len(results) == 0 {
+		m.suggestion = prompt.DidYouMean(m.prompts, query)
+	}
+}
+
+// sorted returns results in the selected sort order, loading when prompts were
+// added the first time they are sorted by recency.
+func (m *model) sorted(results []prompt.Prompt) []prompt.Prompt {
+	if m.sortOrder == "" || m.sortOrder == prompt.SortRelevance {
+		return results
+	}
+	if m.sortOrder == prompt.SortRecent && m.added == nil {
+		added, err := prompt.AddedTimes(m.config)
+		if err != nil {
+			m.status = "Failed to load when prompts were added: " + err.Error()
+		}
+		m.added = added
+	}
+	return prompt.SortPrompts(results, m.sortOrder, m.added)
+}
+
+// reloadPrompts loads the prompts again after they were changed, and searches them
+// for the current query.
+func (m *model) reloadPrompts() error {
+	data, err := loadPromptsFunc(m.config)
+	if err != nil {
+		return fmt.Errorf("reloading prompts failed: %w", err)
+	}
+	m.prompts = data
+	m.searchPool = generateSearchPoolFromSections(data)
+	if m.config.DedupeResults {
+		m.searchPool = prompt.MergeDuplicates(m.searchPool)
+	}
+	m.filterResults()
+	m.cursor = 0
+	return nil
+}
+
+// removeFromPool drops the first prompt equal to p from the search pool.
+func (m *model) removeFromPool(p prompt.Prompt) {
+	for i, candidate := range m.searchPool {
+		if candidate == p {
+			m.searchPool = append(m.searchPool[:i:i], m.searchPool[i+1:]...)
+			return
+		}
+	}
+}
+
+// namespacePool returns the search pool restricted to the selected namespace.
+func (m *model) namespacePool() []prompt.Prompt {
+	if m.namespace == "" {
+		return m.searchPool
+	}
+	var pool []prompt.Prompt
+	for _, p := range m.searchPool {
+		if prompt.InNamespace(p.Namespace, m.namespace) {
+			pool = append(pool, p)
+		}
+	}
+	return pool
+}
+
+// hasNamespaces reports whether prompts from more than one library are loaded.
+func (m *model) hasNamespaces() bool {
+	for _, p := range m.searchPool {
+		if p.Namespace != "" {
+			return true
+		}
 	}
+	return false
+}
 
-	matches := fuzzy.RankFindNormalizedFold(query, searchData)
-	m.filteredResults = make([]prompt.Prompt, len(matches))
-	for i, match := range matches {
-		m.filteredResults[i] = m.searchPool[match.OriginalIndex]
+// nextNamespace cycles the namespace filter: all → mine → team → all.
+func nextNamespace(current string) string {
+	switch current {
+	case "":
+		return prompt.NamespacePersonal
+	case prompt.NamespacePersonal:
+		return prompt.NamespaceTeam
+	default:
+		return ""
 	}
 }
 
@@ -152,19 +404,55 @@ func (m model) View() string {
 	var b strings.Builder
 
 	// Title
-	b.WriteString(titleStyle.Render("Where's My Prompt?"))
+	b.WriteString(m.styles.title.Render("Where's My Prompt?"))
+	b.WriteString(" " + m.styles.help.Render(m.summary()))
 	b.WriteString("\n\n")
 
+	if m.status != "" {
+		b.WriteString(m.styles.help.Render(m.status))
+		b.WriteString("\n\n")
+	}
+
+	if m.adding {
+		b.WriteString(m.form.view())
+		return b.String()
+	}
+
+	// Namespace filter
+	if m.hasNamespaces() {
+		ns := m.namespace
+		if ns == "" {
+			ns = "all"
+		}
+		b.WriteString(fmt.Sprintf("Library: %s\n", ns))
+	}
+
 	// Search input
-	b.WriteString("Search: ")
+	if m.titlesOnly {
+		b.WriteString("Search (titles only): ")
+	} else {
+		b.WriteString("Search: ")
+	}
 	b.WriteString(m.textInput.View())
+	if m.searching {
+		b.WriteString(m.styles.help.Render(" searching…"))
+	}
 	b.WriteString("\n\n")
 
 	// Results
 	if len(m.filteredResults) == 0 {
 		b.WriteString("No prompts found.\n")
+		if m.suggestion != "" {
+			b.WriteString(m.suggestion + "\n")
+		}
+		b.WriteString(m.styles.help.Render("Press enter to add it as a new prompt."))
+		b.WriteString("\n")
 	} else {
-		b.WriteString(fmt.Sprintf("Found %d prompt(s):\n\n", len(m.filteredResults)))
+		if m.sortOrder != "" && m.sortOrder != prompt.SortRelevance {
+			b.WriteString(fmt.Sprintf("Found %d prompt(s), sorted by %s:\n\n", len(m.filteredResults), m.sortOrder))
+		} else {
+			b.WriteString(fmt.Sprintf("Found %d prompt(s):\n\n", len(m.filteredResults)))
+		}
 
 		// Show first few results
 		maxDisplay := 5
@@ -179,26 +467,50 @@ func (m model) View() string {
 				cursor = "▶"
 			}
 
-			title := prompt.Section
-			if m.cursor == i {
-				title = selectedStyle.Render(title)
+			badge := ""
+			if prompt.Namespace != "" {
+				badge = m.styles.help.Render(fmt.Sprintf("[%s] ", prompt.Namespace))
+			}
+			if icon := sectionIcon(m.config, strings.Split(prompt.Title, " > "), prompt.Icon); icon != "" {
+				badge += icon + " "
 			}
 
+			// Fit the line to the terminal, dropping the section before cutting the title
+			room := 0
+			if m.width > 0 {
+				room = max(m.width-lipgloss.Width(cursor+" "+badge), 1)
+			}
+			title := prompt.Section
 			section := ""
 			if prompt.Section != "" {
 				section = fmt.Sprintf(" [%s]", prompt.Section)
 			}
+			if room > 0 && lipgloss.Width(title+section) > room {
+				section = ""
+				title = fitWidth(title, room)
+			} else if section != "" {
+				section = fmt.Sprintf(" [%s]", isolateBidi(prompt.Section))
+			}
+			title = isolateBidi(title)
+			if m.cursor == i {
+				title = m.styles.selected.Render(title)
+			}
 
-			b.WriteString(fmt.Sprintf("%s %s%s\n", cursor, title, section))
+			b.WriteString(fmt.Sprintf("%s %s%s%s\n", cursor, badge, title, section))
 
 			// Show preview of content for selected item
 			if m.cursor == i {
-				preview := prompt.Content
-				if len(preview) > 100 {
-					preview = preview[:100] + "..."
+				// The border and padding of the preview take 6 columns
+				lineWidth := 0
+				if m.width > 0 {
+					lineWidth = max(m.width-6, 1)
 				}
-				b.WriteString(promptStyle.Render(preview))
+				b.WriteString(m.styles.prompt.Render(previewText(prompt.Content, lineWidth)))
 				b.WriteString("\n")
+				if m.config.ShowScores {
+					b.WriteString(m.styles.help.Render(fmt.Sprintf("Quality score: %d/100", qualityScore(prompt.Content))))
+					b.WriteString("\n")
+				}
 			}
 		}
 
@@ -209,11 +521,86 @@ func (m model) View() string {
 
 	// Help
 	b.WriteString("\n")
-	b.WriteString(helpStyle.Render("↑/k up • ↓/j down • enter select & copy • ctrl+c/esc quit"))
+	help := "↑/k up • ↓/j down • enter select & copy • alt+enter copy & type • o open in editor • ctrl+t titles only • ctrl+o sort • ctrl+x archive • ctrl+c/esc quit"
+	if m.hasNamespaces() {
+		help = "↑/k up • ↓/j down • tab switch library • enter select & copy • alt+enter copy & type • o open in editor • ctrl+t titles only • ctrl+o sort • ctrl+x archive • ctrl+c/esc quit"
+	}
+	b.WriteString(m.styles.help.Render(help))
 
 	return b.String()
 }
 
+// summary describes the loaded library for the title bar, such as
+// "1,245 prompts · 18 sections · source: prompts.md (modified 2h ago)".
+func (m model) summary() string {
+	sections := make(map[[2]string]bool)
+	for _, p := range m.searchPool {
+		sections[[2]string{p.Namespace, p.Title}] = true
+	}
+	parts := []string{
+		plural(len(m.searchPool), "prompt"),
+		plural(len(sections), "section"),
+	}
+	if m.prompts != nil && m.prompts.Source != "" {
+		source := "source: " + m.prompts.Source
+		if !m.prompts.Modified.IsZero() {
+			source += " (modified " + formatAge(summaryNow().Sub(m.prompts.Modified)) + ")"
+		}
+		parts = append(parts, source)
+	}
+	return strings.Join(parts, " · ")
+}
+
+// plural formats n with thousands separators followed by noun, adding an "s"
+// unless n is 1.
+func plural(n int, noun string) string {
+	digits := strconv.Itoa(n)
+	var b strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(d)
+	}
+	if n != 1 {
+		noun += "s"
+	}
+	return b.String() + " " + noun
+}
+
+// formatAge formats how long ago something happened, such as "2h ago".
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	}
+}
+
+// sectionIcon returns the icon shown for a section: the marker parsed from its
+// headings, or else the SECTION_ICONS entry of its deepest heading having one.
+func sectionIcon(conf config.Config, headings []string, marker string) string {
+	if marker != "" {
+		return marker
+	}
+	for i := len(headings) - 1; i >= 0; i-- {
+		if icon := conf.SectionIcons[headings[i]]; icon != "" {
+			return icon
+		}
+	}
+	return ""
+}
+
+// qualityScore returns the heuristic quality score of a prompt's content.
+func qualityScore(content string) int {
+	return prompt.ScorePrompt(content).Total
+}
+
 // Helper to flatten PromptData.Sections into []Prompt
 func generateSearchPoolFromSections(data *prompt.PromptData) []prompt.Prompt {
 	var pool []prompt.Prompt
@@ -225,8 +612,11 @@ func generateSearchPoolFromSections(data *prompt.PromptData) []prompt.Prompt {
 		for _, line := range sec.Lines {
 			if strings.TrimSpace(line) != "" {
 				pool = append(pool, prompt.Prompt{
-					Content: line,
-					Section: sectionTitle,
+					Content:   line,
+					Section:   sectionTitle,
+					Namespace: sec.Namespace,
+					Title:     strings.Join(sec.Headings, " > "),
+					Icon:      sec.Icon,
 				})
 			}
 		}
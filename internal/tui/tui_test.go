@@ -2,8 +2,10 @@ package tui
 
 import (
 	"fmt"
+	"os/exec"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
@@ -203,6 +205,21 @@ func TestModel_FilterResults(t *testing.T) {
 			query:         "CODE",
 			expectedCount: 3, // Should find same as lowercase 'code'
 		},
+		{
+			name:          "excluded term",
+			query:         "code -review",
+			expectedCount: 2, // Drops the review prompt
+		},
+		{
+			name:          "section operator",
+			query:         "section:review code",
+			expectedCount: 1,
+		},
+		{
+			name:          "quoted phrase",
+			query:         `"unit tests"`,
+			expectedCount: 1,
+		},
 	}
 
 	for _, tt := range tests {
@@ -411,6 +428,254 @@ func TestModel_View_ContentPreview(t *testing.T) {
 	}
 }
 
+func TestModel_View_QualityScore(t *testing.T) {
+	searchPool := generateSearchPoolFromSections(mockPrompts)
+	m := model{
+		textInput:       textinput.New(),
+		prompts:         mockPrompts,
+		filteredResults: searchPool,
+		searchPool:      searchPool,
+	}
+
+	if strings.Contains(m.View(), "Quality score:") {
+		t.Error("quality score should be hidden by default")
+	}
+
+	m.config = config.Config{ShowScores: true}
+	expected := fmt.Sprintf("Quality score: %d/100", qualityScore(searchPool[0].Content))
+	if !strings.Contains(m.View(), expected) {
+		t.Errorf("expected View() to contain %q", expected)
+	}
+}
+
+func TestModel_NamespaceToggle(t *testing.T) {
+	data := &prompt.PromptData{
+		Sections: []prompt.Section{
+			{Headings: []string{"golang"}, Lines: []string{"Personal prompt"}, Namespace: prompt.NamespacePersonal},
+			{Headings: []string{"golang"}, Lines: []string{"Team prompt"}, Namespace: prompt.NamespaceTeam},
+		},
+	}
+	searchPool := generateSearchPoolFromSections(data)
+	m := model{
+		textInput:       textinput.New(),
+		prompts:         data,
+		searchPool:      searchPool,
+		filteredResults: searchPool,
+	}
+
+	if !strings.Contains(m.View(), "[team]") || !strings.Contains(m.View(), "Library: all") {
+		t.Error("expected namespace badge and library indicator in view")
+	}
+
+	expected := []struct {
+		namespace string
+		count     int
+	}{
+		{prompt.NamespacePersonal, 1},
+		{prompt.NamespaceTeam, 1},
+		{"", 2},
+	}
+	for _, e := range expected {
+		updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyTab})
+		m = updated.(model)
+		if m.namespace != e.namespace || len(m.filteredResults) != e.count {
+			t.Errorf("after tab: namespace=%q results=%d, expected %q and %d", m.namespace, len(m.filteredResults), e.namespace, e.count)
+		}
+	}
+}
+
+func TestModel_Archive(t *testing.T) {
+	var archived []prompt.Prompt
+	originalArchive := archivePromptFunc
+	defer func() { archivePromptFunc = originalArchive }()
+	archivePromptFunc = func(_ config.Config, p prompt.Prompt) error {
+		archived = append(archived, p)
+		return nil
+	}
+
+	searchPool := generateSearchPoolFromSections(mockPrompts)
+	m := model{
+		textInput:       textinput.New(),
+		prompts:         mockPrompts,
+		searchPool:      searchPool,
+		filteredResults: searchPool,
+		config:          config.Config{ArchiveSection: "Archive"},
+	}
+	first := searchPool[0]
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyCtrlX})
+	m = updated.(model)
+
+	if len(archived) != 1 || archived[0] != first {
+		t.Fatalf("expected selected prompt to be archived, got %+v", archived)
+	}
+	if len(m.searchPool) != len(searchPool)-1 || len(m.filteredResults) != len(searchPool)-1 {
+		t.Errorf("expected archived prompt to be removed from results")
+	}
+	if !strings.Contains(m.View(), "Archived prompt to section 'Archive'") {
+		t.Error("expected archive status in view")
+	}
+}
+
+func TestModel_TitlesOnlyToggle(t *testing.T) {
+	searchPool := generateSearchPoolFromSections(mockPrompts)
+	ti := textinput.New()
+	ti.SetValue("testing")
+	m := model{
+		textInput:       ti,
+		prompts:         mockPrompts,
+		searchPool:      searchPool,
+		filteredResults: searchPool,
+		config:          mockConfig,
+	}
+	m.filterResults()
+	if len(m.filteredResults) != 0 {
+		t.Errorf("expected no full-text matches for %q, got %d", "testing", len(m.filteredResults))
+	}
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyCtrlT})
+	m = updated.(model)
+	if !m.titlesOnly {
+		t.Fatal("expected ctrl+t to enable title-only search")
+	}
+	if len(m.filteredResults) != 1 || m.filteredResults[0].Section != "testing" {
+		t.Errorf("expected the prompt under the testing heading, got %+v", m.filteredResults)
+	}
+	if !strings.Contains(m.View(), "Search (titles only)") {
+		t.Error("expected title-only indicator in view")
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlT})
+	m = updated.(model)
+	if m.titlesOnly || len(m.filteredResults) != 0 {
+		t.Error("expected ctrl+t to switch back to full-text search")
+	}
+}
+
+func TestModel_SortToggle(t *testing.T) {
+	searchPool := []prompt.Prompt{
+		{Content: "write pytest tests", Section: "Python"},
+		{Content: "Add docs", Section: "Python"},
+		{Content: "benchmark it", Section: "Golang"},
+	}
+	m := model{
+		textInput:       textinput.New(),
+		prompts:         mockPrompts,
+		searchPool:      searchPool,
+		filteredResults: searchPool,
+		config:          mockConfig,
+	}
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyCtrlO})
+	m = updated.(model)
+	if m.sortOrder != prompt.SortAlpha {
+		t.Fatalf("expected ctrl+o to sort alphabetically, got %q", m.sortOrder)
+	}
+	if m.filteredResults[0].Content != "Add docs" || m.filteredResults[2].Content != "write pytest tests" {
+		t.Errorf("expected results sorted alphabetically, got %+v", m.filteredResults)
+	}
+	if !strings.Contains(m.View(), "sorted by alpha") {
+		t.Error("expected sort order in view")
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlO})
+	m = updated.(model)
+	if m.sortOrder != prompt.SortSection || m.filteredResults[0].Content != "benchmark it" {
+		t.Errorf("expected results sorted by section, got %q: %+v", m.sortOrder, m.filteredResults)
+	}
+	if searchPool[0].Content != "write pytest tests" {
+		t.Error("expected sorting to leave the search pool untouched")
+	}
+}
+
+func TestModel_Summary(t *testing.T) {
+	original := summaryNow
+	defer func() { summaryNow = original }()
+	modified := time.Date(2026, 3, 14, 9, 0, 0, 0, time.UTC)
+	summaryNow = func() time.Time { return modified.Add(2*time.Hour + 10*time.Minute) }
+
+	pool := make([]prompt.Prompt, 1245)
+	for i := range pool {
+		pool[i] = prompt.Prompt{Content: fmt.Sprint("prompt ", i), Title: fmt.Sprint("Prompts > Section ", i%18)}
+	}
+
+	tests := []struct {
+		name     string
+		pool     []prompt.Prompt
+		data     *prompt.PromptData
+		expected string
+	}{
+		{
+			name:     "file source",
+			pool:     pool,
+			data:     &prompt.PromptData{Source: "prompts.md", Modified: modified},
+			expected: "1,245 prompts · 18 sections · source: prompts.md (modified 2h ago)",
+		},
+		{
+			name:     "unknown modification time",
+			pool:     pool[:1],
+			data:     &prompt.PromptData{Source: "prompts (Simplenote)"},
+			expected: "1 prompt · 1 section · source: prompts (Simplenote)",
+		},
+		{
+			name:     "unknown source",
+			data:     &prompt.PromptData{},
+			expected: "0 prompts · 0 sections",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := model{textInput: textinput.New(), prompts: tt.data, searchPool: tt.pool, config: mockConfig}
+			if got := m.summary(); got != tt.expected {
+				t.Errorf("summary() = %q, want %q", got, tt.expected)
+			}
+			if !strings.Contains(m.View(), tt.expected) {
+				t.Errorf("expected %q in the title bar", tt.expected)
+			}
+		})
+	}
+}
+
+func TestModel_CopyAndType(t *testing.T) {
+	originalCopy := copyToClipboardFunc
+	defer func() { copyToClipboardFunc = originalCopy }()
+	copyToClipboardFunc = func(string) error { return nil }
+
+	tests := []struct {
+		name     string
+		key      tea.KeyMsg
+		config   config.Config
+		expected bool
+	}{
+		{name: "enter only copies", key: tea.KeyMsg{Type: tea.KeyEnter}, expected: false},
+		{name: "alt+enter copies and types", key: tea.KeyMsg{Type: tea.KeyEnter, Alt: true}, expected: true},
+		{name: "enter types with TypeOnSelect", key: tea.KeyMsg{Type: tea.KeyEnter}, config: config.Config{TypeOnSelect: true}, expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			searchPool := generateSearchPoolFromSections(mockPrompts)
+			m := model{
+				textInput:       textinput.New(),
+				prompts:         mockPrompts,
+				searchPool:      searchPool,
+				filteredResults: searchPool,
+				config:          tt.config,
+			}
+
+			updated, cmd := m.Update(tt.key)
+			m = updated.(model)
+			if cmd == nil {
+				t.Error("expected selection to quit the TUI")
+			}
+			if typed := m.typeText == searchPool[0].Content; typed != tt.expected {
+				t.Errorf("typeText = %q, expected typing: %v", m.typeText, tt.expected)
+			}
+		})
+	}
+}
+
 func TestModel_View_HelpText(t *testing.T) {
 	ti := textinput.New()
 	searchPool := generateSearchPoolFromSections(mockPrompts)
@@ -425,7 +690,7 @@ func TestModel_View_HelpText(t *testing.T) {
 
 	view := m.View()
 
-	expectedHelp := "↑/k up • ↓/j down • enter select & copy • ctrl+c/esc quit"
+	expectedHelp := "↑/k up • ↓/j down • enter select & copy • alt+enter copy & type • o open in editor • ctrl+t titles only • ctrl+o sort • ctrl+x archive • ctrl+c/esc quit"
 	if !strings.Contains(view, expectedHelp) {
 		t.Errorf("expected help text '%s' in view, but didn't find it", expectedHelp)
 	}
@@ -486,3 +751,213 @@ func BenchmarkModel_View(b *testing.B) {
 		m.View()
 	}
 }
+
+func TestNewModel_DefaultQuery(t *testing.T) {
+	m := newModel(mockPrompts, config.Config{DefaultQuery: "comprehensive"})
+	if got := m.textInput.Value(); got != "comprehensive" {
+		t.Errorf("expected the search box to hold the default query, got %q", got)
+	}
+	if m.textInput.Position() != len("comprehensive") {
+		t.Errorf("expected the cursor at the end of the query, got %d", m.textInput.Position())
+	}
+	if len(m.filteredResults) != 1 || m.filteredResults[0].Section != "testing" {
+		t.Errorf("expected results filtered by the default query, got %+v", m.filteredResults)
+	}
+
+	m = newModel(mockPrompts, mockConfig)
+	if m.textInput.Value() != "" || len(m.filteredResults) != len(m.searchPool) {
+		t.Errorf("expected an empty search box listing every prompt, got %q with %d results", m.textInput.Value(), len(m.filteredResults))
+	}
+}
+
+func TestNewModel_DedupeResults(t *testing.T) {
+	data := &prompt.PromptData{
+		Sections: []prompt.Section{
+			{Headings: []string{"golang"}, Lines: []string{"Shared prompt", "Personal prompt"}, Namespace: prompt.NamespacePersonal},
+			{Headings: []string{"golang"}, Lines: []string{"shared  prompt"}, Namespace: prompt.NamespaceTeam},
+		},
+	}
+
+	m := newModel(data, config.Config{})
+	if len(m.searchPool) != 3 {
+		t.Errorf("expected duplicates to be kept by default, got %d prompts", len(m.searchPool))
+	}
+
+	m = newModel(data, config.Config{DedupeResults: true})
+	if len(m.searchPool) != 2 {
+		t.Fatalf("expected the shared prompt to be merged, got %d prompts", len(m.searchPool))
+	}
+	if !strings.Contains(m.View(), "[mine+team]") {
+		t.Error("expected a merged namespace badge in view")
+	}
+
+	// The merged prompt belongs to both libraries
+	for _, e := range []struct {
+		namespace string
+		count     int
+	}{{prompt.NamespacePersonal, 2}, {prompt.NamespaceTeam, 1}} {
+		updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyTab})
+		m = updated.(model)
+		if m.namespace != e.namespace || len(m.filteredResults) != e.count {
+			t.Errorf("after tab: namespace=%q results=%d, expected %q and %d", m.namespace, len(m.filteredResults), e.namespace, e.count)
+		}
+	}
+}
+
+func TestModel_CopyResolvesRefs(t *testing.T) {
+	originalCopy := copyToClipboardFunc
+	defer func() { copyToClipboardFunc = originalCopy }()
+	var copied string
+	copyToClipboardFunc = func(text string) error {
+		copied = text
+		return nil
+	}
+
+	data := &prompt.PromptData{
+		Sections: []prompt.Section{
+			{Headings: []string{"Prompts", "Review"}, Lines: []string{"@ref(Persona) Review this code"}},
+			{Headings: []string{"Prompts", "Persona"}, Lines: []string{"You are a Go expert."}},
+		},
+	}
+	m := newModel(data, config.Config{TypeOnSelect: true})
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(model)
+	if expected := "You are a Go expert. Review this code"; copied != expected || m.typeText != expected {
+		t.Errorf("expected the reference inlined, copied %q and typing %q", copied, m.typeText)
+	}
+}
+
+func TestModel_View_SectionIcons(t *testing.T) {
+	data := &prompt.PromptData{
+		Sections: []prompt.Section{
+			{Headings: []string{"Prompts", "Golang"}, Lines: []string{"Review this Go code"}, Icon: "🐹"},
+			{Headings: []string{"Prompts", "Python", "Tests"}, Lines: []string{"Write pytest tests"}},
+			{Headings: []string{"Prompts", "Writing"}, Lines: []string{"Draft an email"}},
+		},
+	}
+	m := newModel(data, config.Config{SectionIcons: map[string]string{"Golang": "G", "Python": "🐍"}})
+
+	view := m.View()
+	for _, expected := range []string{"🐹 Golang", "🐍 Tests", " Writing"} {
+		if !strings.Contains(view, expected) {
+			t.Errorf("expected %q in view:\n%s", expected, view)
+		}
+	}
+	if strings.Contains(view, "G Golang") {
+		t.Error("expected the heading marker to take precedence over SECTION_ICONS")
+	}
+
+	form := newAddForm(data, m.config, "")
+	form.section = 1
+	if !strings.Contains(form.view(), "◀ 🐹 Golang ▶") {
+		t.Errorf("expected the section picker to show the icon, got:\n%s", form.view())
+	}
+	if form.selectedSection() != "Golang" {
+		t.Errorf("expected the section name without its icon, got %q", form.selectedSection())
+	}
+}
+
+func TestModel_View_Suggestion(t *testing.T) {
+	data := &prompt.PromptData{
+		Sections: []prompt.Section{
+			{Headings: []string{"Prompts", "Golang", "Code Review Checklist"}, Lines: []string{"Check error handling"}},
+		},
+	}
+	m := newModel(data, config.Config{})
+	m.textInput.SetValue("code reviw checklst")
+	m.filterResults()
+
+	if view := m.View(); !strings.Contains(view, "Did you mean: 'Code Review Checklist' in section Golang?") {
+		t.Errorf("expected a near-miss suggestion in view:\n%s", view)
+	}
+
+	m.textInput.SetValue("check")
+	m.filterResults()
+	if m.suggestion != "" {
+		t.Errorf("expected no suggestion once the query matches, got %q", m.suggestion)
+	}
+}
+
+func TestModel_Update_DebouncesLargePools(t *testing.T) {
+	lines := make([]string, debouncePoolSize)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("Prompt number %d", i)
+	}
+	lines[0] = "Review this Go code"
+	data := &prompt.PromptData{Sections: []prompt.Section{{Headings: []string{"Prompts", "Bulk"}, Lines: lines}}}
+	m := newModel(data, config.Config{})
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("g")})
+	m = updated.(model)
+	if !m.searching || cmd == nil {
+		t.Fatal("expected a keystroke on a large pool to schedule a debounced filter")
+	}
+	if len(m.filteredResults) != debouncePoolSize {
+		t.Errorf("expected results to stay unfiltered until the debounce fires, got %d", len(m.filteredResults))
+	}
+	if !strings.Contains(m.View(), "searching…") {
+		t.Error("expected a searching indicator while the filter is pending")
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("o")})
+	m = updated.(model)
+	updated, _ = m.Update(filterMsg{seq: m.filterSeq - 1})
+	m = updated.(model)
+	if !m.searching {
+		t.Error("expected a superseded filter to be ignored")
+	}
+
+	updated, _ = m.Update(filterMsg{seq: m.filterSeq})
+	m = updated.(model)
+	if m.searching || len(m.filteredResults) != 1 || m.filteredResults[0].Content != "Review this Go code" {
+		t.Errorf("expected the last filter to run, got searching=%v results=%d", m.searching, len(m.filteredResults))
+	}
+}
+
+func TestModel_Edit(t *testing.T) {
+	originalEdit, originalLoad := editPromptFunc, loadPromptsFunc
+	defer func() { editPromptFunc, loadPromptsFunc = originalEdit, originalLoad }()
+	var edited []prompt.Prompt
+	editPromptFunc = func(conf config.Config, p prompt.Prompt) (*prompt.EditSession, error) {
+		edited = append(edited, p)
+		if conf.ReadOnly {
+			return nil, fmt.Errorf("%w: unset READ_ONLY to modify prompts", prompt.ErrReadOnly)
+		}
+		if p.Namespace == prompt.NamespaceTeam {
+			return nil, fmt.Errorf("%w: team prompt", prompt.ErrNotEditable)
+		}
+		return &prompt.EditSession{Cmd: exec.Command("true")}, nil
+	}
+	reloaded := &prompt.PromptData{Sections: []prompt.Section{{Headings: []string{"edited"}, Lines: []string{"Edited prompt"}}}}
+	loadPromptsFunc = func(config.Config) (*prompt.PromptData, error) { return reloaded, nil }
+
+	searchPool := generateSearchPoolFromSections(mockPrompts)
+	m := model{textInput: textinput.New(), prompts: mockPrompts, searchPool: searchPool, filteredResults: searchPool, config: mockConfig}
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'o'}})
+	m = updated.(model)
+	if len(edited) != 1 || edited[0] != searchPool[0] || cmd == nil {
+		t.Fatalf("expected the selected prompt to be opened in the editor, got %+v", edited)
+	}
+
+	updated, _ = m.Update(editorFinishedMsg{})
+	m = updated.(model)
+	if len(m.searchPool) != 1 || m.searchPool[0].Content != "Edited prompt" {
+		t.Errorf("expected prompts to be reloaded after editing, got %+v", m.searchPool)
+	}
+
+	m.filteredResults = []prompt.Prompt{{Content: "Team prompt", Namespace: prompt.NamespaceTeam}}
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'o'}})
+	m = updated.(model)
+	if m.err != nil || !strings.Contains(m.status, "team prompt") {
+		t.Errorf("expected a status for a prompt that cannot be edited, got status %q, err %v", m.status, m.err)
+	}
+
+	m.config.ReadOnly = true
+	updated, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'o'}})
+	m = updated.(model)
+	if m.err != nil || cmd != nil || !strings.Contains(m.status, "READ_ONLY") {
+		t.Errorf("expected a status for a read-only source, got status %q, err %v", m.status, m.err)
+	}
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "Rewrite the golden files in testdata/e2e")
+
+// binary is the wheresmyprompt executable built for the end-to-end tests.
+var binary string
+
+// library is the prompt file each end-to-end test starts from.
+const library = `# Prompts
+
+## Golang
+
+### Code Review
+Review this Go code for best practices and potential bugs.
+
+### Unit Tests
+Write table-driven unit tests for this Go function.
+
+## Python
+
+### Unit Tests
+Write pytest tests for this Python function.
+`
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+
+	dir, err := os.MkdirTemp("", "wheresmyprompt-e2e-")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "failed to create build directory:", err)
+		os.Exit(1)
+	}
+	binary = filepath.Join(dir, "wheresmyprompt")
+	if runtime.GOOS == "windows" {
+		binary += ".exe"
+	}
+	if out, err := exec.Command("go", "build", "-o", binary, ".").CombinedOutput(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to build wheresmyprompt: %v\n%s", err, out)
+		os.Exit(1)
+	}
+
+	code := m.Run()
+	_ = os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// step is one invocation of the CLI; its stdout, stderr and exit code are compared
+// with testdata/e2e/<test>/<golden>.golden.
+type step struct {
+	golden string
+	args   []string
+}
+
+func TestCLI(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps []step
+		file  bool // Also compare the prompt file after the last step with file.golden
+	}{
+		{
+			name:  "one-shot",
+			steps: []step{{golden: "one-shot", args: []string{"-o", "code review"}}},
+		},
+		{
+			name: "search",
+			steps: []step{
+				{golden: "best", args: []string{"search", "--best", "code review"}},
+				{golden: "all-json", args: []string{"search", "--output", "json", "unit tests"}},
+			},
+		},
+		{
+			name: "section-filter",
+			steps: []step{
+				{golden: "in-section", args: []string{"search", "-s", "Python", "tests"}},
+				{golden: "other-section", args: []string{"search", "-s", "Python", "table-driven"}},
+				{golden: "list", args: []string{"list"}},
+			},
+		},
+		{
+			name: "write-then-find",
+			steps: []step{
+				{golden: "add", args: []string{"add", "Explain goroutine leaks in this code", "Golang"}},
+				{golden: "find", args: []string{"search", "--best", "goroutine leaks"}},
+				{golden: "recent", args: []string{"search", "--sort", "recent"}},
+				{golden: "alpha", args: []string{"search", "--sort", "alpha"}},
+			},
+			file: true,
+		},
+		{
+			name: "legacy-query",
+			steps: []step{
+				{golden: "all-json", args: []string{"--all", "review", "--output", "json"}},
+				{golden: "one-shot", args: []string{"-o", "review"}},
+				{golden: "several-words", args: []string{"review", "go", "code"}},
+				{golden: "subcommand", args: []string{"search", "--best", "review"}},
+			},
+		},
+		{
+			name: "exit-codes",
+			steps: []step{
+				{golden: "no-match", args: []string{"search", "kubernetes"}},
+				{golden: "near-miss", args: []string{"search", "--best", "kode reveiw"}},
+				{golden: "no-match-json", args: []string{"search", "--output", "json", "kubernetes"}},
+				{golden: "invalid-output", args: []string{"search", "--output", "yaml", "review"}},
+				{golden: "invalid-sort", args: []string{"search", "--sort", "newest", "review"}},
+				{golden: "missing-file", args: []string{"search", "--load", "missing.md", "review"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "prompts.md")
+			if err := os.WriteFile(path, []byte(library), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, s := range tt.steps {
+				stdout, stderr, code := runCLI(t, dir, path, s.args...)
+				got := fmt.Sprintf("$ wheresmyprompt %s\n%s--- stderr\n%s--- exit %d\n", commandLine(s.args), stdout, stderr, code)
+				checkGolden(t, filepath.Join(tt.name, s.golden), strings.ReplaceAll(got, dir, "$DIR"))
+			}
+			if tt.file {
+				data, err := os.ReadFile(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				checkGolden(t, filepath.Join(tt.name, "file"), string(data))
+			}
+		})
+	}
+}
+
+// runCLI runs the built binary in dir with args, reading prompts from path and
+// keeping its data in dir, and returns its output and exit code. The environment
+// is reduced to what the CLI needs so the developer's settings don't leak in.
+func runCLI(t *testing.T, dir, path string, args ...string) (string, string, int) {
+	t.Helper()
+	cmd := exec.Command(binary, args...) // #nosec G204
+	cmd.Dir = dir
+	cmd.Env = []string{
+		"PATH=" + os.Getenv("PATH"),
+		"HOME=" + dir,
+		"FILEPATH=" + path,
+		"DATA_DIR=" + filepath.Join(dir, "data"),
+		"AUTO_SECTION=false",
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		t.Fatalf("failed to run wheresmyprompt %v: %v", args, err)
+	}
+	return stdout.String(), stderr.String(), cmd.ProcessState.ExitCode()
+}
+
+// commandLine joins args as typed in a shell, quoting those containing spaces.
+func commandLine(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = arg
+		if strings.Contains(arg, " ") {
+			quoted[i] = strconv.Quote(arg)
+		}
+	}
+	return strings.Join(quoted, " ")
+}
+
+// checkGolden compares got with testdata/e2e/<name>.golden, rewriting the file
+// instead when the tests run with -update.
+func checkGolden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", "e2e", name+".golden")
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path) // #nosec G304
+	if err != nil {
+		t.Fatalf("failed to read golden file (run go test -update to create it): %v", err)
+	}
+	if got != string(want) {
+		t.Errorf("%s mismatch:\n--- got\n%s--- want\n%s", path, got, want)
+	}
+}
@@ -30,6 +30,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/caarlos0/env/v11"
 	"github.com/joho/godotenv"
@@ -59,21 +60,476 @@ type Config struct {
 	// Defaults to "LLM Prompts" if not set.
 	SNNote string `env:"SN_NOTE" envDefault:"LLM Prompts"`
 
-	// SNCredential specifies the 1Password item name for Simplenote credentials.
+	// SNCredential specifies the secret provider item holding the Simplenote credentials,
+	// such as a 1Password item name or a pass entry path.
 	// It is loaded from the SN_CREDENTIAL environment variable.
 	SNCredential string `env:"SN_CREDENTIAL"`
 
-	// SNUsername specifies the Simplenote username or 1Password field name.
+	// SNUsername specifies the Simplenote username, or the secret provider field holding it.
 	// It is loaded from the SN_USERNAME environment variable.
 	SNUsername string `env:"SN_USERNAME"`
 
-	// SNPassword specifies the Simplenote password or 1Password field name.
+	// SNPassword specifies the Simplenote password, or the secret provider field holding it.
 	// It is loaded from the SN_PASSWORD environment variable.
 	SNPassword string `env:"SN_PASSWORD"`
 
+	// SecretProvider selects where SN_USERNAME and SN_PASSWORD are fetched from:
+	// 1password (op), bitwarden (bw), pass (password-store) or env, which reads the
+	// environment variables they name. It is loaded from the SECRET_PROVIDER
+	// environment variable. Defaults to 1password when SN_CREDENTIAL is set.
+	SecretProvider string `env:"SECRET_PROVIDER"`
+
+	// SNLocalDB reads the note from sncli's local database instead of running
+	// "sncli dump" on every search, fetching it from Simplenote only when the local
+	// copy is missing or stale. Writes always fetch the latest note.
+	// It is loaded from the SN_LOCAL_DB environment variable. Defaults to false.
+	SNLocalDB bool `env:"SN_LOCAL_DB"`
+
+	// SNDBPath specifies sncli's local database directory (sncli's cfg_db_path).
+	// It is loaded from the SN_DB_PATH environment variable. Defaults to ~/.sncli if not set.
+	SNDBPath string `env:"SN_DB_PATH"`
+
+	// SNLocalMaxAge specifies how long after its last sync the local copy of the note
+	// is used with SN_LOCAL_DB. It is loaded from the SN_LOCAL_MAX_AGE environment
+	// variable. Defaults to 15m if not set; 0 always uses the local copy.
+	SNLocalMaxAge time.Duration `env:"SN_LOCAL_MAX_AGE" envDefault:"15m"`
+
 	// FilePath specifies the local file path for prompts (overrides Simplenote).
 	// It is loaded from the FILEPATH environment variable.
 	FilePath string `env:"FILEPATH"`
+
+	// RemoteWrite enables writing back to a FilePath that is an http(s) or s3:// URL,
+	// replacing the file only if it did not change since it was read.
+	// It is loaded from the REMOTE_WRITE environment variable.
+	RemoteWrite bool `env:"REMOTE_WRITE"`
+
+	// HTTPTimeout specifies how long a request to a network source, such as a URL,
+	// S3, Google Drive, Joplin, a prompt pack or a GitHub gist, may take in total,
+	// including its retries. It is loaded from the HTTP_TIMEOUT environment variable.
+	// Defaults to 30s if not set.
+	HTTPTimeout time.Duration `env:"HTTP_TIMEOUT" envDefault:"30s"`
+
+	// HTTPRetries specifies how often a request to a network source is retried after
+	// a connection error, or a 429 or 5xx status, backing off exponentially.
+	// It is loaded from the HTTP_RETRIES environment variable.
+	// Defaults to 3 if not set.
+	HTTPRetries int `env:"HTTP_RETRIES" envDefault:"3"`
+
+	// HTTPRateLimit specifies how many requests per second are sent to each host of a
+	// network source, 0 for no limit.
+	// It is loaded from the HTTP_RATE_LIMIT environment variable.
+	// Defaults to 5 if not set.
+	HTTPRateLimit float64 `env:"HTTP_RATE_LIMIT" envDefault:"5"`
+
+	// Source selects an external source plugin, the executable named
+	// "wheresmyprompt-source-<name>" on the PATH, to load and write prompts instead
+	// of Simplenote. FilePath takes precedence over it.
+	// It is loaded from the SOURCE environment variable.
+	Source string `env:"SOURCE"`
+
+	// AppleNote specifies the name of an Apple Notes note to load and write prompts
+	// with instead of Simplenote, on macOS. FilePath and Source take precedence over it.
+	// It is loaded from the APPLE_NOTE environment variable.
+	AppleNote string `env:"APPLE_NOTE"`
+
+	// JoplinNotebook specifies a Joplin notebook whose notes, one section each, are
+	// loaded and written through Joplin's local Data API instead of Simplenote.
+	// It is loaded from the JOPLIN_NOTEBOOK environment variable.
+	JoplinNotebook string `env:"JOPLIN_NOTEBOOK"`
+
+	// JoplinTag selects the Joplin notes with this tag instead of a notebook.
+	// It is loaded from the JOPLIN_TAG environment variable.
+	JoplinTag string `env:"JOPLIN_TAG"`
+
+	// JoplinToken specifies the authorization token of Joplin's Web Clipper service.
+	// It is loaded from the JOPLIN_TOKEN environment variable.
+	JoplinToken string `env:"JOPLIN_TOKEN"`
+
+	// JoplinPort specifies the port Joplin's Web Clipper service listens on.
+	// It is loaded from the JOPLIN_PORT environment variable.
+	// Defaults to 41184 if not set.
+	JoplinPort int `env:"JOPLIN_PORT" envDefault:"41184"`
+
+	// StandardNotesBackup specifies a decrypted Standard Notes backup file whose note
+	// StandardNotesNote holds the prompts, read and written instead of Simplenote.
+	// It is loaded from the STANDARD_NOTES_BACKUP environment variable.
+	StandardNotesBackup string `env:"STANDARD_NOTES_BACKUP"`
+
+	// StandardNotesNote specifies the title of the note in StandardNotesBackup.
+	// It is loaded from the STANDARD_NOTES_NOTE environment variable.
+	// Defaults to "LLM Prompts" if not set.
+	StandardNotesNote string `env:"STANDARD_NOTES_NOTE" envDefault:"LLM Prompts"`
+
+	// GoogleDoc specifies a Google Doc, or a Markdown file in Google Drive, to read
+	// prompts from, as a link or file ID. The source is read-only.
+	// It is loaded from the GOOGLE_DOC environment variable.
+	GoogleDoc string `env:"GOOGLE_DOC"`
+
+	// GoogleClientID specifies the OAuth client used to read GoogleDoc through the
+	// Drive API, signing in with the device flow. Without it, GoogleDoc must be
+	// shared with anyone holding the link.
+	// It is loaded from the GOOGLE_CLIENT_ID environment variable.
+	GoogleClientID string `env:"GOOGLE_CLIENT_ID"`
+
+	// GoogleClientSecret specifies the secret of GoogleClientID.
+	// It is loaded from the GOOGLE_CLIENT_SECRET environment variable.
+	GoogleClientSecret string `env:"GOOGLE_CLIENT_SECRET"`
+
+	// GoogleCacheMaxAge specifies how long a downloaded copy of GoogleDoc is used
+	// before it is downloaded again; older copies are still used when Google cannot
+	// be reached. 0 always downloads it.
+	// It is loaded from the GOOGLE_CACHE_MAX_AGE environment variable.
+	// Defaults to 15 minutes if not set.
+	GoogleCacheMaxAge time.Duration `env:"GOOGLE_CACHE_MAX_AGE" envDefault:"15m"`
+
+	// Offline reads network sources (URLs, S3, Google Drive and the Simplenote note)
+	// from their local caches, with a warning giving their age, instead of reaching
+	// the network, and refuses to write to them. Without it, the same happens after a
+	// request fails because the network cannot be reached.
+	// It is loaded from the OFFLINE environment variable, or set by --offline. Defaults to false.
+	Offline bool `env:"OFFLINE"`
+
+	// MaxLineSize specifies the longest line, in bytes, accepted when parsing a prompt library.
+	// It is loaded from the MAX_LINE_SIZE environment variable.
+	// Defaults to 10 MiB when not set or not positive.
+	MaxLineSize int `env:"MAX_LINE_SIZE"`
+
+	// JoinWrappedLines treats consecutive non-empty lines as one prompt, joined with
+	// spaces, for libraries whose prompts are hard-wrapped by an editor. List items
+	// and fenced code blocks still keep one prompt per line.
+	// It is loaded from the JOIN_WRAPPED_LINES environment variable. Defaults to false.
+	JoinWrappedLines bool `env:"JOIN_WRAPPED_LINES"`
+
+	// GroupListItems keeps a list and the line introducing it together as one prompt,
+	// so copying "Review this code for:" also copies the bullets below it. Disable it
+	// if you store one prompt per bullet. It is loaded from the GROUP_LIST_ITEMS
+	// environment variable. Defaults to true if not set.
+	GroupListItems bool `env:"GROUP_LIST_ITEMS" envDefault:"true"`
+
+	// LockTimeout specifies how long to wait for another process to release the
+	// lock on a local prompts file before giving up on a write.
+	// It is loaded from the LOCK_TIMEOUT environment variable.
+	// Defaults to 5s if not set.
+	LockTimeout time.Duration `env:"LOCK_TIMEOUT" envDefault:"5s"`
+
+	// TeamFilePath specifies a local Markdown file holding a shared team library,
+	// loaded alongside the personal library. Writes never go to the team library.
+	// It is loaded from the TEAM_FILEPATH environment variable.
+	TeamFilePath string `env:"TEAM_FILEPATH"`
+
+	// TeamSNNote specifies a Simplenote note holding a shared team library,
+	// used when TeamFilePath is not set.
+	// It is loaded from the TEAM_SN_NOTE environment variable.
+	TeamSNNote string `env:"TEAM_SN_NOTE"`
+
+	// OverrideSection specifies the section of the personal library holding local
+	// overrides of team prompts, one "### <team prompt title>" block each.
+	// It is loaded from the OVERRIDE_SECTION environment variable.
+	// Defaults to "Overrides" if not set.
+	OverrideSection string `env:"OVERRIDE_SECTION" envDefault:"Overrides"`
+
+	// WriteRoutes maps section names to the file or note prompts added to them are
+	// written to, such as "Golang=go-prompts.md,Writing=writing.md". Targets are
+	// files, relative to the FILEPATH directory, when FILEPATH is set and Simplenote
+	// notes otherwise. Searches read every target as well as the main source.
+	// It is loaded from the WRITE_ROUTES environment variable.
+	WriteRoutes map[string]string `env:"WRITE_ROUTES" envKeyValSeparator:"="`
+
+	// DedupeResults merges prompts with the same content (ignoring case and
+	// whitespace) found in both the personal and team libraries into a single
+	// search result badged with both namespaces, such as "mine+team".
+	// It is loaded from the DEDUPE_RESULTS environment variable.
+	DedupeResults bool `env:"DEDUPE_RESULTS"`
+
+	// ReadOnly disables every write path (such as adding prompts) so a shared,
+	// canonical prompt note cannot be modified accidentally.
+	// It is loaded from the READ_ONLY environment variable.
+	// Read-only mode is always enabled for URL sources.
+	ReadOnly bool `env:"READ_ONLY"`
+
+	// Staging routes newly written prompts into StagingSection for review
+	// instead of their target section, keeping shared libraries curated.
+	// It is loaded from the STAGING environment variable.
+	Staging bool `env:"STAGING"`
+
+	// StagingSection specifies the section staged prompts are written to.
+	// It is loaded from the STAGING_SECTION environment variable.
+	// Defaults to "Inbox" if not set.
+	StagingSection string `env:"STAGING_SECTION" envDefault:"Inbox"`
+
+	// ServeAddr specifies the address the serve command listens on.
+	// It is loaded from the SERVE_ADDR environment variable.
+	// Defaults to "127.0.0.1:8765" if not set.
+	ServeAddr string `env:"SERVE_ADDR" envDefault:"127.0.0.1:8765"`
+
+	// ServeToken specifies the bearer token POST /prompts of the serve command requires.
+	// It is loaded from the SERVE_TOKEN environment variable.
+	// Writes over HTTP are disabled if not set.
+	ServeToken string `env:"SERVE_TOKEN"`
+
+	// ReloadInterval specifies how often the serve command reloads the prompt source.
+	// It is loaded from the RELOAD_INTERVAL environment variable.
+	// Defaults to 1m if not set; 0 disables periodic reloads.
+	ReloadInterval time.Duration `env:"RELOAD_INTERVAL" envDefault:"1m"`
+
+	// AutoSection enables selecting the section from the primary language of the
+	// current directory when no section is given.
+	// It is loaded from the AUTO_SECTION environment variable.
+	// Defaults to true if not set.
+	AutoSection bool `env:"AUTO_SECTION" envDefault:"true"`
+
+	// LanguagesFile specifies a JSON file extending or overriding the built-in
+	// extension and shebang mappings used to auto-detect the section.
+	// It is loaded from the LANGUAGES_FILE environment variable.
+	LanguagesFile string `env:"LANGUAGES_FILE"`
+
+	// DetectMaxFileSize specifies the size in bytes above which files are skipped
+	// when detecting the primary language.
+	// It is loaded from the DETECT_MAX_FILE_SIZE environment variable.
+	// Defaults to the built-in limit of 1 MiB if not set; -1 disables the limit.
+	DetectMaxFileSize int64 `env:"DETECT_MAX_FILE_SIZE"`
+
+	// DataDir specifies the directory used for local state such as usage history.
+	// It is loaded from the DATA_DIR environment variable.
+	// Defaults to $XDG_DATA_HOME/wheresmyprompt (or ~/.local/share/wheresmyprompt) if not set.
+	DataDir string `env:"DATA_DIR"`
+
+	// IndexCache stores the parsed prompt sources in an index in DataDir, so sources
+	// that have not changed since the last run are not parsed again.
+	// It is loaded from the INDEX_CACHE environment variable.
+	IndexCache bool `env:"INDEX_CACHE"`
+
+	// BackupDir specifies where a snapshot of the Simplenote note is saved before
+	// every write, so the undo command can restore it. It is loaded from the
+	// BACKUP_DIR environment variable. Defaults to "backups" in DataDir if not set.
+	BackupDir string `env:"BACKUP_DIR"`
+
+	// PacksDir specifies where prompt packs installed with "pack install" are kept.
+	// Their prompts are searched alongside the library. It is loaded from the
+	// PACKS_DIR environment variable. Defaults to "packs" in DataDir if not set.
+	PacksDir string `env:"PACKS_DIR"`
+
+	// PackRegistry specifies the URL or file of a JSON index of curated prompt packs,
+	// so "pack install" can install them by name.
+	// It is loaded from the PACK_REGISTRY environment variable.
+	PackRegistry string `env:"PACK_REGISTRY"`
+
+	// LogFile specifies a file that log output is written to instead of stderr,
+	// keeping the TUI screen clean. Relative paths are placed in DataDir.
+	// It is loaded from the LOG_FILE environment variable.
+	// Defaults to "" (log to stderr) if not set.
+	LogFile string `env:"LOG_FILE"`
+
+	// LogMaxSize specifies the size in megabytes at which LogFile is rotated.
+	// It is loaded from the LOG_MAX_SIZE environment variable.
+	// Defaults to 10 if not set; 0 disables rotation.
+	LogMaxSize int `env:"LOG_MAX_SIZE" envDefault:"10"`
+
+	// LogMaxBackups specifies how many rotated log files are kept.
+	// It is loaded from the LOG_MAX_BACKUPS environment variable.
+	// Defaults to 3 if not set.
+	LogMaxBackups int `env:"LOG_MAX_BACKUPS" envDefault:"3"`
+
+	// Analytics enables recording of prompt usage to a local history file in DataDir
+	// for the report command. Nothing is ever sent over the network.
+	// It is loaded from the ANALYTICS environment variable.
+	Analytics bool `env:"ANALYTICS"`
+
+	// ShowScores displays the heuristic quality score of prompts in the TUI
+	// detail view and as a column in usage reports.
+	// It is loaded from the SHOW_SCORES environment variable.
+	ShowScores bool `env:"SHOW_SCORES"`
+
+	// LLMBaseURL specifies the base URL of an OpenAI-compatible API (e.g.,
+	// https://api.openai.com/v1 or a local llama.cpp server) used by opt-in
+	// LLM features. They are disabled when it is not set.
+	// It is loaded from the LLM_BASE_URL environment variable.
+	LLMBaseURL string `env:"LLM_BASE_URL"`
+
+	// LLMAPIKey specifies the API key sent to LLMBaseURL, if it requires one.
+	// It is loaded from the LLM_API_KEY environment variable.
+	LLMAPIKey string `env:"LLM_API_KEY"`
+
+	// LLMModel specifies the chat model used by LLM features.
+	// It is loaded from the LLM_MODEL environment variable.
+	// Defaults to "gpt-4o-mini" if not set.
+	LLMModel string `env:"LLM_MODEL" envDefault:"gpt-4o-mini"`
+
+	// LLMEmbeddingModel specifies the embedding model used by semantic search.
+	// It is loaded from the LLM_EMBEDDING_MODEL environment variable.
+	// Defaults to "text-embedding-3-small" if not set.
+	LLMEmbeddingModel string `env:"LLM_EMBEDDING_MODEL" envDefault:"text-embedding-3-small"`
+
+	// AutoFormat normalizes the prompt library (see the fmt command) after every write.
+	// It is loaded from the AUTO_FORMAT environment variable.
+	AutoFormat bool `env:"AUTO_FORMAT"`
+
+	// OnConflict specifies what to do when a new prompt's title already exists in its
+	// section: "replace", "rename" (add as "Title (2)") or "abort".
+	// It is loaded from the ON_CONFLICT environment variable and can be set per
+	// invocation with --on-conflict. When unset the user is asked interactively.
+	OnConflict string `env:"ON_CONFLICT"`
+
+	// TitlesOnly matches searches against prompt titles and section headings only,
+	// ignoring prompt bodies. It is loaded from the TITLES_ONLY environment variable
+	// and can be enabled per invocation with --titles-only.
+	TitlesOnly bool `env:"TITLES_ONLY"`
+
+	// Stemming also matches query words to prompt words sharing their English stem,
+	// so "testing" matches "tests". It is loaded from the STEMMING environment
+	// variable and can be enabled per invocation with --stem.
+	Stemming bool `env:"STEMMING"`
+
+	// StopWords lists the query words, separated by commas, that prompts need not
+	// contain to match, though prompts containing them rank higher.
+	// It is loaded from the STOP_WORDS environment variable. Defaults to common
+	// English words such as "a", "the" and "for" if not set; "none" requires every word.
+	StopWords []string `env:"STOP_WORDS"`
+
+	// Sort specifies the order of search results and listings: "relevance" (best
+	// match first), "alpha", "section" (alphabetically within each section),
+	// "length" (shortest first) or "recent" (most recently added first).
+	// It is loaded from the SORT environment variable and can be set per invocation
+	// with --sort. Defaults to "relevance" if not set.
+	Sort string `env:"SORT" envDefault:"relevance"`
+
+	// MinRelevance specifies the relevance, between 0 and 1, the best match must reach
+	// in one-shot modes; weaker matches are reported as "no confident match" instead.
+	// A match in which every query word appears exactly has relevance 1, and each
+	// fuzzily matched word lowers it. It is loaded from the MIN_RELEVANCE environment
+	// variable and can be set per invocation with --min-relevance.
+	// Defaults to 0.02 if not set; 0 disables the cutoff.
+	MinRelevance float64 `env:"MIN_RELEVANCE" envDefault:"0.02"`
+
+	// TypeOnSelect also types the selected prompt into the focused window via keyboard
+	// emulation (xdotool, wtype or osascript). It is loaded from the TYPE_ON_SELECT
+	// environment variable and can be enabled per invocation with --type.
+	TypeOnSelect bool `env:"TYPE_ON_SELECT"`
+
+	// DefaultQuery pre-fills the interactive search box, with the cursor at the end
+	// so it can be refined. It is loaded from the DEFAULT_QUERY environment variable
+	// and can be set per invocation with --default-query.
+	DefaultQuery string `env:"DEFAULT_QUERY"`
+
+	// Theme selects the colors of the TUI: "default" (purple), "high-contrast" or
+	// "colorblind", which uses a palette told apart with any color vision. It is
+	// loaded from the THEME environment variable and can be set per invocation with
+	// --theme. Defaults to "default" if not set.
+	Theme string `env:"THEME" envDefault:"default"`
+
+	// SectionIcons maps section names to an emoji or short badge shown next to them
+	// in the TUI, such as "Golang=🐹,Python=🐍". A marker in the heading itself
+	// (e.g. "## Golang 🐹") takes precedence. It is loaded from the SECTION_ICONS
+	// environment variable.
+	SectionIcons map[string]string `env:"SECTION_ICONS" envKeyValSeparator:"="`
+
+	// PickerCommand is the menu used by the launcher command to pick a prompt, such
+	// as "rofi -dmenu -i". It reads one prompt per line on stdin and prints the chosen
+	// line. It is loaded from the PICKER_COMMAND environment variable.
+	// Detected from the installed launchers if not set.
+	PickerCommand string `env:"PICKER_COMMAND"`
+
+	// TypeDelay specifies how long to wait before typing so focus can return to the target window.
+	// It is loaded from the TYPE_DELAY environment variable.
+	// Defaults to 500ms if not set.
+	TypeDelay time.Duration `env:"TYPE_DELAY" envDefault:"500ms"`
+
+	// ArchiveSection specifies the section archived prompts are moved to.
+	// It is loaded from the ARCHIVE_SECTION environment variable.
+	// Defaults to "Archive" if not set.
+	ArchiveSection string `env:"ARCHIVE_SECTION" envDefault:"Archive"`
+
+	// Templates renders prompts as Go templates when they are copied, printed or
+	// typed, with the now, clipboard and shell functions.
+	// It is loaded from the TEMPLATES environment variable.
+	Templates bool `env:"TEMPLATES"`
+
+	// AllowShell lets the shell template function run commands. It is only set per
+	// invocation with --allow-shell, never from the environment, so a stray
+	// variable cannot make prompts run commands.
+	AllowShell bool
+
+	// WithAttachments prints the absolute paths of the files a copied or printed
+	// prompt attaches with "<!-- attach: path -->" comments. It is set per
+	// invocation with --with-attachments.
+	WithAttachments bool
+
+	// NoExec refuses to run any external program, such as sncli, secret provider CLIs,
+	// clipboard utilities, hooks or $EDITOR, so only local files can be used and copies
+	// go through the terminal or stdout. Builds with the noexec tag always set it.
+	// It is loaded from the NO_EXEC environment variable.
+	NoExec bool `env:"NO_EXEC"`
+
+	// CopyTransform lists transforms applied in order to prompts as they are copied:
+	// strip-markdown, one-line, code-block or json.
+	// It is loaded from the COPY_TRANSFORM environment variable, separated by commas,
+	// and can be set per invocation with --transform.
+	CopyTransform []string `env:"COPY_TRANSFORM"`
+
+	// CopyPrefix is added before prompts as they are copied, after CopyTransform.
+	// It is loaded from the COPY_PREFIX environment variable.
+	CopyPrefix string `env:"COPY_PREFIX"`
+
+	// CopySuffix is added after prompts as they are copied, after CopyTransform.
+	// It is loaded from the COPY_SUFFIX environment variable.
+	CopySuffix string `env:"COPY_SUFFIX"`
+
+	// Preset names the preset formatting copied and printed prompts for a destination,
+	// such as "chatgpt" or "slack". It is only set per invocation with --for.
+	Preset string
+
+	// PresetsFile specifies a JSON file of presets adding to or replacing the built-in ones.
+	// It is loaded from the PRESETS_FILE environment variable.
+	PresetsFile string `env:"PRESETS_FILE"`
+
+	// HookPreCopy is a shell command run before a prompt is copied, with the prompt in
+	// the WMP_EVENT, WMP_SECTION, WMP_TITLE, WMP_NAMESPACE and WMP_PROMPT environment
+	// variables. The copy is cancelled if it fails.
+	// It is loaded from the HOOK_PRE_COPY environment variable.
+	HookPreCopy string `env:"HOOK_PRE_COPY"`
+
+	// HookPostCopy is a shell command run after a prompt is copied, such as
+	// "notify-send 'Prompt copied'". It is loaded from the HOOK_POST_COPY environment variable.
+	HookPostCopy string `env:"HOOK_POST_COPY"`
+
+	// HookPreWrite is a shell command run before a prompt is added to the library.
+	// The write is cancelled if it fails.
+	// It is loaded from the HOOK_PRE_WRITE environment variable.
+	HookPreWrite string `env:"HOOK_PRE_WRITE"`
+
+	// HookPostWrite is a shell command run after a prompt is added to the library,
+	// such as "git -C ~/notes commit -am 'prompt added'".
+	// It is loaded from the HOOK_POST_WRITE environment variable.
+	HookPostWrite string `env:"HOOK_POST_WRITE"`
+
+	// Force writes a Simplenote note even when the new content is much shorter than
+	// the note, which is otherwise refused as a likely parse or writer bug. It is only
+	// set per invocation with --force.
+	Force bool
+
+	// IncludeArchived includes prompts in ArchiveSection in searches.
+	// It is loaded from the INCLUDE_ARCHIVED environment variable
+	// and can be enabled per invocation with --include-archived.
+	IncludeArchived bool `env:"INCLUDE_ARCHIVED"`
+
+	// ShareProvider selects the paste service used by the share command,
+	// either "gist" (GitHub gist) or "endpoint" (self-hosted paste endpoint).
+	// It is loaded from the SHARE_PROVIDER environment variable.
+	// Defaults to "gist" if not set.
+	ShareProvider string `env:"SHARE_PROVIDER" envDefault:"gist"`
+
+	// ShareEndpoint specifies the URL of a self-hosted paste endpoint.
+	// It is loaded from the SHARE_ENDPOINT environment variable.
+	ShareEndpoint string `env:"SHARE_ENDPOINT"`
+
+	// ShareToken specifies the GitHub token (gist scope) or endpoint bearer token.
+	// It is loaded from the SHARE_TOKEN environment variable.
+	ShareToken string `env:"SHARE_TOKEN"`
+
+	// ShareExpiry specifies how long a shared prompt should live on paste endpoints.
+	// It is loaded from the SHARE_EXPIRY environment variable. Endpoints are asked
+	// for 24h if not set; gists never expire, so it must not be set for them.
+	ShareExpiry time.Duration `env:"SHARE_EXPIRY"`
 }
 
 // GetEnvVars loads and returns the application configuration from environment
@@ -157,3 +613,42 @@ func GetEnvVars() Config {
 
 	return conf
 }
+
+// UsesSecretProvider reports whether SN_USERNAME and SN_PASSWORD name fields of a
+// secret provider rather than holding the credentials themselves.
+func (c Config) UsesSecretProvider() bool {
+	return c.SNCredential != "" || c.SecretProvider != ""
+}
+
+// UsesSimplenote reports whether prompts are kept in the Simplenote note SN_NOTE
+// rather than in a file or another source.
+func (c Config) UsesSimplenote() bool {
+	return c.FilePath == "" && c.Source == "" && c.AppleNote == "" && !c.UsesJoplin() && c.StandardNotesBackup == "" && c.GoogleDoc == "" && c.SNNote != ""
+}
+
+// UsesJoplin reports whether prompts are kept in Joplin, selected by a notebook or tag.
+func (c Config) UsesJoplin() bool {
+	return c.JoplinNotebook != "" || c.JoplinTag != ""
+}
+
+// ResolveDataDir returns the directory used for local application state.
+//
+// The DataDir field takes precedence. Otherwise $XDG_DATA_HOME/wheresmyprompt
+// is used, falling back to ~/.local/share/wheresmyprompt. The directory is not created.
+//
+// Returns:
+//   - string: Absolute path of the data directory
+//   - error: Error if the user's home directory cannot be determined
+func ResolveDataDir(conf Config) (string, error) {
+	if conf.DataDir != "" {
+		return conf.DataDir, nil
+	}
+	if xdg := os.Getenv("XDG_DATA_HOME"); xdg != "" {
+		return filepath.Join(xdg, "wheresmyprompt"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to determine data directory: %w", err)
+	}
+	return filepath.Join(home, ".local", "share", "wheresmyprompt"), nil
+}
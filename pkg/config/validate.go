@@ -0,0 +1,192 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Validate cross-checks the configuration and reports every problem found, each with
+// guidance on how to fix it, so misconfiguration is caught before any command runs
+// rather than part way through.
+//
+// Checks include:
+//   - A prompt source is configured (FILEPATH, SOURCE or SN_NOTE) and SOURCE names a plugin
+//   - SN_CREDENTIAL or SECRET_PROVIDER is accompanied by the SN_USERNAME and SN_PASSWORD field names
+//   - Direct Simplenote credentials are set together
+//   - Enumerated values such as SECRET_PROVIDER, ON_CONFLICT, SORT, COPY_TRANSFORM and SHARE_PROVIDER are recognized
+//   - NO_EXEC is not combined with sources or hooks that run external programs
+//   - Every WRITE_ROUTES entry names a section and a target
+//   - Sizes and durations are not negative and MIN_RELEVANCE is between 0 and 1
+//
+// Returns:
+//   - error: All problems joined with errors.Join, or nil if the configuration is valid
+func (c Config) Validate() error {
+	var errs []error
+	add := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if c.Source != "" && !validSourceName(c.Source) {
+		add("invalid SOURCE %q: must be the <name> of a wheresmyprompt-source-<name> plugin, using only letters, digits, '-' and '_'", c.Source)
+	}
+
+	if c.UsesJoplin() {
+		switch {
+		case c.JoplinNotebook != "" && c.JoplinTag != "":
+			add("JOPLIN_NOTEBOOK and JOPLIN_TAG cannot both be set; select the notes to load with one of them")
+		case c.JoplinToken == "":
+			add("JOPLIN_TOKEN is required with JOPLIN_NOTEBOOK or JOPLIN_TAG; copy it from Joplin's Web Clipper options")
+		}
+		if c.JoplinPort < 1 || c.JoplinPort > 65535 {
+			add("invalid JOPLIN_PORT %d: must be between 1 and 65535", c.JoplinPort)
+		}
+	}
+
+	if c.StandardNotesBackup != "" && c.StandardNotesNote == "" {
+		add("STANDARD_NOTES_NOTE must name the note holding your prompts in STANDARD_NOTES_BACKUP")
+	}
+
+	if c.GoogleClientID != "" && c.GoogleClientSecret == "" {
+		add("GOOGLE_CLIENT_SECRET is required with GOOGLE_CLIENT_ID; copy both from your OAuth client in the Google Cloud console")
+	}
+
+	if c.FilePath == "" && c.Source == "" && c.AppleNote == "" && !c.UsesJoplin() && c.StandardNotesBackup == "" && c.GoogleDoc == "" {
+		switch {
+		case c.SNNote == "":
+			add("no prompt source configured: set FILEPATH (or --load) to a Markdown file, SN_NOTE to the name of a Simplenote note, APPLE_NOTE to the name of an Apple Notes note, JOPLIN_NOTEBOOK or JOPLIN_TAG to Joplin notes, STANDARD_NOTES_BACKUP to a Standard Notes backup, GOOGLE_DOC to a Google Doc, or SOURCE to a source plugin")
+		case c.UsesSecretProvider():
+			if c.SNCredential == "" && c.SecretProvider != "env" {
+				add("SECRET_PROVIDER=%s requires SN_CREDENTIAL to name the item holding your Simplenote credentials", c.SecretProvider)
+			}
+			if c.SNUsername == "" || c.SNPassword == "" {
+				setting := "SN_CREDENTIAL"
+				if c.SNCredential == "" {
+					setting = "SECRET_PROVIDER"
+				}
+				add("%s is set, so SN_USERNAME and SN_PASSWORD must name the secret provider fields holding your Simplenote username and password (e.g. SN_USERNAME=username SN_PASSWORD=password)", setting)
+			}
+		case (c.SNUsername == "") != (c.SNPassword == ""):
+			add("SN_USERNAME and SN_PASSWORD must be set together; set both to your Simplenote credentials, or neither if sncli is already logged in")
+		}
+	}
+
+	if c.NoExec {
+		for _, setting := range []struct{ name, value string }{
+			{"SOURCE", c.Source},
+			{"APPLE_NOTE", c.AppleNote},
+			{"TEAM_SN_NOTE", c.TeamSNNote},
+			{"HOOK_PRE_COPY", c.HookPreCopy},
+			{"HOOK_POST_COPY", c.HookPostCopy},
+			{"HOOK_PRE_WRITE", c.HookPreWrite},
+			{"HOOK_POST_WRITE", c.HookPostWrite},
+		} {
+			if setting.value != "" {
+				add("%s runs an external program, which NO_EXEC=true forbids; unset it", setting.name)
+			}
+		}
+		if c.UsesSimplenote() {
+			add("NO_EXEC=true forbids running sncli for Simplenote; set FILEPATH (or --load) to a local Markdown file")
+		}
+	}
+
+	switch c.SecretProvider {
+	case "", "1password", "bitwarden", "pass", "env":
+	default:
+		add("invalid SECRET_PROVIDER %q: must be 1password, bitwarden, pass or env", c.SecretProvider)
+	}
+
+	switch c.OnConflict {
+	case "", "replace", "rename", "abort":
+	default:
+		add("invalid ON_CONFLICT %q: must be replace, rename or abort (or unset to be asked)", c.OnConflict)
+	}
+
+	for section, target := range c.WriteRoutes {
+		if strings.TrimSpace(section) == "" || strings.TrimSpace(target) == "" {
+			add("invalid WRITE_ROUTES entry %q: use Section=target, such as Golang=go-prompts.md", section+"="+target)
+		}
+	}
+
+	switch c.Sort {
+	case "", "relevance", "alpha", "section", "length", "recent":
+	default:
+		add("invalid SORT %q: must be relevance, alpha, section, length or recent", c.Sort)
+	}
+
+	switch c.Theme {
+	case "", "default", "high-contrast", "colorblind":
+	default:
+		add("invalid THEME %q: must be default, high-contrast or colorblind", c.Theme)
+	}
+
+	for _, name := range c.CopyTransform {
+		switch strings.TrimSpace(name) {
+		case "", "strip-markdown", "one-line", "code-block", "json":
+		default:
+			add("invalid COPY_TRANSFORM entry %q: must be strip-markdown, one-line, code-block or json", name)
+		}
+	}
+
+	switch c.ShareProvider {
+	case "", "gist":
+	case "endpoint":
+		if c.ShareEndpoint == "" {
+			add("SHARE_PROVIDER=endpoint requires SHARE_ENDPOINT to be set to the URL of your paste endpoint")
+		}
+	default:
+		add("invalid SHARE_PROVIDER %q: must be gist or endpoint", c.ShareProvider)
+	}
+
+	if c.HTTPRateLimit < 0 {
+		add("HTTP_RATE_LIMIT must not be negative, got %v", c.HTTPRateLimit)
+	}
+
+	if c.MinRelevance < 0 || c.MinRelevance > 1 {
+		add("MIN_RELEVANCE must be between 0 and 1, got %v", c.MinRelevance)
+	}
+
+	for _, n := range []struct {
+		name  string
+		value int
+	}{
+		{"MAX_LINE_SIZE", c.MaxLineSize},
+		{"LOG_MAX_SIZE", c.LogMaxSize},
+		{"LOG_MAX_BACKUPS", c.LogMaxBackups},
+		{"HTTP_RETRIES", c.HTTPRetries},
+	} {
+		if n.value < 0 {
+			add("%s must not be negative, got %d", n.name, n.value)
+		}
+	}
+	for _, d := range []struct {
+		name  string
+		value time.Duration
+	}{
+		{"LOCK_TIMEOUT", c.LockTimeout},
+		{"SN_LOCAL_MAX_AGE", c.SNLocalMaxAge},
+		{"GOOGLE_CACHE_MAX_AGE", c.GoogleCacheMaxAge},
+		{"RELOAD_INTERVAL", c.ReloadInterval},
+		{"TYPE_DELAY", c.TypeDelay},
+		{"SHARE_EXPIRY", c.ShareExpiry},
+		{"HTTP_TIMEOUT", c.HTTPTimeout},
+	} {
+		if d.value < 0 {
+			add("%s must not be negative, got %s", d.name, d.value)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// validSourceName reports whether name can complete a "wheresmyprompt-source-<name>"
+// executable name without changing its directory or meaning to a shell.
+func validSourceName(name string) bool {
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
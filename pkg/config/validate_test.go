@@ -0,0 +1,76 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		conf    Config
+		wantErr []string
+	}{
+		{"file source", Config{FilePath: "prompts.md"}, nil},
+		{"simplenote with sncli already logged in", Config{SNNote: "LLM Prompts"}, nil},
+		{"simplenote with direct credentials", Config{SNNote: "LLM Prompts", SNUsername: "me@example.com", SNPassword: "pw"}, nil},
+		{"simplenote with 1Password", Config{SNNote: "LLM Prompts", SNCredential: "Simplenote", SNUsername: "username", SNPassword: "password"}, nil},
+		{"file source ignores simplenote fields", Config{FilePath: "prompts.md", SNCredential: "Simplenote"}, nil},
+		{"no source", Config{}, []string{"no prompt source configured"}},
+		{"1Password without field names", Config{SNNote: "n", SNCredential: "Simplenote", SNUsername: "username"}, []string{"SN_CREDENTIAL is set"}},
+		{"simplenote with env secrets", Config{SNNote: "n", SecretProvider: "env", SNUsername: "SIMPLENOTE_USER", SNPassword: "SIMPLENOTE_PASS"}, nil},
+		{"pass without item", Config{SNNote: "n", SecretProvider: "pass", SNUsername: "login", SNPassword: "password"}, []string{"requires SN_CREDENTIAL"}},
+		{"invalid secret provider", Config{FilePath: "p.md", SecretProvider: "keepass"}, []string{`invalid SECRET_PROVIDER "keepass"`}},
+		{"username without password", Config{SNNote: "n", SNUsername: "me@example.com"}, []string{"must be set together"}},
+		{"invalid on conflict", Config{FilePath: "p.md", OnConflict: "merge"}, []string{`invalid ON_CONFLICT "merge"`}},
+		{"invalid sort", Config{FilePath: "p.md", Sort: "newest"}, []string{`invalid SORT "newest"`}},
+		{"invalid theme", Config{FilePath: "p.md", Theme: "dark"}, []string{`invalid THEME "dark"`}},
+		{"valid copy transforms", Config{FilePath: "p.md", CopyTransform: []string{"strip-markdown", " json"}}, nil},
+		{"invalid copy transform", Config{FilePath: "p.md", CopyTransform: []string{"one-line", "yaml"}}, []string{`invalid COPY_TRANSFORM entry "yaml"`}},
+		{"no exec with local file", Config{FilePath: "p.md", NoExec: true}, nil},
+		{"no exec with simplenote", Config{SNNote: "LLM Prompts", NoExec: true}, []string{"NO_EXEC=true forbids running sncli"}},
+		{"no exec with plugin and hook", Config{Source: "joplin", HookPostCopy: "notify-send copied", NoExec: true}, []string{"SOURCE runs an external program", "HOOK_POST_COPY runs an external program"}},
+		{"invalid source", Config{Source: "../joplin"}, []string{`invalid SOURCE "../joplin"`}},
+		{"source plugin", Config{Source: "joplin"}, nil},
+		{"apple notes source", Config{AppleNote: "LLM Prompts"}, nil},
+		{"joplin notebook", Config{JoplinNotebook: "Prompts", JoplinToken: "token", JoplinPort: 41184}, nil},
+		{"joplin without token", Config{JoplinTag: "prompt", JoplinPort: 41184}, []string{"JOPLIN_TOKEN is required"}},
+		{"joplin notebook and tag", Config{JoplinNotebook: "Prompts", JoplinTag: "prompt", JoplinToken: "token", JoplinPort: 41184}, []string{"cannot both be set"}},
+		{"standard notes backup", Config{StandardNotesBackup: "backup.txt", StandardNotesNote: "LLM Prompts"}, nil},
+		{"standard notes without note", Config{StandardNotesBackup: "backup.txt"}, []string{"STANDARD_NOTES_NOTE must name"}},
+		{"google doc", Config{GoogleDoc: "https://docs.google.com/document/d/1AbCdEfGhIjK/edit"}, nil},
+		{"google client without secret", Config{GoogleDoc: "1AbCdEfGhIjK", GoogleClientID: "client"}, []string{"GOOGLE_CLIENT_SECRET is required"}},
+		{"invalid joplin port", Config{JoplinNotebook: "Prompts", JoplinToken: "token"}, []string{"invalid JOPLIN_PORT 0"}},
+		{"write route without target", Config{FilePath: "p.md", WriteRoutes: map[string]string{"Golang": ""}}, []string{`invalid WRITE_ROUTES entry "Golang="`}},
+		{"endpoint without url", Config{FilePath: "p.md", ShareProvider: "endpoint"}, []string{"requires SHARE_ENDPOINT"}},
+		{"invalid share provider", Config{FilePath: "p.md", ShareProvider: "pastebin"}, []string{`invalid SHARE_PROVIDER "pastebin"`}},
+		{"min relevance out of range", Config{FilePath: "p.md", MinRelevance: 1.5}, []string{"MIN_RELEVANCE must be between 0 and 1"}},
+		{"negative rate limit", Config{FilePath: "p.md", HTTPRateLimit: -1}, []string{"HTTP_RATE_LIMIT must not be negative"}},
+		{
+			"negative values reported together",
+			Config{FilePath: "p.md", LogMaxSize: -1, LockTimeout: -time.Second},
+			[]string{"LOG_MAX_SIZE must not be negative", "LOCK_TIMEOUT must not be negative"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.conf.Validate()
+			if len(tt.wantErr) == 0 {
+				if err != nil {
+					t.Errorf("Validate() unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Validate() expected error containing %q, got nil", tt.wantErr)
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Validate() error = %q, want it to contain %q", err.Error(), want)
+				}
+			}
+		})
+	}
+}
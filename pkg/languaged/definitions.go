@@ -0,0 +1,145 @@
+package languaged
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// defaultDefinitionsJSON holds the built-in language definitions.
+//
+//go:embed languages.json
+var defaultDefinitionsJSON []byte
+
+// Definitions maps file extensions and shebang interpreters to language names.
+//
+// Language names double as prompt section names, so a definitions file can map
+// files to whatever sections a prompt library uses.
+//
+// Example definitions file:
+//
+//	{
+//	  "extensions": {".tf": "Infrastructure", ".go": "Go"},
+//	  "filenames": {"Tiltfile": "Starlark"},
+//	  "shebangs": {"bun": "TypeScript"}
+//	}
+type Definitions struct {
+	// Extensions maps lower-case file extensions, including the dot, to languages.
+	Extensions map[string]string `json:"extensions"`
+	// Filenames maps file names, or filepath.Match patterns such as "Dockerfile.*",
+	// to languages for files without a meaningful extension. They take precedence
+	// over Extensions.
+	Filenames map[string]string `json:"filenames"`
+	// Manifests maps project files such as go.mod or package.json to languages. They
+	// are only used when no source file is recognized, e.g. in a repository holding
+	// nothing but a go.mod.
+	Manifests map[string]string `json:"manifests"`
+	// Shebangs maps interpreter names, as found in #! lines, to languages.
+	Shebangs map[string]string `json:"shebangs"`
+	// Ignore lists filepath.Match patterns of file names that are never counted,
+	// such as minified bundles and lock files.
+	Ignore []string `json:"ignore"`
+	// MaxFileSize is the size in bytes above which files are skipped; 0 disables the limit.
+	MaxFileSize int64 `json:"max_file_size"`
+}
+
+// DefaultDefinitions returns a copy of the built-in language definitions.
+//
+// Returns:
+//   - *Definitions: The embedded definitions, safe to modify
+func DefaultDefinitions() *Definitions {
+	var defs Definitions
+	if err := json.Unmarshal(defaultDefinitionsJSON, &defs); err != nil {
+		panic(fmt.Sprintf("languaged: invalid embedded languages.json: %v", err))
+	}
+	return &defs
+}
+
+// LoadDefinitions returns the built-in definitions overlaid with those in the JSON
+// file at path. Entries in the file add to or replace built-in entries; mapping an
+// extension or interpreter to "" removes it. Ignore patterns are added to the
+// built-in ones and a non-zero max_file_size replaces the default (-1 removes the
+// limit). An empty path returns the defaults.
+//
+// Parameters:
+//   - path: Path of a JSON definitions file, or "" for the defaults only
+//
+// Returns:
+//   - *Definitions: The merged definitions
+//   - error: Error if the file cannot be read or parsed
+func LoadDefinitions(path string) (*Definitions, error) {
+	defs := DefaultDefinitions()
+	if path == "" {
+		return defs, nil
+	}
+
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read language definitions: %w", err)
+	}
+	var user Definitions
+	if err := json.Unmarshal(data, &user); err != nil {
+		return nil, fmt.Errorf("failed to parse language definitions %s: %w", path, err)
+	}
+
+	for ext, lang := range user.Extensions {
+		ext = strings.ToLower(ext)
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		setOrDelete(defs.Extensions, ext, lang)
+	}
+	for name, lang := range user.Filenames {
+		setOrDelete(defs.Filenames, name, lang)
+	}
+	for name, lang := range user.Manifests {
+		setOrDelete(defs.Manifests, name, lang)
+	}
+	for interpreter, lang := range user.Shebangs {
+		setOrDelete(defs.Shebangs, interpreter, lang)
+	}
+	defs.Ignore = append(defs.Ignore, user.Ignore...)
+	if user.MaxFileSize != 0 {
+		defs.MaxFileSize = user.MaxFileSize
+	}
+	return defs, nil
+}
+
+// ignored reports whether a file named name matches an Ignore pattern.
+func (d *Definitions) ignored(name string) bool {
+	for _, pattern := range d.Ignore {
+		if matched, _ := filepath.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// languageForName returns the language of a file named name according to
+// Filenames and then Extensions, or "" if neither matches.
+func (d *Definitions) languageForName(name string) string {
+	if lang, ok := d.Filenames[name]; ok {
+		return lang
+	}
+	// Check patterns in a stable order so overlapping patterns behave predictably
+	patterns := slices.Sorted(maps.Keys(d.Filenames))
+	for _, pattern := range patterns {
+		if matched, _ := filepath.Match(pattern, name); matched {
+			return d.Filenames[pattern]
+		}
+	}
+	return d.Extensions[strings.ToLower(filepath.Ext(name))]
+}
+
+func setOrDelete(m map[string]string, key, value string) {
+	if value == "" {
+		delete(m, key)
+		return
+	}
+	m[key] = value
+}
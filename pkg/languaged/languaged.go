@@ -6,16 +6,20 @@
 //
 // The detection process:
 //  1. Scans all files in the repository directory tree
-//  2. Identifies languages using file extensions and shebang analysis
+//  2. Identifies languages using file names, extensions and shebang analysis
 //  3. Respects .gitattributes linguist-language overrides
 //  4. Counts lines of code per language
 //  5. Returns the language with the most lines of code
 //
-// Supported languages include:
+// Supported languages are defined in the embedded languages.json and include:
 //   - Go, Python, JavaScript, TypeScript, Java, C/C++, C#
 //   - Ruby, PHP, Rust, Swift, Kotlin, Objective-C, Scala
+//   - Zig, Elixir, Erlang, Dart, R, Julia, Clojure, F#, OCaml, Nim
+//   - Terraform/HCL, Dockerfile, Makefile, SQL, YAML
 //   - Shell scripts, Lua, Haskell, HTML, CSS, and more
 //
+// The definitions can be extended or overridden with a JSON file, see LoadDefinitions.
+//
 // Example usage:
 //
 //	import "github.com/toozej/wheresmyprompt/pkg/languaged"
@@ -30,55 +34,21 @@ package languaged
 
 import (
 	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 )
 
-// extensionToLanguage maps file extensions to programming languages.
-var extensionToLanguage = map[string]string{
-	".go":    "Golang",
-	".py":    "Python",
-	".js":    "JavaScript",
-	".ts":    "TypeScript",
-	".java":  "Java",
-	".c":     "C",
-	".cpp":   "C++",
-	".cs":    "C#",
-	".rb":    "Ruby",
-	".php":   "PHP",
-	".rs":    "Rust",
-	".swift": "Swift",
-	".kt":    "Kotlin",
-	".m":     "Objective-C",
-	".scala": "Scala",
-	".sh":    "Shell",
-	".lua":   "Lua",
-	".hs":    "Haskell",
-	".html":  "HTML",
-	".css":   "CSS",
-}
-
-// shebangToLanguage maps common shebang interpreters to languages.
-var shebangToLanguage = map[string]string{
-	"python":  "Python",
-	"python3": "Python",
-	"python2": "Python",
-	"bash":    "Shell",
-	"sh":      "Shell",
-	"ruby":    "Ruby",
-	"node":    "JavaScript",
-	"perl":    "Perl",
-	"php":     "PHP",
-	"lua":     "Lua",
-}
-
 // DetectPrimaryLanguage analyzes a repository directory and returns its primary programming language.
 //
 // This function performs comprehensive language detection by:
 //  1. Walking the entire directory tree starting from repoPath
-//  2. Identifying file languages using extensions and shebang analysis
+//  2. Identifying file languages using file names, extensions and shebang analysis
 //  3. Respecting .gitattributes linguist-language overrides
 //  4. Counting lines of code for each detected language
 //  5. Returning the language with the highest line count
@@ -95,7 +65,8 @@ var shebangToLanguage = map[string]string{
 //   - error: Error if directory cannot be accessed or walked
 //
 // Special cases:
-//   - Returns "Unknown" if no recognizable source files are found
+//   - Falls back to project manifests (go.mod, package.json, ...) if no source files are recognized
+//   - Returns "Unknown" if neither recognizable source files nor manifests are found
 //   - Empty repositories return "Unknown" without error
 //   - Unreadable files are skipped without causing errors
 //
@@ -112,8 +83,56 @@ var shebangToLanguage = map[string]string{
 //		fmt.Printf("Detected %s project\n", lang)
 //	}
 func DetectPrimaryLanguage(repoPath string) (string, error) {
+	return DetectPrimaryLanguageWith(repoPath, DefaultDefinitions())
+}
+
+// DetectPrimaryLanguageWith works like DetectPrimaryLanguage but identifies files
+// using defs, such as definitions returned by LoadDefinitions.
+func DetectPrimaryLanguageWith(repoPath string, defs *Definitions) (string, error) {
+	breakdown, err := Analyze(repoPath, defs)
+	if err != nil {
+		return "", err
+	}
+	return breakdown.Primary, nil
+}
+
+// LanguageStat is the share of one language in a repository.
+type LanguageStat struct {
+	Language string  `json:"language"`
+	Lines    int     `json:"lines"`
+	Percent  float64 `json:"percent"`
+}
+
+// Breakdown is the result of analyzing a repository.
+type Breakdown struct {
+	// Primary is the language with the most lines, the manifest language if no
+	// source file was recognized, or "Unknown".
+	Primary string `json:"primary"`
+	// Languages lists every recognized language, most lines first.
+	Languages []LanguageStat `json:"languages"`
+	// Manifest is the language of the shallowest project manifest, if any.
+	Manifest string `json:"manifest,omitempty"`
+}
+
+// Analyze walks repoPath like DetectPrimaryLanguage and returns the line count and
+// share of every recognized language along with the primary language.
+//
+// Parameters:
+//   - repoPath: Path to the repository root directory to analyze
+//   - defs: Language definitions, see DefaultDefinitions and LoadDefinitions
+//
+// Returns:
+//   - *Breakdown: Languages ordered by line count (ties by name) and the primary language
+//   - error: Error if directory cannot be accessed or walked
+func Analyze(repoPath string, defs *Definitions) (*Breakdown, error) {
 	languageLineCounts := make(map[string]int)
 
+	// The shallowest project manifest found, used if no source file is recognized
+	var manifest struct {
+		lang  string
+		depth int
+	}
+
 	// Load linguist-language overrides from .gitattributes
 	overrides, _ := parseGitattributes(filepath.Join(repoPath, ".gitattributes"))
 
@@ -125,6 +144,9 @@ func DetectPrimaryLanguage(repoPath string) (string, error) {
 
 		// Skip directories like .git, vendor, node_modules
 		if info.IsDir() {
+			if path == repoPath {
+				return nil // repoPath itself may be "." or a hidden directory
+			}
 			base := info.Name()
 			if strings.HasPrefix(base, ".") || base == "vendor" || base == "node_modules" {
 				return filepath.SkipDir
@@ -132,18 +154,33 @@ func DetectPrimaryLanguage(repoPath string) (string, error) {
 			return nil
 		}
 
+		// Skip generated and oversized files that would skew the line counts
+		if defs.ignored(info.Name()) || (defs.MaxFileSize > 0 && info.Size() > defs.MaxFileSize) {
+			return nil
+		}
+
 		var lang string
 
+		// Remember project manifests as a fallback for repositories without source files
+		if manifestLang, ok := defs.Manifests[info.Name()]; ok {
+			if info.Name() == "package.json" && usesTypeScript(path) {
+				manifestLang = "TypeScript"
+			}
+			depth := strings.Count(relPath, string(filepath.Separator))
+			if manifest.lang == "" || depth < manifest.depth {
+				manifest.lang, manifest.depth = manifestLang, depth
+			}
+		}
+
 		// Check if this file is overridden in .gitattributes
 		if overrideLang, ok := overrides[relPath]; ok {
 			lang = overrideLang
 		} else {
-			ext := strings.ToLower(filepath.Ext(info.Name()))
-			if knownLang, ok := extensionToLanguage[ext]; ok {
+			if knownLang := defs.languageForName(info.Name()); knownLang != "" {
 				lang = knownLang
 			} else {
 				// Try detect by shebang
-				shebangLang, err := detectLanguageByShebang(path)
+				shebangLang, err := detectLanguageByShebang(path, defs.Shebangs)
 				if err == nil && shebangLang != "" {
 					lang = shebangLang
 				} else {
@@ -161,23 +198,38 @@ func DetectPrimaryLanguage(repoPath string) (string, error) {
 		return nil
 	})
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	// Find language with most lines
-	var primaryLang string
-	maxLines := 0
+	breakdown := &Breakdown{Manifest: manifest.lang}
+	total := 0
 	for lang, count := range languageLineCounts {
-		if count > maxLines {
-			primaryLang = lang
-			maxLines = count
+		breakdown.Languages = append(breakdown.Languages, LanguageStat{Language: lang, Lines: count})
+		total += count
+	}
+	sort.Slice(breakdown.Languages, func(i, j int) bool {
+		a, b := breakdown.Languages[i], breakdown.Languages[j]
+		if a.Lines != b.Lines {
+			return a.Lines > b.Lines
+		}
+		return a.Language < b.Language
+	})
+	for i := range breakdown.Languages {
+		if total > 0 {
+			breakdown.Languages[i].Percent = float64(breakdown.Languages[i].Lines) * 100 / float64(total)
 		}
 	}
 
-	if primaryLang == "" {
-		return "Unknown", nil
+	// The language with most lines wins, then the manifest language
+	switch {
+	case len(breakdown.Languages) > 0 && breakdown.Languages[0].Lines > 0:
+		breakdown.Primary = breakdown.Languages[0].Language
+	case manifest.lang != "":
+		breakdown.Primary = manifest.lang
+	default:
+		breakdown.Primary = "Unknown"
 	}
-	return primaryLang, nil
+	return breakdown, nil
 }
 
 // parseGitattributes parses .gitattributes for linguist-language overrides.
@@ -211,8 +263,9 @@ func parseGitattributes(path string) (map[string]string, error) {
 	return overrides, nil
 }
 
-// detectLanguageByShebang reads first line and returns detected language.
-func detectLanguageByShebang(path string) (string, error) {
+// detectLanguageByShebang reads the first line and returns the language of its
+// interpreter, looked up in shebangs.
+func detectLanguageByShebang(path string, shebangs map[string]string) (string, error) {
 	f, err := os.Open(path) // #nosec G304
 	if err != nil {
 		return "", err
@@ -223,17 +276,66 @@ func detectLanguageByShebang(path string) (string, error) {
 	if scanner.Scan() {
 		line := scanner.Text()
 		if strings.HasPrefix(line, "#!") {
-			for key, lang := range shebangToLanguage {
-				if strings.Contains(line, key) {
-					return lang, nil
-				}
+			interpreter := shebangInterpreter(line)
+			if lang, ok := shebangs[interpreter]; ok {
+				return lang, nil
+			}
+			// Fall back to the name without a version suffix, e.g. python3.12 -> python
+			if lang, ok := shebangs[strings.TrimRight(interpreter, "0123456789.")]; ok {
+				return lang, nil
 			}
 		}
 	}
 	return "", nil
 }
 
-// countLines counts the number of lines in a file.
+// shebangInterpreter returns the interpreter name of a #! line, looking through
+// /usr/bin/env and its options: "#!/usr/bin/env -S python3 -u" yields "python3".
+func shebangInterpreter(line string) string {
+	fields := strings.Fields(strings.TrimPrefix(line, "#!"))
+	if len(fields) == 0 {
+		return ""
+	}
+	interpreter := filepath.Base(fields[0])
+	if interpreter == "env" {
+		interpreter = ""
+		for _, field := range fields[1:] {
+			if strings.HasPrefix(field, "-") || strings.Contains(field, "=") {
+				continue
+			}
+			interpreter = filepath.Base(field)
+			break
+		}
+	}
+	return interpreter
+}
+
+// usesTypeScript reports whether the package.json at path depends on TypeScript.
+func usesTypeScript(path string) bool {
+	data, err := os.ReadFile(path) // #nosec G304
+	if err != nil {
+		return false
+	}
+	var pkg struct {
+		Dependencies    map[string]string `json:"dependencies"`
+		DevDependencies map[string]string `json:"devDependencies"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return false
+	}
+	_, dep := pkg.Dependencies["typescript"]
+	_, devDep := pkg.DevDependencies["typescript"]
+	return dep || devDep
+}
+
+// sniffSize is how much of a file is checked for NUL bytes to detect binaries.
+const sniffSize = 8 * 1024
+
+// errBinary is returned by countLines for files that look binary.
+var errBinary = errors.New("binary file")
+
+// countLines counts the number of lines in a file. Files with a NUL byte in their
+// first 8 KiB are treated as binary and return errBinary.
 func countLines(path string) (int, error) {
 	f, err := os.Open(path) // #nosec G304
 	if err != nil {
@@ -241,8 +343,14 @@ func countLines(path string) (int, error) {
 	}
 	defer f.Close()
 
+	reader := bufio.NewReaderSize(f, sniffSize)
+	head, _ := reader.Peek(sniffSize)
+	if bytes.IndexByte(head, 0) >= 0 {
+		return 0, errBinary
+	}
+
 	count := 0
-	scanner := bufio.NewScanner(f)
+	scanner := bufio.NewScanner(reader)
 	for scanner.Scan() {
 		count++
 	}
@@ -0,0 +1,189 @@
+package languaged
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFiles creates files relative to dir.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+}
+
+func TestDetectPrimaryLanguage(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{"empty", nil, "Unknown"},
+		{"go", map[string]string{"main.go": "package main\n\nfunc main() {}\n"}, "Golang"},
+		{"zig", map[string]string{"src/main.zig": "const std = @import(\"std\");\npub fn main() void {}\n"}, "Zig"},
+		{"elixir", map[string]string{"lib/app.ex": "defmodule App do\nend\n", "mix.exs": "defmodule Mix do\nend\n"}, "Elixir"},
+		{"terraform", map[string]string{"main.tf": "resource \"x\" \"y\" {\n}\n", "vars.tfvars": "a = 1\n"}, "Terraform"},
+		{"upper-case R extension", map[string]string{"analysis.R": "x <- 1\ny <- 2\n"}, "R"},
+		{"yaml infra", map[string]string{"deploy.yaml": "a: 1\nb: 2\nc: 3\n", "run.sh": "echo hi\n"}, "YAML"},
+		{"hidden and vendor directories skipped", map[string]string{"app.py": "print(1)\n", "vendor/x.go": "a\nb\nc\nd\n", ".cache/y.go": "a\nb\nc\nd\n"}, "Python"},
+		{"env shebang", map[string]string{"tool": "#!/usr/bin/env -S python3 -u\nprint(1)\n"}, "Python"},
+		{"versioned shebang", map[string]string{"tool": "#!/usr/local/bin/python3.12\nprint(1)\n"}, "Python"},
+		{"julia shebang", map[string]string{"script": "#!/usr/bin/env julia\nprintln(1)\n"}, "Julia"},
+		{"bash is not sh", map[string]string{"script": "#!/bin/bash\necho hi\n"}, "Shell"},
+		{"dockerfile", map[string]string{"Dockerfile": "FROM alpine\nRUN true\n", "Dockerfile.dev": "FROM alpine\n"}, "Dockerfile"},
+		{"makefile", map[string]string{"Makefile": "all:\n\ttrue\n"}, "Makefile"},
+		{"cmake", map[string]string{"CMakeLists.txt": "project(x)\n"}, "CMake"},
+		{"jenkinsfile", map[string]string{"Jenkinsfile": "pipeline {\n}\n"}, "Groovy"},
+		{"rakefile", map[string]string{"Rakefile": "task :default\n"}, "Ruby"},
+		{"go.mod only", map[string]string{"go.mod": "module example.com/x\n\ngo 1.22\n"}, "Golang"},
+		{"package.json only", map[string]string{"package.json": `{"dependencies": {"left-pad": "1.0.0"}}`}, "JavaScript"},
+		{"package.json with typescript", map[string]string{"package.json": `{"devDependencies": {"typescript": "^5"}}`}, "TypeScript"},
+		{"root manifest preferred", map[string]string{"sub/Cargo.toml": "[package]\n", "go.mod": "module x\n"}, "Golang"},
+		{"minified bundle skipped", map[string]string{"app.py": "print(1)\n", "dist/app.min.js": "a\nb\nc\nd\ne\n"}, "Python"},
+		{"lock file skipped", map[string]string{"main.go": "package main\n", "pnpm-lock.yaml": "a: 1\nb: 2\nc: 3\n"}, "Golang"},
+		{"binary skipped", map[string]string{"main.go": "package main\n", "blob.js": "a\x00\nb\nc\nd\n"}, "Golang"},
+		{"source files beat manifests", map[string]string{"go.mod": "module x\n", "app.py": "print(1)\n"}, "Python"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, tt.files)
+			got, err := DetectPrimaryLanguage(dir)
+			if err != nil {
+				t.Fatalf("DetectPrimaryLanguage() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("DetectPrimaryLanguage() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadDefinitions(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "languages.json")
+	writeFiles(t, dir, map[string]string{
+		"languages.json": `{"extensions": {"tf": "Infrastructure", ".go": "Go", ".sql": ""}, "shebangs": {"bun": "TypeScript"}}`,
+	})
+
+	defs, err := LoadDefinitions(path)
+	if err != nil {
+		t.Fatalf("LoadDefinitions() error = %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		table map[string]string
+		key   string
+		want  string
+	}{
+		{"extension without dot added", defs.Extensions, ".tf", "Infrastructure"},
+		{"built-in overridden", defs.Extensions, ".go", "Go"},
+		{"built-in kept", defs.Extensions, ".py", "Python"},
+		{"built-in removed", defs.Extensions, ".sql", ""},
+		{"shebang added", defs.Shebangs, "bun", "TypeScript"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.table[tt.key]; got != tt.want {
+				t.Errorf("definition for %q = %q, want %q", tt.key, got, tt.want)
+			}
+		})
+	}
+
+	if DefaultDefinitions().Extensions[".go"] != "Golang" {
+		t.Error("expected LoadDefinitions not to modify the built-in definitions")
+	}
+
+	if _, err := LoadDefinitions(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("expected error for a missing definitions file")
+	}
+
+	repo := t.TempDir()
+	writeFiles(t, repo, map[string]string{"main.tf": "a\nb\n"})
+	if got, _ := DetectPrimaryLanguageWith(repo, defs); got != "Infrastructure" {
+		t.Errorf("DetectPrimaryLanguageWith() = %q, want %q", got, "Infrastructure")
+	}
+}
+
+func TestDetectPrimaryLanguage_MaxFileSize(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"main.go":   "package main\n",
+		"bundle.js": strings.Repeat("x\n", 100),
+	})
+
+	tests := []struct {
+		name        string
+		maxFileSize int64
+		want        string
+	}{
+		{"large file skipped", 50, "Golang"},
+		{"limit disabled", -1, "JavaScript"},
+		{"limit above size", 1024, "JavaScript"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defs := DefaultDefinitions()
+			defs.MaxFileSize = tt.maxFileSize
+			got, err := DetectPrimaryLanguageWith(dir, defs)
+			if err != nil {
+				t.Fatalf("DetectPrimaryLanguageWith() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("DetectPrimaryLanguageWith() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"main.go":  "a\nb\nc\n",
+		"run.sh":   "a\n",
+		"go.mod":   "module x\n",
+		"tool.zig": "a\n",
+	})
+
+	breakdown, err := Analyze(dir, DefaultDefinitions())
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+	want := []LanguageStat{
+		{Language: "Golang", Lines: 3, Percent: 60},
+		{Language: "Shell", Lines: 1, Percent: 20},
+		{Language: "Zig", Lines: 1, Percent: 20},
+	}
+	if len(breakdown.Languages) != len(want) {
+		t.Fatalf("Analyze() languages = %+v, want %+v", breakdown.Languages, want)
+	}
+	for i := range want {
+		if breakdown.Languages[i] != want[i] {
+			t.Errorf("Analyze() languages[%d] = %+v, want %+v", i, breakdown.Languages[i], want[i])
+		}
+	}
+	if breakdown.Primary != "Golang" || breakdown.Manifest != "Golang" {
+		t.Errorf("Analyze() primary = %q, manifest = %q; want Golang, Golang", breakdown.Primary, breakdown.Manifest)
+	}
+}
+
+func TestDetectPrimaryLanguage_RelativeHiddenRoot(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), ".hidden")
+	writeFiles(t, dir, map[string]string{"main.go": "package main\n"})
+	t.Chdir(dir)
+
+	if got, err := DetectPrimaryLanguage("."); err != nil || got != "Golang" {
+		t.Errorf("DetectPrimaryLanguage(\".\") = %q, %v; want Golang", got, err)
+	}
+}
@@ -13,6 +13,8 @@
 //   - Standard roff formatting for compatibility with man command
 //   - Hidden command integration (not shown in help but available for internal use)
 //   - Error handling for generation failures
+//   - Post-install helper that writes the man page and shell completions into
+//     standard locations under a prefix
 //
 // Example usage:
 //
@@ -23,11 +25,19 @@
 //
 //	// Generate man pages:
 //	// ./wheresmyprompt man > wheresmyprompt.1
+//
+//	// Install man page and completions:
+//	// sudo ./wheresmyprompt man --install
 package man
 
 import (
+	"bytes"
+	"compress/gzip"
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"runtime"
 
 	mcoral "github.com/muesli/mango-cobra"
 	"github.com/muesli/roff"
@@ -46,6 +56,8 @@ import (
 //   - Args: cobra.NoArgs - accepts no command-line arguments
 //   - SilenceUsage: true - suppresses usage on errors
 //   - DisableFlagsInUseLine: true - cleaner usage line display
+//   - Flags: --install writes the man page and completions via Install,
+//     --prefix overrides the installation prefix
 //
 // The generated man page includes:
 //   - Command descriptions and usage patterns
@@ -70,6 +82,9 @@ import (
 //	// ./wheresmyprompt man > wheresmyprompt.1
 //	// man ./wheresmyprompt.1
 func NewManCmd() *cobra.Command {
+	var install bool
+	var prefix string
+
 	cmd := &cobra.Command{
 		Use:                   "man",
 		Short:                 "Generates wheresmyprompt's command line manpages",
@@ -78,6 +93,17 @@ func NewManCmd() *cobra.Command {
 		Hidden:                true,
 		Args:                  cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if install {
+				if prefix == "" {
+					prefix = DefaultPrefix()
+				}
+				paths, err := Install(cmd.Root(), prefix)
+				for _, p := range paths {
+					fmt.Fprintf(cmd.OutOrStdout(), "Installed %s\n", p)
+				}
+				return err
+			}
+
 			manPage, err := mcoral.NewManPage(1, cmd.Root())
 			if err != nil {
 				return err
@@ -88,5 +114,92 @@ func NewManCmd() *cobra.Command {
 		},
 	}
 
+	cmd.Flags().BoolVar(&install, "install", false, "Install the man page and shell completions instead of printing the man page")
+	cmd.Flags().StringVar(&prefix, "prefix", "", "Installation prefix (default /usr/local when run as root, otherwise ~/.local)")
+
 	return cmd
 }
+
+// DefaultPrefix returns the installation prefix used by Install when none is given.
+// It is /usr/local when running as root (e.g., via sudo) and ~/.local otherwise,
+// so unprivileged users can install without elevated permissions.
+func DefaultPrefix() string {
+	if runtime.GOOS != "windows" && os.Geteuid() == 0 {
+		return "/usr/local"
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(home, ".local")
+	}
+	return "/usr/local"
+}
+
+// Install writes the gzipped man page and bash, zsh and fish completion scripts
+// for root into the standard locations below prefix:
+//
+//	share/man/man1/<name>.1.gz
+//	share/bash-completion/completions/<name>
+//	share/zsh/site-functions/_<name>
+//	share/fish/vendor_completions.d/<name>.fish
+//
+// It returns the paths that were written. Permission errors for non-root users
+// are annotated with a hint to re-run the command with sudo.
+func Install(root *cobra.Command, prefix string) ([]string, error) {
+	name := root.Name()
+	var written []string
+
+	manPage, err := mcoral.NewManPage(1, root)
+	if err != nil {
+		return written, err
+	}
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	if _, err := zw.Write([]byte(manPage.Build(roff.NewDocument()))); err != nil {
+		return written, err
+	}
+	if err := zw.Close(); err != nil {
+		return written, err
+	}
+
+	var bash, zsh, fish bytes.Buffer
+	if err := root.GenBashCompletionV2(&bash, true); err != nil {
+		return written, err
+	}
+	if err := root.GenZshCompletion(&zsh); err != nil {
+		return written, err
+	}
+	if err := root.GenFishCompletion(&fish, true); err != nil {
+		return written, err
+	}
+
+	files := []struct {
+		path string
+		data []byte
+	}{
+		{filepath.Join(prefix, "share", "man", "man1", name+".1.gz"), gz.Bytes()},
+		{filepath.Join(prefix, "share", "bash-completion", "completions", name), bash.Bytes()},
+		{filepath.Join(prefix, "share", "zsh", "site-functions", "_"+name), zsh.Bytes()},
+		{filepath.Join(prefix, "share", "fish", "vendor_completions.d", name+".fish"), fish.Bytes()},
+	}
+	for _, f := range files {
+		if err := writeInstallFile(f.path, f.data); err != nil {
+			return written, err
+		}
+		written = append(written, f.path)
+	}
+	return written, nil
+}
+
+// writeInstallFile writes data to path, creating parent directories as needed.
+func writeInstallFile(path string, data []byte) error {
+	err := os.MkdirAll(filepath.Dir(path), 0755) // #nosec G301
+	if err == nil {
+		err = os.WriteFile(path, data, 0644) // #nosec G306
+	}
+	if err != nil && errors.Is(err, os.ErrPermission) && runtime.GOOS != "windows" && os.Geteuid() != 0 {
+		return fmt.Errorf("failed to install %s: %w (re-run with sudo or pass --prefix)", path, err)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to install %s: %w", path, err)
+	}
+	return nil
+}
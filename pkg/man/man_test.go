@@ -1,7 +1,11 @@
 package man
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
+
+	"github.com/spf13/cobra"
 )
 
 func TestNewManCmd(t *testing.T) {
@@ -39,3 +43,37 @@ func TestNewManCmd(t *testing.T) {
 	//	}
 
 }
+
+func TestInstall(t *testing.T) {
+	prefix := t.TempDir()
+	root := &cobra.Command{Use: "wheresmyprompt", Short: "test root"}
+	root.AddCommand(NewManCmd())
+
+	paths, err := Install(root, prefix)
+	if err != nil {
+		t.Fatalf("Install() returned error: %v", err)
+	}
+
+	expected := []string{
+		filepath.Join(prefix, "share", "man", "man1", "wheresmyprompt.1.gz"),
+		filepath.Join(prefix, "share", "bash-completion", "completions", "wheresmyprompt"),
+		filepath.Join(prefix, "share", "zsh", "site-functions", "_wheresmyprompt"),
+		filepath.Join(prefix, "share", "fish", "vendor_completions.d", "wheresmyprompt.fish"),
+	}
+	if len(paths) != len(expected) {
+		t.Fatalf("Install() wrote %d files, expected %d", len(paths), len(expected))
+	}
+	for i, p := range expected {
+		if paths[i] != p {
+			t.Errorf("Install() path %d = %q, expected %q", i, paths[i], p)
+		}
+		info, err := os.Stat(p)
+		if err != nil {
+			t.Errorf("expected %s to exist: %v", p, err)
+			continue
+		}
+		if info.Size() == 0 {
+			t.Errorf("expected %s to be non-empty", p)
+		}
+	}
+}
@@ -0,0 +1,403 @@
+package version
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// releasesURL is the GitHub API endpoint describing the latest published release.
+// It is a variable so tests can point it at a local server.
+var releasesURL = "https://api.github.com/repos/toozej/wheresmyprompt/releases/latest"
+
+// httpClient is used for all release lookups and downloads.
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// Size limits of the files read while updating, so a broken or malicious server
+// cannot exhaust memory. They are variables so tests can lower them.
+var (
+	maxChecksumsSize int64 = 1 << 20   // checksums.txt
+	maxArchiveSize   int64 = 200 << 20 // A release archive, and the binary extracted from it
+)
+
+// Release describes the subset of a GitHub release used for update checks.
+type Release struct {
+	// TagName is the git tag of the release (e.g., "v1.2.3").
+	TagName string `json:"tag_name"`
+
+	// HTMLURL is the release page on GitHub.
+	HTMLURL string `json:"html_url"`
+
+	// Assets lists the downloadable files attached to the release.
+	Assets []Asset `json:"assets"`
+}
+
+// Asset is a single downloadable file attached to a release.
+type Asset struct {
+	// Name is the file name of the asset.
+	Name string `json:"name"`
+
+	// DownloadURL is the direct download URL of the asset.
+	DownloadURL string `json:"browser_download_url"`
+}
+
+// UpdateStatus is the result of comparing the running version with the latest release.
+type UpdateStatus struct {
+	// Current is the version of the running binary.
+	Current string `json:"current"`
+
+	// Latest is the tag of the latest published release.
+	Latest string `json:"latest"`
+
+	// UpdateAvailable is true when Latest is newer than Current.
+	UpdateAvailable bool `json:"update_available"`
+
+	// ReleaseURL links to the release page of Latest.
+	ReleaseURL string `json:"release_url"`
+}
+
+// LatestRelease fetches the latest published release from GitHub.
+func LatestRelease() (Release, error) {
+	var rel Release
+
+	req, err := http.NewRequest(http.MethodGet, releasesURL, nil)
+	if err != nil {
+		return rel, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return rel, fmt.Errorf("failed to query latest release: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return rel, fmt.Errorf("failed to query latest release: unexpected status %s", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&rel); err != nil {
+		return rel, fmt.Errorf("failed to decode latest release: %w", err)
+	}
+	return rel, nil
+}
+
+// CheckForUpdate compares the embedded Version against the latest GitHub release.
+// Development builds (non-semver versions such as "local") are always reported
+// as having an update available so users can find the latest release.
+func CheckForUpdate() (UpdateStatus, error) {
+	rel, err := LatestRelease()
+	if err != nil {
+		return UpdateStatus{}, err
+	}
+	return UpdateStatus{
+		Current:         Version,
+		Latest:          rel.TagName,
+		UpdateAvailable: compareVersions(Version, rel.TagName) < 0,
+		ReleaseURL:      rel.HTMLURL,
+	}, nil
+}
+
+// compareVersions compares two semantic version strings, ignoring a leading "v"
+// and any pre-release or build suffix. It returns -1, 0 or 1. Versions that
+// cannot be parsed sort before any parseable version.
+func compareVersions(a, b string) int {
+	pa, okA := parseVersion(a)
+	pb, okB := parseVersion(b)
+	switch {
+	case !okA && !okB:
+		return 0
+	case !okA:
+		return -1
+	case !okB:
+		return 1
+	}
+	for i := 0; i < 3; i++ {
+		if pa[i] < pb[i] {
+			return -1
+		}
+		if pa[i] > pb[i] {
+			return 1
+		}
+	}
+	return 0
+}
+
+// parseVersion parses "vMAJOR.MINOR.PATCH[-pre][+build]" into its numeric parts.
+func parseVersion(v string) ([3]int, bool) {
+	var parts [3]int
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+	fields := strings.Split(v, ".")
+	if len(fields) == 0 || len(fields) > 3 {
+		return parts, false
+	}
+	for i, f := range fields {
+		n, err := strconv.Atoi(f)
+		if err != nil {
+			return parts, false
+		}
+		parts[i] = n
+	}
+	return parts, true
+}
+
+// printUpdateStatus writes a human-readable summary of status, including
+// upgrade instructions when a newer release exists.
+func printUpdateStatus(w io.Writer, status UpdateStatus) {
+	if !status.UpdateAvailable {
+		fmt.Fprintf(w, "wheresmyprompt %s is up to date\n", status.Current)
+		return
+	}
+	fmt.Fprintf(w, "A new version of wheresmyprompt is available: %s (current: %s)\n", status.Latest, status.Current)
+	fmt.Fprintf(w, "Release notes: %s\n", status.ReleaseURL)
+	fmt.Fprintln(w, "To upgrade, run one of:")
+	fmt.Fprintln(w, "  wheresmyprompt self-update")
+	fmt.Fprintln(w, "  go install github.com/toozej/wheresmyprompt@latest")
+}
+
+// archiveName returns the goreleaser archive name for the given platform.
+func archiveName(goos, goarch string) string {
+	arch := goarch
+	switch goarch {
+	case "amd64":
+		arch = "x86_64"
+	case "386":
+		arch = "i386"
+	}
+	ext := ".tar.gz"
+	if goos == "windows" {
+		ext = ".zip"
+	}
+	return fmt.Sprintf("wheresmyprompt_%s%s_%s%s", strings.ToUpper(goos[:1]), goos[1:], arch, ext)
+}
+
+// download fetches url and returns the response body.
+func download(url string, limit int64) ([]byte, error) {
+	resp, err := httpClient.Get(url) // #nosec G107
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %s: unexpected status %s", url, resp.Status)
+	}
+	data, err := readLimited(resp.Body, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	return data, nil
+}
+
+// readLimited reads r to the end, failing once it is larger than limit bytes.
+func readLimited(r io.Reader, limit int64) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("larger than the limit of %d bytes", limit)
+	}
+	return data, nil
+}
+
+// verifyChecksum checks data against the entry for name in a goreleaser checksums.txt file.
+func verifyChecksum(checksums []byte, name string, data []byte) error {
+	sum := sha256.Sum256(data)
+	actual := hex.EncodeToString(sum[:])
+
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[1] == name {
+			if !strings.EqualFold(fields[0], actual) {
+				return fmt.Errorf("checksum mismatch for %s: expected %s, got %s", name, fields[0], actual)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("no checksum found for %s", name)
+}
+
+// extractBinary returns the wheresmyprompt executable from a release archive.
+func extractBinary(archive []byte, name string) ([]byte, error) {
+	binary := "wheresmyprompt"
+	if strings.HasSuffix(name, ".zip") {
+		binary += ".exe"
+		zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", name, err)
+		}
+		for _, f := range zr.File {
+			if filepath.Base(f.Name) == binary {
+				rc, err := f.Open()
+				if err != nil {
+					return nil, err
+				}
+				defer rc.Close()
+				return readBinary(rc, name)
+			}
+		}
+		return nil, fmt.Errorf("%s not found in %s", binary, name)
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		if hdr.Typeflag == tar.TypeReg && filepath.Base(hdr.Name) == binary {
+			return readBinary(tr, name)
+		}
+	}
+	return nil, fmt.Errorf("%s not found in %s", binary, name)
+}
+
+// readBinary reads the executable extracted from the archive name, up to maxArchiveSize.
+func readBinary(r io.Reader, name string) ([]byte, error) {
+	data, err := readLimited(r, maxArchiveSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract from %s: %w", name, err)
+	}
+	return data, nil
+}
+
+// SelfUpdate downloads the latest release for the running platform, verifies it
+// against the release's checksums.txt and replaces the running executable.
+// Returns the installed release tag, or an error if any step fails.
+func SelfUpdate() (string, error) {
+	rel, err := LatestRelease()
+	if err != nil {
+		return "", err
+	}
+	if compareVersions(Version, rel.TagName) >= 0 {
+		return rel.TagName, nil
+	}
+
+	name := archiveName(runtime.GOOS, runtime.GOARCH)
+	var archiveURL, checksumsURL string
+	for _, a := range rel.Assets {
+		switch a.Name {
+		case name:
+			archiveURL = a.DownloadURL
+		case "checksums.txt":
+			checksumsURL = a.DownloadURL
+		}
+	}
+	if archiveURL == "" {
+		return "", fmt.Errorf("release %s has no asset %s", rel.TagName, name)
+	}
+	if checksumsURL == "" {
+		return "", fmt.Errorf("release %s has no checksums.txt", rel.TagName)
+	}
+
+	checksums, err := download(checksumsURL, maxChecksumsSize)
+	if err != nil {
+		return "", err
+	}
+	archive, err := download(archiveURL, maxArchiveSize)
+	if err != nil {
+		return "", err
+	}
+	if err := verifyChecksum(checksums, name, archive); err != nil {
+		return "", err
+	}
+	binary, err := extractBinary(archive, name)
+	if err != nil {
+		return "", err
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate running executable: %w", err)
+	}
+	if exe, err = filepath.EvalSymlinks(exe); err != nil {
+		return "", fmt.Errorf("failed to resolve running executable: %w", err)
+	}
+	if err := replaceExecutable(exe, binary); err != nil {
+		return "", err
+	}
+	return rel.TagName, nil
+}
+
+// replaceExecutable atomically swaps the file at path for data, keeping the
+// original file mode. On Windows the running binary is renamed out of the way first.
+func replaceExecutable(path string, data []byte) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".new"
+	if err := os.WriteFile(tmp, data, info.Mode().Perm()); err != nil { // #nosec G306
+		return fmt.Errorf("failed to write new executable: %w", err)
+	}
+	if runtime.GOOS == "windows" {
+		old := path + ".old"
+		_ = os.Remove(old)
+		if err := os.Rename(path, old); err != nil {
+			_ = os.Remove(tmp)
+			return fmt.Errorf("failed to move old executable: %w", err)
+		}
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to replace executable: %w", err)
+	}
+	return nil
+}
+
+// UpdateCommand creates and returns a cobra command that replaces the running
+// binary with the latest GitHub release after verifying its checksum.
+//
+// Example:
+//
+//	rootCmd.AddCommand(version.UpdateCommand())
+//
+//	// Command line usage:
+//	// ./wheresmyprompt self-update
+func UpdateCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "self-update",
+		Short: "Update wheresmyprompt to the latest release.",
+		Long:  `Download the latest release for this platform, verify its checksum and replace the running binary.`,
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tag, err := SelfUpdate()
+			if err != nil {
+				return err
+			}
+			if compareVersions(Version, tag) >= 0 {
+				fmt.Fprintf(cmd.OutOrStdout(), "wheresmyprompt %s is already up to date\n", Version)
+				return nil
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Updated wheresmyprompt from %s to %s\n", Version, tag)
+			return nil
+		},
+	}
+}
@@ -0,0 +1,168 @@
+package version
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"v1.0.0", "v1.0.0", 0},
+		{"v1.0.0", "v1.0.1", -1},
+		{"v1.2.0", "v1.1.9", 1},
+		{"1.10.0", "v1.9.0", 1},
+		{"v2.0.0-rc1", "v2.0.0", 0},
+		{"local", "v0.0.1", -1},
+		{"v0.0.1", "local", 1},
+		{"local", "dev", 0},
+	}
+
+	for _, tt := range tests {
+		if got := compareVersions(tt.a, tt.b); got != tt.expected {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.expected)
+		}
+	}
+}
+
+func TestCheckForUpdate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(Release{TagName: "v1.2.0", HTMLURL: "https://example.com/v1.2.0"})
+	}))
+	defer server.Close()
+
+	originalURL, originalVersion := releasesURL, Version
+	defer func() {
+		releasesURL, Version = originalURL, originalVersion
+	}()
+	releasesURL = server.URL
+
+	tests := []struct {
+		current         string
+		updateAvailable bool
+	}{
+		{"v1.1.0", true},
+		{"v1.2.0", false},
+		{"v1.3.0", false},
+		{"local", true},
+	}
+
+	for _, tt := range tests {
+		Version = tt.current
+		status, err := CheckForUpdate()
+		if err != nil {
+			t.Fatalf("CheckForUpdate() returned error: %v", err)
+		}
+		if status.UpdateAvailable != tt.updateAvailable {
+			t.Errorf("CheckForUpdate() with version %q: UpdateAvailable = %t, want %t", tt.current, status.UpdateAvailable, tt.updateAvailable)
+		}
+		if status.Latest != "v1.2.0" {
+			t.Errorf("CheckForUpdate() Latest = %q, want %q", status.Latest, "v1.2.0")
+		}
+	}
+}
+
+func TestPrintUpdateStatus(t *testing.T) {
+	var buf bytes.Buffer
+	printUpdateStatus(&buf, UpdateStatus{Current: "v1.0.0", Latest: "v1.1.0", UpdateAvailable: true})
+	if !strings.Contains(buf.String(), "self-update") {
+		t.Errorf("expected upgrade instructions, got %q", buf.String())
+	}
+
+	buf.Reset()
+	printUpdateStatus(&buf, UpdateStatus{Current: "v1.1.0", Latest: "v1.1.0"})
+	if !strings.Contains(buf.String(), "up to date") {
+		t.Errorf("expected up to date message, got %q", buf.String())
+	}
+}
+
+func TestArchiveName(t *testing.T) {
+	tests := []struct {
+		goos, goarch, expected string
+	}{
+		{"linux", "amd64", "wheresmyprompt_Linux_x86_64.tar.gz"},
+		{"darwin", "arm64", "wheresmyprompt_Darwin_arm64.tar.gz"},
+		{"windows", "386", "wheresmyprompt_Windows_i386.zip"},
+	}
+
+	for _, tt := range tests {
+		if got := archiveName(tt.goos, tt.goarch); got != tt.expected {
+			t.Errorf("archiveName(%q, %q) = %q, want %q", tt.goos, tt.goarch, got, tt.expected)
+		}
+	}
+}
+
+func TestVerifyChecksumAndExtract(t *testing.T) {
+	var archive bytes.Buffer
+	gz := gzip.NewWriter(&archive)
+	tw := tar.NewWriter(gz)
+	content := []byte("binary contents")
+	_ = tw.WriteHeader(&tar.Header{Name: "wheresmyprompt", Mode: 0755, Size: int64(len(content)), Typeflag: tar.TypeReg})
+	_, _ = tw.Write(content)
+	_ = tw.Close()
+	_ = gz.Close()
+
+	name := "wheresmyprompt_Linux_x86_64.tar.gz"
+	sum := sha256.Sum256(archive.Bytes())
+	checksums := []byte(hex.EncodeToString(sum[:]) + "  " + name + "\n")
+
+	if err := verifyChecksum(checksums, name, archive.Bytes()); err != nil {
+		t.Errorf("verifyChecksum() returned error: %v", err)
+	}
+	if err := verifyChecksum(checksums, name, []byte("tampered")); err == nil {
+		t.Error("verifyChecksum() should fail for tampered data")
+	}
+	if err := verifyChecksum(checksums, "other.tar.gz", archive.Bytes()); err == nil {
+		t.Error("verifyChecksum() should fail for missing entry")
+	}
+
+	binary, err := extractBinary(archive.Bytes(), name)
+	if err != nil {
+		t.Fatalf("extractBinary() returned error: %v", err)
+	}
+	if !bytes.Equal(binary, content) {
+		t.Errorf("extractBinary() = %q, want %q", binary, content)
+	}
+}
+
+func TestDownload_SizeLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(bytes.Repeat([]byte("x"), 64))
+	}))
+	defer server.Close()
+
+	if data, err := download(server.URL, 64); err != nil || len(data) != 64 {
+		t.Errorf("download() at the limit = %d bytes, %v, want the whole file", len(data), err)
+	}
+	if _, err := download(server.URL, 63); err == nil || !strings.Contains(err.Error(), "limit") {
+		t.Errorf("download() over the limit error = %v, want a size error", err)
+	}
+}
+
+func TestExtractBinary_SizeLimit(t *testing.T) {
+	var archive bytes.Buffer
+	gz := gzip.NewWriter(&archive)
+	tw := tar.NewWriter(gz)
+	content := bytes.Repeat([]byte{0}, 4096)
+	_ = tw.WriteHeader(&tar.Header{Name: "wheresmyprompt", Mode: 0755, Size: int64(len(content)), Typeflag: tar.TypeReg})
+	_, _ = tw.Write(content)
+	_ = tw.Close()
+	_ = gz.Close()
+
+	original := maxArchiveSize
+	defer func() { maxArchiveSize = original }()
+	maxArchiveSize = 1024
+	if _, err := extractBinary(archive.Bytes(), "wheresmyprompt_Linux_x86_64.tar.gz"); err == nil {
+		t.Error("extractBinary() should fail for a binary larger than the limit")
+	}
+}
@@ -159,7 +159,9 @@ func Get() (Info, error) {
 //   - Use: "version" - command name for invocation
 //   - Output: JSON-formatted version information
 //   - Args: No arguments accepted
-//   - Errors: Returns error if JSON marshaling or Info retrieval fails
+//   - Flags: --check-update queries GitHub for the latest release, --json
+//     prints the update check result as JSON for scripts
+//   - Errors: Returns error if JSON marshaling, Info retrieval or the update check fails
 //
 // The JSON output includes all available version fields and follows a consistent
 // format that can be parsed by scripts or other automated tools.
@@ -175,12 +177,28 @@ func Get() (Info, error) {
 //	// Command line usage:
 //	// ./wheresmyprompt version
 //	// Output: {"Commit":"abc123","Version":"v1.0.0","Branch":"main",...}
+//	// ./wheresmyprompt version --check-update --json
+//	// Output: {"current":"v1.0.0","latest":"v1.1.0","update_available":true,...}
 func Command() *cobra.Command {
-	return &cobra.Command{
+	var checkUpdate, jsonOutput bool
+
+	cmd := &cobra.Command{
 		Use:   "version",
 		Short: "Print the version.",
-		Long:  `Print the version and build information.`,
+		Long:  `Print the version and build information, optionally checking GitHub for a newer release.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if checkUpdate {
+				status, err := CheckForUpdate()
+				if err != nil {
+					return err
+				}
+				if jsonOutput {
+					return json.NewEncoder(cmd.OutOrStdout()).Encode(status)
+				}
+				printUpdateStatus(cmd.OutOrStdout(), status)
+				return nil
+			}
+
 			info, err := Get()
 			if err != nil {
 				return err
@@ -189,9 +207,14 @@ func Command() *cobra.Command {
 			if err != nil {
 				return err
 			}
-			fmt.Println(string(json))
+			fmt.Fprintln(cmd.OutOrStdout(), string(json))
 
 			return nil
 		},
 	}
+
+	cmd.Flags().BoolVar(&checkUpdate, "check-update", false, "Check GitHub for a newer release")
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Print the update check result as JSON")
+
+	return cmd
 }